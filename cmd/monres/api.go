@@ -0,0 +1,127 @@
+//go:build !no_api
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/api"
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+	"github.com/mattmezza/monres/internal/replay"
+)
+
+// Build with -tags no_api to leave the HTTP API and the status command out.
+func init() {
+	buildinfo.RegisterFeature("api")
+}
+
+// startAPI serves the HTTP API when it is configured and returns a function stopping it.
+func startAPI(cfg *config.Config, a *alerter.Alerter, hist *history.MetricHistoryBuffer, notificationLog *audit.Log, silences *alerter.Silences, reload func() error, heartbeats *collector.HeartbeatCollector) (stop func()) {
+	if cfg.API.Listen == "" {
+		return func() {}
+	}
+	srv := api.NewServer(cfg.API.Listen, cfg.EffectiveHostname, a, hist)
+	srv.SetNotificationLog(notificationLog)
+	srv.SetSilences(silences)
+	srv.SetReloadFunc(reload)
+	srv.SetToken(cfg.API.Token)
+	srv.SetSlackSigningSecret(cfg.API.SlackSigningSecret)
+	if heartbeats != nil {
+		srv.SetHeartbeats(heartbeats)
+	}
+	if cfg.API.Token == "" {
+		log.Println("API write endpoints are disabled; set MONRES_API_TOKEN to enable silences, acks and reload.")
+	}
+	if err := srv.Start(); err != nil {
+		log.Fatalf("FATAL: Failed to start API: %v", err)
+	}
+	log.Printf("API listening on %s", cfg.API.Listen)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Warning: Failed to shut down API: %v", err)
+		}
+	}
+}
+
+// status prints the state of a running daemon, queried through its API.
+func status(configPath string, args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	output := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] status [-output text|json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setOutput(*output)
+
+	cfg := loadConfigOrFail(*output, configPath)
+	if cfg.API.Listen == "" {
+		fail(*output, exitFailure, fmt.Errorf("the status command needs the API, but api.listen is not set in %s", configPath))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := api.FetchStatus(ctx, cfg.API.Listen)
+	if err != nil {
+		fail(*output, exitFailure, err)
+	}
+	if *output == outputJSON {
+		writeJSON(os.Stdout, st)
+		return
+	}
+	printStatus(os.Stdout, st)
+}
+
+// fetchNotifications queries the notification attempts of a running daemon through its API.
+func fetchNotifications(cfg *config.Config, q audit.Query) ([]audit.Entry, error) {
+	if cfg.API.Listen == "" {
+		return nil, fmt.Errorf("neither notification_log.path nor api.listen is set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return api.FetchNotifications(ctx, cfg.API.Listen, q)
+}
+
+// printStatus writes a human readable summary of a daemon status.
+func printStatus(w io.Writer, st *api.Status) {
+	fmt.Fprintf(w, "monres %s on %s, running since %s\n", st.Version, st.Hostname, st.Started.Format(time.RFC3339))
+	if len(st.Rules) == 0 {
+		fmt.Fprintln(w, "no alert rules configured")
+		return
+	}
+	for _, rule := range st.Rules {
+		fmt.Fprintln(w, rule.Message)
+	}
+}
+
+// fetchMetric queries the history a running daemon holds for a metric since
+// the given time through its API. Downsampled points yield their average.
+func fetchMetric(cfg *config.Config, name string, since time.Time) ([]replay.Sample, error) {
+	if cfg.API.Listen == "" {
+		return nil, fmt.Errorf("the export command needs the API, but api.listen is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	points, err := api.FetchMetric(ctx, cfg.API.Listen, name, since)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]replay.Sample, 0, len(points))
+	for _, p := range points {
+		samples = append(samples, replay.Sample{Timestamp: p.Time, Metric: name, Value: p.Value})
+	}
+	return samples, nil
+}
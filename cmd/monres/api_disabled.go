@@ -0,0 +1,42 @@
+//go:build no_api
+
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+	"github.com/mattmezza/monres/internal/replay"
+)
+
+func startAPI(cfg *config.Config, _ *alerter.Alerter, _ *history.MetricHistoryBuffer, _ *audit.Log, _ *alerter.Silences, _ func() error, _ *collector.HeartbeatCollector) func() {
+	if cfg.API.Listen != "" {
+		log.Println("Warning: api.listen is configured, but this build does not include the API (built with -tags no_api).")
+	}
+	if len(cfg.CronJobs) > 0 {
+		log.Println("Warning: cron_jobs are configured, but their heartbeats can't be received without the API.")
+	}
+	return func() {}
+}
+
+func status(_ string, _ []string) {
+	log.Fatalf("ERROR: This build does not include the API needed by the status command (built with -tags no_api).")
+}
+
+func top(_ string, _ []string) {
+	log.Fatalf("ERROR: This build does not include the API needed by the top command (built with -tags no_api).")
+}
+
+func fetchNotifications(_ *config.Config, _ audit.Query) ([]audit.Entry, error) {
+	return nil, errors.New("notification_log.path is not set, and this build does not include the API (built with -tags no_api)")
+}
+
+func fetchMetric(_ *config.Config, _ string, _ time.Time) ([]replay.Sample, error) {
+	return nil, errors.New("this build does not include the API needed by the export command (built with -tags no_api)")
+}
@@ -0,0 +1,30 @@
+//go:build !no_api
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattmezza/monres/internal/api"
+)
+
+func TestPrintStatus(t *testing.T) {
+	var buf bytes.Buffer
+	printStatus(&buf, &api.Status{
+		Hostname: "web-1",
+		Version:  "1.2.0",
+		Started:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Rules: []api.RuleStatus{
+			{Name: "High CPU", State: api.StateWaiting, Message: "rule High CPU waiting for history (42% of 5m window)"},
+			{Name: "Disk", State: api.StateOK, Message: "rule Disk ok"},
+		},
+	})
+
+	assert.Equal(t, "monres 1.2.0 on web-1, running since 2024-01-01T00:00:00Z\n"+
+		"rule High CPU waiting for history (42% of 5m window)\n"+
+		"rule Disk ok\n", buf.String())
+}
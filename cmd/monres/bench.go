@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattmezza/monres/internal/collector"
+)
+
+// bench runs the configured collectors back to back and prints their cost,
+// to judge the overhead of enabling collectors on small machines.
+func bench(configPath string, args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	cycles := fs.Int("n", 1000, "Number of collection cycles to run per collector.")
+	output := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] bench [-n cycles] [-output text|json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setOutput(*output)
+
+	cfg := loadConfigOrFail(*output, configPath)
+
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard) // Per-cycle collector logs would distort the measurements
+	metricCollector := newMetricCollector(cfg)
+	if *output == outputText {
+		fmt.Printf("Running %d collection cycles per collector...\n\n", *cycles)
+	}
+	results := metricCollector.Benchmark(*cycles)
+	log.SetOutput(logOutput)
+
+	if *output == outputJSON {
+		writeJSON(os.Stdout, benchJSON(results))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "COLLECTOR\tAVG LATENCY\tMAX LATENCY\tALLOCS/CYCLE\tBYTES/CYCLE\tCPU/CYCLE\tERRORS\t")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.1f\t%.0f\t%s\t%d\t\n",
+			r.Name, r.AvgLatency.Round(time.Microsecond/10), r.MaxLatency.Round(time.Microsecond/10),
+			r.AllocsPerCycle, r.BytesPerCycle, r.CPUPerCycle.Round(time.Microsecond/10), r.Errors)
+	}
+	w.Flush()
+
+	for _, r := range results {
+		if r.Name == "total" && cfg.CollectionInterval > 0 {
+			fmt.Printf("\nAt an interval of %s, collection uses about %.3f%% of one CPU.\n",
+				cfg.CollectionInterval, 100*float64(r.CPUPerCycle)/float64(cfg.CollectionInterval))
+		}
+	}
+}
+
+// benchResult is a collector.BenchmarkResult in the JSON output of bench,
+// with durations in microseconds.
+type benchResult struct {
+	Collector         string  `json:"collector"`
+	Cycles            int     `json:"cycles"`
+	Errors            int     `json:"errors"`
+	AvgLatencyMicros  float64 `json:"avg_latency_us"`
+	MaxLatencyMicros  float64 `json:"max_latency_us"`
+	AllocsPerCycle    float64 `json:"allocs_per_cycle"`
+	BytesPerCycle     float64 `json:"bytes_per_cycle"`
+	CPUPerCycleMicros float64 `json:"cpu_per_cycle_us"`
+}
+
+func benchJSON(results []collector.BenchmarkResult) []benchResult {
+	out := make([]benchResult, 0, len(results))
+	for _, r := range results {
+		out = append(out, benchResult{
+			Collector:         r.Name,
+			Cycles:            r.Cycles,
+			Errors:            r.Errors,
+			AvgLatencyMicros:  micros(r.AvgLatency),
+			MaxLatencyMicros:  micros(r.MaxLatency),
+			AllocsPerCycle:    r.AllocsPerCycle,
+			BytesPerCycle:     r.BytesPerCycle,
+			CPUPerCycleMicros: micros(r.CPUPerCycle),
+		})
+	}
+	return out
+}
+
+func micros(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// Exit codes of the check command, following the Nagios plugin convention.
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+// check collects metrics once, evaluates a single alert rule against them,
+// prints the result and exits with checkOK, checkWarning or checkCritical
+// (checkUnknown if the rule cannot be evaluated).
+func check(configPath string, args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	sample := fs.Duration("sample", time.Second, "Time between the two collections needed by CPU and rate metrics.")
+	output := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] check [-sample 1s] [-output text|json] <alert-name>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(checkUnknown)
+	}
+	setOutput(*output)
+	report := func(result checkResult) {
+		if *output == outputJSON {
+			writeJSON(os.Stdout, result)
+		} else {
+			fmt.Println(result.Output)
+		}
+		os.Exit(result.Code)
+	}
+	unknown := func(format string, args ...any) {
+		report(checkResult{Rule: fs.Arg(0), Status: "UNKNOWN", Output: "UNKNOWN - " + fmt.Sprintf(format, args...), Code: checkUnknown})
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		unknown("failed to load configuration from %s: %v", configPath, err)
+	}
+	registerMetricMetadata(cfg)
+	rule, ok := findAlertRule(cfg, fs.Arg(0))
+	if !ok {
+		unknown("no alert rule named '%s'", fs.Arg(0))
+	}
+	if rule.IsTemplate() {
+		unknown("alert rule '%s' is a template; check one of its entities, e.g. '%s'", rule.Name, rule.InstanceName("<entity>"))
+	}
+
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard) // Keep the output to the result line
+	metricCollector := newMetricCollector(cfg)
+	// CPU and rate metrics are computed from the difference to the previous cycle
+	_, err = metricCollector.CollectAll()
+	var metrics collector.CollectedMetrics
+	if err == nil {
+		time.Sleep(*sample)
+		metrics, err = metricCollector.CollectAll()
+	}
+	log.SetOutput(logOutput)
+	if err != nil {
+		unknown("failed to collect metrics: %v", err)
+	}
+	report(evaluateCheck(rule, metrics))
+}
+
+// findAlertRule returns the alert rule with the given name, or the rule of a
+// templated rule's entity named like "Disk Almost Full (var_lib_docker)".
+func findAlertRule(cfg *config.Config, name string) (config.AlertRuleConfig, bool) {
+	for _, rule := range cfg.Alerts {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	for _, rule := range cfg.Alerts {
+		entity, ok := strings.CutPrefix(name, rule.Name+" (")
+		if entity, found := strings.CutSuffix(entity, ")"); ok && found && entity != "" && rule.IsTemplate() {
+			return rule.Instantiate(entity), true
+		}
+	}
+	return config.AlertRuleConfig{}, false
+}
+
+// checkResult is the result of check, also its JSON output.
+type checkResult struct {
+	Rule      string   `json:"rule"`
+	Metric    string   `json:"metric"`
+	Status    string   `json:"status"`             // OK, WARNING, CRITICAL or UNKNOWN
+	Severity  string   `json:"severity,omitempty"` // Of the breached tier
+	Value     *float64 `json:"value,omitempty"`
+	Threshold *float64 `json:"threshold,omitempty"` // Of the breached tier, else the most severe
+	Output    string   `json:"output"`              // The Nagios-style result line
+	Code      int      `json:"code"`                // The exit code
+}
+
+// evaluateCheck evaluates rule against a single collection of metrics, with
+// a Nagios-style result line and exit code. Durations are not applied since
+// only the current value is known.
+func evaluateCheck(ruleCfg config.AlertRuleConfig, metrics collector.CollectedMetrics) checkResult {
+	result := checkResult{Rule: ruleCfg.Name, Metric: ruleCfg.Metric, Status: "UNKNOWN", Code: checkUnknown}
+	value, ok := metrics[ruleCfg.Metric]
+	if !ok {
+		result.Output = fmt.Sprintf("UNKNOWN - %s: metric '%s' was not collected", ruleCfg.Name, ruleCfg.Metric)
+		return result
+	}
+
+	ruleCfg.Duration = 0
+	rule := alerter.NewAlertRule(ruleCfg)
+	tier, value, err := rule.EvaluateTier([]history.DataPoint{{Timestamp: time.Now(), Value: value}})
+	if err != nil {
+		result.Output = fmt.Sprintf("UNKNOWN - %s: %v", ruleCfg.Name, err)
+		return result
+	}
+
+	status, code := "OK", checkOK
+	threshold := rule.Tiers[len(rule.Tiers)-1].Threshold // Most severe
+	if tier != nil {
+		threshold = tier.Threshold
+		result.Severity = tier.Severity
+		switch tier.Severity {
+		case config.SeverityCritical:
+			status, code = "CRITICAL", checkCritical
+		case config.SeverityWarning:
+			status, code = "WARNING", checkWarning
+		}
+	}
+	result.Status, result.Code, result.Value, result.Threshold = status, code, &value, &threshold
+	if status == "OK" && tier != nil {
+		status = "OK (" + tier.Severity + ")"
+	}
+	result.Output = fmt.Sprintf("%s - %s: %s = %s (%s %s) | %s=%.6g", status, ruleCfg.Name, ruleCfg.Metric,
+		notifier.FormatValue(ruleCfg.Metric, value), rule.Condition,
+		notifier.FormatValue(ruleCfg.Metric, threshold), ruleCfg.Metric, value)
+	return result
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func TestEvaluateCheck(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "cpu_high"
+    metric: "cpu_percent_total"
+    condition: ">"
+    thresholds: {warning: 80, critical: 95}
+    duration: "5m"
+    aggregation: "average"
+    channels: ["stdout"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+	rule, ok := findAlertRule(cfg, "cpu_high")
+	require.True(t, ok)
+	_, ok = findAlertRule(cfg, "cpu_low")
+	assert.False(t, ok)
+
+	testCases := []struct {
+		name     string
+		metrics  collector.CollectedMetrics
+		expected int
+		output   string
+	}{
+		{"ok", collector.CollectedMetrics{"cpu_percent_total": 10}, checkOK, "OK - cpu_high: cpu_percent_total = 10.0% (> 95.0%) | cpu_percent_total=10"},
+		{"warning", collector.CollectedMetrics{"cpu_percent_total": 85}, checkWarning, "WARNING - cpu_high"},
+		{"critical", collector.CollectedMetrics{"cpu_percent_total": 99.5}, checkCritical, "CRITICAL - cpu_high: cpu_percent_total = 99.5% (> 95.0%)"},
+		{"missing", collector.CollectedMetrics{}, checkUnknown, "UNKNOWN - cpu_high: metric 'cpu_percent_total' was not collected"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := evaluateCheck(rule, tc.metrics)
+			assert.Equal(t, tc.expected, result.Code)
+			assert.Contains(t, result.Output, tc.output)
+		})
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeJSON(&out, evaluateCheck(rule, collector.CollectedMetrics{"cpu_percent_total": 85})))
+	assert.JSONEq(t, `{"rule": "cpu_high", "metric": "cpu_percent_total", "status": "WARNING", "severity": "warning",
+		"value": 85, "threshold": 80, "output": "WARNING - cpu_high: cpu_percent_total = 85.0% (> 80.0%) | cpu_percent_total=85", "code": 1}`, out.String())
+}
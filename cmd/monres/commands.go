@@ -0,0 +1,219 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/buildinfo"
+)
+
+// command is a subcommand of monres. Commands parse their own flags, so
+// run gets the arguments following the command name.
+type command struct {
+	name    string
+	args    string // Synopsis of the arguments, e.g. "[-n 20] [-failed]"
+	summary string // One line, without a trailing period
+	run     func(args []string)
+}
+
+// commands lists the subcommands, in the order help shows them. Without
+// one, monres runs the monitor.
+func commands() []command {
+	return []command{
+		{"status", "[-output text|json]", "Show the state of the running monitor through its API", func(args []string) { status(configFile, args) }},
+		{"top", "[-interval 2s] [-window 10m] [-metrics cpu_*,mem_*]", "Live terminal view of the running monitor", func(args []string) { top(configFile, args) }},
+		{"notifications", "[-n 20] [-alert name] [-channel name] [-failed] [-since 24h] [-captures] [-output text|json]", "List recorded notification attempts", func(args []string) { notifications(configFile, args) }},
+		{"export", "-metric name [-last 1h] [-format csv|json]", "Print the history the running monitor holds for a metric", func(args []string) { export(configFile, args) }},
+		{"validate", "[-output text|json]", "Check the configuration and render the notification templates", func(args []string) { validate(configFile, args) }},
+		{"check", "[-sample 1s] [-output text|json] <alert-name>", "Evaluate one alert rule against the current metrics, Nagios-style", func(args []string) { check(configFile, args) }},
+		{"test-notification", "[-rule name] [-state fired|resolved] [-metric name] [-value 42.5] [-threshold 40] [-condition >] [-severity critical] [-output text|json] [channel]", "Send a test notification to one or all channels", func(args []string) { testNotification(configFile, args) }},
+		{"render", "[-template fired|resolved] [-alert name] [-value 95] [-channel name] [-html file]", "Print the notification every channel would send, without sending it", func(args []string) { render(configFile, args) }},
+		{"test-rules", "[-verbose] samples.csv", "Replay recorded metric values through the alert rules", func(args []string) { testRules(configFile, args) }},
+		{"bench", "[-n 1000]", "Measure the time and allocations of every enabled collector", func(args []string) { bench(configFile, args) }},
+		{"import-prom-rules", "[-channels email,telegram] rules.yml", "Convert Prometheus alerting rules into monres alert rules", importPromRules},
+		{"export-prom-rules", "[-group monres] [-node-exporter]", "Print the alert rules as a Prometheus rule file", func(args []string) { exportPromRules(configFile, args) }},
+		{"schema", "", "Print a JSON Schema of the config file", runSchema},
+		{"version", "[-output text|json]", "Print the version and the optional features compiled in", version},
+		{"completion", "bash|zsh|fish", "Print a shell completion script", runCompletion},
+		{"man", "", "Print the man page", func([]string) { writeManPage(os.Stdout, time.Now()) }},
+		{"help", "", "Show this help", func([]string) { printUsage(os.Stdout) }},
+	}
+}
+
+// findCommand returns the subcommand with the given name.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func runSchema([]string) {
+	if err := printSchema(os.Stdout); err != nil {
+		log.Fatalf("ERROR: Failed to write schema: %v", err)
+	}
+}
+
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: monres completion bash|zsh|fish")
+		os.Exit(2)
+	}
+	if err := writeCompletion(os.Stdout, args[0]); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+// printUsage writes the global flags and the subcommands.
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: monres [flags] [command [arguments]]")
+	fmt.Fprintln(w, "\nWithout a command, monres runs the monitor.\n\nFlags:")
+	flag.CommandLine.SetOutput(w)
+	flag.PrintDefaults()
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-18s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRun 'monres <command> -h' for the options of a command.")
+}
+
+// globalFlags returns the names and usages of the flags preceding the
+// command, in order.
+func globalFlags() [][2]string {
+	var flags [][2]string
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, [2]string{f.Name, f.Usage})
+	})
+	return flags
+}
+
+// writeCompletion writes the completion script of shell, completing the
+// commands, the global flags and the shells of the completion command.
+func writeCompletion(w io.Writer, shell string) error {
+	cmds := commands()
+	var names, flagNames []string
+	for _, cmd := range cmds {
+		names = append(names, cmd.name)
+	}
+	for _, f := range globalFlags() {
+		flagNames = append(flagNames, "-"+f[0])
+	}
+
+	switch shell {
+	case "bash":
+		fmt.Fprintf(w, `# bash completion for monres; add to ~/.bashrc: source <(monres completion bash)
+_monres() {
+    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+    local i cmd=""
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case ${COMP_WORDS[i]} in
+            %[1]s) ((i++)) ;;
+            -*) ;;
+            *) cmd=${COMP_WORDS[i]}; break ;;
+        esac
+    done
+    case $prev in
+        %[1]s) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+    esac
+    case $cmd in
+        "") COMPREPLY=($(compgen -W "%[2]s %[3]s" -- "$cur")) ;;
+        completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+        *) COMPREPLY=($(compgen -f -- "$cur")) ;;
+    esac
+}
+complete -o filenames -F _monres monres
+`, strings.Join(flagNames, "|"), strings.Join(names, " "), strings.Join(flagNames, " "))
+	case "zsh":
+		fmt.Fprintln(w, "#compdef monres\n# zsh completion for monres; save as _monres in a directory of $fpath\n_monres() {\n    local -a commands\n    commands=(")
+		for _, cmd := range cmds {
+			fmt.Fprintf(w, "        '%s:%s'\n", cmd.name, zshEscape(cmd.summary))
+		}
+		fmt.Fprintln(w, "    )\n    local state\n    _arguments -C \\")
+		for _, f := range globalFlags() {
+			fmt.Fprintf(w, "        '-%s[%s]:value:_files' \\\n", f[0], zshEscape(f[1]))
+		}
+		fmt.Fprint(w, `        '1: :->command' \
+        '*:: :->args'
+    case $state in
+        command) _describe 'command' commands ;;
+        args)
+            case $words[1] in
+                completion) _values 'shell' bash zsh fish ;;
+                *) _files ;;
+            esac
+            ;;
+    esac
+}
+_monres "$@"
+`)
+	case "fish":
+		fmt.Fprintln(w, "# fish completion for monres; save as ~/.config/fish/completions/monres.fish")
+		for _, f := range globalFlags() {
+			fmt.Fprintf(w, "complete -c monres -n __fish_use_subcommand -o %s -r -d %s\n", f[0], fishQuote(f[1]))
+		}
+		for _, cmd := range cmds {
+			fmt.Fprintf(w, "complete -c monres -n __fish_use_subcommand -f -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+		}
+		fmt.Fprintln(w, "complete -c monres -n '__fish_seen_subcommand_from completion' -f -a 'bash zsh fish'")
+	default:
+		return fmt.Errorf("unknown shell '%s', use bash, zsh or fish", shell)
+	}
+	return nil
+}
+
+// zshEscape escapes text for a single-quoted _arguments or _describe spec.
+func zshEscape(s string) string {
+	s = strings.ReplaceAll(s, "'", `'\''`)
+	s = strings.ReplaceAll(s, ":", `\:`)
+	s = strings.ReplaceAll(s, "[", `\[`)
+	return strings.ReplaceAll(s, "]", `\]`)
+}
+
+// fishQuote quotes text as a single fish argument.
+func fishQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", `\'`) + "'"
+}
+
+// writeManPage writes the monres(1) man page in roff.
+func writeManPage(w io.Writer, now time.Time) {
+	fmt.Fprintf(w, ".TH MONRES 1 %q %q\n", now.Format("2006-01-02"), "monres "+buildinfo.GetVersion())
+	fmt.Fprintln(w, ".SH NAME\nmonres \\- lightweight VPS resource monitor and alerter")
+	fmt.Fprintln(w, ".SH SYNOPSIS\n.B monres\n[\\fIflags\\fR] [\\fIcommand\\fR [\\fIarguments\\fR]]")
+	fmt.Fprintln(w, ".SH DESCRIPTION\nmonres collects CPU, memory, disk and network metrics from /proc and /sys,")
+	fmt.Fprintln(w, "evaluates alert rules against them and sends notifications.")
+	fmt.Fprintln(w, "Without a command, it runs the monitor with the configuration file given by")
+	fmt.Fprintln(w, ".BR \\-config .")
+	fmt.Fprintln(w, ".SH OPTIONS")
+	for _, f := range globalFlags() {
+		fmt.Fprintf(w, ".TP\n.BI %s \" value\"\n%s\n", roffEscape("-"+f[0]), roffEscape(f[1]))
+	}
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, ".TP\n.B %s", cmd.name)
+		if cmd.args != "" {
+			fmt.Fprintf(w, " \" %s\"", roffEscape(cmd.args))
+		}
+		fmt.Fprintf(w, "\n%s.\n", roffEscape(cmd.summary))
+	}
+	fmt.Fprintln(w, ".PP\nRun\n.B monres\n.I command\n.B \\-h\nfor the options of a command.")
+	fmt.Fprintln(w, ".SH FILES\n.TP\n.I config.yaml\nThe default configuration file; see config.example.yaml.")
+	fmt.Fprintln(w, ".SH SEE ALSO\n.BR systemd.service (5)")
+}
+
+// roffEscape escapes backslashes and dashes, and leading dots and quotes
+// that roff would read as requests.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
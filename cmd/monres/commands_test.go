@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCommand(t *testing.T) {
+	cmd, ok := findCommand("export-prom-rules")
+	require.True(t, ok)
+	assert.Equal(t, "export-prom-rules", cmd.name)
+
+	_, ok = findCommand("silence")
+	assert.False(t, ok)
+
+	seen := make(map[string]bool)
+	for _, cmd := range commands() {
+		assert.False(t, seen[cmd.name], "duplicate command %s", cmd.name)
+		seen[cmd.name] = true
+	}
+}
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var buf bytes.Buffer
+		require.NoError(t, writeCompletion(&buf, shell))
+		for _, cmd := range commands() {
+			assert.Contains(t, buf.String(), cmd.name, shell)
+		}
+		assert.Contains(t, buf.String(), "config", shell)
+	}
+	assert.Error(t, writeCompletion(&bytes.Buffer{}, "powershell"))
+}
+
+func TestZshEscape(t *testing.T) {
+	assert.Equal(t, `it'\''s \[a\]\: b`, zshEscape("it's [a]: b"))
+}
+
+func TestWriteManPage(t *testing.T) {
+	var buf bytes.Buffer
+	writeManPage(&buf, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	out := buf.String()
+	assert.Contains(t, out, `.TH MONRES 1 "2024-01-01"`)
+	assert.Contains(t, out, ".BI \\-config \" value\"\n")
+	assert.Contains(t, out, ".B export \" \\-metric name [\\-last 1h] [\\-format csv|json]\"\n")
+}
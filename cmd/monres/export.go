@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/replay"
+)
+
+// Formats of the export command.
+const (
+	exportCSV  = "csv"
+	exportJSON = "json"
+)
+
+// export prints the history a running daemon holds for a metric, queried
+// through its API, as CSV or JSON samples.
+func export(configPath string, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	metric := fs.String("metric", "", "Metric to export, e.g. cpu_percent_total.")
+	last := fs.Duration("last", time.Hour, "Period to export (e.g. 24h); history.retention must cover it.")
+	format := fs.String("format", exportCSV, "Output format: csv or json.")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] export -metric name [-last 1h] [-format csv|json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *metric == "" || fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *format != exportCSV && *format != exportJSON {
+		log.Fatalf("ERROR: Unknown format '%s', use csv or json", *format)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to load configuration from %s: %v", configPath, err)
+	}
+	samples, err := fetchMetric(cfg, *metric, time.Now().Add(-*last))
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	if err := writeSamples(os.Stdout, *format, samples); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+// writeSamples writes samples in the CSV (timestamp,metric,value) or JSON
+// format read by test-rules, so exports can be replayed.
+func writeSamples(w io.Writer, format string, samples []replay.Sample) error {
+	if format == exportJSON {
+		if samples == nil {
+			samples = []replay.Sample{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(samples)
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"timestamp", "metric", "value"})
+	for _, s := range samples {
+		cw.Write([]string{s.Timestamp.Format(time.RFC3339), s.Metric, strconv.FormatFloat(s.Value, 'f', -1, 64)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
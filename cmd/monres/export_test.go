@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/replay"
+)
+
+func TestWriteSamples(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []replay.Sample{
+		{Timestamp: start, Metric: "cpu_percent_total", Value: 12.5},
+		{Timestamp: start.Add(time.Minute), Metric: "cpu_percent_total", Value: 80},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeSamples(&buf, exportCSV, samples))
+	assert.Equal(t, "timestamp,metric,value\n2024-01-01T00:00:00Z,cpu_percent_total,12.5\n2024-01-01T00:01:00Z,cpu_percent_total,80\n", buf.String())
+
+	// Both formats replay with test-rules
+	for _, format := range []string{exportCSV, exportJSON} {
+		buf.Reset()
+		require.NoError(t, writeSamples(&buf, format, samples))
+		path := filepath.Join(t.TempDir(), "samples."+format)
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+		loaded, err := replay.LoadSamples(path)
+		require.NoError(t, err, format)
+		assert.Equal(t, samples, loaded, format)
+	}
+
+	buf.Reset()
+	require.NoError(t, writeSamples(&buf, exportJSON, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/promrules"
+)
+
+// exportPromRules prints the configured alert rules as a Prometheus rule file.
+func exportPromRules(configPath string, args []string) {
+	fs := flag.NewFlagSet("export-prom-rules", flag.ExitOnError)
+	group := fs.String("group", "monres", "Name of the Prometheus rule group.")
+	nodeExporter := fs.Bool("node-exporter", false, "Compute the built-in metrics from node_exporter metrics.")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] export-prom-rules [-group monres] [-node-exporter]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to load configuration from %s: %v", configPath, err)
+	}
+	if err := writePromExport(os.Stdout, cfg, *group, *nodeExporter); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d rule(s); review the comments in the output.\n", len(cfg.Alerts))
+}
+
+// writePromExport writes the alert rules of cfg to w as a Prometheus rule file.
+func writePromExport(w io.Writer, cfg *config.Config, group string, nodeExporter bool) error {
+	out, err := promrules.MarshalPrometheus(promrules.Export(cfg.Alerts, nodeExporter), group)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
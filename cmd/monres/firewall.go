@@ -0,0 +1,21 @@
+//go:build !no_firewall
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Build with -tags no_firewall to leave the firewall counter collector out.
+func init() {
+	buildinfo.RegisterFeature("firewall")
+}
+
+func addFirewallCollector(gc *collector.GlobalCollector, cfg *config.Config) {
+	gc.AddCollector(collector.NewFirewallCollector(cfg.Firewall.Backend, cfg.Firewall.Counters))
+	log.Printf("Firewall collector enabled. Backend: %s", cfg.Firewall.Backend)
+}
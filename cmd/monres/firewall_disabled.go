@@ -0,0 +1,14 @@
+//go:build no_firewall
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func addFirewallCollector(_ *collector.GlobalCollector, _ *config.Config) {
+	log.Println("Warning: firewall is configured, but this build does not include the firewall collector (built with -tags no_firewall).")
+}
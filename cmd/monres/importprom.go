@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mattmezza/monres/internal/promrules"
+)
+
+// importPromRules converts a Prometheus rule file into monres alert rules
+// and prints them as the alerts section of a config.
+func importPromRules(args []string) {
+	fs := flag.NewFlagSet("import-prom-rules", flag.ExitOnError)
+	channels := fs.String("channels", "stdout", "Comma-separated notification channels of the converted rules.")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres import-prom-rules [-channels email,telegram] <rules.yml>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	converted, skipped, err := writePromRules(os.Stdout, fs.Arg(0), strings.Split(*channels, ","))
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Converted %d rule(s), skipped %d (see the comments in the output).\n", converted, skipped)
+}
+
+// writePromRules converts the rules in path and writes them to w.
+func writePromRules(w io.Writer, path string, channels []string) (converted, skipped int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	results, err := promrules.Convert(data, channels)
+	if err != nil {
+		return 0, 0, err
+	}
+	out, err := promrules.Marshal(results)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, result := range results {
+		if result.Rule == nil {
+			skipped++
+		} else {
+			converted++
+		}
+	}
+	_, err = w.Write(out)
+	return converted, skipped, err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func TestWritePromRules(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`
+groups:
+  - name: node
+    rules:
+      - alert: HighCPU
+        expr: cpu_percent_total > 90
+        for: 5m
+      - alert: HighLoad
+        expr: rate(node_cpu_seconds_total[5m]) > 0.9
+`), 0644))
+
+	var out bytes.Buffer
+	converted, skipped, err := writePromRules(&out, rulesFile, []string{"email"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, converted)
+	assert.Equal(t, 1, skipped)
+	assert.Contains(t, out.String(), "name: HighCPU")
+	assert.Contains(t, out.String(), "# Skipped HighLoad")
+
+	_, _, err = writePromRules(&out, filepath.Join(t.TempDir(), "missing.yml"), nil)
+	assert.Error(t, err)
+}
+
+func TestWritePromExport(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "Disk"
+    metric: "disk_percent_used"
+    condition: ">"
+    thresholds: {warning: 80, critical: 95}
+    duration: "10m"
+    aggregation: "max"
+    channels: ["console"]
+notification_channels:
+  - name: "console"
+    type: "stdout"
+`), 0600))
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, writePromExport(&out, cfg, "vps", false))
+	assert.Contains(t, out.String(), "- name: vps")
+	assert.Contains(t, out.String(), "expr: max_over_time(disk_percent_used[10m]) > 95")
+}
@@ -0,0 +1,82 @@
+//go:build !no_journald
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/journal"
+)
+
+// Build with -tags no_journald to leave journal logging out.
+func init() {
+	buildinfo.RegisterFeature("journald")
+}
+
+// setupLogOutput sends the log to the systemd journal when log_output is
+// journald. The returned function starts logging the alert events of an
+// alerter as entries with structured fields.
+func setupLogOutput(cfg *config.Config) (watchAlerts func(*alerter.Alerter)) {
+	if cfg.LogOutput != config.LogOutputJournald {
+		return func(*alerter.Alerter) {}
+	}
+	if !journal.Available() {
+		log.Printf("Warning: log_output is journald, but %s does not exist; logging to stdout.", journal.SocketPath)
+		return func(*alerter.Alerter) {}
+	}
+	j, err := journal.Open("monres")
+	if err != nil {
+		log.Printf("Warning: %v; logging to stdout.", err)
+		return func(*alerter.Alerter) {}
+	}
+	log.Printf("Logging to the systemd journal.")
+	log.SetFlags(0) // The journal timestamps entries itself
+	log.SetOutput(j)
+
+	return func(a *alerter.Alerter) {
+		events := a.Subscribe(alertEventBuffer)
+		go func() {
+			for event := range events {
+				priority, message, fields := alertEventEntry(event)
+				if err := j.Send(priority, message, fields); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			}
+		}()
+	}
+}
+
+// alertEventEntry builds the journal entry of an alert event, so e.g.
+// `journalctl -p warning ALERT_NAME="High CPU"` finds it.
+func alertEventEntry(event alerter.AlertEvent) (journal.Priority, string, map[string]string) {
+	priority := journal.PriNotice
+	if event.Type == alerter.EventTypeFired {
+		switch event.Severity {
+		case config.SeverityCritical:
+			priority = journal.PriCrit
+		case config.SeverityWarning:
+			priority = journal.PriWarning
+		}
+	}
+	message := fmt.Sprintf("Alert %s %s: %s %s %g (current: %g)", event.Rule.Name, event.Type, event.Rule.Metric, event.Rule.Condition, event.Threshold, event.MetricValue)
+	fields := map[string]string{
+		"ALERT_NAME":  event.Rule.Name,
+		"ALERT_STATE": string(event.Type),
+		"SEVERITY":    event.Severity,
+		"METRIC":      event.Rule.Metric,
+		"VALUE":       strconv.FormatFloat(event.MetricValue, 'g', -1, 64),
+		"THRESHOLD":   strconv.FormatFloat(event.Threshold, 'g', -1, 64),
+	}
+	if event.Stale {
+		fields["STALE"] = "1"
+	}
+	if event.Action != nil {
+		fields["ACTION"] = event.Action.Name
+	}
+	return priority, message, fields
+}
@@ -0,0 +1,17 @@
+//go:build no_journald
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func setupLogOutput(cfg *config.Config) func(*alerter.Alerter) {
+	if cfg.LogOutput == config.LogOutputJournald {
+		log.Println("Warning: log_output is journald, but this build does not include journal logging (built with -tags no_journald).")
+	}
+	return func(*alerter.Alerter) {}
+}
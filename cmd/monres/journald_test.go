@@ -0,0 +1,36 @@
+//go:build !no_journald
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/journal"
+)
+
+func TestAlertEventEntry(t *testing.T) {
+	rule := alerter.NewAlertRule(config.AlertRuleConfig{Name: "High CPU", Metric: "cpu_percent_total", Condition: ">", Threshold: 90})
+	priority, message, fields := alertEventEntry(alerter.AlertEvent{
+		Rule: rule, Type: alerter.EventTypeFired, Severity: config.SeverityCritical, Threshold: 90, MetricValue: 97.5,
+	})
+	assert.Equal(t, journal.PriCrit, priority)
+	assert.Equal(t, "Alert High CPU FIRED: cpu_percent_total > 90 (current: 97.5)", message)
+	assert.Equal(t, map[string]string{
+		"ALERT_NAME":  "High CPU",
+		"ALERT_STATE": "FIRED",
+		"SEVERITY":    "critical",
+		"METRIC":      "cpu_percent_total",
+		"VALUE":       "97.5",
+		"THRESHOLD":   "90",
+	}, fields)
+
+	priority, _, fields = alertEventEntry(alerter.AlertEvent{
+		Rule: rule, Type: alerter.EventTypeResolved, Severity: config.SeverityCritical, Stale: true,
+	})
+	assert.Equal(t, journal.PriNotice, priority)
+	assert.Equal(t, "1", fields["STALE"])
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -10,135 +11,237 @@ import (
 	"time"
 
 	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/buildinfo"
 	"github.com/mattmezza/monres/internal/collector"
 	"github.com/mattmezza/monres/internal/config"
 	"github.com/mattmezza/monres/internal/history"
+	"github.com/mattmezza/monres/internal/metrics"
 	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+	"github.com/mattmezza/monres/internal/relabel"
+	"github.com/mattmezza/monres/internal/replay"
+	"github.com/mattmezza/monres/internal/sink"
+	"github.com/mattmezza/monres/internal/transform"
 )
 
-var configFile string
+var (
+	configFile  string
+	recordFile  string
+	replayFile  string
+	replaySpeed float64
+)
+
+// alertEventBuffer is how many alert events may queue up for delivery.
+const alertEventBuffer = 64
 
 func init() {
 	flag.StringVar(&configFile, "config", "config.yaml", "Path to the configuration file.")
+	flag.StringVar(&recordFile, "record", "", "Append every collection cycle to this trace file.")
+	flag.StringVar(&replayFile, "replay", "", "Run the alerter against a recorded trace file instead of live metrics, then exit.")
+	flag.Float64Var(&replaySpeed, "replay-speed", 0, "Replay speed factor for -replay (e.g. 60 replays an hour in a minute). 0 replays as fast as possible.")
 	// Set up logger
 	log.SetOutput(os.Stdout) // Systemd will capture this
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 }
 
-func testNotification(configPath, channelName string) {
-	log.Println("Testing notification channels...")
-	
-	// Load configuration
-	cfg, err := config.LoadConfig(configPath)
-	if err != nil {
-		log.Fatalf("FATAL: Failed to load configuration from %s: %v", configPath, err)
+// registerMetricMetadata adds the metric metadata declared in the config to the
+// metrics registry, overriding built-in entries with the same name, and sets
+// the locale values are formatted with.
+func registerMetricMetadata(cfg *config.Config) {
+	notifier.SetLocale(cfg.Locale)
+	if err := notifier.SetDashboardURLTemplate(cfg.DashboardURLTemplate); err != nil {
+		log.Printf("Warning: %v", err) // Already validated by LoadConfig
 	}
-	
-	// Check if specific channel exists in config
-	if channelName != "" {
-		found := false
-		var availableChannels []string
-		for _, channel := range cfg.NotificationChannels {
-			availableChannels = append(availableChannels, channel.Name)
-			if channel.Name == channelName {
-				found = true
-			}
+	for _, mc := range cfg.Metrics {
+		if mc.Unit == "" && mc.Description == "" && mc.Type == "" {
+			continue // Transform-only entry
 		}
-		if !found {
-			if len(availableChannels) > 0 {
-				log.Fatalf("ERROR: Channel '%s' not found in configuration. Available channels: %s", 
-					channelName, strings.Join(availableChannels, ", "))
-			} else {
-				log.Fatalf("ERROR: Channel '%s' not found and no notification channels configured", channelName)
-			}
+		md := metrics.Metadata{
+			Name:        mc.Name,
+			Unit:        metrics.Unit(mc.Unit),
+			Description: mc.Description,
+			Type:        metrics.Type(mc.Type),
+		}
+		if strings.HasSuffix(mc.Name, "*") {
+			metrics.RegisterPrefix(strings.TrimSuffix(mc.Name, "*"), md)
+		} else {
+			metrics.Register(md)
 		}
 	}
-	
-	// Initialize notifiers
+}
+
+// reloadConfig re-reads the configuration file and applies its alert rules,
+// notification channels and templates. The other settings (collectors, sinks,
+// API, history, dedupe) only change on restart, except that the history keeps
+// series long enough for the reloaded auto_resolve_after. On error the running
+// configuration is kept.
+func reloadConfig(path string, histDuration time.Duration, hist *history.MetricHistoryBuffer, a *alerter.Alerter, router *alerter.Router) error {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration from %s: %w", path, err)
+	}
 	configuredNotifiers, err := notifier.InitializeNotifiers(cfg.NotificationChannels)
 	if err != nil {
-		log.Fatalf("FATAL: Failed to initialize notifiers: %v", err)
+		return fmt.Errorf("failed to initialize notifiers: %w", err)
 	}
-	
-	if len(configuredNotifiers) == 0 {
-		log.Fatalf("ERROR: No notification channels were successfully initialized")
+	registerMetricMetadata(cfg)
+	router.Update(cfg, configuredNotifiers)
+	hist.KeepSeriesFor(history.MaxAutoResolveAfter(cfg.Alerts))
+	a.UpdateRules(cfg)
+	if d := history.GetMaxConfiguredDuration(cfg.Alerts, cfg.CollectionInterval); d > histDuration {
+		log.Printf("Warning: The reloaded rules need %s of history, but the buffer holds %s until monres is restarted.", d, histDuration)
 	}
-	
-	// Create test notification data
-	testData := notifier.NotificationData{
-		AlertName:      "Test Alert",
-		MetricName:     "test_metric",
-		MetricValue:    42.5,
-		ThresholdValue: 40.0,
-		Condition:      ">",
-		State:          "FIRED",
-		Hostname:       cfg.EffectiveHostname,
-		Time:           time.Now(),
-		DurationString: "1m",
-		Aggregation:    "average",
+	log.Printf("Configuration reloaded from %s: %d alert rule(s), %d notification channel(s). Collector, sink and API settings apply after a restart.",
+		path, len(cfg.Alerts), len(configuredNotifiers))
+	return nil
+}
+
+// newMetricCollector builds the GlobalCollector with the collectors enabled in the config.
+func newMetricCollector(cfg *config.Config) *collector.GlobalCollector {
+	networkFilter := &collector.NetworkInterfaceFilter{
+		ExcludeInterfaces: cfg.Network.ExcludeInterfaces,
+		ExcludePrefixes:   cfg.Network.ExcludePrefixes,
 	}
-	
-	templates := notifier.NotificationTemplates{
-		FiredTemplate:    cfg.Templates.AlertFired,
-		ResolvedTemplate: cfg.Templates.AlertResolved,
+	metricCollector := collector.NewGlobalCollector(networkFilter)
+	metricCollector.SetTimeout(cfg.CollectionTimeout)
+	metricCollector.SetExpectedMounts(cfg.Filesystems.ExpectedMounts)
+	metricCollector.SetDiskSpaceMounts(cfg.Filesystems.Include, cfg.Filesystems.Exclude)
+	if len(cfg.ListenPorts) > 0 {
+		metricCollector.AddCollector(collector.NewListenCollector(cfg.ListenPorts))
+		log.Printf("Listen collector enabled for %d port(s).", len(cfg.ListenPorts))
 	}
-	
-	// Test specific channel or all channels
-	if channelName != "" {
-		// Test specific channel
-		if notifierInstance, exists := configuredNotifiers[channelName]; exists {
-			log.Printf("Testing notification channel: %s", channelName)
-			err := notifierInstance.Send(testData, templates)
-			if err != nil {
-				log.Fatalf("ERROR: Failed to send test notification to channel '%s': %v", channelName, err)
-			}
-			log.Printf("✅ Test notification sent successfully to channel: %s", channelName)
+	if cfg.ZFS.Enabled {
+		metricCollector.AddCollector(collector.NewZFSCollector())
+		log.Println("ZFS collector enabled.")
+	}
+	if cfg.LVM.Enabled {
+		metricCollector.AddCollector(collector.NewLVMCollector())
+		log.Println("LVM thin pool collector enabled.")
+	}
+	if cfg.Libvirt.Enabled {
+		metricCollector.AddCollector(collector.NewLibvirtCollector(cfg.Libvirt.URI))
+		log.Println("Libvirt collector enabled.")
+	}
+	if cfg.Users.Enabled {
+		metricCollector.AddCollector(collector.NewUserCollector(cfg.Users.MinUID))
+		log.Printf("User collector enabled for UIDs from %d.", cfg.Users.MinUID)
+	}
+	if cfg.TopProcesses.Count > 0 {
+		metricCollector.AddCollector(collector.NewTopProcessCollector(cfg.TopProcesses.Count))
+		log.Printf("Top process collector enabled for %d process(es).", cfg.TopProcesses.Count)
+	}
+	if len(cfg.Processes) > 0 {
+		processes := make([]collector.WatchedProcess, 0, len(cfg.Processes))
+		for _, pc := range cfg.Processes {
+			processes = append(processes, collector.WatchedProcess{Name: pc.Name, Pattern: pc.Regex, PIDFile: pc.PIDFile})
+		}
+		metricCollector.AddCollector(collector.NewProcessCollector(processes))
+		log.Printf("Process collector enabled for %d process(es).", len(processes))
+	}
+	if cfg.Slices.Enabled {
+		metricCollector.AddCollector(collector.NewCgroupCollector(cfg.Slices.Paths))
+		if len(cfg.Slices.Paths) > 0 {
+			log.Printf("Slice collector enabled for %d cgroup(s).", len(cfg.Slices.Paths))
 		} else {
-			log.Fatalf("ERROR: Channel '%s' was not successfully initialized", channelName)
+			log.Println("Slice collector enabled for every slice.")
 		}
-	} else {
-		// Test all channels
-		log.Printf("Testing all %d configured notification channels...", len(configuredNotifiers))
-		successCount := 0
-		for name, notifierInstance := range configuredNotifiers {
-			log.Printf("Testing channel: %s", name)
-			err := notifierInstance.Send(testData, templates)
-			if err != nil {
-				log.Printf("❌ Failed to send test notification to channel '%s': %v", name, err)
-			} else {
-				log.Printf("✅ Test notification sent successfully to channel: %s", name)
-				successCount++
-			}
+	}
+	if cfg.Kubelet.Address != "" {
+		client, err := outbound.NewHTTPClient(outbound.Options{Proxy: outbound.ProxyDirect, TLS: cfg.Kubelet.TLSOptions()}, 0)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to create kubelet client: %v", err)
 		}
-		log.Printf("Test completed: %d/%d channels successful", successCount, len(configuredNotifiers))
-		if successCount == 0 {
-			log.Fatalf("ERROR: All notification channels failed")
+		metricCollector.AddCollector(collector.NewKubeletCollector(cfg.Kubelet.Address, cfg.Kubelet.TokenFile, client))
+		log.Printf("Kubelet collector enabled. Address: %s", cfg.Kubelet.Address)
+	}
+	if len(cfg.CertFiles) > 0 {
+		files := make([]collector.CertFile, 0, len(cfg.CertFiles))
+		for _, cf := range cfg.CertFiles {
+			files = append(files, collector.CertFile{Name: cf.Name, Path: cf.Path})
 		}
+		metricCollector.AddCollector(collector.NewCertFileCollector(files))
+		log.Printf("Certificate file collector enabled with %d file(s).", len(files))
+	}
+	if len(cfg.ACME.Directories) > 0 {
+		metricCollector.AddCollector(collector.NewACMECollector(cfg.ACME.Directories))
+		log.Printf("ACME collector enabled. Directories: %s", strings.Join(cfg.ACME.Directories, ", "))
+	}
+	if len(cfg.Services) > 0 {
+		services := make([]collector.Service, 0, len(cfg.Services))
+		for _, svc := range cfg.Services {
+			services = append(services, collector.Service{
+				Name:     svc.Name,
+				Type:     svc.Type,
+				Address:  svc.Address,
+				Timeout:  svc.Timeout,
+				Username: svc.Username,
+				Password: svc.Password,
+				Group:    svc.Group,
+				Command:  svc.Command,
+			})
+		}
+		metricCollector.AddCollector(collector.NewServiceCollector(services))
+		log.Printf("Service collector enabled with %d service(s).", len(services))
+	}
+	if cfg.Firewall.Backend != "" {
+		addFirewallCollector(metricCollector, cfg)
+	}
+	if cfg.WireGuard.Enabled {
+		addWireGuardCollector(metricCollector, cfg)
+	}
+	if cfg.Textfile.Directory != "" {
+		addTextfileCollector(metricCollector, cfg)
+	}
+	if len(cfg.NagiosChecks) > 0 {
+		addNagiosCollector(metricCollector, cfg)
+	}
+	if len(cfg.Relabel) > 0 {
+		metricCollector.AddProcessor(relabel.New(cfg.Relabel))
+		log.Printf("Relabeling enabled with %d rule(s).", len(cfg.Relabel))
+	}
+	if t := transform.New(cfg.Metrics); t != nil {
+		metricCollector.AddProcessor(t) // After relabeling, so transforms match the final names
+		log.Println("Metric transforms enabled.")
+	}
+	return metricCollector
+}
+
+// recordCycle appends a collection cycle to the trace file when recording is enabled.
+func recordCycle(tw *replay.TraceWriter, t time.Time, metrics collector.CollectedMetrics) {
+	if tw == nil {
+		return
+	}
+	if err := tw.Write(t, metrics); err != nil {
+		log.Printf("Warning: Failed to record collection cycle: %v", err)
 	}
 }
 
 func main() {
+	flag.Usage = func() { printUsage(flag.CommandLine.Output()) }
 	flag.Parse()
 	
-	// Check if test-notification subcommand is provided
+	// Check if a subcommand is provided
 	args := flag.Args()
-	if len(args) > 0 && args[0] == "test-notification" {
-		var channelName string
-		if len(args) > 1 {
-			channelName = args[1]
+	if len(args) > 0 {
+		if cmd, ok := findCommand(args[0]); ok {
+			cmd.run(args[1:])
+			return
 		}
-		testNotification(configFile, channelName)
-		return
 	}
 	
-	log.Println("Starting monres...")
+	log.Printf("Starting %s...", buildinfo.String())
 
 	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to load configuration from %s: %v", configFile, err)
 	}
+	watchAlerts := setupLogOutput(cfg)
 	log.Printf("Configuration loaded successfully from %s. Interval: %ds, Hostname: %s",
             configFile, cfg.IntervalSeconds, cfg.EffectiveHostname)
+	registerMetricMetadata(cfg)
+	for _, err := range notifier.LintTemplates(templatesForConfig(cfg)) {
+		log.Printf("Warning: %v (run 'monres validate' to check the configuration)", err)
+	}
 
 
 	// Initialize Metric History Buffer
@@ -154,15 +257,11 @@ func main() {
 	} else {
         log.Printf("Initializing metric history buffer for max duration: %s (collection interval: %s)", maxHistDuration, cfg.CollectionInterval)
     }
-	metricHist := history.NewMetricHistoryBuffer(maxHistDuration, cfg.CollectionInterval)
+	metricHist := history.NewForConfig(cfg, cfg.CollectionInterval)
 
 
 	// Initialize Metric Collectors with network interface filter from config
-	networkFilter := &collector.NetworkInterfaceFilter{
-		ExcludeInterfaces: cfg.Network.ExcludeInterfaces,
-		ExcludePrefixes:   cfg.Network.ExcludePrefixes,
-	}
-	metricCollector := collector.NewGlobalCollector(networkFilter)
+	metricCollector := newMetricCollector(cfg)
 	log.Printf("Metric collectors initialized. Network filter: exclude interfaces %v, exclude prefixes %v",
 		cfg.Network.ExcludeInterfaces, cfg.Network.ExcludePrefixes)
 
@@ -176,18 +275,95 @@ func main() {
     } else {
         log.Printf("%d notification channel(s) initialized.", len(configuredNotifiers))
     }
+	if health := notifier.NewHealthCollector(cfg.NotificationChannels, configuredNotifiers); health != nil {
+		metricCollector.AddCollector(health)
+		log.Printf("Health checks enabled for %d notification channel(s).", health.Len())
+	}
+	var heartbeats *collector.HeartbeatCollector
+	if len(cfg.CronJobs) > 0 {
+		jobs := make([]collector.CronJob, 0, len(cfg.CronJobs))
+		for _, job := range cfg.CronJobs {
+			jobs = append(jobs, collector.CronJob{Name: job.Name, Period: job.Period, Grace: job.Grace})
+		}
+		heartbeats = collector.NewHeartbeatCollector(jobs)
+		metricCollector.AddCollector(heartbeats)
+		log.Printf("Cron heartbeats enabled for %d job(s).", len(jobs))
+	}
+
 
+	if replayFile != "" {
+		cycles, err := replay.LoadTrace(replayFile)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to load trace: %v", err)
+		}
+		start, end := replay.Span(cycles)
+		log.Printf("Replaying %d cycles (%s to %s) from %s at speed %.1fx...", len(cycles), start.Format(time.RFC3339), end.Format(time.RFC3339), replayFile, replaySpeed)
+		if err := replay.Run(cfg, cycles, configuredNotifiers, replaySpeed); err != nil {
+			log.Fatalf("FATAL: Replay failed: %v", err)
+		}
+		log.Println("Replay complete.")
+		return
+	}
+
+	var traceWriter *replay.TraceWriter
+	if recordFile != "" {
+		traceWriter, err = replay.NewTraceWriter(recordFile)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		defer traceWriter.Close()
+		log.Printf("Recording collection cycles to %s", recordFile)
+	}
+
+	sinks := sink.New(cfg.Sinks)
+	if sinks.Len() > 0 {
+		log.Printf("%d sink(s) initialized.", sinks.Len())
+	}
 
 	// Initialize Alerter (loads initial state itself)
-	alertProcessor, err := alerter.NewAlerter(cfg, metricHist, configuredNotifiers)
+	alertProcessor, err := alerter.NewAlerter(cfg, metricHist)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to initialize alerter: %v", err)
 	}
 	log.Println("Alerter initialized. Loaded initial alert states.")
+	watchAlerts(alertProcessor)
+
+	// Deliver notifications from a separate goroutine so slow channels don't delay evaluation
+	router := alerter.NewRouter(cfg, configuredNotifiers)
+	notificationLog := openNotificationLog(cfg)
+	defer notificationLog.Close()
+	router.SetNotificationLog(notificationLog)
+	silences := alerter.NewSilences()
+	router.SetSilences(silences)
+	router.EnableActions()
+	if cfg.Dedupe.Window > 0 {
+		dedupe, err := alerter.NewDedupe(cfg.Dedupe.Window, cfg.Dedupe.Path)
+		if err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+		router.SetDedupe(dedupe)
+	}
+	alertEvents := alertProcessor.Subscribe(alertEventBuffer)
+	routerDone := make(chan struct{})
+	go func() {
+		router.Run(alertEvents)
+		close(routerDone)
+	}()
+
+	// Reloads requested through the API are applied from the main loop, like SIGHUP
+	reloadRequests := make(chan chan error)
+	requestReload := func() error {
+		reply := make(chan error, 1)
+		reloadRequests <- reply
+		return <-reply
+	}
+	stopAPI := startAPI(cfg, alertProcessor, metricHist, notificationLog, silences, requestReload, heartbeats)
 
 	// Setup Graceful Shutdown
 	shutdownSignal := make(chan os.Signal, 1)
 	signal.Notify(shutdownSignal, syscall.SIGINT, syscall.SIGTERM)
+	reloadSignal := make(chan os.Signal, 1)
+	signal.Notify(reloadSignal, syscall.SIGHUP)
 
 	// Main Application Loop
 	ticker := time.NewTicker(cfg.CollectionInterval)
@@ -201,12 +377,16 @@ func main() {
 	log.Println("Performing initial metric collection...")
 	initialMetrics, err := metricCollector.CollectAll()
 	if err != nil {
+		// Failed collectors are reported individually; the others' metrics are still usable.
 		log.Printf("Warning: Error during initial metric collection: %v", err)
-	} else {
+	}
+	if len(initialMetrics) > 0 {
 		now := time.Now()
 		for name, val := range initialMetrics {
 			metricHist.AddDataPoint(name, val, now)
 		}
+		recordCycle(traceWriter, now, initialMetrics)
+		sinks.Push(now, initialMetrics)
 		log.Printf("Initial metrics collected. %d data points added to history.", len(initialMetrics))
 		// Run alerter once after initial collection to catch immediate state changes for non-duration alerts.
         // This is important if an alert condition is met by the very first data sample.
@@ -238,11 +418,33 @@ func main() {
 				// log.Printf("Metric %s: %v", name, value)
 			}
 
+			recordCycle(traceWriter, currentTime, collectedData)
+			sinks.Push(currentTime, collectedData)
+
 			alertProcessor.CheckAndNotify(currentTime, collectedData)
 
+		case <-reloadSignal:
+			log.Println("Received SIGHUP. Reloading configuration...")
+			if err := reloadConfig(configFile, maxHistDuration, metricHist, alertProcessor, router); err != nil {
+				log.Printf("Error: Reload failed, keeping the running configuration: %v", err)
+			}
+
+		case reply := <-reloadRequests:
+			log.Println("Reload requested through the API. Reloading configuration...")
+			err := reloadConfig(configFile, maxHistDuration, metricHist, alertProcessor, router)
+			if err != nil {
+				log.Printf("Error: Reload failed, keeping the running configuration: %v", err)
+			}
+			reply <- err
+
 		case sig := <-shutdownSignal:
 			log.Printf("Received signal: %s. Shutting down gracefully...", sig)
-			// Perform any necessary cleanup here
+			stopAPI()
+			sinks.Close() // Deliver queued metrics
+			// Flush notifications that are still queued
+			alertProcessor.Close()
+			<-routerDone
+			router.WaitActions() // Report the outcome of running remediation actions
 			log.Println("monres shut down.")
 			return
 		}
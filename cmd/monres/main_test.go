@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/mattmezza/monres/internal/buildinfo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -233,4 +236,29 @@ func TestBuildAndVersion(t *testing.T) {
 	
 	replacedString := strings.ReplaceAll(testString, "-", "_")
 	assert.Equal(t, "test_channel_name", replacedString)
-}
\ No newline at end of file
+}
+func TestPrintVersion(t *testing.T) {
+	var buf bytes.Buffer
+	printVersion(&buf)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "monres "))
+	// The features compiled in depend on the build tags of the test run
+	features := buildinfo.Features()
+	if len(features) == 0 {
+		assert.Equal(t, "features: none", lines[1])
+	} else {
+		assert.Equal(t, "features: "+strings.Join(features, " "), lines[1])
+	}
+}
+
+func TestVersionInfo(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeJSON(&buf, newVersionInfo()))
+	assert.Contains(t, buf.String(), `"version": "`)
+
+	var info versionInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &info))
+	assert.ElementsMatch(t, buildinfo.Features(), info.Features)
+}
@@ -0,0 +1,30 @@
+//go:build !no_nagios
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Build with -tags no_nagios to leave the Nagios check plugin collector out.
+func init() {
+	buildinfo.RegisterFeature("nagios")
+}
+
+func addNagiosCollector(gc *collector.GlobalCollector, cfg *config.Config) {
+	checks := make([]collector.NagiosCheck, 0, len(cfg.NagiosChecks))
+	for _, nc := range cfg.NagiosChecks {
+		checks = append(checks, collector.NagiosCheck{
+			Name:     nc.Name,
+			Command:  nc.Command,
+			Timeout:  nc.Timeout,
+			Interval: nc.Interval,
+		})
+	}
+	gc.AddCollector(collector.NewNagiosCollector(checks))
+	log.Printf("Nagios collector enabled with %d check(s).", len(checks))
+}
@@ -0,0 +1,14 @@
+//go:build no_nagios
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func addNagiosCollector(_ *collector.GlobalCollector, _ *config.Config) {
+	log.Println("Warning: nagios_checks are configured, but this build does not include the Nagios collector (built with -tags no_nagios).")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// openNotificationLog opens the log recording every notification attempt.
+// Without a configured path, attempts are only kept in memory.
+func openNotificationLog(cfg *config.Config) *audit.Log {
+	if cfg.NotificationLog.Path == "" {
+		return audit.NewLog(cfg.NotificationLog.MaxEntries)
+	}
+	l, err := audit.Open(cfg.NotificationLog.Path, cfg.NotificationLog.MaxEntries)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	log.Printf("Recording notification attempts to %s", cfg.NotificationLog.Path)
+	return l
+}
+
+// notifications lists recorded notification attempts, read from the
+// notification log file or, without one, from the daemon's API.
+func notifications(configPath string, args []string) {
+	fs := flag.NewFlagSet("notifications", flag.ExitOnError)
+	limit := fs.Int("n", 20, "Number of most recent attempts to show (0 for all).")
+	alertName := fs.String("alert", "", "Only show attempts for this alert.")
+	channel := fs.String("channel", "", "Only show attempts on this channel.")
+	failed := fs.Bool("failed", false, "Only show attempts that failed or were skipped.")
+	since := fs.Duration("since", 0, "Only show attempts in this period (e.g. 24h).")
+	showCaptures := fs.Bool("captures", false, "Also show the output of capture commands run when alerts fired.")
+	output := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] notifications [-n 20] [-alert name] [-channel name] [-failed] [-since 24h] [-captures] [-output text|json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setOutput(*output)
+
+	cfg := loadConfigOrFail(*output, configPath)
+
+	q := audit.Query{Alert: *alertName, Channel: *channel, FailedOnly: *failed, Limit: *limit}
+	if *since > 0 {
+		q.Since = time.Now().Add(-*since)
+	}
+
+	var entries []audit.Entry
+	var err error
+	if cfg.NotificationLog.Path != "" {
+		all, err := audit.ReadFile(cfg.NotificationLog.Path)
+		if err != nil {
+			fail(*output, exitFailure, err)
+		}
+		entries = audit.Filter(all, q)
+	} else if entries, err = fetchNotifications(cfg, q); err != nil {
+		fail(*output, exitFailure, err)
+	}
+	if *output == outputJSON { // Captures are part of the entries
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		writeJSON(os.Stdout, entries)
+		return
+	}
+	printNotifications(os.Stdout, entries)
+	if *showCaptures {
+		printCaptures(os.Stdout, entries)
+	}
+}
+
+// printNotifications writes notification attempts as a table, oldest first.
+func printNotifications(w io.Writer, entries []audit.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No notification attempts recorded.")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tALERT\tSTATE\tCHANNEL\tSTATUS\tLATENCY\tRETRIES\tTEXT\tERROR")
+	for _, e := range entries {
+		channel := e.Channel
+		if e.FallbackFor != "" {
+			channel += " (for " + e.FallbackFor + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05"), e.Alert, e.State, channel, e.Status,
+			e.Latency.Round(time.Millisecond), e.Retries, e.TextHash, e.Error)
+	}
+	tw.Flush()
+}
+
+// printCaptures writes the output of the capture commands recorded with
+// notification attempts, once per alert event rather than once per channel.
+func printCaptures(w io.Writer, entries []audit.Entry) {
+	type event struct {
+		alert string
+		time  time.Time
+	}
+	seen := make(map[event]bool)
+	for _, e := range entries {
+		key := event{e.Alert, e.Time}
+		if len(e.Captures) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		fmt.Fprintf(w, "\n%s %s at %s:\n", e.Alert, e.State, e.Time.Local().Format("2006-01-02 15:04:05"))
+		for _, c := range e.Captures {
+			fmt.Fprintf(w, "$ %s\n%s\n", c.Command, c.Output)
+			if c.Error != "" {
+				fmt.Fprintf(w, "(%s)\n", c.Error)
+			}
+		}
+	}
+}
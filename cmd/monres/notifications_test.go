@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/audit"
+)
+
+func TestPrintNotifications(t *testing.T) {
+	var buf bytes.Buffer
+	printNotifications(&buf, nil)
+	assert.Equal(t, "No notification attempts recorded.\n", buf.String())
+
+	buf.Reset()
+	printNotifications(&buf, []audit.Entry{
+		{Time: time.Now(), Alert: "High CPU", State: "FIRED", Channel: "email", Status: audit.StatusFailed,
+			Latency: 1234 * time.Millisecond, TextHash: "0123456789abcdef", Error: "dial tcp: i/o timeout"},
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "TIME"))
+	for _, field := range []string{"High CPU", "FIRED", "email", "failed", "1.234s", "0123456789abcdef", "dial tcp: i/o timeout"} {
+		assert.Contains(t, lines[1], field)
+	}
+}
+
+func TestPrintCaptures(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	captures := []audit.Capture{{Command: "df -h", Output: "/dev/sda1 93%"}, {Command: "false", Error: "exit status 1"}}
+	var buf bytes.Buffer
+	printCaptures(&buf, []audit.Entry{
+		{Time: at, Alert: "Disk", State: "FIRED", Channel: "email", Captures: captures},
+		{Time: at, Alert: "Disk", State: "FIRED", Channel: "chat", Captures: captures},
+		{Time: at, Alert: "Disk", State: "RESOLVED", Channel: "email"},
+	})
+	assert.Equal(t, "\nDisk FIRED at 2024-05-01 12:00:00:\n$ df -h\n/dev/sda1 93%\n$ false\n\n(exit status 1)\n", buf.String())
+}
@@ -0,0 +1,13 @@
+//go:build !no_email
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/notifier/email" // Registers the "email" channel type
+)
+
+// Build with -tags no_email to leave the email notification channel out.
+func init() {
+	buildinfo.RegisterFeature("notifier_email")
+}
@@ -0,0 +1,13 @@
+//go:build !no_issue
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/notifier/issue" // Registers the "issue" channel type
+)
+
+// Build with -tags no_issue to leave the GitHub and Jira issue channel out.
+func init() {
+	buildinfo.RegisterFeature("notifier_issue")
+}
@@ -0,0 +1,14 @@
+//go:build !no_oncall
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/notifier/oncall" // Registers the on-call router channel types
+)
+
+// Build with -tags no_oncall to leave the Grafana OnCall, Squadcast and
+// Zenduty notification channels out.
+func init() {
+	buildinfo.RegisterFeature("notifier_oncall")
+}
@@ -0,0 +1,13 @@
+//go:build !no_stdout
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/notifier/stdout" // Registers the "stdout" channel type
+)
+
+// Build with -tags no_stdout to leave the stdout notification channel out.
+func init() {
+	buildinfo.RegisterFeature("notifier_stdout")
+}
@@ -0,0 +1,13 @@
+//go:build !no_telegram
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/notifier/telegram" // Registers the "telegram" channel type
+)
+
+// Build with -tags no_telegram to leave the telegram notification channel out.
+func init() {
+	buildinfo.RegisterFeature("notifier_telegram")
+}
@@ -0,0 +1,13 @@
+//go:build !no_voice
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/notifier/voice" // Registers the "voice" channel type
+)
+
+// Build with -tags no_voice to leave the voice call notification channel out.
+func init() {
+	buildinfo.RegisterFeature("notifier_voice")
+}
@@ -0,0 +1,13 @@
+//go:build !no_webhook
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/notifier/webhook" // Registers the "webhook" channel type
+)
+
+// Build with -tags no_webhook to leave the webhook notification channel out.
+func init() {
+	buildinfo.RegisterFeature("notifier_webhook")
+}
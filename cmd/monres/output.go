@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Formats of the -output flag.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// Exit codes shared by the commands. Some commands add their own, such as
+// check with the Nagios codes and validate with validateTemplateError.
+const (
+	exitOK      = 0
+	exitFailure = 1 // The command failed, e.g. the configuration could not be loaded
+	exitUsage   = 2 // Invalid arguments, like the flag package
+)
+
+// outputFlag adds the -output flag to fs.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", outputText, "Output format: text or json.")
+}
+
+// setOutput checks the value of the -output flag. With JSON output, logs go
+// to stderr so that stdout only holds the JSON document.
+func setOutput(output string) {
+	switch output {
+	case outputText:
+	case outputJSON:
+		log.SetOutput(os.Stderr)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format '%s', use text or json\n", output)
+		os.Exit(exitUsage)
+	}
+}
+
+// writeJSON writes v as indented JSON. Conditions such as ">" are not
+// escaped, since the output isn't meant for HTML.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// fail ends a command with code after err. With JSON output the error is
+// printed to stdout as {"error": "..."}, so scripts always get a document.
+func fail(output string, code int, err error) {
+	if output == outputJSON {
+		writeJSON(os.Stdout, map[string]string{"error": err.Error()})
+	} else {
+		log.Printf("ERROR: %v", err)
+	}
+	os.Exit(code)
+}
+
+// loadConfigOrFail loads the configuration, ending the command with
+// exitFailure if it cannot be loaded.
+func loadConfigOrFail(output, configPath string) *config.Config {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		if output != outputJSON {
+			log.Fatalf("FATAL: Failed to load configuration from %s: %v", configPath, err)
+		}
+		fail(output, exitFailure, fmt.Errorf("failed to load configuration from %s: %w", configPath, err))
+	}
+	return cfg
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// htmlPreviewer is implemented by notifiers that can lay out their message
+// as an HTML page, such as email.
+type htmlPreviewer interface {
+	PreviewHTML(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error)
+}
+
+// render prints the notification every channel would send, without sending
+// anything.
+func render(configPath string, args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	state := fs.String("template", "fired", "Template to render: fired or resolved.")
+	alertName := fs.String("alert", "", "Render the notification of this alert rule instead of a sample one.")
+	var value *float64
+	fs.Func("value", "Metric value (default the sample's, or the rule's threshold).", floatFlag(&value))
+	channelName := fs.String("channel", "", "Render for this channel only.")
+	htmlPath := fs.String("html", "", "Also write the email of email channels as an HTML page to this file.")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] render [-template fired|resolved] [-alert name] [-value 95] [-channel name] [-html file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	log.SetOutput(os.Stderr) // stdout holds the rendered notifications
+
+	cfg := loadConfigOrFail(outputText, configPath)
+	registerMetricMetadata(cfg)
+	data, err := renderData(cfg, *state, *alertName, value, time.Now())
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+
+	var channels []config.NotificationChannelConfig
+	for _, channel := range cfg.NotificationChannels {
+		if *channelName == "" || channel.Name == *channelName {
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		log.Fatalf("ERROR: No notification channel to render for (channel '%s')", *channelName)
+	}
+	notifiers, err := notifier.InitializeNotifiers(channels)
+	if err != nil {
+		log.Fatalf("ERROR: Failed to initialize notifiers: %v", err)
+	}
+
+	failed := 0
+	var pages []struct{ channel, html string }
+	templates := templatesForConfig(cfg)
+	for _, channel := range channels {
+		n, ok := notifiers[channel.Name]
+		if !ok {
+			failed++
+			continue // Logged by InitializeNotifiers
+		}
+		if err := writePreview(os.Stdout, channel, n, data, templates); err != nil {
+			log.Printf("ERROR: Channel '%s': %v", channel.Name, err)
+			failed++
+			continue
+		}
+		if hp, ok := n.(htmlPreviewer); ok && *htmlPath != "" {
+			page, err := hp.PreviewHTML(data, templates)
+			if err != nil {
+				log.Printf("ERROR: Channel '%s': %v", channel.Name, err)
+				failed++
+				continue
+			}
+			pages = append(pages, struct{ channel, html string }{channel.Name, page})
+		}
+	}
+	if *htmlPath != "" {
+		if len(pages) == 0 {
+			log.Printf("Warning: No email channel to write an HTML preview for")
+		}
+		for _, page := range pages {
+			path := *htmlPath
+			if len(pages) > 1 {
+				path = htmlPreviewPath(path, page.channel)
+			}
+			if err := os.WriteFile(path, []byte(page.html), 0644); err != nil {
+				log.Fatalf("ERROR: %v", err)
+			}
+			log.Printf("Wrote the HTML preview of channel '%s' to %s", page.channel, path)
+		}
+	}
+	if failed > 0 {
+		os.Exit(exitFailure)
+	}
+}
+
+// renderData returns the notification data to render: the sample fired or
+// resolved notification, or the one of the named alert rule.
+func renderData(cfg *config.Config, state, alertName string, value *float64, now time.Time) (notifier.NotificationData, error) {
+	if alertName != "" {
+		data, _, err := testNotificationData(cfg, testAlert{rule: alertName, state: state, value: value}, now)
+		return data, err
+	}
+	var data notifier.NotificationData
+	samples := notifier.SampleNotificationData(cfg.EffectiveHostname)
+	switch strings.ToLower(state) {
+	case "fired":
+		data = samples[0]
+	case "resolved":
+		data = samples[2]
+	default:
+		return data, fmt.Errorf("invalid template '%s', use fired or resolved", state)
+	}
+	if value != nil {
+		data.MetricValue = *value
+		data.FormattedMetricValue = notifier.FormatValue(data.MetricName, *value)
+	}
+	data.DashboardURL = notifier.DashboardURL(data, 5*time.Minute) // The sample's duration
+	return data, nil
+}
+
+// writePreview writes the notification n would send under a header naming
+// the channel.
+func writePreview(w io.Writer, channel config.NotificationChannelConfig, n notifier.Notifier, data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	p, ok := n.(notifier.Previewer)
+	if !ok {
+		return fmt.Errorf("channel type '%s' does not support previews", channel.Type)
+	}
+	preview, err := p.Preview(data, templates)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "==> %s (%s) <==\n%s\n\n", channel.Name, channel.Type, strings.TrimRight(preview, "\n"))
+	return err
+}
+
+// htmlPreviewPath returns the file of the HTML preview of a channel when
+// there are several, e.g. preview-ops.html for preview.html.
+func htmlPreviewPath(path, channel string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + channel + ext
+}
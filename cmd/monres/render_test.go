@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier/stdout"
+)
+
+func TestRenderData(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+hostname: "vm"
+alerts:
+  - name: "High CPU"
+    metric: "cpu_percent_total"
+    condition: ">"
+    threshold: 90
+    duration: "5m"
+    aggregation: "average"
+    channels: ["stdout"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	value := 95.0
+
+	data, err := renderData(cfg, "fired", "", nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, "FIRED", data.State)
+	assert.Equal(t, "vm", data.Hostname)
+	assert.NotEmpty(t, data.TopProcesses)
+
+	data, err = renderData(cfg, "resolved", "", &value, now)
+	require.NoError(t, err)
+	assert.Equal(t, "RESOLVED", data.State)
+	assert.Equal(t, "95.0%", data.FormattedMetricValue)
+
+	data, err = renderData(cfg, "fired", "High CPU", &value, now)
+	require.NoError(t, err)
+	assert.Equal(t, "High CPU", data.AlertName)
+	assert.Equal(t, 95.0, data.MetricValue)
+	assert.Equal(t, 90.0, data.ThresholdValue)
+
+	_, err = renderData(cfg, "action", "", nil, now)
+	assert.ErrorContains(t, err, "invalid template 'action'")
+	_, err = renderData(cfg, "fired", "Low CPU", nil, now)
+	assert.ErrorContains(t, err, "no alert rule named 'Low CPU'")
+
+	n, err := stdout.New("stdout")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, writePreview(&out, cfg.NotificationChannels[0], n, data, templatesForConfig(cfg)))
+	assert.Contains(t, out.String(), "==> stdout (stdout) <==\nALERT FIRED: High CPU on vm.")
+	assert.NotContains(t, out.String(), "\x1b[")
+
+	assert.Equal(t, "/tmp/preview-ops.html", htmlPreviewPath("/tmp/preview.html", "ops"))
+	assert.Equal(t, "preview-ops", htmlPreviewPath("preview", "ops"))
+}
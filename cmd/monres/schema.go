@@ -0,0 +1,15 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// printSchema writes the JSON Schema of the config file.
+func printSchema(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(config.JSONSchema())
+}
@@ -0,0 +1,13 @@
+//go:build !no_influxdb
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/sink/influxdb" // Registers the "influxdb" sink type
+)
+
+// Build with -tags no_influxdb to leave the InfluxDB sink out.
+func init() {
+	buildinfo.RegisterFeature("sink_influxdb")
+}
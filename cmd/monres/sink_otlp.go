@@ -0,0 +1,13 @@
+//go:build !no_otlp
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/sink/otlp" // Registers the "otlp" sink type
+)
+
+// Build with -tags no_otlp to leave the OpenTelemetry sink out.
+func init() {
+	buildinfo.RegisterFeature("sink_otlp")
+}
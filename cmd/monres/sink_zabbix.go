@@ -0,0 +1,13 @@
+//go:build !no_zabbix
+
+package main
+
+import (
+	"github.com/mattmezza/monres/internal/buildinfo"
+	_ "github.com/mattmezza/monres/internal/sink/zabbix" // Registers the "zabbix" sink type
+)
+
+// Build with -tags no_zabbix to leave the Zabbix sink out.
+func init() {
+	buildinfo.RegisterFeature("sink_zabbix")
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// testNotificationPartial is the exit code of test-notification when some
+// channels failed; it exits with exitFailure when every channel failed.
+const testNotificationPartial = 3
+
+// channelTest is the result of sending the test notification to a channel.
+type channelTest struct {
+	Channel string `json:"channel"`
+	Sent    bool   `json:"sent"`
+	Error   string `json:"error,omitempty"`
+}
+
+// testAlert describes the alert test-notification sends. Unset fields come
+// from the rule if one is named, else from a sample alert.
+type testAlert struct {
+	rule      string
+	state     string // fired or resolved
+	metric    string
+	condition string
+	severity  string
+	value     *float64
+	threshold *float64
+}
+
+// Sample alert sent by test-notification without a rule.
+var sampleTestRule = config.AlertRuleConfig{
+	Name:        "Test Alert",
+	Metric:      "test_metric",
+	Condition:   ">",
+	Threshold:   40,
+	DurationStr: "1m",
+	Aggregation: "average",
+}
+
+const sampleTestValue = 42.5
+
+func testNotification(configPath string, args []string) {
+	fs := flag.NewFlagSet("test-notification", flag.ExitOnError)
+	output := outputFlag(fs)
+	var alert testAlert
+	fs.StringVar(&alert.rule, "rule", "", "Send the alert of this rule, to its channels unless a channel is given.")
+	fs.StringVar(&alert.state, "state", "fired", "State of the alert: fired or resolved.")
+	fs.StringVar(&alert.metric, "metric", "", "Metric name (default test_metric, or the rule's).")
+	fs.StringVar(&alert.condition, "condition", "", "Condition, e.g. > (default >, or the rule's).")
+	fs.StringVar(&alert.severity, "severity", "", "Severity: info, warning or critical (default critical, or the rule's most severe).")
+	fs.Func("value", "Metric value (default 42.5, or the rule's threshold).", floatFlag(&alert.value))
+	fs.Func("threshold", "Threshold (default 40, or the rule's threshold for the severity).", floatFlag(&alert.threshold))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] test-notification [-rule name] [-state fired|resolved] [-metric name] [-value 42.5] [-threshold 40] [-condition >] [-severity critical] [-output text|json] [channel]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	setOutput(*output)
+	channelName := fs.Arg(0)
+	log.Println("Testing notification channels...")
+
+	cfg := loadConfigOrFail(*output, configPath)
+	registerMetricMetadata(cfg)
+
+	testData, ruleChannels, err := testNotificationData(cfg, alert, time.Now())
+	if err != nil {
+		fail(*output, exitUsage, err)
+	}
+
+	// Check if specific channel exists in config
+	if channelName != "" {
+		found := false
+		var availableChannels []string
+		for _, channel := range cfg.NotificationChannels {
+			availableChannels = append(availableChannels, channel.Name)
+			if channel.Name == channelName {
+				found = true
+			}
+		}
+		if !found {
+			if len(availableChannels) > 0 {
+				fail(*output, exitFailure, fmt.Errorf("channel '%s' not found in configuration. Available channels: %s",
+					channelName, strings.Join(availableChannels, ", ")))
+			} else {
+				fail(*output, exitFailure, fmt.Errorf("channel '%s' not found and no notification channels configured", channelName))
+			}
+		}
+	}
+
+	configuredNotifiers, err := notifier.InitializeNotifiers(cfg.NotificationChannels)
+	if err != nil {
+		fail(*output, exitFailure, fmt.Errorf("failed to initialize notifiers: %w", err))
+	}
+	if len(configuredNotifiers) == 0 {
+		fail(*output, exitFailure, errors.New("no notification channels were successfully initialized"))
+	}
+
+	templates := templatesForConfig(cfg)
+
+	// Test a specific channel, the rule's channels or all channels
+	var names []string
+	switch {
+	case channelName != "":
+		names = []string{channelName}
+	case alert.rule != "":
+		if len(ruleChannels) == 0 {
+			fail(*output, exitFailure, fmt.Errorf("alert rule '%s' has no channels", alert.rule))
+		}
+		names = ruleChannels
+	default:
+		log.Printf("Testing all %d configured notification channels...", len(configuredNotifiers))
+		for name := range configuredNotifiers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	results := make([]channelTest, 0, len(names))
+	successCount := 0
+	for _, name := range names {
+		log.Printf("Testing channel: %s", name)
+		result := channelTest{Channel: name}
+		notifierInstance, ok := configuredNotifiers[name]
+		if !ok {
+			err = fmt.Errorf("channel '%s' was not successfully initialized", name)
+		} else {
+			err = notifierInstance.Send(testData, templates)
+		}
+		if err != nil {
+			log.Printf("❌ Failed to send test notification to channel '%s': %v", name, err)
+			result.Error = err.Error()
+		} else {
+			log.Printf("✅ Test notification sent successfully to channel: %s", name)
+			result.Sent = true
+			successCount++
+		}
+		results = append(results, result)
+	}
+	log.Printf("Test completed: %d/%d channels successful", successCount, len(names))
+	if *output == outputJSON {
+		writeJSON(os.Stdout, results)
+	}
+	switch {
+	case successCount == 0:
+		if *output == outputText {
+			log.Printf("ERROR: All notification channels failed")
+		}
+		os.Exit(exitFailure)
+	case successCount < len(names):
+		os.Exit(testNotificationPartial)
+	}
+}
+
+// floatFlag parses a flag value into *dst, leaving it nil if the flag is
+// not given.
+func floatFlag(dst **float64) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number '%s'", s)
+		}
+		*dst = &v
+		return nil
+	}
+}
+
+// testNotificationData builds the notification of a test alert the way the
+// router does for real alerts, and returns the channels of its rule.
+func testNotificationData(cfg *config.Config, alert testAlert, now time.Time) (notifier.NotificationData, []string, error) {
+	ruleCfg, value := sampleTestRule, sampleTestValue
+	if alert.rule != "" {
+		var ok bool
+		if ruleCfg, ok = findAlertRule(cfg, alert.rule); !ok {
+			return notifier.NotificationData{}, nil, fmt.Errorf("no alert rule named '%s'", alert.rule)
+		}
+	}
+	rule := alerter.NewAlertRule(ruleCfg)
+	if alert.metric != "" {
+		rule.Metric = alert.metric
+	}
+	if alert.condition != "" {
+		rule.Condition = alert.condition
+	}
+
+	tier := rule.Tiers[len(rule.Tiers)-1] // Most severe
+	if alert.severity != "" {
+		if !slices.Contains(config.Severities, alert.severity) {
+			return notifier.NotificationData{}, nil, fmt.Errorf("invalid severity '%s' (valid: %s)", alert.severity, strings.Join(config.Severities, ", "))
+		}
+		tier.Severity = alert.severity
+		for _, t := range rule.Tiers {
+			if t.Severity == alert.severity {
+				tier.Threshold = t.Threshold
+			}
+		}
+	}
+	if alert.threshold != nil {
+		tier.Threshold = *alert.threshold
+	}
+	if alert.rule != "" {
+		value = tier.Threshold
+	}
+	if alert.value != nil {
+		value = *alert.value
+	}
+
+	event := alerter.AlertEvent{
+		Rule:        rule,
+		Severity:    tier.Severity,
+		Threshold:   tier.Threshold,
+		Hostname:    cfg.EffectiveHostname,
+		Timestamp:   now,
+		MetricValue: value,
+		IncidentID:  alerter.IncidentID(cfg.EffectiveHostname, rule.Name, now),
+	}
+	switch strings.ToLower(alert.state) {
+	case "fired":
+		event.Type = alerter.EventTypeFired
+	case "resolved":
+		event.Type = alerter.EventTypeResolved
+	default:
+		return notifier.NotificationData{}, nil, fmt.Errorf("invalid state '%s', use fired or resolved", alert.state)
+	}
+	return alerter.NotificationDataForEvent(event), rule.Channels, nil
+}
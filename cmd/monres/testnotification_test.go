@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func TestTestNotificationData(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+hostname: "vm"
+alerts:
+  - name: "cpu_high"
+    metric: "cpu_percent_total"
+    condition: ">"
+    thresholds: {warning: 80, critical: 95}
+    duration: "5m"
+    aggregation: "average"
+    channels: ["stdout"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	value := 90.0
+
+	data, channels, err := testNotificationData(cfg, testAlert{state: "fired"}, now)
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+	assert.Equal(t, "Test Alert", data.AlertName)
+	assert.Equal(t, "test_metric", data.MetricName)
+	assert.Equal(t, "FIRED", data.State)
+	assert.Equal(t, sampleTestValue, data.MetricValue)
+	assert.Equal(t, 40.0, data.ThresholdValue)
+	assert.Equal(t, "vm", data.Hostname)
+
+	data, channels, err = testNotificationData(cfg, testAlert{rule: "cpu_high", state: "fired"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stdout"}, channels)
+	assert.Equal(t, "cpu_percent_total", data.MetricName)
+	assert.Equal(t, "critical", data.Severity)
+	assert.Equal(t, 95.0, data.ThresholdValue)
+	assert.Equal(t, 95.0, data.MetricValue)
+
+	data, _, err = testNotificationData(cfg, testAlert{rule: "cpu_high", state: "RESOLVED", severity: "warning", value: &value}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "RESOLVED", data.State)
+	assert.Equal(t, "warning", data.Severity)
+	assert.Equal(t, 80.0, data.ThresholdValue)
+	assert.Equal(t, 90.0, data.MetricValue)
+
+	data, _, err = testNotificationData(cfg, testAlert{state: "fired", metric: "mem_percent_used", condition: "<", threshold: &value}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "mem_percent_used", data.MetricName)
+	assert.Equal(t, "<", data.Condition)
+	assert.Equal(t, 90.0, data.ThresholdValue)
+
+	_, _, err = testNotificationData(cfg, testAlert{rule: "cpu_low", state: "fired"}, now)
+	assert.ErrorContains(t, err, "no alert rule named 'cpu_low'")
+	_, _, err = testNotificationData(cfg, testAlert{state: "pending"}, now)
+	assert.ErrorContains(t, err, "invalid state 'pending'")
+	_, _, err = testNotificationData(cfg, testAlert{state: "fired", severity: "fatal"}, now)
+	assert.ErrorContains(t, err, "invalid severity 'fatal'")
+}
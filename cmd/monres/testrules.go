@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/replay"
+)
+
+// testRules replays a file of recorded metric samples through the alerter and
+// prints which rules would have fired, when, and what would have been sent.
+func testRules(configPath string, args []string) {
+	fs := flag.NewFlagSet("test-rules", flag.ExitOnError)
+	verbose := fs.Bool("verbose", false, "Show alerter log output during the replay.")
+	output := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] test-rules [-verbose] [-output text|json] <samples.csv|samples.json|trace.jsonl>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+	setOutput(*output)
+
+	cfg := loadConfigOrFail(*output, configPath)
+	registerMetricMetadata(cfg)
+
+	cycles, err := replay.LoadCycles(fs.Arg(0))
+	if err != nil {
+		fail(*output, exitFailure, err)
+	}
+	if len(cycles) == 0 {
+		fail(*output, exitFailure, fmt.Errorf("no samples found in %s", fs.Arg(0)))
+	}
+
+	logOutput := log.Writer()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	notifications, err := replay.Evaluate(cfg, cycles)
+	log.SetOutput(logOutput)
+	if err != nil {
+		fail(*output, exitFailure, fmt.Errorf("replay failed: %w", err))
+	}
+
+	if *output == outputJSON {
+		writeJSON(os.Stdout, newReplayReport(cfg, cycles, notifications))
+		return
+	}
+	printReplayReport(os.Stdout, cfg, cycles, notifications)
+}
+
+// replayReport is the JSON output of test-rules.
+type replayReport struct {
+	Start   time.Time          `json:"start"`
+	End     time.Time          `json:"end"`
+	Cycles  int                `json:"cycles"`
+	Events  []replayEvent      `json:"events"`
+	Summary []replayRuleResult `json:"summary"`
+}
+
+// replayEvent is an alert event of the replay, with what each channel got.
+type replayEvent struct {
+	Time          time.Time            `json:"time"`
+	Alert         string               `json:"alert"`
+	State         string               `json:"state"`
+	Severity      string               `json:"severity,omitempty"`
+	Metric        string               `json:"metric"`
+	Condition     string               `json:"condition"`
+	Threshold     float64              `json:"threshold"`
+	Value         float64              `json:"value"`
+	Notifications []replayNotification `json:"notifications"`
+}
+
+type replayNotification struct {
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+// replayRuleResult sums up the replay of one rule.
+type replayRuleResult struct {
+	Rule        string `json:"rule"`
+	Fired       int    `json:"fired"`
+	Resolved    int    `json:"resolved"`
+	FiringAtEnd bool   `json:"firing_at_end"`
+}
+
+func newReplayReport(cfg *config.Config, cycles []replay.Cycle, notifications []replay.Notification) replayReport {
+	start, end := replay.Span(cycles)
+	report := replayReport{Start: start, End: end, Cycles: len(cycles), Events: []replayEvent{}, Summary: []replayRuleResult{}}
+	results := make(map[string]*replayRuleResult)
+	var order []string // Configured rules, then the rules instantiated from templates
+	for _, rule := range cfg.Alerts {
+		if !rule.IsTemplate() {
+			results[rule.Name] = &replayRuleResult{Rule: rule.Name}
+			order = append(order, rule.Name)
+		}
+	}
+
+	var lastEvent string
+	for _, n := range notifications {
+		eventKey := n.Data.AlertName + "|" + n.Data.State + "|" + n.Data.Time.String()
+		if eventKey != lastEvent {
+			lastEvent = eventKey
+			report.Events = append(report.Events, replayEvent{
+				Time: n.Data.Time, Alert: n.Data.AlertName, State: n.Data.State, Severity: n.Data.Severity,
+				Metric: n.Data.MetricName, Condition: n.Data.Condition, Threshold: n.Data.ThresholdValue, Value: n.Data.MetricValue,
+			})
+			r, ok := results[n.Data.AlertName]
+			if !ok {
+				r = &replayRuleResult{Rule: n.Data.AlertName}
+				results[n.Data.AlertName] = r
+				order = append(order, n.Data.AlertName)
+			}
+			if n.Data.State == "RESOLVED" {
+				r.Resolved++
+			} else {
+				r.Fired++
+			}
+		}
+		e := &report.Events[len(report.Events)-1]
+		e.Notifications = append(e.Notifications, replayNotification{Channel: n.Channel, Message: n.Message})
+	}
+	for _, name := range order {
+		r := results[name]
+		r.FiringAtEnd = r.Fired > r.Resolved
+		report.Summary = append(report.Summary, *r)
+	}
+	return report
+}
+
+func printReplayReport(w io.Writer, cfg *config.Config, cycles []replay.Cycle, notifications []replay.Notification) {
+	report := newReplayReport(cfg, cycles, notifications)
+	fmt.Fprintf(w, "Replayed %d cycles from %s to %s (%s) against %d rule(s).\n\n",
+		report.Cycles, report.Start.Format(time.RFC3339), report.End.Format(time.RFC3339), report.End.Sub(report.Start), len(cfg.Alerts))
+
+	for _, e := range report.Events {
+		fmt.Fprintf(w, "%s  %-8s  %s  (%s %s %s, value %s)\n",
+			e.Time.Format(time.RFC3339), e.State, e.Alert,
+			e.Metric, e.Condition, notifier.FormatValue(e.Metric, e.Threshold), notifier.FormatValue(e.Metric, e.Value))
+		for _, n := range e.Notifications {
+			fmt.Fprintf(w, "    [%s]\n", n.Channel)
+			for _, line := range strings.Split(strings.TrimRight(n.Message, "\n"), "\n") {
+				fmt.Fprintf(w, "      %s\n", line)
+			}
+		}
+	}
+	if len(report.Events) == 0 {
+		fmt.Fprintln(w, "No notifications would have been sent.")
+	}
+
+	fmt.Fprintln(w, "\nSummary:")
+	for _, r := range report.Summary {
+		switch {
+		case r.Fired == 0:
+			fmt.Fprintf(w, "  %s: never fired\n", r.Rule)
+		case r.FiringAtEnd:
+			fmt.Fprintf(w, "  %s: fired %d time(s), resolved %d time(s), still firing at end of replay\n", r.Rule, r.Fired, r.Resolved)
+		default:
+			fmt.Fprintf(w, "  %s: fired %d time(s), resolved %d time(s)\n", r.Rule, r.Fired, r.Resolved)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/replay"
+)
+
+func TestReplayReport(t *testing.T) {
+	cfg := &config.Config{Alerts: []config.AlertRuleConfig{{Name: "cpu"}, {Name: "mem"}}}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cycles := []replay.Cycle{
+		{Time: start, Metrics: collector.CollectedMetrics{"cpu_percent_total": 10}},
+		{Time: start.Add(time.Minute), Metrics: collector.CollectedMetrics{"cpu_percent_total": 99}},
+	}
+	fired := notifier.NotificationData{AlertName: "cpu", State: "FIRED", Severity: "critical", MetricName: "cpu_percent_total",
+		Condition: ">", ThresholdValue: 90, MetricValue: 99, Time: start.Add(time.Minute)}
+	notifications := []replay.Notification{
+		{Channel: "email", Data: fired, Message: "cpu fired"},
+		{Channel: "telegram", Data: fired, Message: "cpu fired"},
+	}
+
+	report := newReplayReport(cfg, cycles, notifications)
+	assert.Equal(t, 2, report.Cycles)
+	require.Len(t, report.Events, 1) // One event sent to two channels
+	assert.Equal(t, []replayNotification{{"email", "cpu fired"}, {"telegram", "cpu fired"}}, report.Events[0].Notifications)
+	assert.Equal(t, []replayRuleResult{{Rule: "cpu", Fired: 1, FiringAtEnd: true}, {Rule: "mem"}}, report.Summary)
+
+	var out bytes.Buffer
+	printReplayReport(&out, cfg, cycles, notifications)
+	assert.Contains(t, out.String(), "2024-01-01T00:01:00Z  FIRED     cpu  (cpu_percent_total > 90.0%, value 99.0%)\n    [email]\n      cpu fired\n    [telegram]")
+	assert.Contains(t, out.String(), "  cpu: fired 1 time(s), resolved 0 time(s), still firing at end of replay\n  mem: never fired\n")
+}
@@ -0,0 +1,21 @@
+//go:build !no_textfile
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Build with -tags no_textfile to leave the textfile collector out.
+func init() {
+	buildinfo.RegisterFeature("textfile")
+}
+
+func addTextfileCollector(gc *collector.GlobalCollector, cfg *config.Config) {
+	gc.AddCollector(collector.NewTextfileCollector(cfg.Textfile.Directory, cfg.Textfile.MaxAge))
+	log.Printf("Textfile collector enabled. Directory: %s, max age: %s", cfg.Textfile.Directory, cfg.Textfile.MaxAge)
+}
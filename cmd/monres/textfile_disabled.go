@@ -0,0 +1,14 @@
+//go:build no_textfile
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func addTextfileCollector(_ *collector.GlobalCollector, _ *config.Config) {
+	log.Println("Warning: textfile is configured, but this build does not include the textfile collector (built with -tags no_textfile).")
+}
@@ -0,0 +1,234 @@
+//go:build !no_api
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattmezza/monres/internal/api"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// Terminal control sequences used by top.
+const (
+	termAltScreen  = "\x1b[?1049h\x1b[?25l" // Switch to the alternate screen, hide the cursor
+	termMainScreen = "\x1b[?25h\x1b[?1049l" // Show the cursor, back to the main screen
+	termClear      = "\x1b[H\x1b[2J"
+	termReset      = "\x1b[0m"
+	termDefault    = "\x1b[39m" // Default color, as long as the others
+	termRed        = "\x1b[31m"
+	termGreen      = "\x1b[32m"
+	termYellow     = "\x1b[33m"
+)
+
+// sparklineWidth is the number of characters of top's sparklines.
+const sparklineWidth = 40
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// topView is what top displays: the daemon status and the recent history of
+// the metrics shown.
+type topView struct {
+	status *api.Status
+	series map[string][]api.MetricPoint
+	err    error // Failure of the last refresh, shown instead of the view
+}
+
+// top shows a live view of a running daemon, queried through its API: the
+// alert states and the current value and recent history of every metric.
+func top(configPath string, args []string) {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	interval := fs.Duration("interval", 2*time.Second, "Refresh interval.")
+	window := fs.Duration("window", 10*time.Minute, "Period covered by the sparklines.")
+	metricsFlag := fs.String("metrics", "", "Comma-separated metrics to show; a name ending in * is a prefix. Empty shows all.")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] top [-interval 2s] [-window 10m] [-metrics cpu_*,mem_*]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *interval <= 0 || *window <= 0 {
+		log.Fatalf("ERROR: -interval and -window must be positive")
+	}
+	var patterns []string
+	if *metricsFlag != "" {
+		patterns = strings.Split(*metricsFlag, ",")
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to load configuration from %s: %v", configPath, err)
+	}
+	if cfg.API.Listen == "" {
+		log.Fatalf("ERROR: The top command needs the API, but api.listen is not set in %s", configPath)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	fmt.Print(termAltScreen)
+	defer fmt.Print(termMainScreen)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		view := fetchTopView(cfg.API.Listen, *window, patterns)
+		var buf bytes.Buffer
+		buf.WriteString(termClear)
+		renderTop(&buf, view, time.Now())
+		os.Stdout.Write(buf.Bytes())
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchTopView queries the status and the history of the metrics matching
+// patterns over window.
+func fetchTopView(addr string, window time.Duration, patterns []string) topView {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	st, err := api.FetchStatus(ctx, addr)
+	if err != nil {
+		return topView{err: err}
+	}
+	view := topView{status: st, series: make(map[string][]api.MetricPoint)}
+	since := time.Now().Add(-window)
+	for name := range st.Metrics {
+		if !matchesMetric(name, patterns) {
+			continue
+		}
+		points, err := api.FetchMetric(ctx, addr, name, since)
+		if err != nil {
+			return topView{err: err}
+		}
+		view.series[name] = points
+	}
+	return view
+}
+
+// matchesMetric reports whether name matches one of patterns, names or
+// prefixes followed by "*". No patterns match every metric.
+func matchesMetric(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// renderTop writes one screen of the live view.
+func renderTop(w io.Writer, view topView, now time.Time) {
+	if view.err != nil {
+		fmt.Fprintf(w, "%s  %s%v%s\n\nRetrying; press Ctrl-C to quit.\n", now.Format("15:04:05"), termRed, view.err, termReset)
+		return
+	}
+	st := view.status
+	fmt.Fprintf(w, "monres %s on %s, up %s  %s  (Ctrl-C to quit)\n\n",
+		st.Version, st.Hostname, now.Sub(st.Started).Round(time.Second), now.Format("15:04:05"))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(st.Rules) == 0 {
+		fmt.Fprintln(tw, "No alert rules configured.")
+	} else {
+		fmt.Fprintln(tw, termDefault+"STATE"+termReset+"\tALERT\tMETRIC\tVALUE\tSEVERITY")
+		for _, rule := range st.Rules {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", stateColor(rule.State), rule.Name, rule.Metric,
+				notifier.FormatValue(rule.Metric, rule.LastValue), rule.Severity)
+		}
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	names := make([]string, 0, len(view.series))
+	for name := range view.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(tw, "METRIC\tVALUE\tHISTORY\tMIN\tMAX")
+	for _, name := range names {
+		points := view.series[name]
+		if len(points) == 0 {
+			continue
+		}
+		lo, hi := points[0].Min, points[0].Max
+		values := make([]float64, len(points))
+		for i, p := range points {
+			values[i] = p.Value
+			lo, hi = min(lo, p.Min), max(hi, p.Max)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, notifier.FormatValue(name, values[len(values)-1]),
+			sparkline(values, sparklineWidth), notifier.FormatValue(name, lo), notifier.FormatValue(name, hi))
+	}
+	tw.Flush()
+}
+
+// stateColor returns a rule state colored for the terminal. Every state, and
+// the column header, has escape sequences of the same length, so tabwriter
+// still aligns the columns.
+func stateColor(state string) string {
+	color := termGreen
+	switch state {
+	case api.StateFiring:
+		color = termRed
+	case api.StateWaiting:
+		color = termYellow
+	}
+	return color + strings.ToUpper(state) + termReset
+}
+
+// sparkline draws values as a line of block characters at most width long,
+// averaging neighbouring values when there are more than width, scaled
+// between their minimum and maximum.
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			from, to := i*len(values)/width, (i+1)*len(values)/width
+			var sum float64
+			for _, v := range values[from:to] {
+				sum += v
+			}
+			buckets[i] = sum / float64(to-from)
+		}
+		values = buckets
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		sb.WriteRune(sparkBlocks[level])
+	}
+	return sb.String()
+}
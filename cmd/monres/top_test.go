@@ -0,0 +1,64 @@
+//go:build !no_api
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattmezza/monres/internal/api"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▂▃▄▅▆▇█", sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}, 10))
+	assert.Equal(t, "▁▁▁", sparkline([]float64{5, 5, 5}, 10))
+	assert.Equal(t, "▁█", sparkline([]float64{0, 0, 10, 10}, 2)) // Averaged
+	assert.Equal(t, "", sparkline(nil, 10))
+}
+
+func TestMatchesMetric(t *testing.T) {
+	assert.True(t, matchesMetric("cpu_percent_total", nil))
+	assert.True(t, matchesMetric("cpu_percent_total", []string{"mem_percent_used", " cpu_*"}))
+	assert.True(t, matchesMetric("mem_percent_used", []string{"mem_percent_used"}))
+	assert.False(t, matchesMetric("mem_percent_free", []string{"mem_percent_used", "cpu_*"}))
+}
+
+func TestRenderTop(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	view := topView{
+		status: &api.Status{
+			Hostname: "web-1",
+			Version:  "1.2.0",
+			Started:  start,
+			Rules: []api.RuleStatus{
+				{Name: "High CPU", Metric: "cpu_percent_total", State: api.StateFiring, Severity: "critical", LastValue: 95},
+				{Name: "Memory", Metric: "mem_percent_used", State: api.StateOK, LastValue: 40},
+			},
+		},
+		series: map[string][]api.MetricPoint{
+			"cpu_percent_total": {
+				{Time: start, Value: 10, Min: 10, Max: 10, Samples: 1},
+				{Time: start.Add(time.Minute), Value: 50, Min: 5, Max: 60, Samples: 6},
+				{Time: start.Add(2 * time.Minute), Value: 95, Min: 95, Max: 95, Samples: 1},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	renderTop(&buf, view, start.Add(time.Hour))
+	assert.Equal(t, "monres 1.2.0 on web-1, up 1h0m0s  01:00:00  (Ctrl-C to quit)\n\n"+
+		"\x1b[39mSTATE\x1b[0m   ALERT     METRIC             VALUE  SEVERITY\n"+
+		"\x1b[31mFIRING\x1b[0m  High CPU  cpu_percent_total  95.0%  critical\n"+
+		"\x1b[32mOK\x1b[0m      Memory    mem_percent_used   40.0%  \n"+
+		"\n"+
+		"METRIC             VALUE  HISTORY  MIN   MAX\n"+
+		"cpu_percent_total  95.0%  ▁▄█      5.0%  95.0%\n", buf.String())
+
+	buf.Reset()
+	renderTop(&buf, topView{err: errors.New("connection refused")}, start)
+	assert.Contains(t, buf.String(), "connection refused")
+}
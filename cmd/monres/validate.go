@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// validateTemplateError is the exit code of validate when the configuration
+// loads but its templates don't render. Unloadable files exit with exitFailure.
+const validateTemplateError = 2
+
+// validation is the result of validate.
+type validation struct {
+	Config         string   `json:"config"`
+	Valid          bool     `json:"valid"`
+	AlertRules     int      `json:"alert_rules"`
+	Channels       int      `json:"notification_channels"`
+	ConfigError    string   `json:"config_error,omitempty"`
+	TemplateErrors []string `json:"template_errors,omitempty"`
+}
+
+// exitCode returns the exit code of validate for v.
+func (v validation) exitCode() int {
+	switch {
+	case v.ConfigError != "":
+		return exitFailure
+	case len(v.TemplateErrors) > 0:
+		return validateTemplateError
+	}
+	return exitOK
+}
+
+// validate checks the configuration file, including that its templates
+// render, and exits non-zero on errors.
+func validate(configPath string, args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	output := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres [-config file] validate [-output text|json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setOutput(*output)
+
+	v := validateConfig(configPath)
+	if *output == outputJSON {
+		writeJSON(os.Stdout, v)
+	} else {
+		printValidation(os.Stdout, v)
+	}
+	os.Exit(v.exitCode())
+}
+
+// validateConfig loads configPath and renders its templates.
+func validateConfig(configPath string) validation {
+	v := validation{Config: configPath}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		v.ConfigError = err.Error()
+		return v
+	}
+	registerMetricMetadata(cfg)
+	v.AlertRules, v.Channels = len(cfg.Alerts), len(cfg.NotificationChannels)
+
+	for _, err := range notifier.LintTemplates(templatesForConfig(cfg)) {
+		v.TemplateErrors = append(v.TemplateErrors, err.Error())
+	}
+	v.Valid = len(v.TemplateErrors) == 0
+	return v
+}
+
+// printValidation reports v to w, one line per error.
+func printValidation(w io.Writer, v validation) {
+	if v.ConfigError != "" {
+		fmt.Fprintf(w, "%s: %s\n", v.Config, v.ConfigError)
+	}
+	for _, err := range v.TemplateErrors {
+		fmt.Fprintf(w, "%s: %s\n", v.Config, err)
+	}
+	if v.Valid {
+		fmt.Fprintf(w, "%s: OK (%d alert rule(s), %d notification channel(s))\n", v.Config, v.AlertRules, v.Channels)
+	}
+}
+
+func templatesForConfig(cfg *config.Config) notifier.NotificationTemplates {
+	return notifier.NotificationTemplates{
+		FiredTemplate:    cfg.Templates.AlertFired,
+		ResolvedTemplate: cfg.Templates.AlertResolved,
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(fired string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+templates:
+  alert_fired: "`+fired+`"
+`), 0644))
+	}
+
+	var out bytes.Buffer
+	write("{{ .AlertName }} fired")
+	v := validateConfig(configFile)
+	assert.Equal(t, exitOK, v.exitCode())
+	printValidation(&out, v)
+	assert.Contains(t, out.String(), "OK (0 alert rule(s), 1 notification channel(s))")
+
+	out.Reset()
+	write("{{ .AlertNmae }} fired")
+	v = validateConfig(configFile)
+	assert.False(t, v.Valid)
+	assert.Equal(t, validateTemplateError, v.exitCode())
+	printValidation(&out, v)
+	assert.Contains(t, out.String(), "alert_fired:1:3")
+	assert.Contains(t, out.String(), "AlertNmae")
+
+	out.Reset()
+	require.NoError(t, writeJSON(&out, v))
+	assert.Contains(t, out.String(), `"valid": false`)
+	assert.Contains(t, out.String(), `"template_errors": [`)
+
+	v = validateConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.False(t, v.Valid)
+	assert.NotEmpty(t, v.ConfigError)
+	assert.Equal(t, exitFailure, v.exitCode())
+}
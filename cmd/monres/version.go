@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/mattmezza/monres/internal/buildinfo"
+)
+
+// version prints the version and the optional features of this binary.
+func version(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	output := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: monres version [-output text|json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setOutput(*output)
+
+	if *output == outputJSON {
+		writeJSON(os.Stdout, newVersionInfo())
+		return
+	}
+	printVersion(os.Stdout)
+}
+
+// versionInfo is the JSON output of version.
+type versionInfo struct {
+	Version   string   `json:"version"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+	Features  []string `json:"features"`
+}
+
+func newVersionInfo() versionInfo {
+	return versionInfo{
+		Version:   buildinfo.GetVersion(),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Features:  buildinfo.Features(),
+	}
+}
+
+// printVersion writes the version line followed by the optional features
+// compiled into this binary.
+func printVersion(w io.Writer) {
+	fmt.Fprintln(w, buildinfo.String())
+	features := buildinfo.Features()
+	if len(features) == 0 {
+		fmt.Fprintln(w, "features: none")
+		return
+	}
+	fmt.Fprintf(w, "features: %s\n", strings.Join(features, " "))
+}
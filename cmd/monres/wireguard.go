@@ -0,0 +1,21 @@
+//go:build !no_wireguard
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Build with -tags no_wireguard to leave the WireGuard peer collector out.
+func init() {
+	buildinfo.RegisterFeature("wireguard")
+}
+
+func addWireGuardCollector(gc *collector.GlobalCollector, cfg *config.Config) {
+	gc.AddCollector(collector.NewWireGuardCollector(cfg.WireGuard.Peers))
+	log.Printf("WireGuard collector enabled with %d named peer(s).", len(cfg.WireGuard.Peers))
+}
@@ -0,0 +1,14 @@
+//go:build no_wireguard
+
+package main
+
+import (
+	"log"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func addWireGuardCollector(_ *collector.GlobalCollector, _ *config.Config) {
+	log.Println("Warning: wireguard is enabled, but this build does not include the WireGuard collector (built with -tags no_wireguard).")
+}
@@ -0,0 +1,76 @@
+package alerter
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// dueActions appends an ACTION event for every action of a firing rule that
+// has become due and was not yet run during this firing. Acknowledged alerts
+// are left to whoever acknowledged them. Callers hold a.mu.
+func (a *Alerter) dueActions(events []AlertEvent, rule *AlertRule, now time.Time) []AlertEvent {
+	if len(rule.Actions) == 0 || !rule.State.AckedAt.IsZero() {
+		return events
+	}
+	if len(rule.State.ActionsDone) != len(rule.Actions) {
+		// New rule, or the actions changed on reload
+		rule.State.ActionsDone = make([]bool, len(rule.Actions))
+		rule.State.ActionRuns = make([][]time.Time, len(rule.Actions))
+	}
+	for i := range rule.Actions {
+		action := &rule.Actions[i]
+		if rule.State.ActionsDone[i] || now.Sub(rule.State.LastActiveTime) < action.After {
+			continue
+		}
+		rule.State.ActionsDone[i] = true
+
+		runs := rule.State.ActionRuns[i]
+		for len(runs) > 0 && now.Sub(runs[0]) >= time.Hour {
+			runs = runs[1:]
+		}
+		limited := len(runs) >= action.MaxPerHour
+		if !limited {
+			runs = append(runs, now)
+		}
+		rule.State.ActionRuns[i] = runs
+
+		tier, _ := rule.Tier(rule.State.Severity)
+		events = append(events, AlertEvent{
+			Rule:          rule,
+			Type:          EventTypeAction,
+			Severity:      tier.Severity,
+			Threshold:     tier.Threshold,
+			Hostname:      a.hostname,
+			Timestamp:     now,
+			MetricValue:   rule.State.LastValue,
+			Action:        action,
+			ActionLimited: limited,
+			IncidentID:    rule.State.IncidentID,
+		})
+	}
+	return events
+}
+
+// runAction runs the command of an action and reports its outcome.
+func runAction(name string, command []string, timeout time.Duration) notifier.ActionResult {
+	log.Printf("Running action '%s': %v", name, command)
+	output, errText := runCommand(command, timeout)
+	result := notifier.ActionResult{Name: name, Status: notifier.ActionSucceeded, Output: output}
+	if errText != "" {
+		result.Status, result.Error = notifier.ActionFailed, errText
+		log.Printf("Action '%s' failed: %s", name, errText)
+	} else {
+		log.Printf("Action '%s' succeeded.", name)
+	}
+	return result
+}
+
+// skippedAction reports an action that was not run.
+func skippedAction(name, format string, args ...any) notifier.ActionResult {
+	reason := fmt.Sprintf(format, args...)
+	log.Printf("Action '%s' skipped: %s", name, reason)
+	return notifier.ActionResult{Name: name, Status: notifier.ActionSkipped, Error: reason}
+}
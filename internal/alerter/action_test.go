@@ -0,0 +1,128 @@
+package alerter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func TestDueActions(t *testing.T) {
+	cfg := &config.Config{
+		Alerts: []config.AlertRuleConfig{{
+			Name:      "nginx down",
+			Metric:    "check_nginx_status",
+			Condition: ">",
+			Threshold: 0,
+			Actions: []config.AlertActionConfig{
+				{Name: "restart nginx", Command: []string{"systemctl", "restart", "nginx"}, After: 2 * time.Minute, MaxPerHour: 1},
+			},
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	step := func(minute int, v float64) {
+		at := now.Add(time.Duration(minute) * time.Minute)
+		hist.AddDataPoint("check_nginx_status", v, at)
+		a.CheckAndNotify(at, nil)
+	}
+	step(0, 2)
+	step(1, 2)
+	step(2, 2) // Due
+	step(3, 2) // Once per firing
+	step(4, 0)
+	step(5, 2)
+	step(7, 2) // Due again, but it ran less than an hour ago
+	step(8, 0)
+	step(70, 2)
+	step(72, 2) // The previous run is more than an hour old
+	a.Close()
+
+	var got []string
+	for e := range events {
+		s := string(e.Type)
+		if e.Type == EventTypeAction {
+			s += " " + e.Action.Name
+			if e.ActionLimited {
+				s += " (limited)"
+			}
+		}
+		got = append(got, s)
+	}
+	assert.Equal(t, []string{
+		"FIRED", "ACTION restart nginx", "RESOLVED",
+		"FIRED", "ACTION restart nginx (limited)", "RESOLVED",
+		"FIRED", "ACTION restart nginx",
+	}, got)
+}
+
+func TestDueActionsSkipsAcknowledged(t *testing.T) {
+	cfg := &config.Config{
+		Alerts: []config.AlertRuleConfig{{
+			Name:      "nginx down",
+			Metric:    "check_nginx_status",
+			Condition: ">",
+			Threshold: 0,
+			Actions:   []config.AlertActionConfig{{Name: "restart nginx", Command: []string{"true"}, After: time.Minute, MaxPerHour: 1}},
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	hist.AddDataPoint("check_nginx_status", 2, now)
+	a.CheckAndNotify(now, nil)
+	require.NoError(t, a.Acknowledge("nginx down", "ops", "on it", now))
+	hist.AddDataPoint("check_nginx_status", 2, now.Add(2*time.Minute))
+	a.CheckAndNotify(now.Add(2*time.Minute), nil)
+	a.Close()
+
+	var got []EventType
+	for e := range events {
+		got = append(got, e.Type)
+	}
+	assert.Equal(t, []EventType{EventTypeFired}, got)
+}
+
+func TestRouterActions(t *testing.T) {
+	var results []notifier.ActionResult
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{{Name: "chat", Type: "stdout"}}}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"chat": notifierFunc(func(d notifier.NotificationData) {
+		assert.Equal(t, "ACTION", d.State)
+		results = append(results, *d.Action)
+	})})
+
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "nginx down", Channels: []string{"chat"}})
+	event := func(command []string, limited bool) AlertEvent {
+		action := &config.AlertActionConfig{Name: "fix", Command: command, Timeout: time.Second, MaxPerHour: 1}
+		return AlertEvent{Rule: rule, Type: EventTypeAction, Action: action, ActionLimited: limited}
+	}
+	router.Dispatch(event([]string{"echo", "restarted"}, false))
+	router.EnableActions()
+	for _, e := range []AlertEvent{
+		event([]string{"echo", "restarted"}, false),
+		event([]string{"sh", "-c", "echo no such unit >&2; exit 5"}, false),
+		event([]string{"echo", "restarted"}, true),
+	} {
+		router.Dispatch(e)
+		router.WaitActions()
+	}
+
+	assert.Equal(t, []notifier.ActionResult{
+		{Name: "fix", Status: notifier.ActionSkipped, Error: "actions are disabled"},
+		{Name: "fix", Status: notifier.ActionSucceeded, Output: "restarted"},
+		{Name: "fix", Status: notifier.ActionFailed, Output: "no such unit", Error: "exit status 5"},
+		{Name: "fix", Status: notifier.ActionSkipped, Error: "max_per_hour (1) reached"},
+	}, results)
+}
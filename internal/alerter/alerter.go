@@ -1,14 +1,17 @@
 package alerter
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mattmezza/monres/internal/collector"
 	"github.com/mattmezza/monres/internal/config"
 	"github.com/mattmezza/monres/internal/history"
-	"github.com/mattmezza/monres/internal/notifier"
 	"github.com/mattmezza/monres/internal/state"
 )
 
@@ -17,38 +20,56 @@ type EventType string
 const (
 	EventTypeFired    EventType = "FIRED"
 	EventTypeResolved EventType = "RESOLVED"
+	EventTypeAction   EventType = "ACTION" // A remediation action of a firing alert is due
 )
 
+// AlertEvent is a state change of an alert rule, published to subscribers of the Alerter.
+// Rule's configuration may be read by subscribers; its State belongs to the Alerter.
 type AlertEvent struct {
 	Rule          *AlertRule
 	Type          EventType
+	Severity      string  // Severity of the tier that fired, or that was active before resolving
+	Threshold     float64 // Threshold of that tier
+	Stale         bool    // RESOLVED because the metric stopped reporting (auto_resolve_after)
+	AtStartup     bool    // FIRED at the rule's first evaluation, announced as such (startup_mode: announce)
 	Hostname      string
 	Timestamp     time.Time
 	MetricValue   float64 // The value that caused the state change
+	Metrics       map[string]float64 // Latest value of every metric when the event happened; read-only
 	TriggeringPoints []history.DataPoint // Optional: points that led to this state
+	Action        *config.AlertActionConfig // The action due, for ACTION events
+	ActionLimited bool    // The action is not to be run, as it reached max_per_hour
+	IncidentID    string  // The firing the event belongs to, shared by its FIRED, ACTION and RESOLVED events
 }
 
+// Alerter evaluates alert rules against the metric history and publishes
+// state changes as AlertEvents. It does not deliver notifications itself;
+// that is the job of subscribers such as the Router.
 type Alerter struct {
 	rules         []*AlertRule
+	templates     []config.AlertRuleConfig // Templated rules, instantiated into rules for every entity discovered
+	instances     map[string]bool          // Names of the rules instantiated from templates
+	evaluated     bool                     // Rules have been evaluated at least once
 	historyBuffer *history.MetricHistoryBuffer
-	notifiers     map[string]notifier.Notifier // map channel name to notifier instance
-	templates     notifier.NotificationTemplates
 	hostname      string
-	mu            sync.Mutex // Protects rules' states
+	interval      time.Duration // Collection interval, the startup wait of rules without a duration
+	subscribers   []chan AlertEvent
+	mu            sync.Mutex // Protects rules' states and subscribers
 }
 
-func NewAlerter(cfg *config.Config, histBuffer *history.MetricHistoryBuffer, configuredNotifiers map[string]notifier.Notifier) (*Alerter, error) {
+func NewAlerter(cfg *config.Config, histBuffer *history.MetricHistoryBuffer) (*Alerter, error) {
 	a := &Alerter{
 		historyBuffer: histBuffer,
-		notifiers:     configuredNotifiers,
 		hostname:      cfg.EffectiveHostname,
-		templates: notifier.NotificationTemplates{
-			FiredTemplate:    cfg.Templates.AlertFired,
-			ResolvedTemplate: cfg.Templates.AlertResolved,
-		},
+		interval:      cfg.CollectionInterval,
+		instances:     make(map[string]bool),
 	}
 
 	for _, ruleCfg := range cfg.Alerts {
+		if ruleCfg.IsTemplate() {
+			a.templates = append(a.templates, ruleCfg)
+			continue
+		}
 		rule := NewAlertRule(ruleCfg)
 		a.rules = append(a.rules, rule)
 	}
@@ -56,121 +77,451 @@ func NewAlerter(cfg *config.Config, histBuffer *history.MetricHistoryBuffer, con
 	return a, nil
 }
 
-// CheckAndNotify evaluates all rules and sends notifications if state changes.
-func (a *Alerter) CheckAndNotify(now time.Time, currentMetrics collector.CollectedMetrics) {
+// UpdateRules replaces the rules after a configuration reload. A rule keeps
+// its state if a rule with the same name existed before.
+func (a *Alerter) UpdateRules(cfg *config.Config) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	var events []AlertEvent
+	old := a.rules
+	previous := make(map[string]*AlertRule, len(old))
+	for _, rule := range old {
+		previous[rule.Name] = rule
+	}
+	keepState := func(rule *AlertRule) {
+		if prev, ok := previous[rule.Name]; ok {
+			rule.State = prev.State
+			delete(previous, rule.Name)
+		} else {
+			// Rules added by a reload don't fire "at startup"
+			rule.State.Evaluated = a.evaluated
+		}
+		a.rules = append(a.rules, rule)
+	}
+	a.rules, a.templates, a.instances = nil, nil, make(map[string]bool)
+	for _, ruleCfg := range cfg.Alerts {
+		if ruleCfg.IsTemplate() {
+			a.templates = append(a.templates, ruleCfg)
+			continue
+		}
+		keepState(NewAlertRule(ruleCfg))
+	}
+	// Instantiate the entities discovered before again, so their alerts keep their state
+	for _, rule := range old {
+		if rule.Template == "" {
+			continue
+		}
+		for _, tmpl := range a.templates {
+			if _, ok := tmpl.MatchEntity(rule.Metric); ok && tmpl.Name == rule.Template {
+				a.instances[tmpl.InstanceName(rule.Entity)] = true
+				keepState(NewAlertRule(tmpl.Instantiate(rule.Entity)))
+			}
+		}
+	}
+	for name, rule := range previous {
+		if rule.State.IsActive {
+			log.Printf("Warning: Rule '%s' was removed while firing; it will not send a RESOLVED notification.", name)
+		}
+	}
+	a.hostname = cfg.EffectiveHostname
+	a.interval = cfg.CollectionInterval
+}
 
+// Acknowledge marks the active alert of a rule as acknowledged until it
+// resolves. Acknowledged alerts don't notify severity changes.
+func (a *Alerter) Acknowledge(name, by, comment string, now time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	for _, rule := range a.rules {
+		if rule.Name != name {
+			continue
+		}
+		if !rule.State.IsActive {
+			return fmt.Errorf("alert '%s' is not firing", name)
+		}
+		rule.State.AckedBy = by
+		rule.State.AckedAt = now
+		rule.State.AckComment = comment
+		log.Printf("ALERT ACKNOWLEDGED: %s by %s", name, by)
+		return nil
+	}
+	return fmt.Errorf("%w: '%s'", ErrUnknownRule, name)
+}
+
+// ErrUnknownRule is returned for operations on a rule that is not configured.
+var ErrUnknownRule = errors.New("no such alert rule")
+
+// Subscribe returns a channel receiving every AlertEvent published from now on.
+// Publishing blocks once buffer events are pending, so subscribers must keep
+// draining the channel until it is closed by Close.
+func (a *Alerter) Subscribe(buffer int) <-chan AlertEvent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ch := make(chan AlertEvent, buffer)
+	a.subscribers = append(a.subscribers, ch)
+	return ch
+}
+
+// Close closes all subscriber channels. CheckAndNotify must not be called afterwards.
+func (a *Alerter) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, ch := range a.subscribers {
+		close(ch)
+	}
+	a.subscribers = nil
+}
+
+// CheckAndNotify evaluates all rules and publishes an AlertEvent to every
+// subscriber for each rule whose state changed.
+func (a *Alerter) CheckAndNotify(now time.Time, currentMetrics collector.CollectedMetrics) {
+	a.mu.Lock()
+	if len(a.templates) > 0 {
+		a.instantiateTemplates(a.metricNames(currentMetrics))
+	}
+	events := a.evaluate(now)
+	subscribers := a.subscribers
+	a.mu.Unlock()
+
+	if len(events) > 0 {
+		snapshot := a.metricsSnapshot(currentMetrics)
+		for i := range events {
+			events[i].Metrics = snapshot
+		}
+	}
+
+	// Publish outside the lock so slow subscribers never hold up state queries
+	for _, event := range events {
+		for _, ch := range subscribers {
+			ch <- event
+		}
+	}
+}
+
+// metricsSnapshot copies the metrics of the current cycle for events, or the
+// latest value of every metric in the history when they were not passed.
+func (a *Alerter) metricsSnapshot(currentMetrics collector.CollectedMetrics) map[string]float64 {
+	snapshot := make(map[string]float64, len(currentMetrics))
+	if currentMetrics != nil {
+		for name, value := range currentMetrics {
+			snapshot[name] = value
+		}
+		return snapshot
+	}
+	for name := range a.historyBuffer.AllCoverage() {
+		if dp, ok := a.historyBuffer.GetLatestDataPoint(name); ok {
+			snapshot[name] = dp.Value
+		}
+	}
+	return snapshot
+}
+
+// metricNames returns the names of the metrics of the current cycle, or of
+// every metric in the history when they were not passed.
+func (a *Alerter) metricNames(currentMetrics collector.CollectedMetrics) []string {
+	var names []string
+	if currentMetrics != nil {
+		for name := range currentMetrics {
+			names = append(names, name)
+		}
+	} else {
+		for name := range a.historyBuffer.AllCoverage() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// instantiateTemplates adds a rule for every entity of a templated rule that
+// first appears among names. Rules of entities that disappear are kept, so
+// their alerts resolve with auto_resolve_after. Callers hold a.mu.
+func (a *Alerter) instantiateTemplates(names []string) {
+	for _, tmpl := range a.templates {
+		for _, name := range names {
+			entity, ok := tmpl.MatchEntity(name)
+			if !ok || a.instances[tmpl.InstanceName(entity)] {
+				continue
+			}
+			rule := NewAlertRule(tmpl.Instantiate(entity))
+			// Entities appearing later, e.g. a new mount, don't fire "at startup"
+			rule.State.Evaluated = a.evaluated
+			a.instances[rule.Name] = true
+			a.rules = append(a.rules, rule)
+			log.Printf("Alerter: Rule '%s' instantiated for metric %s.", rule.Name, rule.Metric)
+		}
+	}
+}
+
+// evaluate updates rule states and returns the resulting events. Callers hold a.mu.
+func (a *Alerter) evaluate(now time.Time) []AlertEvent {
+	var events []AlertEvent
+
+	for i, rule := range a.rules {
+		if !rule.OverrideUntil.IsZero() {
+			// Revert a copy, as subscribers may still read the configuration of past events' rule
+			if reverted := *rule; reverted.RevertOverride(now) {
+				rule = &reverted
+				a.rules[i] = rule
+				log.Printf("Alerter: The override of rule '%s' expired; threshold reverted to %g.", rule.Name, rule.Threshold)
+			}
+		}
+		if a.isStale(rule, now) {
+			// The metric is gone; don't keep the alert firing (or re-fire it) on old data
+			if rule.State.IsActive {
+				log.Printf("ALERT RESOLVED (stale): %s (no data for metric %s in %s)", rule.Name, rule.Metric, rule.AutoResolveAfter)
+				events = a.resolve(events, rule, now, rule.State.LastValue, true)
+			}
+			continue
+		}
+
 		metricValuePoints := a.historyBuffer.GetDataPointsForDuration(rule.Metric, rule.Duration, now)
 
 		// Rule evaluation can only happen if enough data exists for the duration window
-		if rule.Duration > 0 {
-			if len(metricValuePoints) == 0 {
-				log.Printf("Alerter: Not enough data points yet for duration based rule '%s' (metric: %s, duration: %s). Skipping.", rule.Name, rule.Metric, rule.DurationStr)
-				continue // Not enough data yet
+		if rule.Duration > 0 && rule.MinCoverage > 0 && a.interval > 0 {
+			// Count samples rather than the timespan, so missed cycles or a
+			// slightly late first point don't hold up evaluation
+			samples := 0
+			for _, dp := range metricValuePoints {
+				samples += dp.Weight()
+			}
+			expected := max(int(rule.Duration/a.interval), 1)
+			if float64(samples) < rule.MinCoverage*float64(expected) {
+				a.waitForHistory(rule, rule.Duration*time.Duration(samples)/time.Duration(expected))
+				continue // Not enough samples accumulated yet
+			}
+		} else if rule.Duration > 0 {
+			var covered time.Duration
+			if len(metricValuePoints) > 0 {
+				covered = now.Sub(metricValuePoints[0].Timestamp)
 			}
 			// Check if the actual timespan of collected points covers the rule's duration
 			// This is crucial for new services or after gaps in collection
-			if len(metricValuePoints) > 0 {
-				firstPointTime := metricValuePoints[0].Timestamp
-				// Allow a small tolerance (e.g., 100ms) for time variations
-				if now.Sub(firstPointTime) < rule.Duration - 100*time.Millisecond {
-					log.Printf("Alerter: Data points for rule '%s' (metric: %s) span %s, which is less than required duration %s. Skipping.",
-					rule.Name, rule.Metric, now.Sub(firstPointTime).String(), rule.Duration.String())
-					continue // Not enough history accumulated yet
-				}
+			// Allow a small tolerance (e.g., 100ms) for time variations
+			if covered < rule.Duration-100*time.Millisecond {
+				a.waitForHistory(rule, covered)
+				continue // Not enough history accumulated yet
 			}
 		} else { // Instantaneous alert
 		    latestDP, exists := a.historyBuffer.GetLatestDataPoint(rule.Metric)
 		    if !exists {
-		        log.Printf("Alerter: No data point found for instantaneous rule '%s' (metric: %s). Skipping.", rule.Name, rule.Metric)
+		        a.waitForHistory(rule, 0)
 		        continue
 		    }
 		    metricValuePoints = []history.DataPoint{latestDP} // Evaluate on this single point
 		}
+		if rule.State.WaitingForHistory {
+			rule.State.WaitingForHistory = false
+			log.Printf("Alerter: Rule '%s' (metric: %s) has enough history and is now evaluated.", rule.Name, rule.Metric)
+		}
+		rule.State.HistoryCovered = rule.Duration
 
 
-		conditionMet, aggregatedValue, err := rule.Evaluate(metricValuePoints)
+		tier, aggregatedValue, err := rule.EvaluateTier(metricValuePoints)
 		if err != nil {
 			log.Printf("Error evaluating rule '%s': %v", rule.Name, err)
 			continue
 		}
+		atStartup := !rule.State.Evaluated
+		rule.State.Evaluated = true
 
-		if conditionMet && !rule.State.IsActive {
-			// Alert FIRED
+		if tier != nil && (!rule.State.IsActive || tier.Severity != rule.State.Severity) {
+			// Alert FIRED, or moved to another severity tier while active
+			if rule.State.IsActive {
+				log.Printf("ALERT SEVERITY CHANGED: %s (%s -> %s)", rule.Name, rule.State.Severity, tier.Severity)
+			} else {
+				rule.State.LastActiveTime = now
+				rule.State.IncidentID = IncidentID(a.hostname, rule.Name, now)
+				rule.State.Notified = false
+				rule.State.FiredAtStartup = atStartup
+				rule.State.NotifyAt = now.Add(rule.MinimumFiringDuration)
+				if atStartup && rule.StartupMode == config.StartupWait {
+					// Already breached at startup: only notify if it still is one window later
+					window := max(rule.Duration, a.interval)
+					rule.State.NotifyAt = now.Add(max(window, rule.MinimumFiringDuration))
+					log.Printf("Alerter: Rule '%s' fires at startup; waiting %s before notifying (startup_mode: wait).", rule.Name, window)
+				}
+			}
 			rule.State.IsActive = true
-			rule.State.LastActiveTime = now
+			rule.State.Severity = tier.Severity
 			rule.State.LastValue = aggregatedValue
-			events = append(events, AlertEvent{
-				Rule:        rule,
-				Type:        EventTypeFired,
-				Hostname:    a.hostname,
-				Timestamp:   now,
-				MetricValue: aggregatedValue,
-			})
-			log.Printf("ALERT FIRED: %s (Metric: %s %s %.2f, Current: %.2f)", rule.Name, rule.Metric, rule.Condition, rule.Threshold, aggregatedValue)
-
-		} else if !conditionMet && rule.State.IsActive {
+			log.Printf("ALERT FIRED: %s [%s] (Metric: %s %s %.2f, Current: %.2f)", rule.Name, tier.Severity, rule.Metric, rule.Condition, tier.Threshold, aggregatedValue)
+			if rule.State.Notified && !rule.State.AckedAt.IsZero() {
+				log.Printf("Alerter: Rule '%s' is acknowledged by %s; not notifying the severity change.", rule.Name, rule.State.AckedBy)
+			} else if rule.State.Notified || !now.Before(rule.State.NotifyAt) {
+				events = append(events, a.fired(rule, now))
+			}
+
+		} else if tier != nil && !rule.State.Notified && !now.Before(rule.State.NotifyAt) {
+			// Still firing after minimum_firing_duration or the startup wait: time to tell someone
+			rule.State.LastValue = aggregatedValue
+			events = append(events, a.fired(rule, now))
+
+		} else if tier == nil && rule.State.IsActive {
 			// Alert RESOLVED
-			rule.State.IsActive = false
-			rule.State.LastResolvedTime = now
-			rule.State.LastValue = aggregatedValue // Value at time of resolution
-			events = append(events, AlertEvent{
-				Rule:        rule,
-				Type:        EventTypeResolved,
-				Hostname:    a.hostname,
-				Timestamp:   now,
-				MetricValue: aggregatedValue,  // Could be current value which is now "good"
-			})
 			log.Printf("ALERT RESOLVED: %s", rule.Name)
+			events = a.resolve(events, rule, now, aggregatedValue, false) // Value could be current value which is now "good"
+		}
+		if rule.State.IsActive && rule.State.Notified {
+			events = a.dueActions(events, rule, now)
 		}
 	}
+	a.evaluated = true
 
-	// Send notifications outside the loop to avoid holding lock for too long if notifiers are slow
-	// Unlock isn't needed here if defer is used, but good to keep in mind for complex locking
-	// a.mu.Unlock()
+	return events
+}
 
-	for _, event := range events {
-		a.sendNotificationsForRule(event)
+// isStale reports whether a rule with auto_resolve_after has gone without
+// data for its metric for that long.
+func (a *Alerter) isStale(rule *AlertRule, now time.Time) bool {
+	if rule.AutoResolveAfter <= 0 {
+		return false
 	}
-    // a.mu.Lock() // Re-lock if needed for further state ops, covered by defer
+	latest, exists := a.historyBuffer.GetLatestDataPoint(rule.Metric)
+	return !exists || now.Sub(latest.Timestamp) >= rule.AutoResolveAfter
 }
 
-func (a *Alerter) sendNotificationsForRule(event AlertEvent) {
-	for _, channelName := range event.Rule.Channels {
-		notifierInstance, ok := a.notifiers[channelName]
-		if !ok {
-			log.Printf("Warning: Notification channel '%s' for alert '%s' not found/configured.", channelName, event.Rule.Name)
-			continue
-		}
+// fired returns the FIRED event for the active tier of a rule and marks it as notified.
+func (a *Alerter) fired(rule *AlertRule, now time.Time) AlertEvent {
+	tier, _ := rule.Tier(rule.State.Severity)
+	rule.State.Notified = true
+	return AlertEvent{
+		Rule:        rule,
+		Type:        EventTypeFired,
+		Severity:    tier.Severity,
+		Threshold:   tier.Threshold,
+		AtStartup:   rule.State.FiredAtStartup && rule.StartupMode == config.StartupAnnounce,
+		Hostname:    a.hostname,
+		Timestamp:   now,
+		MetricValue: rule.State.LastValue,
+		IncidentID:  rule.State.IncidentID,
+	}
+}
 
-		// Prepare notification context
-		data := notifier.NotificationData{
-			AlertName:      event.Rule.Name,
-			MetricName:     event.Rule.Metric,
-			MetricValue:    event.MetricValue, // The value causing state change
-			ThresholdValue: event.Rule.Threshold,
-			Condition:      event.Rule.Condition,
-			State:          string(event.Type),
-			Hostname:       a.hostname,
-			Time:           event.Timestamp,
-			DurationString: event.Rule.DurationStr,
-			Aggregation:    event.Rule.Aggregation,
-			// Human-readable formatted values
-			FormattedMetricValue:    notifier.FormatValue(event.Rule.Metric, event.MetricValue),
-			FormattedThresholdValue: notifier.FormatValue(event.Rule.Metric, event.Rule.Threshold),
-		}
-
-		err := notifierInstance.Send(data, a.templates)
-		if err != nil {
-			log.Printf("Failed to send notification for alert '%s' via channel '%s': %v", event.Rule.Name, channelName, err)
-		} else {
-			log.Printf("Notification sent for alert '%s' via channel '%s' (State: %s)", event.Rule.Name, channelName, event.Type)
-		}
+// resolve marks an active rule as resolved and appends the RESOLVED event to
+// events, unless the alert resolved before its FIRED event was ever published.
+// Stale resolutions happen because the metric stopped reporting, not because
+// it returned to normal.
+func (a *Alerter) resolve(events []AlertEvent, rule *AlertRule, now time.Time, value float64, stale bool) []AlertEvent {
+	resolvedTier, _ := rule.Tier(rule.State.Severity)
+	notified := rule.State.Notified
+	rule.State.IsActive = false
+	rule.State.Notified = false
+	rule.State.Severity = ""
+	rule.State.LastResolvedTime = now
+	rule.State.LastValue = value // Value at time of resolution
+	rule.State.AckedBy, rule.State.AckedAt, rule.State.AckComment = "", time.Time{}, ""
+	clear(rule.State.ActionsDone)
+	if !notified {
+		log.Printf("Alerter: Rule '%s' resolved before its FIRED notification was due; no notifications sent.", rule.Name)
+		return events
+	}
+	return append(events, AlertEvent{
+		Rule:        rule,
+		Type:        EventTypeResolved,
+		Severity:    resolvedTier.Severity,
+		Threshold:   resolvedTier.Threshold,
+		Stale:       stale,
+		Hostname:    a.hostname,
+		Timestamp:   now,
+		MetricValue: value,
+		IncidentID:  rule.State.IncidentID,
+	})
+}
+
+// waitForHistory records that a rule was skipped for lack of history. Only the
+// first skip is logged; the progress is available from Status afterwards.
+func (a *Alerter) waitForHistory(rule *AlertRule, covered time.Duration) {
+	if covered < 0 {
+		covered = 0
+	}
+	rule.State.HistoryCovered = covered
+	if rule.State.WaitingForHistory {
+		return
+	}
+	rule.State.WaitingForHistory = true
+	if rule.Duration > 0 {
+		log.Printf("Alerter: Rule '%s' (metric: %s) is waiting for %s of history. Skipping until covered.", rule.Name, rule.Metric, rule.Duration)
+	} else {
+		log.Printf("Alerter: No data point found for instantaneous rule '%s' (metric: %s). Skipping until available.", rule.Name, rule.Metric)
+	}
+}
+
+// RuleStatus is a point-in-time view of an alert rule, for status reporting.
+type RuleStatus struct {
+	Name              string
+	Metric            string
+	Active            bool
+	Severity          string // Severity of the active tier, empty when not active
+	LastValue         float64
+	Window            time.Duration
+	WaitingForHistory bool
+	HistoryCovered    time.Duration
+	AckedBy           string    // Who acknowledged the active alert, if anyone
+	AckedAt           time.Time // Zero if not acknowledged
+}
+
+// Coverage returns the fraction of the rule's window covered by history, from 0 to 1.
+func (rs RuleStatus) Coverage() float64 {
+	if !rs.WaitingForHistory {
+		return 1
+	}
+	if rs.Window <= 0 {
+		return 0
+	}
+	return min(float64(rs.HistoryCovered)/float64(rs.Window), 1)
+}
+
+// String describes the rule's state, e.g. "rule X waiting for history (42% of 5m window)".
+func (rs RuleStatus) String() string {
+	switch {
+	case rs.WaitingForHistory && rs.Window > 0:
+		return fmt.Sprintf("rule %s waiting for history (%.0f%% of %s window)", rs.Name, 100*rs.Coverage(), shortDuration(rs.Window))
+	case rs.WaitingForHistory:
+		return fmt.Sprintf("rule %s waiting for a first sample of %s", rs.Name, rs.Metric)
+	case rs.Active && !rs.AckedAt.IsZero():
+		return fmt.Sprintf("rule %s firing (%s, %s = %g), acknowledged by %s", rs.Name, rs.Severity, rs.Metric, rs.LastValue, rs.AckedBy)
+	case rs.Active:
+		return fmt.Sprintf("rule %s firing (%s, %s = %g)", rs.Name, rs.Severity, rs.Metric, rs.LastValue)
+	default:
+		return fmt.Sprintf("rule %s ok", rs.Name)
+	}
+}
+
+// shortDuration formats d without trailing zero units, e.g. "5m" instead of "5m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// Status returns the status of every rule, in configuration order, followed
+// by the rules instantiated from templates in the order they were discovered.
+func (a *Alerter) Status() []RuleStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	statuses := make([]RuleStatus, 0, len(a.rules))
+	for _, rule := range a.rules {
+		statuses = append(statuses, RuleStatus{
+			Name:              rule.Name,
+			Metric:            rule.Metric,
+			Active:            rule.State.IsActive,
+			Severity:          rule.State.Severity,
+			LastValue:         rule.State.LastValue,
+			Window:            rule.Duration,
+			WaitingForHistory: rule.State.WaitingForHistory,
+			HistoryCovered:    rule.State.HistoryCovered,
+			AckedBy:           rule.State.AckedBy,
+			AckedAt:           rule.State.AckedAt,
+		})
 	}
+	return statuses
 }
 
 // GetCurrentActiveAlerts returns a map of active alert names for state saving.
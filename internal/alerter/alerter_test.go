@@ -0,0 +1,521 @@
+package alerter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+)
+
+func TestTieredThresholds(t *testing.T) {
+	cfg := &config.Config{
+		EffectiveHostname: "web-1",
+		Alerts: []config.AlertRuleConfig{{
+			Name:      "Disk usage",
+			Metric:    "disk_percent_used",
+			Condition: ">",
+			Tiers: []config.ThresholdTier{
+				{Severity: config.SeverityWarning, Threshold: 80},
+				{Severity: config.SeverityCritical, Threshold: 95},
+			},
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	for i, v := range []float64{50, 85, 90, 97, 85, 50} {
+		at := now.Add(time.Duration(i) * time.Second)
+		hist.AddDataPoint("disk_percent_used", v, at)
+		a.CheckAndNotify(at, nil)
+	}
+	a.Close()
+
+	type summary struct {
+		Type      EventType
+		Severity  string
+		Threshold float64
+		Value     float64
+	}
+	var got []summary
+	for e := range events {
+		got = append(got, summary{e.Type, e.Severity, e.Threshold, e.MetricValue})
+	}
+	assert.Equal(t, []summary{
+		{EventTypeFired, config.SeverityWarning, 80, 85},
+		{EventTypeFired, config.SeverityCritical, 95, 97},
+		{EventTypeFired, config.SeverityWarning, 80, 85},
+		{EventTypeResolved, config.SeverityWarning, 80, 50},
+	}, got)
+}
+
+func TestIncidentID(t *testing.T) {
+	cfg := &config.Config{
+		EffectiveHostname: "web-1",
+		Alerts: []config.AlertRuleConfig{{
+			Name:      "Disk usage",
+			Metric:    "disk_percent_used",
+			Condition: ">",
+			Tiers: []config.ThresholdTier{
+				{Severity: config.SeverityWarning, Threshold: 80},
+				{Severity: config.SeverityCritical, Threshold: 95},
+			},
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	for i, v := range []float64{85, 97, 50, 85, 50} {
+		at := now.Add(time.Duration(i) * time.Second)
+		hist.AddDataPoint("disk_percent_used", v, at)
+		a.CheckAndNotify(at, nil)
+	}
+	a.Close()
+
+	var ids []string
+	for e := range events {
+		ids = append(ids, e.IncidentID)
+	}
+	require.Len(t, ids, 5)
+	first, second := IncidentID("web-1", "Disk usage", now), IncidentID("web-1", "Disk usage", now.Add(3*time.Second))
+	assert.NotEqual(t, first, second)
+	// The severity change and the RESOLVED event keep the ID of the firing
+	assert.Equal(t, []string{first, first, first, second, second}, ids)
+	assert.Equal(t, first, NotificationDataForEvent(AlertEvent{Rule: a.rules[0], IncidentID: first}).IncidentID)
+}
+
+func TestAutoResolveAfter(t *testing.T) {
+	cfg := &config.Config{
+		Alerts: []config.AlertRuleConfig{{
+			Name:             "Container CPU",
+			Metric:           "container_cpu_percent_web",
+			Condition:        ">",
+			Threshold:        90,
+			AutoResolveAfter: 10 * time.Second,
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	hist.AddDataPoint("container_cpu_percent_web", 99, now)
+	a.CheckAndNotify(now, nil)
+	// The container is removed: no more samples
+	for i := 1; i <= 20; i++ {
+		a.CheckAndNotify(now.Add(time.Duration(i)*time.Second), nil)
+	}
+	a.Close()
+
+	var got []AlertEvent
+	for e := range events {
+		got = append(got, e)
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, EventTypeFired, got[0].Type)
+	assert.Equal(t, EventTypeResolved, got[1].Type)
+	assert.True(t, got[1].Stale)
+	assert.Equal(t, now.Add(10*time.Second), got[1].Timestamp)
+	assert.Equal(t, 99.0, got[1].MetricValue)
+}
+
+func TestAutoResolveAfterLongerThanHistory(t *testing.T) {
+	// The history only needs 2 samples (1m), but the rule waits 10m for data
+	cfg := &config.Config{
+		CollectionInterval: 30 * time.Second,
+		Alerts: []config.AlertRuleConfig{{
+			Name:             "Container CPU",
+			Metric:           "container_cpu_percent_web",
+			Condition:        ">",
+			Threshold:        90,
+			AutoResolveAfter: 10 * time.Minute,
+		}},
+	}
+	hist := history.NewForConfig(cfg, cfg.CollectionInterval)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	hist.AddDataPoint("container_cpu_percent_web", 99, now)
+	a.CheckAndNotify(now, nil)
+	// The container is removed; other metrics keep the history sweeping
+	for i := 1; i <= 30; i++ {
+		ts := now.Add(time.Duration(i) * cfg.CollectionInterval)
+		hist.AddDataPoint("cpu_percent_total", 5, ts)
+		a.CheckAndNotify(ts, nil)
+	}
+	a.Close()
+
+	var got []AlertEvent
+	for e := range events {
+		got = append(got, e)
+	}
+	require.Len(t, got, 2)
+	assert.True(t, got[1].Stale)
+	assert.Equal(t, now.Add(10*time.Minute), got[1].Timestamp)
+}
+
+func TestMinimumFiringDuration(t *testing.T) {
+	cfg := &config.Config{
+		Alerts: []config.AlertRuleConfig{{
+			Name:                  "Network spike",
+			Metric:                "net_recv_bytes_ps",
+			Condition:             ">",
+			Threshold:             100,
+			MinimumFiringDuration: 3 * time.Second,
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	// A 2s spike is never notified; a 5s one is, once it has fired for 3s
+	for i, v := range []float64{500, 500, 0, 500, 600, 700, 800, 900, 0} {
+		at := now.Add(time.Duration(i) * time.Second)
+		hist.AddDataPoint("net_recv_bytes_ps", v, at)
+		a.CheckAndNotify(at, nil)
+	}
+	a.Close()
+
+	var got []AlertEvent
+	for e := range events {
+		got = append(got, e)
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, EventTypeFired, got[0].Type)
+	assert.Equal(t, now.Add(6*time.Second), got[0].Timestamp)
+	assert.Equal(t, 800.0, got[0].MetricValue)
+	assert.Equal(t, EventTypeResolved, got[1].Type)
+}
+
+func TestMinCoverage(t *testing.T) {
+	rule := config.AlertRuleConfig{
+		Metric:      "cpu_percent_total",
+		Condition:   ">",
+		Threshold:   90,
+		Duration:    10 * time.Second,
+		Aggregation: "average",
+	}
+	strict, tolerant := rule, rule
+	strict.Name = "strict"
+	tolerant.Name, tolerant.MinCoverage = "tolerant", 0.8
+	cfg := &config.Config{CollectionInterval: time.Second, Alerts: []config.AlertRuleConfig{strict, tolerant}}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	// 8 of the 10 samples of the window, starting late and missing a cycle
+	now := time.Now()
+	for _, i := range []int{1, 2, 3, 5, 6, 7, 8, 9} {
+		hist.AddDataPoint("cpu_percent_total", 95, now.Add(time.Duration(i)*time.Second))
+	}
+	a.CheckAndNotify(now.Add(9*time.Second), nil)
+	a.Close()
+
+	var fired []string
+	for e := range events {
+		fired = append(fired, e.Rule.Name)
+	}
+	assert.Equal(t, []string{"tolerant"}, fired)
+
+	status := a.Status()
+	assert.True(t, status[0].WaitingForHistory)
+	assert.False(t, status[1].WaitingForHistory)
+}
+
+func TestStartupMode(t *testing.T) {
+	tests := []struct {
+		mode          string
+		wantFiredAt   time.Duration
+		wantAtStartup bool
+	}{
+		{config.StartupFire, 0, false},
+		{config.StartupWait, 10 * time.Second, false},
+		{config.StartupAnnounce, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			cfg := &config.Config{
+				CollectionInterval: time.Second,
+				Alerts: []config.AlertRuleConfig{{
+					Name:        "High load",
+					Metric:      "load",
+					Condition:   ">",
+					Threshold:   5,
+					Duration:    10 * time.Second,
+					Aggregation: "average",
+					StartupMode: tt.mode,
+				}},
+			}
+			hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+			a, err := NewAlerter(cfg, hist)
+			require.NoError(t, err)
+			events := a.Subscribe(16)
+
+			// Restarted with history already breached (e.g. from a replayed trace)
+			start := time.Now()
+			for i := 0; i <= 10; i++ {
+				hist.AddDataPoint("load", 9, start.Add(time.Duration(i)*time.Second))
+			}
+			firstEval := start.Add(10 * time.Second)
+			for i := 0; i <= 15; i++ {
+				at := firstEval.Add(time.Duration(i) * time.Second)
+				if i > 0 {
+					hist.AddDataPoint("load", 9, at)
+				}
+				a.CheckAndNotify(at, nil)
+			}
+			a.Close()
+
+			var got []AlertEvent
+			for e := range events {
+				got = append(got, e)
+			}
+			require.Len(t, got, 1)
+			assert.Equal(t, EventTypeFired, got[0].Type)
+			assert.Equal(t, firstEval.Add(tt.wantFiredAt), got[0].Timestamp)
+			assert.Equal(t, tt.wantAtStartup, got[0].AtStartup)
+		})
+	}
+}
+
+func TestStartupModeOnlyAffectsFirstEvaluation(t *testing.T) {
+	cfg := &config.Config{
+		CollectionInterval: time.Second,
+		Alerts: []config.AlertRuleConfig{{
+			Name: "Probe", Metric: "probe_up", Condition: "==", Threshold: 0, StartupMode: config.StartupAnnounce,
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	for i, v := range []float64{1, 0} {
+		at := now.Add(time.Duration(i) * time.Second)
+		hist.AddDataPoint("probe_up", v, at)
+		a.CheckAndNotify(at, nil)
+	}
+	a.Close()
+
+	e := <-events
+	assert.Equal(t, EventTypeFired, e.Type)
+	assert.False(t, e.AtStartup)
+}
+
+func TestAcknowledge(t *testing.T) {
+	cfg := &config.Config{
+		Alerts: []config.AlertRuleConfig{{
+			Name:      "Disk usage",
+			Metric:    "disk_percent_used",
+			Condition: ">",
+			Tiers: []config.ThresholdTier{
+				{Severity: config.SeverityWarning, Threshold: 80},
+				{Severity: config.SeverityCritical, Threshold: 95},
+			},
+		}},
+	}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	assert.ErrorIs(t, a.Acknowledge("missing", "ops", "", now), ErrUnknownRule)
+	assert.Error(t, a.Acknowledge("Disk usage", "ops", "", now), "not firing")
+
+	step := func(i int, v float64) {
+		at := now.Add(time.Duration(i) * time.Second)
+		hist.AddDataPoint("disk_percent_used", v, at)
+		a.CheckAndNotify(at, nil)
+	}
+	step(0, 85)
+	require.NoError(t, a.Acknowledge("Disk usage", "ops", "cleaning up", now))
+	assert.Equal(t, "rule Disk usage firing (warning, disk_percent_used = 85), acknowledged by ops", a.Status()[0].String())
+	step(1, 97) // Severity change is not notified while acknowledged
+	step(2, 50)
+	assert.Empty(t, a.Status()[0].AckedBy, "cleared on resolve")
+	step(3, 97)
+	a.Close()
+
+	var got []string
+	for e := range events {
+		got = append(got, string(e.Type)+" "+e.Severity)
+	}
+	assert.Equal(t, []string{"FIRED warning", "RESOLVED critical", "FIRED critical"}, got)
+}
+
+func TestUpdateRules(t *testing.T) {
+	rule := config.AlertRuleConfig{Name: "mem", Metric: "mem_percent_used", Condition: ">", Threshold: 50}
+	cfg := &config.Config{Alerts: []config.AlertRuleConfig{rule}}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	hist.AddDataPoint("mem_percent_used", 80, now)
+	a.CheckAndNotify(now, nil)
+
+	// The firing rule keeps its state, so it is not notified again.
+	rule.Threshold = 60
+	a.UpdateRules(&config.Config{Alerts: []config.AlertRuleConfig{
+		rule,
+		{Name: "cpu", Metric: "cpu_percent_total", Condition: ">", Threshold: 90},
+	}})
+	hist.AddDataPoint("mem_percent_used", 70, now.Add(time.Second))
+	hist.AddDataPoint("cpu_percent_total", 95, now.Add(time.Second))
+	a.CheckAndNotify(now.Add(time.Second), nil)
+	a.Close()
+
+	var got []string
+	for e := range events {
+		got = append(got, string(e.Type)+" "+e.Rule.Name)
+	}
+	assert.Equal(t, []string{"FIRED mem", "FIRED cpu"}, got)
+	require.Len(t, a.Status(), 2)
+	assert.True(t, a.Status()[0].Active)
+}
+
+func TestUpdateRulesAddedRuleNotAtStartup(t *testing.T) {
+	cfg := &config.Config{CollectionInterval: time.Second, Alerts: []config.AlertRuleConfig{
+		{Name: "mem", Metric: "mem_percent_used", Condition: ">", Threshold: 50},
+	}}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	a.CheckAndNotify(now, nil)
+
+	// A rule added by a reload, firing on its first evaluation
+	cfg.Alerts = append(cfg.Alerts, config.AlertRuleConfig{
+		Name: "cpu", Metric: "cpu_percent_total", Condition: ">", Threshold: 90, StartupMode: config.StartupAnnounce,
+	})
+	a.UpdateRules(cfg)
+	hist.AddDataPoint("cpu_percent_total", 95, now.Add(time.Second))
+	a.CheckAndNotify(now.Add(time.Second), nil)
+	a.Close()
+
+	e := <-events
+	assert.Equal(t, EventTypeFired, e.Type)
+	assert.Equal(t, "cpu", e.Rule.Name)
+	assert.False(t, e.AtStartup, "the reload is not a startup")
+}
+
+func TestRuleTemplates(t *testing.T) {
+	tmpl := config.AlertRuleConfig{
+		Name:        "Disk full",
+		Metric:      "disk_percent_used_*",
+		Condition:   ">",
+		Threshold:   90,
+		Exclude:     []string{"boot"},
+		EntityTiers: map[string][]config.ThresholdTier{"var_lib_docker": {{Severity: config.SeverityCritical, Threshold: 95}}},
+	}
+	cfg := &config.Config{Alerts: []config.AlertRuleConfig{tmpl}}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	hist.AddDataPoint("disk_percent_used_root", 50, now)
+	hist.AddDataPoint("disk_percent_used_var_lib_docker", 92, now) // Below its own threshold
+	hist.AddDataPoint("disk_percent_used_boot", 99, now)
+	a.CheckAndNotify(now, nil)
+
+	// A mount appears, and the rules are reloaded while root fires
+	now = now.Add(time.Second)
+	hist.AddDataPoint("disk_percent_used_root", 95, now)
+	hist.AddDataPoint("disk_percent_used_srv", 97, now)
+	a.CheckAndNotify(now, nil)
+	a.UpdateRules(cfg)
+	now = now.Add(time.Second)
+	hist.AddDataPoint("disk_percent_used_root", 96, now)
+	hist.AddDataPoint("disk_percent_used_srv", 40, now)
+	a.CheckAndNotify(now, nil)
+	a.Close()
+
+	var got []string
+	for e := range events {
+		got = append(got, string(e.Type)+" "+e.Rule.Name)
+	}
+	assert.Equal(t, []string{"FIRED Disk full (root)", "FIRED Disk full (srv)", "RESOLVED Disk full (srv)"}, got)
+
+	var names []string
+	for _, s := range a.Status() {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"Disk full (root)", "Disk full (var_lib_docker)", "Disk full (srv)"}, names)
+}
+
+func TestTemporaryOverrideReverts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	rule := config.AlertRuleConfig{
+		Name: "cpu", Metric: "cpu_percent_total", Condition: ">",
+		Threshold:     98,
+		Tiers:         []config.ThresholdTier{{Severity: config.SeverityCritical, Threshold: 98}},
+		BaseTiers:     []config.ThresholdTier{{Severity: config.SeverityCritical, Threshold: 90}},
+		OverrideUntil: now.Add(time.Minute),
+	}
+	hist := history.NewMetricHistoryBuffer(time.Hour, time.Second)
+	a, err := NewAlerter(&config.Config{Alerts: []config.AlertRuleConfig{rule}}, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	hist.AddDataPoint("cpu_percent_total", 95, now)
+	a.CheckAndNotify(now, nil)
+	hist.AddDataPoint("cpu_percent_total", 95, now.Add(time.Minute))
+	a.CheckAndNotify(now.Add(time.Minute), nil)
+	a.Close()
+
+	var got []string
+	for e := range events {
+		got = append(got, fmt.Sprintf("%s %g", e.Type, e.Threshold))
+	}
+	assert.Equal(t, []string{"FIRED 90"}, got, "fires once the override expired")
+}
+
+func TestEventMetricsSnapshot(t *testing.T) {
+	cfg := &config.Config{Alerts: []config.AlertRuleConfig{
+		{Name: "mem", Metric: "mem_percent_used", Condition: ">", Threshold: 50},
+	}}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	events := a.Subscribe(16)
+
+	now := time.Now()
+	current := map[string]float64{"mem_percent_used": 80, "cpu_percent_total": 12}
+	for name, value := range current {
+		hist.AddDataPoint(name, value, now)
+	}
+	a.CheckAndNotify(now, current)
+	current["cpu_percent_total"] = 99 // The event holds a copy
+
+	hist.AddDataPoint("mem_percent_used", 10, now.Add(time.Second))
+	a.CheckAndNotify(now.Add(time.Second), nil) // Without current metrics, the history's latest values are used
+	a.Close()
+
+	fired, resolved := <-events, <-events
+	assert.Equal(t, map[string]float64{"mem_percent_used": 80, "cpu_percent_total": 12}, fired.Metrics)
+	assert.Equal(t, map[string]float64{"mem_percent_used": 10, "cpu_percent_total": 12}, resolved.Metrics)
+	assert.Equal(t, fired.Metrics, NotificationDataForEvent(fired).Metrics)
+}
@@ -0,0 +1,91 @@
+package alerter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// captureTimeout bounds each capture command.
+const captureTimeout = 10 * time.Second
+
+// maxCommandOutput is how much of the output of capture and action commands
+// is kept, as notification channels limit the size of messages.
+const maxCommandOutput = 2000
+
+// outputTruncated marks output cut at maxCommandOutput.
+const outputTruncated = "\n[truncated]"
+
+// runCaptures runs the capture commands of a rule concurrently, each in a
+// shell, and returns their output in the order of the commands.
+func runCaptures(commands []string) []notifier.CaptureOutput {
+	outputs := make([]notifier.CaptureOutput, len(commands))
+	var wg sync.WaitGroup
+	for i, command := range commands {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outputs[i] = runCapture(command, captureTimeout)
+		}()
+	}
+	wg.Wait()
+	return outputs
+}
+
+func runCapture(command string, timeout time.Duration) notifier.CaptureOutput {
+	output, errText := runCommand([]string{"/bin/sh", "-c", command}, timeout)
+	return notifier.CaptureOutput{Command: command, Output: output, Error: errText}
+}
+
+// runCommand runs a program and returns its combined output, truncated, and
+// why it failed, if it did.
+func runCommand(command []string, timeout time.Duration) (output, errText string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out := &limitedBuffer{max: maxCommandOutput}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = time.Second // Don't wait for children of a killed command holding the output open
+	err := cmd.Run()
+
+	output = string(bytes.TrimRight(out.buf.Bytes(), "\n"))
+	if out.truncated {
+		output += outputTruncated
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		errText = fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		errText = exitErr.Error()
+	case err != nil:
+		errText = err.Error()
+	}
+	return output, errText
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the
+// rest, so a chatty command can't exhaust memory.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil // Pretend everything was written, so the command isn't killed by EPIPE
+}
@@ -0,0 +1,33 @@
+package alerter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCapture(t *testing.T) {
+	out := runCapture("echo hello; echo oops >&2", time.Second)
+	assert.Equal(t, "hello\noops", out.Output)
+	assert.Empty(t, out.Error)
+
+	out = runCapture("echo partial; exit 3", time.Second)
+	assert.Equal(t, "partial", out.Output)
+	assert.Equal(t, "exit status 3", out.Error)
+
+	out = runCapture("head -c 10000 /dev/zero | tr '\\0' x", time.Second)
+	assert.Equal(t, strings.Repeat("x", maxCommandOutput)+outputTruncated, out.Output)
+
+	out = runCapture("sleep 5", 50*time.Millisecond)
+	assert.Equal(t, "timed out after 50ms", out.Error)
+}
+
+func TestRunCapturesKeepsOrder(t *testing.T) {
+	outputs := runCaptures([]string{"sleep 0.1; echo first", "echo second"})
+	if assert.Len(t, outputs, 2) {
+		assert.Equal(t, "first", outputs[0].Output)
+		assert.Equal(t, "second", outputs[1].Output)
+	}
+}
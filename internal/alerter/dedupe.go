@@ -0,0 +1,150 @@
+package alerter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventFingerprint identifies what an event notifies: the same alert of the
+// same host in the same state and severity (and, for ACTION events, the same
+// action) always has the same fingerprint, across restarts and instances.
+func EventFingerprint(event AlertEvent) string {
+	parts := []string{event.Hostname, event.Rule.Name, string(event.Type), event.Severity}
+	if event.Action != nil {
+		parts = append(parts, event.Action.Name)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// IncidentID identifies the firing of an alert that became active at since:
+// a short hash of the host, the alert and that time, so it stays the same
+// across severity changes and until the alert resolves.
+func IncidentID(hostname, alert string, since time.Time) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{hostname, alert, since.UTC().Format(time.RFC3339Nano)}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// dedupeRecord is the last notification sent for an alert on a channel.
+type dedupeRecord struct {
+	Fingerprint string    `json:"fingerprint"`
+	Sent        time.Time `json:"sent"`
+}
+
+// Dedupe suppresses notifications repeating the last one sent for the same
+// alert on the same channel within a window, so an alert firing while monres
+// crash-loops pages once. A notification in another state, such as RESOLVED
+// in between, is not a repeat. With a file, the notifications sent survive
+// restarts and are shared by the instances using the same file.
+type Dedupe struct {
+	mu     sync.Mutex
+	window time.Duration
+	path   string // Optional
+	sent   map[string]dedupeRecord
+}
+
+// NewDedupe creates a Dedupe, loading the notifications already sent from
+// path if it is set and exists.
+func NewDedupe(window time.Duration, path string) (*Dedupe, error) {
+	d := &Dedupe{window: window, path: path, sent: make(map[string]dedupeRecord)}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Duplicate reports whether fingerprint was the last notification sent for
+// key within the window, and when it was sent.
+func (d *Dedupe) Duplicate(key, fingerprint string, now time.Time) (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.load(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	rec, ok := d.sent[key]
+	if !ok || rec.Fingerprint != fingerprint || now.Sub(rec.Sent) >= d.window {
+		return time.Time{}, false
+	}
+	return rec.Sent, true
+}
+
+// Sent records that fingerprint was sent for key, saving the file if any.
+func (d *Dedupe) Sent(key, fingerprint string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.load(); err != nil { // Keep what other instances sent
+		log.Printf("Warning: %v", err)
+	}
+	d.sent[key] = dedupeRecord{Fingerprint: fingerprint, Sent: now}
+	for k, rec := range d.sent {
+		if now.Sub(rec.Sent) >= d.window {
+			delete(d.sent, k)
+		}
+	}
+	if err := d.save(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// load merges the notifications recorded in the file, which other instances
+// may have written to. Notifications are rare enough to read it every time.
+// Callers hold d.mu.
+func (d *Dedupe) load() error {
+	if d.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(d.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dedupe file: %w", err)
+	}
+	sent := make(map[string]dedupeRecord)
+	if err := json.Unmarshal(data, &sent); err != nil {
+		return fmt.Errorf("failed to parse dedupe file %s: %w", d.path, err)
+	}
+	for key, rec := range sent {
+		if cur, ok := d.sent[key]; !ok || rec.Sent.After(cur.Sent) {
+			d.sent[key] = rec
+		}
+	}
+	return nil
+}
+
+// save writes the file atomically, so instances never read it half written.
+// Callers hold d.mu.
+func (d *Dedupe) save() error {
+	if d.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(d.sent)
+	if err != nil {
+		return fmt.Errorf("failed to encode dedupe file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), ".dedupe-*")
+	if err != nil {
+		return fmt.Errorf("failed to write dedupe file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write dedupe file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write dedupe file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.path); err != nil {
+		return fmt.Errorf("failed to write dedupe file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package alerter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func TestEventFingerprint(t *testing.T) {
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "cpu"})
+	fired := AlertEvent{Rule: rule, Type: EventTypeFired, Severity: "critical", Hostname: "web-1", Timestamp: time.Now(), MetricValue: 95}
+	later := fired
+	later.Timestamp, later.MetricValue = fired.Timestamp.Add(time.Hour), 97
+	assert.Equal(t, EventFingerprint(fired), EventFingerprint(later), "independent of time and value")
+
+	for _, other := range []AlertEvent{
+		{Rule: rule, Type: EventTypeResolved, Severity: "critical", Hostname: "web-1"},
+		{Rule: rule, Type: EventTypeFired, Severity: "warning", Hostname: "web-1"},
+		{Rule: rule, Type: EventTypeFired, Severity: "critical", Hostname: "web-2"},
+	} {
+		assert.NotEqual(t, EventFingerprint(fired), EventFingerprint(other))
+	}
+}
+
+func TestDedupePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedupe.json")
+	now := time.Now()
+	d, err := NewDedupe(time.Hour, path)
+	require.NoError(t, err)
+	_, dup := d.Duplicate("web-1/cpu/chat", "aaaa", now)
+	assert.False(t, dup)
+	d.Sent("web-1/cpu/chat", "aaaa", now)
+
+	// A restarted instance, or another one sharing the file
+	other, err := NewDedupe(time.Hour, path)
+	require.NoError(t, err)
+	sentAt, dup := other.Duplicate("web-1/cpu/chat", "aaaa", now.Add(time.Minute))
+	assert.True(t, dup)
+	assert.True(t, sentAt.Equal(now))
+	_, dup = other.Duplicate("web-1/cpu/chat", "aaaa", now.Add(time.Hour))
+	assert.False(t, dup, "outside the window")
+	_, dup = other.Duplicate("web-1/cpu/email", "aaaa", now)
+	assert.False(t, dup, "per channel")
+
+	other.Sent("web-1/cpu/chat", "bbbb", now.Add(2*time.Minute)) // e.g. RESOLVED
+	_, dup = d.Duplicate("web-1/cpu/chat", "aaaa", now.Add(3*time.Minute))
+	assert.False(t, dup, "no longer the last notification sent")
+}
+
+func TestRouterDedupe(t *testing.T) {
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{{Name: "chat", Type: "stdout"}}}
+	chat := &recordingNotifier{name: "chat"}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"chat": chat})
+	notificationLog := audit.NewLog(10)
+	router.SetNotificationLog(notificationLog)
+	d, err := NewDedupe(time.Hour, "")
+	require.NoError(t, err)
+	router.SetDedupe(d)
+
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "cpu", Channels: []string{"chat"}})
+	for _, typ := range []EventType{EventTypeFired, EventTypeFired, EventTypeResolved, EventTypeFired} {
+		router.Dispatch(AlertEvent{Rule: rule, Type: typ, Severity: "critical", Hostname: "web-1"})
+	}
+
+	assert.Equal(t, []string{"FIRED", "RESOLVED", "FIRED"}, chat.states)
+	entries := notificationLog.Query(audit.Query{})
+	require.Len(t, entries, 4)
+	assert.Equal(t, audit.StatusSkipped, entries[1].Status)
+	assert.Contains(t, entries[1].Error, "duplicate of the notification sent at")
+	assert.Equal(t, entries[0].Fingerprint, entries[1].Fingerprint)
+}
@@ -0,0 +1,381 @@
+package alerter
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// Router delivers alert events to the notification channels listed on their rules.
+type Router struct {
+	notifiers       map[string]notifier.Notifier // map channel name to notifier instance
+	templates       notifier.NotificationTemplates
+	channels        map[string]*channelPolicy // Delivery settings by channel name
+	notificationLog *audit.Log                // Optional
+	silences        *Silences                 // Optional
+	dedupe          *Dedupe                   // Optional
+	runActions      bool                      // Run remediation actions, see EnableActions
+	actions         sync.WaitGroup            // Actions running in the background
+	mu              sync.RWMutex              // Held for reading while dispatching, for writing by Update
+}
+
+// channelPolicy holds how events are delivered to one channel.
+type channelPolicy struct {
+	sendsResolved bool
+	timeout       time.Duration
+	breaker       *notifier.CircuitBreaker // Optional
+	fallback      []string
+	disabled      bool   // enabled: false in the config
+	minSeverity   string // Less severe alerts are skipped, if set
+}
+
+func NewRouter(cfg *config.Config, configuredNotifiers map[string]notifier.Notifier) *Router {
+	r := &Router{}
+	r.apply(cfg, configuredNotifiers)
+	return r
+}
+
+// Update replaces the channels and templates after a configuration reload.
+// It waits for the event being dispatched, if any.
+func (r *Router) Update(cfg *config.Config, configuredNotifiers map[string]notifier.Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apply(cfg, configuredNotifiers)
+}
+
+func (r *Router) apply(cfg *config.Config, configuredNotifiers map[string]notifier.Notifier) {
+	channels := make(map[string]*channelPolicy)
+	for _, nc := range cfg.NotificationChannels {
+		policy := &channelPolicy{
+			sendsResolved: nc.SendsResolved(),
+			timeout:       nc.Timeout,
+			fallback:      nc.Fallback,
+			disabled:      !nc.IsEnabled(),
+			minSeverity:   nc.MinSeverity,
+		}
+		if nc.CircuitBreaker != nil {
+			policy.breaker = notifier.NewCircuitBreaker(nc.CircuitBreaker.Failures, nc.CircuitBreaker.Cooldown)
+		}
+		channels[nc.Name] = policy
+	}
+	r.channels = channels
+	r.notifiers = configuredNotifiers
+	r.templates = notifier.NotificationTemplates{
+		FiredTemplate:    cfg.Templates.AlertFired,
+		ResolvedTemplate: cfg.Templates.AlertResolved,
+	}
+}
+
+// Run dispatches events until the channel is closed.
+func (r *Router) Run(events <-chan AlertEvent) {
+	for event := range events {
+		r.Dispatch(event)
+	}
+}
+
+// SetNotificationLog records every notification attempt in l.
+func (r *Router) SetNotificationLog(l *audit.Log) {
+	r.notificationLog = l
+}
+
+// SetSilences skips the delivery of events muted by one of s.
+func (r *Router) SetSilences(s *Silences) {
+	r.silences = s
+}
+
+// SetDedupe skips notifications that repeat the last one sent for the same
+// alert and channel, according to d.
+func (r *Router) SetDedupe(d *Dedupe) {
+	r.dedupe = d
+}
+
+// EnableActions runs the remediation actions of ACTION events. Without it,
+// they are reported as skipped, e.g. when replaying recorded metrics.
+func (r *Router) EnableActions() {
+	r.runActions = true
+}
+
+// WaitActions waits for the actions running in the background and the
+// delivery of their results.
+func (r *Router) WaitActions() {
+	r.actions.Wait()
+}
+
+// Dispatch sends one event to each of its rule's channels. ACTION events run
+// their action first.
+func (r *Router) Dispatch(event AlertEvent) {
+	if event.Type == EventTypeAction {
+		r.dispatchAction(event)
+		return
+	}
+	data := NotificationDataForEvent(event)
+	if event.Type == EventTypeFired {
+		// Captures run shell commands and process sampling takes a while,
+		// so they work on a copy of the rule, without holding up Update.
+		r.mu.RLock()
+		rule := &AlertRule{AlertRuleConfig: event.Rule.AlertRuleConfig}
+		captures := slices.Clone(rule.Capture)
+		r.mu.RUnlock()
+		data.TopProcesses = topProcessesFor(rule)
+		if len(captures) > 0 {
+			data.Captures = runCaptures(captures)
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.notify(event, data)
+}
+
+// dispatchAction runs the remediation action of an ACTION event and sends
+// its result to the rule's channels. Actions run in the background, so a
+// slow one doesn't hold up other notifications.
+func (r *Router) dispatchAction(event AlertEvent) {
+	action := event.Action
+	report := func(result notifier.ActionResult) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		data := NotificationDataForEvent(event)
+		data.Action = &result
+		r.notify(event, data)
+	}
+	silence, silenced := Silence{}, false
+	if r.silences != nil {
+		silence, silenced = r.silences.Match(event.Rule.Name, event.Timestamp)
+	}
+	switch {
+	case !r.runActions:
+		report(skippedAction(action.Name, "actions are disabled"))
+	case silenced:
+		report(skippedAction(action.Name, "the alert is silenced until %s", silence.Ends.Format(time.RFC3339)))
+	case event.ActionLimited:
+		report(skippedAction(action.Name, "max_per_hour (%d) reached", action.MaxPerHour))
+	default:
+		r.actions.Add(1)
+		go func() {
+			defer r.actions.Done()
+			report(runAction(action.Name, action.Command, action.Timeout))
+		}()
+	}
+}
+
+// notify delivers the data of an event to each of its rule's channels.
+// Callers hold r.mu for reading.
+func (r *Router) notify(event AlertEvent, data notifier.NotificationData) {
+	var textHash string
+	if r.notificationLog != nil {
+		if text, err := notifier.RenderMessage(data, r.templates); err == nil {
+			textHash = audit.HashText(text)
+		}
+	}
+
+	// Fallbacks are not used for channels the rule notifies anyway
+	targeted := make(map[string]bool)
+	for _, channelName := range event.Rule.Channels {
+		targeted[channelName] = true
+	}
+	for _, channelName := range event.Rule.Channels {
+		r.deliver(event, data, textHash, channelName, "", targeted)
+	}
+}
+
+// deliver sends an event to one channel and, if that fails or the channel's
+// circuit is open, to the channel's fallbacks. fallbackFor names the channel
+// this delivery stands in for, if any; targeted tracks the channels already
+// notified for the event so fallbacks never loop.
+func (r *Router) deliver(event AlertEvent, data notifier.NotificationData, textHash, channelName, fallbackFor string, targeted map[string]bool) {
+	entry := audit.Entry{
+		Time:        event.Timestamp,
+		Alert:       event.Rule.Name,
+		Channel:     channelName,
+		State:       string(event.Type),
+		Severity:    event.Severity,
+		TextHash:    textHash,
+		Status:      audit.StatusSkipped,
+		FallbackFor: fallbackFor,
+		Fingerprint: data.Fingerprint,
+		IncidentID:  data.IncidentID,
+		Captures:    auditCaptures(data.Captures),
+	}
+
+	policy := r.channels[channelName]
+	if policy == nil {
+		policy = &channelPolicy{sendsResolved: true}
+	}
+	notifierInstance, ok := r.notifiers[channelName]
+	useFallback := false
+	now := time.Now()
+	silence, silenced := Silence{}, false
+	if r.silences != nil {
+		silence, silenced = r.silences.Match(event.Rule.Name, event.Timestamp)
+	}
+	dedupeKey := event.Hostname + "/" + event.Rule.Name + "/" + channelName
+	var sentAt time.Time
+	duplicate := false
+	if r.dedupe != nil {
+		sentAt, duplicate = r.dedupe.Duplicate(dedupeKey, data.Fingerprint, now)
+	}
+	switch {
+	case silenced:
+		log.Printf("Skipping notification for alert '%s' via channel '%s': silenced until %s", event.Rule.Name, channelName, silence.Ends.Format(time.RFC3339))
+		entry.Error = "silenced by silence " + silence.ID + " until " + silence.Ends.Format(time.RFC3339)
+	case event.Type == EventTypeResolved && !event.Rule.SendsResolved():
+		entry.Error = "notify_on_resolve is false for the rule"
+	case event.Type == EventTypeResolved && !policy.sendsResolved:
+		entry.Error = "notify_on_resolve is false for the channel"
+	case policy.disabled:
+		entry.Error = "channel is disabled"
+		useFallback = true
+	case policy.minSeverity != "" && event.Severity != "" && slices.Index(config.Severities, event.Severity) < slices.Index(config.Severities, policy.minSeverity):
+		entry.Error = "severity " + event.Severity + " is below the channel's min_severity " + policy.minSeverity
+	case !ok:
+		log.Printf("Warning: Notification channel '%s' for alert '%s' not found/configured.", channelName, event.Rule.Name)
+		entry.Status = audit.StatusFailed
+		entry.Error = "channel not found/configured"
+	case policy.breaker != nil && !policy.breaker.Allow(now):
+		log.Printf("Skipping notification for alert '%s' via channel '%s': circuit breaker open", event.Rule.Name, channelName)
+		entry.Error = "circuit breaker open until " + policy.breaker.OpenUntil().Format(time.RFC3339)
+		useFallback = true
+	case duplicate:
+		log.Printf("Skipping notification for alert '%s' via channel '%s': duplicate of the one sent at %s", event.Rule.Name, channelName, sentAt.Format(time.RFC3339))
+		entry.Error = "duplicate of the notification sent at " + sentAt.Format(time.RFC3339)
+	default:
+		err := notifier.SendWithTimeout(notifierInstance, data, r.templates, policy.timeout)
+		entry.Latency = time.Since(now)
+		if err != nil {
+			log.Printf("Failed to send notification for alert '%s' via channel '%s': %v", event.Rule.Name, channelName, err)
+			entry.Status = audit.StatusFailed
+			entry.Error = err.Error()
+			useFallback = true
+			if policy.breaker != nil && policy.breaker.Failure(time.Now()) {
+				log.Printf("Warning: Circuit breaker opened for channel '%s'; skipping it until %s.", channelName, policy.breaker.OpenUntil().Format(time.RFC3339))
+			}
+		} else {
+			log.Printf("Notification sent for alert '%s' via channel '%s' (State: %s)", event.Rule.Name, channelName, event.Type)
+			entry.Status = audit.StatusSent
+			if policy.breaker != nil {
+				policy.breaker.Success()
+			}
+			if r.dedupe != nil {
+				r.dedupe.Sent(dedupeKey, data.Fingerprint, now)
+			}
+		}
+	}
+	r.record(entry)
+
+	if !useFallback {
+		return
+	}
+	for _, fallback := range policy.fallback {
+		if targeted[fallback] {
+			continue
+		}
+		targeted[fallback] = true
+		log.Printf("Notifying fallback channel '%s' for alert '%s' instead of '%s'", fallback, event.Rule.Name, channelName)
+		r.deliver(event, data, textHash, fallback, channelName, targeted)
+	}
+}
+
+// auditCaptures converts the output of capture commands for the notification log.
+func auditCaptures(outputs []notifier.CaptureOutput) []audit.Capture {
+	var captures []audit.Capture
+	for _, o := range outputs {
+		captures = append(captures, audit.Capture{Command: o.Command, Output: o.Output, Error: o.Error})
+	}
+	return captures
+}
+
+func (r *Router) record(entry audit.Entry) {
+	if r.notificationLog == nil {
+		return
+	}
+	if err := r.notificationLog.Record(entry); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// topProcessesSample is how long CPU usage is measured for top_processes.
+const topProcessesSample = 500 * time.Millisecond
+
+// listProcesses is collector.TopProcesses, replaced in tests.
+var listProcesses = collector.TopProcesses
+
+// topProcessesFor lists the rule's top_processes, by memory for memory and
+// swap metrics and by CPU otherwise. Rules on a proc_top<i>_ metric list the
+// processes down to rank i by default, so they name the process at fault.
+func topProcessesFor(rule *AlertRule) []notifier.ProcessInfo {
+	n := rule.TopProcesses
+	by := collector.ProcessesByCPU
+	if strings.HasPrefix(rule.Metric, "mem_") || strings.HasPrefix(rule.Metric, "swap_") {
+		by = collector.ProcessesByMemory
+	}
+	var rank int
+	if _, err := fmt.Sscanf(rule.Metric, "proc_top%d_", &rank); err == nil && rank > 0 {
+		if n == 0 {
+			n = rank
+		}
+		if strings.HasSuffix(rule.Metric, "_rss_bytes") {
+			by = collector.ProcessesByMemory
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	procs, err := listProcesses(n, by, topProcessesSample)
+	if err != nil {
+		log.Printf("Warning: Failed to list top processes for alert '%s': %v", rule.Name, err)
+		return nil
+	}
+	infos := make([]notifier.ProcessInfo, len(procs))
+	for i, p := range procs {
+		infos[i] = notifier.ProcessInfo{
+			PID:          p.PID,
+			Name:         p.Name,
+			CPUPercent:   p.CPUPercent,
+			RSSBytes:     p.RSSBytes,
+			FormattedCPU: notifier.FormatUnitValue(metrics.UnitPercent, p.CPUPercent),
+			FormattedRSS: notifier.FormatUnitValue(metrics.UnitBytes, float64(p.RSSBytes)),
+		}
+	}
+	return infos
+}
+
+// NotificationDataForEvent prepares the template context for an event.
+func NotificationDataForEvent(event AlertEvent) notifier.NotificationData {
+	data := notifier.NotificationData{
+		AlertName:      event.Rule.Name,
+		MetricName:     event.Rule.Metric,
+		MetricValue:    event.MetricValue, // The value causing state change
+		ThresholdValue: event.Threshold,
+		Severity:       event.Severity,
+		Stale:          event.Stale,
+		AtStartup:      event.AtStartup,
+		Condition:      event.Rule.Condition,
+		State:          string(event.Type),
+		Hostname:       event.Hostname,
+		Time:           event.Timestamp,
+		FormattedTime:  notifier.FormatTime(event.Timestamp),
+		DurationString: event.Rule.DurationStr,
+		Aggregation:    event.Rule.Aggregation,
+		// Human-readable formatted values
+		FormattedMetricValue:    notifier.FormatValue(event.Rule.Metric, event.MetricValue),
+		FormattedThresholdValue: notifier.FormatValue(event.Rule.Metric, event.Threshold),
+		Metrics:                 event.Metrics,
+		Fingerprint:             EventFingerprint(event),
+		IncidentID:              event.IncidentID,
+	}
+	if md, ok := metrics.Lookup(event.Rule.Metric); ok {
+		data.MetricUnit = string(md.Unit)
+		data.MetricDescription = md.Description
+	}
+	data.DashboardURL = notifier.DashboardURL(data, event.Rule.Duration)
+	return data
+}
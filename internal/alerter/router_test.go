@@ -0,0 +1,290 @@
+package alerter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// recordingNotifier records the states it was asked to send.
+type recordingNotifier struct {
+	name   string
+	states []string
+}
+
+func (r *recordingNotifier) Name() string { return r.name }
+
+func (r *recordingNotifier) Send(data notifier.NotificationData, _ notifier.NotificationTemplates) error {
+	r.states = append(r.states, data.State)
+	return nil
+}
+
+func TestRouterNotifyOnResolve(t *testing.T) {
+	no := false
+	tests := []struct {
+		name        string
+		ruleResolve *bool
+		chanResolve *bool
+		want        []string
+	}{
+		{"default", nil, nil, []string{"FIRED", "RESOLVED"}},
+		{"rule", &no, nil, []string{"FIRED"}},
+		{"channel", nil, &no, []string{"FIRED"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{
+				{Name: "chat", Type: "stdout", NotifyOnResolve: tt.chanResolve},
+			}}
+			rec := &recordingNotifier{name: "chat"}
+			router := NewRouter(cfg, map[string]notifier.Notifier{"chat": rec})
+			rule := NewAlertRule(config.AlertRuleConfig{Name: "r", Channels: []string{"chat"}, NotifyOnResolve: tt.ruleResolve})
+
+			router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired})
+			router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeResolved})
+			assert.Equal(t, tt.want, rec.states)
+		})
+	}
+}
+
+// failingNotifier always fails to send.
+type failingNotifier struct{}
+
+func (failingNotifier) Name() string { return "broken" }
+
+func (failingNotifier) Send(notifier.NotificationData, notifier.NotificationTemplates) error {
+	return errors.New("connection refused")
+}
+
+func TestRouterRecordsNotifications(t *testing.T) {
+	no := false
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{
+		{Name: "chat", Type: "stdout", NotifyOnResolve: &no},
+		{Name: "broken", Type: "stdout"},
+	}}
+	router := NewRouter(cfg, map[string]notifier.Notifier{
+		"chat":   &recordingNotifier{name: "chat"},
+		"broken": failingNotifier{},
+	})
+	notificationLog := audit.NewLog(10)
+	router.SetNotificationLog(notificationLog)
+
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "cpu", Channels: []string{"chat", "broken", "missing"}})
+	now := time.Now()
+	router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired, Timestamp: now})
+	router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeResolved, Timestamp: now})
+
+	type summary struct{ State, Channel, Status, Error string }
+	var got []summary
+	for _, e := range notificationLog.Query(audit.Query{}) {
+		assert.Equal(t, "cpu", e.Alert)
+		assert.NotEmpty(t, e.TextHash)
+		got = append(got, summary{e.State, e.Channel, e.Status, e.Error})
+	}
+	assert.Equal(t, []summary{
+		{"FIRED", "chat", audit.StatusSent, ""},
+		{"FIRED", "broken", audit.StatusFailed, "connection refused"},
+		{"FIRED", "missing", audit.StatusFailed, "channel not found/configured"},
+		{"RESOLVED", "chat", audit.StatusSkipped, "notify_on_resolve is false for the channel"},
+		{"RESOLVED", "broken", audit.StatusFailed, "connection refused"},
+		{"RESOLVED", "missing", audit.StatusFailed, "channel not found/configured"},
+	}, got)
+}
+
+// hangingNotifier blocks until released.
+type hangingNotifier struct{ release chan struct{} }
+
+func (hangingNotifier) Name() string { return "hanging" }
+
+func (h hangingNotifier) Send(notifier.NotificationData, notifier.NotificationTemplates) error {
+	<-h.release
+	return nil
+}
+
+func TestRouterCircuitBreakerAndFallback(t *testing.T) {
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{
+		{Name: "broken", Type: "stdout", Fallback: []string{"chat"},
+			CircuitBreaker: &config.CircuitBreakerConfig{Failures: 2, Cooldown: time.Hour}},
+		{Name: "chat", Type: "stdout"},
+	}}
+	chat := &recordingNotifier{name: "chat"}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"broken": failingNotifier{}, "chat": chat})
+	notificationLog := audit.NewLog(20)
+	router.SetNotificationLog(notificationLog)
+
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "cpu", Channels: []string{"broken"}})
+	for i := 0; i < 3; i++ {
+		router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired})
+	}
+
+	assert.Len(t, chat.states, 3, "every event reaches the fallback")
+	var statuses []string
+	for _, e := range notificationLog.Query(audit.Query{Channel: "broken"}) {
+		statuses = append(statuses, e.Status)
+	}
+	assert.Equal(t, []string{audit.StatusFailed, audit.StatusFailed, audit.StatusSkipped}, statuses)
+	for _, e := range notificationLog.Query(audit.Query{Channel: "chat"}) {
+		assert.Equal(t, "broken", e.FallbackFor)
+	}
+}
+
+func TestRouterFallbackNotUsedForTargetedChannel(t *testing.T) {
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{
+		{Name: "broken", Type: "stdout", Fallback: []string{"chat"}},
+		{Name: "chat", Type: "stdout"},
+	}}
+	chat := &recordingNotifier{name: "chat"}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"broken": failingNotifier{}, "chat": chat})
+
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "cpu", Channels: []string{"broken", "chat"}})
+	router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired})
+	assert.Len(t, chat.states, 1)
+}
+
+func TestRouterTimeout(t *testing.T) {
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{
+		{Name: "hanging", Type: "stdout", Timeout: 20 * time.Millisecond},
+	}}
+	hanging := hangingNotifier{release: make(chan struct{})}
+	defer close(hanging.release)
+	router := NewRouter(cfg, map[string]notifier.Notifier{"hanging": hanging})
+	notificationLog := audit.NewLog(10)
+	router.SetNotificationLog(notificationLog)
+
+	start := time.Now()
+	router.Dispatch(AlertEvent{Rule: NewAlertRule(config.AlertRuleConfig{Name: "cpu", Channels: []string{"hanging"}}), Type: EventTypeFired})
+	assert.Less(t, time.Since(start), time.Second)
+
+	entries := notificationLog.Query(audit.Query{})
+	assert.Equal(t, audit.StatusFailed, entries[0].Status)
+	assert.Contains(t, entries[0].Error, "timed out")
+}
+
+func TestRouterDisabledChannel(t *testing.T) {
+	no := false
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{
+		{Name: "pager", Type: "stdout", Enabled: &no, Fallback: []string{"chat"}},
+		{Name: "chat", Type: "stdout"},
+	}}
+	chat := &recordingNotifier{name: "chat"}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"chat": chat})
+	notificationLog := audit.NewLog(10)
+	router.SetNotificationLog(notificationLog)
+
+	router.Dispatch(AlertEvent{Rule: NewAlertRule(config.AlertRuleConfig{Name: "cpu", Channels: []string{"pager"}}), Type: EventTypeFired, Timestamp: time.Now()})
+	assert.Equal(t, []string{"FIRED"}, chat.states, "fallback notified instead")
+	entries := notificationLog.Query(audit.Query{Channel: "pager"})
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, audit.StatusSkipped, entries[0].Status)
+		assert.Equal(t, "channel is disabled", entries[0].Error)
+	}
+}
+
+func TestRouterMinSeverity(t *testing.T) {
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{
+		{Name: "phone", Type: "voice", MinSeverity: config.SeverityCritical},
+	}}
+	phone := &recordingNotifier{name: "phone"}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"phone": phone})
+	notificationLog := audit.NewLog(10)
+	router.SetNotificationLog(notificationLog)
+
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "disk", Channels: []string{"phone"}})
+	router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired, Severity: config.SeverityWarning, Timestamp: time.Now()})
+	router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired, Severity: config.SeverityCritical, Timestamp: time.Now()})
+	assert.Equal(t, []string{"FIRED"}, phone.states, "only the escalation to critical")
+	entries := notificationLog.Query(audit.Query{Channel: "phone"})
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, audit.StatusSkipped, entries[0].Status)
+		assert.Equal(t, "severity warning is below the channel's min_severity critical", entries[0].Error)
+	}
+}
+
+func TestRouterTopProcesses(t *testing.T) {
+	var gotN int
+	var gotBy string
+	listProcesses = func(n int, by string, _ time.Duration) ([]collector.Process, error) {
+		gotN, gotBy = n, by
+		return []collector.Process{{PID: 42, Name: "postgres", CPUPercent: 85, RSSBytes: 512 * 1024 * 1024}}, nil
+	}
+	defer func() { listProcesses = collector.TopProcesses }()
+
+	var data []notifier.NotificationData
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{{Name: "chat", Type: "stdout"}}}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"chat": notifierFunc(func(d notifier.NotificationData) { data = append(data, d) })})
+
+	mem := NewAlertRule(config.AlertRuleConfig{Name: "mem", Metric: "mem_percent_used", Channels: []string{"chat"}, TopProcesses: 3})
+	router.Dispatch(AlertEvent{Rule: mem, Type: EventTypeFired})
+	router.Dispatch(AlertEvent{Rule: mem, Type: EventTypeResolved})
+	assert.Equal(t, 3, gotN)
+	assert.Equal(t, collector.ProcessesByMemory, gotBy)
+	if assert.Len(t, data, 2) {
+		assert.Equal(t, "postgres (pid 42): CPU 85.0%, RSS 512.0 MB", data[0].TopProcesses[0].String())
+		assert.Empty(t, data[1].TopProcesses, "only listed when firing")
+	}
+	// Rules on the top process collector's metrics list down to their rank
+	data = nil
+	top := NewAlertRule(config.AlertRuleConfig{Name: "rss", Metric: "proc_top2_rss_bytes", Channels: []string{"chat"}})
+	router.Dispatch(AlertEvent{Rule: top, Type: EventTypeFired})
+	assert.Equal(t, 2, gotN)
+	assert.Equal(t, collector.ProcessesByMemory, gotBy)
+	if assert.Len(t, data, 1) {
+		assert.Len(t, data[0].TopProcesses, 1)
+	}
+}
+
+// notifierFunc passes the data of every send to a function.
+type notifierFunc func(notifier.NotificationData)
+
+func (notifierFunc) Name() string { return "func" }
+
+func (f notifierFunc) Send(data notifier.NotificationData, _ notifier.NotificationTemplates) error {
+	f(data)
+	return nil
+}
+
+func TestRouterCaptures(t *testing.T) {
+	var data []notifier.NotificationData
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{{Name: "chat", Type: "stdout"}}}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"chat": notifierFunc(func(d notifier.NotificationData) { data = append(data, d) })})
+	notificationLog := audit.NewLog(10)
+	router.SetNotificationLog(notificationLog)
+
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "disk", Channels: []string{"chat"}, Capture: []string{"echo 93% used"}})
+	router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired})
+	router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeResolved})
+
+	if assert.Len(t, data, 2) {
+		assert.Equal(t, []notifier.CaptureOutput{{Command: "echo 93% used", Output: "93% used"}}, data[0].Captures)
+		assert.Empty(t, data[1].Captures, "only run when firing")
+	}
+	entries := notificationLog.Query(audit.Query{})
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, []audit.Capture{{Command: "echo 93% used", Output: "93% used"}}, entries[0].Captures)
+		assert.Empty(t, entries[1].Captures)
+	}
+}
+
+func TestRouterCapturesDontBlockUpdate(t *testing.T) {
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{{Name: "chat", Type: "stdout"}}}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"chat": notifierFunc(func(notifier.NotificationData) {})})
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "disk", Channels: []string{"chat"}, Capture: []string{"sleep 1"}})
+
+	done := make(chan struct{})
+	go func() {
+		router.Dispatch(AlertEvent{Rule: rule, Type: EventTypeFired})
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond) // Let the capture start
+	start := time.Now()
+	router.Update(cfg, map[string]notifier.Notifier{"chat": notifierFunc(func(notifier.NotificationData) {})})
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Update waited for the capture")
+	<-done
+}
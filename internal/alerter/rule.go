@@ -2,6 +2,7 @@ package alerter
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -12,9 +13,25 @@ import (
 // AlertState represents the current status of an alert.
 type AlertState struct {
 	IsActive         bool
+	Severity         string    // Severity of the active tier, empty when not active
+	Notified         bool      // The FIRED event was published (see minimum_firing_duration)
+	NotifyAt         time.Time // When the FIRED event is due
+	FiredAtStartup   bool      // Became active at the rule's first evaluation
+	Evaluated        bool      // The rule has been evaluated at least once
 	LastActiveTime   time.Time // When it last became active
+	IncidentID       string    // Identifies the current or last firing, see IncidentID
 	LastResolvedTime time.Time // When it last became resolved
 	LastValue        float64   // The value that triggered/resolved the alert
+
+	AckedBy    string    // Who acknowledged the active alert, cleared when it resolves
+	AckedAt    time.Time // When it was acknowledged, zero if not
+	AckComment string
+
+	ActionsDone []bool        // Per action: it was due during the current firing, cleared when it resolves
+	ActionRuns  [][]time.Time // Per action: when it ran in the last hour, for max_per_hour
+
+	WaitingForHistory bool          // Evaluation is skipped until the rule's window is covered
+	HistoryCovered    time.Duration // How much of the window the history covered at the last evaluation
 }
 
 // AlertRule is the runtime representation of an alert rule.
@@ -24,6 +41,13 @@ type AlertRule struct {
 }
 
 func NewAlertRule(cfg config.AlertRuleConfig) *AlertRule {
+	if len(cfg.Tiers) == 0 { // Not built by config.LoadConfig
+		severity := cfg.Severity
+		if severity == "" {
+			severity = config.SeverityCritical
+		}
+		cfg.Tiers = []config.ThresholdTier{{Severity: severity, Threshold: cfg.Threshold}}
+	}
 	return &AlertRule{
 		AlertRuleConfig: cfg,
 		State: AlertState{
@@ -35,11 +59,18 @@ func NewAlertRule(cfg config.AlertRuleConfig) *AlertRule {
 // Evaluate processes a set of data points against the rule.
 // Returns true if the alert condition is met, the aggregated value, and any error.
 func (ar *AlertRule) Evaluate(points []history.DataPoint) (conditionMet bool, aggregatedValue float64, err error) {
+	tier, aggregatedValue, err := ar.EvaluateTier(points)
+	return tier != nil, aggregatedValue, err
+}
+
+// EvaluateTier processes a set of data points against the rule's threshold tiers.
+// Returns the most severe tier whose condition is met (nil if none), the aggregated value, and any error.
+func (ar *AlertRule) EvaluateTier(points []history.DataPoint) (tier *config.ThresholdTier, aggregatedValue float64, err error) {
 	if len(points) == 0 && ar.Duration > 0 {
-		return false, 0, fmt.Errorf("not enough data points for duration-based alert '%s'", ar.Name)
+		return nil, 0, fmt.Errorf("not enough data points for duration-based alert '%s'", ar.Name)
 	}
     if len(points) == 0 && ar.Duration == 0 { // Instantaneous check but no data yet
-        return false, 0, fmt.Errorf("no data point available for instantaneous alert '%s'", ar.Name)
+        return nil, 0, fmt.Errorf("no data point available for instantaneous alert '%s'", ar.Name)
     }
 
 
@@ -49,12 +80,12 @@ func (ar *AlertRule) Evaluate(points []history.DataPoint) (conditionMet bool, ag
 		if len(points) > 0 {
 			valueToCompare = points[len(points)-1].Value
 		} else {
-			return false, 0, fmt.Errorf("no data points for instantaneous alert '%s'", ar.Name) // Should be caught earlier
+			return nil, 0, fmt.Errorf("no data points for instantaneous alert '%s'", ar.Name) // Should be caught earlier
 		}
 	} else { // Duration-based: aggregate
 		// Ensure we have enough data for the duration
 		if len(points) == 0 {
-			return false, 0, fmt.Errorf("not enough data points (0) for duration '%s' for alert '%s'", ar.DurationStr, ar.Name)
+			return nil, 0, fmt.Errorf("not enough data points (0) for duration '%s' for alert '%s'", ar.DurationStr, ar.Name)
 		}
 		// Check if the timespan of points covers the required duration.
 		// The history buffer should ideally provide points *within* the duration window.
@@ -63,52 +94,86 @@ func (ar *AlertRule) Evaluate(points []history.DataPoint) (conditionMet bool, ag
 		// firstPointTime := points[0].Timestamp
 		// lastPointTime := points[len(points)-1].Timestamp
 		// if lastPointTime.Sub(firstPointTime) < ar.Duration {
-		//     return false, 0, fmt.Errorf("data points span %s, less than required %s for alert '%s'",
+		//     return nil, 0, fmt.Errorf("data points span %s, less than required %s for alert '%s'",
 		//         lastPointTime.Sub(firstPointTime).String(), ar.DurationStr, ar.Name)
 		// }
 
 
 		switch strings.ToLower(ar.Aggregation) {
 		case "average":
-			sum := 0.0
+			// Downsampled points stand for several samples and are weighted accordingly
+			sum, weight := 0.0, 0
 			for _, dp := range points {
-				sum += dp.Value
+				sum += dp.Value * float64(dp.Weight())
+				weight += dp.Weight()
 			}
-			valueToCompare = sum / float64(len(points))
+			valueToCompare = sum / float64(weight)
 		case "max":
 			if len(points) > 0 {
-				valueToCompare = points[0].Value
+				valueToCompare = points[0].MaxValue()
 				for _, dp := range points {
-					if dp.Value > valueToCompare {
-						valueToCompare = dp.Value
+					if dp.MaxValue() > valueToCompare {
+						valueToCompare = dp.MaxValue()
 					}
 				}
 			} else {
-                return false, 0, fmt.Errorf("no data points to calculate max for alert '%s'", ar.Name)
+                return nil, 0, fmt.Errorf("no data points to calculate max for alert '%s'", ar.Name)
             }
 		default: // Should be caught by config validation, but default to average or error.
-			return false, 0, fmt.Errorf("unknown aggregation type '%s' for alert '%s'", ar.Aggregation, ar.Name)
+			return nil, 0, fmt.Errorf("unknown aggregation type '%s' for alert '%s'", ar.Aggregation, ar.Name)
 		}
 	}
 
 	aggregatedValue = valueToCompare // This is the value to report
 
+	for i := len(ar.Tiers) - 1; i >= 0; i-- { // Most severe first
+		met, err := ar.conditionMet(valueToCompare, ar.Tiers[i].Threshold)
+		if err != nil {
+			return nil, valueToCompare, err
+		}
+		if met {
+			return &ar.Tiers[i], aggregatedValue, nil
+		}
+	}
+	return nil, aggregatedValue, nil
+}
+
+// Tier returns the rule's threshold tier for a severity.
+func (ar *AlertRule) Tier(severity string) (config.ThresholdTier, bool) {
+	for _, tier := range ar.Tiers {
+		if tier.Severity == severity {
+			return tier, true
+		}
+	}
+	return config.ThresholdTier{}, false
+}
+
+func (ar *AlertRule) conditionMet(value, threshold float64) (bool, error) {
 	switch ar.Condition {
 	case ">":
-		conditionMet = valueToCompare > ar.Threshold
+		return value > threshold, nil
 	case "<":
-		conditionMet = valueToCompare < ar.Threshold
-	case "=":
-		conditionMet = valueToCompare == ar.Threshold // Float equality can be tricky
+		return value < threshold, nil
+	case "==", "=":
+		return approxEqual(value, threshold, ar.Epsilon), nil
 	case "!=":
-		conditionMet = valueToCompare != ar.Threshold
+		return !approxEqual(value, threshold, ar.Epsilon), nil
 	case ">=":
-		conditionMet = valueToCompare >= ar.Threshold
+		return value >= threshold, nil
 	case "<=":
-		conditionMet = valueToCompare <= ar.Threshold
+		return value <= threshold, nil
 	default:
-		return false, valueToCompare, fmt.Errorf("unknown condition '%s' for alert '%s'", ar.Condition, ar.Name)
+		return false, fmt.Errorf("unknown condition '%s' for alert '%s'", ar.Condition, ar.Name)
 	}
+}
 
-	return conditionMet, aggregatedValue, nil
+// approxEqual reports whether a and b are equal within epsilon, relative to
+// the larger magnitude once it exceeds 1. Exact float equality almost never
+// holds for computed values such as averages.
+func approxEqual(a, b, epsilon float64) bool {
+	if epsilon <= 0 {
+		epsilon = config.DefaultEpsilon
+	}
+	scale := math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= epsilon*scale
 }
@@ -0,0 +1,62 @@
+package alerter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+)
+
+func TestEvaluateConditions(t *testing.T) {
+	now := time.Now()
+	points := func(values ...float64) []history.DataPoint {
+		var dps []history.DataPoint
+		for i, v := range values {
+			dps = append(dps, history.DataPoint{Timestamp: now.Add(time.Duration(i) * time.Second), Value: v})
+		}
+		return dps
+	}
+
+	tests := []struct {
+		name      string
+		condition string
+		threshold float64
+		duration  time.Duration
+		points    []history.DataPoint
+		want      bool
+	}{
+		{"equal", "==", 0.3, 0, points(0.1 + 0.2), true},
+		{"legacy equal", "=", 0.3, 0, points(0.1 + 0.2), true},
+		{"not equal", "!=", 0.3, 0, points(0.1 + 0.2), false},
+		{"average equal", "==", 0.3, time.Second, points(0.1, 0.2, 0.6), true},
+		{"large values", "==", 1e12, 0, points(1e12 + 1e-3), true},
+		{"clearly different", "==", 1, 0, points(1.001), false},
+		{"greater", ">", 1, 0, points(2), true},
+		{"less or equal", "<=", 1, 0, points(2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := NewAlertRule(config.AlertRuleConfig{
+				Name:        tt.name,
+				Condition:   tt.condition,
+				Threshold:   tt.threshold,
+				Duration:    tt.duration,
+				Aggregation: "average",
+			})
+			met, _, err := rule.Evaluate(tt.points)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, met)
+		})
+	}
+}
+
+func TestEvaluateEpsilon(t *testing.T) {
+	rule := NewAlertRule(config.AlertRuleConfig{Name: "loose", Condition: "==", Threshold: 1, Epsilon: 0.01})
+	met, _, err := rule.Evaluate([]history.DataPoint{{Value: 1.005}})
+	require.NoError(t, err)
+	assert.True(t, met)
+}
@@ -0,0 +1,103 @@
+package alerter
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Silence mutes the notifications of matching alerts for a while. The
+// alerts are still evaluated; only their delivery is skipped.
+type Silence struct {
+	ID        string    `json:"id"`
+	Alert     string    `json:"alert"` // Alert name or shell-style pattern, e.g. "disk_*" or "*"
+	Starts    time.Time `json:"starts"`
+	Ends      time.Time `json:"ends"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+// Matches reports whether the silence mutes alertName at now.
+func (s Silence) Matches(alertName string, now time.Time) bool {
+	if now.Before(s.Starts) || !now.Before(s.Ends) {
+		return false
+	}
+	ok, _ := path.Match(s.Alert, alertName)
+	return ok
+}
+
+// Silences holds the silences of a running daemon. They are kept in memory
+// only and are lost on restart.
+type Silences struct {
+	mu     sync.Mutex
+	nextID int
+	list   []Silence
+}
+
+func NewSilences() *Silences {
+	return &Silences{nextID: 1}
+}
+
+// Add validates a silence, assigns it an ID and stores it. Starts defaults to now.
+func (s *Silences) Add(sil Silence, now time.Time) (Silence, error) {
+	if sil.Alert == "" {
+		return Silence{}, fmt.Errorf("silence is missing alert")
+	}
+	if _, err := path.Match(sil.Alert, ""); err != nil {
+		return Silence{}, fmt.Errorf("silence has invalid alert pattern %q: %w", sil.Alert, err)
+	}
+	if sil.Starts.IsZero() {
+		sil.Starts = now
+	}
+	if !sil.Ends.After(sil.Starts) || !sil.Ends.After(now) {
+		return Silence{}, fmt.Errorf("silence must end in the future and after it starts")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sil.ID = strconv.Itoa(s.nextID)
+	s.nextID++
+	s.list = append(s.list, sil)
+	return sil, nil
+}
+
+// Expire removes the silence with the given ID, reporting whether it existed.
+func (s *Silences) Expire(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, sil := range s.list {
+		if sil.ID == id {
+			s.list = append(s.list[:i], s.list[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the silences that have not ended yet, dropping the others.
+func (s *Silences) List(now time.Time) []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.list[:0]
+	for _, sil := range s.list {
+		if now.Before(sil.Ends) {
+			kept = append(kept, sil)
+		}
+	}
+	s.list = kept
+	return append([]Silence(nil), kept...)
+}
+
+// Match returns the first silence muting alertName at now.
+func (s *Silences) Match(alertName string, now time.Time) (Silence, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sil := range s.list {
+		if sil.Matches(alertName, now) {
+			return sil, true
+		}
+	}
+	return Silence{}, false
+}
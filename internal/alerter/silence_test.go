@@ -0,0 +1,64 @@
+package alerter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func TestSilences(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSilences()
+
+	_, err := s.Add(Silence{Alert: "disk_*", Ends: now.Add(-time.Minute)}, now)
+	assert.Error(t, err, "ended silence")
+	_, err = s.Add(Silence{Alert: "[", Ends: now.Add(time.Hour)}, now)
+	assert.Error(t, err, "invalid pattern")
+	_, err = s.Add(Silence{Ends: now.Add(time.Hour)}, now)
+	assert.Error(t, err, "missing alert")
+
+	disk, err := s.Add(Silence{Alert: "disk_*", Ends: now.Add(time.Hour)}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "1", disk.ID)
+	assert.Equal(t, now, disk.Starts)
+	later, err := s.Add(Silence{Alert: "High CPU", Starts: now.Add(time.Hour), Ends: now.Add(2 * time.Hour)}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2", later.ID)
+
+	_, ok := s.Match("disk_root", now)
+	assert.True(t, ok)
+	_, ok = s.Match("High CPU", now)
+	assert.False(t, ok, "not started yet")
+	sil, ok := s.Match("High CPU", now.Add(90*time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, "2", sil.ID)
+
+	assert.Len(t, s.List(now.Add(90*time.Minute)), 1, "ended silences are dropped")
+	assert.True(t, s.Expire("2"))
+	assert.False(t, s.Expire("2"))
+	assert.Empty(t, s.List(now))
+}
+
+func TestRouterSilences(t *testing.T) {
+	cfg := &config.Config{NotificationChannels: []config.NotificationChannelConfig{{Name: "chat", Type: "stdout"}}}
+	rec := &recordingNotifier{name: "chat"}
+	router := NewRouter(cfg, map[string]notifier.Notifier{"chat": rec})
+	silences := NewSilences()
+	router.SetSilences(silences)
+
+	now := time.Now()
+	_, err := silences.Add(Silence{Alert: "cpu", Ends: now.Add(time.Hour)}, now)
+	require.NoError(t, err)
+
+	cpu := NewAlertRule(config.AlertRuleConfig{Name: "cpu", Channels: []string{"chat"}})
+	mem := NewAlertRule(config.AlertRuleConfig{Name: "mem", Channels: []string{"chat"}})
+	router.Dispatch(AlertEvent{Rule: cpu, Type: EventTypeFired, Timestamp: now})
+	router.Dispatch(AlertEvent{Rule: mem, Type: EventTypeFired, Timestamp: now})
+	router.Dispatch(AlertEvent{Rule: cpu, Type: EventTypeResolved, Timestamp: now.Add(2 * time.Hour)})
+	assert.Equal(t, []string{"FIRED", "RESOLVED"}, rec.states)
+}
@@ -0,0 +1,306 @@
+// Package api serves monres' local HTTP API, used by the status subcommand
+// and by scripts that want to know what the daemon is doing.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/history"
+)
+
+// Paths served by the API.
+const (
+	StatusPath        = "/api/v1/status"
+	NotificationsPath = "/api/v1/notifications"
+	MetricsPath       = "/api/v1/metrics"
+	SilencesPath      = "/api/v1/silences"
+	AlertsPath        = "/api/v1/alerts"
+	ReloadPath        = "/api/v1/reload"
+)
+
+// Rule states reported in RuleStatus.State.
+const (
+	StateOK      = "ok"
+	StateFiring  = "firing"
+	StateWaiting = "waiting"
+)
+
+// Status is the response of GET /api/v1/status.
+type Status struct {
+	Hostname string                    `json:"hostname"`
+	Version  string                    `json:"version"`
+	Started  time.Time                 `json:"started"`
+	Rules    []RuleStatus              `json:"rules"`
+	Metrics  map[string]MetricCoverage `json:"metrics"`
+}
+
+// RuleStatus describes the state of one alert rule.
+type RuleStatus struct {
+	Name      string  `json:"name"`
+	Metric    string  `json:"metric"`
+	State     string  `json:"state"`              // StateOK, StateFiring or StateWaiting
+	Severity  string  `json:"severity,omitempty"` // Severity of the firing tier
+	LastValue float64 `json:"last_value"`
+	Window    string  `json:"window,omitempty"` // The rule's duration, empty for instantaneous rules
+	// AcknowledgedBy is who acknowledged the firing alert, empty if nobody did
+	AcknowledgedBy string `json:"acknowledged_by,omitempty"`
+	// HistoryCoverage is the fraction of the window covered by history, from 0 to 1
+	HistoryCoverage float64 `json:"history_coverage"`
+	Message         string  `json:"message"` // Human readable summary
+}
+
+// MetricCoverage describes the history held for one metric.
+type MetricCoverage struct {
+	Oldest time.Time `json:"oldest"`
+	Newest time.Time `json:"newest"`
+	Points int       `json:"points"`
+}
+
+// MetricPoint is a point of a metric's history, as served by
+// GET /api/v1/metrics/<name>. Points older than history.raw_retention are
+// aggregates: Value is their average and Samples the number of samples.
+type MetricPoint struct {
+	Time    time.Time `json:"time"`
+	Value   float64   `json:"value"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	Samples int       `json:"samples"`
+}
+
+// Server serves the API for a running alerter.
+type Server struct {
+	alerter  *alerter.Alerter
+	history  *history.MetricHistoryBuffer
+	hostname string
+	started  time.Time
+	srv      *http.Server
+
+	notificationLog *audit.Log // Optional
+
+	// Write endpoints, see write.go
+	token    string
+	silences *alerter.Silences
+	reload   func() error
+	now      func() time.Time
+
+	slackSecret string // Enables slash commands, see slack.go
+
+	heartbeats HeartbeatRecorder // Enables cron heartbeats, see heartbeat.go
+}
+
+func NewServer(listen, hostname string, a *alerter.Alerter, hist *history.MetricHistoryBuffer) *Server {
+	s := &Server{
+		alerter:  a,
+		history:  hist,
+		hostname: hostname,
+		started:  time.Now(),
+		now:      time.Now,
+	}
+	s.srv = &http.Server{
+		Addr:              listen,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the API's request handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+StatusPath, s.handleStatus)
+	mux.HandleFunc("GET "+NotificationsPath, s.handleNotifications)
+	mux.HandleFunc("GET "+MetricsPath+"/{name}", s.handleMetric)
+	mux.HandleFunc("GET "+SilencesPath, s.handleListSilences)
+	mux.HandleFunc("POST "+SilencesPath, s.authorized(s.handleCreateSilence))
+	mux.HandleFunc("DELETE "+SilencesPath+"/{id}", s.authorized(s.handleExpireSilence))
+	mux.HandleFunc("POST "+AlertsPath+"/{name}/ack", s.authorized(s.handleAck))
+	mux.HandleFunc("POST "+ReloadPath, s.authorized(s.handleReload))
+	mux.HandleFunc("POST "+SlackCommandPath, s.handleSlackCommand)
+	mux.HandleFunc("GET "+HeartbeatPath+"/{job}", s.handleHeartbeat)
+	mux.HandleFunc("POST "+HeartbeatPath+"/{job}", s.handleHeartbeat)
+	return mux
+}
+
+// SetNotificationLog serves the notification attempts recorded in l.
+func (s *Server) SetNotificationLog(l *audit.Log) {
+	s.notificationLog = l
+}
+
+// Start listens on the configured address and serves requests in the background.
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.srv.Addr, err)
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Error: API server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops the server, waiting for active requests until ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// Status builds the current status.
+func (s *Server) Status() Status {
+	status := Status{
+		Hostname: s.hostname,
+		Version:  buildinfo.GetVersion(),
+		Started:  s.started,
+		Metrics:  make(map[string]MetricCoverage),
+	}
+	for _, rs := range s.alerter.Status() {
+		rule := RuleStatus{
+			Name:            rs.Name,
+			Metric:          rs.Metric,
+			State:           StateOK,
+			Severity:        rs.Severity,
+			LastValue:       rs.LastValue,
+			HistoryCoverage: rs.Coverage(),
+			Message:         rs.String(),
+			AcknowledgedBy:  rs.AckedBy,
+		}
+		if rs.Window > 0 {
+			rule.Window = rs.Window.String()
+		}
+		switch {
+		case rs.WaitingForHistory:
+			rule.State = StateWaiting
+		case rs.Active:
+			rule.State = StateFiring
+		}
+		status.Rules = append(status.Rules, rule)
+	}
+	for name, c := range s.history.AllCoverage() {
+		status.Metrics[name] = MetricCoverage{Oldest: c.Oldest, Newest: c.Newest, Points: c.Points}
+	}
+	return status
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Status())
+}
+
+// handleNotifications serves the recorded notification attempts, filtered by
+// the alert, channel, failed, since (RFC 3339) and limit query parameters.
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if s.notificationLog == nil {
+		writeError(w, http.StatusNotFound, "notification log is not enabled")
+		return
+	}
+	q, err := ParseNotificationQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries := s.notificationLog.Query(q)
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleMetric serves the history held for a metric, oldest first, since
+// the time in the since query parameter (RFC 3339) or all of it.
+func (s *Server) handleMetric(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	c, ok := s.history.Coverage(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no history for metric %q", name))
+		return
+	}
+	since := c.Oldest
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid since parameter %q, expected RFC 3339", v))
+			return
+		}
+		since = t
+	}
+	now := s.now()
+	points := []MetricPoint{}
+	for _, dp := range s.history.GetDataPointsForDuration(name, now.Sub(since), now) {
+		if dp.Timestamp.Before(since) {
+			continue // Part of an aggregate reaching before since
+		}
+		points = append(points, MetricPoint{Time: dp.Timestamp, Value: dp.Value, Min: dp.MinValue(), Max: dp.MaxValue(), Samples: dp.Weight()})
+	}
+	writeJSON(w, http.StatusOK, points)
+}
+
+// ParseNotificationQuery reads an audit.Query from URL query parameters.
+func ParseNotificationQuery(values url.Values) (audit.Query, error) {
+	q := audit.Query{
+		Alert:   values.Get("alert"),
+		Channel: values.Get("channel"),
+	}
+	var err error
+	if v := values.Get("failed"); v != "" {
+		if q.FailedOnly, err = strconv.ParseBool(v); err != nil {
+			return q, fmt.Errorf("invalid failed parameter %q", v)
+		}
+	}
+	if v := values.Get("since"); v != "" {
+		if q.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return q, fmt.Errorf("invalid since parameter %q, expected RFC 3339", v)
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 0 {
+			return q, fmt.Errorf("invalid limit parameter %q", v)
+		}
+	}
+	return q, nil
+}
+
+// NotificationQueryValues encodes an audit.Query as URL query parameters.
+func NotificationQueryValues(q audit.Query) url.Values {
+	values := url.Values{}
+	if q.Alert != "" {
+		values.Set("alert", q.Alert)
+	}
+	if q.Channel != "" {
+		values.Set("channel", q.Channel)
+	}
+	if q.FailedOnly {
+		values.Set("failed", "true")
+	}
+	if !q.Since.IsZero() {
+		values.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("Warning: Failed to write API response: %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/audit"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+)
+
+func TestStatus(t *testing.T) {
+	cfg := &config.Config{
+		EffectiveHostname: "web-1",
+		Alerts: []config.AlertRuleConfig{
+			{Name: "High CPU", Metric: "cpu_percent_total", Condition: ">", Threshold: 90, Aggregation: "average", DurationStr: "5m", Duration: 5 * time.Minute},
+			{Name: "Memory", Metric: "mem_percent_used", Condition: ">", Threshold: 50},
+		},
+	}
+	hist := history.NewMetricHistoryBuffer(5*time.Minute, 10*time.Second)
+	a, err := alerter.NewAlerter(cfg, hist)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var now time.Time
+	for i := 0; i <= 12; i++ { // Two minutes of samples
+		now = start.Add(time.Duration(i) * 10 * time.Second)
+		hist.AddDataPoint("cpu_percent_total", 10, now)
+		hist.AddDataPoint("mem_percent_used", 80, now)
+		a.CheckAndNotify(now, nil)
+	}
+
+	srv := NewServer("127.0.0.1:0", cfg.EffectiveHostname, a, hist)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	status, err := FetchStatus(context.Background(), strings.TrimPrefix(ts.URL, "http://"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "web-1", status.Hostname)
+	require.Len(t, status.Rules, 2)
+
+	cpu := status.Rules[0]
+	assert.Equal(t, StateWaiting, cpu.State)
+	assert.Equal(t, "5m0s", cpu.Window)
+	assert.InDelta(t, 0.4, cpu.HistoryCoverage, 0.001)
+	assert.Equal(t, "rule High CPU waiting for history (40% of 5m window)", cpu.Message)
+
+	mem := status.Rules[1]
+	assert.Equal(t, StateFiring, mem.State)
+	assert.Equal(t, 80.0, mem.LastValue)
+	assert.Equal(t, 1.0, mem.HistoryCoverage)
+
+	assert.Equal(t, MetricCoverage{Oldest: start, Newest: now, Points: 13}, status.Metrics["cpu_percent_total"])
+}
+
+func TestFetchStatusUnreachable(t *testing.T) {
+	ts := httptest.NewServer(nil)
+	addr := strings.TrimPrefix(ts.URL, "http://")
+	ts.Close()
+
+	_, err := FetchStatus(context.Background(), addr)
+	assert.Error(t, err)
+}
+
+func TestNotifications(t *testing.T) {
+	cfg := &config.Config{}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := alerter.NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	srv := NewServer("127.0.0.1:0", "web-1", a, hist)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+
+	_, err = FetchNotifications(context.Background(), addr, audit.Query{})
+	assert.ErrorContains(t, err, "notification log is not enabled")
+
+	notificationLog := audit.NewLog(10)
+	srv.SetNotificationLog(notificationLog)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, status := range []string{audit.StatusSent, audit.StatusFailed, audit.StatusFailed} {
+		require.NoError(t, notificationLog.Record(audit.Entry{
+			Time: start.Add(time.Duration(i) * time.Minute), Alert: "cpu", Channel: "email", Status: status, Error: "timeout",
+		}))
+	}
+
+	entries, err := FetchNotifications(context.Background(), addr, audit.Query{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	entries, err = FetchNotifications(context.Background(), addr, audit.Query{FailedOnly: true, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, start.Add(2*time.Minute), entries[0].Time)
+
+	entries, err = FetchNotifications(context.Background(), addr, audit.Query{Channel: "telegram"})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMetric(t *testing.T) {
+	hist := history.NewDownsampledMetricHistoryBuffer(time.Hour, 10*time.Second, time.Minute, 5*time.Minute)
+	a, err := alerter.NewAlerter(&config.Config{}, hist)
+	require.NoError(t, err)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var now time.Time
+	for i := 0; i < 60; i++ { // Ten minutes of samples, four downsampled
+		now = start.Add(time.Duration(i) * 10 * time.Second)
+		hist.AddDataPoint("cpu_percent_total", float64(i), now)
+	}
+	srv := NewServer("127.0.0.1:0", "web-1", a, hist)
+	srv.now = func() time.Time { return now }
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+
+	points, err := FetchMetric(context.Background(), addr, "cpu_percent_total", time.Time{})
+	require.NoError(t, err)
+	total := 0
+	for _, p := range points {
+		total += p.Samples
+	}
+	assert.Equal(t, 60, total)
+	assert.Equal(t, MetricPoint{Time: start, Value: 14.5, Min: 0, Max: 29, Samples: 30}, points[0])
+	assert.Equal(t, MetricPoint{Time: now, Value: 59, Min: 59, Max: 59, Samples: 1}, points[len(points)-1])
+
+	points, err = FetchMetric(context.Background(), addr, "cpu_percent_total", now.Add(-20*time.Second))
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, 57.0, points[0].Value)
+
+	_, err = FetchMetric(context.Background(), addr, "mem_percent_used", time.Time{})
+	assert.ErrorContains(t, err, `no history for metric "mem_percent_used"`)
+}
+
+func TestParseNotificationQuery(t *testing.T) {
+	q := audit.Query{Alert: "cpu", Channel: "email", FailedOnly: true, Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Limit: 5}
+	parsed, err := ParseNotificationQuery(NotificationQueryValues(q))
+	require.NoError(t, err)
+	assert.Equal(t, q, parsed)
+
+	for _, raw := range []string{"failed=maybe", "since=yesterday", "limit=-1"} {
+		values, err := url.ParseQuery(raw)
+		require.NoError(t, err)
+		_, err = ParseNotificationQuery(values)
+		assert.Error(t, err, raw)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mattmezza/monres/internal/audit"
+)
+
+// FetchStatus queries the status of the daemon whose API listens on addr.
+func FetchStatus(ctx context.Context, addr string) (*Status, error) {
+	var status Status
+	if err := get(ctx, addr, StatusPath, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// FetchNotifications queries the notification attempts recorded by the daemon whose API listens on addr.
+func FetchNotifications(ctx context.Context, addr string, q audit.Query) ([]audit.Entry, error) {
+	var entries []audit.Entry
+	path := NotificationsPath
+	if values := NotificationQueryValues(q); len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+	if err := get(ctx, addr, path, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FetchMetric queries the history of a metric held by the daemon whose API
+// listens on addr, since the given time or all of it if since is zero.
+func FetchMetric(ctx context.Context, addr, name string, since time.Time) ([]MetricPoint, error) {
+	var points []MetricPoint
+	path := MetricsPath + "/" + url.PathEscape(name)
+	if !since.IsZero() {
+		path += "?" + url.Values{"since": {since.Format(time.RFC3339)}}.Encode()
+	}
+	if err := get(ctx, addr, path, &points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// get requests path from the API and decodes the JSON response into v.
+func get(ctx context.Context, addr, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach monres API at %s: %w", addr, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("monres API at %s returned %s: %s", addr, resp.Status, apiErr.Error)
+		}
+		return fmt.Errorf("monres API at %s returned %s", addr, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HeartbeatPath receives the heartbeats of cron jobs, e.g.
+// "curl -fsS http://127.0.0.1:9600/api/v1/heartbeat/backup" after a
+// successful backup.
+const HeartbeatPath = "/api/v1/heartbeat"
+
+// HeartbeatRecorder records the successful runs of cron jobs.
+type HeartbeatRecorder interface {
+	// Beat records a run of job, returning false for unknown jobs.
+	Beat(job string) bool
+}
+
+// SetHeartbeats enables the heartbeat endpoint, recording heartbeats in h.
+// Like the read endpoints it needs no token, so that jobs can report with a
+// plain curl.
+func (s *Server) SetHeartbeats(h HeartbeatRecorder) {
+	s.heartbeats = h
+}
+
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if s.heartbeats == nil {
+		writeError(w, http.StatusNotFound, "no cron jobs are configured")
+		return
+	}
+	job := r.PathValue("job")
+	if !s.heartbeats.Beat(job) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown cron job %q", job))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHeartbeats map[string]int
+
+func (f fakeHeartbeats) Beat(job string) bool {
+	if _, ok := f[job]; !ok {
+		return false
+	}
+	f[job]++
+	return true
+}
+
+func TestHeartbeatEndpoint(t *testing.T) {
+	srv, _, _ := newWriteServer(t, "s3cret")
+	assert.Equal(t, http.StatusNotFound, do(t, srv, "GET", HeartbeatPath+"/backup", "", "").Code, "no cron jobs")
+
+	beats := fakeHeartbeats{"backup": 0}
+	srv.SetHeartbeats(beats)
+	assert.Equal(t, http.StatusOK, do(t, srv, "GET", HeartbeatPath+"/backup", "", "").Code)
+	assert.Equal(t, http.StatusOK, do(t, srv, "POST", HeartbeatPath+"/backup", "", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(t, srv, "POST", HeartbeatPath+"/restore", "", "").Code)
+	assert.Equal(t, 2, beats["backup"])
+}
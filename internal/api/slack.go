@@ -0,0 +1,182 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/util"
+)
+
+// SlackCommandPath receives Slack slash commands, e.g. "/monres status".
+const SlackCommandPath = "/api/v1/slack/command"
+
+// slackMaxSkew is how old a signed Slack request may be, to prevent replays.
+const slackMaxSkew = 5 * time.Minute
+
+// slackMaxMetrics bounds the lines of a metrics reply.
+const slackMaxMetrics = 40
+
+const slackUsage = "Usage:\n" +
+	"/monres status - state of every alert rule\n" +
+	"/monres silence <alert> <duration> [comment] - mute an alert, e.g. /monres silence disk_* 1h resizing\n" +
+	"/monres metrics [prefix] - latest metric values, e.g. /monres metrics cpu"
+
+// slackResponse is the reply to a slash command. Ephemeral replies are only
+// shown to the user who ran the command.
+type slackResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// SetSlackSigningSecret enables the Slack slash command endpoint, which
+// only accepts requests signed with secret.
+func (s *Server) SetSlackSigningSecret(secret string) {
+	s.slackSecret = secret
+}
+
+// SignSlackRequest returns the X-Slack-Signature of a request: "v0=" followed
+// by the hex HMAC-SHA256 of "v0:<timestamp>:<body>".
+func SignSlackRequest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySlackRequest checks the signature and age of a Slack request.
+func verifySlackRequest(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid X-Slack-Request-Timestamp")
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return errors.New("request timestamp is too old")
+	}
+	expected := SignSlackRequest(secret, timestamp, body)
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(expected)) {
+		return errors.New("invalid X-Slack-Signature")
+	}
+	return nil
+}
+
+func (s *Server) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if s.slackSecret == "" {
+		writeError(w, http.StatusNotFound, "slack commands are disabled (set MONRES_SLACK_SIGNING_SECRET)")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if err := verifySlackRequest(s.slackSecret, r.Header, body, s.now()); err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+	user := form.Get("user_name")
+	log.Printf("Slack command from %s: %s %s", user, form.Get("command"), form.Get("text"))
+	writeJSON(w, http.StatusOK, slackResponse{ResponseType: "ephemeral", Text: s.slackCommand(form.Get("text"), user)})
+}
+
+// slackCommand runs the text of a slash command and returns the reply.
+func (s *Server) slackCommand(text, user string) string {
+	args := strings.Fields(text)
+	if len(args) == 0 {
+		return slackUsage
+	}
+	switch args[0] {
+	case "status":
+		return s.slackStatus()
+	case "silence":
+		return s.slackSilence(args[1:], user)
+	case "metrics":
+		prefix := ""
+		if len(args) > 1 {
+			prefix = args[1]
+		}
+		return s.slackMetrics(prefix)
+	default:
+		return fmt.Sprintf("Unknown command %q.\n%s", args[0], slackUsage)
+	}
+}
+
+func (s *Server) slackStatus() string {
+	status := s.Status()
+	var b strings.Builder
+	fmt.Fprintf(&b, "monres %s on %s\n", status.Version, status.Hostname)
+	if len(status.Rules) == 0 {
+		b.WriteString("no alert rules configured")
+	}
+	for _, rule := range status.Rules {
+		b.WriteString(rule.Message + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (s *Server) slackSilence(args []string, user string) string {
+	if s.silences == nil {
+		return "Silences are not enabled."
+	}
+	if len(args) < 2 {
+		return "Usage: /monres silence <alert> <duration> [comment]"
+	}
+	d, err := util.ParseDurationString(args[1])
+	if err != nil {
+		return fmt.Sprintf("Invalid duration %q: %v", args[1], err)
+	}
+	now := s.now()
+	sil, err := s.silences.Add(alerter.Silence{
+		Alert:     args[0],
+		Ends:      now.Add(d),
+		Comment:   strings.Join(args[2:], " "),
+		CreatedBy: user,
+	}, now)
+	if err != nil {
+		return fmt.Sprintf("Failed to silence: %v", err)
+	}
+	log.Printf("Silence %s created for '%s' until %s by %s via Slack", sil.ID, sil.Alert, sil.Ends.Format(time.RFC3339), user)
+	return fmt.Sprintf("Silenced %s until %s (silence %s).", sil.Alert, sil.Ends.Format(time.RFC3339), sil.ID)
+}
+
+func (s *Server) slackMetrics(prefix string) string {
+	var names []string
+	for name := range s.history.AllCoverage() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("No metrics starting with %q.", prefix)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for i, name := range names {
+		if i == slackMaxMetrics {
+			fmt.Fprintf(&b, "... and %d more, narrow down with a prefix", len(names)-i)
+			break
+		}
+		if dp, ok := s.history.GetLatestDataPoint(name); ok {
+			fmt.Fprintf(&b, "%s: %s\n", name, notifier.FormatValue(name, dp.Value))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignSlackRequest(t *testing.T) {
+	// Example from Slack's "Verifying requests from Slack" documentation
+	body := "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	assert.Equal(t, "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+		SignSlackRequest("8f742231b10e8888abcd99yyyzzz85a5", "1531420618", []byte(body)))
+}
+
+func slackCommand(t *testing.T, srv *Server, secret, text string, at time.Time) (int, string) {
+	t.Helper()
+	body := url.Values{"command": {"/monres"}, "text": {text}, "user_name": {"ops"}}.Encode()
+	req := httptest.NewRequest("POST", SlackCommandPath, strings.NewReader(body))
+	ts := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", SignSlackRequest(secret, ts, []byte(body)))
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	var resp slackResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+	return rec.Code, resp.Text
+}
+
+func TestSlackCommands(t *testing.T) {
+	srv, a, hist := newWriteServer(t, "")
+	now := srv.now()
+
+	code, _ := slackCommand(t, srv, "secret", "status", now)
+	assert.Equal(t, http.StatusNotFound, code, "disabled without a signing secret")
+
+	srv.SetSlackSigningSecret("secret")
+	code, _ = slackCommand(t, srv, "wrong", "status", now)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = slackCommand(t, srv, "secret", "status", now.Add(-10*time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, code, "replayed request")
+
+	hist.AddDataPoint("mem_percent_used", 80, now)
+	hist.AddDataPoint("cpu_percent_total", 12.5, now)
+	a.CheckAndNotify(now, nil)
+
+	code, text := slackCommand(t, srv, "secret", "status", now)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, text, "rule Memory firing")
+
+	_, text = slackCommand(t, srv, "secret", "silence Memory 1h upgrading RAM", now)
+	assert.Equal(t, "Silenced Memory until 2024-01-01T13:00:00Z (silence 1).", text)
+	sils := srv.silences.List(now)
+	require.Len(t, sils, 1)
+	assert.Equal(t, "ops", sils[0].CreatedBy)
+	assert.Equal(t, "upgrading RAM", sils[0].Comment)
+
+	_, text = slackCommand(t, srv, "secret", "silence Memory", now)
+	assert.Contains(t, text, "Usage")
+
+	_, text = slackCommand(t, srv, "secret", "metrics cpu", now)
+	assert.Equal(t, "cpu_percent_total: 12.5%", text)
+
+	_, text = slackCommand(t, srv, "secret", "reboot", now)
+	assert.Contains(t, text, `Unknown command "reboot"`)
+}
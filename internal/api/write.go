@@ -0,0 +1,181 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/util"
+)
+
+// maxRequestBody bounds the size of write request bodies.
+const maxRequestBody = 64 << 10
+
+// SilenceRequest is the body of POST /api/v1/silences. Either Duration
+// (e.g. "2h") or Ends must be set.
+type SilenceRequest struct {
+	Alert     string    `json:"alert"` // Alert name or shell-style pattern
+	Duration  string    `json:"duration,omitempty"`
+	Starts    time.Time `json:"starts,omitempty"` // Defaults to now
+	Ends      time.Time `json:"ends,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+// AckRequest is the body of POST /api/v1/alerts/{name}/ack.
+type AckRequest struct {
+	By      string `json:"by"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// SetToken enables the write endpoints, which require token as a bearer
+// token. They are refused when token is empty.
+func (s *Server) SetToken(token string) {
+	s.token = token
+}
+
+// SetSilences serves and manages the silences applied by the router.
+func (s *Server) SetSilences(silences *alerter.Silences) {
+	s.silences = silences
+}
+
+// SetReloadFunc sets the function reloading the configuration on POST /api/v1/reload.
+func (s *Server) SetReloadFunc(reload func() error) {
+	s.reload = reload
+}
+
+// authorized wraps a write handler, checking the bearer token.
+func (s *Server) authorized(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" {
+			writeError(w, http.StatusForbidden, "write endpoints are disabled (set MONRES_API_TOKEN)")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="monres"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		h(w, r)
+	}
+}
+
+func (s *Server) handleListSilences(w http.ResponseWriter, r *http.Request) {
+	if s.silences == nil {
+		writeError(w, http.StatusNotFound, "silences are not enabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.silences.List(s.now()))
+}
+
+func (s *Server) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	if s.silences == nil {
+		writeError(w, http.StatusNotFound, "silences are not enabled")
+		return
+	}
+	var req SilenceRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := s.now()
+	sil := alerter.Silence{
+		Alert:     req.Alert,
+		Starts:    req.Starts,
+		Ends:      req.Ends,
+		Comment:   req.Comment,
+		CreatedBy: req.CreatedBy,
+	}
+	switch {
+	case req.Duration != "" && !req.Ends.IsZero():
+		writeError(w, http.StatusBadRequest, "set either duration or ends, not both")
+		return
+	case req.Duration != "":
+		d, err := util.ParseDurationString(req.Duration)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration: %v", err))
+			return
+		}
+		start := req.Starts
+		if start.IsZero() {
+			start = now
+		}
+		sil.Ends = start.Add(d)
+	case req.Ends.IsZero():
+		writeError(w, http.StatusBadRequest, "silence needs a duration or an end time")
+		return
+	}
+	created, err := s.silences.Add(sil, now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("Silence %s created for '%s' until %s by %s: %s", created.ID, created.Alert, created.Ends.Format(time.RFC3339), created.CreatedBy, created.Comment)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleExpireSilence(w http.ResponseWriter, r *http.Request) {
+	if s.silences == nil {
+		writeError(w, http.StatusNotFound, "silences are not enabled")
+		return
+	}
+	id := r.PathValue("id")
+	if !s.silences.Expire(id) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no silence with id %s", id))
+		return
+	}
+	log.Printf("Silence %s expired through the API", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAck(w http.ResponseWriter, r *http.Request) {
+	var req AckRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.By == "" {
+		writeError(w, http.StatusBadRequest, "ack is missing by")
+		return
+	}
+	name := r.PathValue("name")
+	if err := s.alerter.Acknowledge(name, req.By, req.Comment, s.now()); err != nil {
+		code := http.StatusConflict
+		if errors.Is(err, alerter.ErrUnknownRule) {
+			code = http.StatusNotFound
+		}
+		writeError(w, code, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "acknowledged"})
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if s.reload == nil {
+		writeError(w, http.StatusNotFound, "reload is not available")
+		return
+	}
+	if err := s.reload(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "reloaded"})
+}
+
+// decodeBody decodes a JSON request body into v, rejecting unknown fields.
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+)
+
+func newWriteServer(t *testing.T, token string) (*Server, *alerter.Alerter, *history.MetricHistoryBuffer) {
+	t.Helper()
+	cfg := &config.Config{Alerts: []config.AlertRuleConfig{
+		{Name: "Memory", Metric: "mem_percent_used", Condition: ">", Threshold: 50},
+	}}
+	hist := history.NewMetricHistoryBuffer(time.Minute, time.Second)
+	a, err := alerter.NewAlerter(cfg, hist)
+	require.NoError(t, err)
+	srv := NewServer("127.0.0.1:0", "web-1", a, hist)
+	srv.SetToken(token)
+	srv.SetSilences(alerter.NewSilences())
+	srv.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return srv, a, hist
+}
+
+func do(t *testing.T, srv *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWriteEndpointsAuth(t *testing.T) {
+	srv, _, _ := newWriteServer(t, "")
+	assert.Equal(t, http.StatusForbidden, do(t, srv, "POST", ReloadPath, "anything", "").Code)
+
+	srv.SetToken("s3cret")
+	assert.Equal(t, http.StatusUnauthorized, do(t, srv, "POST", ReloadPath, "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(t, srv, "POST", ReloadPath, "wrong", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(t, srv, "POST", ReloadPath, "s3cret", "").Code, "no reload func")
+}
+
+func TestSilenceEndpoints(t *testing.T) {
+	srv, _, _ := newWriteServer(t, "s3cret")
+
+	rec := do(t, srv, "POST", SilencesPath, "s3cret", `{"alert": "disk_*", "duration": "2h", "comment": "resizing", "created_by": "ops"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created alerter.Silence
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "1", created.ID)
+	assert.Equal(t, time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC), created.Ends)
+
+	assert.Equal(t, http.StatusBadRequest, do(t, srv, "POST", SilencesPath, "s3cret", `{"alert": "cpu"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(t, srv, "POST", SilencesPath, "s3cret", `{"alert": "cpu", "duration": "1h", "ends": "2024-01-01T13:00:00Z"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(t, srv, "POST", SilencesPath, "s3cret", `{"alert": "cpu", "duraton": "1h"}`).Code)
+
+	rec = do(t, srv, "GET", SilencesPath, "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list []alerter.Silence
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, "resizing", list[0].Comment)
+
+	assert.Equal(t, http.StatusNoContent, do(t, srv, "DELETE", SilencesPath+"/1", "s3cret", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(t, srv, "DELETE", SilencesPath+"/1", "s3cret", "").Code)
+}
+
+func TestAckEndpoint(t *testing.T) {
+	srv, a, hist := newWriteServer(t, "s3cret")
+
+	assert.Equal(t, http.StatusNotFound, do(t, srv, "POST", AlertsPath+"/Nope/ack", "s3cret", `{"by": "ops"}`).Code)
+	assert.Equal(t, http.StatusConflict, do(t, srv, "POST", AlertsPath+"/Memory/ack", "s3cret", `{"by": "ops"}`).Code)
+
+	now := time.Now()
+	hist.AddDataPoint("mem_percent_used", 80, now)
+	a.CheckAndNotify(now, nil)
+	assert.Equal(t, http.StatusBadRequest, do(t, srv, "POST", AlertsPath+"/Memory/ack", "s3cret", `{}`).Code)
+	rec := do(t, srv, "POST", AlertsPath+"/Memory/ack", "s3cret", `{"by": "ops", "comment": "on it"}`)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "ops", srv.Status().Rules[0].AcknowledgedBy)
+}
+
+func TestReloadEndpoint(t *testing.T) {
+	srv, _, _ := newWriteServer(t, "s3cret")
+	var reloadErr error
+	srv.SetReloadFunc(func() error { return reloadErr })
+
+	assert.Equal(t, http.StatusOK, do(t, srv, "POST", ReloadPath, "s3cret", "").Code)
+	reloadErr = errors.New("alert rule 'x' missing metric")
+	rec := do(t, srv, "POST", ReloadPath, "s3cret", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "missing metric")
+}
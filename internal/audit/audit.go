@@ -0,0 +1,204 @@
+// Package audit records every notification attempt, so "why didn't I get
+// paged?" can be answered after the fact.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Delivery statuses.
+const (
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped" // Deliberately not sent, e.g. notify_on_resolve: false
+)
+
+// DefaultMaxEntries is how many recent entries a Log keeps in memory.
+const DefaultMaxEntries = 1000
+
+// Entry is one notification attempt of an alert event on a channel.
+type Entry struct {
+	Time     time.Time     `json:"time"`
+	Alert    string        `json:"alert"`
+	Channel  string        `json:"channel"`
+	State    string        `json:"state"` // "FIRED" or "RESOLVED"
+	Severity string        `json:"severity,omitempty"`
+	TextHash string        `json:"text_hash,omitempty"` // See HashText
+	Status   string        `json:"status"`              // StatusSent, StatusFailed or StatusSkipped
+	Error    string        `json:"error,omitempty"`     // Why it failed or was skipped
+	Latency  time.Duration `json:"latency"`
+	Retries  int           `json:"retries"`
+	// FallbackFor names the channel this attempt stood in for, if it was a fallback
+	FallbackFor string `json:"fallback_for,omitempty"`
+	// Fingerprint identifies the alert, state and severity notified
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// IncidentID identifies the firing of the alert, the same when it resolves
+	IncidentID string `json:"incident_id,omitempty"`
+	// Captures holds the output of the alert's capture commands, when it fired
+	Captures []Capture `json:"captures,omitempty"`
+}
+
+// Capture is the output of a capture command run when an alert fired, kept
+// for diagnosis after the condition has cleared.
+type Capture struct {
+	Command string `json:"command"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HashText returns a short hash of a rendered notification, enough to tell
+// whether two attempts sent the same text without storing it.
+func HashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Query selects entries of a Log. Zero fields match everything.
+type Query struct {
+	Alert      string
+	Channel    string
+	FailedOnly bool // Only entries that were not sent
+	Since      time.Time
+	Limit      int // Most recent entries only
+}
+
+// Match reports whether an entry satisfies the query, ignoring Limit.
+func (q Query) Match(e Entry) bool {
+	switch {
+	case q.Alert != "" && e.Alert != q.Alert,
+		q.Channel != "" && e.Channel != q.Channel,
+		q.FailedOnly && e.Status == StatusSent,
+		!q.Since.IsZero() && e.Time.Before(q.Since):
+		return false
+	}
+	return true
+}
+
+// Filter returns the entries matching q, oldest first.
+func Filter(entries []Entry, q Query) []Entry {
+	var result []Entry
+	for _, e := range entries {
+		if q.Match(e) {
+			result = append(result, e)
+		}
+	}
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+	return result
+}
+
+// Log keeps the most recent entries in memory and, when it has a file,
+// appends every entry to it as one JSON object per line.
+type Log struct {
+	mu         sync.Mutex
+	entries    []Entry
+	maxEntries int
+	file       *os.File
+	enc        *json.Encoder
+}
+
+// NewLog creates an in-memory log keeping up to maxEntries entries.
+func NewLog(maxEntries int) *Log {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Log{maxEntries: maxEntries}
+}
+
+// Open creates a log backed by a file, which is created if needed. The most
+// recent entries already in the file are loaded, so they survive restarts.
+func Open(path string, maxEntries int) (*Log, error) {
+	l := NewLog(maxEntries)
+	entries, err := ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	l.append(entries...)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification log %s: %w", path, err)
+	}
+	l.file = file
+	l.enc = json.NewEncoder(file)
+	return l, nil
+}
+
+// Record adds an entry to the log.
+func (l *Log) Record(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.append(e)
+	if l.enc == nil {
+		return nil
+	}
+	if err := l.enc.Encode(e); err != nil {
+		return fmt.Errorf("failed to write notification log entry: %w", err)
+	}
+	return nil
+}
+
+// append adds entries to the in-memory window. Callers hold l.mu or own l.
+func (l *Log) append(entries ...Entry) {
+	l.entries = append(l.entries, entries...)
+	if len(l.entries) > l.maxEntries {
+		l.entries = append(l.entries[:0], l.entries[len(l.entries)-l.maxEntries:]...)
+	}
+}
+
+// Query returns the entries in memory matching q, oldest first.
+func (l *Log) Query(q Query) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Filter(l.entries, q)
+}
+
+// Close closes the log file, if any.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file, l.enc = nil, nil
+	return err
+}
+
+// ReadFile reads all entries of a log file. Malformed lines, such as one cut
+// short by a crash, are skipped.
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification log %s: %w", path, err)
+	}
+	defer file.Close()
+	return read(file)
+}
+
+func read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notification log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogPersistsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l, err := Open(path, 2)
+	require.NoError(t, err)
+	for i, status := range []string{StatusSent, StatusFailed, StatusSent} {
+		require.NoError(t, l.Record(Entry{
+			Time:    start.Add(time.Duration(i) * time.Minute),
+			Alert:   "High CPU",
+			Channel: "email",
+			Status:  status,
+			Latency: 150 * time.Millisecond,
+		}))
+	}
+	assert.Len(t, l.Query(Query{}), 2) // Only the last maxEntries in memory
+	require.NoError(t, l.Close())
+
+	entries, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, 150*time.Millisecond, entries[0].Latency)
+
+	// A line cut short by a crash doesn't prevent reopening
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"time":"2024-01-01T00:0`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	l, err = Open(path, 10)
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Len(t, l.Query(Query{}), 3)
+}
+
+func TestQuery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: start, Alert: "cpu", Channel: "email", Status: StatusSent},
+		{Time: start.Add(time.Minute), Alert: "cpu", Channel: "telegram", Status: StatusFailed},
+		{Time: start.Add(2 * time.Minute), Alert: "disk", Channel: "email", Status: StatusSkipped},
+		{Time: start.Add(3 * time.Minute), Alert: "disk", Channel: "email", Status: StatusSent},
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int
+	}{
+		{"all", Query{}, []int{0, 1, 2, 3}},
+		{"alert", Query{Alert: "cpu"}, []int{0, 1}},
+		{"channel", Query{Channel: "email"}, []int{0, 2, 3}},
+		{"failed", Query{FailedOnly: true}, []int{1, 2}},
+		{"since", Query{Since: start.Add(2 * time.Minute)}, []int{2, 3}},
+		{"limit", Query{Channel: "email", Limit: 2}, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []Entry
+			for _, i := range tt.want {
+				want = append(want, entries[i])
+			}
+			assert.Equal(t, want, Filter(entries, tt.query))
+		})
+	}
+}
+
+func TestHashText(t *testing.T) {
+	assert.Equal(t, HashText("ALERT FIRED"), HashText("ALERT FIRED"))
+	assert.NotEqual(t, HashText("ALERT FIRED"), HashText("ALERT RESOLVED"))
+	assert.Len(t, HashText("x"), 16)
+}
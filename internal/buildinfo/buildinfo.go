@@ -0,0 +1,86 @@
+// Package buildinfo describes the running binary: its version and the
+// optional features that were compiled into it.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+)
+
+// Version is set at build time with
+// -ldflags "-X github.com/mattmezza/monres/internal/buildinfo.Version=v1.2.3".
+// When unset, the module version or VCS revision recorded by the Go toolchain is used.
+var Version = ""
+
+var (
+	featuresMu sync.Mutex
+	features   = make(map[string]bool)
+)
+
+// RegisterFeature records an optional feature as compiled in. Build-tagged
+// files call it from init, so the list reflects the tags used for the build.
+func RegisterFeature(name string) {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	features[name] = true
+}
+
+// Features returns the compiled-in optional features, sorted.
+func Features() []string {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	list := make([]string, 0, len(features))
+	for name := range features {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// HasFeature reports whether the named feature was compiled in.
+func HasFeature(name string) bool {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	return features[name]
+}
+
+// GetVersion returns Version, falling back to the build information embedded by the Go toolchain.
+func GetVersion() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision == "" {
+		return "dev"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified == "true" {
+		revision += "-dirty"
+	}
+	return "dev-" + revision
+}
+
+// String returns a one-line description of the binary, e.g. "monres v1.2.3 (go1.24.3 linux/amd64)".
+func String() string {
+	return fmt.Sprintf("monres %s (%s %s/%s)", GetVersion(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,26 @@
+package buildinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatures(t *testing.T) {
+	RegisterFeature("zeta")
+	RegisterFeature("alpha")
+	RegisterFeature("zeta")
+
+	assert.Equal(t, []string{"alpha", "zeta"}, Features())
+	assert.True(t, HasFeature("alpha"))
+	assert.False(t, HasFeature("beta"))
+}
+
+func TestVersionOverride(t *testing.T) {
+	old := Version
+	defer func() { Version = old }()
+
+	Version = "v9.9.9"
+	assert.Equal(t, "v9.9.9", GetVersion())
+	assert.Contains(t, String(), "monres v9.9.9 (go")
+}
@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// ACMECollector reports the age of the certificates issued by an ACME
+// client, as acme_cert_age_days_<domain>, and the oldest as
+// acme_cert_age_days_max. A renewal job that silently stopped working
+// shows as certificates older than the renewal threshold, e.g. 70 days for
+// Let's Encrypt certificates valid for 90 days and renewed after 60.
+//
+// Each directory holds a subdirectory per certificate, in the layout of
+// certbot (/etc/letsencrypt/live/<domain>/cert.pem) or acme.sh
+// (~/.acme.sh/<domain>[_ecc]/<domain>.cer).
+type ACMECollector struct {
+	dirs  []string
+	state certCache
+	mu    sync.Mutex
+}
+
+// NewACMECollector creates a collector scanning the given directories.
+func NewACMECollector(dirs []string) *ACMECollector {
+	return &ACMECollector{dirs: dirs, state: make(certCache)}
+}
+
+func (ac *ACMECollector) Name() string {
+	return "acme"
+}
+
+// Collect returns the age of every certificate found in the directories.
+// Certificates that can't be read are logged and left out.
+func (ac *ACMECollector) Collect() (CollectedMetrics, error) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	now := time.Now()
+	metrics := make(CollectedMetrics)
+	oldest := 0.0
+	for _, dir := range ac.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACME directory: %w", err)
+		}
+		for _, entry := range entries {
+			domain, path, ok := acmeCertPath(dir, entry)
+			if !ok {
+				continue
+			}
+			st, last := ac.state.read(path)
+			if st.err != nil {
+				if last == nil || last.err == nil {
+					log.Printf("Warning: ACME certificate of '%s' could not be read: %v", domain, st.err)
+				}
+				continue
+			}
+			age := now.Sub(st.notBefore).Hours() / 24
+			name := "acme_cert_age_days_" + sanitizeMetricName(domain)
+			metrics[name] = age
+			oldest = max(oldest, age)
+			if _, known := metricsmeta.Lookup(name); !known {
+				metricsmeta.Register(metricsmeta.Metadata{
+					Name:        name,
+					Type:        metricsmeta.TypeGauge,
+					Description: fmt.Sprintf("Days since the ACME certificate of %s was issued", domain),
+				})
+			}
+		}
+	}
+	metrics["acme_cert_age_days_max"] = oldest
+	return metrics, nil
+}
+
+// acmeCertPath returns the domain and the certificate file of an entry of an
+// ACME directory, if it is a certificate directory of certbot or acme.sh.
+func acmeCertPath(dir string, entry os.DirEntry) (domain, path string, ok bool) {
+	if !entry.IsDir() {
+		return "", "", false
+	}
+	// certbot: live/<domain>/cert.pem
+	path = filepath.Join(dir, entry.Name(), "cert.pem")
+	if _, err := os.Stat(path); err == nil {
+		return entry.Name(), path, true
+	}
+	// acme.sh: <domain>/<domain>.cer, or <domain>_ecc/<domain>.cer for ECDSA
+	domain = strings.TrimSuffix(entry.Name(), "_ecc")
+	path = filepath.Join(dir, entry.Name(), domain+".cer")
+	if _, err := os.Stat(path); err == nil {
+		return domain, path, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACMECollector(t *testing.T) {
+	now := time.Now()
+	// writeCertFile makes certificates valid for 90 days
+	certbot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(certbot, "example.com"), 0755))
+	writeCertFile(t, filepath.Join(certbot, "example.com", "cert.pem"), now.Add(80*24*time.Hour)) // Issued 10 days ago
+	require.NoError(t, os.MkdirAll(filepath.Join(certbot, "stale.example.com"), 0755))
+	writeCertFile(t, filepath.Join(certbot, "stale.example.com", "cert.pem"), now.Add(15*24*time.Hour))
+	require.NoError(t, os.WriteFile(filepath.Join(certbot, "README"), []byte("certbot\n"), 0644))
+
+	acmesh := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(acmesh, "api.example.org_ecc"), 0755))
+	writeCertFile(t, filepath.Join(acmesh, "api.example.org_ecc", "api.example.org.cer"), now.Add(60*24*time.Hour))
+	require.NoError(t, os.MkdirAll(filepath.Join(acmesh, "ca"), 0755)) // Not a certificate directory
+
+	metrics, err := NewACMECollector([]string{certbot, acmesh}).Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 10, metrics["acme_cert_age_days_example_com"], 0.01)
+	assert.InDelta(t, 75, metrics["acme_cert_age_days_stale_example_com"], 0.01)
+	assert.InDelta(t, 30, metrics["acme_cert_age_days_api_example_org"], 0.01)
+	assert.InDelta(t, 75, metrics["acme_cert_age_days_max"], 0.01)
+	assert.Len(t, metrics, 4)
+
+	_, err = NewACMECollector([]string{filepath.Join(certbot, "missing")}).Collect()
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// BenchmarkResult summarizes the cost of one collector over several cycles.
+type BenchmarkResult struct {
+	Name           string
+	Cycles         int
+	Errors         int
+	AvgLatency     time.Duration
+	MaxLatency     time.Duration
+	AllocsPerCycle float64
+	BytesPerCycle  float64
+	CPUPerCycle    time.Duration // User + system CPU time of the process
+}
+
+// Benchmark runs every source the given number of times back to back and
+// reports latency, allocations and CPU cost per cycle for each of them,
+// followed by a "total" entry for complete CollectAll cycles.
+func (gc *GlobalCollector) Benchmark(cycles int) []BenchmarkResult {
+	if cycles < 1 {
+		cycles = 1
+	}
+
+	gc.mu.Lock()
+	srcs := gc.sources()
+	gc.mu.Unlock()
+
+	var results []BenchmarkResult
+	for _, src := range srcs {
+		dst := make(CollectedMetrics)
+		results = append(results, measure(src.name, cycles, func() error {
+			return src.collect(dst)
+		}))
+	}
+	results = append(results, measure("total", cycles, func() error {
+		_, err := gc.CollectAll()
+		return err
+	}))
+	return results
+}
+
+func measure(name string, cycles int, fn func() error) BenchmarkResult {
+	res := BenchmarkResult{Name: name, Cycles: cycles}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	cpuBefore := processCPUTime()
+
+	var total time.Duration
+	for i := 0; i < cycles; i++ {
+		start := time.Now()
+		if err := fn(); err != nil {
+			res.Errors++
+		}
+		elapsed := time.Since(start)
+		total += elapsed
+		if elapsed > res.MaxLatency {
+			res.MaxLatency = elapsed
+		}
+	}
+
+	cpuAfter := processCPUTime()
+	runtime.ReadMemStats(&after)
+
+	res.AvgLatency = total / time.Duration(cycles)
+	res.AllocsPerCycle = float64(after.Mallocs-before.Mallocs) / float64(cycles)
+	res.BytesPerCycle = float64(after.TotalAlloc-before.TotalAlloc) / float64(cycles)
+	res.CPUPerCycle = (cpuAfter - cpuBefore) / time.Duration(cycles)
+	return res
+}
+
+// processCPUTime returns the user + system CPU time consumed by this process.
+func processCPUTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// sysFSBtrfs holds a directory per mounted btrfs filesystem. Replaced in tests.
+var sysFSBtrfs = "/sys/fs/btrfs"
+
+// btrfsErrorKinds are the device error counters of btrfs, as named in
+// devinfo/<devid>/error_stats and by `btrfs device stats`.
+var btrfsErrorKinds = []string{"write_io_errs", "read_io_errs", "flush_io_errs", "corruption_errs", "generation_errs"}
+
+// readBtrfsErrors reads the device error counters of every mounted btrfs
+// filesystem under dir, summed over its devices, by filesystem name: its
+// label, or the start of its UUID. A missing dir, without the btrfs module
+// loaded, yields no filesystems.
+func readBtrfsErrors(dir string) (map[string]map[string]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	filesystems := make(map[string]map[string]uint64)
+	for _, entry := range entries {
+		// Besides the filesystems, the directory holds features/
+		stats, _ := filepath.Glob(filepath.Join(dir, entry.Name(), "devinfo", "*", "error_stats"))
+		if len(stats) == 0 {
+			continue
+		}
+		errs := make(map[string]uint64, len(btrfsErrorKinds))
+		for _, kind := range btrfsErrorKinds {
+			errs[kind] = 0
+		}
+		for _, path := range stats {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			for len(data) > 0 {
+				var line []byte
+				line, data = nextLine(data)
+				kind, rest := nextField(line)
+				value, _ := nextField(rest)
+				if n, ok := parseUintBytes(value); ok {
+					if _, known := errs[string(kind)]; known {
+						errs[string(kind)] += n
+					}
+				}
+			}
+		}
+		filesystems[btrfsName(filepath.Join(dir, entry.Name()))] = errs
+	}
+	return filesystems, nil
+}
+
+// btrfsName returns the name of a btrfs filesystem in metric names: its
+// label, or the first 8 characters of its UUID.
+func btrfsName(fsDir string) string {
+	label, _ := os.ReadFile(filepath.Join(fsDir, "label"))
+	if name := sanitizeMetricName(string(bytes.TrimSpace(label))); name != "" {
+		return name
+	}
+	uuid := filepath.Base(fsDir)
+	if len(uuid) > 8 {
+		uuid = uuid[:8]
+	}
+	return sanitizeMetricName(uuid)
+}
+
+// collectBtrfs reports the device error counters of every mounted btrfs
+// filesystem as btrfs_<fs>_<kind>, e.g. btrfs_data_corruption_errs. The
+// counters persist across reboots until reset with `btrfs device stats -z`,
+// so any value above 0 calls for a look at the disks.
+func (gc *GlobalCollector) collectBtrfs(metrics CollectedMetrics) error {
+	filesystems, err := readBtrfsErrors(sysFSBtrfs)
+	if err != nil {
+		return fmt.Errorf("failed to read btrfs error counters: %w", err)
+	}
+	for name, errs := range filesystems {
+		prefix := "btrfs_" + name
+		for kind, n := range errs {
+			metrics[prefix+"_"+kind] = float64(n)
+		}
+		registerBtrfsMetrics(prefix, name)
+	}
+	return nil
+}
+
+// registerBtrfsMetrics registers the metadata of the metrics of a btrfs
+// filesystem the first time it is seen.
+func registerBtrfsMetrics(prefix, name string) {
+	if _, known := metricsmeta.Lookup(prefix + "_corruption_errs"); known {
+		return
+	}
+	for _, kind := range btrfsErrorKinds {
+		metricsmeta.Register(metricsmeta.Metadata{
+			Name:        prefix + "_" + kind,
+			Type:        metricsmeta.TypeCounter,
+			Description: fmt.Sprintf("Btrfs %s of the devices of %s", kind, name),
+		})
+	}
+}
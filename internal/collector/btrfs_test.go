@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectBtrfs(t *testing.T) {
+	oldDir := sysFSBtrfs
+	sysFSBtrfs = t.TempDir()
+	t.Cleanup(func() { sysFSBtrfs = oldDir })
+	write := func(path, content string) {
+		path = filepath.Join(sysFSBtrfs, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write("features/raid1c34", "0\n")
+	write("1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9/label", "data\n")
+	write("1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9/devinfo/1/error_stats",
+		"write_io_errs 0\nread_io_errs 2\nflush_io_errs 0\ncorruption_errs 5\ngeneration_errs 0\n")
+	write("1f2e3d4c-5b6a-4978-8695-a4b3c2d1e0f9/devinfo/2/error_stats",
+		"write_io_errs 1\nread_io_errs 0\nflush_io_errs 0\ncorruption_errs 1\ngeneration_errs 0\n")
+	write("9a8b7c6d-0000-4000-8000-000000000000/label", "\n")
+	write("9a8b7c6d-0000-4000-8000-000000000000/devinfo/1/error_stats",
+		"write_io_errs 0\nread_io_errs 0\nflush_io_errs 0\ncorruption_errs 0\ngeneration_errs 0\n")
+
+	gc := NewGlobalCollector(nil)
+	metrics := make(CollectedMetrics)
+	require.NoError(t, gc.collectBtrfs(metrics))
+	assert.Equal(t, CollectedMetrics{
+		"btrfs_data_write_io_errs":       1,
+		"btrfs_data_read_io_errs":        2,
+		"btrfs_data_flush_io_errs":       0,
+		"btrfs_data_corruption_errs":     6,
+		"btrfs_data_generation_errs":     0,
+		"btrfs_9a8b7c6d_write_io_errs":   0,
+		"btrfs_9a8b7c6d_read_io_errs":    0,
+		"btrfs_9a8b7c6d_flush_io_errs":   0,
+		"btrfs_9a8b7c6d_corruption_errs": 0,
+		"btrfs_9a8b7c6d_generation_errs": 0,
+	}, metrics)
+
+	sysFSBtrfs = filepath.Join(t.TempDir(), "missing")
+	metrics = make(CollectedMetrics)
+	require.NoError(t, gc.collectBtrfs(metrics), "the btrfs module is not loaded")
+	assert.Empty(t, metrics)
+}
@@ -0,0 +1,154 @@
+package collector
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// CertFile is a PEM file of certificates checked by the CertFileCollector.
+type CertFile struct {
+	Name string // Used in the metric name, cert_days_left_<name>
+	Path string
+}
+
+// certFileState caches the validity of the certificates of a file until
+// the file changes.
+type certFileState struct {
+	modTime   time.Time
+	size      int64
+	notBefore time.Time // Of the most recently issued certificate
+	notAfter  time.Time // Of the first certificate to expire
+	err       error
+}
+
+// certCache holds the states of certificate files by path.
+type certCache map[string]*certFileState
+
+// read returns the state of a file, parsing it again if it changed since
+// the previous call. last is the previous state, nil the first time.
+func (c certCache) read(path string) (st, last *certFileState) {
+	last = c[path]
+	info, err := os.Stat(path)
+	if err == nil && last != nil && last.err == nil && info.ModTime().Equal(last.modTime) && info.Size() == last.size {
+		return last, last
+	}
+	st = &certFileState{err: err}
+	if err == nil {
+		st.modTime, st.size = info.ModTime(), info.Size()
+		st.notBefore, st.notAfter, st.err = readCertValidity(path)
+	}
+	c[path] = st
+	return st, last
+}
+
+// CertFileCollector reports the days until the certificates in PEM files on
+// disk expire, as cert_days_left_<name> (negative once expired). For a file
+// holding several certificates, such as a chain or a CA bundle, the first
+// to expire counts. Unlike a probe of the live server, this catches a
+// certificate renewed on disk but never deployed, and the other way round.
+// Files that can't be read or hold no certificate are left out of the
+// metrics and counted in cert_files_unreadable.
+type CertFileCollector struct {
+	files []CertFile
+	state certCache
+	mu    sync.Mutex
+}
+
+// NewCertFileCollector creates a collector checking the given files.
+func NewCertFileCollector(files []CertFile) *CertFileCollector {
+	for _, f := range files {
+		name := certMetricName(f.Name)
+		if _, known := metricsmeta.Lookup(name); !known {
+			metricsmeta.Register(metricsmeta.Metadata{
+				Name:        name,
+				Type:        metricsmeta.TypeGauge,
+				Description: fmt.Sprintf("Days until the first certificate of %s expires", f.Path),
+			})
+		}
+	}
+	return &CertFileCollector{files: files, state: make(certCache)}
+}
+
+func (cc *CertFileCollector) Name() string {
+	return "certfiles"
+}
+
+// Collect returns the days left of every file, parsing only the files that
+// changed since the previous call.
+func (cc *CertFileCollector) Collect() (CollectedMetrics, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	now := time.Now()
+	metrics := make(CollectedMetrics, len(cc.files)+1)
+	unreadable := 0
+	for _, f := range cc.files {
+		st := cc.check(f)
+		if st.err != nil {
+			unreadable++
+			continue
+		}
+		metrics[certMetricName(f.Name)] = st.notAfter.Sub(now).Hours() / 24
+	}
+	metrics["cert_files_unreadable"] = float64(unreadable)
+	return metrics, nil
+}
+
+// check returns the state of a file, logging when it becomes unreadable or
+// readable again.
+func (cc *CertFileCollector) check(f CertFile) *certFileState {
+	st, last := cc.state.read(f.Path)
+	switch {
+	case st.err != nil && (last == nil || last.err == nil):
+		log.Printf("Warning: certificate file '%s' could not be checked: %v", f.Name, st.err)
+	case st.err == nil && last != nil && last.err != nil:
+		log.Printf("Certificate file '%s' is readable again.", f.Name)
+	}
+	return st
+}
+
+// readCertValidity returns when the most recently issued certificate of a
+// PEM file became valid and when the first to expire does. Other blocks,
+// such as a private key in a combined file, are skipped.
+func readCertValidity(path string) (notBefore, notAfter time.Time, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return notBefore, notAfter, err
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return notBefore, notAfter, fmt.Errorf("%s: %w", path, err)
+		}
+		if notAfter.IsZero() || cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+		if cert.NotBefore.After(notBefore) {
+			notBefore = cert.NotBefore
+		}
+	}
+	if notAfter.IsZero() {
+		return notBefore, notAfter, errors.New(path + ": no PEM certificate found")
+	}
+	return notBefore, notAfter, nil
+}
+
+func certMetricName(name string) string {
+	return "cert_days_left_" + sanitizeMetricName(name)
+}
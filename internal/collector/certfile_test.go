@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertFile writes a PEM file with a certificate expiring at each of
+// notAfter, followed by a private key.
+func writeCertFile(t *testing.T, path string, notAfter ...time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	var data []byte
+	for i, na := range notAfter {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 1)),
+			Subject:      pkix.Name{CommonName: "example.com"},
+			NotBefore:    na.Add(-90 * 24 * time.Hour),
+			NotAfter:     na,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		require.NoError(t, err)
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func TestCertFileCollector(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	web := filepath.Join(dir, "web.pem")
+	writeCertFile(t, web, now.Add(30*24*time.Hour), now.Add(10*24*time.Hour)) // Leaf and an intermediate expiring first
+	old := filepath.Join(dir, "old.pem")
+	writeCertFile(t, old, now.Add(-2*24*time.Hour))
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate\n"), 0600))
+
+	cc := NewCertFileCollector([]CertFile{
+		{Name: "web", Path: web},
+		{Name: "old-api", Path: old},
+		{Name: "empty", Path: empty},
+		{Name: "missing", Path: filepath.Join(dir, "missing.pem")},
+	})
+	metrics, err := cc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 10, metrics["cert_days_left_web"], 0.01)
+	assert.InDelta(t, -2, metrics["cert_days_left_old_api"], 0.01)
+	assert.Equal(t, 2.0, metrics["cert_files_unreadable"])
+	assert.Len(t, metrics, 3)
+
+	// A renewed certificate is picked up once the file changes
+	writeCertFile(t, old, now.Add(60*24*time.Hour))
+	require.NoError(t, os.Chtimes(old, now.Add(time.Minute), now.Add(time.Minute)))
+	metrics, err = cc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 60, metrics["cert_days_left_old_api"], 0.01)
+}
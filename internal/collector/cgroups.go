@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// sysFSCgroup is the root of the cgroup v2 hierarchy. Replaced in tests.
+var sysFSCgroup = "/sys/fs/cgroup"
+
+// cgroupStats is the usage of a cgroup.
+type cgroupStats struct {
+	cpuUsec     uint64 // usage_usec of cpu.stat
+	memoryBytes uint64 // memory.current
+	memoryMax   uint64 // memory.max, 0 without a limit
+}
+
+// CgroupCollector reports the usage of systemd slices, or of any cgroup,
+// from the cgroup v2 hierarchy:
+//
+//	slice_<name>_cpu_percent     CPU use, of one CPU like top
+//	slice_<name>_memory_bytes    memory used, page cache included
+//	slice_<name>_memory_percent  memory used, of its MemoryMax= limit
+//
+// Cgroups are named by their last path element without ".slice", e.g.
+// user_1000 for user.slice/user-1000.slice. The memory percentage is left
+// out for cgroups without a limit. CPU use is measured against the previous
+// call; the first call reports 0.
+type CgroupCollector struct {
+	paths    []string // Relative to sysFSCgroup, every slice if empty
+	last     map[string]cgroupStats
+	lastTime time.Time
+	mu       sync.Mutex
+}
+
+// NewCgroupCollector creates a collector reporting the given cgroups,
+// relative to the root of the hierarchy (e.g. "system.slice/nginx.service"),
+// or every slice when paths is empty.
+func NewCgroupCollector(paths []string) *CgroupCollector {
+	return &CgroupCollector{paths: paths}
+}
+
+func (cc *CgroupCollector) Name() string {
+	return "cgroups"
+}
+
+// Collect reads the usage of the cgroups. Configured cgroups that don't
+// exist, such as the slice of a user who is not logged in, are left out.
+func (cc *CgroupCollector) Collect() (CollectedMetrics, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if _, err := os.Stat(filepath.Join(sysFSCgroup, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("no cgroup v2 hierarchy at %s: %w", sysFSCgroup, err)
+	}
+	paths := cc.paths
+	if len(paths) == 0 {
+		var err error
+		if paths, err = findSlices(sysFSCgroup); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(cc.lastTime).Seconds()
+	current := make(map[string]cgroupStats, len(paths))
+	metrics := make(CollectedMetrics, 3*len(paths))
+	for _, path := range paths {
+		st, err := readCgroupStats(filepath.Join(sysFSCgroup, path))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		current[path] = st
+		cpu := 0.0
+		if last, ok := cc.last[path]; ok && elapsed > 0.1 && st.cpuUsec >= last.cpuUsec {
+			cpu = float64(st.cpuUsec-last.cpuUsec) / 1e6 / elapsed * 100
+		}
+		prefix := "slice_" + CgroupMetricName(path)
+		metrics[prefix+"_cpu_percent"] = cpu
+		metrics[prefix+"_memory_bytes"] = float64(st.memoryBytes)
+		if st.memoryMax > 0 {
+			metrics[prefix+"_memory_percent"] = float64(st.memoryBytes) * 100 / float64(st.memoryMax)
+		}
+		registerCgroupMetrics(prefix, path)
+	}
+	cc.last, cc.lastTime = current, now
+	return metrics, nil
+}
+
+// CgroupMetricName returns the name of a cgroup in metric names: its last
+// path element without ".slice", e.g. "user_1000" for
+// user.slice/user-1000.slice.
+func CgroupMetricName(path string) string {
+	return sanitizeMetricName(strings.TrimSuffix(filepath.Base(path), ".slice"))
+}
+
+// findSlices returns the slices under root, relative to it. Slices only
+// nest in other slices, so the walk doesn't enter services and scopes.
+func findSlices(root string) ([]string, error) {
+	var slices []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".slice") {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(root, path)
+		slices = append(slices, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list slices: %w", err)
+	}
+	return slices, nil
+}
+
+// readCgroupStats reads the usage of the cgroup at dir.
+func readCgroupStats(dir string) (cgroupStats, error) {
+	var st cgroupStats
+	data, err := os.ReadFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return st, err
+	}
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		key, rest := nextField(line)
+		if bytes.Equal(key, []byte("usage_usec")) {
+			value, _ := nextField(rest)
+			st.cpuUsec, _ = parseUintBytes(value)
+			break
+		}
+	}
+	// Without the memory controller enabled for the parent, the files are missing
+	if n, ok := readUintFile(filepath.Join(dir, "memory.current")); ok {
+		st.memoryBytes = n
+	}
+	if n, ok := readUintFile(filepath.Join(dir, "memory.max")); ok { // "max" without a limit
+		st.memoryMax = n
+	}
+	return st, nil
+}
+
+// registerCgroupMetrics registers the metadata of the metrics of a cgroup
+// the first time it is seen.
+func registerCgroupMetrics(prefix, path string) {
+	if _, known := metricsmeta.Lookup(prefix + "_cpu_percent"); known {
+		return
+	}
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_cpu_percent",
+		Unit:        metricsmeta.UnitPercent,
+		Type:        metricsmeta.TypeGauge,
+		Description: "CPU use of " + path + ", of one CPU",
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_memory_bytes",
+		Unit:        metricsmeta.UnitBytes,
+		Type:        metricsmeta.TypeGauge,
+		Description: "Memory used by " + path,
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_memory_percent",
+		Unit:        metricsmeta.UnitPercent,
+		Type:        metricsmeta.TypeGauge,
+		Description: "Memory used by " + path + ", of its limit",
+	})
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCgroupCollector(t *testing.T) {
+	oldRoot := sysFSCgroup
+	sysFSCgroup = t.TempDir()
+	t.Cleanup(func() { sysFSCgroup = oldRoot })
+	write := func(path, content string) {
+		path = filepath.Join(sysFSCgroup, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	cgroup := func(path string, usec, memory int, max string) {
+		write(path+"/cpu.stat", "usage_usec "+strconv.Itoa(usec)+"\nuser_usec 0\nsystem_usec 0\n")
+		write(path+"/memory.current", strconv.Itoa(memory)+"\n")
+		write(path+"/memory.max", max+"\n")
+	}
+	write("cgroup.controllers", "cpuset cpu io memory pids\n")
+	cgroup("init.scope", 1000, 1000, "max")
+	cgroup("system.slice", 5000000, 400<<20, "max")
+	cgroup("system.slice/nginx.service", 2000000, 100<<20, "max")
+	cgroup("user.slice", 9000000, 1<<30, "max")
+	cgroup("user.slice/user-1000.slice", 8000000, 512<<20, strconv.Itoa(1<<30))
+
+	cc := NewCgroupCollector(nil)
+	metrics, err := cc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{
+		"slice_system_cpu_percent":       0,
+		"slice_system_memory_bytes":      400 << 20,
+		"slice_user_cpu_percent":         0,
+		"slice_user_memory_bytes":        1 << 30,
+		"slice_user_1000_cpu_percent":    0,
+		"slice_user_1000_memory_bytes":   512 << 20,
+		"slice_user_1000_memory_percent": 50,
+	}, metrics, "every slice, no CPU use on the first call")
+
+	// user-1000 used 5s of CPU in 10s
+	cc.lastTime = time.Now().Add(-10 * time.Second)
+	cgroup("user.slice/user-1000.slice", 13000000, 512<<20, strconv.Itoa(1<<30))
+	metrics, err = cc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 50, metrics["slice_user_1000_cpu_percent"], 0.01)
+
+	metrics, err = NewCgroupCollector([]string{"system.slice/nginx.service", "user.slice/user-1001.slice"}).Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{
+		"slice_nginx_service_cpu_percent":  0,
+		"slice_nginx_service_memory_bytes": 100 << 20,
+	}, metrics, "missing cgroups are left out")
+
+	require.NoError(t, os.Remove(filepath.Join(sysFSCgroup, "cgroup.controllers")))
+	_, err = cc.Collect()
+	assert.ErrorContains(t, err, "no cgroup v2 hierarchy")
+}
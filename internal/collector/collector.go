@@ -1,8 +1,8 @@
 package collector
 
 import (
-	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,21 +16,68 @@ type MetricCollector interface {
 	Name() string // e.g., "cpu", "memory"
 }
 
+// Processor rewrites the metrics of a cycle after collection (e.g. renaming,
+// dropping or smoothing metrics). Processors run in the order they were added.
+type Processor interface {
+	Process(now time.Time, metrics CollectedMetrics)
+}
+
 // GlobalCollector orchestrates all individual metric collectors.
 type GlobalCollector struct {
 	collectors []MetricCollector
-	// For rate-based metrics like disk/network IO
-	lastDiskStats          *DiskStats             // Pointer to allow nil for first run
-	lastNetworkStats       *NetworkStats          // Pointer to allow nil for first run
-	lastCollectTime        time.Time
+	// Optional collectors enabled by configuration (e.g. textfile)
+	extraCollectors []MetricCollector
+	processors      []Processor
+	// Previous samples of the rate-based sources, like disk/network IO
+	disk    rateSample[DiskStats]
+	network rateSample[NetworkStats]
+	cpuFreq rateSample[CPUFreqStats]
+	nfs     rateSample[map[string]NFSStats] // By mount point
+	// State of the sources themselves, as a source that missed a cycle's
+	// deadline keeps running after CollectAll returns
+	mountsMu               sync.Mutex
+	expectedMounts         map[string]bool // Mount points reported by mount_present_
+	learnMounts            bool            // Add every mount seen to expectedMounts
+	diskSpaceMu            sync.Mutex
+	diskSpaceInclude       []string               // Mount point patterns reported by disk_percent_used_, empty for all local ones
+	diskSpaceExclude       []string               // Mount point patterns left out of them
+	diskSpaceFailed        map[string]bool        // Mount points whose usage could not be read, logged once
+	lastMetricCount        int                    // Metrics in the previous cycle, to presize the next map
+	cachedSources          []*sourceRun           // Built lazily by sources()
+	timeout                time.Duration          // Per-cycle deadline; zero waits for every collector
 	networkInterfaceFilter NetworkInterfaceFilter // Filter for network interfaces
-	mu                     sync.Mutex             // Protects last stats and time
+	now                    func() time.Time       // Replaced in tests
+	mu                     sync.Mutex             // Protects the collectors, processors and cycle settings
+}
+
+// rateSample is the previous sample of a rate-based source. Rates span the
+// time between the source's own samples rather than between cycles, which
+// the source may have been left out of after a timeout. Each source locks
+// its own sample, as it may still be running when the next cycle starts.
+type rateSample[T any] struct {
+	mu   sync.Mutex
+	last T
+	at   time.Time // Zero before the first sample
+}
+
+// elapsed returns the seconds between the previous sample and now, or 0
+// before the first sample.
+func (s *rateSample[T]) elapsed(now time.Time) float64 {
+	if s.at.IsZero() {
+		return 0
+	}
+	return now.Sub(s.at).Seconds()
+}
+
+// store makes current, taken at now, the previous sample.
+func (s *rateSample[T]) store(current T, now time.Time) {
+	s.last, s.at = current, now
 }
 
 // NewGlobalCollector creates a new GlobalCollector with the given network interface filter.
 // If filter is nil or empty, it uses the default filter that excludes Docker interfaces.
 func NewGlobalCollector(networkFilter *NetworkInterfaceFilter) *GlobalCollector {
-	gc := &GlobalCollector{}
+	gc := &GlobalCollector{expectedMounts: make(map[string]bool), learnMounts: true, diskSpaceFailed: make(map[string]bool), now: time.Now}
 	// Initialize specific collectors
 	gc.collectors = append(gc.collectors, NewCPUCollector())
 	gc.collectors = append(gc.collectors, NewMemoryCollector())
@@ -49,72 +96,234 @@ func NewGlobalCollector(networkFilter *NetworkInterfaceFilter) *GlobalCollector
 	return gc
 }
 
+// AddCollector registers an optional collector whose metrics are merged into
+// the result of every CollectAll call.
+func (gc *GlobalCollector) AddCollector(c MetricCollector) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	gc.extraCollectors = append(gc.extraCollectors, c)
+	gc.cachedSources = nil
+}
+
+// SetExpectedMounts sets the mount points reported as mount_present_<mount>.
+// Without any, every disk or network filesystem seen mounted is expected
+// from then on.
+func (gc *GlobalCollector) SetExpectedMounts(paths []string) {
+	gc.mountsMu.Lock()
+	defer gc.mountsMu.Unlock()
+	gc.expectedMounts = make(map[string]bool, len(paths))
+	for _, path := range paths {
+		gc.expectedMounts[path] = true
+	}
+	gc.learnMounts = len(paths) == 0
+}
+
+// SetTimeout bounds how long CollectAll waits for collectors. Zero disables the deadline.
+func (gc *GlobalCollector) SetTimeout(d time.Duration) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	gc.timeout = d
+}
+
+// AddProcessor registers a processor applied to the merged metrics of every cycle.
+func (gc *GlobalCollector) AddProcessor(p Processor) {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	gc.processors = append(gc.processors, p)
+}
+
+// source is one named step of a collection cycle. Rate-based sources keep
+// their previous sample in a rateSample. Sources write into the cycle's map
+// rather than returning their own, to keep per-cycle allocations down.
+type source struct {
+	name    string
+	collect func(dst CollectedMetrics) error
+}
+
+// sourceRun is a source together with the state of its concurrent execution.
+type sourceRun struct {
+	source
+	out  CollectedMetrics // Reused between cycles while the source is idle
+	up   string           // Name of the source's collector_up metric
+	busy atomic.Bool      // Set while the source's goroutine is running
+}
+
+// sourceResult reports the completion of the source at index in a cycle.
+type sourceResult struct {
+	index int
+	err   error
+}
+
+// sources returns the built-in collection steps followed by the optional collectors.
+// The slice is built once and reused until a collector is added.
+func (gc *GlobalCollector) sources() []*sourceRun {
+	if gc.cachedSources != nil {
+		return gc.cachedSources
+	}
+	srcs := []source{
+		{name: "cpu", collect: collectCPUStatsInto}, // Percentages of jiffies, no elapsed time needed
+		{name: "memory", collect: collectMemoryStatsInto},
+		{name: "disk", collect: gc.collectDiskIO},
+		{name: "network", collect: gc.collectNetworkIO},
+		{name: "cpufreq", collect: gc.collectCPUFreq},
+		{name: "thermal", collect: gc.collectThermal},
+		{name: "filesystem", collect: gc.collectFilesystems},
+		{name: "diskspace", collect: gc.collectDiskSpace},
+		{name: "nfs", collect: gc.collectNFS},
+		{name: "btrfs", collect: gc.collectBtrfs},
+	}
+	for _, c := range gc.extraCollectors {
+		srcs = append(srcs, source{name: c.Name(), collect: func(dst CollectedMetrics) error {
+			metrics, err := c.Collect()
+			if err != nil {
+				return err
+			}
+			for k, v := range metrics {
+				dst[k] = v
+			}
+			return nil
+		}})
+	}
+	runs := make([]*sourceRun, len(srcs))
+	for i, src := range srcs {
+		runs[i] = &sourceRun{source: src, out: make(CollectedMetrics), up: upMetricName(src.name)}
+	}
+	gc.cachedSources = runs
+	return runs
+}
+
 // CollectAll gathers all metrics from all registered collectors.
+// Collectors run concurrently, so a cycle takes as long as the slowest one.
+// When a timeout is set, collectors that have not finished by then are left
+// out of the cycle, and skipped in later cycles until they return.
+//
+// Failures do not discard the rest of the cycle: the metrics that were
+// collected are returned together with a CollectErrors listing each failed
+// collector, and every collector reports collector_up_<name> (1 or 0).
 func (gc *GlobalCollector) CollectAll() (CollectedMetrics, error) {
 	gc.mu.Lock()
 	defer gc.mu.Unlock()
 
-	allMetrics := make(CollectedMetrics)
-	now := time.Now()
-	var elapsedSeconds float64
-	if !gc.lastCollectTime.IsZero() {
-		elapsedSeconds = now.Sub(gc.lastCollectTime).Seconds()
+	now := gc.now()
+
+	runs := gc.sources()
+	const (
+		failed = iota
+		running
+		finished
+	)
+	states := make([]int8, len(runs))
+	failures := make([]error, len(runs))
+	// Buffered so that collectors finishing after the deadline never block
+	results := make(chan sourceResult, len(runs))
+	pending := 0
+	for i, run := range runs {
+		if !run.busy.CompareAndSwap(false, true) {
+			failures[i] = ErrCollectorBusy
+			continue
+		}
+		clear(run.out)
+		states[i] = running
+		pending++
+		go func(i int, run *sourceRun) {
+			err := run.collect(run.out)
+			results <- sourceResult{index: i, err: err}
+			run.busy.Store(false)
+		}(i, run)
 	}
 
+	var deadline <-chan time.Time
+	if gc.timeout > 0 {
+		timer := time.NewTimer(gc.timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
 
-	// CPU
-	cpuMetrics, err := CollectCPUStats(elapsedSeconds) // Pass elapsed for rate based on previous total/idle
-	if err != nil {
-		log.Printf("Error collecting CPU metrics: %v", err)
-	} else {
-		for k, v := range cpuMetrics {
-			allMetrics[k] = v
+wait:
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending--
+			states[res.index] = finished
+			if res.err != nil {
+				failures[res.index] = res.err
+				states[res.index] = failed // Drop partial results
+			}
+		case <-deadline:
+			break wait
 		}
 	}
 
-	// Memory
-	memMetrics, err := CollectMemoryStats()
-	if err != nil {
-		log.Printf("Error collecting Memory metrics: %v", err)
-	} else {
-		for k, v := range memMetrics {
+	// Merge in source order so that the result does not depend on timing.
+	// The returned map is handed to the caller, so it can't be reused, but
+	// sizing it from the previous cycle avoids growing it metric by metric.
+	allMetrics := make(CollectedMetrics, gc.lastMetricCount)
+	var errs CollectErrors
+	for i, run := range runs {
+		if states[i] != finished {
+			if states[i] == running {
+				failures[i] = ErrCollectorTimeout
+			}
+			allMetrics[run.up] = 0
+			errs = append(errs, &CollectorError{Collector: run.name, Err: failures[i]})
+			continue
+		}
+		for k, v := range run.out {
 			allMetrics[k] = v
 		}
+		allMetrics[run.up] = 1
+	}
+	for _, p := range gc.processors {
+		p.Process(now, allMetrics)
 	}
 
-	// Disk I/O
-	currentDiskStats, err := GetDiskStats()
-	if err != nil {
-		log.Printf("Error collecting Disk I/O stats: %v", err)
-	} else {
-		if gc.lastDiskStats != nil && elapsedSeconds > 0.1 { // Avoid division by zero or tiny intervals
-			readBps, writeBps := CalculateDiskIORates(*gc.lastDiskStats, *currentDiskStats, elapsedSeconds)
-			allMetrics["disk_read_bytes_ps"] = readBps
-			allMetrics["disk_write_bytes_ps"] = writeBps
-		} else {
-			allMetrics["disk_read_bytes_ps"] = 0
-			allMetrics["disk_write_bytes_ps"] = 0
-		}
-		gc.lastDiskStats = currentDiskStats
+	gc.lastMetricCount = len(allMetrics)
+	if len(errs) > 0 {
+		return allMetrics, errs
 	}
+	return allMetrics, nil
+}
+
+// collectDiskIO computes disk I/O rates against the previous sample.
+func (gc *GlobalCollector) collectDiskIO(metrics CollectedMetrics) error {
+	gc.disk.mu.Lock()
+	defer gc.disk.mu.Unlock()
 
-	// Network I/O
-	currentNetStats, err := GetNetworkStats(gc.networkInterfaceFilter)
-	if err != nil {
-		log.Printf("Error collecting Network I/O stats: %v", err)
+	var current DiskStats
+	if err := readDiskStats(&current); err != nil {
+		return err
+	}
+	now := gc.now()
+	if elapsedSeconds := gc.disk.elapsed(now); elapsedSeconds > 0.1 { // Avoid division by zero or tiny intervals
+		readBps, writeBps := CalculateDiskIORates(gc.disk.last, current, elapsedSeconds)
+		metrics["disk_read_bytes_ps"] = readBps
+		metrics["disk_write_bytes_ps"] = writeBps
 	} else {
-		if gc.lastNetworkStats != nil && elapsedSeconds > 0.1 {
-			recvBps, sentBps := CalculateNetworkIORates(*gc.lastNetworkStats, *currentNetStats, elapsedSeconds)
-			allMetrics["net_recv_bytes_ps"] = recvBps
-			allMetrics["net_sent_bytes_ps"] = sentBps
-		} else {
-			allMetrics["net_recv_bytes_ps"] = 0
-			allMetrics["net_sent_bytes_ps"] = 0
-		}
-		gc.lastNetworkStats = currentNetStats
+		metrics["disk_read_bytes_ps"] = 0
+		metrics["disk_write_bytes_ps"] = 0
 	}
+	gc.disk.store(current, now)
+	return nil
+}
 
+// collectNetworkIO computes network I/O rates against the previous sample.
+func (gc *GlobalCollector) collectNetworkIO(metrics CollectedMetrics) error {
+	gc.network.mu.Lock()
+	defer gc.network.mu.Unlock()
 
-	gc.lastCollectTime = now
-	return allMetrics, nil // Overall error can be nil if some collectors succeed
+	var current NetworkStats
+	if err := readNetworkStats(gc.networkInterfaceFilter, &current); err != nil {
+		return err
+	}
+	now := gc.now()
+	if elapsedSeconds := gc.network.elapsed(now); elapsedSeconds > 0.1 {
+		recvBps, sentBps := CalculateNetworkIORates(gc.network.last, current, elapsedSeconds)
+		metrics["net_recv_bytes_ps"] = recvBps
+		metrics["net_sent_bytes_ps"] = sentBps
+	} else {
+		metrics["net_recv_bytes_ps"] = 0
+		metrics["net_sent_bytes_ps"] = 0
+	}
+	gc.network.store(current, now)
+	return nil
 }
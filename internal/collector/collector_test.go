@@ -1,8 +1,10 @@
 package collector
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -67,9 +69,12 @@ SwapFree:        1024000 kB
 		assert.Contains(t, metrics, "swap_percent_free")
 		
 		// Validate that percentages are reasonable
-		for _, value := range metrics {
-			assert.True(t, value >= 0.0 && value <= 100.0, "Memory percentage should be between 0 and 100")
+		for name, value := range metrics {
+			if strings.Contains(name, "_percent_") {
+				assert.True(t, value >= 0.0 && value <= 100.0, "Memory percentage should be between 0 and 100")
+			}
 		}
+		assert.Greater(t, metrics["mem_total_bytes"], 0.0)
 	}
 }
 
@@ -204,4 +209,113 @@ func TestRealSystemMetrics(t *testing.T) {
 			time.Sleep(500 * time.Millisecond)
 		}
 	}
-}
\ No newline at end of file
+}
+func TestGlobalCollectorBenchmark(t *testing.T) {
+	gc := NewGlobalCollector(nil)
+	gc.AddCollector(NewTextfileCollector(t.TempDir(), 0))
+
+	results := gc.Benchmark(3)
+
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Name)
+		assert.Equal(t, 3, r.Cycles)
+		assert.True(t, r.MaxLatency >= r.AvgLatency)
+	}
+	assert.Equal(t, []string{"cpu", "memory", "disk", "network", "cpufreq", "thermal", "filesystem", "diskspace", "nfs", "btrfs", "textfile", "total"}, names)
+}
+
+// slowCollector blocks until release is closed (or delay elapses) before reporting a metric.
+type slowCollector struct {
+	name    string
+	delay   time.Duration
+	release chan struct{}
+}
+
+func (s *slowCollector) Name() string { return s.name }
+func (s *slowCollector) Collect() (CollectedMetrics, error) {
+	select {
+	case <-s.release:
+	case <-time.After(s.delay):
+	}
+	return CollectedMetrics{s.name + "_value": 1}, nil
+}
+
+func TestGlobalCollectorRunsCollectorsConcurrently(t *testing.T) {
+	gc := NewGlobalCollector(nil)
+	gc.AddCollector(&slowCollector{name: "slow_a", delay: 200 * time.Millisecond})
+	gc.AddCollector(&slowCollector{name: "slow_b", delay: 200 * time.Millisecond})
+
+	start := time.Now()
+	metrics, err := gc.CollectAll()
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 390*time.Millisecond) // Not the sum of both delays
+	assert.Contains(t, metrics, "slow_a_value")
+	assert.Contains(t, metrics, "slow_b_value")
+}
+
+func TestGlobalCollectorTimeout(t *testing.T) {
+	stuck := &slowCollector{name: "stuck", delay: time.Hour, release: make(chan struct{})}
+	gc := NewGlobalCollector(nil)
+	gc.AddCollector(stuck)
+	gc.SetTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	metrics, err := gc.CollectAll()
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, ErrCollectorTimeout)
+	assert.NotContains(t, metrics, "stuck_value")
+	assert.Contains(t, metrics, "cpu_percent_total")
+	assert.Equal(t, 0.0, metrics["collector_up_stuck"])
+	assert.Equal(t, 1.0, metrics["collector_up_cpu"])
+
+	// Still running: skipped without waiting for the deadline again
+	start = time.Now()
+	metrics, err = gc.CollectAll()
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.ErrorIs(t, err, ErrCollectorBusy)
+	assert.NotContains(t, metrics, "stuck_value")
+
+	close(stuck.release)
+	require.Eventually(t, func() bool {
+		metrics, err := gc.CollectAll()
+		return err == nil && metrics["stuck_value"] == 1 && metrics["collector_up_stuck"] == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+// failingCollector always returns an error.
+type failingCollector struct{}
+
+func (failingCollector) Name() string                       { return "broken" }
+func (failingCollector) Collect() (CollectedMetrics, error) { return nil, errors.New("boom") }
+
+func TestGlobalCollectorPartialFailure(t *testing.T) {
+	gc := NewGlobalCollector(nil)
+	gc.AddCollector(failingCollector{})
+
+	metrics, err := gc.CollectAll()
+	require.Error(t, err)
+
+	var errs CollectErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 1)
+	assert.Equal(t, "broken", errs[0].Collector)
+	assert.True(t, errs.Failed("broken"))
+	assert.False(t, errs.Failed("cpu"))
+	assert.EqualError(t, err, "broken: boom")
+
+	// Metrics of the healthy collectors are still returned
+	assert.Contains(t, metrics, "mem_percent_used")
+	assert.Equal(t, 0.0, metrics["collector_up_broken"])
+	assert.Equal(t, 1.0, metrics["collector_up_memory"])
+}
+
+func TestRateSampleElapsed(t *testing.T) {
+	var s rateSample[DiskStats]
+	now := time.Now()
+	assert.Zero(t, s.elapsed(now), "no elapsed time before the first sample")
+
+	s.store(DiskStats{}, now)
+	// A source left out of a cycle measures against its own last sample
+	assert.Equal(t, 30.0, s.elapsed(now.Add(30*time.Second)))
+}
@@ -0,0 +1,12 @@
+package collector
+
+import (
+	"context"
+	"os/exec"
+)
+
+// runCommand runs a tool whose output a collector parses, such as nft or wg,
+// and returns its standard output. Replaced in tests.
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
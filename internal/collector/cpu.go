@@ -1,19 +1,15 @@
 package collector
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
-	"os"
-	"strconv"
-	"strings"
 	"sync"
 )
 
 // Store previous CPU times to calculate usage delta.
 var (
-	prevCPUTotal uint64
-	prevCPUIdle  uint64
-	cpuOnce      sync.Once
+	prevCPU      CPUStatLine
+	prevCPUValid bool
 	cpuMu        sync.Mutex
 )
 
@@ -31,107 +27,150 @@ type CPUStatLine struct {
 	GuestNice uint64
 }
 
-func parseCPUStatLine(line string) (*CPUStatLine, error) {
-	fields := strings.Fields(line)
-	if len(fields) < 9 || fields[0] != "cpu" { // Need at least user, nice, system, idle, iowait, irq, softirq, steal
-		return nil, fmt.Errorf("invalid cpu stat line format")
+// parseCPUStatLine parses the aggregate "cpu" line of /proc/stat.
+func parseCPUStatLine(line []byte) (CPUStatLine, error) {
+	var s CPUStatLine
+	name, rest := nextField(line)
+	if string(name) != "cpu" {
+		return s, fmt.Errorf("invalid cpu stat line format")
 	}
 
-	var s CPUStatLine
-	var err error
-
-	s.User, err = strconv.ParseUint(fields[1], 10, 64)
-	if err != nil { return nil, err }
-	s.Nice, err = strconv.ParseUint(fields[2], 10, 64)
-	if err != nil { return nil, err }
-	s.System, err = strconv.ParseUint(fields[3], 10, 64)
-	if err != nil { return nil, err }
-	s.Idle, err = strconv.ParseUint(fields[4], 10, 64)
-	if err != nil { return nil, err }
-	if len(fields) > 5 { s.IOWait, _ = strconv.ParseUint(fields[5], 10, 64) }
-	if len(fields) > 6 { s.IRQ, _ = strconv.ParseUint(fields[6], 10, 64) }
-	if len(fields) > 7 { s.SoftIRQ, _ = strconv.ParseUint(fields[7], 10, 64) }
-	if len(fields) > 8 { s.Steal, _ = strconv.ParseUint(fields[8], 10, 64) }
-	if len(fields) > 9 { s.Guest, _ = strconv.ParseUint(fields[9], 10, 64) }
-	if len(fields) > 10 { s.GuestNice, _ = strconv.ParseUint(fields[10], 10, 64) }
-
-	return &s, nil
+	// user, nice, system, idle, iowait, irq, softirq, steal, guest, guest_nice
+	fields := [...]*uint64{&s.User, &s.Nice, &s.System, &s.Idle, &s.IOWait, &s.IRQ, &s.SoftIRQ, &s.Steal, &s.Guest, &s.GuestNice}
+	n := 0
+	for ; n < len(fields); n++ {
+		var f []byte
+		f, rest = nextField(rest)
+		if len(f) == 0 {
+			break
+		}
+		v, ok := parseUintBytes(f)
+		if !ok {
+			if n < 4 { // user, nice, system and idle are mandatory
+				return s, fmt.Errorf("invalid value %q in cpu stat line", f)
+			}
+			continue
+		}
+		*fields[n] = v
+	}
+	if n < 8 { // Need at least user, nice, system, idle, iowait, irq, softirq, steal
+		return s, fmt.Errorf("invalid cpu stat line format")
+	}
+	return s, nil
 }
 
-func getCPUTimes() (totalTime, idleTime uint64, err error) {
-	file, err := os.Open("/proc/stat")
+// Total returns the jiffies spent in all states. Guest and GuestNice are
+// already included in User and Nice.
+func (s CPUStatLine) Total() uint64 {
+	return s.User + s.Nice + s.System + s.Idle + s.IOWait + s.IRQ + s.SoftIRQ + s.Steal
+}
+
+// parseCPUStat extracts the aggregate cpu line from /proc/stat contents.
+func parseCPUStat(data []byte) (CPUStatLine, error) {
+	line, _ := nextLine(data)
+	if !bytes.HasPrefix(line, []byte("cpu ")) {
+		return CPUStatLine{}, fmt.Errorf("cpu line not found in /proc/stat")
+	}
+	stats, err := parseCPUStatLine(line)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to open /proc/stat: %w", err)
+		return CPUStatLine{}, fmt.Errorf("failed to parse cpu line from /proc/stat: %w", err)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	if scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "cpu ") {
-			stats, err := parseCPUStatLine(line)
-			if err != nil {
-				return 0, 0, fmt.Errorf("failed to parse cpu line from /proc/stat: %w", err)
-			}
+	return stats, nil
+}
 
-			// Total time is sum of all times except Guest and GuestNice if they are already included in User and Nice
-			// More accurately, total = user + nice + system + idle + iowait + irq + softirq + steal
-			total := stats.User + stats.Nice + stats.System + stats.Idle + stats.IOWait + stats.IRQ + stats.SoftIRQ + stats.Steal
-			// Some consider IOWait as idle, others as busy. Common to include in idle for overall usage.
-			// idle := stats.Idle + stats.IOWait
-			// For strict CPU busy, idle is just stats.Idle. Let's use simple idle.
-			idle := stats.Idle
-			return total, idle, nil
-		}
+// parseCPUTimes extracts total and idle jiffies from /proc/stat contents.
+func parseCPUTimes(data []byte) (totalTime, idleTime uint64, err error) {
+	stats, err := parseCPUStat(data)
+	if err != nil {
+		return 0, 0, err
 	}
-	if err := scanner.Err(); err != nil {
-		return 0, 0, fmt.Errorf("error scanning /proc/stat: %w", err)
+	// Some consider IOWait as idle, others as busy. For strict CPU busy, idle is just stats.Idle.
+	return stats.Total(), stats.Idle, nil
+}
+
+func getCPUStat() (CPUStatLine, error) {
+	bp, err := readProcFile("/proc/stat")
+	if err != nil {
+		return CPUStatLine{}, fmt.Errorf("failed to read /proc/stat: %w", err)
 	}
-	return 0, 0, fmt.Errorf("cpu line not found in /proc/stat")
+	defer releaseProcBuf(bp)
+	return parseCPUStat(*bp)
 }
 
 
-// CollectCPUStats returns total CPU usage percentage.
-// This function is stateful and needs to be called sequentially.
+// CollectCPUStats returns the total CPU usage percentage and its breakdown.
+// This function is stateful and needs to be called sequentially; the first
+// call reports 0. elapsedHint is ignored: percentages are ratios of jiffies.
 func CollectCPUStats(elapsedHint float64) (CollectedMetrics, error) {
+	metrics := make(CollectedMetrics, 1)
+	if err := collectCPUStatsInto(metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// cpuBreakdown are the metrics splitting cpu_percent_total by CPU state.
+// With the idle time they add up to 100%.
+var cpuBreakdown = [...]struct {
+	name    string
+	jiffies func(s CPUStatLine) uint64
+}{
+	{"cpu_percent_user", func(s CPUStatLine) uint64 { return s.User + s.Nice }},
+	{"cpu_percent_system", func(s CPUStatLine) uint64 { return s.System + s.IRQ + s.SoftIRQ }},
+	{"cpu_percent_iowait", func(s CPUStatLine) uint64 { return s.IOWait }},
+	{"cpu_percent_steal", func(s CPUStatLine) uint64 { return s.Steal }},
+}
+
+// collectCPUStatsInto is CollectCPUStats writing into an existing map.
+func collectCPUStatsInto(metrics CollectedMetrics) error {
 	cpuMu.Lock()
 	defer cpuMu.Unlock()
 
-	metrics := make(CollectedMetrics)
-
-	currentTotal, currentIdle, err := getCPUTimes()
+	current, err := getCPUStat()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// On the first run, we can't calculate a percentage, so store and return 0 or error.
-	// For simplicity, we'll allow it to report 0 on the first valid run if prev values are 0.
-	// The caller (GlobalCollector) manages the elapsed time, so it won't call with elapsedHint=0 after the first time.
-
-	if prevCPUTotal == 0 && prevCPUIdle == 0 && elapsedHint <= 0 { // Very first call
-		prevCPUTotal = currentTotal
-		prevCPUIdle = currentIdle
+	// On the first run, we can't calculate a percentage, so store and report 0.
+	if !prevCPUValid { // Very first call
+		prevCPU, prevCPUValid = current, true
 		metrics["cpu_percent_total"] = 0.0 // Cannot calculate on first sample
-		return metrics, nil
+		for _, b := range cpuBreakdown {
+			metrics[b.name] = 0.0
+		}
+		return nil
 	}
 
+	previous := prevCPU
+	prevCPU, prevCPUValid = current, true
+	cpuPercentsInto(previous, current, metrics)
+	return nil
+}
 
-	deltaTotal := currentTotal - prevCPUTotal
-	deltaIdle := currentIdle - prevCPUIdle
-
-	prevCPUTotal = currentTotal
-	prevCPUIdle = currentIdle
-
+// cpuPercentsInto sets cpu_percent_total and its breakdown from the CPU
+// times of two samples. A counter going backwards counts as no time spent.
+func cpuPercentsInto(previous, current CPUStatLine, metrics CollectedMetrics) {
+	delta := func(jiffies func(s CPUStatLine) uint64) uint64 {
+		if cur, prev := jiffies(current), jiffies(previous); cur > prev {
+			return cur - prev
+		}
+		return 0
+	}
+	deltaTotal := delta(CPUStatLine.Total)
 	if deltaTotal == 0 { // No change in ticks, or time warped backwards.
 		metrics["cpu_percent_total"] = 0.0
-	} else {
-		cpuUsage := (1.0 - float64(deltaIdle)/float64(deltaTotal)) * 100.0
-		if cpuUsage < 0 { cpuUsage = 0.0 } // Cap at 0 if deltaIdle > deltaTotal (e.g. time skew)
-		if cpuUsage > 100 { cpuUsage = 100.0 } // Cap at 100
-		metrics["cpu_percent_total"] = cpuUsage
+		for _, b := range cpuBreakdown {
+			metrics[b.name] = 0.0
+		}
+		return
+	}
+	percent := func(jiffies uint64) float64 {
+		return min(float64(jiffies)/float64(deltaTotal)*100.0, 100.0)
+	}
+	metrics["cpu_percent_total"] = 100.0 - percent(delta(func(s CPUStatLine) uint64 { return s.Idle }))
+	for _, b := range cpuBreakdown {
+		metrics[b.name] = percent(delta(b.jiffies))
 	}
-
-	return metrics, nil
 }
 
 // For unit testing or direct use if GlobalCollector doesn't handle initialization
@@ -142,12 +181,9 @@ func NewCPUCollector() MetricCollector {
 type cpuCollectorAdaptor struct{}
 
 func (cca *cpuCollectorAdaptor) Collect() (CollectedMetrics, error) {
-	// This simplified adapter implies CollectCPUStats is called by GlobalCollector which manages elapsed time
-	// For standalone, it would need its own prev time tracking.
-	// We rely on GlobalCollector's elapsedSeconds calculation for now.
-	// A truly independent CPUCollector would need its own lastCollectTime.
-	// For the given design, GlobalCollector is managing state for rates, which is fine.
-	return CollectCPUStats(1) // Dummy elapsed, actual elapsed is handled by GlobalCollector
+	// CPU percentages are ratios of jiffies between two samples, so no elapsed
+	// time is needed; the previous sample is shared with GlobalCollector.
+	return CollectCPUStats(0)
 }
 
 func (cca *cpuCollectorAdaptor) Name() string {
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+// sysCPUDir holds the per-CPU cpufreq and thermal_throttle directories.
+// Replaced in tests.
+var sysCPUDir = "/sys/devices/system/cpu"
+
+// CPUFreqStats is a snapshot of the clock speed and thermal throttling of the CPUs.
+type CPUFreqStats struct {
+	FreqCPUs       int     // CPUs reporting their current frequency (none in most VMs)
+	AvgFreqMHz     float64 // Average current frequency of those CPUs
+	ThrottleCPUs   int     // CPUs with thermal throttle counters (x86 only)
+	ThrottleEvents uint64  // Core throttle events, plus package events once per package
+}
+
+// readCPUFreqStats reads the cpufreq and thermal_throttle files of every CPU
+// under dir. Missing files are not an error: they are only present on hosts
+// whose drivers expose them.
+func readCPUFreqStats(dir string, stats *CPUFreqStats) error {
+	*stats = CPUFreqStats{}
+	cpus, err := filepath.Glob(filepath.Join(dir, "cpu[0-9]*"))
+	if err != nil {
+		return err
+	}
+	var freqSum float64
+	packages := make(map[string]bool)
+	for _, cpu := range cpus {
+		if khz, ok := readUintFile(filepath.Join(cpu, "cpufreq", "scaling_cur_freq")); ok {
+			stats.FreqCPUs++
+			freqSum += float64(khz) / 1000
+		}
+		core, ok := readUintFile(filepath.Join(cpu, "thermal_throttle", "core_throttle_count"))
+		if !ok {
+			continue
+		}
+		stats.ThrottleCPUs++
+		stats.ThrottleEvents += core
+		// Every CPU of a package reports the same package counter
+		pkg, _ := os.ReadFile(filepath.Join(cpu, "topology", "physical_package_id"))
+		if id := string(bytes.TrimSpace(pkg)); !packages[id] {
+			packages[id] = true
+			if n, ok := readUintFile(filepath.Join(cpu, "thermal_throttle", "package_throttle_count")); ok {
+				stats.ThrottleEvents += n
+			}
+		}
+	}
+	if stats.FreqCPUs > 0 {
+		stats.AvgFreqMHz = freqSum / float64(stats.FreqCPUs)
+	}
+	return nil
+}
+
+// readUintFile reads a sysfs file holding a single unsigned integer.
+func readUintFile(path string) (uint64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	return parseUintBytes(bytes.TrimSpace(data))
+}
+
+// collectCPUFreq reports the average CPU frequency and the rate of thermal
+// throttle events against the previous sample. Metrics the host doesn't
+// expose are left out.
+func (gc *GlobalCollector) collectCPUFreq(metrics CollectedMetrics) error {
+	gc.cpuFreq.mu.Lock()
+	defer gc.cpuFreq.mu.Unlock()
+
+	var current CPUFreqStats
+	if err := readCPUFreqStats(sysCPUDir, &current); err != nil {
+		return err
+	}
+	now := gc.now()
+	if current.FreqCPUs > 0 {
+		metrics["cpu_freq_mhz_avg"] = current.AvgFreqMHz
+	}
+	if current.ThrottleCPUs > 0 {
+		rate := 0.0
+		last, elapsedSeconds := gc.cpuFreq.last, gc.cpuFreq.elapsed(now)
+		if last.ThrottleCPUs == current.ThrottleCPUs && elapsedSeconds > 0.1 && current.ThrottleEvents >= last.ThrottleEvents {
+			rate = float64(current.ThrottleEvents-last.ThrottleEvents) / elapsedSeconds
+		}
+		metrics["cpu_throttle_events_ps"] = rate
+	}
+	gc.cpuFreq.store(current, now)
+	return nil
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSysCPU creates the sysfs files of a CPU under dir.
+func writeSysCPU(t *testing.T, dir, cpu string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, cpu, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0644))
+	}
+}
+
+func TestReadCPUFreqStats(t *testing.T) {
+	dir := t.TempDir()
+	for cpu, files := range map[string]map[string]string{
+		"cpu0": {"cpufreq/scaling_cur_freq": "2400000", "thermal_throttle/core_throttle_count": "3", "thermal_throttle/package_throttle_count": "10", "topology/physical_package_id": "0"},
+		"cpu1": {"cpufreq/scaling_cur_freq": "1200000", "thermal_throttle/core_throttle_count": "1", "thermal_throttle/package_throttle_count": "10", "topology/physical_package_id": "0"},
+		"cpu2": {"thermal_throttle/core_throttle_count": "0", "thermal_throttle/package_throttle_count": "5", "topology/physical_package_id": "1"},
+	} {
+		writeSysCPU(t, dir, cpu, files)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "cpufreq"), 0755)) // Not a CPU
+
+	var stats CPUFreqStats
+	require.NoError(t, readCPUFreqStats(dir, &stats))
+	assert.Equal(t, CPUFreqStats{FreqCPUs: 2, AvgFreqMHz: 1800, ThrottleCPUs: 3, ThrottleEvents: 3 + 1 + 0 + 10 + 5}, stats)
+
+	// A VM without cpufreq or throttle counters
+	require.NoError(t, readCPUFreqStats(t.TempDir(), &stats))
+	assert.Equal(t, CPUFreqStats{}, stats)
+}
+
+func TestCollectCPUFreq(t *testing.T) {
+	dir := t.TempDir()
+	oldDir := sysCPUDir
+	sysCPUDir = dir
+	t.Cleanup(func() { sysCPUDir = oldDir })
+	writeSysCPU(t, dir, "cpu0", map[string]string{"cpufreq/scaling_cur_freq": "3000000", "thermal_throttle/core_throttle_count": "100"})
+
+	gc := NewGlobalCollector(nil)
+	now := time.Now()
+	gc.now = func() time.Time { return now }
+	metrics := make(CollectedMetrics)
+	require.NoError(t, gc.collectCPUFreq(metrics))
+	assert.Equal(t, CollectedMetrics{"cpu_freq_mhz_avg": 3000, "cpu_throttle_events_ps": 0}, metrics)
+
+	writeSysCPU(t, dir, "cpu0", map[string]string{"thermal_throttle/core_throttle_count": "120"})
+	now = now.Add(10 * time.Second)
+	require.NoError(t, gc.collectCPUFreq(metrics))
+	assert.Equal(t, 2.0, metrics["cpu_throttle_events_ps"])
+
+	sysCPUDir = t.TempDir()
+	clear(metrics)
+	require.NoError(t, gc.collectCPUFreq(metrics))
+	assert.Empty(t, metrics)
+}
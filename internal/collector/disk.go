@@ -1,10 +1,7 @@
 package collector
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 )
 
@@ -49,46 +46,49 @@ func isRelevantDevice(deviceName string) bool {
 
 // GetDiskStats reads /proc/diskstats and aggregates read/write bytes across relevant devices.
 func GetDiskStats() (*DiskStats, error) {
-	file, err := os.Open("/proc/diskstats")
-	if err != nil {
-		return nil, fmt.Errorf("failed to open /proc/diskstats: %w", err)
+	stats := &DiskStats{}
+	if err := readDiskStats(stats); err != nil {
+		return nil, err
 	}
-	defer file.Close()
+	return stats, nil
+}
 
-	stats := &DiskStats{}
-	scanner := bufio.NewScanner(file)
+func readDiskStats(stats *DiskStats) error {
+	bp, err := readProcFile("/proc/diskstats")
+	if err != nil {
+		return fmt.Errorf("failed to open /proc/diskstats: %w", err)
+	}
+	defer releaseProcBuf(bp)
+	parseDiskStatsData(*bp, stats)
+	return nil
+}
 
-	for scanner.Scan() {
-		fields := strings.Fields(scanner.Text())
-		if len(fields) < 10 { // Need at least up to sectors written
-			continue
-		}
+// parseDiskStatsData aggregates sector counters from /proc/diskstats contents into stats.
+func parseDiskStatsData(data []byte, stats *DiskStats) {
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
 
-		deviceName := fields[2]
-		if !isRelevantDevice(deviceName) {
+		deviceName, rest := nextField(skipFields(line, 2)) // Skip major and minor numbers
+		if len(deviceName) == 0 || !isRelevantDevice(string(deviceName)) {
 			continue
 		}
 		// Field 5: sectors read
-		sectorsRead, err := strconv.ParseUint(fields[5], 10, 64)
-		if err != nil {
-			// log.Printf("Warning: could not parse sectors_read for %s: %v", deviceName, err)
+		field, rest := nextField(skipFields(rest, 2))
+		sectorsRead, ok := parseUintBytes(field)
+		if !ok {
 			continue
 		}
 		// Field 9: sectors written
-		sectorsWritten, err := strconv.ParseUint(fields[9], 10, 64)
-		if err != nil {
-			// log.Printf("Warning: could not parse sectors_written for %s: %v", deviceName, err)
+		field, _ = nextField(skipFields(rest, 3))
+		sectorsWritten, ok := parseUintBytes(field)
+		if !ok { // Also covers lines too short to contain sectors written
 			continue
 		}
 
 		stats.TotalSectorsRead += sectorsRead
 		stats.TotalSectorsWritten += sectorsWritten
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error scanning /proc/diskstats: %w", err)
-	}
-	return stats, nil
 }
 
 // CalculateDiskIORates computes read/write bytes per second.
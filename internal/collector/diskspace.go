@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"syscall"
+)
+
+// statfs reads the usage of a mounted filesystem. Replaced in tests.
+var statfs = syscall.Statfs
+
+// networkFilesystems are left out of the disk space metrics unless included
+// explicitly: statfs blocks for as long as their server doesn't answer.
+var networkFilesystems = map[string]bool{"nfs": true, "nfs4": true, "cifs": true, "smb3": true}
+
+// SetDiskSpaceMounts sets the mount points (shell-style patterns) whose
+// usage is reported. Without include patterns, every local disk filesystem
+// is reported; exclude patterns are applied afterwards.
+func (gc *GlobalCollector) SetDiskSpaceMounts(include, exclude []string) {
+	gc.diskSpaceMu.Lock()
+	defer gc.diskSpaceMu.Unlock()
+	gc.diskSpaceInclude, gc.diskSpaceExclude = include, exclude
+}
+
+// reportsDiskSpace reports whether the usage of a mount is collected.
+func (gc *GlobalCollector) reportsDiskSpace(m Mount) bool {
+	if !diskFilesystems[m.FSType] {
+		return false
+	}
+	included := len(gc.diskSpaceInclude) == 0 && !networkFilesystems[m.FSType]
+	for _, pattern := range gc.diskSpaceInclude {
+		if ok, _ := path.Match(pattern, m.Path); ok {
+			included = true
+			break
+		}
+	}
+	for _, pattern := range gc.diskSpaceExclude {
+		if ok, _ := path.Match(pattern, m.Path); ok {
+			return false
+		}
+	}
+	return included
+}
+
+// collectDiskSpace reports disk_percent_used_<mount> and
+// disk_bytes_free_<mount> for the mounted filesystems selected by
+// SetDiskSpaceMounts. Like df, the percentage leaves out the blocks reserved
+// for root, and the free bytes are those available to other users. A device
+// mounted on several paths, e.g. by bind mounts, is reported once, under its
+// shortest path. Mounts whose usage can't be read are left out with a
+// warning; the collector only fails if none can be read.
+func (gc *GlobalCollector) collectDiskSpace(metrics CollectedMetrics) error {
+	mounts, err := readMounts()
+	if err != nil {
+		return err
+	}
+	gc.diskSpaceMu.Lock()
+	defer gc.diskSpaceMu.Unlock()
+	byDevice := make(map[string]string) // Device -> shortest mount path
+	for _, m := range mounts {
+		if !gc.reportsDiskSpace(m) {
+			continue
+		}
+		if prev, ok := byDevice[m.Device]; !ok || len(m.Path) < len(prev) {
+			byDevice[m.Device] = m.Path
+		}
+	}
+	failed := 0
+	for _, mountPath := range byDevice {
+		var st syscall.Statfs_t
+		if err := statfs(mountPath, &st); err != nil {
+			// E.g. a mount point monres has no permission to search
+			if !gc.diskSpaceFailed[mountPath] {
+				log.Printf("Warning: Failed to read the disk space of %s: %v", mountPath, err)
+				gc.diskSpaceFailed[mountPath] = true
+			}
+			failed++
+			continue
+		}
+		if gc.diskSpaceFailed[mountPath] {
+			log.Printf("Disk space of %s can be read again.", mountPath)
+			delete(gc.diskSpaceFailed, mountPath)
+		}
+		if st.Blocks == 0 {
+			continue
+		}
+		blockSize := uint64(st.Frsize)
+		if blockSize == 0 {
+			blockSize = uint64(st.Bsize)
+		}
+		used := st.Blocks - st.Bfree
+		name := MountMetricName(mountPath)
+		metrics["disk_percent_used_"+name] = 100 * float64(used) / float64(used+st.Bavail)
+		metrics["disk_bytes_free_"+name] = float64(st.Bavail * blockSize)
+	}
+	if failed > 0 && failed == len(byDevice) {
+		return fmt.Errorf("failed to read the disk space of all %d filesystems", failed)
+	}
+	return nil
+}
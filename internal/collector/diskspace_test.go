@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectDiskSpace(t *testing.T) {
+	oldMounts, oldStatfs := procMounts, statfs
+	procMounts = filepath.Join(t.TempDir(), "mounts")
+	t.Cleanup(func() { procMounts, statfs = oldMounts, oldStatfs })
+	require.NoError(t, os.WriteFile(procMounts, []byte(`proc /proc proc rw 0 0
+/dev/sda1 / ext4 rw,relatime 0 0
+/dev/sda1 /srv/www ext4 rw,relatime 0 0
+/dev/sda2 /var xfs rw,relatime 0 0
+/dev/sdb1 /boot/efi vfat rw,relatime 0 0
+nas:/export /mnt/backups nfs4 rw,relatime 0 0
+`), 0644))
+
+	var calls []string
+	statfs = func(path string, st *syscall.Statfs_t) error {
+		calls = append(calls, path)
+		switch path {
+		case "/":
+			// 100 blocks of 4 KiB, 25 free of which 5 are reserved for root
+			*st = syscall.Statfs_t{Bsize: 4096, Frsize: 4096, Blocks: 100, Bfree: 25, Bavail: 20}
+		case "/var", "/boot/efi", "/mnt/backups":
+			*st = syscall.Statfs_t{Bsize: 1024, Blocks: 10, Bfree: 5, Bavail: 5}
+		default:
+			return errors.New("unexpected path " + path)
+		}
+		return nil
+	}
+
+	gc := NewGlobalCollector(nil)
+	gc.SetDiskSpaceMounts(nil, []string{"/boot/*"})
+	metrics := make(CollectedMetrics)
+	require.NoError(t, gc.collectDiskSpace(metrics))
+	assert.Equal(t, CollectedMetrics{
+		"disk_percent_used_root": 75.0 / 95 * 100,
+		"disk_bytes_free_root":   20 * 4096,
+		"disk_percent_used_var":  50,
+		"disk_bytes_free_var":    5 * 1024,
+	}, metrics)
+	assert.ElementsMatch(t, []string{"/", "/var"}, calls, "the bind mount, excluded and network filesystems are not read")
+
+	// Network filesystems are only reported when included
+	gc.SetDiskSpaceMounts([]string{"/mnt/*"}, nil)
+	metrics = make(CollectedMetrics)
+	require.NoError(t, gc.collectDiskSpace(metrics))
+	assert.Equal(t, CollectedMetrics{"disk_percent_used_mnt_backups": 50, "disk_bytes_free_mnt_backups": 5 * 1024}, metrics)
+
+	// Unreadable mounts are left out, and fail the collector once none is left
+	gc.SetDiskSpaceMounts(nil, nil)
+	statfs = func(path string, st *syscall.Statfs_t) error {
+		if path == "/" {
+			return syscall.EACCES
+		}
+		*st = syscall.Statfs_t{Bsize: 1024, Blocks: 10, Bfree: 5, Bavail: 5}
+		return nil
+	}
+	metrics = make(CollectedMetrics)
+	require.NoError(t, gc.collectDiskSpace(metrics))
+	assert.NotContains(t, metrics, "disk_percent_used_root")
+	assert.Contains(t, metrics, "disk_percent_used_var")
+
+	statfs = func(string, *syscall.Statfs_t) error { return syscall.EIO }
+	assert.Error(t, gc.collectDiskSpace(make(CollectedMetrics)))
+}
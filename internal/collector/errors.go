@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrCollectorTimeout is reported for collectors that did not finish before the cycle deadline.
+var ErrCollectorTimeout = errors.New("collector did not finish before the deadline")
+
+// ErrCollectorBusy is reported for collectors skipped because a previous run is still in progress.
+var ErrCollectorBusy = errors.New("collector still running from a previous cycle")
+
+// CollectorError is the failure of a single collector during a cycle.
+type CollectorError struct {
+	Collector string
+	Err       error
+}
+
+func (e *CollectorError) Error() string {
+	return e.Collector + ": " + e.Err.Error()
+}
+
+func (e *CollectorError) Unwrap() error {
+	return e.Err
+}
+
+// CollectErrors lists every collector that failed during a cycle.
+// CollectAll returns it alongside whatever metrics the other collectors produced.
+type CollectErrors []*CollectorError
+
+func (errs CollectErrors) Error() string {
+	parts := make([]string, len(errs))
+	for i, e := range errs {
+		parts[i] = e.Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap allows errors.Is and errors.As to inspect the individual failures.
+func (errs CollectErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, e := range errs {
+		unwrapped[i] = e
+	}
+	return unwrapped
+}
+
+// Failed reports whether the named collector is among the failures.
+func (errs CollectErrors) Failed(name string) bool {
+	for _, e := range errs {
+		if e.Collector == name {
+			return true
+		}
+	}
+	return false
+}
+
+// upMetricName returns the name of the collector_up metric for a collector.
+func upMetricName(collector string) string {
+	return "collector_up_" + collector
+}
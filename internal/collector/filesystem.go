@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// procMounts is the mount table read by the filesystem source. Replaced in tests.
+var procMounts = "/proc/mounts"
+
+// diskFilesystems are the filesystem types whose mounts are reported: those
+// backed by disks or network storage. Pseudo filesystems, squashfs images
+// and the like are read-only by design or don't hold data.
+var diskFilesystems = map[string]bool{
+	"ext2": true, "ext3": true, "ext4": true, "xfs": true, "btrfs": true, "zfs": true, "bcachefs": true,
+	"f2fs": true, "jfs": true, "reiserfs": true, "vfat": true, "exfat": true, "ntfs": true, "ntfs3": true,
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true,
+}
+
+// Mount is an entry of /proc/mounts.
+type Mount struct {
+	Device   string
+	Path     string
+	FSType   string
+	ReadOnly bool
+}
+
+// parseMounts parses the contents of /proc/mounts. When several filesystems
+// are mounted on the same path, only the last one, which hides the others,
+// is kept.
+func parseMounts(data []byte) []Mount {
+	var mounts []Mount
+	index := make(map[string]int) // Path -> index in mounts
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		device, rest := nextField(line)
+		path, rest := nextField(rest)
+		fstype, rest := nextField(rest)
+		options, _ := nextField(rest)
+		if len(options) == 0 {
+			continue
+		}
+		m := Mount{
+			Device:   unescapeMountField(device),
+			Path:     unescapeMountField(path),
+			FSType:   string(fstype),
+			ReadOnly: bytes.Equal(options, []byte("ro")) || bytes.HasPrefix(options, []byte("ro,")),
+		}
+		if i, ok := index[m.Path]; ok {
+			mounts[i] = m
+			continue
+		}
+		index[m.Path] = len(mounts)
+		mounts = append(mounts, m)
+	}
+	return mounts
+}
+
+// unescapeMountField decodes the octal escapes (\040 for a space) the kernel
+// uses in /proc/mounts.
+func unescapeMountField(field []byte) string {
+	if bytes.IndexByte(field, '\\') < 0 {
+		return string(field)
+	}
+	var b []byte
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if n, err := strconv.ParseUint(string(field[i+1:i+4]), 8, 8); err == nil {
+				b = append(b, byte(n))
+				i += 3
+				continue
+			}
+		}
+		b = append(b, field[i])
+	}
+	return string(b)
+}
+
+// readMounts reads the mount table.
+func readMounts() ([]Mount, error) {
+	bp, err := readProcFile(procMounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procMounts, err)
+	}
+	defer releaseProcBuf(bp)
+	return parseMounts(*bp), nil
+}
+
+// MountMetricName returns the name of a mount point in metric names, e.g.
+// "var_lib_docker" for /var/lib/docker and "root" for /.
+func MountMetricName(path string) string {
+	if name := sanitizeMetricName(path); name != "" {
+		return name
+	}
+	return "root"
+}
+
+// collectFilesystems reports, for every mounted disk or network filesystem,
+// fs_readonly_<mount> (1 when mounted read-only, e.g. after the kernel
+// remounted it on I/O errors) and their total as fs_readonly_count, and
+// mount_present_<mount> for every expected mount point.
+func (gc *GlobalCollector) collectFilesystems(metrics CollectedMetrics) error {
+	mounts, err := readMounts()
+	if err != nil {
+		return err
+	}
+	gc.mountsMu.Lock()
+	defer gc.mountsMu.Unlock()
+	mounted := make(map[string]bool, len(mounts))
+	readOnly := 0
+	for _, m := range mounts {
+		mounted[m.Path] = true
+		if !diskFilesystems[m.FSType] {
+			continue
+		}
+		if gc.learnMounts {
+			gc.expectedMounts[m.Path] = true
+		}
+		value := 0.0
+		if m.ReadOnly {
+			value = 1
+			readOnly++
+		}
+		metrics["fs_readonly_"+MountMetricName(m.Path)] = value
+	}
+	metrics["fs_readonly_count"] = float64(readOnly)
+	for path := range gc.expectedMounts {
+		value := 0.0
+		if mounted[path] {
+			value = 1
+		}
+		metrics["mount_present_"+MountMetricName(path)] = value
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMounts(t *testing.T) {
+	mounts := parseMounts(readTestdata(t, "proc_mounts"))
+	require.Len(t, mounts, 8)
+	assert.Equal(t, Mount{Device: "/dev/sda1", Path: "/", FSType: "ext4"}, mounts[2])
+	assert.Equal(t, Mount{Device: "/dev/sda2", Path: "/var/lib/docker", FSType: "xfs", ReadOnly: true}, mounts[3])
+	assert.Equal(t, "/mnt/My Backups", mounts[6].Path)
+	assert.Equal(t, Mount{Device: "/dev/sdc1", Path: "/data", FSType: "ext4", ReadOnly: true}, mounts[7], "the last mount on a path hides the others")
+}
+
+func TestMountMetricName(t *testing.T) {
+	assert.Equal(t, "root", MountMetricName("/"))
+	assert.Equal(t, "var_lib_docker", MountMetricName("/var/lib/docker"))
+	assert.Equal(t, "mnt_my_backups", MountMetricName("/mnt/My Backups"))
+}
+
+func TestCollectFilesystems(t *testing.T) {
+	oldMounts := procMounts
+	procMounts = filepath.Join("..", "..", "testdata", "proc_mounts")
+	t.Cleanup(func() { procMounts = oldMounts })
+
+	metrics := make(CollectedMetrics)
+	require.NoError(t, NewGlobalCollector(nil).collectFilesystems(metrics))
+	assert.Equal(t, CollectedMetrics{
+		"fs_readonly_root":             0,
+		"fs_readonly_var_lib_docker":   1,
+		"fs_readonly_mnt_my_backups":   0,
+		"fs_readonly_data":             1,
+		"fs_readonly_count":            2,
+		"mount_present_root":           1,
+		"mount_present_var_lib_docker": 1,
+		"mount_present_mnt_my_backups": 1,
+		"mount_present_data":           1,
+	}, metrics)
+
+	procMounts = filepath.Join(t.TempDir(), "missing")
+	assert.Error(t, NewGlobalCollector(nil).collectFilesystems(metrics))
+}
+
+func TestCollectFilesystemsMountPresent(t *testing.T) {
+	oldMounts := procMounts
+	dir := t.TempDir()
+	procMounts = filepath.Join(dir, "mounts")
+	t.Cleanup(func() { procMounts = oldMounts })
+	writeMounts := func(lines string) {
+		require.NoError(t, os.WriteFile(procMounts, []byte(lines), 0644))
+	}
+	present := func(gc *GlobalCollector) CollectedMetrics {
+		metrics := make(CollectedMetrics)
+		require.NoError(t, gc.collectFilesystems(metrics))
+		result := make(CollectedMetrics)
+		for name, value := range metrics {
+			if strings.HasPrefix(name, "mount_present_") {
+				result[name] = value
+			}
+		}
+		return result
+	}
+	root := "/dev/sda1 / ext4 rw 0 0\ntmpfs /run tmpfs rw 0 0\n"
+	nfs := "nas:/export /mnt/backups nfs4 rw 0 0\n"
+
+	// Learned from the disk and network filesystems seen mounted
+	gc := NewGlobalCollector(nil)
+	writeMounts(root + nfs)
+	assert.Equal(t, CollectedMetrics{"mount_present_root": 1, "mount_present_mnt_backups": 1}, present(gc))
+	writeMounts(root)
+	assert.Equal(t, CollectedMetrics{"mount_present_root": 1, "mount_present_mnt_backups": 0}, present(gc))
+
+	// Configured, including a mount never seen
+	gc = NewGlobalCollector(nil)
+	gc.SetExpectedMounts([]string{"/mnt/backups", "/srv"})
+	writeMounts(root + nfs)
+	assert.Equal(t, CollectedMetrics{"mount_present_mnt_backups": 1, "mount_present_srv": 0}, present(gc))
+}
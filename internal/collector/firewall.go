@@ -0,0 +1,223 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// Firewall backends of the FirewallCollector.
+const (
+	FirewallNftables = "nftables"
+	FirewallIptables = "iptables"
+)
+
+// firewallTimeout bounds a run of nft or iptables-save.
+const firewallTimeout = 10 * time.Second
+
+// FirewallCounter is the packet and byte count of a named firewall counter.
+type FirewallCounter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// FirewallCollector reports the rate of named firewall counters as
+// fw_<name>_packets_ps and fw_<name>_bytes_ps: nftables named counters and
+// rules with a comment and a counter, or iptables rules with a comment.
+// Counters sharing a name, e.g. the same comment on rules of several chains,
+// are summed. Reading the ruleset needs CAP_NET_ADMIN.
+type FirewallCollector struct {
+	backend  string
+	counters map[string]bool // Names to report; empty reports all
+	last     map[string]FirewallCounter
+	lastTime time.Time
+	mu       sync.Mutex
+}
+
+// NewFirewallCollector creates a collector reading the counters of backend
+// (FirewallNftables or FirewallIptables). With names, only those counters
+// are reported.
+func NewFirewallCollector(backend string, names []string) *FirewallCollector {
+	fc := &FirewallCollector{backend: backend, counters: make(map[string]bool, len(names))}
+	for _, name := range names {
+		fc.counters[name] = true
+	}
+	return fc
+}
+
+func (fc *FirewallCollector) Name() string {
+	return "firewall"
+}
+
+// Collect reads the counters and returns their rates since the previous
+// call; the first call reports 0.
+func (fc *FirewallCollector) Collect() (CollectedMetrics, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), firewallTimeout)
+	defer cancel()
+	var current map[string]FirewallCounter
+	switch fc.backend {
+	case FirewallNftables:
+		out, err := runCommand(ctx, "nft", "-j", "list", "ruleset")
+		if err != nil {
+			return nil, fmt.Errorf("failed to run nft: %w", err)
+		}
+		if current, err = parseNftCounters(out); err != nil {
+			return nil, err
+		}
+	case FirewallIptables:
+		out, err := runCommand(ctx, "iptables-save", "-c")
+		if err != nil {
+			return nil, fmt.Errorf("failed to run iptables-save: %w", err)
+		}
+		current = parseIptablesCounters(out)
+	default:
+		return nil, fmt.Errorf("unknown firewall backend '%s'", fc.backend)
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(fc.lastTime).Seconds()
+	metrics := make(CollectedMetrics)
+	for name, cur := range current {
+		if len(fc.counters) > 0 && !fc.counters[name] {
+			continue
+		}
+		packetRate, byteRate := 0.0, 0.0
+		if last, ok := fc.last[name]; ok && elapsed > 0.1 && cur.Packets >= last.Packets && cur.Bytes >= last.Bytes {
+			packetRate = float64(cur.Packets-last.Packets) / elapsed
+			byteRate = float64(cur.Bytes-last.Bytes) / elapsed
+		}
+		prefix := "fw_" + sanitizeMetricName(name)
+		metrics[prefix+"_packets_ps"] = packetRate
+		metrics[prefix+"_bytes_ps"] = byteRate
+		registerFirewallMetrics(prefix, name)
+	}
+	fc.last, fc.lastTime = current, now
+	return metrics, nil
+}
+
+// registerFirewallMetrics registers the metadata of the metrics of a counter
+// the first time it is seen.
+func registerFirewallMetrics(prefix, name string) {
+	if _, known := metricsmeta.Lookup(prefix + "_bytes_ps"); known {
+		return
+	}
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_packets_ps",
+		Type:        metricsmeta.TypeRate,
+		Description: "Packets per second matching the " + name + " firewall counter",
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_bytes_ps",
+		Unit:        metricsmeta.UnitBytesPerSecond,
+		Type:        metricsmeta.TypeRate,
+		Description: "Traffic matching the " + name + " firewall counter",
+	})
+}
+
+// nftCounter is a counter in the JSON output of nft.
+type nftCounter struct {
+	Name    string `json:"name"`
+	Packets uint64 `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+// parseNftCounters parses the output of `nft -j list ruleset`, returning the
+// named counters and the counters of the rules with a comment.
+func parseNftCounters(data []byte) (map[string]FirewallCounter, error) {
+	var ruleset struct {
+		Nftables []struct {
+			Counter *nftCounter `json:"counter"`
+			Rule    *struct {
+				Comment string            `json:"comment"`
+				Expr    []json.RawMessage `json:"expr"`
+			} `json:"rule"`
+		} `json:"nftables"`
+	}
+	if err := json.Unmarshal(data, &ruleset); err != nil {
+		return nil, fmt.Errorf("failed to parse nft output: %w", err)
+	}
+	counters := make(map[string]FirewallCounter)
+	add := func(name string, packets, bytes uint64) {
+		c := counters[name]
+		c.Packets += packets
+		c.Bytes += bytes
+		counters[name] = c
+	}
+	for _, item := range ruleset.Nftables {
+		switch {
+		case item.Counter != nil:
+			add(item.Counter.Name, item.Counter.Packets, item.Counter.Bytes)
+		case item.Rule != nil && item.Rule.Comment != "":
+			for _, raw := range item.Rule.Expr {
+				var expr struct {
+					Counter json.RawMessage `json:"counter"`
+				}
+				var c nftCounter
+				// A reference to a named counter is a string, counted above
+				if json.Unmarshal(raw, &expr) == nil && json.Unmarshal(expr.Counter, &c) == nil {
+					add(item.Rule.Comment, c.Packets, c.Bytes)
+				}
+			}
+		}
+	}
+	return counters, nil
+}
+
+// parseIptablesCounters parses the output of `iptables-save -c`, returning
+// the counters of the rules with a comment:
+//
+//	[12:720] -A INPUT -s 192.0.2.1/32 -m comment --comment "ssh drop" -j DROP
+func parseIptablesCounters(data []byte) map[string]FirewallCounter {
+	counters := make(map[string]FirewallCounter)
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		if !bytes.HasPrefix(line, []byte("[")) {
+			continue
+		}
+		end := bytes.IndexByte(line, ']')
+		i := bytes.Index(line, []byte("--comment "))
+		if end < 0 || i < 0 {
+			continue
+		}
+		packetsField, bytesField, ok := bytes.Cut(line[1:end], []byte(":"))
+		if !ok {
+			continue
+		}
+		packets, ok1 := parseUintBytes(packetsField)
+		n, ok2 := parseUintBytes(bytesField)
+		name := iptablesComment(line[i+len("--comment "):])
+		if !ok1 || !ok2 || name == "" {
+			continue
+		}
+		c := counters[name]
+		c.Packets += packets
+		c.Bytes += n
+		counters[name] = c
+	}
+	return counters
+}
+
+// iptablesComment returns the comment at the start of s, which
+// iptables-save quotes when it contains spaces.
+func iptablesComment(s []byte) string {
+	if len(s) > 0 && s[0] == '"' {
+		if comment, err := strconv.QuotedPrefix(string(s)); err == nil {
+			if unquoted, err := strconv.Unquote(comment); err == nil {
+				return unquoted
+			}
+		}
+		return ""
+	}
+	field, _ := nextField(s)
+	return string(field)
+}
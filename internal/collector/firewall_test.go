@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNftCounters(t *testing.T) {
+	counters, err := parseNftCounters(readTestdata(t, "nft_ruleset.json"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]FirewallCounter{
+		"ssh_drop":     {Packets: 40, Bytes: 2400},
+		"drop invalid": {Packets: 15, Bytes: 780},
+	}, counters, "named counters referenced by rules are counted once")
+
+	_, err = parseNftCounters([]byte("Error: Could not process rule: Operation not permitted"))
+	assert.Error(t, err)
+}
+
+func TestParseIptablesCounters(t *testing.T) {
+	assert.Equal(t, map[string]FirewallCounter{
+		"ssh drop": {Packets: 40, Bytes: 2400},
+		"invalid":  {Packets: 15, Bytes: 780},
+	}, parseIptablesCounters(readTestdata(t, "iptables_save")))
+}
+
+func TestFirewallCollector(t *testing.T) {
+	oldRun := runCommand
+	t.Cleanup(func() { runCommand = oldRun })
+	output := "[10:1000] -A INPUT -m comment --comment drop -j DROP\n[5:500] -A INPUT -m comment --comment other -j DROP\n"
+	var ran []string
+	runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		ran = append([]string{name}, args...)
+		return []byte(output), nil
+	}
+
+	fc := NewFirewallCollector(FirewallIptables, []string{"drop"})
+	metrics, err := fc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"iptables-save", "-c"}, ran)
+	assert.Equal(t, CollectedMetrics{"fw_drop_packets_ps": 0, "fw_drop_bytes_ps": 0}, metrics, "no rates on the first run")
+
+	fc.lastTime = fc.lastTime.Add(-10 * time.Second)
+	output = "[110:11000] -A INPUT -m comment --comment drop -j DROP\n"
+	metrics, err = fc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 10, metrics["fw_drop_packets_ps"], 0.1)
+	assert.InDelta(t, 1000, metrics["fw_drop_bytes_ps"], 10)
+
+	md, ok := metricsmeta.Lookup("fw_drop_bytes_ps")
+	require.True(t, ok)
+	assert.Equal(t, metricsmeta.UnitBytesPerSecond, md.Unit)
+
+	runCommand = func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("permission denied")
+	}
+	_, err = NewFirewallCollector(FirewallNftables, nil).Collect()
+	assert.ErrorContains(t, err, "failed to run nft")
+}
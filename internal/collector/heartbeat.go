@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// CronJob is a scheduled job reporting its successful runs as heartbeats.
+type CronJob struct {
+	Name   string
+	Period time.Duration // Expected time between two successful runs
+	Grace  time.Duration // How late a run may report before the job is overdue
+}
+
+// HeartbeatCollector receives the heartbeats that cron jobs send when they
+// succeed, e.g. with curl through the API, and reports for every job
+// cron_<job>_seconds_since_success and cron_<job>_overdue: 1 once no
+// heartbeat arrived for the job's period plus its grace time. Heartbeats
+// aren't persisted, so after a restart jobs count from the start of monres.
+type HeartbeatCollector struct {
+	jobs        []CronJob
+	lastSuccess map[string]time.Time
+	overdue     map[string]bool
+	now         func() time.Time
+	mu          sync.Mutex
+}
+
+// NewHeartbeatCollector creates a collector for the given jobs.
+func NewHeartbeatCollector(jobs []CronJob) *HeartbeatCollector {
+	hc := &HeartbeatCollector{
+		jobs:        jobs,
+		lastSuccess: make(map[string]time.Time, len(jobs)),
+		overdue:     make(map[string]bool, len(jobs)),
+		now:         time.Now,
+	}
+	started := hc.now()
+	for _, job := range jobs {
+		hc.lastSuccess[job.Name] = started
+		for _, md := range []metricsmeta.Metadata{
+			{
+				Name:        cronMetricName(job.Name, "seconds_since_success"),
+				Unit:        metricsmeta.UnitSeconds,
+				Type:        metricsmeta.TypeGauge,
+				Description: fmt.Sprintf("Seconds since the cron job %s last reported success", job.Name),
+			},
+			{
+				Name:        cronMetricName(job.Name, "overdue"),
+				Type:        metricsmeta.TypeGauge,
+				Description: fmt.Sprintf("1 if the cron job %s missed its schedule, else 0", job.Name),
+			},
+		} {
+			if _, known := metricsmeta.Lookup(md.Name); !known {
+				metricsmeta.Register(md)
+			}
+		}
+	}
+	return hc
+}
+
+func (hc *HeartbeatCollector) Name() string {
+	return "cron"
+}
+
+// Beat records a successful run of the named job. It returns false if no
+// such job is configured.
+func (hc *HeartbeatCollector) Beat(job string) bool {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if _, ok := hc.lastSuccess[job]; !ok {
+		return false
+	}
+	hc.lastSuccess[job] = hc.now()
+	if hc.overdue[job] {
+		log.Printf("Cron job '%s' reported success again.", job)
+		hc.overdue[job] = false
+	}
+	return true
+}
+
+// Collect returns the time since the last heartbeat of every job.
+func (hc *HeartbeatCollector) Collect() (CollectedMetrics, error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	now := hc.now()
+	metrics := make(CollectedMetrics, 2*len(hc.jobs))
+	for _, job := range hc.jobs {
+		since := now.Sub(hc.lastSuccess[job.Name])
+		overdue := since > job.Period+job.Grace
+		if overdue && !hc.overdue[job.Name] {
+			log.Printf("Warning: Cron job '%s' has not reported success for %s.", job.Name, since.Round(time.Second))
+		}
+		hc.overdue[job.Name] = overdue
+		metrics[cronMetricName(job.Name, "seconds_since_success")] = since.Seconds()
+		metrics[cronMetricName(job.Name, "overdue")] = boolValue(overdue)
+	}
+	return metrics, nil
+}
+
+// cronMetricName returns the name of a metric of a cron job, e.g.
+// cron_backup_overdue.
+func cronMetricName(job, metric string) string {
+	return "cron_" + sanitizeMetricName(job) + "_" + metric
+}
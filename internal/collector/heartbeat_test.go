@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatCollector(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	hc := NewHeartbeatCollector([]CronJob{
+		{Name: "backup", Period: 24 * time.Hour, Grace: time.Hour},
+		{Name: "sync-feeds", Period: 15 * time.Minute, Grace: 5 * time.Minute},
+	})
+	hc.now = func() time.Time { return now }
+	for job := range hc.lastSuccess {
+		hc.lastSuccess[job] = now // Jobs count from the start of monres
+	}
+
+	now = now.Add(30 * time.Minute)
+	assert.True(t, hc.Beat("backup"))
+	assert.False(t, hc.Beat("unknown"))
+
+	now = now.Add(10 * time.Minute)
+	metrics, err := hc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{
+		"cron_backup_seconds_since_success":     600,
+		"cron_backup_overdue":                   0,
+		"cron_sync_feeds_seconds_since_success": 2400,
+		"cron_sync_feeds_overdue":               1,
+	}, metrics)
+
+	assert.True(t, hc.Beat("sync-feeds"))
+	metrics, err = hc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, metrics["cron_sync_feeds_seconds_since_success"])
+	assert.Equal(t, 0.0, metrics["cron_sync_feeds_overdue"])
+}
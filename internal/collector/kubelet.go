@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kubeletTimeout bounds a read of the kubelet's stats.
+const kubeletTimeout = 10 * time.Second
+
+// KubeletCollector reads the resource usage of the pods of a Kubernetes
+// node from the kubelet's /stats/summary and reports, for every pod, named
+// by namespace and pod (e.g. default_web_7d4b9c_x2kfp):
+//
+//	pod_cpu_millicores_<pod>  CPU use, in thousandths of a core
+//	pod_memory_bytes_<pod>    working set memory, what the OOM killer counts
+//
+// It suits small clusters, such as a single k3s node, where a Prometheus
+// stack would cost more than the workloads it watches.
+type KubeletCollector struct {
+	address   string
+	tokenFile string
+	client    *http.Client
+	mu        sync.Mutex
+}
+
+// NewKubeletCollector creates a collector reading the kubelet at address,
+// e.g. https://127.0.0.1:10250, authenticating with the bearer token in
+// tokenFile unless it is empty. The file is read on every collection, so
+// rotated tokens are picked up.
+func NewKubeletCollector(address, tokenFile string, client *http.Client) *KubeletCollector {
+	return &KubeletCollector{address: strings.TrimSuffix(address, "/"), tokenFile: tokenFile, client: client}
+}
+
+func (kc *KubeletCollector) Name() string {
+	return "kubelet"
+}
+
+// Collect reads the stats summary and returns the usage of every pod.
+func (kc *KubeletCollector) Collect() (CollectedMetrics, error) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), kubeletTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, kc.address+"/stats/summary", nil)
+	if err != nil {
+		return nil, err
+	}
+	if kc.tokenFile != "" {
+		token, err := os.ReadFile(kc.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubelet token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+string(bytes.TrimSpace(token)))
+	}
+	resp, err := kc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStatusSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kubelet returned %s", resp.Status)
+	}
+	metrics := make(CollectedMetrics)
+	if err := parseKubeletSummary(body, metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// parseKubeletSummary parses the kubelet's stats summary, adding the usage
+// of every pod to metrics. Pods without stats yet, e.g. just started, are
+// left out.
+func parseKubeletSummary(data []byte, metrics CollectedMetrics) error {
+	var summary struct {
+		Pods []struct {
+			PodRef struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"podRef"`
+			CPU *struct {
+				UsageNanoCores *float64 `json:"usageNanoCores"`
+			} `json:"cpu"`
+			Memory *struct {
+				WorkingSetBytes *float64 `json:"workingSetBytes"`
+			} `json:"memory"`
+		} `json:"pods"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return fmt.Errorf("invalid kubelet stats summary: %w", err)
+	}
+	for _, pod := range summary.Pods {
+		name := sanitizeMetricName(pod.PodRef.Namespace + "_" + pod.PodRef.Name)
+		if pod.CPU != nil && pod.CPU.UsageNanoCores != nil {
+			metrics["pod_cpu_millicores_"+name] = *pod.CPU.UsageNanoCores / 1e6
+		}
+		if pod.Memory != nil && pod.Memory.WorkingSetBytes != nil {
+			metrics["pod_memory_bytes_"+name] = *pod.Memory.WorkingSetBytes
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKubeletSummary(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	require.NoError(t, parseKubeletSummary(readTestdata(t, "kubelet_stats_summary.json"), metrics))
+	assert.Equal(t, CollectedMetrics{
+		"pod_cpu_millicores_default_web_7d4b9c_x2kfp":            250,
+		"pod_memory_bytes_default_web_7d4b9c_x2kfp":              157286400,
+		"pod_cpu_millicores_kube_system_coredns_6799fbcd5_9vqzr": 3.5,
+		"pod_memory_bytes_kube_system_coredns_6799fbcd5_9vqzr":   20971520,
+	}, metrics, "pods without stats are left out")
+}
+
+func TestKubeletCollector(t *testing.T) {
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/stats/summary", r.URL.Path)
+		w.Write(readTestdata(t, "kubelet_stats_summary.json"))
+	}))
+	defer kubelet.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s3cret\n"), 0600))
+
+	metrics, err := NewKubeletCollector(kubelet.URL+"/", tokenFile, kubelet.Client()).Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 250.0, metrics["pod_cpu_millicores_default_web_7d4b9c_x2kfp"])
+
+	_, err = NewKubeletCollector(kubelet.URL, "", kubelet.Client()).Collect()
+	assert.ErrorContains(t, err, "401 Unauthorized")
+}
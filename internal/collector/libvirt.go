@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// libvirtTimeout bounds a run of virsh.
+const libvirtTimeout = 10 * time.Second
+
+// virDomainRunning is the state.state of a running domain.
+const virDomainRunning = 1
+
+// Domain is the state and usage of a libvirt guest, as listed by
+// `virsh domstats`.
+type Domain struct {
+	Name        string
+	State       int    // state.state: 1 running, 3 paused, 5 shut off, ...
+	CPUTimeNs   uint64 // cpu.time, CPU time used by the guest since it started
+	VCPUs       int    // vcpu.current
+	MemoryBytes uint64 // balloon.rss, or balloon.current without it
+}
+
+// LibvirtCollector reports, for every libvirt guest (domain):
+//
+//	vm_running_<name>       1 if the guest runs, else 0 (shut off, paused, crashed)
+//	vm_cpu_percent_<name>   CPU use, relative to the guest's vCPUs
+//	vm_memory_bytes_<name>  memory used on the host
+//
+// CPU and memory are left out for guests that don't run. The CPU use is
+// measured against the previous call; the first call reports 0.
+type LibvirtCollector struct {
+	uri      string
+	last     map[string]Domain
+	lastTime time.Time
+	mu       sync.Mutex
+}
+
+// NewLibvirtCollector creates a collector listing the guests of the given
+// libvirt connection URI, e.g. qemu:///system, or the default one if empty.
+func NewLibvirtCollector(uri string) *LibvirtCollector {
+	return &LibvirtCollector{uri: uri}
+}
+
+func (lc *LibvirtCollector) Name() string {
+	return "libvirt"
+}
+
+// Collect lists the guests and returns their metrics.
+func (lc *LibvirtCollector) Collect() (CollectedMetrics, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), libvirtTimeout)
+	defer cancel()
+	var args []string
+	if lc.uri != "" {
+		args = append(args, "-c", lc.uri)
+	}
+	args = append(args, "domstats", "--raw", "--state", "--cpu-total", "--vcpu", "--balloon")
+	out, err := runCommand(ctx, "virsh", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run virsh: %w", err)
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(lc.lastTime).Seconds()
+	current := make(map[string]Domain)
+	metrics := make(CollectedMetrics)
+	for _, d := range parseDomStats(out) {
+		name := sanitizeMetricName(d.Name)
+		running := d.State == virDomainRunning
+		metrics["vm_running_"+name] = boolValue(running)
+		if !running {
+			continue
+		}
+		current[d.Name] = d
+		cpu := 0.0
+		if last, ok := lc.last[d.Name]; ok && elapsed > 0.1 && d.VCPUs > 0 && d.CPUTimeNs >= last.CPUTimeNs {
+			cpu = float64(d.CPUTimeNs-last.CPUTimeNs) / 1e9 / elapsed / float64(d.VCPUs) * 100
+		}
+		metrics["vm_cpu_percent_"+name] = cpu
+		metrics["vm_memory_bytes_"+name] = float64(d.MemoryBytes)
+	}
+	lc.last, lc.lastTime = current, now
+	return metrics, nil
+}
+
+// parseDomStats parses the output of `virsh domstats --raw`:
+//
+//	Domain: 'web1'
+//	  state.state=1
+//	  cpu.time=8311236090
+//	  balloon.rss=1048576
+func parseDomStats(data []byte) []Domain {
+	var domains []Domain
+	var rssKiB, currentKiB uint64
+	finish := func() {
+		if len(domains) == 0 {
+			return
+		}
+		d := &domains[len(domains)-1]
+		d.MemoryBytes = currentKiB * 1024
+		if rssKiB > 0 {
+			d.MemoryBytes = rssKiB * 1024
+		}
+	}
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		line = bytes.TrimSpace(line)
+		if name, ok := bytes.CutPrefix(line, []byte("Domain: ")); ok {
+			finish()
+			rssKiB, currentKiB = 0, 0
+			domains = append(domains, Domain{Name: string(bytes.Trim(name, "'"))})
+			continue
+		}
+		key, value, ok := bytes.Cut(line, []byte("="))
+		if !ok || len(domains) == 0 {
+			continue
+		}
+		n, ok := parseUintBytes(value)
+		if !ok {
+			continue
+		}
+		d := &domains[len(domains)-1]
+		switch string(key) {
+		case "state.state":
+			d.State = int(n)
+		case "cpu.time":
+			d.CPUTimeNs = n
+		case "vcpu.current":
+			d.VCPUs = int(n)
+		case "balloon.rss":
+			rssKiB = n
+		case "balloon.current":
+			currentKiB = n
+		}
+	}
+	finish()
+	return domains
+}
@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDomStats(t *testing.T) {
+	assert.Equal(t, []Domain{
+		{Name: "web1", State: 1, CPUTimeNs: 8311236090, VCPUs: 2, MemoryBytes: 1 << 30},
+		{Name: "db-replica", State: 5, VCPUs: 4, MemoryBytes: 4 << 30},
+		{Name: "win10", State: 1, CPUTimeNs: 120000000000, VCPUs: 4, MemoryBytes: 4 << 30},
+	}, parseDomStats(readTestdata(t, "virsh_domstats")))
+}
+
+func TestLibvirtCollector(t *testing.T) {
+	oldRun := runCommand
+	t.Cleanup(func() { runCommand = oldRun })
+	runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		assert.Equal(t, "virsh", name)
+		assert.Equal(t, []string{"-c", "qemu:///system", "domstats"}, args[:3])
+		return readTestdata(t, "virsh_domstats"), nil
+	}
+
+	lc := NewLibvirtCollector("qemu:///system")
+	metrics, err := lc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{
+		"vm_running_web1":       1,
+		"vm_cpu_percent_web1":   0,
+		"vm_memory_bytes_web1":  1 << 30,
+		"vm_running_db_replica": 0,
+		"vm_running_win10":      1,
+		"vm_cpu_percent_win10":  0,
+		"vm_memory_bytes_win10": 4 << 30,
+	}, metrics, "no CPU use on the first call")
+
+	// web1 used 1s of CPU in 10s on 2 vCPUs
+	lc.lastTime = time.Now().Add(-10 * time.Second)
+	web1 := lc.last["web1"]
+	web1.CPUTimeNs -= 1e9
+	lc.last["web1"] = web1
+	metrics, err = lc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 5, metrics["vm_cpu_percent_web1"], 0.01)
+	assert.Equal(t, 0.0, metrics["vm_cpu_percent_win10"])
+}
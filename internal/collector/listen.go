@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// procNetTCP are the TCP socket tables read by the ListenCollector. The IPv6
+// one is missing on hosts with IPv6 disabled. Replaced in tests.
+var procNetTCP = []string{"/proc/net/tcp", "/proc/net/tcp6"}
+
+// tcpListen is the state of a listening socket in /proc/net/tcp.
+var tcpListen = []byte("0A")
+
+// ListenCollector reports port_listening_<port>: 1 when a TCP socket listens
+// on the port, on any address, else 0. It catches a service whose process
+// still runs but no longer accepts connections.
+type ListenCollector struct {
+	ports []int
+}
+
+// NewListenCollector creates a collector checking the given TCP ports.
+func NewListenCollector(ports []int) *ListenCollector {
+	return &ListenCollector{ports: ports}
+}
+
+func (lc *ListenCollector) Name() string {
+	return "listen"
+}
+
+func (lc *ListenCollector) Collect() (CollectedMetrics, error) {
+	listening := make(map[int]bool)
+	read := 0
+	for _, path := range procNetTCP {
+		bp, err := readProcFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		parseListeningPorts(*bp, listening)
+		releaseProcBuf(bp)
+		read++
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("no TCP socket table found (%s)", procNetTCP[0])
+	}
+
+	metrics := make(CollectedMetrics, len(lc.ports))
+	for _, port := range lc.ports {
+		value := 0.0
+		if listening[port] {
+			value = 1
+		}
+		metrics["port_listening_"+strconv.Itoa(port)] = value
+	}
+	return metrics, nil
+}
+
+// parseListeningPorts adds the ports of the listening sockets of a
+// /proc/net/tcp or /proc/net/tcp6 table to listening.
+func parseListeningPorts(data []byte, listening map[int]bool) {
+	_, data = nextLine(data) // Header
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		// sl local_address rem_address st ...
+		local, rest := nextField(skipFields(line, 1))
+		state, _ := nextField(skipFields(rest, 1))
+		if !bytes.Equal(state, tcpListen) {
+			continue
+		}
+		i := bytes.LastIndexByte(local, ':')
+		if i < 0 {
+			continue
+		}
+		if port, err := strconv.ParseUint(string(local[i+1:]), 16, 16); err == nil {
+			listening[int(port)] = true
+		}
+	}
+}
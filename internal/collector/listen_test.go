@@ -0,0 +1,37 @@
+package collector
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListeningPorts(t *testing.T) {
+	listening := make(map[int]bool)
+	parseListeningPorts(readTestdata(t, "proc_net_tcp"), listening)
+	parseListeningPorts(readTestdata(t, "proc_net_tcp6"), listening)
+	assert.Equal(t, map[int]bool{22: true, 5432: true, 80: true}, listening, "connected sockets are not listening")
+}
+
+func TestListenCollector(t *testing.T) {
+	oldTCP := procNetTCP
+	t.Cleanup(func() { procNetTCP = oldTCP })
+	testdata := filepath.Join("..", "..", "testdata")
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	procNetTCP = []string{filepath.Join(testdata, "proc_net_tcp"), missing}
+	metrics, err := NewListenCollector([]int{22, 80, 5432}).Collect()
+	require.NoError(t, err, "a missing IPv6 table is not an error")
+	assert.Equal(t, CollectedMetrics{"port_listening_22": 1, "port_listening_80": 0, "port_listening_5432": 1}, metrics)
+
+	procNetTCP = []string{filepath.Join(testdata, "proc_net_tcp"), filepath.Join(testdata, "proc_net_tcp6")}
+	metrics, err = NewListenCollector([]int{80, 443}).Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{"port_listening_80": 1, "port_listening_443": 0}, metrics)
+
+	procNetTCP = []string{missing}
+	_, err = NewListenCollector([]int{22}).Collect()
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLVSThinPools(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	require.NoError(t, parseLVSThinPools(readTestdata(t, "lvs_thin_pools.json"), metrics))
+	assert.Equal(t, CollectedMetrics{
+		"lvm_thinpool_data_percent_vg0_pool0":         87.35,
+		"lvm_thinpool_metadata_percent_vg0_pool0":     12.04,
+		"lvm_thinpool_data_percent_vm_store_thin":     4.5,
+		"lvm_thinpool_metadata_percent_vm_store_thin": 1.2,
+	}, metrics, "inactive pools are left out")
+
+	assert.Error(t, parseLVSThinPools([]byte("  No volume groups found\n"), metrics))
+}
+
+func TestLVMCollector(t *testing.T) {
+	oldRun := runCommand
+	t.Cleanup(func() { runCommand = oldRun })
+	runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		assert.Equal(t, "lvs", name)
+		assert.Contains(t, args, "segtype=thin-pool")
+		return readTestdata(t, "lvs_thin_pools.json"), nil
+	}
+	metrics, err := NewLVMCollector().Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 87.35, metrics["lvm_thinpool_data_percent_vg0_pool0"])
+}
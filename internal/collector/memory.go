@@ -1,13 +1,14 @@
 package collector
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
 )
 
+// sysNodeDir holds the per-NUMA-node meminfo files. Replaced in tests.
+var sysNodeDir = "/sys/devices/system/node"
+
 // MemInfo represents data parsed from /proc/meminfo
 type MemInfo struct {
 	MemTotal     uint64 // kB
@@ -15,72 +16,99 @@ type MemInfo struct {
 	MemAvailable uint64 // kB (More useful than MemFree)
 	Buffers      uint64 // kB
 	Cached       uint64 // kB
+	Dirty        uint64 // kB waiting to be written back to disk
 	SwapTotal    uint64 // kB
 	SwapFree     uint64 // kB
+
+	HugePagesTotal uint64 // Pages in the huge page pool
+	HugePagesFree  uint64 // Pages not yet allocated
+	HugePagesRsvd  uint64 // Free pages committed to mappings but not yet faulted in
 }
 
 func parseMemInfo() (*MemInfo, error) {
-	file, err := os.Open("/proc/meminfo")
+	bp, err := readProcFile("/proc/meminfo")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open /proc/meminfo: %w", err)
 	}
-	defer file.Close()
+	defer releaseProcBuf(bp)
 
 	info := &MemInfo{}
-	scanner := bufio.NewScanner(file)
-	requiredFields := map[string]*uint64{
-		"MemTotal:":     &info.MemTotal,
-		"MemFree:":      &info.MemFree,
-		"MemAvailable:": &info.MemAvailable,
-		"Buffers:":      &info.Buffers,
-		"Cached:":       &info.Cached,
-		"SwapTotal:":    &info.SwapTotal,
-		"SwapFree:":     &info.SwapFree,
-	}
-	foundCount := 0
+	parseMemInfoData(*bp, info)
+	// If MemAvailable is missing (older kernels), mem_percent_used falls back to MemFree.
+	return info, nil
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Fields(line)
-		if len(parts) < 2 {
+// parseMemInfoData fills info from /proc/meminfo contents.
+// It stops early once all fields of interest have been seen.
+func parseMemInfoData(data []byte, info *MemInfo) {
+	const wanted = 11
+	found := 0
+	for len(data) > 0 && found < wanted {
+		var line []byte
+		line, data = nextLine(data)
+		key, rest := nextField(line)
+		var ptr *uint64
+		switch string(key) { // No allocation: the conversion is only used for comparison
+		case "MemTotal:":
+			ptr = &info.MemTotal
+		case "MemFree:":
+			ptr = &info.MemFree
+		case "MemAvailable:":
+			ptr = &info.MemAvailable
+		case "Buffers:":
+			ptr = &info.Buffers
+		case "Cached:":
+			ptr = &info.Cached
+		case "Dirty:":
+			ptr = &info.Dirty
+		case "SwapTotal:":
+			ptr = &info.SwapTotal
+		case "SwapFree:":
+			ptr = &info.SwapFree
+		case "HugePages_Total:":
+			ptr = &info.HugePagesTotal
+		case "HugePages_Free:":
+			ptr = &info.HugePagesFree
+		case "HugePages_Rsvd:":
+			ptr = &info.HugePagesRsvd
+		default:
 			continue
 		}
-
-		fieldName := parts[0]
-		if ptr, ok := requiredFields[fieldName]; ok {
-			val, err := strconv.ParseUint(parts[1], 10, 64)
-			if err == nil {
-				*ptr = val
-				foundCount++
-			}
+		value, _ := nextField(rest)
+		if val, ok := parseUintBytes(value); ok {
+			*ptr = val
+			found++
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error scanning /proc/meminfo: %w", err)
-	}
-	if foundCount < len(requiredFields) {
-		// If MemAvailable is missing (older kernels), we might try to calculate it
-		if info.MemAvailable == 0 && info.MemTotal > 0 {
-			// This is a rough estimate, modern kernels provide MemAvailable directly
-			// For simplicity, we'll rely on MemAvailable being present.
-			// If not, mem_percent_used calculation would be less accurate.
-		}
-		// Log a warning or error if not all fields are found, but proceed if essential ones are.
-	}
-
-
-	return info, nil
 }
 
 // CollectMemoryStats gathers memory and swap usage statistics.
 func CollectMemoryStats() (CollectedMetrics, error) {
+	metrics := make(CollectedMetrics, 4)
+	if err := collectMemoryStatsInto(metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// collectMemoryStatsInto is CollectMemoryStats writing into an existing map.
+func collectMemoryStatsInto(metrics CollectedMetrics) error {
 	memInfo, err := parseMemInfo()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	memoryMetrics(memInfo, metrics)
+	return collectNUMANodes(sysNodeDir, metrics)
+}
 
-	metrics := make(CollectedMetrics)
+// memoryMetrics computes the memory, swap and huge page metrics of info.
+func memoryMetrics(memInfo *MemInfo, metrics CollectedMetrics) {
+	// Absolute values, for thresholds like "less than 200 MB available"
+	metrics["mem_total_bytes"] = float64(memInfo.MemTotal) * 1024
+	metrics["mem_available_bytes"] = float64(memInfo.MemAvailable) * 1024
+	metrics["mem_cached_bytes"] = float64(memInfo.Cached) * 1024
+	metrics["mem_dirty_bytes"] = float64(memInfo.Dirty) * 1024
+	metrics["swap_used_bytes"] = float64(memInfo.SwapTotal-min(memInfo.SwapFree, memInfo.SwapTotal)) * 1024
 
 	// Memory
 	if memInfo.MemTotal > 0 {
@@ -111,7 +139,56 @@ func CollectMemoryStats() (CollectedMetrics, error) {
 		metrics["swap_percent_free"] = 0
 	}
 
-	return metrics, nil
+	// Huge pages, only when a pool is configured. Reserved pages are promised
+	// to a mapping, so they can't serve new allocations either.
+	if memInfo.HugePagesTotal > 0 {
+		available := memInfo.HugePagesFree - min(memInfo.HugePagesRsvd, memInfo.HugePagesFree)
+		metrics["hugepages_percent_used"] = (1.0 - float64(available)/float64(memInfo.HugePagesTotal)) * 100.0
+	}
+}
+
+// collectNUMANodes reports the free memory of every NUMA node under dir,
+// as mem_free_bytes_node<N> and mem_percent_free_node<N>. Hosts with a
+// single node, where it equals the global free memory, report nothing.
+func collectNUMANodes(dir string, metrics CollectedMetrics) error {
+	nodes, err := filepath.Glob(filepath.Join(dir, "node[0-9]*"))
+	if err != nil || len(nodes) < 2 {
+		return err
+	}
+	for _, node := range nodes {
+		bp, err := readProcFile(filepath.Join(node, "meminfo"))
+		if err != nil {
+			continue // Node went offline
+		}
+		total, free := parseNodeMemInfo(*bp)
+		releaseProcBuf(bp)
+		if total == 0 {
+			continue
+		}
+		id := strings.TrimPrefix(filepath.Base(node), "node")
+		metrics["mem_free_bytes_node"+id] = float64(free) * 1024
+		metrics["mem_percent_free_node"+id] = float64(free) / float64(total) * 100.0
+	}
+	return nil
+}
+
+// parseNodeMemInfo returns MemTotal and MemFree, in kB, of a node's meminfo,
+// whose lines look like "Node 0 MemFree:  123456 kB".
+func parseNodeMemInfo(data []byte) (total, free uint64) {
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		key, rest := nextField(skipFields(line, 2))
+		value, _ := nextField(rest)
+		switch string(key) {
+		case "MemTotal:":
+			total, _ = parseUintBytes(value)
+		case "MemFree:":
+			free, _ = parseUintBytes(value)
+			return total, free
+		}
+	}
+	return total, free
 }
 
 
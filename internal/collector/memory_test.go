@@ -0,0 +1,51 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryMetrics(t *testing.T) {
+	var info MemInfo
+	parseMemInfoData(readTestdata(t, "proc_meminfo"), &info)
+	metrics := make(CollectedMetrics)
+	memoryMetrics(&info, metrics)
+	assert.InDelta(t, 25.0, metrics["mem_percent_used"], 0.001)
+	assert.InDelta(t, 50.0, metrics["swap_percent_used"], 0.001)
+	assert.InDelta(t, 87.5, metrics["hugepages_percent_used"], 0.001) // 128 free, 64 of them reserved
+	assert.Equal(t, 8192000.0*1024, metrics["mem_total_bytes"])
+	assert.Equal(t, 6144000.0*1024, metrics["mem_available_bytes"])
+	assert.Equal(t, 2048000.0*1024, metrics["mem_cached_bytes"])
+	assert.Equal(t, 64000.0*1024, metrics["mem_dirty_bytes"])
+	assert.Equal(t, 1024000.0*1024, metrics["swap_used_bytes"])
+
+	clear(metrics)
+	memoryMetrics(&MemInfo{MemTotal: 1000, MemAvailable: 500}, metrics)
+	assert.NotContains(t, metrics, "hugepages_percent_used")
+}
+
+func TestCollectNUMANodes(t *testing.T) {
+	dir := t.TempDir()
+	writeNode := func(node, meminfo string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, node), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, node, "meminfo"), []byte(meminfo), 0644))
+	}
+	writeNode("node0", "Node 0 MemTotal:       16000000 kB\nNode 0 MemFree:         4000000 kB\nNode 0 MemUsed:        12000000 kB\n")
+
+	metrics := make(CollectedMetrics)
+	require.NoError(t, collectNUMANodes(dir, metrics))
+	assert.Empty(t, metrics, "a single node is the whole host")
+
+	writeNode("node1", "Node 1 MemTotal:       16000000 kB\nNode 1 MemFree:          160000 kB\n")
+	require.NoError(t, collectNUMANodes(dir, metrics))
+	assert.Equal(t, CollectedMetrics{
+		"mem_free_bytes_node0":   4000000 * 1024,
+		"mem_percent_free_node0": 25,
+		"mem_free_bytes_node1":   160000 * 1024,
+		"mem_percent_free_node1": 1,
+	}, metrics)
+}
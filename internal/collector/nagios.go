@@ -0,0 +1,274 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// Nagios plugin states, as reported by the exit code of a check.
+const (
+	NagiosOK       = 0
+	NagiosWarning  = 1
+	NagiosCritical = 2
+	NagiosUnknown  = 3
+)
+
+// NagiosCheck is a Nagios-style check plugin run by the NagiosCollector.
+type NagiosCheck struct {
+	Name     string
+	Command  []string      // Plugin and arguments, run without a shell
+	Timeout  time.Duration // A run exceeding this is killed and reported UNKNOWN
+	Interval time.Duration // Time between runs; zero runs the check every cycle
+}
+
+// nagiosState is the last result of a check, reused until it is due again.
+type nagiosState struct {
+	lastRun time.Time
+	metrics CollectedMetrics
+}
+
+// NagiosCollector runs Nagios/NRPE check plugins and turns their exit codes
+// and performance data into metrics, so existing check scripts can drive
+// monres alerts:
+//
+//	check_<name>_status    0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN
+//	check_<name>_<label>   one metric per perfdata label, in base units
+type NagiosCollector struct {
+	checks []NagiosCheck
+	state  map[string]*nagiosState // check name -> last result
+	mu     sync.Mutex
+}
+
+// NewNagiosCollector creates a collector running the given checks.
+func NewNagiosCollector(checks []NagiosCheck) *NagiosCollector {
+	for _, check := range checks {
+		name := nagiosMetricName(check.Name, "status")
+		if _, known := metricsmeta.Lookup(name); !known {
+			metricsmeta.Register(metricsmeta.Metadata{
+				Name:        name,
+				Type:        metricsmeta.TypeGauge,
+				Description: fmt.Sprintf("State of the %s check (0 OK, 1 WARNING, 2 CRITICAL, 3 UNKNOWN)", check.Name),
+			})
+		}
+	}
+	return &NagiosCollector{
+		checks: checks,
+		state:  make(map[string]*nagiosState),
+	}
+}
+
+func (nc *NagiosCollector) Name() string {
+	return "nagios"
+}
+
+// Collect runs the checks that are due concurrently and returns the latest
+// metrics of every check. A failing plugin is reported as UNKNOWN rather
+// than failing the collector.
+func (nc *NagiosCollector) Collect() (CollectedMetrics, error) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	now := time.Now()
+	var wg sync.WaitGroup
+	results := make([]CollectedMetrics, len(nc.checks))
+	for i, check := range nc.checks {
+		st := nc.state[check.Name]
+		if st != nil && check.Interval > 0 && now.Sub(st.lastRun) < check.Interval {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runNagiosCheck(check)
+		}()
+	}
+	wg.Wait()
+
+	metrics := make(CollectedMetrics)
+	for i, check := range nc.checks {
+		if results[i] != nil {
+			nc.state[check.Name] = &nagiosState{lastRun: now, metrics: results[i]}
+		}
+		for k, v := range nc.state[check.Name].metrics {
+			metrics[k] = v
+		}
+	}
+	return metrics, nil
+}
+
+// runNagiosCheck runs a plugin and converts its result to metrics.
+func runNagiosCheck(check NagiosCheck) CollectedMetrics {
+	ctx, cancel := context.WithTimeout(context.Background(), check.Timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, check.Command[0], check.Command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.WaitDelay = time.Second // Don't wait for children of a killed plugin holding stdout open
+	err := cmd.Run()
+
+	status := NagiosOK
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		log.Printf("Warning: nagios check '%s' timed out after %s.", check.Name, check.Timeout)
+		status = NagiosUnknown
+	case errors.As(err, &exitErr):
+		status = exitErr.ExitCode()
+		if status < NagiosOK || status > NagiosUnknown {
+			status = NagiosUnknown
+		}
+	case err != nil:
+		log.Printf("Warning: nagios check '%s' could not be run: %v", check.Name, err)
+		status = NagiosUnknown
+	}
+
+	metrics := make(CollectedMetrics)
+	if ctx.Err() == nil {
+		for _, pd := range parseNagiosOutput(stdout.String()) {
+			name := nagiosMetricName(check.Name, pd.label)
+			metrics[name] = pd.value
+			if _, known := metricsmeta.Lookup(name); !known && (pd.unit != metricsmeta.UnitNone || pd.counter) {
+				md := metricsmeta.Metadata{Name: name, Unit: pd.unit, Type: metricsmeta.TypeGauge}
+				if pd.counter {
+					md.Type = metricsmeta.TypeCounter
+				}
+				metricsmeta.Register(md)
+			}
+		}
+	}
+	metrics[nagiosMetricName(check.Name, "status")] = float64(status)
+	return metrics
+}
+
+func nagiosMetricName(check, label string) string {
+	return "check_" + sanitizeMetricName(check) + "_" + sanitizeMetricName(label)
+}
+
+// perfdata is a single value of a plugin's performance data.
+type perfdata struct {
+	label   string
+	value   float64 // Converted to the base unit
+	unit    metricsmeta.Unit
+	counter bool
+}
+
+// parseNagiosOutput extracts the performance data from plugin output:
+//
+//	TEXT OUTPUT | perfdata
+//	LONG TEXT LINE 1
+//	LONG TEXT LINE 2 | perfdata
+//	perfdata
+//
+// Items that cannot be parsed or whose value is undetermined ("U") are skipped.
+func parseNagiosOutput(output string) []perfdata {
+	var raw []string
+	lines := strings.Split(output, "\n")
+	if _, pd, ok := strings.Cut(lines[0], "|"); ok {
+		raw = append(raw, pd)
+	}
+	for i, line := range lines[1:] {
+		if _, pd, ok := strings.Cut(line, "|"); ok {
+			raw = append(raw, pd)
+			raw = append(raw, lines[i+2:]...) // Everything after the second "|" is perfdata
+			break
+		}
+	}
+
+	var result []perfdata
+	for _, item := range splitPerfdata(strings.Join(raw, " ")) {
+		pd, ok := parsePerfdataItem(item)
+		if ok {
+			result = append(result, pd)
+		}
+	}
+	return result
+}
+
+// splitPerfdata splits perfdata into its space separated items. Labels may
+// be quoted with single quotes to contain spaces; a doubled quote is a literal one.
+func splitPerfdata(s string) []string {
+	var items []string
+	var cur strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'':
+			if quoted && i+1 < len(s) && s[i+1] == '\'' {
+				cur.WriteByte(c)
+				i++
+			} else {
+				quoted = !quoted
+			}
+		case (c == ' ' || c == '\t' || c == '\r') && !quoted:
+			if cur.Len() > 0 {
+				items = append(items, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if cur.Len() > 0 {
+		items = append(items, cur.String())
+	}
+	return items
+}
+
+// parsePerfdataItem parses label=value[UOM];[warn];[crit];[min];[max].
+// Only the value is kept; thresholds are left to monres alert rules.
+func parsePerfdataItem(item string) (perfdata, bool) {
+	label, rest, ok := strings.Cut(item, "=")
+	if !ok || label == "" {
+		return perfdata{}, false
+	}
+	valueStr, _, _ := strings.Cut(rest, ";")
+	end := strings.LastIndexAny(valueStr, "0123456789.") + 1
+	if end == 0 {
+		return perfdata{}, false // Empty or "U"
+	}
+	value, err := strconv.ParseFloat(valueStr[:end], 64)
+	if err != nil {
+		return perfdata{}, false
+	}
+
+	pd := perfdata{label: label}
+	switch uom := valueStr[end:]; uom {
+	case "":
+	case "%":
+		pd.unit = metricsmeta.UnitPercent
+	case "s":
+		pd.unit = metricsmeta.UnitSeconds
+	case "ms":
+		pd.unit, value = metricsmeta.UnitSeconds, value/1e3
+	case "us":
+		pd.unit, value = metricsmeta.UnitSeconds, value/1e6
+	case "B":
+		pd.unit = metricsmeta.UnitBytes
+	case "KB":
+		pd.unit, value = metricsmeta.UnitBytes, value*(1<<10)
+	case "MB":
+		pd.unit, value = metricsmeta.UnitBytes, value*(1<<20)
+	case "GB":
+		pd.unit, value = metricsmeta.UnitBytes, value*(1<<30)
+	case "TB":
+		pd.unit, value = metricsmeta.UnitBytes, value*(1<<40)
+	case "c":
+		pd.counter = true
+	default:
+		return perfdata{}, false
+	}
+	pd.value = value
+	return pd, true
+}
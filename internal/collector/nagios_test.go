@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+func TestParseNagiosOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []perfdata
+	}{
+		{"no_perfdata", "OK - all good\n", nil},
+		{"simple", "LOAD OK - load average: 0.1 | load1=0.1;5;10;0 load5=0.2;;;0\n", []perfdata{
+			{label: "load1", value: 0.1},
+			{label: "load5", value: 0.2},
+		}},
+		{"units", "OK | time=250ms;;; size=2KB used=50% requests=12c\n", []perfdata{
+			{label: "time", value: 0.25, unit: metricsmeta.UnitSeconds},
+			{label: "size", value: 2048, unit: metricsmeta.UnitBytes},
+			{label: "used", value: 50, unit: metricsmeta.UnitPercent},
+			{label: "requests", value: 12, counter: true},
+		}},
+		{"quoted_label", "OK | '/var lib'=3;; 'it''s'=1\n", []perfdata{
+			{label: "/var lib", value: 3},
+			{label: "it's", value: 1},
+		}},
+		{"undetermined_and_invalid", "OK | a=U b=1parsec c 'd'=4\n", []perfdata{
+			{label: "d", value: 4},
+		}},
+		{"long_output", "DISK OK | root=10%\nfirst line\nsecond line | home=20%\nvar=30%\n", []perfdata{
+			{label: "root", value: 10, unit: metricsmeta.UnitPercent},
+			{label: "home", value: 20, unit: metricsmeta.UnitPercent},
+			{label: "var", value: 30, unit: metricsmeta.UnitPercent},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseNagiosOutput(tt.output))
+		})
+	}
+}
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func TestNagiosCollector(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "runs")
+	checks := []NagiosCheck{
+		{Name: "ok", Command: []string{writePlugin(t, dir, "ok", "echo 'OK | rtt=5ms'\n")}, Timeout: 5 * time.Second},
+		{Name: "disk-root", Command: []string{writePlugin(t, dir, "warn", "echo 'WARNING | used=85%'\nexit 1\n")}, Timeout: 5 * time.Second},
+		{Name: "odd", Command: []string{writePlugin(t, dir, "odd", "exit 42\n")}, Timeout: 5 * time.Second},
+		{Name: "missing", Command: []string{filepath.Join(dir, "does-not-exist")}, Timeout: 5 * time.Second},
+		{Name: "slow", Command: []string{writePlugin(t, dir, "slow", "exec sleep 5\n")}, Timeout: 50 * time.Millisecond},
+		{Name: "cached", Command: []string{writePlugin(t, dir, "cached", "echo x >> "+counter+"\n")}, Timeout: 5 * time.Second, Interval: time.Hour},
+	}
+
+	nc := NewNagiosCollector(checks)
+	assert.Equal(t, "nagios", nc.Name())
+
+	metrics, err := nc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, metrics["check_ok_status"])
+	assert.Equal(t, 0.005, metrics["check_ok_rtt"])
+	assert.Equal(t, 1.0, metrics["check_disk_root_status"])
+	assert.Equal(t, 85.0, metrics["check_disk_root_used"])
+	assert.Equal(t, 3.0, metrics["check_odd_status"])
+	assert.Equal(t, 3.0, metrics["check_missing_status"])
+	assert.Equal(t, 3.0, metrics["check_slow_status"])
+	assert.Equal(t, 0.0, metrics["check_cached_status"])
+
+	md, ok := metricsmeta.Lookup("check_disk_root_used")
+	require.True(t, ok)
+	assert.Equal(t, metricsmeta.UnitPercent, md.Unit)
+
+	// The cached check is not run again within its interval, but still reported
+	metrics, err = nc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, metrics["check_cached_status"])
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "x\n", string(runs))
+}
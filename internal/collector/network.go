@@ -1,11 +1,9 @@
 package collector
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
 	"math"
-	"os"
-	"strconv"
 	"strings"
 )
 
@@ -50,63 +48,62 @@ func isRelevantInterface(ifaceName string, filter NetworkInterfaceFilter) bool {
 // GetNetworkStats reads /proc/net/dev and aggregates received/transmitted bytes.
 // It uses the provided filter to exclude certain interfaces.
 func GetNetworkStats(filter NetworkInterfaceFilter) (*NetworkStats, error) {
-	file, err := os.Open("/proc/net/dev")
-	if err != nil {
-		return nil, fmt.Errorf("failed to open /proc/net/dev: %w", err)
+	stats := &NetworkStats{}
+	if err := readNetworkStats(filter, stats); err != nil {
+		return nil, err
 	}
-	defer file.Close()
+	return stats, nil
+}
 
-	stats := &NetworkStats{}
-	scanner := bufio.NewScanner(file)
+func readNetworkStats(filter NetworkInterfaceFilter, stats *NetworkStats) error {
+	bp, err := readProcFile("/proc/net/dev")
+	if err != nil {
+		return fmt.Errorf("failed to open /proc/net/dev: %w", err)
+	}
+	defer releaseProcBuf(bp)
+	return parseNetDevData(*bp, filter, stats)
+}
 
+// parseNetDevData aggregates byte counters from /proc/net/dev contents into stats.
+func parseNetDevData(data []byte, filter NetworkInterfaceFilter, stats *NetworkStats) error {
 	// Skip header lines
 	for i := 0; i < 2; i++ {
-		if !scanner.Scan() {
-			if err := scanner.Err(); err != nil {
-				return nil, fmt.Errorf("error reading header from /proc/net/dev: %w", err)
-			}
-			return nil, fmt.Errorf("unexpected EOF reading /proc/net/dev header")
+		if len(data) == 0 {
+			return fmt.Errorf("unexpected EOF reading /proc/net/dev header")
 		}
+		_, data = nextLine(data)
 	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Fields(strings.ReplaceAll(line, ":", " ")) // Replace colon for easier field split
-		if len(fields) < 10 { // Interface name, RecvBytes, RecvPackets, ..., SentBytes, SentPackets, ...
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		// The interface name is terminated by a colon that may not be followed by a space (e.g. "eth0:123")
+		colon := bytes.IndexByte(line, ':')
+		if colon < 0 {
 			continue
 		}
-
-		ifaceName := fields[0]
-		if !isRelevantInterface(ifaceName, filter) {
+		ifaceName, _ := nextField(line[:colon])
+		if !isRelevantInterface(string(ifaceName), filter) {
 			continue
 		}
 
-		// Received bytes is the 1st field after name (index 1 if name is 0)
-		recvBytes, err := strconv.ParseUint(fields[1], 10, 64)
-		if err != nil {
-			// log.Printf("Warning: could not parse recv_bytes for %s: %v", ifaceName, err)
+		// fields after the colon: bytes packets errs drop fifo frame compressed multicast | bytes packets ...
+		// Received bytes is the 1st field, transmitted bytes the 9th.
+		field, rest := nextField(line[colon+1:])
+		recvBytes, ok := parseUintBytes(field)
+		if !ok {
 			continue
 		}
-		// Transmitted bytes is the 8th field after name (index 9 if name is 0, but after split it's index 8 after name)
-		// fields: face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
-		//         0     1        2       3    4    5    6     7          8         9       10
-		// After splitting by space and ':', fields are:
-		// <iface_name> <recv_bytes> <recv_packets> ... <sent_bytes> ...
-		// So, if fields[0] is iface_name, fields[1] is recv_bytes, fields[9] is sent_bytes
-		sentBytes, err := strconv.ParseUint(fields[9], 10, 64) // Index 9 after splitting with multiple spaces
-		if err != nil {
-			// log.Printf("Warning: could not parse sent_bytes for %s: %v", ifaceName, err)
+		field, _ = nextField(skipFields(rest, 7))
+		sentBytes, ok := parseUintBytes(field)
+		if !ok {
 			continue
 		}
 
 		stats.TotalRecvBytes += recvBytes
 		stats.TotalSentBytes += sentBytes
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error scanning /proc/net/dev: %w", err)
-	}
-	return stats, nil
+	return nil
 }
 
 // CalculateNetworkIORates computes received/sent bytes per second.
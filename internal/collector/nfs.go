@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"bytes"
+	"fmt"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// procMountStats holds the RPC statistics of the NFS mounts. Replaced in tests.
+var procMountStats = "/proc/self/mountstats"
+
+// NFSStats are the RPC counters of an NFS mount, summed over operations.
+// The kernel counts a request once it completes.
+type NFSStats struct {
+	Ops           uint64 // Completed requests
+	Transmissions uint64 // Times those requests were sent, retransmissions included
+	RTTMillis     uint64 // Total round-trip time of those requests
+}
+
+// parseMountStats parses /proc/self/mountstats, returning the statistics of
+// the NFS mounts by mount point.
+func parseMountStats(data []byte) map[string]NFSStats {
+	stats := make(map[string]NFSStats)
+	var path string // Mount point of the current NFS mount, "" outside of one
+	inOps := false
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		if bytes.HasPrefix(line, []byte("device ")) {
+			// device nas:/export mounted on /mnt/backups with fstype nfs4 statvers=1.1
+			rest := skipFields(line, 4)
+			mount, rest := nextField(rest)
+			fstype, _ := nextField(skipFields(rest, 2))
+			path, inOps = "", false
+			if bytes.HasPrefix(fstype, []byte("nfs")) {
+				path = unescapeMountField(mount)
+				stats[path] = NFSStats{}
+			}
+			continue
+		}
+		if path == "" {
+			continue
+		}
+		field, rest := nextField(line)
+		if !inOps {
+			inOps = bytes.Equal(field, []byte("per-op")) // per-op statistics
+			continue
+		}
+		// READ: ops transmissions major_timeouts bytes_sent bytes_recv queue_ms rtt_ms execute_ms ...
+		if !bytes.HasSuffix(field, []byte(":")) {
+			continue
+		}
+		ops, rest := nextField(rest)
+		trans, rest := nextField(rest)
+		rtt, _ := nextField(skipFields(rest, 4))
+		o, ok1 := parseUintBytes(ops)
+		t, ok2 := parseUintBytes(trans)
+		r, ok3 := parseUintBytes(rtt)
+		if !ok1 || !ok2 || !ok3 {
+			continue
+		}
+		s := stats[path]
+		s.Ops += o
+		s.Transmissions += t
+		s.RTTMillis += r
+		stats[path] = s
+	}
+	return stats
+}
+
+// collectNFS reports, for every NFS mount, the retransmissions per second
+// (nfs_<mount>_retrans_ps) and the average round-trip time of the requests
+// completed since the previous sample (nfs_<mount>_rtt_ms, 0 without any).
+// Both rise when the server stops answering promptly.
+func (gc *GlobalCollector) collectNFS(metrics CollectedMetrics) error {
+	gc.nfs.mu.Lock()
+	defer gc.nfs.mu.Unlock()
+
+	bp, err := readProcFile(procMountStats)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", procMountStats, err)
+	}
+	current := parseMountStats(*bp)
+	releaseProcBuf(bp)
+
+	now := gc.now()
+	elapsedSeconds := gc.nfs.elapsed(now)
+	for path, cur := range current {
+		retrans, rtt := 0.0, 0.0
+		last, ok := gc.nfs.last[path]
+		if ok && elapsedSeconds > 0.1 && cur.Ops >= last.Ops && cur.Transmissions >= last.Transmissions && cur.RTTMillis >= last.RTTMillis {
+			ops, trans := cur.Ops-last.Ops, cur.Transmissions-last.Transmissions
+			if trans > ops {
+				retrans = float64(trans-ops) / elapsedSeconds
+			}
+			if ops > 0 {
+				rtt = float64(cur.RTTMillis-last.RTTMillis) / float64(ops)
+			}
+		}
+		prefix := "nfs_" + MountMetricName(path)
+		metrics[prefix+"_retrans_ps"] = retrans
+		metrics[prefix+"_rtt_ms"] = rtt
+		registerNFSMetrics(prefix, path)
+	}
+	gc.nfs.store(current, now)
+	return nil
+}
+
+// registerNFSMetrics registers the metadata of the metrics of an NFS mount
+// the first time it is seen.
+func registerNFSMetrics(prefix, path string) {
+	if _, known := metricsmeta.Lookup(prefix + "_rtt_ms"); known {
+		return
+	}
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_retrans_ps",
+		Type:        metricsmeta.TypeRate,
+		Description: "NFS retransmissions per second on " + path,
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_rtt_ms",
+		Type:        metricsmeta.TypeGauge,
+		Description: "Average NFS round-trip time in milliseconds on " + path,
+	})
+}
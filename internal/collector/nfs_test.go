@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMountStats(t *testing.T) {
+	stats := parseMountStats(readTestdata(t, "proc_mountstats"))
+	assert.Equal(t, map[string]NFSStats{
+		"/mnt/My Backups": {Ops: 130, Transmissions: 135, RTTMillis: 610},
+		"/srv":            {Ops: 5, Transmissions: 5, RTTMillis: 10},
+	}, stats)
+}
+
+func TestCollectNFS(t *testing.T) {
+	oldMountStats := procMountStats
+	procMountStats = filepath.Join(t.TempDir(), "mountstats")
+	t.Cleanup(func() { procMountStats = oldMountStats })
+	write := func(ops, trans, rtt int) {
+		require.NoError(t, os.WriteFile(procMountStats, []byte(fmt.Sprintf(`device nas:/export mounted on /mnt/backups with fstype nfs4 statvers=1.1
+	per-op statistics
+	        READ: %d %d 0 0 0 0 %d 0 0
+`, ops, trans, rtt)), 0644))
+	}
+
+	gc := NewGlobalCollector(nil)
+	now := time.Now()
+	gc.now = func() time.Time { return now }
+	metrics := make(CollectedMetrics)
+	write(100, 100, 200)
+	require.NoError(t, gc.collectNFS(metrics))
+	assert.Equal(t, CollectedMetrics{"nfs_mnt_backups_retrans_ps": 0, "nfs_mnt_backups_rtt_ms": 0}, metrics, "no rates on the first cycle")
+
+	write(110, 130, 1200)
+	now = now.Add(10 * time.Second)
+	require.NoError(t, gc.collectNFS(metrics))
+	assert.Equal(t, CollectedMetrics{"nfs_mnt_backups_retrans_ps": 2, "nfs_mnt_backups_rtt_ms": 100}, metrics)
+
+	md, ok := metricsmeta.Lookup("nfs_mnt_backups_retrans_ps")
+	require.True(t, ok)
+	assert.Equal(t, metricsmeta.TypeRate, md.Type)
+
+	procMountStats = filepath.Join(t.TempDir(), "missing")
+	assert.Error(t, gc.collectNFS(metrics))
+}
@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// WatchedProcess is a process, or group of processes, watched by the
+// ProcessCollector. It is found either by Pattern or by PIDFile.
+type WatchedProcess struct {
+	Name    string         // Used in the metric names, process_<name>_<metric>
+	Pattern *regexp.Regexp // Matched against the name and the command line of every process
+	PIDFile string         // File holding the PID of the process, e.g. /run/nginx.pid
+}
+
+// ProcessCollector reports for every watched process:
+//
+//	process_<name>_running      1 if a matching process runs, else 0
+//	process_<name>_count        number of matching processes
+//	process_<name>_cpu_percent  CPU use of the matching processes, of one CPU like top
+//	process_<name>_rss_bytes    resident memory of the matching processes
+//
+// A missing or stale PID file means the process isn't running. CPU use is
+// measured against the previous call; the first call reports 0.
+type ProcessCollector struct {
+	processes []WatchedProcess
+	self      int // PID of monres, whose command line may hold a pattern
+	last      map[int]procStat
+	lastTime  time.Time
+	mu        sync.Mutex
+}
+
+// NewProcessCollector creates a collector for the given processes.
+func NewProcessCollector(processes []WatchedProcess) *ProcessCollector {
+	for _, p := range processes {
+		prefix := "process_" + p.Name
+		for _, md := range []metricsmeta.Metadata{
+			{Name: prefix + "_running", Type: metricsmeta.TypeGauge, Description: fmt.Sprintf("1 if a %s process runs, else 0", p.Name)},
+			{Name: prefix + "_count", Type: metricsmeta.TypeGauge, Description: fmt.Sprintf("Number of %s processes", p.Name)},
+			{Name: prefix + "_cpu_percent", Unit: metricsmeta.UnitPercent, Type: metricsmeta.TypeGauge, Description: fmt.Sprintf("CPU use of the %s processes, of one CPU", p.Name)},
+			{Name: prefix + "_rss_bytes", Unit: metricsmeta.UnitBytes, Type: metricsmeta.TypeGauge, Description: fmt.Sprintf("Resident memory of the %s processes", p.Name)},
+		} {
+			if _, known := metricsmeta.Lookup(md.Name); !known {
+				metricsmeta.Register(md)
+			}
+		}
+	}
+	return &ProcessCollector{processes: processes, self: os.Getpid()}
+}
+
+func (pc *ProcessCollector) Name() string {
+	return "process"
+}
+
+// Collect reads every process and returns the metrics of the watched ones.
+func (pc *ProcessCollector) Collect() (CollectedMetrics, error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	current, err := readProcStats(procDir)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	elapsed := now.Sub(pc.lastTime).Seconds()
+	pageSize := uint64(os.Getpagesize())
+	var cmdlines map[int]string // Read once, only if a process has a pattern
+
+	metrics := make(CollectedMetrics, 4*len(pc.processes))
+	for _, p := range pc.processes {
+		var pids []int
+		if p.Pattern != nil {
+			if cmdlines == nil {
+				cmdlines = readCmdlines(procDir, current)
+			}
+			for pid, st := range current {
+				if pid != pc.self && (p.Pattern.MatchString(st.name) || p.Pattern.MatchString(cmdlines[pid])) {
+					pids = append(pids, pid)
+				}
+			}
+		} else if pid, ok := readPIDFile(p.PIDFile); ok {
+			if _, running := current[pid]; running {
+				pids = append(pids, pid)
+			}
+		}
+
+		var ticks, rss uint64
+		for _, pid := range pids {
+			st := current[pid]
+			rss += st.rssPages * pageSize
+			prev, ok := pc.last[pid]
+			switch {
+			case pc.last == nil: // No CPU use on the first call
+			case !ok || st.cpuTicks < prev.cpuTicks:
+				ticks += st.cpuTicks // Started since the previous call
+			default:
+				ticks += st.cpuTicks - prev.cpuTicks
+			}
+		}
+		percent := 0.0
+		if pc.last != nil && elapsed > 0.1 {
+			percent = float64(ticks) / userHZ / elapsed * 100
+		}
+		prefix := "process_" + p.Name
+		metrics[prefix+"_running"] = boolValue(len(pids) > 0)
+		metrics[prefix+"_count"] = float64(len(pids))
+		metrics[prefix+"_cpu_percent"] = percent
+		metrics[prefix+"_rss_bytes"] = float64(rss)
+	}
+	pc.last, pc.lastTime = current, now
+	return metrics, nil
+}
+
+// readCmdlines returns the command line of every process, its arguments
+// separated by spaces. Kernel threads have none.
+func readCmdlines(procRoot string, stats map[int]procStat) map[int]string {
+	cmdlines := make(map[int]string, len(stats))
+	for pid := range stats {
+		data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+		if err != nil {
+			continue // Exited
+		}
+		cmdlines[pid] = string(bytes.TrimRight(bytes.ReplaceAll(data, []byte{0}, []byte{' '}), " "))
+	}
+	return cmdlines
+}
+
+// readPIDFile returns the PID in a PID file, or false if the file is missing
+// or holds no PID.
+func readPIDFile(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil && pid > 0
+}
@@ -0,0 +1,61 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessCollector(t *testing.T) {
+	oldDir := procDir
+	procDir = t.TempDir()
+	t.Cleanup(func() { procDir = oldDir })
+	writeProc := func(pid int, name, cmdline string, ticks, rss int) {
+		dir := filepath.Join(procDir, fmt.Sprint(pid))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		line := fmt.Sprintf("%d (%s) S 1 1 1 0 -1 0 0 0 0 0 %d 0 0 0 20 0 1 0 100 1000 %d 0\n", pid, name, ticks, rss)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(line), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644))
+	}
+	page := float64(os.Getpagesize())
+
+	writeProc(100, "postgres", "/usr/lib/postgresql/16/bin/postgres\x00-D\x00/var/lib/postgresql\x00", 1000, 300)
+	writeProc(101, "postgres", "postgres: checkpointer \x00", 100, 50)
+	writeProc(200, "nginx", "nginx: master process /usr/sbin/nginx\x00", 10, 10)
+	pidFile := filepath.Join(t.TempDir(), "nginx.pid")
+	require.NoError(t, os.WriteFile(pidFile, []byte("200\n"), 0644))
+
+	pc := NewProcessCollector([]WatchedProcess{
+		{Name: "postgres", Pattern: regexp.MustCompile(`bin/postgres|^postgres: `)},
+		{Name: "nginx", PIDFile: pidFile},
+		{Name: "redis", Pattern: regexp.MustCompile("redis-server")},
+	})
+	metrics, err := pc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{
+		"process_postgres_running": 1, "process_postgres_count": 2, "process_postgres_cpu_percent": 0, "process_postgres_rss_bytes": 350 * page,
+		"process_nginx_running": 1, "process_nginx_count": 1, "process_nginx_cpu_percent": 0, "process_nginx_rss_bytes": 10 * page,
+		"process_redis_running": 0, "process_redis_count": 0, "process_redis_cpu_percent": 0, "process_redis_rss_bytes": 0,
+	}, metrics, "no CPU use on the first call")
+
+	// 200 ticks (2s) of postgres in 10s, and nginx stopped leaving a stale PID file
+	pc.lastTime = time.Now().Add(-10 * time.Second)
+	writeProc(100, "postgres", "/usr/lib/postgresql/16/bin/postgres\x00", 1150, 300)
+	writeProc(101, "postgres", "postgres: checkpointer \x00", 150, 50)
+	require.NoError(t, os.RemoveAll(filepath.Join(procDir, "200")))
+	metrics, err = pc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 20, metrics["process_postgres_cpu_percent"], 0.01)
+	assert.Equal(t, 0.0, metrics["process_nginx_running"])
+
+	require.NoError(t, os.Remove(pidFile))
+	metrics, err = pc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, metrics["process_nginx_count"], "missing PID file")
+}
@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Orders of TopProcesses.
+const (
+	ProcessesByCPU    = "cpu"
+	ProcessesByMemory = "memory"
+)
+
+// userHZ is the unit of the CPU times in /proc/<pid>/stat (USER_HZ), which
+// is 100 on every Linux architecture.
+const userHZ = 100
+
+// Process is a running process, as listed by TopProcesses.
+type Process struct {
+	PID        int
+	Name       string
+	CPUPercent float64 // Of one CPU over the sample, like top; may exceed 100 on several CPUs
+	RSSBytes   uint64
+}
+
+// procStat is the part of /proc/<pid>/stat TopProcesses needs, and the
+// owner of the process.
+type procStat struct {
+	name     string
+	cpuTicks uint64 // utime + stime
+	rssPages uint64
+	uid      uint32 // Effective UID, the owner of the /proc/<pid> directory
+}
+
+// TopProcesses returns the n processes using the most CPU or memory (by is
+// ProcessesByCPU or ProcessesByMemory). CPU usage is measured over sample,
+// during which it blocks.
+func TopProcesses(n int, by string, sample time.Duration) ([]Process, error) {
+	return topProcesses("/proc", n, by, sample)
+}
+
+func topProcesses(procRoot string, n int, by string, sample time.Duration) ([]Process, error) {
+	before, err := readProcStats(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	if by != ProcessesByCPU {
+		return rankProcesses(nil, before, 0, n, by), nil
+	}
+	time.Sleep(sample)
+	after, err := readProcStats(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	return rankProcesses(before, after, sample, n, by), nil
+}
+
+// rankProcesses returns the n processes of after using the most CPU or
+// memory. CPU usage is measured against before, taken elapsed earlier; it is
+// 0 for processes started since and when before is nil.
+func rankProcesses(before, after map[int]procStat, elapsed time.Duration, n int, by string) []Process {
+	pageSize := uint64(os.Getpagesize())
+	procs := make([]Process, 0, len(after))
+	for pid, st := range after {
+		p := Process{PID: pid, Name: st.name, RSSBytes: st.rssPages * pageSize}
+		if prev, ok := before[pid]; ok && elapsed > 0 && st.cpuTicks >= prev.cpuTicks {
+			p.CPUPercent = float64(st.cpuTicks-prev.cpuTicks) / userHZ / elapsed.Seconds() * 100
+		}
+		procs = append(procs, p)
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		if by == ProcessesByCPU && procs[i].CPUPercent != procs[j].CPUPercent {
+			return procs[i].CPUPercent > procs[j].CPUPercent
+		}
+		if procs[i].RSSBytes != procs[j].RSSBytes {
+			return procs[i].RSSBytes > procs[j].RSSBytes
+		}
+		return procs[i].PID < procs[j].PID
+	})
+	if len(procs) > n {
+		procs = procs[:n]
+	}
+	return procs
+}
+
+// readProcStats reads the stat file of every process. Processes exiting
+// while they are read are skipped.
+func readProcStats(procRoot string) (map[int]procStat, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	stats := make(map[int]procStat, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue // Not a process directory
+		}
+		bp, err := readProcFile(filepath.Join(procRoot, e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		if st, ok := parseProcStat(*bp); ok {
+			if info, err := e.Info(); err == nil {
+				if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+					st.uid = sys.Uid
+				}
+			}
+			stats[pid] = st
+		}
+		releaseProcBuf(bp)
+	}
+	return stats, nil
+}
+
+// parseProcStat parses a /proc/<pid>/stat line:
+//
+//	1234 (nginx: worker) S 1 ... utime stime ... rss ...
+//
+// The name may contain spaces and parentheses, so it ends at the last ")".
+func parseProcStat(data []byte) (procStat, bool) {
+	open, end := bytes.IndexByte(data, '('), bytes.LastIndexByte(data, ')')
+	if open < 0 || end < open {
+		return procStat{}, false
+	}
+	st := procStat{name: string(data[open+1 : end])}
+	rest := skipFields(data[end+1:], 11) // state (field 3) to cmajflt (field 13)
+	var utime, stime []byte
+	utime, rest = nextField(rest)
+	stime, rest = nextField(rest)
+	rss, _ := nextField(skipFields(rest, 8)) // cutime (field 16) to vsize (field 23)
+	u, ok1 := parseUintBytes(utime)
+	s, ok2 := parseUintBytes(stime)
+	r, ok3 := parseUintBytes(rss)
+	if !ok1 || !ok2 || !ok3 {
+		return procStat{}, false
+	}
+	st.cpuTicks, st.rssPages = u+s, r
+	return st, true
+}
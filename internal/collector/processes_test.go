@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcStat(t *testing.T) {
+	st, ok := parseProcStat([]byte("14560 (nginx: worker (1)) R 14541 14560 14541 0 -1 4194304 78 0 0 0 150 50 0 0 20 0 1 0 418956 2703360 272 18446744073709551615 0\n"))
+	require.True(t, ok)
+	assert.Equal(t, procStat{name: "nginx: worker (1)", cpuTicks: 200, rssPages: 272}, st)
+
+	_, ok = parseProcStat([]byte("14560 (cat R"))
+	assert.False(t, ok)
+}
+
+func TestTopProcessesByMemory(t *testing.T) {
+	root := t.TempDir()
+	writeStat := func(pid int, name string, rss int) {
+		dir := filepath.Join(root, fmt.Sprint(pid))
+		require.NoError(t, os.Mkdir(dir, 0755))
+		line := fmt.Sprintf("%d (%s) S 1 1 1 0 -1 0 0 0 0 0 10 5 0 0 20 0 1 0 100 1000 %d 0\n", pid, name, rss)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(line), 0644))
+	}
+	writeStat(1, "init", 100)
+	writeStat(42, "postgres", 5000)
+	writeStat(77, "redis", 3000)
+	require.NoError(t, os.Mkdir(filepath.Join(root, "self"), 0755)) // Not a process
+
+	procs, err := topProcesses(root, 2, ProcessesByMemory, 0)
+	require.NoError(t, err)
+	page := uint64(os.Getpagesize())
+	assert.Equal(t, []Process{
+		{PID: 42, Name: "postgres", RSSBytes: 5000 * page},
+		{PID: 77, Name: "redis", RSSBytes: 3000 * page},
+	}, procs)
+}
+
+func TestTopProcessesByCPU(t *testing.T) {
+	procs, err := TopProcesses(3, ProcessesByCPU, 10*time.Millisecond)
+	if err != nil {
+		t.Skipf("no /proc: %v", err)
+	}
+	assert.NotEmpty(t, procs)
+	assert.LessOrEqual(t, len(procs), 3)
+}
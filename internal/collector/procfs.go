@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// procBufPool holds reusable buffers for reading /proc files, so a collection
+// cycle does not allocate a fresh buffer (or bufio.Scanner) per file.
+var procBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 16*1024)
+		return &b
+	},
+}
+
+// readProcFile reads a whole file into a pooled buffer.
+// The caller must hand the buffer back with releaseProcBuf when done.
+func readProcFile(path string) (*[]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bp := procBufPool.Get().(*[]byte)
+	buf := (*bp)[:0]
+	for {
+		if len(buf) == cap(buf) {
+			buf = append(buf, 0)[:len(buf)] // Grow; the larger buffer is kept in the pool
+		}
+		n, err := f.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			*bp = buf
+			releaseProcBuf(bp)
+			return nil, err
+		}
+	}
+	*bp = buf
+	return bp, nil
+}
+
+func releaseProcBuf(bp *[]byte) {
+	procBufPool.Put(bp)
+}
+
+// nextLine splits off the first line of data.
+func nextLine(data []byte) (line, rest []byte) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
+
+// nextField splits off the first whitespace-separated field of data.
+func nextField(data []byte) (field, rest []byte) {
+	i := 0
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t') {
+		i++
+	}
+	start := i
+	for i < len(data) && data[i] != ' ' && data[i] != '\t' {
+		i++
+	}
+	return data[start:i], data[i:]
+}
+
+// skipFields drops the first n whitespace-separated fields of data.
+func skipFields(data []byte, n int) []byte {
+	for ; n > 0; n-- {
+		_, data = nextField(data)
+	}
+	return data
+}
+
+// parseUintBytes parses an unsigned decimal integer without allocating.
+func parseUintBytes(b []byte) (uint64, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	var n uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		d := uint64(c - '0')
+		if n > (^uint64(0)-d)/10 {
+			return 0, false // Overflow
+		}
+		n = n*10 + d
+	}
+	return n, true
+}
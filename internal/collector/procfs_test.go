@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../testdata/" + name)
+	require.NoError(t, err)
+	return data
+}
+
+func TestParseCPUTimes(t *testing.T) {
+	total, idle, err := parseCPUTimes(readTestdata(t, "proc_stat"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1234567+890+234567+8901234+5678+90+1234+0), total)
+	assert.Equal(t, uint64(8901234), idle)
+
+	_, _, err = parseCPUTimes([]byte("intr 1 2 3\n"))
+	assert.Error(t, err)
+	_, _, err = parseCPUTimes([]byte("cpu  1 2 x 4 5 6 7 8\n"))
+	assert.Error(t, err)
+	_, _, err = parseCPUTimes([]byte("cpu  1 2 3 4\n"))
+	assert.Error(t, err)
+}
+
+func TestCPUPercents(t *testing.T) {
+	previous := CPUStatLine{User: 1000, Nice: 100, System: 200, Idle: 5000, IOWait: 50, IRQ: 10, SoftIRQ: 40, Steal: 100}
+	current := CPUStatLine{User: 1300, Nice: 100, System: 300, Idle: 5350, IOWait: 150, IRQ: 20, SoftIRQ: 80, Steal: 200, Guest: 50}
+	metrics := CollectedMetrics{}
+	cpuPercentsInto(previous, current, metrics)
+	// 1000 jiffies elapsed: 300 user, 150 system, 100 iowait, 100 steal, 350 idle
+	assert.InDelta(t, 65.0, metrics["cpu_percent_total"], 1e-9)
+	assert.InDelta(t, 30.0, metrics["cpu_percent_user"], 1e-9)
+	assert.InDelta(t, 15.0, metrics["cpu_percent_system"], 1e-9)
+	assert.InDelta(t, 10.0, metrics["cpu_percent_iowait"], 1e-9)
+	assert.InDelta(t, 10.0, metrics["cpu_percent_steal"], 1e-9)
+
+	// Counters going backwards, e.g. iowait on some kernels, count as zero
+	current.IOWait = 10
+	cpuPercentsInto(previous, current, metrics)
+	assert.Equal(t, 0.0, metrics["cpu_percent_iowait"])
+
+	cpuPercentsInto(current, current, metrics)
+	assert.Equal(t, 0.0, metrics["cpu_percent_total"])
+	assert.Equal(t, 0.0, metrics["cpu_percent_steal"])
+}
+
+func TestParseMemInfoData(t *testing.T) {
+	var info MemInfo
+	parseMemInfoData(readTestdata(t, "proc_meminfo"), &info)
+	assert.Equal(t, uint64(8192000), info.MemTotal)
+	assert.Equal(t, uint64(6144000), info.MemAvailable)
+	assert.Equal(t, uint64(2048000), info.SwapTotal)
+	assert.Equal(t, uint64(1024000), info.SwapFree)
+	assert.Equal(t, uint64(512), info.HugePagesTotal)
+	assert.Equal(t, uint64(128), info.HugePagesFree)
+	assert.Equal(t, uint64(64), info.HugePagesRsvd)
+}
+
+func TestParseDiskStatsData(t *testing.T) {
+	var stats DiskStats
+	parseDiskStatsData(readTestdata(t, "proc_diskstats"), &stats)
+	assert.Equal(t, uint64(1234567+987654+543210), stats.TotalSectorsRead)
+	assert.Equal(t, uint64(987654+543210+123456), stats.TotalSectorsWritten)
+}
+
+func TestParseNetDevData(t *testing.T) {
+	var stats NetworkStats
+	require.NoError(t, parseNetDevData(readTestdata(t, "proc_net_dev"), DefaultNetworkInterfaceFilter(), &stats))
+	assert.Equal(t, uint64(9876543210+1000), stats.TotalRecvBytes)
+	assert.Equal(t, uint64(5432109876+2000), stats.TotalSentBytes)
+
+	// Interface names glued to the first counter are still recognized
+	stats = NetworkStats{}
+	data := "header\nheader\n  eth0:12345 1 0 0 0 0 0 0 678 1 0 0 0 0 0 0\n"
+	require.NoError(t, parseNetDevData([]byte(data), DefaultNetworkInterfaceFilter(), &stats))
+	assert.Equal(t, uint64(12345), stats.TotalRecvBytes)
+	assert.Equal(t, uint64(678), stats.TotalSentBytes)
+
+	assert.Error(t, parseNetDevData([]byte("header\n"), DefaultNetworkInterfaceFilter(), &stats))
+}
+
+func TestParseUintBytes(t *testing.T) {
+	v, ok := parseUintBytes([]byte("18446744073709551615"))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(18446744073709551615), v)
+
+	for _, in := range []string{"", "12a", "-1", "18446744073709551616"} {
+		_, ok := parseUintBytes([]byte(in))
+		assert.False(t, ok, in)
+	}
+}
@@ -0,0 +1,796 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// Service types of the ServiceCollector.
+const (
+	ServicePHPFPM   = "php-fpm"
+	ServiceUWSGI    = "uwsgi"
+	ServiceGunicorn = "gunicorn"
+	ServiceNginx    = "nginx"
+	ServiceApache   = "apache"
+	ServiceHAProxy  = "haproxy"
+	ServiceCaddy    = "caddy"
+	ServiceRabbitMQ = "rabbitmq"
+	ServiceKafka    = "kafka"
+
+	ServiceElasticsearch = "elasticsearch"
+	ServiceOpenSearch    = "opensearch" // Same API as Elasticsearch
+)
+
+// DefaultKafkaCommand is the Kafka tool reading the lag of consumer groups.
+const DefaultKafkaCommand = "kafka-consumer-groups.sh"
+
+// maxStatusSize bounds the status documents read from services.
+const maxStatusSize = 4 << 20
+
+// Service is an application server whose status the ServiceCollector reads.
+type Service struct {
+	Name    string // Used in the metric names, service_<name>_<metric>
+	Type    string // One of the Service* types
+	Address string // Status page URL, stats socket or statsd listen address, depending on Type
+	Timeout time.Duration
+
+	Username string // RabbitMQ management API or Elasticsearch credentials
+	Password string
+	Group    string // Kafka consumer group
+	Command  string // Kafka tool, DefaultKafkaCommand if empty
+}
+
+// serviceStatus holds the metrics of a service by name suffix, e.g.
+// "workers_busy" for service_<name>_workers_busy.
+type serviceStatus map[string]float64
+
+// requestsCounter is the suffix under which a status holds the total number
+// of requests served, which the collector turns into requests_ps.
+const requestsCounter = "requests_total"
+
+// counterSample is a reading of a counter, to compute its rate.
+type counterSample struct {
+	value float64
+	time  time.Time
+}
+
+// serviceMetrics describes the metrics the services may report, by suffix.
+var serviceMetrics = []metricsmeta.Metadata{
+	{Name: "up", Description: "1 if the status of the service could be read, else 0"},
+	{Name: "workers_total", Description: "Worker processes of the service"},
+	{Name: "workers_busy", Description: "Worker processes serving a request"},
+	{Name: "busy_percent", Unit: metricsmeta.UnitPercent, Description: "Busy worker processes"},
+	{Name: "queue_length", Description: "Connections waiting for a worker"},
+	{Name: "requests_ps", Type: metricsmeta.TypeRate, Description: "Requests per second"},
+	{Name: "connections_active", Description: "Open client connections"},
+}
+
+// esMetrics describes the metrics of Elasticsearch and OpenSearch clusters,
+// named es_<name>_<suffix>.
+var esMetrics = []metricsmeta.Metadata{
+	{Name: "status", Description: "Cluster health: 0 green, 1 yellow, 2 red"},
+	{Name: "unassigned_shards", Description: "Shards not allocated to any node"},
+	{Name: "heap_percent", Unit: metricsmeta.UnitPercent, Description: "JVM heap used by the fullest node"},
+}
+
+// esStatus maps the cluster health colors to the values of es_<name>_status.
+var esStatus = map[string]float64{"green": 0, "yellow": 1, "red": 2}
+
+// ServiceCollector reads the status of application servers and reports it
+// as service_<name>_<metric>:
+//
+//	php-fpm   status page (pm.status_path), read over HTTP: workers, busy
+//	          workers and percentage, listen queue length
+//	uwsgi     stats server (--stats), over TCP, a unix socket or HTTP:
+//	          workers, busy workers and percentage, listen queue length
+//	gunicorn  statsd metrics (--statsd-host) received on a UDP address:
+//	          workers and requests per second
+//	nginx     stub_status page, read over HTTP: active connections and
+//	          requests per second
+//	apache    mod_status page (server-status), read over HTTP with ?auto:
+//	          busy workers and percentage of MaxRequestWorkers, active
+//	          connections (event MPM) and requests per second
+//	haproxy   stats socket or CSV stats page: per backend, whether it is
+//	          up, its queue and the percentage of its servers up, as
+//	          haproxy_backend_up_<backend>, haproxy_backend_queue_<backend>
+//	          and haproxy_backend_servers_up_percent_<backend>
+//	caddy     admin API: per reverse proxy upstream, whether it is healthy
+//	          and its active requests, as caddy_upstream_up_<address> and
+//	          caddy_upstream_requests_<address>, and the percentage of
+//	          upstreams up as caddy_upstreams_up_percent
+//	rabbitmq  management API: messages in every queue, as
+//	          queue_depth_<queue>
+//	kafka     kafka-consumer-groups.sh --describe of a consumer group: lag
+//	          per topic, summed over partitions, as consumer_lag_<topic>
+//	elasticsearch, opensearch
+//	          REST API: cluster health, unassigned shards and the heap
+//	          use of the fullest node, as es_<name>_status (green 0,
+//	          yellow 1, red 2), es_<name>_unassigned_shards and
+//	          es_<name>_heap_percent
+//
+// service_<name>_up is 0 when the status could not be read, or for
+// gunicorn when it sent no worker count for 30 seconds; the other metrics
+// of the service are left out then.
+type ServiceCollector struct {
+	services []Service
+	client   *http.Client
+	statsd   map[string]*statsdListener // Service name -> listener, started on first use
+	up       map[string]bool            // Service name -> last state, to log changes
+	requests map[string]counterSample   // Service name -> last requests_total
+	mu       sync.Mutex
+}
+
+// NewServiceCollector creates a collector reading the given services.
+func NewServiceCollector(services []Service) *ServiceCollector {
+	for _, svc := range services {
+		for _, md := range serviceMetrics {
+			md.Name = serviceMetricName(svc.Name, md.Name)
+			if _, known := metricsmeta.Lookup(md.Name); !known {
+				md.Description += " (" + svc.Name + ")"
+				metricsmeta.Register(md)
+			}
+		}
+		if svc.Type != ServiceElasticsearch && svc.Type != ServiceOpenSearch {
+			continue
+		}
+		for _, md := range esMetrics {
+			md.Name = esMetricName(svc.Name, md.Name)
+			if _, known := metricsmeta.Lookup(md.Name); !known {
+				md.Description += " (" + svc.Name + ")"
+				metricsmeta.Register(md)
+			}
+		}
+	}
+	return &ServiceCollector{
+		services: services,
+		client:   &http.Client{},
+		statsd:   make(map[string]*statsdListener),
+		up:       make(map[string]bool),
+		requests: make(map[string]counterSample),
+	}
+}
+
+func (sc *ServiceCollector) Name() string {
+	return "services"
+}
+
+// Collect reads the status of every service concurrently. A service that
+// can't be read is reported down rather than failing the collector.
+func (sc *ServiceCollector) Collect() (CollectedMetrics, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]serviceStatus, len(sc.services))
+	extras := make([]CollectedMetrics, len(sc.services)) // Metrics not named after the service
+	errs := make([]error, len(sc.services))
+	var wg sync.WaitGroup
+	for i, svc := range sc.services {
+		if svc.Type == ServiceGunicorn {
+			statuses[i], errs[i] = sc.gunicornStatus(svc, now)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), svc.Timeout)
+			defer cancel()
+			extras[i] = make(CollectedMetrics)
+			statuses[i], errs[i] = sc.readStatus(ctx, svc, extras[i])
+		}()
+	}
+	wg.Wait()
+
+	metrics := make(CollectedMetrics)
+	for i, svc := range sc.services {
+		up, known := sc.up[svc.Name]
+		switch {
+		case errs[i] != nil && (up || !known):
+			log.Printf("Warning: status of service '%s' could not be read: %v", svc.Name, errs[i])
+		case errs[i] == nil && !up && known:
+			log.Printf("Status of service '%s' can be read again.", svc.Name)
+		}
+		sc.up[svc.Name] = errs[i] == nil
+		metrics[serviceMetricName(svc.Name, "up")] = boolValue(errs[i] == nil)
+		if errs[i] != nil {
+			continue
+		}
+		if total, ok := statuses[i][requestsCounter]; ok {
+			delete(statuses[i], requestsCounter)
+			statuses[i]["requests_ps"] = sc.requestRate(svc.Name, total, now)
+		}
+		for suffix, v := range statuses[i] {
+			metrics[serviceMetricName(svc.Name, suffix)] = v
+		}
+		for name, v := range extras[i] {
+			metrics[name] = v
+		}
+	}
+	return metrics, nil
+}
+
+// requestRate returns the rate of the requests counter of a service since
+// the previous call, 0 the first time or after a restart of the service.
+func (sc *ServiceCollector) requestRate(service string, total float64, now time.Time) float64 {
+	last, ok := sc.requests[service]
+	sc.requests[service] = counterSample{value: total, time: now}
+	elapsed := now.Sub(last.time).Seconds()
+	if !ok || elapsed <= 0.1 || total < last.value {
+		return 0
+	}
+	return (total - last.value) / elapsed
+}
+
+// readStatus reads the status of a service that is polled. Metrics that
+// are not named after the service, such as those of HAProxy backends, are
+// added to metrics.
+func (sc *ServiceCollector) readStatus(ctx context.Context, svc Service, metrics CollectedMetrics) (serviceStatus, error) {
+	switch svc.Type {
+	case ServicePHPFPM:
+		u, err := url.Parse(svc.Address)
+		if err != nil {
+			return nil, err
+		}
+		q := u.Query()
+		q.Set("json", "")
+		u.RawQuery = q.Encode()
+		body, err := sc.get(ctx, u.String())
+		if err != nil {
+			return nil, err
+		}
+		return parsePHPFPMStatus(body)
+	case ServiceUWSGI:
+		var body []byte
+		var err error
+		if strings.HasPrefix(svc.Address, "http://") || strings.HasPrefix(svc.Address, "https://") {
+			body, err = sc.get(ctx, svc.Address)
+		} else {
+			body, err = readSocket(ctx, svc.Address, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return parseUWSGIStats(body)
+	case ServiceNginx:
+		body, err := sc.get(ctx, svc.Address)
+		if err != nil {
+			return nil, err
+		}
+		return parseNginxStatus(body)
+	case ServiceApache:
+		u, err := url.Parse(svc.Address)
+		if err != nil {
+			return nil, err
+		}
+		u.RawQuery = "auto"
+		body, err := sc.get(ctx, u.String())
+		if err != nil {
+			return nil, err
+		}
+		return parseApacheStatus(body)
+	case ServiceHAProxy:
+		var body []byte
+		var err error
+		if strings.HasPrefix(svc.Address, "http://") || strings.HasPrefix(svc.Address, "https://") {
+			body, err = sc.get(ctx, strings.TrimSuffix(svc.Address, "/")+"/;csv")
+		} else {
+			body, err = readSocket(ctx, svc.Address, "show stat\n")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return serviceStatus{}, parseHAProxyStats(body, metrics)
+	case ServiceCaddy:
+		body, err := sc.get(ctx, strings.TrimSuffix(svc.Address, "/")+"/reverse_proxy/upstreams")
+		if err != nil {
+			return nil, err
+		}
+		return serviceStatus{}, parseCaddyUpstreams(body, metrics)
+	case ServiceRabbitMQ:
+		body, err := sc.getAuth(ctx, strings.TrimSuffix(svc.Address, "/")+"/api/queues?columns=name,vhost,messages", svc.Username, svc.Password)
+		if err != nil {
+			return nil, err
+		}
+		return serviceStatus{}, parseRabbitMQQueues(body, metrics)
+	case ServiceKafka:
+		command := svc.Command
+		if command == "" {
+			command = DefaultKafkaCommand
+		}
+		out, err := runCommand(ctx, command, "--bootstrap-server", svc.Address, "--describe", "--group", svc.Group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run %s: %w", command, err)
+		}
+		return serviceStatus{}, parseKafkaConsumerGroup(out, metrics)
+	case ServiceElasticsearch, ServiceOpenSearch:
+		base := strings.TrimSuffix(svc.Address, "/")
+		health, err := sc.getAuth(ctx, base+"/_cluster/health", svc.Username, svc.Password)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := sc.getAuth(ctx, base+"/_nodes/stats/jvm", svc.Username, svc.Password)
+		if err != nil {
+			return nil, err
+		}
+		return serviceStatus{}, parseElasticsearchHealth(svc.Name, health, nodes, metrics)
+	}
+	return nil, fmt.Errorf("unknown service type '%s'", svc.Type)
+}
+
+// get fetches a status page.
+func (sc *ServiceCollector) get(ctx context.Context, url string) ([]byte, error) {
+	return sc.getAuth(ctx, url, "", "")
+}
+
+// getAuth fetches a status page with basic authentication, unless username
+// is empty.
+func (sc *ServiceCollector) getAuth(ctx context.Context, url, username, password string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := sc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxStatusSize))
+}
+
+// readSocket sends request, if any, to a stats server and reads everything
+// it sends back until it closes the connection. The address is host:port,
+// or the path of a unix socket, optionally prefixed with "unix:".
+func readSocket(ctx context.Context, address, request string) ([]byte, error) {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	} else if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if request != "" {
+		if _, err := io.WriteString(conn, request); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(io.LimitReader(conn, maxStatusSize))
+}
+
+// parsePHPFPMStatus parses the JSON status page of a php-fpm pool.
+func parsePHPFPMStatus(data []byte) (serviceStatus, error) {
+	var status struct {
+		ListenQueue     *float64 `json:"listen queue"`
+		ActiveProcesses float64  `json:"active processes"`
+		TotalProcesses  *float64 `json:"total processes"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("invalid php-fpm status: %w", err)
+	}
+	if status.TotalProcesses == nil || status.ListenQueue == nil {
+		return nil, errors.New("invalid php-fpm status: missing process counts")
+	}
+	return workerStatus(*status.TotalProcesses, status.ActiveProcesses, *status.ListenQueue), nil
+}
+
+// parseUWSGIStats parses the JSON document of a uwsgi stats server. Cheap
+// workers, stopped until load requires them, are not counted.
+func parseUWSGIStats(data []byte) (serviceStatus, error) {
+	var stats struct {
+		ListenQueue float64 `json:"listen_queue"`
+		Workers     []struct {
+			Status string `json:"status"`
+		} `json:"workers"`
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("invalid uwsgi stats: %w", err)
+	}
+	var total, busy float64
+	for _, w := range stats.Workers {
+		switch w.Status {
+		case "cheap":
+			continue
+		case "busy":
+			busy++
+		}
+		total++
+	}
+	return workerStatus(total, busy, stats.ListenQueue), nil
+}
+
+// parseNginxStatus parses the stub_status page of nginx:
+//
+//	Active connections: 291
+//	server accepts handled requests
+//	 16630948 16630948 31070465
+//	Reading: 6 Writing: 179 Waiting: 106
+func parseNginxStatus(data []byte) (serviceStatus, error) {
+	var active, accepts, handled, requests float64
+	_, err := fmt.Sscanf(string(data), "Active connections: %g\nserver accepts handled requests\n%g %g %g",
+		&active, &accepts, &handled, &requests)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nginx stub_status: %w", err)
+	}
+	return serviceStatus{"connections_active": active, requestsCounter: requests}, nil
+}
+
+// parseApacheStatus parses the machine-readable mod_status page of Apache
+// (server-status?auto). The scoreboard has a slot per worker up to
+// MaxRequestWorkers, so busy_percent is the saturation of the server.
+func parseApacheStatus(data []byte) (serviceStatus, error) {
+	status := make(serviceStatus)
+	var busy, slots float64
+	var haveBusy, haveScoreboard bool
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		key, value, ok := strings.Cut(string(line), ": ")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Total Accesses":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				status[requestsCounter] = v
+			}
+		case "BusyWorkers":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				busy, haveBusy = v, true
+			}
+		case "ConnsTotal":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				status["connections_active"] = v
+			}
+		case "Scoreboard":
+			slots, haveScoreboard = float64(len(value)), true
+		}
+	}
+	if !haveBusy || !haveScoreboard {
+		return nil, errors.New("invalid apache server-status: missing BusyWorkers or Scoreboard")
+	}
+	status["workers_total"] = slots
+	status["workers_busy"] = busy
+	status["busy_percent"] = 0
+	if slots > 0 {
+		status["busy_percent"] = busy / slots * 100
+	}
+	return status, nil
+}
+
+// parseHAProxyStats parses the CSV output of the HAProxy "show stat"
+// command, adding the metrics of every backend to metrics. Servers without
+// health checks ("no check") count as up; those in maintenance as down.
+func parseHAProxyStats(data []byte, metrics CollectedMetrics) error {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("# "))))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return fmt.Errorf("invalid haproxy stats: %w", err)
+	}
+	if len(records) == 0 {
+		return errors.New("invalid haproxy stats: no header")
+	}
+	col := make(map[string]int)
+	for i, name := range records[0] {
+		col[name] = i
+	}
+	for _, name := range []string{"pxname", "svname", "qcur", "status"} {
+		if _, ok := col[name]; !ok {
+			return fmt.Errorf("invalid haproxy stats: missing column %s", name)
+		}
+	}
+	servers := make(map[string][2]int) // Backend -> servers up, servers
+	var backends []string
+	for _, rec := range records[1:] {
+		if len(rec) <= col["status"] || len(rec) <= col["qcur"] {
+			continue
+		}
+		backend, status := rec[col["pxname"]], rec[col["status"]]
+		switch rec[col["svname"]] {
+		case "FRONTEND":
+		case "BACKEND":
+			name := sanitizeMetricName(backend)
+			metrics["haproxy_backend_up_"+name] = boolValue(status == "UP")
+			queue, _ := strconv.ParseFloat(rec[col["qcur"]], 64)
+			metrics["haproxy_backend_queue_"+name] = queue
+			backends = append(backends, backend)
+		default:
+			counts := servers[backend]
+			if strings.HasPrefix(status, "UP") || status == "no check" {
+				counts[0]++
+			}
+			counts[1]++
+			servers[backend] = counts
+		}
+	}
+	for _, backend := range backends {
+		percent := 0.0
+		if counts := servers[backend]; counts[1] > 0 {
+			percent = float64(counts[0]) * 100 / float64(counts[1])
+		}
+		metrics["haproxy_backend_servers_up_percent_"+sanitizeMetricName(backend)] = percent
+	}
+	return nil
+}
+
+// parseCaddyUpstreams parses the upstreams listed by the Caddy admin API,
+// adding their metrics to metrics. An upstream is up unless it is marked
+// unhealthy or has recent failures (passive health checks).
+func parseCaddyUpstreams(data []byte, metrics CollectedMetrics) error {
+	var upstreams []struct {
+		Address     string `json:"address"`
+		NumRequests int    `json:"num_requests"`
+		Fails       int    `json:"fails"`
+		Healthy     *bool  `json:"healthy"`
+	}
+	if err := json.Unmarshal(data, &upstreams); err != nil {
+		return fmt.Errorf("invalid caddy upstreams: %w", err)
+	}
+	up := 0
+	for _, u := range upstreams {
+		healthy := u.Fails == 0 && (u.Healthy == nil || *u.Healthy)
+		if healthy {
+			up++
+		}
+		name := sanitizeMetricName(u.Address)
+		metrics["caddy_upstream_up_"+name] = boolValue(healthy)
+		metrics["caddy_upstream_requests_"+name] = float64(u.NumRequests)
+	}
+	if len(upstreams) > 0 {
+		metrics["caddy_upstreams_up_percent"] = float64(up) * 100 / float64(len(upstreams))
+	}
+	return nil
+}
+
+// parseRabbitMQQueues parses the queues listed by the RabbitMQ management
+// API, adding their depths to metrics. Queues of several virtual hosts with
+// the same name are summed.
+func parseRabbitMQQueues(data []byte, metrics CollectedMetrics) error {
+	var queues []struct {
+		Name     string  `json:"name"`
+		Messages float64 `json:"messages"` // Missing until the queue has been sampled
+	}
+	if err := json.Unmarshal(data, &queues); err != nil {
+		return fmt.Errorf("invalid rabbitmq queues: %w", err)
+	}
+	for _, q := range queues {
+		metrics["queue_depth_"+sanitizeMetricName(q.Name)] += q.Messages
+	}
+	return nil
+}
+
+// parseKafkaConsumerGroup parses the output of kafka-consumer-groups.sh
+// --describe, adding the lag of the group on every topic to metrics:
+//
+//	GROUP   TOPIC   PARTITION  CURRENT-OFFSET  LOG-END-OFFSET  LAG  CONSUMER-ID  HOST  CLIENT-ID
+//	orders  orders  0          1500            1520            20   consumer-1   /10.0.0.5  consumer-1
+//
+// Partitions without a committed offset have no lag ("-") and are skipped.
+func parseKafkaConsumerGroup(data []byte, metrics CollectedMetrics) error {
+	topicCol, lagCol := -1, -1
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		fields := strings.Fields(string(line))
+		if len(fields) > 0 && fields[0] == "GROUP" {
+			topicCol = slices.Index(fields, "TOPIC")
+			lagCol = slices.Index(fields, "LAG")
+			continue
+		}
+		if topicCol < 0 || lagCol < 0 || len(fields) <= max(topicCol, lagCol) {
+			continue
+		}
+		lag, err := strconv.ParseFloat(fields[lagCol], 64)
+		if err != nil {
+			continue
+		}
+		metrics["consumer_lag_"+sanitizeMetricName(fields[topicCol])] += lag
+	}
+	if topicCol < 0 || lagCol < 0 {
+		return errors.New("invalid kafka consumer group description: missing TOPIC and LAG columns")
+	}
+	return nil
+}
+
+// parseElasticsearchHealth parses the cluster health and the JVM stats of
+// the nodes of an Elasticsearch or OpenSearch cluster, adding the metrics
+// of the service to metrics.
+func parseElasticsearchHealth(service string, health, nodeStats []byte, metrics CollectedMetrics) error {
+	var h struct {
+		Status           string  `json:"status"`
+		UnassignedShards float64 `json:"unassigned_shards"`
+	}
+	if err := json.Unmarshal(health, &h); err != nil {
+		return fmt.Errorf("invalid cluster health: %w", err)
+	}
+	status, ok := esStatus[h.Status]
+	if !ok {
+		return fmt.Errorf("invalid cluster health: unknown status '%s'", h.Status)
+	}
+	var stats struct {
+		Nodes map[string]struct {
+			JVM struct {
+				Mem struct {
+					HeapUsedPercent float64 `json:"heap_used_percent"`
+				} `json:"mem"`
+			} `json:"jvm"`
+		} `json:"nodes"`
+	}
+	if err := json.Unmarshal(nodeStats, &stats); err != nil {
+		return fmt.Errorf("invalid node stats: %w", err)
+	}
+	metrics[esMetricName(service, "status")] = status
+	metrics[esMetricName(service, "unassigned_shards")] = h.UnassignedShards
+	if len(stats.Nodes) > 0 {
+		heap := 0.0
+		for _, node := range stats.Nodes {
+			heap = max(heap, node.JVM.Mem.HeapUsedPercent)
+		}
+		metrics[esMetricName(service, "heap_percent")] = heap
+	}
+	return nil
+}
+
+// workerStatus returns the metrics of a pool of workers.
+func workerStatus(total, busy, queue float64) serviceStatus {
+	status := serviceStatus{"workers_total": total, "workers_busy": busy, "queue_length": queue, "busy_percent": 0}
+	if total > 0 {
+		status["busy_percent"] = busy / total * 100
+	}
+	return status
+}
+
+// gunicornStatus returns the status received from a gunicorn service,
+// starting its statsd listener the first time.
+func (sc *ServiceCollector) gunicornStatus(svc Service, now time.Time) (serviceStatus, error) {
+	l, ok := sc.statsd[svc.Name]
+	if !ok {
+		var err error
+		if l, err = listenStatsd(svc.Address); err != nil {
+			return nil, err
+		}
+		sc.statsd[svc.Name] = l
+	}
+	return l.status(now)
+}
+
+// statsdStaleAfter is how long a gunicorn service is considered up without
+// receiving its worker count, which the arbiter sends every second.
+const statsdStaleAfter = 30 * time.Second
+
+// statsdListener receives the statsd metrics of a gunicorn server.
+type statsdListener struct {
+	conn        net.PacketConn
+	mu          sync.Mutex
+	workers     float64
+	workersTime time.Time // When the worker count was last received
+	requests    float64   // Since the last status
+	lastStatus  time.Time
+}
+
+// listenStatsd starts receiving statsd metrics on a UDP address.
+func listenStatsd(address string) (*statsdListener, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	l := &statsdListener{conn: conn}
+	go l.run()
+	return l, nil
+}
+
+func (l *statsdListener) run() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		l.handle(buf[:n], time.Now())
+	}
+}
+
+// handle parses a statsd packet, one metric per line:
+//
+//	gunicorn.workers:4|g
+//	gunicorn.requests:1|c|@0.5
+//
+// Names may have a prefix (--statsd-prefix); other metrics are ignored.
+func (l *statsdListener) handle(packet []byte, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(packet) > 0 {
+		var line []byte
+		line, packet = nextLine(packet)
+		name, rest, ok := strings.Cut(string(line), ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(rest, "|")
+		if len(parts) < 2 {
+			continue
+		}
+		var value float64
+		if _, err := fmt.Sscan(parts[0], &value); err != nil {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(name, "gunicorn.workers") && parts[1] == "g":
+			l.workers, l.workersTime = value, now
+		case strings.HasSuffix(name, "gunicorn.requests") && parts[1] == "c":
+			rate := 1.0
+			if len(parts) > 2 && strings.HasPrefix(parts[2], "@") {
+				if _, err := fmt.Sscan(parts[2][1:], &rate); err != nil || rate <= 0 {
+					rate = 1
+				}
+			}
+			l.requests += value / rate
+		}
+	}
+}
+
+// status returns the worker count and the request rate since the previous
+// call; the first call reports no requests.
+func (l *statsdListener) status(now time.Time) (serviceStatus, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rate := 0.0
+	if elapsed := now.Sub(l.lastStatus).Seconds(); !l.lastStatus.IsZero() && elapsed > 0.1 {
+		rate = l.requests / elapsed
+	}
+	l.requests, l.lastStatus = 0, now
+	if l.workersTime.IsZero() || now.Sub(l.workersTime) > statsdStaleAfter {
+		return nil, fmt.Errorf("no worker count received from gunicorn in %s", statsdStaleAfter)
+	}
+	return serviceStatus{"workers_total": l.workers, "requests_ps": rate}, nil
+}
+
+func serviceMetricName(service, suffix string) string {
+	return "service_" + sanitizeMetricName(service) + "_" + suffix
+}
+
+// esMetricName returns the name of a metric of an Elasticsearch service,
+// e.g. es_search_status.
+func esMetricName(service, suffix string) string {
+	return "es_" + sanitizeMetricName(service) + "_" + suffix
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,285 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePHPFPMStatus(t *testing.T) {
+	status, err := parsePHPFPMStatus(readTestdata(t, "php_fpm_status.json"))
+	require.NoError(t, err)
+	assert.Equal(t, serviceStatus{"workers_total": 8, "workers_busy": 7, "busy_percent": 87.5, "queue_length": 3}, status)
+
+	_, err = parsePHPFPMStatus([]byte(`pool: www`))
+	assert.Error(t, err, "the text status page")
+	_, err = parsePHPFPMStatus([]byte(`{"pool": "www"}`))
+	assert.Error(t, err)
+}
+
+func TestParseUWSGIStats(t *testing.T) {
+	status, err := parseUWSGIStats(readTestdata(t, "uwsgi_stats.json"))
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, status["workers_total"], "cheap workers are not counted")
+	assert.Equal(t, 2.0, status["workers_busy"])
+	assert.InDelta(t, 66.67, status["busy_percent"], 0.01)
+	assert.Equal(t, 2.0, status["queue_length"])
+}
+
+func TestParseNginxStatus(t *testing.T) {
+	status, err := parseNginxStatus(readTestdata(t, "nginx_stub_status"))
+	require.NoError(t, err)
+	assert.Equal(t, serviceStatus{"connections_active": 291, requestsCounter: 31070465}, status)
+
+	_, err = parseNginxStatus([]byte("<html>404 Not Found</html>"))
+	assert.Error(t, err)
+}
+
+func TestParseApacheStatus(t *testing.T) {
+	status, err := parseApacheStatus(readTestdata(t, "apache_server_status"))
+	require.NoError(t, err)
+	assert.Equal(t, serviceStatus{
+		"workers_total":      150,
+		"workers_busy":       15,
+		"busy_percent":       10,
+		"connections_active": 22,
+		requestsCounter:      172800,
+	}, status)
+
+	_, err = parseApacheStatus([]byte("<html>Apache Server Status</html>"))
+	assert.Error(t, err, "the HTML page")
+}
+
+func TestParseHAProxyStats(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	require.NoError(t, parseHAProxyStats(readTestdata(t, "haproxy_show_stat"), metrics))
+	assert.Equal(t, CollectedMetrics{
+		"haproxy_backend_up_app":                    1,
+		"haproxy_backend_queue_app":                 3,
+		"haproxy_backend_servers_up_percent_app":    200.0 / 3,
+		"haproxy_backend_up_static":                 1,
+		"haproxy_backend_queue_static":              0,
+		"haproxy_backend_servers_up_percent_static": 50,
+		"haproxy_backend_up_legacy":                 0,
+		"haproxy_backend_queue_legacy":              0,
+		"haproxy_backend_servers_up_percent_legacy": 0,
+	}, metrics)
+
+	assert.Error(t, parseHAProxyStats([]byte("Unknown command.\n"), metrics))
+}
+
+func TestParseCaddyUpstreams(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	require.NoError(t, parseCaddyUpstreams(readTestdata(t, "caddy_upstreams.json"), metrics))
+	assert.Equal(t, CollectedMetrics{
+		"caddy_upstream_up_10_0_0_11_8080":           1,
+		"caddy_upstream_requests_10_0_0_11_8080":     4,
+		"caddy_upstream_up_10_0_0_12_8080":           0,
+		"caddy_upstream_requests_10_0_0_12_8080":     0,
+		"caddy_upstream_up_unix__run_app_sock":       1,
+		"caddy_upstream_requests_unix__run_app_sock": 1,
+		"caddy_upstreams_up_percent":                 200.0 / 3,
+	}, metrics)
+}
+
+func TestServiceCollectorHAProxySocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "haproxy.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		cmd, _ := bufio.NewReader(conn).ReadString('\n')
+		if cmd == "show stat\n" {
+			conn.Write(readTestdata(t, "haproxy_show_stat"))
+		}
+	}()
+
+	metrics, err := NewServiceCollector([]Service{{Name: "lb", Type: ServiceHAProxy, Address: socket, Timeout: time.Second}}).Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["service_lb_up"])
+	assert.Equal(t, 0.0, metrics["haproxy_backend_up_legacy"])
+	assert.Equal(t, 3.0, metrics["haproxy_backend_queue_app"])
+}
+
+func TestParseRabbitMQQueues(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	require.NoError(t, parseRabbitMQQueues(readTestdata(t, "rabbitmq_queues.json"), metrics))
+	assert.Equal(t, CollectedMetrics{"queue_depth_orders": 1255, "queue_depth_emails": 0, "queue_depth_new_queue": 0}, metrics)
+}
+
+func TestParseKafkaConsumerGroup(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	require.NoError(t, parseKafkaConsumerGroup(readTestdata(t, "kafka_consumer_group"), metrics))
+	assert.Equal(t, CollectedMetrics{"consumer_lag_invoices": 150, "consumer_lag_refunds_v2": 0}, metrics, "partitions without committed offset are skipped")
+
+	assert.Error(t, parseKafkaConsumerGroup([]byte("Error: Consumer group 'billing' does not exist.\n"), metrics))
+}
+
+func TestServiceCollectorQueues(t *testing.T) {
+	rabbit := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "monitor" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "/api/queues", r.URL.Path)
+		w.Write(readTestdata(t, "rabbitmq_queues.json"))
+	}))
+	defer rabbit.Close()
+	oldRun := runCommand
+	t.Cleanup(func() { runCommand = oldRun })
+	runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		assert.Equal(t, []string{"/opt/kafka/bin/kafka-consumer-groups.sh", "--bootstrap-server", "localhost:9092", "--describe", "--group", "billing"}, append([]string{name}, args...))
+		return readTestdata(t, "kafka_consumer_group"), nil
+	}
+
+	metrics, err := NewServiceCollector([]Service{
+		{Name: "mq", Type: ServiceRabbitMQ, Address: rabbit.URL, Timeout: time.Second, Username: "monitor", Password: "secret"},
+		{Name: "mq-anonymous", Type: ServiceRabbitMQ, Address: rabbit.URL, Timeout: time.Second},
+		{Name: "kafka", Type: ServiceKafka, Address: "localhost:9092", Group: "billing", Command: "/opt/kafka/bin/kafka-consumer-groups.sh", Timeout: time.Second},
+	}).Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["service_mq_up"])
+	assert.Equal(t, 1255.0, metrics["queue_depth_orders"])
+	assert.Equal(t, 0.0, metrics["service_mq_anonymous_up"])
+	assert.Equal(t, 1.0, metrics["service_kafka_up"])
+	assert.Equal(t, 150.0, metrics["consumer_lag_invoices"])
+}
+
+func TestParseElasticsearchHealth(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	require.NoError(t, parseElasticsearchHealth("search", readTestdata(t, "es_cluster_health.json"), readTestdata(t, "es_nodes_stats.json"), metrics))
+	assert.Equal(t, CollectedMetrics{
+		"es_search_status":            1,
+		"es_search_unassigned_shards": 12,
+		"es_search_heap_percent":      83,
+	}, metrics)
+
+	assert.ErrorContains(t, parseElasticsearchHealth("search", []byte(`{"status": "purple"}`), []byte(`{}`), metrics), "unknown status")
+}
+
+func TestServiceCollectorElasticsearch(t *testing.T) {
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_cluster/health":
+			w.Write(readTestdata(t, "es_cluster_health.json"))
+		case "/_nodes/stats/jvm":
+			w.Write(readTestdata(t, "es_nodes_stats.json"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer es.Close()
+
+	metrics, err := NewServiceCollector([]Service{
+		{Name: "search", Type: ServiceOpenSearch, Address: es.URL + "/", Timeout: time.Second},
+	}).Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["service_search_up"])
+	assert.Equal(t, 1.0, metrics["es_search_status"])
+	assert.Equal(t, 83.0, metrics["es_search_heap_percent"])
+}
+
+func TestServiceCollectorRequestRate(t *testing.T) {
+	sc := NewServiceCollector(nil)
+	now := time.Now()
+	assert.Equal(t, 0.0, sc.requestRate("web", 1000, now), "no rate on the first reading")
+	assert.Equal(t, 50.0, sc.requestRate("web", 1500, now.Add(10*time.Second)))
+	assert.Equal(t, 0.0, sc.requestRate("web", 10, now.Add(20*time.Second)), "the counter was reset by a restart")
+}
+
+func TestStatsdListener(t *testing.T) {
+	l := &statsdListener{}
+	now := time.Now()
+	_, err := l.status(now)
+	assert.Error(t, err, "no worker count yet")
+
+	l.handle([]byte("app.gunicorn.workers:4|g\napp.gunicorn.requests:1|c\ngunicorn.request.duration:12.5|ms\n"), now)
+	l.handle([]byte("gunicorn.requests:1|c|@0.5"), now)
+	status, err := l.status(now)
+	require.NoError(t, err)
+	assert.Equal(t, serviceStatus{"workers_total": 4, "requests_ps": 0}, status, "no rate on the first status")
+
+	l.handle([]byte("gunicorn.requests:20|c"), now)
+	status, err = l.status(now.Add(10 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, serviceStatus{"workers_total": 4, "requests_ps": 2}, status)
+
+	_, err = l.status(now.Add(time.Minute))
+	assert.ErrorContains(t, err, "no worker count received", "gunicorn stopped sending")
+}
+
+func TestServiceCollector(t *testing.T) {
+	fpm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, r.URL.Query().Has("json"))
+		w.Write(readTestdata(t, "php_fpm_status.json"))
+	}))
+	defer fpm.Close()
+
+	nginx := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(readTestdata(t, "nginx_stub_status"))
+	}))
+	defer nginx.Close()
+
+	socket := filepath.Join(t.TempDir(), "uwsgi.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write(readTestdata(t, "uwsgi_stats.json"))
+			conn.Close()
+		}
+	}()
+
+	sc := NewServiceCollector([]Service{
+		{Name: "shop", Type: ServicePHPFPM, Address: fpm.URL + "/status", Timeout: time.Second},
+		{Name: "api", Type: ServiceUWSGI, Address: "unix:" + socket, Timeout: time.Second},
+		{Name: "down", Type: ServicePHPFPM, Address: "http://127.0.0.1:1/status", Timeout: time.Second},
+		{Name: "web", Type: ServiceGunicorn, Address: "127.0.0.1:0", Timeout: time.Second},
+		{Name: "front", Type: ServiceNginx, Address: nginx.URL + "/nginx_status", Timeout: time.Second},
+	})
+	metrics, err := sc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["service_shop_up"])
+	assert.Equal(t, 87.5, metrics["service_shop_busy_percent"])
+	assert.Equal(t, 1.0, metrics["service_api_up"])
+	assert.Equal(t, 2.0, metrics["service_api_workers_busy"])
+	assert.Equal(t, 0.0, metrics["service_down_up"])
+	assert.NotContains(t, metrics, "service_down_workers_total")
+	assert.Equal(t, 0.0, metrics["service_web_up"], "gunicorn sent nothing yet")
+	assert.Equal(t, 291.0, metrics["service_front_connections_active"])
+	assert.Equal(t, 0.0, metrics["service_front_requests_ps"], "no rate on the first cycle")
+	assert.NotContains(t, metrics, "service_front_"+requestsCounter)
+
+	// gunicorn sending its statsd metrics
+	l := sc.statsd["web"]
+	require.NotNil(t, l)
+	defer l.conn.Close()
+	conn, err := net.Dial("udp", l.conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("gunicorn.workers:3|g"))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		metrics, err := sc.Collect()
+		return err == nil && metrics["service_web_up"] == 1 && metrics["service_web_workers_total"] == 3
+	}, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,277 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// TextfileCollector reads metrics from *.prom files in a directory, in the
+// style of node_exporter's textfile collector. Cron jobs and scripts can drop
+// files there to feed custom values into monres without any network setup.
+type TextfileCollector struct {
+	directory string
+	maxAge    time.Duration   // Files older than this are considered stale (0 disables)
+	stale     map[string]bool // file path -> last known staleness, to log transitions only
+	mu        sync.Mutex
+}
+
+// NewTextfileCollector creates a collector reading *.prom files from directory.
+// If maxAge is greater than zero, files whose modification time is older than
+// maxAge are ignored until they are rewritten.
+func NewTextfileCollector(directory string, maxAge time.Duration) *TextfileCollector {
+	return &TextfileCollector{
+		directory: directory,
+		maxAge:    maxAge,
+		stale:     make(map[string]bool),
+	}
+}
+
+func (tc *TextfileCollector) Name() string {
+	return "textfile"
+}
+
+// Collect parses every *.prom file in the directory. A file that fails to
+// parse is skipped as a whole and reported via textfile_scrape_error.
+func (tc *TextfileCollector) Collect() (CollectedMetrics, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(tc.directory, "*.prom"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list textfile directory %s: %w", tc.directory, err)
+	}
+	sort.Strings(files)
+
+	metrics := make(CollectedMetrics)
+	scrapeError := 0.0
+	now := time.Now()
+
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("Warning: textfile collector could not stat %s: %v", path, err)
+			scrapeError = 1
+			continue
+		}
+
+		isStale := tc.maxAge > 0 && now.Sub(info.ModTime()) > tc.maxAge
+		if isStale != tc.stale[path] {
+			if isStale {
+				log.Printf("Warning: textfile %s is stale (last modified %s ago), ignoring its metrics.", path, now.Sub(info.ModTime()).Round(time.Second))
+			} else {
+				log.Printf("Textfile %s is fresh again, resuming collection.", path)
+			}
+			tc.stale[path] = isStale
+		}
+		if isStale {
+			continue
+		}
+
+		fileMetrics, err := parseTextfile(path)
+		if err != nil {
+			log.Printf("Warning: textfile collector failed to parse %s: %v", path, err)
+			scrapeError = 1
+			continue
+		}
+		for k, v := range fileMetrics {
+			metrics[k] = v
+		}
+	}
+
+	metrics["textfile_scrape_error"] = scrapeError
+	return metrics, nil
+}
+
+func parseTextfile(path string) (CollectedMetrics, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	metrics := make(CollectedMetrics)
+	descriptors := make(map[string]*metricsmeta.Metadata) // base name -> HELP/TYPE/UNIT
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			parseTextfileDescriptor(line, descriptors)
+			continue
+		}
+		baseName, name, value, err := parseTextfileSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue // Not representable in alert comparisons
+		}
+		metrics[name] = value
+
+		// Metadata from config or built-ins takes precedence over the file
+		if md, ok := descriptors[baseName]; ok {
+			if _, known := metricsmeta.Lookup(name); !known {
+				md.Name = name
+				metricsmeta.Register(*md)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning %s: %w", path, err)
+	}
+	return metrics, nil
+}
+
+// parseTextfileDescriptor handles "# HELP", "# TYPE" and "# UNIT" comment lines.
+// Other comments are ignored.
+func parseTextfileDescriptor(line string, descriptors map[string]*metricsmeta.Metadata) {
+	fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "#")), " ", 3)
+	if len(fields) < 3 {
+		return
+	}
+	baseName := sanitizeMetricName(fields[1])
+	md, ok := descriptors[baseName]
+	if !ok {
+		md = &metricsmeta.Metadata{}
+		descriptors[baseName] = md
+	}
+	value := strings.TrimSpace(fields[2])
+	switch fields[0] {
+	case "HELP":
+		md.Description = value
+	case "TYPE":
+		if value == "counter" {
+			md.Type = metricsmeta.TypeCounter
+		} else {
+			md.Type = metricsmeta.TypeGauge
+		}
+	case "UNIT":
+		if metricsmeta.IsKnownUnit(value) {
+			md.Unit = metricsmeta.Unit(value)
+		}
+	}
+}
+
+// parseTextfileSample parses a single exposition-format sample line:
+//
+//	metric_name{label="value",...} 42 [timestamp]
+//
+// Label values are folded into the metric name (metric_name_value) since
+// monres metrics are flat names. Both the sanitized base name and the full
+// metric name are returned.
+func parseTextfileSample(line string) (string, string, float64, error) {
+	var labelValues []string
+
+	idx := strings.IndexAny(line, "{ \t")
+	if idx == -1 {
+		return "", "", 0, fmt.Errorf("missing value in sample %q", line)
+	}
+	name, rest := line[:idx], line[idx:]
+	if name == "" {
+		return "", "", 0, fmt.Errorf("missing metric name in sample %q", line)
+	}
+
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		labelValues, rest, err = parseTextfileLabels(rest[1:])
+		if err != nil {
+			return "", "", 0, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 { // value and optional timestamp
+		return "", "", 0, fmt.Errorf("invalid sample %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid value in sample %q: %w", line, err)
+	}
+
+	return sanitizeMetricName(name), TextfileMetricName(name, labelValues), value, nil
+}
+
+// TextfileMetricName returns the monres metric name of a textfile sample:
+// the sanitized name followed by the sanitized label values, in order.
+func TextfileMetricName(name string, labelValues []string) string {
+	metricName := sanitizeMetricName(name)
+	for _, lv := range labelValues {
+		if lv = sanitizeMetricName(lv); lv != "" {
+			metricName += "_" + lv
+		}
+	}
+	return metricName
+}
+
+// parseTextfileLabels parses the label set after the opening brace and returns
+// the label values in order along with the remainder of the line.
+func parseTextfileLabels(s string) ([]string, string, error) {
+	var values []string
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if strings.HasPrefix(s, "}") {
+			return values, s[1:], nil
+		}
+		eq := strings.Index(s, "=")
+		if eq == -1 {
+			return nil, "", fmt.Errorf("invalid label set")
+		}
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("label value must be quoted")
+		}
+		var value strings.Builder
+		i := 1
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			if c == '"' {
+				break
+			}
+			value.WriteByte(c)
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("unterminated label value")
+		}
+		values = append(values, value.String())
+		s = s[i+1:]
+	}
+}
+
+// sanitizeMetricName lowercases a name and replaces every character outside
+// [a-z0-9_] with an underscore, trimming leading/trailing underscores.
+func sanitizeMetricName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+func TestParseTextfileSample(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		expectedName string
+		expected     float64
+		wantErr      bool
+	}{
+		{"plain", "backup_last_success 1", "backup_last_success", 1, false},
+		{"with_timestamp", "queue_size 42 1700000000000", "queue_size", 42, false},
+		{"labels", `backup_age_seconds{job="db",target="s3"} 3600`, "backup_age_seconds_db_s3", 3600, false},
+		{"label_sanitized", `mount_ok{path="/var/lib"} 1`, "mount_ok_var_lib", 1, false},
+		{"escaped_quote", `x{a="q\"v"} 2`, "x_q_v", 2, false},
+		{"float", "ratio 0.25", "ratio", 0.25, false},
+		{"missing_value", "lonely_metric", "", 0, true},
+		{"bad_value", "metric abc", "", 0, true},
+		{"unterminated_labels", `metric{a="b 1`, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, name, value, err := parseTextfileSample(tt.line)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedName, name)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestTextfileCollector(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "backup.prom"), []byte(`# HELP backup_last_success Whether the last backup succeeded
+# TYPE backup_last_success gauge
+backup_last_success 1
+backup_size_bytes{job="db"} 1024
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("not_collected 1\n"), 0644))
+
+	tc := NewTextfileCollector(dir, 0)
+	assert.Equal(t, "textfile", tc.Name())
+
+	metrics, err := tc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["backup_last_success"])
+	assert.Equal(t, 1024.0, metrics["backup_size_bytes_db"])
+	assert.Equal(t, 0.0, metrics["textfile_scrape_error"])
+	assert.NotContains(t, metrics, "not_collected")
+
+	md, ok := metricsmeta.Lookup("backup_last_success")
+	require.True(t, ok)
+	assert.Equal(t, "Whether the last backup succeeded", md.Description)
+	assert.Equal(t, metricsmeta.TypeGauge, md.Type)
+}
+
+func TestTextfileCollectorUnitDescriptor(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "job.prom"), []byte(`# UNIT job_runtime_seconds seconds
+job_runtime_seconds{job="backup"} 12.5
+`), 0644))
+
+	_, err := NewTextfileCollector(dir, 0).Collect()
+	require.NoError(t, err)
+
+	md, ok := metricsmeta.Lookup("job_runtime_seconds_backup")
+	require.True(t, ok)
+	assert.Equal(t, metricsmeta.UnitSeconds, md.Unit)
+}
+
+func TestTextfileCollectorInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "good.prom"), []byte("good_metric 5\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.prom"), []byte("bad_metric 1\nbroken line here\n"), 0644))
+
+	metrics, err := NewTextfileCollector(dir, 0).Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, metrics["good_metric"])
+	assert.NotContains(t, metrics, "bad_metric") // Whole file is skipped
+	assert.Equal(t, 1.0, metrics["textfile_scrape_error"])
+}
+
+func TestTextfileCollectorStaleness(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cron.prom")
+	require.NoError(t, os.WriteFile(path, []byte("cron_job_ok 1\n"), 0644))
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	tc := NewTextfileCollector(dir, time.Hour)
+	metrics, err := tc.Collect()
+	require.NoError(t, err)
+	assert.NotContains(t, metrics, "cron_job_ok")
+
+	// Rewriting the file makes it fresh again
+	require.NoError(t, os.WriteFile(path, []byte("cron_job_ok 1\n"), 0644))
+	metrics, err = tc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["cron_job_ok"])
+}
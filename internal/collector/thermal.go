@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysClassDir holds the thermal and hwmon device classes. Replaced in tests.
+var sysClassDir = "/sys/class"
+
+// readThermalZones returns the temperature in °C of every thermal zone under
+// dir (/sys/class/thermal), by zone type, e.g. "x86_pkg_temp" or "acpitz".
+// Zones sharing a type are told apart by their number.
+func readThermalZones(dir string, temps map[string]float64) {
+	zones, _ := filepath.Glob(filepath.Join(dir, "thermal_zone[0-9]*"))
+	byType := make(map[string][]string)
+	for _, zone := range zones {
+		name := sanitizeMetricName(readSysString(filepath.Join(zone, "type")))
+		if name == "" {
+			name = "zone"
+		}
+		byType[name] = append(byType[name], zone)
+	}
+	for name, zones := range byType {
+		for _, zone := range zones {
+			temp, ok := readMilliCelsius(filepath.Join(zone, "temp"))
+			if !ok {
+				continue // Disabled zone, or one whose sensor can't be read
+			}
+			if len(zones) > 1 {
+				temps[name+"_"+strings.TrimPrefix(filepath.Base(zone), "thermal_zone")] = temp
+			} else {
+				temps[name] = temp
+			}
+		}
+	}
+}
+
+// readHwmonSensors returns the temperature in °C of every temperature sensor
+// of the hwmon chips under dir (/sys/class/hwmon), named by chip and label,
+// e.g. "coretemp_core_0" or "nvme_composite", or by chip and sensor number
+// for sensors without a label ("k10temp_temp1"). Chips sharing a name, like
+// two NVMe drives, are told apart by their hwmon number.
+func readHwmonSensors(dir string, temps map[string]float64) {
+	chips, _ := filepath.Glob(filepath.Join(dir, "hwmon[0-9]*"))
+	byName := make(map[string][]string)
+	for _, chip := range chips {
+		name := sanitizeMetricName(readSysString(filepath.Join(chip, "name")))
+		if name == "" {
+			name = "hwmon"
+		}
+		byName[name] = append(byName[name], chip)
+	}
+	for name, chips := range byName {
+		for _, chip := range chips {
+			prefix := name
+			if len(chips) > 1 {
+				prefix += "_" + strings.TrimPrefix(filepath.Base(chip), "hwmon")
+			}
+			inputs, _ := filepath.Glob(filepath.Join(chip, "temp[0-9]*_input"))
+			for _, input := range inputs {
+				temp, ok := readMilliCelsius(input)
+				if !ok {
+					continue
+				}
+				sensor := strings.TrimSuffix(filepath.Base(input), "_input")
+				if label := sanitizeMetricName(readSysString(filepath.Join(chip, sensor+"_label"))); label != "" {
+					sensor = label
+				}
+				temps[prefix+"_"+sensor] = temp
+			}
+		}
+	}
+}
+
+// readSysString reads a sysfs file holding a single line.
+func readSysString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(bytes.TrimSpace(data))
+}
+
+// readMilliCelsius reads a sysfs temperature in thousandths of a degree,
+// which may be negative, and returns it in °C.
+func readMilliCelsius(path string) (float64, bool) {
+	milli, err := strconv.ParseInt(readSysString(path), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return float64(milli) / 1000, true
+}
+
+// collectThermal reports temp_celsius_<sensor> for every thermal zone and
+// hwmon temperature sensor, and the hottest of them as temp_celsius_max.
+// Hosts without sensors, like most VMs, report none.
+func (gc *GlobalCollector) collectThermal(metrics CollectedMetrics) error {
+	temps := make(map[string]float64)
+	readThermalZones(filepath.Join(sysClassDir, "thermal"), temps)
+	readHwmonSensors(filepath.Join(sysClassDir, "hwmon"), temps)
+	first := true
+	for name, temp := range temps {
+		metrics["temp_celsius_"+name] = temp
+		if first || temp > metrics["temp_celsius_max"] {
+			metrics["temp_celsius_max"] = temp
+			first = false
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectThermal(t *testing.T) {
+	dir := t.TempDir()
+	oldDir := sysClassDir
+	sysClassDir = dir
+	t.Cleanup(func() { sysClassDir = oldDir })
+	for path, content := range map[string]string{
+		"thermal/thermal_zone0/type": "acpitz", "thermal/thermal_zone0/temp": "27800",
+		"thermal/thermal_zone1/type": "acpitz", "thermal/thermal_zone1/temp": "29800",
+		"thermal/thermal_zone2/type": "x86_pkg_temp", "thermal/thermal_zone2/temp": "54000",
+		"thermal/thermal_zone3/type": "iwlwifi_1", // No temp while the interface is down
+		"hwmon/hwmon0/name":          "coretemp", "hwmon/hwmon0/temp1_input": "55000", "hwmon/hwmon0/temp1_label": "Package id 0",
+		"hwmon/hwmon0/temp2_input": "51000", "hwmon/hwmon0/temp2_label": "Core 0",
+		"hwmon/hwmon1/name": "nvme", "hwmon/hwmon1/temp1_input": "38850", "hwmon/hwmon1/temp1_label": "Composite",
+		"hwmon/hwmon2/name": "nvme", "hwmon/hwmon2/temp1_input": "-1500", "hwmon/hwmon2/temp1_label": "Composite",
+		"hwmon/hwmon3/name": "k10temp", "hwmon/hwmon3/temp1_input": "61250",
+	} {
+		writeSysCPU(t, dir, filepath.Dir(path), map[string]string{filepath.Base(path): content})
+	}
+
+	gc := NewGlobalCollector(nil)
+	metrics := make(CollectedMetrics)
+	require.NoError(t, gc.collectThermal(metrics))
+	assert.Equal(t, CollectedMetrics{
+		"temp_celsius_acpitz_0":              27.8,
+		"temp_celsius_acpitz_1":              29.8,
+		"temp_celsius_x86_pkg_temp":          54,
+		"temp_celsius_coretemp_package_id_0": 55,
+		"temp_celsius_coretemp_core_0":       51,
+		"temp_celsius_nvme_1_composite":      38.85,
+		"temp_celsius_nvme_2_composite":      -1.5,
+		"temp_celsius_k10temp_temp1":         61.25,
+		"temp_celsius_max":                   61.25,
+	}, metrics)
+
+	// A VM without sensors
+	sysClassDir = t.TempDir()
+	clear(metrics)
+	require.NoError(t, gc.collectThermal(metrics))
+	assert.Empty(t, metrics)
+}
@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+func TestTopProcessCollector(t *testing.T) {
+	oldDir := procDir
+	procDir = t.TempDir()
+	t.Cleanup(func() { procDir = oldDir })
+	writeStat := func(pid int, name string, ticks, rss int) {
+		dir := filepath.Join(procDir, fmt.Sprint(pid))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		line := fmt.Sprintf("%d (%s) R 1 1 1 0 -1 0 0 0 0 0 %d 0 0 0 20 0 1 0 100 1000 %d 0\n", pid, name, ticks, rss)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(line), 0644))
+	}
+	page := float64(os.Getpagesize())
+
+	writeStat(100, "postgres", 1000, 500)
+	writeStat(101, "nginx", 1000, 20)
+	tc := NewTopProcessCollector(3)
+	metrics, err := tc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{
+		"proc_top1_cpu_percent": 0, "proc_top1_rss_bytes": 500 * page,
+		"proc_top2_cpu_percent": 0, "proc_top2_rss_bytes": 20 * page,
+		"proc_top3_cpu_percent": 0, "proc_top3_rss_bytes": 0,
+	}, metrics, "no CPU use on the first call, 0 for ranks without a process")
+
+	// In 10s, nginx used 300 ticks (3s) and postgres 100
+	tc.lastTime = time.Now().Add(-10 * time.Second)
+	writeStat(100, "postgres", 1100, 500)
+	writeStat(101, "nginx", 1300, 20)
+	metrics, err = tc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 30, metrics["proc_top1_cpu_percent"], 0.1)
+	assert.InDelta(t, 10, metrics["proc_top2_cpu_percent"], 0.1)
+	assert.Equal(t, 500*page, metrics["proc_top1_rss_bytes"])
+
+	md, ok := metricsmeta.Lookup("proc_top2_rss_bytes")
+	require.True(t, ok)
+	assert.Equal(t, metricsmeta.UnitBytes, md.Unit)
+}
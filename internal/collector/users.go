@@ -0,0 +1,128 @@
+package collector
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// procDir holds a directory per process, scanned by the user collector.
+// Replaced in tests.
+var procDir = "/proc"
+
+// lookupUsername returns the name of a user, or false if it has none.
+// Replaced in tests.
+var lookupUsername = func(uid uint32) (string, bool) {
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		return "", false
+	}
+	return u.Username, true
+}
+
+// UserCollector sums the resource usage of the processes of every user:
+//
+//	user_<name>_cpu_percent  CPU use, of one CPU like top, so it may exceed 100
+//	user_<name>_rss_bytes    resident memory
+//
+// Users are named by username, or by UID when they have none. Users whose
+// processes have all exited report 0 until monres restarts, so that alerts
+// on them resolve. CPU use is measured against the previous call; the
+// first call reports 0.
+type UserCollector struct {
+	minUID   uint32
+	names    map[uint32]string // UID -> name in metric names
+	last     map[int]procStat
+	lastTime time.Time
+	mu       sync.Mutex
+}
+
+// NewUserCollector creates a collector reporting the users with a UID of at
+// least minUID, e.g. 1000 to leave out system users.
+func NewUserCollector(minUID uint32) *UserCollector {
+	return &UserCollector{minUID: minUID, names: make(map[uint32]string)}
+}
+
+func (uc *UserCollector) Name() string {
+	return "users"
+}
+
+// Collect reads every process and returns the usage of every user.
+func (uc *UserCollector) Collect() (CollectedMetrics, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	current, err := readProcStats(procDir)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	elapsed := now.Sub(uc.lastTime).Seconds()
+	pageSize := uint64(os.Getpagesize())
+
+	cpu := make(map[uint32]uint64) // UID -> CPU ticks since the previous call
+	rss := make(map[uint32]uint64)
+	for uid := range uc.names {
+		rss[uid] = 0
+	}
+	for pid, st := range current {
+		if st.uid < uc.minUID {
+			continue
+		}
+		rss[st.uid] += st.rssPages * pageSize
+		prev, ok := uc.last[pid]
+		switch {
+		case uc.last == nil: // No CPU use on the first call
+		case !ok || prev.uid != st.uid || st.cpuTicks < prev.cpuTicks:
+			cpu[st.uid] += st.cpuTicks // Started since the previous call
+		default:
+			cpu[st.uid] += st.cpuTicks - prev.cpuTicks
+		}
+	}
+
+	metrics := make(CollectedMetrics, 2*len(rss))
+	for uid, total := range rss {
+		prefix := "user_" + uc.userName(uid)
+		percent := 0.0
+		if uc.last != nil && elapsed > 0.1 {
+			percent = float64(cpu[uid]) / userHZ / elapsed * 100
+		}
+		metrics[prefix+"_cpu_percent"] = percent
+		metrics[prefix+"_rss_bytes"] = float64(total)
+	}
+	uc.last, uc.lastTime = current, now
+	return metrics, nil
+}
+
+// userName returns the name of a user in metric names, registering the
+// metadata of its metrics the first time it is seen.
+func (uc *UserCollector) userName(uid uint32) string {
+	if name, ok := uc.names[uid]; ok {
+		return name
+	}
+	name, ok := lookupUsername(uid)
+	if name = sanitizeMetricName(name); !ok || name == "" {
+		name = strconv.FormatUint(uint64(uid), 10)
+	}
+	uc.names[uid] = name
+	prefix := "user_" + name
+	if _, known := metricsmeta.Lookup(prefix + "_cpu_percent"); !known {
+		metricsmeta.Register(metricsmeta.Metadata{
+			Name:        prefix + "_cpu_percent",
+			Unit:        metricsmeta.UnitPercent,
+			Type:        metricsmeta.TypeGauge,
+			Description: "CPU use of the processes of " + name + ", of one CPU",
+		})
+		metricsmeta.Register(metricsmeta.Metadata{
+			Name:        prefix + "_rss_bytes",
+			Unit:        metricsmeta.UnitBytes,
+			Type:        metricsmeta.TypeGauge,
+			Description: "Resident memory of the processes of " + name,
+		})
+	}
+	return name
+}
@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCollector(t *testing.T) {
+	oldDir, oldLookup := procDir, lookupUsername
+	procDir = t.TempDir()
+	t.Cleanup(func() { procDir, lookupUsername = oldDir, oldLookup })
+	// The test can only create processes owned by itself
+	uid := uint32(os.Getuid())
+	lookupUsername = func(u uint32) (string, bool) {
+		return "alice.smith", u == uid
+	}
+	writeStat := func(pid int, ticks, rss int) {
+		dir := filepath.Join(procDir, fmt.Sprint(pid))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		line := fmt.Sprintf("%d (python3) R 1 1 1 0 -1 0 0 0 0 0 %d 0 0 0 20 0 1 0 100 1000 %d 0\n", pid, ticks, rss)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(line), 0644))
+	}
+	page := float64(os.Getpagesize())
+
+	writeStat(100, 1000, 100)
+	writeStat(101, 50, 20)
+	uc := NewUserCollector(0)
+	metrics, err := uc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, CollectedMetrics{
+		"user_alice_smith_cpu_percent": 0,
+		"user_alice_smith_rss_bytes":   120 * page,
+	}, metrics, "no CPU use on the first call")
+
+	// 150 ticks (1.5s) in 10s: 100 more for pid 100, 50 for the new pid 102
+	uc.lastTime = time.Now().Add(-10 * time.Second)
+	require.NoError(t, os.RemoveAll(filepath.Join(procDir, "101")))
+	writeStat(100, 1100, 100)
+	writeStat(102, 50, 30)
+	metrics, err = uc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 15, metrics["user_alice_smith_cpu_percent"], 0.01)
+	assert.Equal(t, 130*page, metrics["user_alice_smith_rss_bytes"])
+
+	require.NoError(t, os.RemoveAll(filepath.Join(procDir, "100")))
+	require.NoError(t, os.RemoveAll(filepath.Join(procDir, "102")))
+	metrics, err = uc.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, metrics["user_alice_smith_rss_bytes"], "reported until restart once seen")
+
+	metrics, err = NewUserCollector(uid + 1).Collect()
+	require.NoError(t, err)
+	assert.Empty(t, metrics, "below min_uid")
+}
@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// wireGuardTimeout bounds a run of wg.
+const wireGuardTimeout = 10 * time.Second
+
+// WireGuardPeer is a peer of a WireGuard interface, as listed by
+// `wg show all dump`.
+type WireGuardPeer struct {
+	Interface     string
+	PublicKey     string
+	LastHandshake time.Time // Zero if there was none yet
+	RxBytes       uint64
+	TxBytes       uint64
+}
+
+// WireGuardCollector reports, for every peer of the WireGuard interfaces:
+//
+//	wg_<peer>_last_handshake_seconds  time since the last handshake
+//	wg_<peer>_rx_bytes_ps             traffic received from the peer
+//	wg_<peer>_tx_bytes_ps             traffic sent to the peer
+//
+// An active tunnel renews its handshake every 2 minutes, so a handshake
+// older than about 3 minutes means the tunnel is down. A peer without any
+// handshake reports the time since monres first saw it. Peers are named by
+// their configured name, or by interface and the start of their public key.
+// Reading the peers needs CAP_NET_ADMIN.
+type WireGuardCollector struct {
+	names     map[string]string // Public key -> name
+	firstSeen map[string]time.Time
+	last      map[string]WireGuardPeer
+	lastTime  time.Time
+	mu        sync.Mutex
+}
+
+// NewWireGuardCollector creates a collector naming the peers with the given
+// public keys by names (name -> public key).
+func NewWireGuardCollector(names map[string]string) *WireGuardCollector {
+	wc := &WireGuardCollector{
+		names:     make(map[string]string, len(names)),
+		firstSeen: make(map[string]time.Time),
+	}
+	for name, key := range names {
+		wc.names[key] = sanitizeMetricName(name)
+	}
+	return wc
+}
+
+func (wc *WireGuardCollector) Name() string {
+	return "wireguard"
+}
+
+// Collect lists the peers and returns their metrics. Transfer rates are
+// measured against the previous call; the first call reports 0.
+func (wc *WireGuardCollector) Collect() (CollectedMetrics, error) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), wireGuardTimeout)
+	defer cancel()
+	out, err := runCommand(ctx, "wg", "show", "all", "dump")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run wg: %w", err)
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(wc.lastTime).Seconds()
+	current := make(map[string]WireGuardPeer)
+	metrics := make(CollectedMetrics)
+	for _, peer := range parseWireGuardDump(out) {
+		current[peer.PublicKey] = peer
+		since := peer.LastHandshake
+		if since.IsZero() {
+			if _, ok := wc.firstSeen[peer.PublicKey]; !ok {
+				wc.firstSeen[peer.PublicKey] = now
+			}
+			since = wc.firstSeen[peer.PublicKey]
+		}
+		rx, tx := 0.0, 0.0
+		if last, ok := wc.last[peer.PublicKey]; ok && elapsed > 0.1 && peer.RxBytes >= last.RxBytes && peer.TxBytes >= last.TxBytes {
+			rx = float64(peer.RxBytes-last.RxBytes) / elapsed
+			tx = float64(peer.TxBytes-last.TxBytes) / elapsed
+		}
+		prefix := "wg_" + wc.peerName(peer)
+		metrics[prefix+"_last_handshake_seconds"] = max(now.Sub(since).Seconds(), 0)
+		metrics[prefix+"_rx_bytes_ps"] = rx
+		metrics[prefix+"_tx_bytes_ps"] = tx
+		registerWireGuardMetrics(prefix)
+	}
+	wc.last, wc.lastTime = current, now
+	return metrics, nil
+}
+
+// peerName returns the name of a peer in metric names: its configured name,
+// or its interface and the first 8 characters of its public key.
+func (wc *WireGuardCollector) peerName(peer WireGuardPeer) string {
+	if name, ok := wc.names[peer.PublicKey]; ok {
+		return name
+	}
+	key := peer.PublicKey
+	if len(key) > 8 {
+		key = key[:8]
+	}
+	return sanitizeMetricName(peer.Interface + "_" + key)
+}
+
+// registerWireGuardMetrics registers the metadata of the metrics of a peer
+// the first time it is seen.
+func registerWireGuardMetrics(prefix string) {
+	if _, known := metricsmeta.Lookup(prefix + "_last_handshake_seconds"); known {
+		return
+	}
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_last_handshake_seconds",
+		Unit:        metricsmeta.UnitSeconds,
+		Type:        metricsmeta.TypeGauge,
+		Description: "Time since the last WireGuard handshake with the peer",
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_rx_bytes_ps",
+		Unit:        metricsmeta.UnitBytesPerSecond,
+		Type:        metricsmeta.TypeRate,
+		Description: "WireGuard traffic received from the peer",
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_tx_bytes_ps",
+		Unit:        metricsmeta.UnitBytesPerSecond,
+		Type:        metricsmeta.TypeRate,
+		Description: "WireGuard traffic sent to the peer",
+	})
+}
+
+// parseWireGuardDump parses the output of `wg show all dump`: a line per
+// interface (interface, private key, public key, listen port, fwmark)
+// followed by a line per peer:
+//
+//	interface public-key preshared-key endpoint allowed-ips latest-handshake transfer-rx transfer-tx persistent-keepalive
+func parseWireGuardDump(data []byte) []WireGuardPeer {
+	var peers []WireGuardPeer
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		fields := bytes.Split(line, []byte("\t"))
+		if len(fields) != 9 {
+			continue // Interface line
+		}
+		handshake, ok1 := parseUintBytes(fields[5])
+		rx, ok2 := parseUintBytes(fields[6])
+		tx, ok3 := parseUintBytes(fields[7])
+		if !ok1 || !ok2 || !ok3 {
+			continue
+		}
+		peer := WireGuardPeer{
+			Interface: string(fields[0]),
+			PublicKey: string(fields[1]),
+			RxBytes:   rx,
+			TxBytes:   tx,
+		}
+		if handshake > 0 {
+			peer.LastHandshake = time.Unix(int64(handshake), 0)
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWireGuardDump(t *testing.T) {
+	peers := parseWireGuardDump(readTestdata(t, "wg_show_dump"))
+	assert.Equal(t, []WireGuardPeer{
+		{Interface: "wg0", PublicKey: "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=", LastHandshake: time.Unix(1791800000, 0), RxBytes: 5242880, TxBytes: 1048576},
+		{Interface: "wg0", PublicKey: "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2Pi+y71lOWWXX0="},
+	}, peers)
+}
+
+func TestWireGuardCollector(t *testing.T) {
+	oldRun := runCommand
+	t.Cleanup(func() { runCommand = oldRun })
+	handshake := time.Now().Add(-30 * time.Second).Unix()
+	rx := 1000
+	runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		assert.Equal(t, []string{"wg", "show", "all", "dump"}, append([]string{name}, args...))
+		return []byte(fmt.Sprintf("wg0\tpriv\tpub\t51820\toff\n"+
+			"wg0\tdbKey+abcdefgh=\t(none)\t203.0.113.7:51820\t10.8.0.2/32\t%d\t%d\t500\t25\n"+
+			"wg0\tab/cdEFGhij=\t(none)\t(none)\t10.8.0.3/32\t0\t0\t0\toff\n", handshake, rx)), nil
+	}
+
+	wc := NewWireGuardCollector(map[string]string{"db-1": "dbKey+abcdefgh="})
+	metrics, err := wc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 30, metrics["wg_db_1_last_handshake_seconds"], 2)
+	assert.Equal(t, 0.0, metrics["wg_db_1_rx_bytes_ps"], "no rates on the first run")
+	assert.InDelta(t, 0, metrics["wg_wg0_ab_cdefg_last_handshake_seconds"], 1, "a peer without handshake counts from when it was first seen")
+	assert.Len(t, metrics, 6)
+
+	wc.lastTime = wc.lastTime.Add(-10 * time.Second)
+	rx = 11000
+	metrics, err = wc.Collect()
+	require.NoError(t, err)
+	assert.InDelta(t, 1000, metrics["wg_db_1_rx_bytes_ps"], 10)
+	assert.Equal(t, 0.0, metrics["wg_db_1_tx_bytes_ps"])
+
+	runCommand = func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("Unable to access interface: Operation not permitted")
+	}
+	_, err = wc.Collect()
+	assert.ErrorContains(t, err, "failed to run wg")
+}
@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	metricsmeta "github.com/mattmezza/monres/internal/metrics"
+)
+
+// zfsTimeout bounds a run of zpool.
+const zfsTimeout = 10 * time.Second
+
+// zpoolStates maps the health of a pool to the value of zpool_<pool>_state.
+// Every other health (FAULTED, UNAVAIL, SUSPENDED, ...) is 2.
+var zpoolStates = map[string]float64{"ONLINE": 0, "DEGRADED": 1}
+
+// ZFSCollector reports, for every imported ZFS pool:
+//
+//	zpool_<pool>_state                  0 online, 1 degraded, 2 faulted or unavailable
+//	zpool_<pool>_scrub_errors           errors found by the last completed scrub
+//	zpool_<pool>_fragmentation_percent  fragmentation of the free space
+//
+// Scrub errors are missing for pools that were never scrubbed and while a
+// scrub runs.
+type ZFSCollector struct {
+	mu sync.Mutex
+}
+
+// NewZFSCollector creates a collector reading the pools with zpool.
+func NewZFSCollector() *ZFSCollector {
+	return &ZFSCollector{}
+}
+
+func (zc *ZFSCollector) Name() string {
+	return "zfs"
+}
+
+// Collect lists the pools and returns their metrics.
+func (zc *ZFSCollector) Collect() (CollectedMetrics, error) {
+	zc.mu.Lock()
+	defer zc.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), zfsTimeout)
+	defer cancel()
+	list, err := runCommand(ctx, "zpool", "list", "-H", "-p", "-o", "name,health,fragmentation")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run zpool list: %w", err)
+	}
+	status, err := runCommand(ctx, "zpool", "status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run zpool status: %w", err)
+	}
+	metrics := make(CollectedMetrics)
+	parseZpoolList(list, metrics)
+	parseZpoolStatus(status, metrics)
+	return metrics, nil
+}
+
+// parseZpoolList parses the output of `zpool list -H -p -o
+// name,health,fragmentation`, adding the state and fragmentation of every
+// pool to metrics. Fragmentation is "-" for pools that don't report it.
+func parseZpoolList(data []byte, metrics CollectedMetrics) {
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		fields := strings.Split(string(line), "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		prefix := "zpool_" + sanitizeMetricName(fields[0])
+		state, ok := zpoolStates[fields[1]]
+		if !ok {
+			state = 2
+		}
+		metrics[prefix+"_state"] = state
+		if frag, ok := parseUintBytes([]byte(strings.TrimSuffix(fields[2], "%"))); ok {
+			metrics[prefix+"_fragmentation_percent"] = float64(frag)
+		}
+		registerZFSMetrics(prefix, fields[0])
+	}
+}
+
+// parseZpoolStatus parses the output of `zpool status`, adding the errors
+// found by the last completed scrub of every pool to metrics:
+//
+//	 pool: tank
+//	state: ONLINE
+//	 scan: scrub repaired 0B in 00:10:22 with 0 errors on Sun Jan 14 00:34:23 2024
+func parseZpoolStatus(data []byte, metrics CollectedMetrics) {
+	var pool string
+	for len(data) > 0 {
+		var line []byte
+		line, data = nextLine(data)
+		key, rest := nextField(line)
+		switch string(key) {
+		case "pool:":
+			name, _ := nextField(rest)
+			pool = string(name)
+		case "scan:":
+			if pool == "" {
+				continue
+			}
+			prefix := "zpool_" + sanitizeMetricName(pool)
+			fields := strings.Fields(string(rest))
+			switch {
+			case len(fields) >= 2 && fields[0] == "none" && fields[1] == "requested":
+				metrics[prefix+"_scrub_errors"] = 0
+			case len(fields) >= 2 && fields[0] == "scrub" && fields[1] == "repaired":
+				// "with <n> errors on"
+				for i := 2; i+2 < len(fields); i++ {
+					if fields[i] == "with" && fields[i+2] == "errors" {
+						if n, ok := parseUintBytes([]byte(fields[i+1])); ok {
+							metrics[prefix+"_scrub_errors"] = float64(n)
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// registerZFSMetrics registers the metadata of the metrics of a pool the
+// first time it is seen.
+func registerZFSMetrics(prefix, pool string) {
+	if _, known := metricsmeta.Lookup(prefix + "_state"); known {
+		return
+	}
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_state",
+		Type:        metricsmeta.TypeGauge,
+		Description: fmt.Sprintf("Health of the ZFS pool %s: 0 online, 1 degraded, 2 faulted or unavailable", pool),
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_scrub_errors",
+		Type:        metricsmeta.TypeGauge,
+		Description: fmt.Sprintf("Errors found by the last scrub of the ZFS pool %s", pool),
+	})
+	metricsmeta.Register(metricsmeta.Metadata{
+		Name:        prefix + "_fragmentation_percent",
+		Unit:        metricsmeta.UnitPercent,
+		Type:        metricsmeta.TypeGauge,
+		Description: fmt.Sprintf("Free space fragmentation of the ZFS pool %s", pool),
+	})
+}
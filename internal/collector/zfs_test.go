@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseZpool(t *testing.T) {
+	metrics := make(CollectedMetrics)
+	parseZpoolList(readTestdata(t, "zpool_list"), metrics)
+	parseZpoolStatus(readTestdata(t, "zpool_status"), metrics)
+	assert.Equal(t, CollectedMetrics{
+		"zpool_tank_state":                   1,
+		"zpool_tank_fragmentation_percent":   12,
+		"zpool_tank_scrub_errors":            3,
+		"zpool_backup_state":                 0,
+		"zpool_backup_fragmentation_percent": 3,
+		"zpool_backup_scrub_errors":          0,
+		"zpool_old_state":                    2,
+		"zpool_old_scrub_errors":             0,
+	}, metrics)
+
+	metrics = make(CollectedMetrics)
+	parseZpoolStatus([]byte("  pool: tank\n state: ONLINE\n  scan: scrub in progress since Sun Jan 14 00:24:01 2024\n"), metrics)
+	assert.Empty(t, metrics, "no errors while a scrub runs")
+}
+
+func TestZFSCollector(t *testing.T) {
+	oldRun := runCommand
+	t.Cleanup(func() { runCommand = oldRun })
+	runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		require.Equal(t, "zpool", name)
+		if args[0] == "list" {
+			return readTestdata(t, "zpool_list"), nil
+		}
+		return readTestdata(t, "zpool_status"), nil
+	}
+	metrics, err := NewZFSCollector().Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, metrics["zpool_tank_state"])
+	assert.Equal(t, 3.0, metrics["zpool_tank_scrub_errors"])
+
+	runCommand = func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("exec: \"zpool\": executable file not found in $PATH")
+	}
+	_, err = NewZFSCollector().Collect()
+	assert.ErrorContains(t, err, "zpool list")
+}
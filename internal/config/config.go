@@ -1,11 +1,24 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/mattmezza/monres/internal/i18n"
+	"github.com/mattmezza/monres/internal/metrics"
+	"github.com/mattmezza/monres/internal/outbound"
 	"github.com/mattmezza/monres/internal/util" // Corrected import path
 	"gopkg.in/yaml.v3"
 )
@@ -14,28 +27,302 @@ type Config struct {
 	IntervalSeconds      int                         `yaml:"interval_seconds"`
 	HostnameOverride     string                      `yaml:"hostname"` // Field for Hostname
 	Alerts               []AlertRuleConfig           `yaml:"alerts"`
+	RulesFiles           []string                    `yaml:"rules_files"` // Files (or glob patterns) with more alerts, relative to this file
 	NotificationChannels []NotificationChannelConfig `yaml:"notification_channels"`
 	Templates            TemplateConfig              `yaml:"templates"`
 	Network              NetworkConfig               `yaml:"network"`
+	Filesystems          FilesystemsConfig           `yaml:"filesystems"`
+	ListenPorts          []int                       `yaml:"listen_ports"` // TCP ports reported as port_listening_<port>
+	Firewall             FirewallConfig              `yaml:"firewall"`
+	WireGuard            WireGuardConfig             `yaml:"wireguard"`
+	ZFS                  ZFSConfig                   `yaml:"zfs"`
+	LVM                  LVMConfig                   `yaml:"lvm"`
+	Libvirt              LibvirtConfig               `yaml:"libvirt"`
+	Kubelet              KubeletConfig               `yaml:"kubelet"`
+	Users                UsersConfig                 `yaml:"users"`
+	Slices               SlicesConfig                `yaml:"slices"`
+	TopProcesses         TopProcessesConfig          `yaml:"top_processes"`
+	Processes            []ProcessConfig             `yaml:"processes"` // Processes watched by name or PID file
+	CertFiles            []CertFileConfig            `yaml:"cert_files"`
+	ACME                 ACMEConfig                  `yaml:"acme"`
+	CronJobs             []CronJobConfig             `yaml:"cron_jobs"` // Jobs reporting heartbeats through the API
+	Textfile             TextfileConfig              `yaml:"textfile"`
+	NagiosChecks         []NagiosCheckConfig         `yaml:"nagios_checks"`
+	Services             []ServiceConfig             `yaml:"services"`
+	Sinks                []SinkConfig                `yaml:"sinks"`
+	Metrics              []MetricConfig              `yaml:"metrics"`
+	Relabel              []RelabelConfig             `yaml:"relabel"`
+	History              HistoryConfig               `yaml:"history"`
+	API                  APIConfig                   `yaml:"api"`
+	NotificationLog      NotificationLogConfig       `yaml:"notification_log"`
+	Dedupe               DedupeConfig                `yaml:"dedupe"`
+	HostGroups           map[string][]string         `yaml:"host_groups"` // group name -> hostname patterns
+	CollectionTimeoutStr string                      `yaml:"collection_timeout"` // e.g. "5s"
+	StartupMode          string                      `yaml:"startup_mode"` // StartupFire (default), StartupWait or StartupAnnounce
+	Proxy                string                      `yaml:"proxy"` // Default proxy of HTTP based channels, see outbound.Options
+	AddressFamily        string                      `yaml:"address_family"` // Default address family of channels: any, prefer_v4 or prefer_v6
+	DNSResolver          string                      `yaml:"dns_resolver"` // Default DNS server (IP[:port]) of channels, empty for the system resolver
+	LogOutput            string                      `yaml:"log_output"` // LogOutputStdout (default) or LogOutputJournald
+	Locale               string                      `yaml:"locale"` // Language of default templates and formatting, e.g. "de"; defaults to "en"
+	DashboardURLTemplate string                      `yaml:"dashboard_url_template"` // Template of a link to the alert's graph added to notifications, e.g. a Grafana URL
 	CollectionInterval   time.Duration               `yaml:"-"` // Derived
+	CollectionTimeout    time.Duration               `yaml:"-"` // Derived, defaults to CollectionInterval
 	EffectiveHostname    string                      `yaml:"-"` // Derived
 }
 
 type AlertRuleConfig struct {
 	Name        string   `yaml:"name"`
 	Metric      string   `yaml:"metric"`
-	Condition   string   `yaml:"condition"`
+	Condition   string   `yaml:"condition"` // ">", "<", ">=", "<=", "==", "!=", optionally with the threshold ("== 0"), or "is_up"/"is_down"
 	Threshold   float64  `yaml:"threshold"`
+	Epsilon     float64  `yaml:"epsilon"` // Tolerance of "==" and "!=", defaults to DefaultEpsilon
+	Severity    string   `yaml:"severity"` // Severity of Threshold, defaults to "critical"
+	Thresholds  map[string]float64 `yaml:"thresholds"` // Tiered thresholds by severity, e.g. {warning: 80, critical: 95}; replaces Threshold
 	DurationStr string   `yaml:"duration"` // e.g., "5m", "300s"
 	Aggregation string   `yaml:"aggregation"` // "average", "max"
+	MinCoverage float64  `yaml:"min_coverage"` // Fraction of the window's expected samples required to evaluate, e.g. 0.8; 0 requires the full window
 	Channels    []string `yaml:"channels"`
+	Overrides   []AlertOverrideConfig `yaml:"overrides"`
+	AutoResolveAfterStr string `yaml:"auto_resolve_after"` // e.g. "10m"; resolves a firing alert whose metric stopped reporting
+	NotifyOnResolve     *bool  `yaml:"notify_on_resolve"` // Send RESOLVED notifications, default true
+	MinimumFiringDurationStr string `yaml:"minimum_firing_duration"` // e.g. "2m"; how long an alert must fire before FIRED is sent
+	StartupMode         string `yaml:"startup_mode"` // Overrides the global startup_mode
+	Enabled             *bool  `yaml:"enabled"` // Set to false to keep the rule in the file without evaluating it
+	TopProcesses        int    `yaml:"top_processes"` // Number of processes by CPU (or memory for mem_/swap_ metrics) listed in FIRED notifications
+	Capture             []string `yaml:"capture"` // Shell commands run on FIRED, e.g. "df -h"; their output is attached to the notification
+	Actions             []AlertActionConfig `yaml:"actions"` // Remediations run while the alert keeps firing
+	Override            *ThresholdOverrideConfig `yaml:"override"` // Temporary thresholds, until OverrideUntilStr
+	OverrideUntilStr    string `yaml:"override_until"` // RFC 3339 timestamp or date (2006-01-02) when Override reverts
+	Entities    map[string]ThresholdOverrideConfig `yaml:"entities"` // Thresholds of single entities of a templated rule, e.g. {var_lib_docker: {threshold: 95}}
+	Exclude     []string `yaml:"exclude"` // Shell-style patterns of entities a templated rule skips, e.g. "boot*"
 	Duration    time.Duration `yaml:"-"` // Parsed
+	AutoResolveAfter time.Duration `yaml:"-"` // Parsed, zero disables
+	MinimumFiringDuration time.Duration `yaml:"-"` // Parsed
+	Tiers       []ThresholdTier `yaml:"-"` // Derived from Thresholds or Threshold, least severe first
+	OverrideUntil time.Time `yaml:"-"` // When Tiers revert to BaseTiers, zero without an active override
+	BaseTiers   []ThresholdTier `yaml:"-"` // Tiers without the temporary override
+	EntityTiers map[string][]ThresholdTier `yaml:"-"` // Derived from Entities
+	Template    string `yaml:"-"` // Name of the templated rule this rule was instantiated from, if any
+	Entity      string `yaml:"-"` // The entity it was instantiated for
+}
+
+// SendsResolved reports whether the rule sends RESOLVED notifications.
+func (rule AlertRuleConfig) SendsResolved() bool {
+	return rule.NotifyOnResolve == nil || *rule.NotifyOnResolve
+}
+
+// IsEnabled reports whether the rule is evaluated, true unless enabled is false.
+func (rule AlertRuleConfig) IsEnabled() bool {
+	return rule.Enabled == nil || *rule.Enabled
+}
+
+// IsTemplate reports whether the rule is a template: its metric has a "*"
+// standing for an entity, such as a mount point, an interface or a
+// container, and the rule is instantiated for every entity discovered.
+func (rule AlertRuleConfig) IsTemplate() bool {
+	return strings.Contains(rule.Metric, "*")
+}
+
+// MatchEntity returns the entity of a templated rule that metric belongs to,
+// e.g. "var_lib_docker" for disk_percent_used_var_lib_docker and the metric
+// disk_percent_used_*. Entities matching an exclude pattern don't match.
+func (rule AlertRuleConfig) MatchEntity(metric string) (string, bool) {
+	prefix, suffix, ok := strings.Cut(rule.Metric, "*")
+	if !ok || len(metric) <= len(prefix)+len(suffix) || !strings.HasPrefix(metric, prefix) || !strings.HasSuffix(metric, suffix) {
+		return "", false
+	}
+	entity := metric[len(prefix) : len(metric)-len(suffix)]
+	for _, pattern := range rule.Exclude {
+		if excluded, _ := path.Match(pattern, entity); excluded {
+			return "", false
+		}
+	}
+	return entity, true
+}
+
+// InstanceName returns the name of the rule instantiated from a templated
+// rule for entity, e.g. "Disk Almost Full (var_lib_docker)".
+func (rule AlertRuleConfig) InstanceName(entity string) string {
+	return rule.Name + " (" + entity + ")"
+}
+
+// Instantiate returns the rule of a templated rule for one entity, with the
+// entity's thresholds if it has any. These replace a temporary override too.
+func (rule AlertRuleConfig) Instantiate(entity string) AlertRuleConfig {
+	instance := rule
+	instance.Name = rule.InstanceName(entity)
+	instance.Metric = strings.Replace(rule.Metric, "*", entity, 1)
+	instance.Template, instance.Entity = rule.Name, entity
+	if tiers, ok := rule.EntityTiers[entity]; ok {
+		instance.Tiers = tiers
+		top := tiers[len(tiers)-1]
+		instance.Threshold, instance.Severity = top.Threshold, top.Severity
+		instance.BaseTiers, instance.OverrideUntil = nil, time.Time{}
+	}
+	return instance
+}
+
+// RevertOverride restores the thresholds replaced by a temporary override
+// once it has expired, reporting whether it did.
+func (rule *AlertRuleConfig) RevertOverride(now time.Time) bool {
+	if rule.OverrideUntil.IsZero() || now.Before(rule.OverrideUntil) {
+		return false
+	}
+	rule.Tiers = rule.BaseTiers
+	top := rule.Tiers[len(rule.Tiers)-1]
+	rule.Threshold, rule.Severity = top.Threshold, top.Severity
+	rule.BaseTiers, rule.OverrideUntil = nil, time.Time{}
+	return true
+}
+
+// AlertActionConfig is a remediation run once an alert has been firing for
+// After, at most once per firing. Its result is sent to the rule's channels.
+type AlertActionConfig struct {
+	// Name identifies the action in notifications, defaults to the command
+	Name string `yaml:"name"`
+	// Command is the program and its arguments, run without a shell
+	Command []string `yaml:"command"`
+	// RestartUnit is a shorthand for command: ["systemctl", "restart", <unit>]
+	RestartUnit string `yaml:"restart_unit"`
+	// AfterStr is how long the alert must fire before the action runs (e.g. "5m"); empty runs it right away
+	AfterStr string `yaml:"after"`
+	// TimeoutStr bounds a run (e.g. "30s"); the action is killed afterwards
+	TimeoutStr string `yaml:"timeout"`
+	// MaxPerHour limits the runs in any hour, so a flapping alert can't restart a service in a loop
+	MaxPerHour int           `yaml:"max_per_hour"`
+	After      time.Duration `yaml:"-"` // Parsed
+	Timeout    time.Duration `yaml:"-"` // Parsed, defaults to DefaultActionTimeout
+}
+
+// Defaults of alert actions.
+const (
+	DefaultActionTimeout    = time.Minute
+	DefaultActionMaxPerHour = 1
+)
+
+// ThresholdOverrideConfig temporarily replaces the thresholds of an alert rule.
+type ThresholdOverrideConfig struct {
+	Threshold  *float64           `yaml:"threshold"`
+	Thresholds map[string]float64 `yaml:"thresholds"`
+}
+
+// Where the monitor writes its log.
+const (
+	LogOutputStdout   = "stdout"   // Plain lines, captured by systemd or a terminal
+	LogOutputJournald = "journald" // Native journal entries with priorities and alert fields
+)
+
+// What to do about alerts whose condition already holds when monres starts,
+// i.e. at a rule's first evaluation.
+const (
+	StartupFire     = "fire"     // Notify right away, like any other alert
+	StartupWait     = "wait"     // Notify only if the alert still fires one full window later
+	StartupAnnounce = "announce" // Notify right away, flagged as already firing at startup
+)
+
+// Alert severities, least severe first.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the alert severities, least severe first.
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// ThresholdTier is one threshold of an alert rule and the severity it fires with.
+type ThresholdTier struct {
+	Severity  string
+	Threshold float64
+}
+
+// AlertOverrideConfig replaces the threshold and/or duration of an alert rule
+// on hosts matching a hostname pattern or belonging to a host group.
+// The first matching override wins.
+type AlertOverrideConfig struct {
+	Host        string   `yaml:"host"`  // Shell-style pattern, e.g. "db-*"
+	Group       string   `yaml:"group"` // Name of an entry in host_groups
+	Threshold   *float64 `yaml:"threshold"`
+	Thresholds  map[string]float64 `yaml:"thresholds"`
+	DurationStr string   `yaml:"duration"`
 }
 
 type NotificationChannelConfig struct {
 	Name   string                 `yaml:"name"`
-	Type   string                 `yaml:"type"` // "email", "telegram"
+	Type   string                 `yaml:"type"` // "email", "telegram", "stdout", "webhook", "issue", "voice", or an OnCallServices type
 	Config map[string]interface{} `yaml:"config"`
+	// NotifyOnResolve controls whether RESOLVED notifications go to this channel, default true
+	NotifyOnResolve *bool `yaml:"notify_on_resolve"`
+	// MinSeverity skips the notifications of alerts less severe than it, e.g.
+	// "critical" for a channel reserved to the highest escalation level
+	MinSeverity string `yaml:"min_severity"`
+	// TimeoutStr bounds how long a send may block delivery, e.g. "10s"
+	TimeoutStr string `yaml:"timeout"`
+	// CircuitBreaker skips the channel for a while after repeated failures
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuit_breaker"`
+	// Fallback lists channels notified instead when a send fails or is skipped by the circuit breaker
+	Fallback []string      `yaml:"fallback"`
+	// Enabled set to false keeps the channel in the file without sending to it (fallbacks are notified instead)
+	Enabled *bool `yaml:"enabled"`
+	// Proxy for HTTP based channels: a proxy URL, "direct", or empty for the global proxy
+	// (which defaults to HTTPS_PROXY/HTTP_PROXY from the environment)
+	Proxy    string        `yaml:"proxy"`
+	// TLS customizes certificate verification for the channel's connections
+	TLS      *TLSConfig    `yaml:"tls"`
+	// AddressFamily tried first for dual-stack hosts, empty for the global address_family
+	AddressFamily string `yaml:"address_family"`
+	// DNSResolver (IP[:port]) used instead of the system resolver, empty for the global dns_resolver
+	DNSResolver string `yaml:"dns_resolver"`
+	// HealthCheckStr is the interval of a silent check that the channel still accepts
+	// messages (e.g. "5m"), reported as channel_healthy_<name>; empty disables it
+	HealthCheckStr string `yaml:"health_check"`
+	Timeout  time.Duration `yaml:"-"` // Parsed, defaults to DefaultChannelTimeout
+	HealthCheck time.Duration `yaml:"-"` // Parsed from HealthCheckStr
+}
+
+// TLSConfig holds a channel's TLS settings, e.g. to trust an internal CA
+// instead of disabling verification.
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	MinVersion         string `yaml:"min_version"` // "1.0" to "1.3"
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// TLSOptions returns the channel's TLS settings for the outbound package.
+func (nc NotificationChannelConfig) TLSOptions() outbound.TLSOptions {
+	if nc.TLS == nil {
+		return outbound.TLSOptions{}
+	}
+	return outbound.TLSOptions{
+		CAFile:             nc.TLS.CAFile,
+		CertFile:           nc.TLS.CertFile,
+		KeyFile:            nc.TLS.KeyFile,
+		MinVersion:         nc.TLS.MinVersion,
+		InsecureSkipVerify: nc.TLS.InsecureSkipVerify,
+	}
+}
+
+// DefaultChannelTimeout bounds notification sends of channels without a timeout.
+const DefaultChannelTimeout = 30 * time.Second
+
+// CircuitBreakerConfig opens a channel's circuit after Failures consecutive
+// failed sends; the channel is skipped until Cooldown has passed.
+type CircuitBreakerConfig struct {
+	Failures    int           `yaml:"failures"` // Default 3
+	CooldownStr string        `yaml:"cooldown"` // e.g. "5m", default 5m
+	Cooldown    time.Duration `yaml:"-"`        // Parsed
+}
+
+// IsEnabled reports whether notifications are sent to the channel, true unless enabled is false.
+func (nc NotificationChannelConfig) IsEnabled() bool {
+	return nc.Enabled == nil || *nc.Enabled
+}
+
+// SendsResolved reports whether RESOLVED notifications go to the channel.
+func (nc NotificationChannelConfig) SendsResolved() bool {
+	return nc.NotifyOnResolve == nil || *nc.NotifyOnResolve
 }
 
 type EmailChannelConfig struct {
@@ -46,13 +333,152 @@ type EmailChannelConfig struct {
 	SMTPFrom     string   `yaml:"smtp_from"`
 	SMTPTo       []string `yaml:"smtp_to"`
 	SMTPUseTLS   bool     `yaml:"smtp_use_tls"`
+	TLS          outbound.TLSOptions `yaml:"-"` // From the channel's tls setting
+	AddressFamily string `yaml:"-"` // From the channel's address_family setting
+	DNSResolver   string `yaml:"-"` // From the channel's dns_resolver setting
 }
 
 type TelegramChannelConfig struct {
 	BotToken string `yaml:"bot_token"` // Will be populated from ENV
 	ChatID   string `yaml:"chat_id"`
+	Threading string `yaml:"threading"` // TelegramThreadingReply (default), TelegramThreadingEdit or TelegramThreadingOff
+	QuickResolve string `yaml:"quick_resolve"` // TelegramQuickResolveDelete or TelegramQuickResolveCollapse; empty keeps the messages
+	QuickResolveMaxAgeStr string `yaml:"quick_resolve_max_age"` // e.g. "5m"
+	QuickResolveMaxAge time.Duration `yaml:"-"` // Parsed, defaults to DefaultQuickResolveMaxAge
+	Proxy    string `yaml:"-"` // From the channel's proxy setting
+	TLS      outbound.TLSOptions `yaml:"-"` // From the channel's tls setting
+	AddressFamily string `yaml:"-"` // From the channel's address_family setting
+	DNSResolver   string `yaml:"-"` // From the channel's dns_resolver setting
+}
+
+// How Telegram channels relate the later messages of an alert to its FIRED message.
+const (
+	TelegramThreadingReply = "reply" // Reply to the FIRED message
+	TelegramThreadingEdit  = "edit"  // Replace the FIRED message with the RESOLVED one, reply with the others
+	TelegramThreadingOff   = "off"   // Send unrelated messages
+)
+
+// What Telegram channels do with the messages of an alert resolving within
+// quick_resolve_max_age, instead of sending the RESOLVED message.
+const (
+	TelegramQuickResolveDelete   = "delete"   // Delete the FIRED message and the replies to it
+	TelegramQuickResolveCollapse = "collapse" // Replace the FIRED message with a line saying it resolved
+
+	DefaultQuickResolveMaxAge = 5 * time.Minute
+)
+
+// WebhookChannelConfig holds the settings of a webhook channel, which POSTs
+// every notification as JSON.
+type WebhookChannelConfig struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"` // Added to every request
+	// Secret signs requests with HMAC-SHA256 when set. Populated from ENV.
+	Secret        string              `yaml:"secret"`
+	Proxy         string              `yaml:"-"` // From the channel's proxy setting
+	TLS           outbound.TLSOptions `yaml:"-"` // From the channel's tls setting
+	AddressFamily string              `yaml:"-"` // From the channel's address_family setting
+	DNSResolver   string              `yaml:"-"` // From the channel's dns_resolver setting
+}
+
+// IssueChannelConfig holds the settings of an issue channel, which opens an
+// issue on GitHub or Jira when an alert fires and comments on and closes it
+// when the alert resolves.
+type IssueChannelConfig struct {
+	Provider   string   `yaml:"provider"`   // IssueProviderGitHub or IssueProviderJira
+	URL        string   `yaml:"url"`        // API of GitHub (defaults to DefaultGitHubAPIURL) or the Jira site
+	Repository string   `yaml:"repository"` // GitHub: "owner/repo"
+	Project    string   `yaml:"project"`    // Jira: project key, e.g. "OPS"
+	IssueType  string   `yaml:"issue_type"` // Jira: type of the issues created, defaults to DefaultJiraIssueType
+	User       string   `yaml:"user"`       // Jira Cloud: account email; empty sends Token as a personal access token
+	Labels     []string `yaml:"labels"`     // Added to the labels derived from the alert
+	// CloseOnResolve closes the issue when the alert resolves; otherwise
+	// the RESOLVED notification is only added as a comment. Defaults to true.
+	CloseOnResolve  bool   `yaml:"close_on_resolve"`
+	CloseTransition string `yaml:"close_transition"` // Jira: workflow transition closing issues, defaults to DefaultJiraCloseTransition
+	// Token authenticates with the API. Populated from ENV.
+	Token         string              `yaml:"token"`
+	Proxy         string              `yaml:"-"` // From the channel's proxy setting
+	TLS           outbound.TLSOptions `yaml:"-"` // From the channel's tls setting
+	AddressFamily string              `yaml:"-"` // From the channel's address_family setting
+	DNSResolver   string              `yaml:"-"` // From the channel's dns_resolver setting
+}
+
+// VoiceChannelConfig holds the settings of a voice channel, which phones
+// the alert's name and host, spoken by text to speech.
+type VoiceChannelConfig struct {
+	Provider   string   `yaml:"provider"`    // VoiceProviderTwilio or VoiceProviderCallMeBot
+	URL        string   `yaml:"url"`         // API of the provider, defaults to DefaultTwilioAPIURL or DefaultCallMeBotURL
+	AccountSID string   `yaml:"account_sid"` // Twilio: account placing the calls
+	From       string   `yaml:"from"`        // Twilio: caller ID, a number of the account
+	To         []string `yaml:"to"`          // Phone numbers (Twilio) or Telegram usernames (CallMeBot) called in turn
+	Language   string   `yaml:"language"`    // Text to speech language, e.g. "en-GB"; the provider's default if empty
+	// Token is the Twilio auth token. Populated from ENV.
+	Token         string              `yaml:"token"`
+	Proxy         string              `yaml:"-"` // From the channel's proxy setting
+	TLS           outbound.TLSOptions `yaml:"-"` // From the channel's tls setting
+	AddressFamily string              `yaml:"-"` // From the channel's address_family setting
+	DNSResolver   string              `yaml:"-"` // From the channel's dns_resolver setting
+}
+
+// Providers of voice channels, and their APIs.
+const (
+	VoiceProviderTwilio    = "twilio"
+	VoiceProviderCallMeBot = "callmebot"
+
+	DefaultTwilioAPIURL = "https://api.twilio.com"
+	DefaultCallMeBotURL = "https://api.callmebot.com"
+)
+
+// Issue trackers of issue channels, and their defaults.
+const (
+	IssueProviderGitHub = "github"
+	IssueProviderJira   = "jira"
+
+	DefaultGitHubAPIURL        = "https://api.github.com"
+	DefaultJiraIssueType       = "Task"
+	DefaultJiraCloseTransition = "Done"
+)
+
+// OnCallChannelConfig holds the settings of a channel of an on-call router,
+// which triggers an incident when an alert fires and resolves it when the
+// alert resolves.
+type OnCallChannelConfig struct {
+	Service string `yaml:"-"` // The channel type, one of OnCallServices
+	// URL is the integration endpoint, which holds its secret key. From
+	// ENV when set there.
+	URL           string              `yaml:"url"`
+	Proxy         string              `yaml:"-"` // From the channel's proxy setting
+	TLS           outbound.TLSOptions `yaml:"-"` // From the channel's tls setting
+	AddressFamily string              `yaml:"-"` // From the channel's address_family setting
+	DNSResolver   string              `yaml:"-"` // From the channel's dns_resolver setting
+}
+
+// Channel types of on-call routers, whose generic alert endpoints take
+// JSON events with fire/resolve semantics.
+const (
+	OnCallGrafana   = "grafana_oncall" // Grafana OnCall formatted webhook integration
+	OnCallSquadcast = "squadcast"      // Squadcast incident webhook
+	OnCallZenduty   = "zenduty"        // Zenduty generic integration
+)
+
+// OnCallServices are the on-call router channel types.
+var OnCallServices = []string{OnCallGrafana, OnCallSquadcast, OnCallZenduty}
+
+// StdoutChannelConfig holds the output settings of a stdout channel.
+type StdoutChannelConfig struct {
+	Format string `yaml:"format"` // StdoutFormatText (default) or StdoutFormatJSON
+	Color  string `yaml:"color"`  // StdoutColorAuto (default), StdoutColorAlways or StdoutColorNever
 }
 
+// Output formats and color modes of stdout channels.
+const (
+	StdoutFormatText  = "text"   // The rendered template
+	StdoutFormatJSON  = "json"   // One JSON object per event
+	StdoutColorAuto   = "auto"   // Colorize when stdout is a terminal
+	StdoutColorAlways = "always"
+	StdoutColorNever  = "never"
+)
+
 type TemplateConfig struct {
 	AlertFired    string `yaml:"alert_fired"`
 	AlertResolved string `yaml:"alert_resolved"`
@@ -68,6 +494,358 @@ type NetworkConfig struct {
 	ExcludePrefixes []string `yaml:"exclude_prefixes"`
 }
 
+// FilesystemsConfig configures the mount metrics of the filesystem collector.
+type FilesystemsConfig struct {
+	// ExpectedMounts are reported as mount_present_<mount>. Empty expects
+	// every disk or network filesystem seen mounted since startup.
+	ExpectedMounts []string `yaml:"expected_mounts"`
+	// Include are the mount points (shell-style patterns, e.g. "/mnt/*")
+	// reported as disk_percent_used_<mount> and disk_bytes_free_<mount>.
+	// Empty reports every local disk filesystem.
+	Include []string `yaml:"include"`
+	// Exclude are mount point patterns left out of the disk space metrics
+	Exclude []string `yaml:"exclude"`
+}
+
+// FirewallConfig configures the firewall counter collector.
+type FirewallConfig struct {
+	// Backend is "nftables" (read with nft) or "iptables" (read with
+	// iptables-save). The collector is disabled when empty.
+	Backend string `yaml:"backend"`
+	// Counters are the nftables named counters and rule comments to report;
+	// empty reports every one.
+	Counters []string `yaml:"counters"`
+}
+
+// WireGuardConfig configures the WireGuard peer collector.
+type WireGuardConfig struct {
+	// Enabled turns on the collector, which reads `wg show all dump`.
+	Enabled bool `yaml:"enabled"`
+	// Peers names peers in metric names (name -> public key). Other peers are
+	// named by interface and the start of their public key.
+	Peers map[string]string `yaml:"peers"`
+}
+
+// ZFSConfig configures the ZFS pool collector.
+type ZFSConfig struct {
+	// Enabled turns on the collector, which reads `zpool list` and `zpool status`.
+	Enabled bool `yaml:"enabled"`
+}
+
+// LVMConfig configures the LVM thin pool collector.
+type LVMConfig struct {
+	// Enabled turns on the collector, which reads `lvs`.
+	Enabled bool `yaml:"enabled"`
+}
+
+// LibvirtConfig configures the libvirt guest collector.
+type LibvirtConfig struct {
+	// Enabled turns on the collector, which reads `virsh domstats`.
+	Enabled bool `yaml:"enabled"`
+	// URI is the libvirt connection, e.g. "qemu:///system"; virsh's default if empty
+	URI string `yaml:"uri"`
+}
+
+// UsersConfig configures the collector of the resource usage per user.
+type UsersConfig struct {
+	// Enabled turns on the collector, which reads every process on each cycle.
+	Enabled bool `yaml:"enabled"`
+	// MinUID leaves out the users with a lower UID, e.g. 1000 for the
+	// system users of most distributions
+	MinUID uint32 `yaml:"min_uid"`
+}
+
+// TopProcessesConfig configures the collector of the busiest processes.
+type TopProcessesConfig struct {
+	// Count is the number of processes reported by CPU and by memory; the
+	// collector, which reads every process on each cycle, is off if 0
+	Count int `yaml:"count"`
+}
+
+// ProcessConfig is a process, or group of processes, whose presence and
+// usage is reported. It is found by Pattern or by PIDFile, not both.
+type ProcessConfig struct {
+	// Name is used in the process's metric names (process_<name>_running);
+	// lowercase letters, digits and '_'
+	Name string `yaml:"name"`
+	// Pattern is a regular expression matched against the name and the
+	// command line of every process, e.g. "^postgres"
+	Pattern string `yaml:"pattern"`
+	// PIDFile holds the PID of the process, e.g. "/run/nginx.pid"
+	PIDFile string         `yaml:"pidfile"`
+	Regex   *regexp.Regexp `yaml:"-"` // Compiled Pattern
+}
+
+// SlicesConfig configures the collector of the usage of systemd slices and
+// other cgroups.
+type SlicesConfig struct {
+	// Enabled turns on the collector, which reads the cgroup v2 hierarchy.
+	Enabled bool `yaml:"enabled"`
+	// Paths lists the cgroups to report, relative to /sys/fs/cgroup (e.g.
+	// "user.slice" or "system.slice/nginx.service"); every slice if empty
+	Paths []string `yaml:"paths"`
+}
+
+// KubeletConfig configures the collector of the pods of a Kubernetes node.
+type KubeletConfig struct {
+	// Address is the kubelet's URL, e.g. "https://127.0.0.1:10250". The
+	// collector is disabled when empty.
+	Address string `yaml:"address"`
+	// TokenFile holds the bearer token authenticating to the kubelet, e.g.
+	// of a service account allowed to get nodes/stats
+	TokenFile string `yaml:"token_file"`
+	// TLS customizes verification of the kubelet's certificate, which is
+	// often self-signed
+	TLS *TLSConfig `yaml:"tls"`
+}
+
+// TLSOptions returns the kubelet's TLS settings for the outbound package.
+func (kc KubeletConfig) TLSOptions() outbound.TLSOptions {
+	return NotificationChannelConfig{TLS: kc.TLS}.TLSOptions()
+}
+
+// CertFileConfig is a PEM certificate file whose expiry is reported.
+type CertFileConfig struct {
+	// Name is used in the metric name (cert_days_left_<name>)
+	Name string `yaml:"name"`
+	// Path is the PEM file: a certificate, a chain or a CA bundle
+	Path string `yaml:"path"`
+}
+
+// ACMEConfig configures the collector of the age of ACME certificates.
+type ACMEConfig struct {
+	// Directories hold a subdirectory per certificate, like certbot's
+	// /etc/letsencrypt/live or acme.sh's home. The collector is disabled
+	// when empty.
+	Directories []string `yaml:"directories"`
+}
+
+// CronJobConfig is a scheduled job that reports its successful runs to
+// the API at /api/v1/heartbeat/<name>.
+type CronJobConfig struct {
+	// Name is used in the heartbeat URL and the job's metric names
+	// (cron_<name>_seconds_since_success); letters, digits, '-' and '_'
+	Name string `yaml:"name"`
+	// PeriodStr is the expected time between two successful runs (e.g. "24h")
+	PeriodStr string `yaml:"period"`
+	// GraceStr is how late a run may report before the job is overdue (e.g. "30m")
+	GraceStr string        `yaml:"grace"`
+	Period   time.Duration `yaml:"-"` // Parsed
+	Grace    time.Duration `yaml:"-"` // Parsed, defaults to DefaultCronGrace
+}
+
+// DefaultCronGrace is how late a cron job may report by default.
+const DefaultCronGrace = 5 * time.Minute
+
+// TextfileConfig holds configuration for the textfile collector
+type TextfileConfig struct {
+	// Directory is scanned for *.prom files on every collection cycle.
+	// The collector is disabled when empty.
+	Directory string `yaml:"directory"`
+	// MaxAgeStr is the age after which a file's metrics are ignored as stale
+	// (e.g. "1h"). Empty disables staleness handling.
+	MaxAgeStr string        `yaml:"max_age"`
+	MaxAge    time.Duration `yaml:"-"` // Parsed
+}
+
+// NagiosCheckConfig describes a Nagios-style check plugin run by the nagios collector
+type NagiosCheckConfig struct {
+	// Name is used in the check's metric names (check_<name>_status)
+	Name string `yaml:"name"`
+	// Command is the plugin and its arguments, run without a shell
+	Command []string `yaml:"command"`
+	// TimeoutStr bounds a single run (e.g. "10s"); a plugin that times out is UNKNOWN
+	TimeoutStr string `yaml:"timeout"`
+	// IntervalStr is the time between runs (e.g. "1m"); empty runs the check every cycle
+	IntervalStr string        `yaml:"interval"`
+	Timeout     time.Duration `yaml:"-"` // Parsed, defaults to DefaultNagiosTimeout
+	Interval    time.Duration `yaml:"-"` // Parsed
+}
+
+// DefaultNagiosTimeout is the timeout of check plugins, as in Nagios itself.
+const DefaultNagiosTimeout = 10 * time.Second
+
+// ServiceConfig is an application server whose status is collected.
+type ServiceConfig struct {
+	// Name is used in the service's metric names (service_<name>_<metric>)
+	Name string `yaml:"name"`
+	// Type is one of ServiceTypes
+	Type string `yaml:"type"`
+	// Address is where the status is read: the status page URL for php-fpm,
+	// the stats server (host:port, unix socket path or URL) for uwsgi, the
+	// UDP address to receive statsd metrics on for gunicorn, the stub_status
+	// URL for nginx, the server-status URL for apache, the stats socket or
+	// stats page URL for haproxy, the admin API URL for caddy, the
+	// management API URL for rabbitmq, the bootstrap server for kafka, the
+	// REST API URL for elasticsearch and opensearch
+	Address string `yaml:"address"`
+	// TimeoutStr bounds a status read (e.g. "5s")
+	TimeoutStr string        `yaml:"timeout"`
+	Timeout    time.Duration `yaml:"-"` // Parsed, defaults to DefaultServiceTimeout
+	// Username authenticates to the rabbitmq management API or the
+	// elasticsearch and opensearch REST API
+	Username string `yaml:"username"`
+	// Password will be populated from ENV (MONRES_SERVICE_PASSWORD_<NAME>)
+	Password string `yaml:"password"`
+	// Group is the kafka consumer group whose lag is reported
+	Group string `yaml:"group"`
+	// Command is the kafka tool describing consumer groups, by default
+	// kafka-consumer-groups.sh from the PATH
+	Command string `yaml:"command"`
+}
+
+// ServiceTypes lists the supported service types.
+var ServiceTypes = []string{"php-fpm", "uwsgi", "gunicorn", "nginx", "apache", "haproxy", "caddy", "rabbitmq", "kafka", "elasticsearch", "opensearch"}
+
+// DefaultServiceTimeout is the timeout of a service status read.
+const DefaultServiceTimeout = 5 * time.Second
+
+// Sink types
+const (
+	SinkInfluxDB = "influxdb"
+	SinkZabbix   = "zabbix"
+	SinkOTLP     = "otlp"
+)
+
+// OTLPProtocolHTTPJSON is OTLP over HTTP with JSON encoding, the only OTLP protocol supported.
+const OTLPProtocolHTTPJSON = "http/json"
+
+// SinkConfig describes an output that receives the metrics of every
+// collection cycle, e.g. to feed existing dashboards.
+type SinkConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"` // SinkInfluxDB, SinkZabbix or SinkOTLP
+	// Metrics limits the metrics sent to these names; a name ending in "*" is a prefix. Empty sends all.
+	Metrics []string `yaml:"metrics"`
+	// Host identifies this machine: the host tag in InfluxDB, the host name in Zabbix. Defaults to the hostname.
+	Host       string        `yaml:"host"`
+	TimeoutStr string        `yaml:"timeout"` // e.g. "10s"
+	Timeout    time.Duration `yaml:"-"`       // Parsed, defaults to DefaultSinkTimeout
+
+	// InfluxDB v2 and OTLP
+	URL         string `yaml:"url"` // e.g. "https://influx.example.com:8086"
+	Org         string `yaml:"org"`
+	Bucket      string `yaml:"bucket"`
+	Token       string `yaml:"token"`       // Populated from MONRES_INFLUXDB_TOKEN_<SINK_NAME>
+	Measurement string `yaml:"measurement"` // Defaults to "monres"
+
+	// Zabbix trapper items
+	Server    string `yaml:"server"`     // Zabbix server or proxy, host[:port] (port defaults to 10051)
+	KeyPrefix string `yaml:"key_prefix"` // Prepended to metric names to form item keys, defaults to "monres."
+
+	// OpenTelemetry (OTLP)
+	Protocol           string            `yaml:"protocol"`            // OTLPProtocolHTTPJSON (default)
+	Headers            map[string]string `yaml:"headers"`             // Added to every request, e.g. for authentication
+	ResourceAttributes map[string]string `yaml:"resource_attributes"` // In addition to host.name and service.name
+
+	// Connection settings, as for notification channels
+	Proxy         string     `yaml:"proxy"` // HTTP sinks (InfluxDB, OTLP)
+	TLS           *TLSConfig `yaml:"tls"`   // HTTP sinks (InfluxDB, OTLP)
+	AddressFamily string     `yaml:"address_family"`
+	DNSResolver   string     `yaml:"dns_resolver"`
+}
+
+// DefaultSinkTimeout bounds a single write to a sink without a timeout.
+const DefaultSinkTimeout = 10 * time.Second
+
+// TLSOptions returns the sink's TLS settings for the outbound package.
+func (sc SinkConfig) TLSOptions() outbound.TLSOptions {
+	return NotificationChannelConfig{TLS: sc.TLS}.TLSOptions()
+}
+
+// SendsMetric reports whether a metric passes the sink's metrics filter.
+func (sc SinkConfig) SendsMetric(name string) bool {
+	if len(sc.Metrics) == 0 {
+		return true
+	}
+	for _, pattern := range sc.Metrics {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// MetricConfig declares metadata for a metric, typically a custom one
+// (e.g. from the textfile collector), so it is displayed with proper units.
+type MetricConfig struct {
+	// Name is the exact metric name, or a prefix followed by "*" to cover a family
+	Name        string `yaml:"name"`
+	Unit        string `yaml:"unit"` // "percent", "bytes", "bytes_per_second", "seconds", "celsius" or empty
+	Description string `yaml:"description"`
+	Type        string `yaml:"type"` // "gauge", "rate", "counter"
+	// Transform is applied to the metric's values before they enter the history buffer
+	Transform *TransformConfig `yaml:"transform"`
+}
+
+// TransformConfig adjusts metric values after collection. The steps run in
+// the order scale, offset, EWMA smoothing, clamp.
+type TransformConfig struct {
+	Scale   *float64      `yaml:"scale"`  // Multiply by this factor
+	Offset  float64       `yaml:"offset"` // Then add this
+	EWMAStr string        `yaml:"ewma"`   // Smoothing time constant, e.g. "1m"
+	Min     *float64      `yaml:"min"`    // Clamp to at least this
+	Max     *float64      `yaml:"max"`    // Clamp to at most this
+	EWMA    time.Duration `yaml:"-"`      // Parsed EWMAStr
+}
+
+// HistoryConfig controls how long raw samples are kept before they are
+// downsampled into per-resolution aggregates for long alert windows, and how
+// much history is kept beyond the alert windows for exports.
+type HistoryConfig struct {
+	RetentionStr    string        `yaml:"retention"`     // e.g. "24h"
+	RawRetentionStr string        `yaml:"raw_retention"` // e.g. "15m"
+	ResolutionStr   string        `yaml:"resolution"`    // e.g. "1m"
+	Retention       time.Duration `yaml:"-"`             // Parsed, zero means the longest alert window
+	RawRetention    time.Duration `yaml:"-"`             // Parsed, zero means default
+	Resolution      time.Duration `yaml:"-"`             // Parsed, zero means default
+}
+
+// APIConfig holds configuration for the local HTTP API
+type APIConfig struct {
+	// Listen is the address the API listens on, e.g. "127.0.0.1:9600".
+	// The API is disabled when empty.
+	Listen string `yaml:"listen"`
+	// Token enables the write endpoints (silences, acks, reload), which
+	// require it as a bearer token. Read from MONRES_API_TOKEN only.
+	Token string `yaml:"-"`
+	// SlackSigningSecret enables the Slack slash command endpoint, which
+	// checks request signatures with it. Read from MONRES_SLACK_SIGNING_SECRET only.
+	SlackSigningSecret string `yaml:"-"`
+}
+
+// NotificationLogConfig holds configuration for the log of notification attempts
+type NotificationLogConfig struct {
+	// Path is the file every notification attempt is appended to (JSON lines).
+	// Attempts are still kept in memory for the API when empty.
+	Path string `yaml:"path"`
+	// MaxEntries is how many recent attempts are kept in memory, default 1000
+	MaxEntries int `yaml:"max_entries"`
+}
+
+// DedupeConfig suppresses notifications repeating the last one sent for the
+// same alert and channel, e.g. FIRED again after a restart.
+type DedupeConfig struct {
+	// WindowStr is how long a sent notification suppresses identical ones (e.g. "1h"); empty disables deduplication
+	WindowStr string `yaml:"window"`
+	// Path keeps the notifications sent across restarts; instances sharing the file dedupe each other
+	Path   string        `yaml:"path"`
+	Window time.Duration `yaml:"-"` // Parsed
+}
+
+// RelabelConfig renames or drops collected metrics whose name matches Source.
+// Rules are applied in order, each to the output of the previous one.
+type RelabelConfig struct {
+	Source string         `yaml:"source"` // Regular expression matched against the whole metric name
+	Target string         `yaml:"target"` // New name for "rename"; may reference capture groups ($1, ${name})
+	Action string         `yaml:"action"` // "rename" (default) or "drop"
+	Regex  *regexp.Regexp `yaml:"-"`      // Compiled, anchored Source
+}
+
 func LoadConfig(filePath string) (*Config, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -75,10 +853,20 @@ func LoadConfig(filePath string) (*Config, error) {
 	}
 
 	var cfg Config
-	err = yaml.Unmarshal(data, &cfg)
-	if err != nil {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // A typo such as "agregation" must not silently disable a setting
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to unmarshal config YAML from %s: %w", filePath, explainUnknownFields(err))
+	}
+	// The channels' config maps are free-form in Config, so their keys are checked apart
+	if err := checkChannelConfigKeys(data); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config YAML from %s: %w", filePath, err)
 	}
+	if len(cfg.RulesFiles) > 0 {
+		if err := loadRulesFiles(&cfg, filePath); err != nil {
+			return nil, err
+		}
+	}
 
 	// Validate and derive values
 	if cfg.IntervalSeconds <= 0 {
@@ -86,6 +874,33 @@ func LoadConfig(filePath string) (*Config, error) {
 	}
 	cfg.CollectionInterval = time.Duration(cfg.IntervalSeconds) * time.Second
 
+	cfg.CollectionTimeout = cfg.CollectionInterval
+	if cfg.CollectionTimeoutStr != "" {
+		cfg.CollectionTimeout, err = util.ParseDurationString(cfg.CollectionTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid collection_timeout: %w", err)
+		}
+		if cfg.CollectionTimeout <= 0 {
+			return nil, fmt.Errorf("collection_timeout must be positive")
+		}
+	}
+	if cfg.NotificationLog.MaxEntries < 0 {
+		return nil, fmt.Errorf("notification_log max_entries must not be negative")
+	}
+	if cfg.Dedupe.WindowStr != "" {
+		cfg.Dedupe.Window, err = util.ParseDurationString(cfg.Dedupe.WindowStr)
+		if err != nil {
+			return nil, fmt.Errorf("dedupe has invalid window: %w", err)
+		}
+		if cfg.Dedupe.Window <= 0 {
+			return nil, fmt.Errorf("dedupe window must be positive")
+		}
+	} else if cfg.Dedupe.Path != "" {
+		return nil, fmt.Errorf("dedupe path is set but window is not")
+	}
+	cfg.API.Token = os.Getenv("MONRES_API_TOKEN")
+	cfg.API.SlackSigningSecret = os.Getenv("MONRES_SLACK_SIGNING_SECRET")
+
 	if strings.TrimSpace(cfg.HostnameOverride) != "" {
 		cfg.EffectiveHostname = cfg.HostnameOverride
 	} else {
@@ -104,14 +919,314 @@ func LoadConfig(filePath string) (*Config, error) {
 		cfg.Network.ExcludePrefixes = []string{"veth", "br-", "docker"}
 	}
 
+	for i, mount := range cfg.Filesystems.ExpectedMounts {
+		if !filepath.IsAbs(mount) {
+			return nil, fmt.Errorf("filesystems has invalid expected mount '%s': must be an absolute path", mount)
+		}
+		cfg.Filesystems.ExpectedMounts[i] = filepath.Clean(mount)
+	}
+	for _, patterns := range [][]string{cfg.Filesystems.Include, cfg.Filesystems.Exclude} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, "/"); err != nil || !strings.HasPrefix(pattern, "/") {
+				return nil, fmt.Errorf("filesystems has invalid mount pattern '%s': must be an absolute path or shell-style pattern", pattern)
+			}
+		}
+	}
+
+	for _, port := range cfg.ListenPorts {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("listen_ports has invalid port %d: must be between 1 and 65535", port)
+		}
+	}
+
+	switch cfg.Firewall.Backend {
+	case "", "nftables", "iptables":
+	default:
+		return nil, fmt.Errorf("firewall has invalid backend '%s', use nftables or iptables", cfg.Firewall.Backend)
+	}
+
+	for name, key := range cfg.WireGuard.Peers {
+		if key == "" {
+			return nil, fmt.Errorf("wireguard peer '%s' missing public key", name)
+		}
+	}
+
+	for i, path := range cfg.Slices.Paths {
+		path = strings.Trim(filepath.Clean("/"+path), "/")
+		if path == "" {
+			return nil, fmt.Errorf("slices has invalid path '%s': must name a cgroup below the root", cfg.Slices.Paths[i])
+		}
+		cfg.Slices.Paths[i] = path
+	}
+
+	if cfg.TopProcesses.Count < 0 {
+		return nil, fmt.Errorf("top_processes has negative count %d", cfg.TopProcesses.Count)
+	}
+
+	processNames := make(map[string]bool)
+	for i := range cfg.Processes {
+		pc := &cfg.Processes[i]
+		if pc.Name == "" {
+			return nil, fmt.Errorf("process at index %d missing name", i)
+		}
+		if strings.Trim(pc.Name, "abcdefghijklmnopqrstuvwxyz0123456789_") != "" {
+			return nil, fmt.Errorf("process '%s' has invalid name: use lowercase letters, digits and '_'", pc.Name)
+		}
+		if processNames[pc.Name] {
+			return nil, fmt.Errorf("duplicate process name '%s'", pc.Name)
+		}
+		processNames[pc.Name] = true
+		if (pc.Pattern == "") == (pc.PIDFile == "") {
+			return nil, fmt.Errorf("process '%s' needs either a pattern or a pidfile", pc.Name)
+		}
+		if pc.Pattern != "" {
+			pc.Regex, err = regexp.Compile(pc.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("process '%s' has invalid pattern: %w", pc.Name, err)
+			}
+		}
+	}
+
+	if cfg.Kubelet.Address != "" {
+		if !strings.HasPrefix(cfg.Kubelet.Address, "https://") && !strings.HasPrefix(cfg.Kubelet.Address, "http://") {
+			return nil, fmt.Errorf("kubelet has invalid address '%s': must be an http(s) URL", cfg.Kubelet.Address)
+		}
+		if err := outbound.ValidateTLS(cfg.Kubelet.TLSOptions()); err != nil {
+			return nil, fmt.Errorf("kubelet: %w", err)
+		}
+	}
+
+	certNames := make(map[string]bool)
+	for i, cf := range cfg.CertFiles {
+		if cf.Name == "" {
+			return nil, fmt.Errorf("cert file at index %d missing name", i)
+		}
+		if certNames[cf.Name] {
+			return nil, fmt.Errorf("duplicate cert file name '%s'", cf.Name)
+		}
+		certNames[cf.Name] = true
+		if cf.Path == "" {
+			return nil, fmt.Errorf("cert file '%s' missing path", cf.Name)
+		}
+	}
+
+	cronNames := make(map[string]bool)
+	for i := range cfg.CronJobs {
+		job := &cfg.CronJobs[i]
+		if job.Name == "" {
+			return nil, fmt.Errorf("cron job at index %d missing name", i)
+		}
+		if strings.Trim(job.Name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+			return nil, fmt.Errorf("cron job '%s' has invalid name: use letters, digits, '-' and '_'", job.Name)
+		}
+		if cronNames[job.Name] {
+			return nil, fmt.Errorf("duplicate cron job name '%s'", job.Name)
+		}
+		cronNames[job.Name] = true
+		if job.PeriodStr == "" {
+			return nil, fmt.Errorf("cron job '%s' missing period", job.Name)
+		}
+		job.Period, err = util.ParseDurationString(job.PeriodStr)
+		if err != nil {
+			return nil, fmt.Errorf("cron job '%s' has invalid period: %w", job.Name, err)
+		}
+		if job.Period <= 0 {
+			return nil, fmt.Errorf("cron job '%s' period must be positive", job.Name)
+		}
+		job.Grace = DefaultCronGrace
+		if job.GraceStr != "" {
+			job.Grace, err = util.ParseDurationString(job.GraceStr)
+			if err != nil {
+				return nil, fmt.Errorf("cron job '%s' has invalid grace: %w", job.Name, err)
+			}
+		}
+	}
+	if len(cfg.CronJobs) > 0 && cfg.API.Listen == "" {
+		return nil, fmt.Errorf("cron_jobs need the API to receive heartbeats, but api.listen is not set")
+	}
+
+	if cfg.Textfile.MaxAgeStr != "" {
+		cfg.Textfile.MaxAge, err = util.ParseDurationString(cfg.Textfile.MaxAgeStr)
+		if err != nil {
+			return nil, fmt.Errorf("textfile has invalid max_age: %w", err)
+		}
+	}
+
+	checkNames := make(map[string]bool)
+	for i := range cfg.NagiosChecks {
+		nc := &cfg.NagiosChecks[i]
+		if nc.Name == "" {
+			return nil, fmt.Errorf("nagios check at index %d missing name", i)
+		}
+		if checkNames[nc.Name] {
+			return nil, fmt.Errorf("duplicate nagios check name '%s'", nc.Name)
+		}
+		checkNames[nc.Name] = true
+		if len(nc.Command) == 0 || nc.Command[0] == "" {
+			return nil, fmt.Errorf("nagios check '%s' missing command", nc.Name)
+		}
+		nc.Timeout = DefaultNagiosTimeout
+		if nc.TimeoutStr != "" {
+			nc.Timeout, err = util.ParseDurationString(nc.TimeoutStr)
+			if err != nil {
+				return nil, fmt.Errorf("nagios check '%s' has invalid timeout: %w", nc.Name, err)
+			}
+			if nc.Timeout <= 0 {
+				return nil, fmt.Errorf("nagios check '%s' must have a positive timeout", nc.Name)
+			}
+		}
+		if nc.IntervalStr != "" {
+			nc.Interval, err = util.ParseDurationString(nc.IntervalStr)
+			if err != nil {
+				return nil, fmt.Errorf("nagios check '%s' has invalid interval: %w", nc.Name, err)
+			}
+		}
+	}
+
+	serviceNames := make(map[string]bool)
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		if svc.Name == "" {
+			return nil, fmt.Errorf("service at index %d missing name", i)
+		}
+		if serviceNames[svc.Name] {
+			return nil, fmt.Errorf("duplicate service name '%s'", svc.Name)
+		}
+		serviceNames[svc.Name] = true
+		if !slices.Contains(ServiceTypes, svc.Type) {
+			return nil, fmt.Errorf("service '%s' has invalid type '%s', use one of %s", svc.Name, svc.Type, strings.Join(ServiceTypes, ", "))
+		}
+		if svc.Address == "" {
+			return nil, fmt.Errorf("service '%s' missing address", svc.Name)
+		}
+		if svc.Type == "kafka" && svc.Group == "" {
+			return nil, fmt.Errorf("service '%s' missing group", svc.Name)
+		}
+		passwordEnvKey := "MONRES_SERVICE_PASSWORD_" + strings.ToUpper(strings.ReplaceAll(svc.Name, "-", "_"))
+		if pass := os.Getenv(passwordEnvKey); pass != "" {
+			svc.Password = pass
+		} else if svc.Password != "" {
+			fmt.Printf("Warning: Password for service '%s' found in config file. It should be set via ENV var %s.\n", svc.Name, passwordEnvKey)
+		}
+		svc.Timeout = DefaultServiceTimeout
+		if svc.TimeoutStr != "" {
+			svc.Timeout, err = util.ParseDurationString(svc.TimeoutStr)
+			if err != nil {
+				return nil, fmt.Errorf("service '%s' has invalid timeout: %w", svc.Name, err)
+			}
+			if svc.Timeout <= 0 {
+				return nil, fmt.Errorf("service '%s' must have a positive timeout", svc.Name)
+			}
+		}
+	}
+
+	if cfg.History.RetentionStr != "" {
+		cfg.History.Retention, err = util.ParseDurationString(cfg.History.RetentionStr)
+		if err != nil {
+			return nil, fmt.Errorf("history has invalid retention: %w", err)
+		}
+	}
+	if cfg.History.RawRetentionStr != "" {
+		cfg.History.RawRetention, err = util.ParseDurationString(cfg.History.RawRetentionStr)
+		if err != nil {
+			return nil, fmt.Errorf("history has invalid raw_retention: %w", err)
+		}
+	}
+	if cfg.History.ResolutionStr != "" {
+		cfg.History.Resolution, err = util.ParseDurationString(cfg.History.ResolutionStr)
+		if err != nil {
+			return nil, fmt.Errorf("history has invalid resolution: %w", err)
+		}
+	}
+
+	for i := range cfg.Relabel {
+		rc := &cfg.Relabel[i]
+		if rc.Source == "" {
+			return nil, fmt.Errorf("relabel rule at index %d missing source", i)
+		}
+		rc.Regex, err = regexp.Compile("^(?:" + rc.Source + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("relabel rule at index %d has invalid source: %w", i, err)
+		}
+		switch rc.Action {
+		case "", "rename":
+			rc.Action = "rename"
+			if rc.Target == "" {
+				return nil, fmt.Errorf("relabel rule at index %d (source '%s') missing target", i, rc.Source)
+			}
+		case "drop":
+			// OK
+		default:
+			return nil, fmt.Errorf("relabel rule at index %d has invalid action '%s'", i, rc.Action)
+		}
+	}
+
+	for i, mc := range cfg.Metrics {
+		if mc.Name == "" || mc.Name == "*" {
+			return nil, fmt.Errorf("metric metadata at index %d missing name", i)
+		}
+		if !metrics.IsKnownUnit(mc.Unit) {
+			return nil, fmt.Errorf("metric '%s' has unknown unit '%s'", mc.Name, mc.Unit)
+		}
+		switch mc.Type {
+		case "", "gauge", "rate", "counter":
+			// OK
+		default:
+			return nil, fmt.Errorf("metric '%s' has invalid type '%s'", mc.Name, mc.Type)
+		}
+		if tc := mc.Transform; tc != nil {
+			if tc.EWMAStr != "" {
+				tc.EWMA, err = util.ParseDurationString(tc.EWMAStr)
+				if err != nil {
+					return nil, fmt.Errorf("metric '%s' has invalid transform ewma: %w", mc.Name, err)
+				}
+			}
+			if tc.Min != nil && tc.Max != nil && *tc.Min > *tc.Max {
+				return nil, fmt.Errorf("metric '%s' has transform min greater than max", mc.Name)
+			}
+		}
+	}
+
+	if cfg.StartupMode == "" {
+		cfg.StartupMode = StartupFire
+	}
+	if err := validateStartupMode(cfg.StartupMode); err != nil {
+		return nil, err
+	}
+	switch cfg.LogOutput {
+	case "":
+		cfg.LogOutput = LogOutputStdout
+	case LogOutputStdout, LogOutputJournald:
+	default:
+		return nil, fmt.Errorf("invalid log_output '%s' (must be %s or %s)", cfg.LogOutput, LogOutputStdout, LogOutputJournald)
+	}
+
 	for i := range cfg.Alerts {
 		rule := &cfg.Alerts[i]
+		if rule.StartupMode == "" {
+			rule.StartupMode = cfg.StartupMode
+		}
 		if rule.Name == "" {
 			return nil, fmt.Errorf("alert rule at index %d missing name", i)
 		}
 		if rule.Metric == "" {
 			return nil, fmt.Errorf("alert rule '%s' missing metric", rule.Name)
 		}
+		if err := parseCondition(rule); err != nil {
+			return nil, err
+		}
+		if err := applyHostOverrides(rule, cfg.EffectiveHostname, cfg.HostGroups); err != nil {
+			return nil, err
+		}
+		if err := buildTiers(rule); err != nil {
+			return nil, err
+		}
+		if err := applyTemporaryOverride(rule, time.Now()); err != nil {
+			return nil, err
+		}
+		if err := buildEntityTiers(rule); err != nil {
+			return nil, err
+		}
 		// Validate condition, aggregation, etc.
 		switch strings.ToLower(rule.Aggregation) {
 		case "average", "max", "":
@@ -125,10 +1240,46 @@ func LoadConfig(filePath string) (*Config, error) {
 				return nil, fmt.Errorf("alert rule '%s' has invalid duration: %w", rule.Name, err)
 			}
 		}
+		if rule.AutoResolveAfterStr != "" {
+			rule.AutoResolveAfter, err = util.ParseDurationString(rule.AutoResolveAfterStr)
+			if err != nil {
+				return nil, fmt.Errorf("alert rule '%s' has invalid auto_resolve_after: %w", rule.Name, err)
+			}
+			if rule.AutoResolveAfter <= 0 {
+				return nil, fmt.Errorf("alert rule '%s' must have a positive auto_resolve_after", rule.Name)
+			}
+		}
+		if err := validateStartupMode(rule.StartupMode); err != nil {
+			return nil, fmt.Errorf("alert rule '%s': %w", rule.Name, err)
+		}
+		if rule.MinimumFiringDurationStr != "" {
+			rule.MinimumFiringDuration, err = util.ParseDurationString(rule.MinimumFiringDurationStr)
+			if err != nil {
+				return nil, fmt.Errorf("alert rule '%s' has invalid minimum_firing_duration: %w", rule.Name, err)
+			}
+		}
+		if rule.MinCoverage < 0 || rule.MinCoverage > 1 {
+			return nil, fmt.Errorf("alert rule '%s' has invalid min_coverage %g: must be between 0 and 1", rule.Name, rule.MinCoverage)
+		}
 		if len(rule.Channels) == 0 {
 			return nil, fmt.Errorf("alert rule '%s' has no notification channels defined", rule.Name)
 		}
+		if rule.TopProcesses < 0 {
+			return nil, fmt.Errorf("alert rule '%s' has negative top_processes", rule.Name)
+		}
+		for j, command := range rule.Capture {
+			if strings.TrimSpace(command) == "" {
+				return nil, fmt.Errorf("alert rule '%s' has an empty capture command at index %d", rule.Name, j)
+			}
+		}
+		for j := range rule.Actions {
+			if err := validateAction(&rule.Actions[j]); err != nil {
+				return nil, fmt.Errorf("alert rule '%s' action at index %d %w", rule.Name, j, err)
+			}
+		}
 	}
+	// Disabled rules are validated like the others, so enabling them again can't break the config
+	cfg.Alerts = slices.DeleteFunc(cfg.Alerts, func(rule AlertRuleConfig) bool { return !rule.IsEnabled() })
 
 	for i := range cfg.NotificationChannels {
 		nc := &cfg.NotificationChannels[i]
@@ -168,22 +1319,544 @@ func LoadConfig(filePath string) (*Config, error) {
 			}
 		case "stdout":
 			// No sensitive data, just a simple channel
+			if _, err := GetStdoutChannelConfig(*nc); err != nil {
+				return nil, err
+			}
+		case "webhook":
+			secretEnvKey := fmt.Sprintf("%sWEBHOOK_SECRET_%s", envVarPrefix, channelNameUpper)
+			if secret := os.Getenv(secretEnvKey); secret != "" {
+				if nc.Config == nil { nc.Config = make(map[string]interface{})}
+				nc.Config["secret"] = secret
+			} else if s, ok := nc.Config["secret"].(string); ok && s != "" {
+				fmt.Printf("Warning: Webhook secret for channel '%s' found in config file. It should be set via ENV var %s.\n", nc.Name, secretEnvKey)
+			}
+			if _, err := GetWebhookChannelConfig(*nc); err != nil {
+				return nil, err
+			}
+		case "issue":
+			tokenEnvKey := fmt.Sprintf("%sISSUE_TOKEN_%s", envVarPrefix, channelNameUpper)
+			if token := os.Getenv(tokenEnvKey); token != "" {
+				if nc.Config == nil { nc.Config = make(map[string]interface{})}
+				nc.Config["token"] = token
+			} else if s, ok := nc.Config["token"].(string); ok && s != "" {
+				fmt.Printf("Warning: Issue tracker token for channel '%s' found in config file. It should be set via ENV var %s.\n", nc.Name, tokenEnvKey)
+			}
+			if _, err := GetIssueChannelConfig(*nc); err != nil {
+				return nil, err
+			}
+		case "voice":
+			tokenEnvKey := fmt.Sprintf("%sVOICE_TOKEN_%s", envVarPrefix, channelNameUpper)
+			if token := os.Getenv(tokenEnvKey); token != "" {
+				if nc.Config == nil { nc.Config = make(map[string]interface{})}
+				nc.Config["token"] = token
+			} else if s, ok := nc.Config["token"].(string); ok && s != "" {
+				fmt.Printf("Warning: Voice provider token for channel '%s' found in config file. It should be set via ENV var %s.\n", nc.Name, tokenEnvKey)
+			}
+			if _, err := GetVoiceChannelConfig(*nc); err != nil {
+				return nil, err
+			}
+		case OnCallGrafana, OnCallSquadcast, OnCallZenduty:
+			urlEnvKey := fmt.Sprintf("%sINTEGRATION_URL_%s", envVarPrefix, channelNameUpper)
+			if u := os.Getenv(urlEnvKey); u != "" {
+				if nc.Config == nil { nc.Config = make(map[string]interface{})}
+				nc.Config["url"] = u
+			}
+			if _, err := GetOnCallChannelConfig(*nc); err != nil {
+				return nil, err
+			}
 		default:
 			return nil, fmt.Errorf("notification channel '%s' has unknown type '%s'", nc.Name, nc.Type)
 		}
+
+		if nc.Proxy == "" {
+			nc.Proxy = cfg.Proxy
+		}
+		if err := outbound.ValidateProxy(nc.Proxy); err != nil {
+			return nil, fmt.Errorf("notification channel '%s': %w", nc.Name, err)
+		}
+		if err := outbound.ValidateTLS(nc.TLSOptions()); err != nil {
+			return nil, fmt.Errorf("notification channel '%s': %w", nc.Name, err)
+		}
+		if nc.AddressFamily == "" {
+			nc.AddressFamily = cfg.AddressFamily
+		}
+		if err := outbound.ValidateAddressFamily(nc.AddressFamily); err != nil {
+			return nil, fmt.Errorf("notification channel '%s': %w", nc.Name, err)
+		}
+		if nc.DNSResolver == "" {
+			nc.DNSResolver = cfg.DNSResolver
+		}
+		if err := outbound.ValidateResolver(nc.DNSResolver); err != nil {
+			return nil, fmt.Errorf("notification channel '%s': %w", nc.Name, err)
+		}
+
+		nc.Timeout = DefaultChannelTimeout
+		if nc.TimeoutStr != "" {
+			nc.Timeout, err = util.ParseDurationString(nc.TimeoutStr)
+			if err != nil {
+				return nil, fmt.Errorf("notification channel '%s' has invalid timeout: %w", nc.Name, err)
+			}
+			if nc.Timeout <= 0 {
+				return nil, fmt.Errorf("notification channel '%s' must have a positive timeout", nc.Name)
+			}
+		}
+		if nc.MinSeverity != "" && !slices.Contains(Severities, nc.MinSeverity) {
+			return nil, fmt.Errorf("notification channel '%s' has invalid min_severity '%s' (valid: %s)", nc.Name, nc.MinSeverity, strings.Join(Severities, ", "))
+		}
+		if nc.HealthCheckStr != "" {
+			nc.HealthCheck, err = util.ParseDurationString(nc.HealthCheckStr)
+			if err != nil {
+				return nil, fmt.Errorf("notification channel '%s' has invalid health_check: %w", nc.Name, err)
+			}
+			if nc.HealthCheck <= 0 {
+				return nil, fmt.Errorf("notification channel '%s' must have a positive health_check interval", nc.Name)
+			}
+		}
+		if cb := nc.CircuitBreaker; cb != nil {
+			if cb.Failures == 0 {
+				cb.Failures = 3
+			}
+			if cb.Failures < 0 {
+				return nil, fmt.Errorf("notification channel '%s' has negative circuit_breaker failures", nc.Name)
+			}
+			cb.Cooldown = 5 * time.Minute
+			if cb.CooldownStr != "" {
+				cb.Cooldown, err = util.ParseDurationString(cb.CooldownStr)
+				if err != nil {
+					return nil, fmt.Errorf("notification channel '%s' has invalid circuit_breaker cooldown: %w", nc.Name, err)
+				}
+			}
+		}
+	}
+	if err := validateSinks(&cfg); err != nil {
+		return nil, err
+	}
+
+	channelNames := make(map[string]bool)
+	for _, nc := range cfg.NotificationChannels {
+		channelNames[nc.Name] = true
+	}
+	for _, nc := range cfg.NotificationChannels {
+		for _, fallback := range nc.Fallback {
+			if fallback == nc.Name || !channelNames[fallback] {
+				return nil, fmt.Errorf("notification channel '%s' has invalid fallback '%s'", nc.Name, fallback)
+			}
+		}
 	}
 
-	// Default templates
+	// Default templates, in the configured language
+	if cfg.Locale == "" {
+		cfg.Locale = i18n.Default
+	}
+	locale, ok := i18n.Lookup(cfg.Locale)
+	if !ok {
+		return nil, fmt.Errorf("unknown locale '%s' (supported: %s)", cfg.Locale, strings.Join(i18n.Codes(), ", "))
+	}
 	if cfg.Templates.AlertFired == "" {
-		cfg.Templates.AlertFired = `ALERT FIRED: {{.AlertName}} on {{.Hostname}}. Metric: {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Current: {{.FormattedMetricValue}}). Time: {{.Time.Format "2006-01-02 15:04:05"}}`
+		cfg.Templates.AlertFired = locale.FiredTemplate
 	}
 	if cfg.Templates.AlertResolved == "" {
-		cfg.Templates.AlertResolved = `ALERT RESOLVED: {{.AlertName}} on {{.Hostname}}. Time: {{.Time.Format "2006-01-02 15:04:05"}}`
+		cfg.Templates.AlertResolved = locale.ResolvedTemplate
+	}
+	if cfg.DashboardURLTemplate != "" {
+		if _, err := template.New("dashboard_url_template").Parse(cfg.DashboardURLTemplate); err != nil {
+			return nil, fmt.Errorf("invalid dashboard_url_template: %w", err)
+		}
 	}
 
 	return &cfg, nil
 }
 
+// DefaultEpsilon is the tolerance of equality conditions: values equal within
+// DefaultEpsilon (relative to the larger value once above 1) compare equal.
+const DefaultEpsilon = 1e-9
+
+// conditionOperators lists the supported operators, longest first for prefix matching.
+var conditionOperators = []string{"==", "!=", ">=", "<=", ">", "<", "="}
+
+// RulesFile is the content allowed in a file listed in rules_files. It can't
+// define channels, secrets or any other setting, so whoever may edit alert
+// rules can't redirect notifications or read credentials.
+type RulesFile struct {
+	Alerts []AlertRuleConfig `yaml:"alerts"`
+}
+
+// loadRulesFiles appends the alerts of cfg.RulesFiles to cfg.Alerts. Relative
+// paths are resolved against the directory of the main config file.
+func loadRulesFiles(cfg *Config, configPath string) error {
+	if info, err := os.Stat(configPath); err == nil && info.Mode().Perm()&0o077 != 0 {
+		fmt.Printf("Warning: Config file %s holds the channels and secrets but is accessible by other users (mode %04o). Restrict it to 0600 and keep rules in rules_files.\n", configPath, info.Mode().Perm())
+	}
+	names := make(map[string]string) // Rule name -> file defining it
+	for _, rule := range cfg.Alerts {
+		names[rule.Name] = configPath
+	}
+	for _, pattern := range cfg.RulesFiles {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(configPath), pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid rules_files pattern '%s': %w", pattern, err)
+		}
+		if len(files) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return fmt.Errorf("rules file %s does not exist", pattern)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read rules file %s: %w", file, err)
+			}
+			var rf RulesFile
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			if err := dec.Decode(&rf); err != nil && !errors.Is(err, io.EOF) { // An empty file is fine
+				return fmt.Errorf("failed to parse rules file %s (only 'alerts' is allowed): %w", file, explainUnknownFields(err))
+			}
+			for _, rule := range rf.Alerts {
+				if prev, ok := names[rule.Name]; ok && rule.Name != "" {
+					return fmt.Errorf("alert rule '%s' in %s is already defined in %s", rule.Name, file, prev)
+				}
+				names[rule.Name] = file
+			}
+			cfg.Alerts = append(cfg.Alerts, rf.Alerts...)
+		}
+	}
+	return nil
+}
+
+// parseCondition normalizes the condition of a rule to a plain operator.
+// A threshold may follow the operator ("== 0"), and the "is_down" and "is_up"
+// shorthands for boolean metrics mean "== 0" and "!= 0".
+func parseCondition(rule *AlertRuleConfig) error {
+	cond := strings.TrimSpace(rule.Condition)
+	switch strings.ToLower(cond) {
+	case "is_down":
+		cond = "== 0"
+	case "is_up":
+		cond = "!= 0"
+	}
+
+	for _, op := range conditionOperators {
+		if !strings.HasPrefix(cond, op) {
+			continue
+		}
+		if rest := strings.TrimSpace(cond[len(op):]); rest != "" {
+			threshold, err := strconv.ParseFloat(rest, 64)
+			if err != nil {
+				return fmt.Errorf("alert rule '%s' has invalid threshold in condition '%s'", rule.Name, rule.Condition)
+			}
+			rule.Threshold = threshold
+		}
+		if op == "=" {
+			op = "=="
+		}
+		rule.Condition = op
+		if rule.Epsilon < 0 {
+			return fmt.Errorf("alert rule '%s' has negative epsilon", rule.Name)
+		}
+		if rule.Epsilon == 0 {
+			rule.Epsilon = DefaultEpsilon
+		}
+		return nil
+	}
+	return fmt.Errorf("alert rule '%s' has invalid condition '%s'", rule.Name, rule.Condition)
+}
+
+// validateSinks checks the sinks and fills in their defaults and tokens.
+func validateSinks(cfg *Config) error {
+	var err error
+	sinkNames := make(map[string]bool)
+	for i := range cfg.Sinks {
+		sc := &cfg.Sinks[i]
+		if sc.Name == "" {
+			return fmt.Errorf("sink at index %d missing name", i)
+		}
+		if sinkNames[sc.Name] {
+			return fmt.Errorf("duplicate sink name '%s'", sc.Name)
+		}
+		sinkNames[sc.Name] = true
+
+		switch sc.Type {
+		case SinkInfluxDB:
+			tokenEnvKey := "MONRES_INFLUXDB_TOKEN_" + strings.ToUpper(strings.ReplaceAll(sc.Name, "-", "_"))
+			if token := os.Getenv(tokenEnvKey); token != "" {
+				sc.Token = token
+			} else if sc.Token != "" {
+				fmt.Printf("Warning: InfluxDB token for sink '%s' found in config file. It should be set via ENV var %s.\n", sc.Name, tokenEnvKey)
+			}
+			if sc.URL == "" || sc.Org == "" || sc.Bucket == "" {
+				return fmt.Errorf("sink '%s': url, org and bucket are required", sc.Name)
+			}
+			if sc.Measurement == "" {
+				sc.Measurement = "monres"
+			}
+		case SinkZabbix:
+			if sc.Server == "" {
+				return fmt.Errorf("sink '%s': server is required", sc.Name)
+			}
+			if sc.KeyPrefix == "" {
+				sc.KeyPrefix = "monres."
+			}
+		case SinkOTLP:
+			if sc.URL == "" {
+				sc.URL = "http://localhost:4318"
+			}
+			switch sc.Protocol {
+			case "", OTLPProtocolHTTPJSON:
+				sc.Protocol = OTLPProtocolHTTPJSON
+			case "grpc":
+				return fmt.Errorf("sink '%s': OTLP over grpc is not supported, use the collector's OTLP/HTTP receiver (port 4318) with protocol %s", sc.Name, OTLPProtocolHTTPJSON)
+			default:
+				return fmt.Errorf("sink '%s' has invalid protocol '%s' (must be %s)", sc.Name, sc.Protocol, OTLPProtocolHTTPJSON)
+			}
+			// 4317 is the collector's OTLP/gRPC port; the HTTP receiver is on 4318.
+			if u, err := url.Parse(sc.URL); err == nil && u.Port() == "4317" {
+				return fmt.Errorf("sink '%s': url %s points at the OTLP/gRPC port 4317, but only OTLP/HTTP with JSON is supported (use the collector's HTTP receiver, port 4318)", sc.Name, sc.URL)
+			}
+		default:
+			return fmt.Errorf("sink '%s' has unknown type '%s' (must be %s, %s or %s)", sc.Name, sc.Type, SinkInfluxDB, SinkZabbix, SinkOTLP)
+		}
+
+		if sc.Host == "" {
+			sc.Host = cfg.EffectiveHostname
+		}
+		sc.Timeout = DefaultSinkTimeout
+		if sc.TimeoutStr != "" {
+			sc.Timeout, err = util.ParseDurationString(sc.TimeoutStr)
+			if err != nil {
+				return fmt.Errorf("sink '%s' has invalid timeout: %w", sc.Name, err)
+			}
+			if sc.Timeout <= 0 {
+				return fmt.Errorf("sink '%s' must have a positive timeout", sc.Name)
+			}
+		}
+		if sc.Proxy == "" {
+			sc.Proxy = cfg.Proxy
+		}
+		if err := outbound.ValidateProxy(sc.Proxy); err != nil {
+			return fmt.Errorf("sink '%s': %w", sc.Name, err)
+		}
+		if err := outbound.ValidateTLS(sc.TLSOptions()); err != nil {
+			return fmt.Errorf("sink '%s': %w", sc.Name, err)
+		}
+		if sc.AddressFamily == "" {
+			sc.AddressFamily = cfg.AddressFamily
+		}
+		if err := outbound.ValidateAddressFamily(sc.AddressFamily); err != nil {
+			return fmt.Errorf("sink '%s': %w", sc.Name, err)
+		}
+		if sc.DNSResolver == "" {
+			sc.DNSResolver = cfg.DNSResolver
+		}
+		if err := outbound.ValidateResolver(sc.DNSResolver); err != nil {
+			return fmt.Errorf("sink '%s': %w", sc.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateStartupMode(mode string) error {
+	switch mode {
+	case StartupFire, StartupWait, StartupAnnounce:
+		return nil
+	default:
+		return fmt.Errorf("invalid startup_mode '%s' (valid: %s, %s, %s)", mode, StartupFire, StartupWait, StartupAnnounce)
+	}
+}
+
+// buildTiers derives the threshold tiers of a rule: one per entry of
+// Thresholds, or the single Threshold with the rule's Severity.
+func buildTiers(rule *AlertRuleConfig) error {
+	rule.Tiers = nil
+	if len(rule.Thresholds) == 0 {
+		severity := strings.ToLower(rule.Severity)
+		if severity == "" {
+			severity = SeverityCritical
+		}
+		if !slices.Contains(Severities, severity) {
+			return fmt.Errorf("alert rule '%s' has invalid severity '%s' (valid: %s)", rule.Name, rule.Severity, strings.Join(Severities, ", "))
+		}
+		rule.Severity = severity
+		rule.Tiers = []ThresholdTier{{Severity: severity, Threshold: rule.Threshold}}
+		return nil
+	}
+
+	for severity := range rule.Thresholds {
+		if !slices.Contains(Severities, severity) {
+			return fmt.Errorf("alert rule '%s' has threshold for invalid severity '%s' (valid: %s)", rule.Name, severity, strings.Join(Severities, ", "))
+		}
+	}
+	for _, severity := range Severities {
+		if threshold, ok := rule.Thresholds[severity]; ok {
+			rule.Tiers = append(rule.Tiers, ThresholdTier{Severity: severity, Threshold: threshold})
+		}
+	}
+	// Threshold and Severity describe the most severe tier, for code that only knows about one
+	top := rule.Tiers[len(rule.Tiers)-1]
+	rule.Threshold, rule.Severity = top.Threshold, top.Severity
+	return nil
+}
+
+// applyTemporaryOverride replaces the tiers of a rule with its override until
+// override_until. An override that already expired is ignored with a warning.
+func applyTemporaryOverride(rule *AlertRuleConfig, now time.Time) error {
+	if rule.Override == nil && rule.OverrideUntilStr == "" {
+		return nil
+	}
+	if rule.Override == nil || rule.OverrideUntilStr == "" {
+		return fmt.Errorf("alert rule '%s' must set both override and override_until", rule.Name)
+	}
+	until, err := time.Parse(time.RFC3339, rule.OverrideUntilStr)
+	if err != nil {
+		if until, err = time.ParseInLocation(time.DateOnly, rule.OverrideUntilStr, time.Local); err != nil {
+			return fmt.Errorf("alert rule '%s' has invalid override_until '%s' (expected RFC 3339 or YYYY-MM-DD)", rule.Name, rule.OverrideUntilStr)
+		}
+	}
+	if !now.Before(until) {
+		fmt.Printf("Warning: The override of alert rule '%s' expired at %s and is ignored; remove it from the config.\n", rule.Name, until.Format(time.RFC3339))
+		return nil
+	}
+
+	overridden := *rule
+	if rule.Override.Threshold != nil {
+		overridden.Threshold, overridden.Thresholds = *rule.Override.Threshold, nil
+	}
+	if rule.Override.Thresholds != nil {
+		overridden.Thresholds = rule.Override.Thresholds
+	}
+	if err := buildTiers(&overridden); err != nil {
+		return fmt.Errorf("%w (in override)", err)
+	}
+	rule.BaseTiers = rule.Tiers
+	rule.Tiers = overridden.Tiers
+	rule.Threshold, rule.Severity = overridden.Threshold, overridden.Severity
+	rule.OverrideUntil = until
+	return nil
+}
+
+// buildEntityTiers checks the metric pattern and the entity settings of a
+// templated rule and derives the tiers of its entities with thresholds.
+func buildEntityTiers(rule *AlertRuleConfig) error {
+	rule.EntityTiers = nil
+	if !rule.IsTemplate() {
+		if len(rule.Entities) > 0 || len(rule.Exclude) > 0 {
+			return fmt.Errorf("alert rule '%s' sets entities or exclude, but its metric has no '*'", rule.Name)
+		}
+		return nil
+	}
+	if strings.Count(rule.Metric, "*") > 1 {
+		return fmt.Errorf("alert rule '%s' has more than one '*' in metric '%s'", rule.Name, rule.Metric)
+	}
+	for _, pattern := range rule.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("alert rule '%s' has invalid exclude pattern '%s': %w", rule.Name, pattern, err)
+		}
+	}
+	for entity, o := range rule.Entities {
+		if entity == "" {
+			return fmt.Errorf("alert rule '%s' has thresholds for an empty entity", rule.Name)
+		}
+		instance := *rule
+		if o.Threshold != nil {
+			instance.Threshold, instance.Thresholds = *o.Threshold, nil
+		}
+		if o.Thresholds != nil {
+			instance.Thresholds = o.Thresholds
+		}
+		if err := buildTiers(&instance); err != nil {
+			return fmt.Errorf("%w (in entity '%s')", err, entity)
+		}
+		if rule.EntityTiers == nil {
+			rule.EntityTiers = make(map[string][]ThresholdTier, len(rule.Entities))
+		}
+		rule.EntityTiers[entity] = instance.Tiers
+	}
+	return nil
+}
+
+// validateAction checks an alert action and fills in its defaults. Errors
+// complete a sentence about the action, e.g. "must set command".
+func validateAction(action *AlertActionConfig) error {
+	if (len(action.Command) == 0) == (action.RestartUnit == "") {
+		return errors.New("must set exactly one of command or restart_unit")
+	}
+	if action.RestartUnit != "" {
+		action.Command = []string{"systemctl", "restart", action.RestartUnit}
+	}
+	if action.Command[0] == "" {
+		return errors.New("has an empty command")
+	}
+	if action.Name == "" {
+		action.Name = strings.Join(action.Command, " ")
+	}
+	var err error
+	if action.AfterStr != "" {
+		if action.After, err = util.ParseDurationString(action.AfterStr); err != nil {
+			return fmt.Errorf("has invalid after: %w", err)
+		}
+	}
+	action.Timeout = DefaultActionTimeout
+	if action.TimeoutStr != "" {
+		if action.Timeout, err = util.ParseDurationString(action.TimeoutStr); err != nil {
+			return fmt.Errorf("has invalid timeout: %w", err)
+		}
+		if action.Timeout <= 0 {
+			return errors.New("must have a positive timeout")
+		}
+	}
+	switch {
+	case action.MaxPerHour < 0:
+		return errors.New("has negative max_per_hour")
+	case action.MaxPerHour == 0:
+		action.MaxPerHour = DefaultActionMaxPerHour
+	}
+	return nil
+}
+
+// applyHostOverrides applies the first override of the rule matching hostname.
+func applyHostOverrides(rule *AlertRuleConfig, hostname string, hostGroups map[string][]string) error {
+	for i, o := range rule.Overrides {
+		if (o.Host == "") == (o.Group == "") {
+			return fmt.Errorf("alert rule '%s' override at index %d must set exactly one of host or group", rule.Name, i)
+		}
+		patterns := []string{o.Host}
+		if o.Group != "" {
+			var ok bool
+			if patterns, ok = hostGroups[o.Group]; !ok {
+				return fmt.Errorf("alert rule '%s' override at index %d references unknown host group '%s'", rule.Name, i, o.Group)
+			}
+		}
+
+		matched := false
+		for _, pattern := range patterns {
+			m, err := path.Match(pattern, hostname)
+			if err != nil {
+				return fmt.Errorf("alert rule '%s' override at index %d has invalid host pattern '%s': %w", rule.Name, i, pattern, err)
+			}
+			if m {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		if o.Threshold != nil {
+			rule.Threshold = *o.Threshold
+		}
+		if o.Thresholds != nil {
+			rule.Thresholds = o.Thresholds
+		}
+		if o.DurationStr != "" {
+			rule.DurationStr = o.DurationStr
+		}
+		return nil
+	}
+	return nil
+}
+
 // Helper to get typed Email config
 func GetEmailChannelConfig(nc NotificationChannelConfig) (*EmailChannelConfig, error) {
 	if nc.Type != "email" {
@@ -205,6 +1878,9 @@ func GetEmailChannelConfig(nc NotificationChannelConfig) (*EmailChannelConfig, e
 		}
 	} else { return nil, fmt.Errorf("channel '%s': smtp_to missing or not a list of strings", nc.Name)}
 	if useTLS, ok := nc.Config["smtp_use_tls"].(bool); ok { emailCfg.SMTPUseTLS = useTLS}
+	emailCfg.TLS = nc.TLSOptions()
+	emailCfg.AddressFamily = nc.AddressFamily
+	emailCfg.DNSResolver = nc.DNSResolver
 
 	if emailCfg.SMTPHost == "" || emailCfg.SMTPPort == 0 || emailCfg.SMTPFrom == "" || len(emailCfg.SMTPTo) == 0 {
 		return nil, fmt.Errorf("channel '%s': one or more required email config fields are missing (host, port, from, to)", nc.Name)
@@ -213,6 +1889,224 @@ func GetEmailChannelConfig(nc NotificationChannelConfig) (*EmailChannelConfig, e
 	return &emailCfg, nil
 }
 
+// Helper to get typed Stdout config
+func GetStdoutChannelConfig(nc NotificationChannelConfig) (*StdoutChannelConfig, error) {
+	if nc.Type != "stdout" {
+		return nil, fmt.Errorf("not a stdout channel")
+	}
+	stdoutCfg := StdoutChannelConfig{Format: StdoutFormatText, Color: StdoutColorAuto}
+	if format, ok := nc.Config["format"].(string); ok {
+		stdoutCfg.Format = format
+	}
+	if color, ok := nc.Config["color"].(string); ok {
+		stdoutCfg.Color = color
+	}
+	switch stdoutCfg.Format {
+	case StdoutFormatText, StdoutFormatJSON:
+	default:
+		return nil, fmt.Errorf("channel '%s': invalid format '%s' (must be %s or %s)", nc.Name, stdoutCfg.Format, StdoutFormatText, StdoutFormatJSON)
+	}
+	switch stdoutCfg.Color {
+	case StdoutColorAuto, StdoutColorAlways, StdoutColorNever:
+	default:
+		return nil, fmt.Errorf("channel '%s': invalid color '%s' (must be %s, %s or %s)", nc.Name, stdoutCfg.Color, StdoutColorAuto, StdoutColorAlways, StdoutColorNever)
+	}
+	return &stdoutCfg, nil
+}
+
+// Helper to get typed Webhook config
+func GetWebhookChannelConfig(nc NotificationChannelConfig) (*WebhookChannelConfig, error) {
+	if nc.Type != "webhook" {
+		return nil, fmt.Errorf("not a webhook channel")
+	}
+	var webhookCfg WebhookChannelConfig
+	if u, ok := nc.Config["url"].(string); ok {
+		webhookCfg.URL = u
+	}
+	parsed, err := url.Parse(webhookCfg.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("channel '%s': url missing or not an http(s) URL", nc.Name)
+	}
+	if headers, ok := nc.Config["headers"].(map[string]interface{}); ok {
+		webhookCfg.Headers = make(map[string]string, len(headers))
+		for k, v := range headers {
+			webhookCfg.Headers[k] = fmt.Sprint(v)
+		}
+	}
+	if secret, ok := nc.Config["secret"].(string); ok {
+		webhookCfg.Secret = secret // Already from ENV
+	}
+	webhookCfg.Proxy = nc.Proxy
+	webhookCfg.TLS = nc.TLSOptions()
+	webhookCfg.AddressFamily = nc.AddressFamily
+	webhookCfg.DNSResolver = nc.DNSResolver
+	return &webhookCfg, nil
+}
+
+// GetIssueChannelConfig returns the typed config of an issue channel.
+func GetIssueChannelConfig(nc NotificationChannelConfig) (*IssueChannelConfig, error) {
+	if nc.Type != "issue" {
+		return nil, fmt.Errorf("not an issue channel")
+	}
+	var issueCfg IssueChannelConfig
+	for key, field := range map[string]*string{
+		"provider":         &issueCfg.Provider,
+		"url":              &issueCfg.URL,
+		"repository":       &issueCfg.Repository,
+		"project":          &issueCfg.Project,
+		"issue_type":       &issueCfg.IssueType,
+		"user":             &issueCfg.User,
+		"close_transition": &issueCfg.CloseTransition,
+		"token":            &issueCfg.Token, // Already from ENV
+	} {
+		if v, ok := nc.Config[key]; ok {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("channel '%s': %s must be a string", nc.Name, key)
+			}
+			*field = s
+		}
+	}
+	if labels, ok := nc.Config["labels"]; ok {
+		list, ok := labels.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("channel '%s': labels must be a list of strings", nc.Name)
+		}
+		for _, label := range list {
+			s, ok := label.(string)
+			if !ok || s == "" {
+				return nil, fmt.Errorf("channel '%s': labels must be a list of strings", nc.Name)
+			}
+			issueCfg.Labels = append(issueCfg.Labels, s)
+		}
+	}
+	issueCfg.CloseOnResolve = true
+	if v, ok := nc.Config["close_on_resolve"]; ok {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("channel '%s': close_on_resolve must be true or false", nc.Name)
+		}
+		issueCfg.CloseOnResolve = b
+	}
+
+	switch issueCfg.Provider {
+	case IssueProviderGitHub:
+		if issueCfg.URL == "" {
+			issueCfg.URL = DefaultGitHubAPIURL
+		}
+		if owner, repo, ok := strings.Cut(issueCfg.Repository, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return nil, fmt.Errorf("channel '%s': repository missing or not in the form owner/repo", nc.Name)
+		}
+	case IssueProviderJira:
+		if issueCfg.Project == "" {
+			return nil, fmt.Errorf("channel '%s': project missing", nc.Name)
+		}
+		if issueCfg.IssueType == "" {
+			issueCfg.IssueType = DefaultJiraIssueType
+		}
+		if issueCfg.CloseTransition == "" {
+			issueCfg.CloseTransition = DefaultJiraCloseTransition
+		}
+	default:
+		return nil, fmt.Errorf("channel '%s': invalid provider '%s' (must be %s or %s)", nc.Name, issueCfg.Provider, IssueProviderGitHub, IssueProviderJira)
+	}
+	parsed, err := url.Parse(issueCfg.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("channel '%s': url missing or not an http(s) URL", nc.Name)
+	}
+	issueCfg.URL = strings.TrimSuffix(issueCfg.URL, "/")
+
+	issueCfg.Proxy = nc.Proxy
+	issueCfg.TLS = nc.TLSOptions()
+	issueCfg.AddressFamily = nc.AddressFamily
+	issueCfg.DNSResolver = nc.DNSResolver
+	return &issueCfg, nil
+}
+
+// GetVoiceChannelConfig returns the typed config of a voice channel.
+func GetVoiceChannelConfig(nc NotificationChannelConfig) (*VoiceChannelConfig, error) {
+	if nc.Type != "voice" {
+		return nil, fmt.Errorf("not a voice channel")
+	}
+	var voiceCfg VoiceChannelConfig
+	for key, field := range map[string]*string{
+		"provider":    &voiceCfg.Provider,
+		"url":         &voiceCfg.URL,
+		"account_sid": &voiceCfg.AccountSID,
+		"from":        &voiceCfg.From,
+		"language":    &voiceCfg.Language,
+		"token":       &voiceCfg.Token, // Already from ENV
+	} {
+		if v, ok := nc.Config[key]; ok {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("channel '%s': %s must be a string", nc.Name, key)
+			}
+			*field = s
+		}
+	}
+	switch to := nc.Config["to"].(type) {
+	case string:
+		voiceCfg.To = []string{to}
+	case []interface{}:
+		for _, v := range to {
+			s, ok := v.(string)
+			if !ok || s == "" {
+				return nil, fmt.Errorf("channel '%s': to must be a string or a list of strings", nc.Name)
+			}
+			voiceCfg.To = append(voiceCfg.To, s)
+		}
+	}
+	if len(voiceCfg.To) == 0 || voiceCfg.To[0] == "" {
+		return nil, fmt.Errorf("channel '%s': to missing", nc.Name)
+	}
+
+	switch voiceCfg.Provider {
+	case VoiceProviderTwilio:
+		if voiceCfg.URL == "" {
+			voiceCfg.URL = DefaultTwilioAPIURL
+		}
+		if voiceCfg.AccountSID == "" || voiceCfg.From == "" {
+			return nil, fmt.Errorf("channel '%s': account_sid and from are required for twilio", nc.Name)
+		}
+	case VoiceProviderCallMeBot:
+		if voiceCfg.URL == "" {
+			voiceCfg.URL = DefaultCallMeBotURL
+		}
+	default:
+		return nil, fmt.Errorf("channel '%s': invalid provider '%s' (must be %s or %s)", nc.Name, voiceCfg.Provider, VoiceProviderTwilio, VoiceProviderCallMeBot)
+	}
+	parsed, err := url.Parse(voiceCfg.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("channel '%s': url missing or not an http(s) URL", nc.Name)
+	}
+	voiceCfg.URL = strings.TrimSuffix(voiceCfg.URL, "/")
+
+	voiceCfg.Proxy = nc.Proxy
+	voiceCfg.TLS = nc.TLSOptions()
+	voiceCfg.AddressFamily = nc.AddressFamily
+	voiceCfg.DNSResolver = nc.DNSResolver
+	return &voiceCfg, nil
+}
+
+// GetOnCallChannelConfig returns the typed config of an on-call router channel.
+func GetOnCallChannelConfig(nc NotificationChannelConfig) (*OnCallChannelConfig, error) {
+	if !slices.Contains(OnCallServices, nc.Type) {
+		return nil, fmt.Errorf("not an on-call router channel")
+	}
+	onCallCfg := OnCallChannelConfig{Service: nc.Type}
+	onCallCfg.URL, _ = nc.Config["url"].(string) // Possibly from ENV
+	parsed, err := url.Parse(onCallCfg.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("channel '%s': url missing or not an http(s) URL", nc.Name)
+	}
+	onCallCfg.Proxy = nc.Proxy
+	onCallCfg.TLS = nc.TLSOptions()
+	onCallCfg.AddressFamily = nc.AddressFamily
+	onCallCfg.DNSResolver = nc.DNSResolver
+	return &onCallCfg, nil
+}
+
 // Helper to get typed Telegram config
 func GetTelegramChannelConfig(nc NotificationChannelConfig) (*TelegramChannelConfig, error) {
 	if nc.Type != "telegram" {
@@ -221,6 +2115,33 @@ func GetTelegramChannelConfig(nc NotificationChannelConfig) (*TelegramChannelCon
 	var telegramCfg TelegramChannelConfig
 	if token, ok := nc.Config["bot_token"].(string); ok { telegramCfg.BotToken = token } // Already from ENV
 	if chatID, ok := nc.Config["chat_id"].(string); ok { telegramCfg.ChatID = chatID } else { return nil, fmt.Errorf("channel '%s': chat_id missing or not a string", nc.Name) }
+	telegramCfg.Threading, _ = nc.Config["threading"].(string)
+	switch telegramCfg.Threading {
+	case "":
+		telegramCfg.Threading = TelegramThreadingReply
+	case TelegramThreadingReply, TelegramThreadingEdit, TelegramThreadingOff:
+	default:
+		return nil, fmt.Errorf("channel '%s': invalid threading '%s' (must be %s, %s or %s)", nc.Name, telegramCfg.Threading, TelegramThreadingReply, TelegramThreadingEdit, TelegramThreadingOff)
+	}
+	telegramCfg.QuickResolve, _ = nc.Config["quick_resolve"].(string)
+	switch telegramCfg.QuickResolve {
+	case "", TelegramQuickResolveDelete, TelegramQuickResolveCollapse:
+	default:
+		return nil, fmt.Errorf("channel '%s': invalid quick_resolve '%s' (must be %s or %s)", nc.Name, telegramCfg.QuickResolve, TelegramQuickResolveDelete, TelegramQuickResolveCollapse)
+	}
+	telegramCfg.QuickResolveMaxAge = DefaultQuickResolveMaxAge
+	if maxAge, ok := nc.Config["quick_resolve_max_age"].(string); ok {
+		d, err := util.ParseDurationString(maxAge)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("channel '%s': invalid quick_resolve_max_age '%s'", nc.Name, maxAge)
+		}
+		telegramCfg.QuickResolveMaxAgeStr, telegramCfg.QuickResolveMaxAge = maxAge, d
+	}
+
+	telegramCfg.Proxy = nc.Proxy
+	telegramCfg.TLS = nc.TLSOptions()
+	telegramCfg.AddressFamily = nc.AddressFamily
+	telegramCfg.DNSResolver = nc.DNSResolver
 
 	if telegramCfg.BotToken == "" || telegramCfg.ChatID == "" {
 		 return nil, fmt.Errorf("channel '%s': bot_token (from ENV) or chat_id are missing", nc.Name)
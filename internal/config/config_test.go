@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -90,8 +91,12 @@ notification_channels: []
 				Alerts:               []AlertRuleConfig{},
 				NotificationChannels: []NotificationChannelConfig{},
 				Templates: TemplateConfig{
-					AlertFired:    `ALERT FIRED: {{.AlertName}} on {{.Hostname}}. Metric: {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Current: {{.FormattedMetricValue}}). Time: {{.Time.Format "2006-01-02 15:04:05"}}`,
-					AlertResolved: `ALERT RESOLVED: {{.AlertName}} on {{.Hostname}}. Time: {{.Time.Format "2006-01-02 15:04:05"}}`,
+					AlertFired: `{{if .Action}}REMEDIATION ACTION ({{.Action.Name}}): {{.Action.Status}}{{else if .AtStartup}}ALERT ALREADY FIRING AT STARTUP{{else}}ALERT FIRED{{end}}: {{.AlertName}} on {{.Hostname}}. Metric: {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Current: {{.FormattedMetricValue}}). Time: {{.FormattedTime}}{{range .TopProcesses}}` + "\n- {{.}}{{end}}" +
+						"{{range .Captures}}\n\n$ {{.Command}}\n{{.Output}}{{if .Error}}\n({{.Error}}){{end}}{{end}}" +
+						"{{with .Action}}{{with .Error}}\n({{.}}){{end}}{{with .Output}}\n{{.}}{{end}}{{end}}" +
+						"{{with .DashboardURL}}\n{{.}}{{end}}",
+					AlertResolved: `ALERT RESOLVED{{if .Stale}} (stale){{end}}: {{.AlertName}} on {{.Hostname}}. Time: {{.FormattedTime}}` +
+						"{{with .DashboardURL}}\n{{.}}{{end}}",
 				},
 			},
 			wantErr: false,
@@ -308,11 +313,76 @@ func TestGetTelegramChannelConfig(t *testing.T) {
 				},
 			},
 			expected: &TelegramChannelConfig{
-				ChatID:   "-123456789",
-				BotToken: "test-token-123",
+				ChatID:    "-123456789",
+				BotToken:  "test-token-123",
+				Threading: TelegramThreadingReply,
 			},
 			wantErr: false,
 		},
+		{
+			name: "edit_threading",
+			input: NotificationChannelConfig{
+				Name: "test-telegram",
+				Type: "telegram",
+				Config: map[string]interface{}{
+					"chat_id":   "-123456789",
+					"bot_token": "test-token-123",
+					"threading": "edit",
+				},
+			},
+			expected: &TelegramChannelConfig{
+				ChatID:    "-123456789",
+				BotToken:  "test-token-123",
+				Threading: TelegramThreadingEdit,
+			},
+		},
+		{
+			name: "quick_resolve",
+			input: NotificationChannelConfig{
+				Name: "test-telegram",
+				Type: "telegram",
+				Config: map[string]interface{}{
+					"chat_id":               "-123456789",
+					"bot_token":             "test-token-123",
+					"quick_resolve":         "collapse",
+					"quick_resolve_max_age": "10m",
+				},
+			},
+			expected: &TelegramChannelConfig{
+				ChatID:             "-123456789",
+				BotToken:           "test-token-123",
+				Threading:          TelegramThreadingReply,
+				QuickResolve:       TelegramQuickResolveCollapse,
+				QuickResolveMaxAge: 10 * time.Minute,
+			},
+		},
+		{
+			name: "invalid_quick_resolve_max_age",
+			input: NotificationChannelConfig{
+				Name: "test-telegram",
+				Type: "telegram",
+				Config: map[string]interface{}{
+					"chat_id":               "-123456789",
+					"bot_token":             "test-token-123",
+					"quick_resolve":         "delete",
+					"quick_resolve_max_age": "soon",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid_threading",
+			input: NotificationChannelConfig{
+				Name: "test-telegram",
+				Type: "telegram",
+				Config: map[string]interface{}{
+					"chat_id":   "-123456789",
+					"bot_token": "test-token-123",
+					"threading": "thread",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing_bot_token",
 			input: NotificationChannelConfig{
@@ -347,6 +417,11 @@ func TestGetTelegramChannelConfig(t *testing.T) {
 			require.NoError(t, err)
 			assert.Equal(t, tc.expected.ChatID, result.ChatID)
 			assert.Equal(t, tc.expected.BotToken, result.BotToken)
+			assert.Equal(t, tc.expected.Threading, result.Threading)
+			assert.Equal(t, tc.expected.QuickResolve, result.QuickResolve)
+			if tc.expected.QuickResolve != "" {
+				assert.Equal(t, tc.expected.QuickResolveMaxAge, result.QuickResolveMaxAge)
+			}
 		})
 	}
 }
@@ -395,4 +470,1444 @@ notification_channels:
 	telegramResult, err := GetTelegramChannelConfig(cfg.NotificationChannels[1])
 	require.NoError(t, err)
 	assert.Equal(t, "test-token", telegramResult.BotToken)
-}
\ No newline at end of file
+}
+func TestLoadConfigMetricMetadata(t *testing.T) {
+	testCases := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{
+			name: "valid_metadata",
+			yaml: `
+metrics:
+  - name: "backup_size_bytes"
+    unit: "bytes"
+    description: "Size of the last backup"
+  - name: "queue_depth_*"
+    type: "gauge"
+`,
+		},
+		{
+			name: "unknown_unit",
+			yaml: `
+metrics:
+  - name: "backup_size"
+    unit: "furlongs"
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid_type",
+			yaml: `
+metrics:
+  - name: "backup_size"
+    type: "histogram"
+`,
+			wantErr: true,
+		},
+		{
+			name: "missing_name",
+			yaml: `
+metrics:
+  - unit: "bytes"
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tc.yaml), 0644))
+
+			cfg, err := LoadConfig(configFile)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, cfg.Metrics, 2)
+		})
+	}
+}
+
+func TestLoadConfigHostOverrides(t *testing.T) {
+	yaml := `
+hostname: "%s"
+host_groups:
+  databases: ["db-*", "pg-*"]
+alerts:
+  - name: "CPU Alert"
+    metric: "cpu_percent_total"
+    condition: ">"
+    threshold: 80
+    duration: "5m"
+    aggregation: "average"
+    channels: ["stdout"]
+    overrides:
+      - host: "web-01"
+        duration: "1m"
+      - group: "databases"
+        threshold: 95
+        duration: "10m"
+`
+	testCases := []struct {
+		hostname          string
+		expectedThreshold float64
+		expectedDuration  time.Duration
+	}{
+		{"web-01", 80, 1 * time.Minute},
+		{"db-01", 95, 10 * time.Minute},
+		{"pg-replica", 95, 10 * time.Minute},
+		{"cache-01", 80, 5 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.hostname, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(yaml, tc.hostname)), 0644))
+
+			cfg, err := LoadConfig(configFile)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedThreshold, cfg.Alerts[0].Threshold)
+			assert.Equal(t, tc.expectedDuration, cfg.Alerts[0].Duration)
+		})
+	}
+}
+
+func TestLoadConfigHostOverridesInvalid(t *testing.T) {
+	testCases := []struct {
+		name      string
+		overrides string
+	}{
+		{"unknown_group", `[{group: "missing", threshold: 1}]`},
+		{"host_and_group", `[{host: "a", group: "b", threshold: 1}]`},
+		{"neither", `[{threshold: 1}]`},
+		{"bad_pattern", `[{host: "[", threshold: 1}]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			yaml := `
+hostname: "host"
+alerts:
+  - name: "CPU Alert"
+    metric: "cpu_percent_total"
+    condition: ">"
+    threshold: 80
+    channels: ["stdout"]
+    overrides: ` + tc.overrides + "\n"
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(yaml), 0644))
+
+			_, err := LoadConfig(configFile)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigCollectionTimeout(t *testing.T) {
+	testCases := []struct {
+		name     string
+		yaml     string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"default_is_interval", "interval_seconds: 10\n", 10 * time.Second, false},
+		{"explicit", "interval_seconds: 10\ncollection_timeout: \"3s\"\n", 3 * time.Second, false},
+		{"invalid", "collection_timeout: \"soon\"\n", 0, true},
+		{"zero", "collection_timeout: \"0s\"\n", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tc.yaml), 0644))
+
+			cfg, err := LoadConfig(configFile)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.CollectionTimeout)
+		})
+	}
+}
+
+func TestLoadConfigRelabelInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule string
+	}{
+		{"missing_source", `{target: "b"}`},
+		{"bad_regex", `{source: "(", target: "b"}`},
+		{"missing_target", `{source: "a"}`},
+		{"unknown_action", `{source: "a", target: "b", action: "keep"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte("relabel: ["+tc.rule+"]\n"), 0644))
+
+			_, err := LoadConfig(configFile)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigMetricTransform(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+metrics:
+  - name: "probe_latency_*"
+    transform:
+      scale: 1000
+      ewma: "1m"
+      min: 0
+`), 0644))
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	tc := cfg.Metrics[0].Transform
+	require.NotNil(t, tc)
+	assert.Equal(t, 1000.0, *tc.Scale)
+	assert.Equal(t, time.Minute, tc.EWMA)
+	assert.Nil(t, tc.Max)
+
+	for name, transform := range map[string]string{
+		"bad_ewma":    `{ewma: "soon"}`,
+		"min_too_big": `{min: 10, max: 1}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(configFile, []byte("metrics: [{name: x, transform: "+transform+"}]\n"), 0644))
+			_, err := LoadConfig(configFile)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigHistory(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("history: {retention: \"24h\", raw_retention: \"30m\", resolution: \"5m\"}\n"), 0644))
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, cfg.History.Retention)
+	assert.Equal(t, 30*time.Minute, cfg.History.RawRetention)
+	assert.Equal(t, 5*time.Minute, cfg.History.Resolution)
+
+	require.NoError(t, os.WriteFile(configFile, []byte("history: {resolution: \"often\"}\n"), 0644))
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigConditions(t *testing.T) {
+	tests := []struct {
+		condition string
+		threshold string
+		wantOp    string
+		want      float64
+		wantErr   bool
+	}{
+		{condition: ">", threshold: "90", wantOp: ">", want: 90},
+		{condition: "== 0", wantOp: "==", want: 0},
+		{condition: "<=2.5", wantOp: "<=", want: 2.5},
+		{condition: "=", threshold: "3", wantOp: "==", want: 3},
+		{condition: "is_down", wantOp: "==", want: 0},
+		{condition: "is_up", threshold: "7", wantOp: "!=", want: 0},
+		{condition: "~", wantErr: true},
+		{condition: "== zero", wantErr: true},
+		{condition: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			threshold := tt.threshold
+			if threshold == "" {
+				threshold = "0"
+			}
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "probe"
+    metric: "probe_up"
+    condition: "`+tt.condition+`"
+    threshold: `+threshold+`
+    channels: ["stdout"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+
+			cfg, err := LoadConfig(configFile)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			rule := cfg.Alerts[0]
+			assert.Equal(t, tt.wantOp, rule.Condition)
+			assert.Equal(t, tt.want, rule.Threshold)
+			assert.Equal(t, DefaultEpsilon, rule.Epsilon)
+		})
+	}
+}
+
+func TestLoadConfigThresholdTiers(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(rule string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "disk"
+    metric: "disk_percent_used"
+    condition: ">"
+    channels: ["stdout"]
+`+rule+`
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("    thresholds: {critical: 95, warning: 80}")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []ThresholdTier{{SeverityWarning, 80}, {SeverityCritical, 95}}, cfg.Alerts[0].Tiers)
+	assert.Equal(t, 95.0, cfg.Alerts[0].Threshold)
+
+	write("    threshold: 90")
+	cfg, err = LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []ThresholdTier{{SeverityCritical, 90}}, cfg.Alerts[0].Tiers)
+
+	write("    threshold: 90\n    severity: warning")
+	cfg, err = LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []ThresholdTier{{SeverityWarning, 90}}, cfg.Alerts[0].Tiers)
+
+	write("    thresholds: {urgent: 99}")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+
+	write("    threshold: 90\n    severity: fatal")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigRuleTemplates(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(metric, rule string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "Disk Almost Full"
+    metric: "`+metric+`"
+    condition: ">"
+    thresholds: {warning: 80, critical: 90}
+    channels: ["stdout"]
+`+rule+`
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("disk_percent_used_*", "    entities:\n      var_lib_docker: {threshold: 95}\n    exclude: [\"boot*\"]")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	rule := cfg.Alerts[0]
+	require.True(t, rule.IsTemplate())
+	assert.Equal(t, map[string][]ThresholdTier{"var_lib_docker": {{SeverityCritical, 95}}}, rule.EntityTiers)
+
+	entity, ok := rule.MatchEntity("disk_percent_used_var_lib_docker")
+	assert.True(t, ok)
+	assert.Equal(t, "var_lib_docker", entity)
+	_, ok = rule.MatchEntity("disk_percent_used_boot_efi")
+	assert.False(t, ok, "excluded")
+	_, ok = rule.MatchEntity("disk_percent_used_")
+	assert.False(t, ok, "empty entity")
+
+	docker := rule.Instantiate("var_lib_docker")
+	assert.Equal(t, "Disk Almost Full (var_lib_docker)", docker.Name)
+	assert.Equal(t, "disk_percent_used_var_lib_docker", docker.Metric)
+	assert.Equal(t, []ThresholdTier{{SeverityCritical, 95}}, docker.Tiers)
+	root := rule.Instantiate("root")
+	assert.Equal(t, []ThresholdTier{{SeverityWarning, 80}, {SeverityCritical, 90}}, root.Tiers)
+	assert.Equal(t, "Disk Almost Full", root.Template)
+
+	write("disk_percent_used_*_*", "")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "more than one '*'")
+
+	write("disk_percent_used", "    exclude: [\"boot\"]")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "has no '*'")
+
+	write("disk_percent_used_*", "    entities:\n      root: {thresholds: {urgent: 99}}")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "in entity 'root'")
+}
+
+func TestLoadConfigAutoResolveAfter(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(value string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "container"
+    metric: "container_up_web"
+    condition: "is_down"
+    auto_resolve_after: "`+value+`"
+    channels: ["stdout"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("10m")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, cfg.Alerts[0].AutoResolveAfter)
+
+	write("whenever")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigMinCoverage(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(value string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "cpu"
+    metric: "cpu_percent_total"
+    condition: ">"
+    threshold: 90
+    duration: "5m"
+    aggregation: "average"
+    min_coverage: `+value+`
+    channels: ["stdout"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("0.8")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, 0.8, cfg.Alerts[0].MinCoverage)
+
+	for _, value := range []string{"80", "-0.5"} {
+		write(value)
+		_, err = LoadConfig(configFile)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLoadConfigNotifyOnResolve(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "spike"
+    metric: "net_recv_bytes_ps"
+    condition: ">"
+    threshold: 100
+    notify_on_resolve: false
+    minimum_firing_duration: "2m"
+    channels: ["stdout", "pager"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+  - name: "pager"
+    type: "stdout"
+    notify_on_resolve: false
+`), 0644))
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.False(t, cfg.Alerts[0].SendsResolved())
+	assert.Equal(t, 2*time.Minute, cfg.Alerts[0].MinimumFiringDuration)
+	assert.True(t, cfg.NotificationChannels[0].SendsResolved())
+	assert.False(t, cfg.NotificationChannels[1].SendsResolved())
+}
+
+func TestLoadConfigStartupMode(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(global, rule string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+startup_mode: "`+global+`"
+alerts:
+  - name: "a"
+    metric: "cpu_percent_total"
+    condition: "> 90"
+    channels: ["stdout"]
+  - name: "b"
+    metric: "cpu_percent_total"
+    condition: "> 90"
+    startup_mode: "`+rule+`"
+    channels: ["stdout"]
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("wait", "announce")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, StartupWait, cfg.Alerts[0].StartupMode)
+	assert.Equal(t, StartupAnnounce, cfg.Alerts[1].StartupMode)
+
+	write("", "")
+	cfg, err = LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, StartupFire, cfg.Alerts[0].StartupMode)
+
+	write("never", "")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+
+	write("fire", "later")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigChannelDelivery(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(extra string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "mail"
+    type: "stdout"
+`+extra+`
+  - name: "chat"
+    type: "stdout"
+`), 0644))
+	}
+
+	write(`    timeout: "5s"
+    circuit_breaker: {failures: 5}
+    fallback: ["chat"]`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	mail, chat := cfg.NotificationChannels[0], cfg.NotificationChannels[1]
+	assert.Equal(t, 5*time.Second, mail.Timeout)
+	assert.Equal(t, 5, mail.CircuitBreaker.Failures)
+	assert.Equal(t, 5*time.Minute, mail.CircuitBreaker.Cooldown)
+	assert.Equal(t, DefaultChannelTimeout, chat.Timeout)
+	assert.Nil(t, chat.CircuitBreaker)
+
+	for name, extra := range map[string]string{
+		"bad_timeout":      `    timeout: "soon"`,
+		"bad_cooldown":     `    circuit_breaker: {cooldown: "later"}`,
+		"unknown_fallback": `    fallback: ["pager"]`,
+		"self_fallback":    `    fallback: ["mail"]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			write(extra)
+			_, err := LoadConfig(configFile)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadConfigProxy(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(global, channel string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+proxy: "`+global+`"
+notification_channels:
+  - name: "chat"
+    type: "stdout"
+    proxy: "`+channel+`"
+  - name: "hook"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("http://proxy.example.com:3128", "socks5://127.0.0.1:1080")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "socks5://127.0.0.1:1080", cfg.NotificationChannels[0].Proxy)
+	assert.Equal(t, "http://proxy.example.com:3128", cfg.NotificationChannels[1].Proxy)
+
+	write("", "direct")
+	cfg, err = LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "direct", cfg.NotificationChannels[0].Proxy)
+	assert.Empty(t, cfg.NotificationChannels[1].Proxy)
+
+	write("ftp://proxy.example.com", "")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigChannelTLS(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(tls string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "mail"
+    type: "email"
+    config:
+      smtp_host: "smtp.internal"
+      smtp_port: 587
+      smtp_from: "monres@internal"
+      smtp_to: ["ops@internal"]
+    tls: `+tls+`
+`), 0644))
+	}
+
+	write(`{min_version: "1.2", insecure_skip_verify: true}`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	emailCfg, err := GetEmailChannelConfig(cfg.NotificationChannels[0])
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", emailCfg.TLS.MinVersion)
+	assert.True(t, emailCfg.TLS.InsecureSkipVerify)
+
+	write(`{min_version: "1.5"}`)
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+
+	write(`{ca_file: "/nonexistent/ca.pem"}`)
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigAddressFamily(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(global, channel string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+address_family: "`+global+`"
+notification_channels:
+  - name: "chat"
+    type: "stdout"
+    address_family: "`+channel+`"
+  - name: "hook"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("prefer_v4", "prefer_v6")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "prefer_v6", cfg.NotificationChannels[0].AddressFamily)
+	assert.Equal(t, "prefer_v4", cfg.NotificationChannels[1].AddressFamily)
+
+	write("", "ipv6")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigDNSResolver(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(global string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+dns_resolver: "`+global+`"
+notification_channels:
+  - name: "chat"
+    type: "stdout"
+    dns_resolver: "9.9.9.9"
+  - name: "hook"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("1.1.1.1:53")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "9.9.9.9", cfg.NotificationChannels[0].DNSResolver)
+	assert.Equal(t, "1.1.1.1:53", cfg.NotificationChannels[1].DNSResolver)
+
+	write("dns.example.com")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigStdoutChannel(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(format string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "stdout"
+    type: "stdout"
+    config:
+      format: "`+format+`"
+      color: "never"
+`), 0644))
+	}
+
+	write("json")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	stdoutCfg, err := GetStdoutChannelConfig(cfg.NotificationChannels[0])
+	require.NoError(t, err)
+	assert.Equal(t, StdoutChannelConfig{Format: StdoutFormatJSON, Color: StdoutColorNever}, *stdoutCfg)
+
+	write("yaml")
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigChannelHealthCheck(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(interval string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "chat"
+    type: "stdout"
+    health_check: "`+interval+`"
+  - name: "hook"
+    type: "stdout"
+`), 0644))
+	}
+
+	write("5m")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cfg.NotificationChannels[0].HealthCheck)
+	assert.Zero(t, cfg.NotificationChannels[1].HealthCheck)
+
+	write("0s")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "positive health_check")
+
+	write("often")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid health_check")
+}
+
+func TestLoadConfigExpectedMounts(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(mount string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+filesystems:
+  expected_mounts: ["/", "`+mount+`"]
+`), 0644))
+	}
+
+	write("/mnt/backups/")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/mnt/backups"}, cfg.Filesystems.ExpectedMounts)
+
+	write("mnt/backups")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "must be an absolute path")
+}
+
+func TestLoadConfigDiskSpaceMounts(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(pattern string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+filesystems:
+  include: ["/", "/mnt/*"]
+  exclude: ["`+pattern+`"]
+`), 0644))
+	}
+
+	write("/mnt/scratch*")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/mnt/*"}, cfg.Filesystems.Include)
+	assert.Equal(t, []string{"/mnt/scratch*"}, cfg.Filesystems.Exclude)
+
+	write("boot")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid mount pattern 'boot'")
+
+	write("/mnt/[")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid mount pattern")
+}
+
+func TestLoadConfigListenPorts(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`listen_ports: [22, 5432]`), 0644))
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []int{22, 5432}, cfg.ListenPorts)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`listen_ports: [22, 70000]`), 0644))
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid port 70000")
+}
+
+func TestLoadConfigFirewall(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(backend string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+firewall:
+  backend: "`+backend+`"
+  counters: ["ssh_drop"]
+`), 0644))
+	}
+
+	write("nftables")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, FirewallConfig{Backend: "nftables", Counters: []string{"ssh_drop"}}, cfg.Firewall)
+
+	write("pf")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid backend 'pf'")
+}
+
+func TestLoadConfigWireGuard(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(key string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+wireguard:
+  enabled: true
+  peers:
+    db1: "`+key+`"
+`), 0644))
+	}
+
+	write("xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.True(t, cfg.WireGuard.Enabled)
+	assert.Equal(t, map[string]string{"db1": "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="}, cfg.WireGuard.Peers)
+
+	write("")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "missing public key")
+}
+
+func TestLoadConfigCertFiles(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(files string) {
+		require.NoError(t, os.WriteFile(configFile, []byte("cert_files:\n"+files), 0644))
+	}
+
+	write(`  - {name: "web", path: "/etc/nginx/ssl/example.com.pem"}
+`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []CertFileConfig{{Name: "web", Path: "/etc/nginx/ssl/example.com.pem"}}, cfg.CertFiles)
+
+	write(`  - {name: "web", path: "/a.pem"}
+  - {name: "web", path: "/b.pem"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "duplicate cert file name 'web'")
+
+	write(`  - {name: "web"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "missing path")
+}
+
+func TestLoadConfigCronJobs(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(api, jobs string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(api+"cron_jobs:\n"+jobs), 0644))
+	}
+	withAPI := "api:\n  listen: \"127.0.0.1:9600\"\n"
+
+	write(withAPI, `  - {name: "backup", period: "24h", grace: "1h"}
+  - {name: "sync-feeds", period: "15m"}
+`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.CronJobs, 2)
+	assert.Equal(t, 24*time.Hour, cfg.CronJobs[0].Period)
+	assert.Equal(t, time.Hour, cfg.CronJobs[0].Grace)
+	assert.Equal(t, DefaultCronGrace, cfg.CronJobs[1].Grace)
+
+	write("", `  - {name: "backup", period: "24h"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "api.listen is not set")
+
+	write(withAPI, `  - {name: "backup/daily", period: "24h"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid name")
+
+	write(withAPI, `  - {name: "backup"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "missing period")
+}
+
+func TestLoadConfigSlices(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(paths string) {
+		require.NoError(t, os.WriteFile(configFile, []byte("slices:\n  enabled: true\n  paths: "+paths+"\n"), 0644))
+	}
+
+	write(`["/user.slice/", "system.slice/nginx.service"]`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.slice", "system.slice/nginx.service"}, cfg.Slices.Paths)
+
+	write(`["/"]`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "slices has invalid path '/'")
+}
+
+func TestLoadConfigProcesses(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(processes string) {
+		require.NoError(t, os.WriteFile(configFile, []byte("processes:\n"+processes), 0644))
+	}
+
+	write(`  - {name: "postgres", pattern: "^postgres"}
+  - {name: "nginx", pidfile: "/run/nginx.pid"}
+`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.Processes, 2)
+	assert.True(t, cfg.Processes[0].Regex.MatchString("postgres: walwriter"))
+	assert.Nil(t, cfg.Processes[1].Regex)
+
+	write(`  - {name: "nginx", pattern: "nginx", pidfile: "/run/nginx.pid"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "needs either a pattern or a pidfile")
+
+	write(`  - {name: "Nginx-Web", pattern: "nginx"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid name")
+
+	write(`  - {name: "app", pattern: "("}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "process 'app' has invalid pattern")
+}
+
+func TestLoadConfigKubelet(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(kubelet string) {
+		require.NoError(t, os.WriteFile(configFile, []byte("kubelet:\n"+kubelet), 0644))
+	}
+
+	write("  address: \"https://127.0.0.1:10250\"\n  token_file: \"/var/lib/monres/kubelet-token\"\n  tls:\n    insecure_skip_verify: true\n")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.True(t, cfg.Kubelet.TLSOptions().InsecureSkipVerify)
+
+	write("  address: \"127.0.0.1:10250\"\n")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "must be an http(s) URL")
+
+	write("  address: \"https://127.0.0.1:10250\"\n  tls:\n    ca_file: \"/nonexistent/ca.pem\"\n")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "ca_file")
+}
+
+func TestLoadConfigServices(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(services string) {
+		require.NoError(t, os.WriteFile(configFile, []byte("services:\n"+services), 0644))
+	}
+
+	write(`  - {name: "shop", type: "php-fpm", address: "http://127.0.0.1/fpm-status"}
+  - {name: "api", type: "gunicorn", address: "127.0.0.1:8125", timeout: "2s"}
+`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 2)
+	assert.Equal(t, DefaultServiceTimeout, cfg.Services[0].Timeout)
+	assert.Equal(t, 2*time.Second, cfg.Services[1].Timeout)
+
+	write(`  - {name: "mq", type: "rabbitmq", address: "http://127.0.0.1:15672", username: "monitor"}
+`)
+	t.Setenv("MONRES_SERVICE_PASSWORD_MQ", "secret")
+	cfg, err = LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", cfg.Services[0].Password)
+
+	write(`  - {name: "events", type: "kafka", address: "localhost:9092"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "missing group")
+
+	write(`  - {name: "db", type: "mysql", address: "127.0.0.1:3306"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid type 'mysql'")
+
+	write(`  - {name: "shop", type: "php-fpm"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "missing address")
+}
+
+func TestLoadConfigLogOutput(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`log_output: "journald"`), 0644))
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, LogOutputJournald, cfg.LogOutput)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`log_output: "syslog"`), 0644))
+	_, err = LoadConfig(configFile)
+	assert.Error(t, err)
+}
+
+func TestLoadConfigLocale(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(locale string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`locale: "`+locale+`"`), 0644))
+	}
+
+	write("de_DE.UTF-8")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, cfg.Templates.AlertFired, "ALARM AUSGELÖST")
+	assert.Contains(t, cfg.Templates.AlertResolved, "ALARM BEHOBEN")
+
+	write("tlh")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "unknown locale")
+}
+
+func TestLoadConfigDashboardURLTemplate(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`dashboard_url_template: "https://grafana/d/x?from={{ .From.UnixMilli }}"`), 0644))
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, "https://grafana/d/x?from={{ .From.UnixMilli }}", cfg.DashboardURLTemplate)
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`dashboard_url_template: "https://grafana/d/x?from={{ .From"`), 0644))
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid dashboard_url_template")
+}
+
+func TestLoadConfigNagiosChecks(t *testing.T) {
+	testCases := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"valid", "nagios_checks:\n  - name: http\n    command: [\"/usr/lib/nagios/plugins/check_http\", \"-H\", \"localhost\"]\n    timeout: \"5s\"\n    interval: \"1m\"\n", ""},
+		{"missing_name", "nagios_checks:\n  - command: [\"check_load\"]\n", "missing name"},
+		{"missing_command", "nagios_checks:\n  - name: load\n", "missing command"},
+		{"duplicate", "nagios_checks:\n  - name: load\n    command: [\"a\"]\n  - name: load\n    command: [\"b\"]\n", "duplicate"},
+		{"bad_timeout", "nagios_checks:\n  - name: load\n    command: [\"a\"]\n    timeout: \"0s\"\n", "positive timeout"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tc.yaml), 0644))
+
+			cfg, err := LoadConfig(configFile)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, cfg.NagiosChecks, 1)
+			assert.Equal(t, 5*time.Second, cfg.NagiosChecks[0].Timeout)
+			assert.Equal(t, time.Minute, cfg.NagiosChecks[0].Interval)
+		})
+	}
+}
+
+func TestLoadConfigSinks(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+hostname: "web1"
+sinks:
+  - name: "influx-main"
+    type: "influxdb"
+    url: "https://influx.example.com:8086"
+    org: "ops"
+    bucket: "hosts"
+  - name: "zabbix"
+    type: "zabbix"
+    server: "zabbix.example.com"
+    metrics: ["cpu_*"]
+    timeout: "3s"
+`), 0644))
+	t.Setenv("MONRES_INFLUXDB_TOKEN_INFLUX_MAIN", "secret")
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.Sinks, 2)
+	influx, zabbix := cfg.Sinks[0], cfg.Sinks[1]
+	assert.Equal(t, "secret", influx.Token)
+	assert.Equal(t, "monres", influx.Measurement)
+	assert.Equal(t, "web1", influx.Host)
+	assert.Equal(t, DefaultSinkTimeout, influx.Timeout)
+	assert.Equal(t, "monres.", zabbix.KeyPrefix)
+	assert.Equal(t, 3*time.Second, zabbix.Timeout)
+	assert.True(t, zabbix.SendsMetric("cpu_percent_total"))
+	assert.False(t, zabbix.SendsMetric("mem_percent_used"))
+	assert.True(t, influx.SendsMetric("mem_percent_used"))
+
+	for _, invalid := range []string{
+		"sinks:\n  - name: x\n    type: graphite\n",
+		"sinks:\n  - name: x\n    type: influxdb\n    url: http://influx\n",
+		"sinks:\n  - name: x\n    type: zabbix\n",
+		"sinks:\n  - type: zabbix\n    server: z\n",
+		"sinks:\n  - name: x\n    type: otlp\n    protocol: grpc\n",
+		"sinks:\n  - name: x\n    type: otlp\n    url: http://otel:4317\n",
+	} {
+		require.NoError(t, os.WriteFile(configFile, []byte(invalid), 0644))
+		_, err := LoadConfig(configFile)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLoadConfigWebhookChannel(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(url string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "ops-hooks"
+    type: "webhook"
+    config:
+      url: "`+url+`"
+      headers:
+        X-Team: ops
+`), 0644))
+	}
+	t.Setenv("MONRES_WEBHOOK_SECRET_OPS_HOOKS", "s3cret")
+
+	write("https://hooks.example.com/monres")
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	webhookCfg, err := GetWebhookChannelConfig(cfg.NotificationChannels[0])
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/monres", webhookCfg.URL)
+	assert.Equal(t, map[string]string{"X-Team": "ops"}, webhookCfg.Headers)
+	assert.Equal(t, "s3cret", webhookCfg.Secret)
+
+	write("ftp://hooks.example.com")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "not an http(s) URL")
+}
+
+func TestLoadConfigIssueChannel(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(channelConfig string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "ops-tickets"
+    type: "issue"
+    config:
+`+channelConfig), 0644))
+	}
+	t.Setenv("MONRES_ISSUE_TOKEN_OPS_TICKETS", "s3cret")
+
+	write(`      provider: "github"
+      repository: "acme/infra"
+      labels: ["infra"]
+`)
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	issueCfg, err := GetIssueChannelConfig(cfg.NotificationChannels[0])
+	require.NoError(t, err)
+	assert.Equal(t, DefaultGitHubAPIURL, issueCfg.URL)
+	assert.Equal(t, []string{"infra"}, issueCfg.Labels)
+	assert.True(t, issueCfg.CloseOnResolve)
+	assert.Equal(t, "s3cret", issueCfg.Token)
+
+	write(`      provider: "jira"
+      url: "https://acme.atlassian.net/"
+      project: "OPS"
+      close_on_resolve: false
+`)
+	cfg, err = LoadConfig(configFile)
+	require.NoError(t, err)
+	issueCfg, err = GetIssueChannelConfig(cfg.NotificationChannels[0])
+	require.NoError(t, err)
+	assert.Equal(t, "https://acme.atlassian.net", issueCfg.URL)
+	assert.Equal(t, DefaultJiraIssueType, issueCfg.IssueType)
+	assert.Equal(t, DefaultJiraCloseTransition, issueCfg.CloseTransition)
+	assert.False(t, issueCfg.CloseOnResolve)
+
+	for _, tc := range []struct{ channelConfig, wantErr string }{
+		{"      provider: gitlab\n", "invalid provider"},
+		{"      provider: github\n      repository: infra\n", "owner/repo"},
+		{"      provider: jira\n      url: https://acme.atlassian.net\n", "project missing"},
+		{"      provider: jira\n      project: OPS\n", "not an http(s) URL"},
+		{"      provider: github\n      repository: acme/infra\n      labels: infra\n", "labels must be a list"},
+	} {
+		write(tc.channelConfig)
+		_, err := LoadConfig(configFile)
+		assert.ErrorContains(t, err, tc.wantErr, tc.channelConfig)
+	}
+}
+
+func TestLoadConfigOnCallChannel(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+notification_channels:
+  - name: "pager"
+    type: "zenduty"
+  - name: "oncall"
+    type: "grafana_oncall"
+    config:
+      url: "https://oncall.example.com/integrations/v1/formatted_webhook/abc/"
+`), 0644))
+	t.Setenv("MONRES_INTEGRATION_URL_PAGER", "https://www.zenduty.com/api/events/key/")
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	onCallCfg, err := GetOnCallChannelConfig(cfg.NotificationChannels[0])
+	require.NoError(t, err)
+	assert.Equal(t, OnCallChannelConfig{Service: OnCallZenduty, URL: "https://www.zenduty.com/api/events/key/"}, *onCallCfg)
+	onCallCfg, err = GetOnCallChannelConfig(cfg.NotificationChannels[1])
+	require.NoError(t, err)
+	assert.Equal(t, OnCallGrafana, onCallCfg.Service)
+
+	t.Setenv("MONRES_INTEGRATION_URL_PAGER", "")
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "channel 'pager': url missing")
+}
+
+func TestLoadConfigVoiceChannel(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(channels string) {
+		require.NoError(t, os.WriteFile(configFile, []byte("notification_channels:\n"+channels), 0644))
+	}
+	write(`  - name: "phone"
+    type: "voice"
+    min_severity: "critical"
+    config:
+      provider: "twilio"
+      account_sid: "AC123"
+      from: "+15550000"
+      to: "+15550001"
+  - name: "tg-call"
+    type: "voice"
+    config:
+      provider: "callmebot"
+      to: ["@alice", "@bob"]
+`)
+	t.Setenv("MONRES_VOICE_TOKEN_PHONE", "s3cret")
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	assert.Equal(t, SeverityCritical, cfg.NotificationChannels[0].MinSeverity)
+	voiceCfg, err := GetVoiceChannelConfig(cfg.NotificationChannels[0])
+	require.NoError(t, err)
+	assert.Equal(t, VoiceChannelConfig{Provider: VoiceProviderTwilio, URL: DefaultTwilioAPIURL, AccountSID: "AC123", From: "+15550000", To: []string{"+15550001"}, Token: "s3cret"}, *voiceCfg)
+	voiceCfg, err = GetVoiceChannelConfig(cfg.NotificationChannels[1])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@alice", "@bob"}, voiceCfg.To)
+	assert.Equal(t, DefaultCallMeBotURL, voiceCfg.URL)
+
+	write(`  - {name: "phone", type: "voice", config: {provider: "twilio", to: "+15550001"}}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "account_sid and from are required")
+
+	write(`  - {name: "phone", type: "voice", config: {provider: "callmebot"}}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "channel 'phone': to missing")
+
+	write(`  - {name: "phone", type: "stdout", min_severity: "urgent"}
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "invalid min_severity 'urgent'")
+}
+
+func TestLoadConfigRulesFiles(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "rules.d"), 0755))
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+rules_files: ["rules.d/*.yaml"]
+alerts:
+  - name: "CPU"
+    metric: "cpu_percent_total"
+    condition: ">"
+    threshold: 90
+    channels: ["console"]
+notification_channels:
+  - name: "console"
+    type: "stdout"
+`), 0600))
+	writeRules := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "rules.d", name), []byte(content), 0644))
+	}
+	writeRules("disk.yaml", `
+alerts:
+  - name: "Disk"
+    metric: "disk_percent_used"
+    condition: ">"
+    threshold: 80
+    channels: ["console"]
+`)
+	writeRules("empty.yaml", "")
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.Alerts, 2)
+	assert.Equal(t, "Disk", cfg.Alerts[1].Name)
+	assert.Equal(t, 80.0, cfg.Alerts[1].Tiers[0].Threshold, "rules from rules files are validated too")
+
+	writeRules("evil.yaml", `
+notification_channels:
+  - name: "console"
+    type: "webhook"
+    config:
+      url: "https://attacker.example.com"
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "only 'alerts' is allowed")
+
+	writeRules("evil.yaml", `
+alerts:
+  - name: "CPU"
+    metric: "cpu_percent_total"
+    condition: ">"
+    threshold: 10
+    channels: ["console"]
+`)
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "already defined")
+
+	require.NoError(t, os.WriteFile(configFile, []byte(`rules_files: ["missing.yaml"]`), 0600))
+	_, err = LoadConfig(configFile)
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+func TestLoadConfigEnabledAndOverride(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := `
+alerts:
+  - name: "CPU"
+    metric: "cpu_percent_total"
+    condition: ">"
+    thresholds: {warning: 80, critical: 90}
+    override: {threshold: 98}
+    override_until: "2999-01-01T00:00:00Z"
+    channels: ["console"]
+  - name: "Disk"
+    metric: "disk_percent_used"
+    condition: ">"
+    threshold: 80
+    override: {threshold: 95}
+    override_until: "2000-01-01"
+    channels: ["console"]
+  - name: "Memory"
+    metric: "mem_percent_used"
+    condition: ">"
+    threshold: 90
+    enabled: false
+    channels: ["console"]
+notification_channels:
+  - name: "console"
+    type: "stdout"
+    enabled: false
+`
+	require.NoError(t, os.WriteFile(configFile, []byte(yaml), 0644))
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+
+	require.Len(t, cfg.Alerts, 2, "disabled rules are dropped")
+	cpu := cfg.Alerts[0]
+	assert.Equal(t, []ThresholdTier{{Severity: SeverityCritical, Threshold: 98}}, cpu.Tiers)
+	assert.Equal(t, time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC), cpu.OverrideUntil)
+	assert.False(t, cpu.RevertOverride(time.Now()))
+	assert.True(t, cpu.RevertOverride(cpu.OverrideUntil))
+	assert.Equal(t, []ThresholdTier{{Severity: SeverityWarning, Threshold: 80}, {Severity: SeverityCritical, Threshold: 90}}, cpu.Tiers)
+	assert.Equal(t, 90.0, cpu.Threshold)
+
+	disk := cfg.Alerts[1]
+	assert.Equal(t, 80.0, disk.Threshold, "expired overrides are ignored")
+	assert.True(t, disk.OverrideUntil.IsZero())
+
+	assert.False(t, cfg.NotificationChannels[0].IsEnabled())
+
+	for name, rule := range map[string]string{
+		"missing until": "override: {threshold: 1}",
+		"bad until":     "override: {threshold: 1}\n    override_until: tomorrow",
+	} {
+		require.NoError(t, os.WriteFile(configFile, []byte(`
+alerts:
+  - name: "CPU"
+    metric: "cpu_percent_total"
+    condition: ">"
+    threshold: 90
+    channels: ["console"]
+    `+rule+`
+`), 0644))
+		_, err := LoadConfig(configFile)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadConfigActions(t *testing.T) {
+	rule := `
+alerts:
+  - name: "nginx down"
+    metric: "check_nginx_status"
+    condition: ">"
+    threshold: 0
+    channels: ["console"]
+    capture: ["systemctl status nginx"]
+    actions:
+%s
+notification_channels:
+  - name: "console"
+    type: "stdout"
+`
+	testCases := []struct {
+		name    string
+		actions string
+		wantErr string
+	}{
+		{"valid", "      - restart_unit: nginx\n        after: 2m\n      - name: flush\n        command: [\"/usr/local/bin/flush-cache\", \"--all\"]\n        timeout: 10s\n        max_per_hour: 3", ""},
+		{"both", "      - restart_unit: nginx\n        command: [\"true\"]", "exactly one of command or restart_unit"},
+		{"neither", "      - name: nothing", "exactly one of command or restart_unit"},
+		{"bad_after", "      - restart_unit: nginx\n        after: soon", "invalid after"},
+		{"bad_max", "      - restart_unit: nginx\n        max_per_hour: -1", "negative max_per_hour"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(rule, tc.actions)), 0644))
+
+			cfg, err := LoadConfig(configFile)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"systemctl status nginx"}, cfg.Alerts[0].Capture)
+			actions := cfg.Alerts[0].Actions
+			require.Len(t, actions, 2)
+			assert.Equal(t, "systemctl restart nginx", actions[0].Name)
+			assert.Equal(t, []string{"systemctl", "restart", "nginx"}, actions[0].Command)
+			assert.Equal(t, 2*time.Minute, actions[0].After)
+			assert.Equal(t, DefaultActionTimeout, actions[0].Timeout)
+			assert.Equal(t, DefaultActionMaxPerHour, actions[0].MaxPerHour)
+			assert.Equal(t, "flush", actions[1].Name)
+			assert.Equal(t, 10*time.Second, actions[1].Timeout)
+			assert.Equal(t, 3, actions[1].MaxPerHour)
+		})
+	}
+}
+
+func TestLoadConfigDedupe(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	for yaml, wantErr := range map[string]string{
+		"dedupe:\n  window: 1h\n  path: /var/lib/monres/dedupe.json\n": "",
+		"dedupe:\n  window: 0s\n":                                        "must be positive",
+		"dedupe:\n  path: /var/lib/monres/dedupe.json\n":                 "window is not",
+	} {
+		require.NoError(t, os.WriteFile(configFile, []byte(yaml), 0644))
+		cfg, err := LoadConfig(configFile)
+		if wantErr != "" {
+			assert.ErrorContains(t, err, wantErr)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, cfg.Dedupe.Window)
+	}
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaURL identifies the JSON Schema dialect of JSONSchema.
+const SchemaURL = "https://json-schema.org/draft/2020-12/schema"
+
+// channelConfigTypes maps each channel type to the struct its "config" map
+// is read into. The yaml tags of the struct are the keys the map accepts.
+var channelConfigTypes = map[string]reflect.Type{
+	"email":         reflect.TypeFor[EmailChannelConfig](),
+	"telegram":      reflect.TypeFor[TelegramChannelConfig](),
+	"stdout":        reflect.TypeFor[StdoutChannelConfig](),
+	"webhook":       reflect.TypeFor[WebhookChannelConfig](),
+	"issue":         reflect.TypeFor[IssueChannelConfig](),
+	"voice":         reflect.TypeFor[VoiceChannelConfig](),
+	OnCallGrafana:   reflect.TypeFor[OnCallChannelConfig](),
+	OnCallSquadcast: reflect.TypeFor[OnCallChannelConfig](),
+	OnCallZenduty:   reflect.TypeFor[OnCallChannelConfig](),
+}
+
+// JSONSchema returns a JSON Schema of the config file, derived from the yaml
+// tags of Config, for editors and CI linting. Unknown keys are not allowed,
+// like in LoadConfig, including in the "config" map of each channel type.
+func JSONSchema() map[string]any {
+	defs := make(map[string]any)
+	root := structSchema(reflect.TypeFor[Config](), defs)
+	root["$schema"] = SchemaURL
+	root["title"] = "monres configuration"
+	root["$defs"] = defs
+	addChannelConfigSchemas(defs)
+	return root
+}
+
+// addChannelConfigSchemas restricts the type of the channels to the known
+// ones and describes the "config" map of each of them.
+func addChannelConfigSchemas(defs map[string]any) {
+	channel := defs["NotificationChannelConfig"].(map[string]any)
+	types := make([]string, 0, len(channelConfigTypes))
+	for typ := range channelConfigTypes {
+		types = append(types, typ)
+	}
+	slices.Sort(types)
+	channel["properties"].(map[string]any)["type"] = map[string]any{"type": "string", "enum": types}
+
+	var byType []any
+	for _, typ := range types {
+		config := structSchema(channelConfigTypes[typ], defs)
+		if typ == "voice" { // A single callee may be given as a string
+			props := config["properties"].(map[string]any)
+			props["to"] = map[string]any{"oneOf": []any{props["to"], map[string]any{"type": "string"}}}
+		}
+		byType = append(byType, map[string]any{
+			"if":   map[string]any{"properties": map[string]any{"type": map[string]any{"const": typ}}, "required": []any{"type"}},
+			"then": map[string]any{"properties": map[string]any{"config": config}},
+		})
+	}
+	channel["allOf"] = byType
+}
+
+// typeSchema returns the schema of a value of type t, adding the structs it
+// refers to to defs.
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil // Placeholder, in case of recursion
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{} // Anything, e.g. interface{}
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	properties := make(map[string]any)
+	for _, f := range yamlFields(t) {
+		properties[f.name] = typeSchema(f.typ, defs)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+type yamlField struct {
+	name string
+	typ  reflect.Type
+}
+
+// yamlFields returns the keys a struct accepts in YAML, in declaration order.
+func yamlFields(t reflect.Type) []yamlField {
+	var fields []yamlField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+			continue // Derived
+		case strings.Contains(opts, "inline"):
+			fields = append(fields, yamlFields(f.Type)...)
+			continue
+		case name == "":
+			name = strings.ToLower(f.Name)
+		}
+		fields = append(fields, yamlField{name: name, typ: f.Type})
+	}
+	return fields
+}
+
+// configTypes maps the names yaml uses in errors ("config.AlertRuleConfig")
+// to the struct types of the config file.
+func configTypes() map[string]reflect.Type {
+	types := make(map[string]reflect.Type)
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			walk(t.Elem())
+		case reflect.Struct:
+			if _, seen := types[t.String()]; seen {
+				return
+			}
+			types[t.String()] = t
+			for _, f := range yamlFields(t) {
+				walk(f.typ)
+			}
+		}
+	}
+	walk(reflect.TypeFor[Config]())
+	walk(reflect.TypeFor[RulesFile]())
+	return types
+}
+
+// unknownFieldPattern matches yaml's error for keys rejected by KnownFields.
+var unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (\S+) not found in type (\S+)$`)
+
+// explainUnknownFields rewrites the unknown key errors of a yaml decoder
+// into "unknown key" errors suggesting the closest valid key.
+func explainUnknownFields(err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	types := configTypes()
+	msgs := make([]string, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		m := unknownFieldPattern.FindStringSubmatch(msg)
+		if m == nil {
+			msgs = append(msgs, msg)
+			continue
+		}
+		msg = fmt.Sprintf("line %s: unknown key '%s'", m[1], m[2])
+		if t, ok := types[m[3]]; ok {
+			var names []string
+			for _, f := range yamlFields(t) {
+				names = append(names, f.name)
+			}
+			if s := suggest(m[2], names); s != "" {
+				msg += fmt.Sprintf(" (did you mean '%s'?)", s)
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// checkChannelConfigKeys rejects the keys of the channels' "config" maps
+// that their channel type doesn't read, like explainUnknownFields does for
+// the rest of the file. Channels of unknown types are left to LoadConfig.
+func checkChannelConfigKeys(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil // Already reported by the decoder, or an empty file
+	}
+	channels := mappingValue(doc.Content[0], "notification_channels")
+	if channels == nil || channels.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var msgs []string
+	for _, channel := range channels.Content {
+		typ := mappingValue(channel, "type")
+		config := mappingValue(channel, "config")
+		if typ == nil || config == nil {
+			continue
+		}
+		t, ok := channelConfigTypes[typ.Value]
+		if !ok {
+			continue
+		}
+		name := typ.Value
+		if n := mappingValue(channel, "name"); n != nil {
+			name = n.Value
+		}
+		var names []string
+		for _, f := range yamlFields(t) {
+			names = append(names, f.name)
+		}
+		for _, key := range mappingKeys(config) {
+			if slices.Contains(names, key.Value) {
+				continue
+			}
+			msg := fmt.Sprintf("line %d: unknown key '%s' in the config of channel '%s'", key.Line, key.Value, name)
+			if s := suggest(key.Value, names); s != "" {
+				msg += fmt.Sprintf(" (did you mean '%s'?)", s)
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a YAML mapping, following
+// aliases, or nil if node isn't a mapping holding key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+			if value.Kind == yaml.AliasNode {
+				value = value.Alias
+			}
+			return value
+		}
+	}
+	return nil
+}
+
+// mappingKeys returns the keys of a YAML mapping, including those merged
+// into it with "<<".
+func mappingKeys(node *yaml.Node) []*yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	var keys []*yaml.Node
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "<<" {
+				keys = append(keys, mappingKeys(node.Content[i+1])...)
+				continue
+			}
+			keys = append(keys, node.Content[i])
+		}
+	case yaml.SequenceNode: // "<<: [*a, *b]"
+		for _, merged := range node.Content {
+			keys = append(keys, mappingKeys(merged)...)
+		}
+	}
+	return keys
+}
+
+// suggest returns the candidate closest to name, or "" if none is close
+// enough to be a likely typo.
+func suggest(name string, candidates []string) string {
+	best, bestDist := "", max(1, len(name)/3)+1
+	for _, c := range candidates {
+		if d := editDistance(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
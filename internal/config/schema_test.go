@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema()
+	_, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	assert.Equal(t, SchemaURL, schema["$schema"])
+	assert.Equal(t, false, schema["additionalProperties"])
+	props := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "integer"}, props["interval_seconds"])
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/AlertRuleConfig"}}, props["alerts"])
+	assert.NotContains(t, props, "CollectionInterval", "derived fields are not part of the file")
+
+	defs := schema["$defs"].(map[string]any)
+	rule := defs["AlertRuleConfig"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string"}, rule["aggregation"])
+	assert.Equal(t, map[string]any{"type": "boolean"}, rule["notify_on_resolve"])
+	assert.Equal(t, map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "number"}}, rule["thresholds"])
+	assert.NotContains(t, rule, "duration_str")
+
+	channel := defs["NotificationChannelConfig"].(map[string]any)
+	assert.Contains(t, channel["properties"].(map[string]any)["type"].(map[string]any)["enum"], "telegram")
+	var telegram map[string]any
+	for _, c := range channel["allOf"].([]any) {
+		c := c.(map[string]any)
+		if c["if"].(map[string]any)["properties"].(map[string]any)["type"].(map[string]any)["const"] == "telegram" {
+			telegram = c["then"].(map[string]any)["properties"].(map[string]any)["config"].(map[string]any)
+		}
+	}
+	require.NotNil(t, telegram)
+	assert.Equal(t, false, telegram["additionalProperties"])
+	assert.Equal(t, map[string]any{"type": "string"}, telegram["properties"].(map[string]any)["threading"])
+	assert.Contains(t, telegram["properties"], "quick_resolve_max_age")
+	assert.NotContains(t, telegram["properties"], "proxy", "set on the channel, not in its config")
+}
+
+func TestLoadConfigUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"top level", "interval_second: 10\n", "line 1: unknown key 'interval_second' (did you mean 'interval_seconds'?)"},
+		{"alert rule", "alerts:\n  - name: cpu\n    metric: cpu_percent_total\n    agregation: max\n", "line 4: unknown key 'agregation' (did you mean 'aggregation'?)"},
+		{"no suggestion", "alerts:\n  - name: cpu\n    color: red\n", "line 3: unknown key 'color'"},
+		{"channel config", "notification_channels:\n  - name: mail\n    type: email\n    config:\n      smtp_host: smtp.example.com\n      smtp_use_tsl: true\n",
+			"line 6: unknown key 'smtp_use_tsl' in the config of channel 'mail' (did you mean 'smtp_use_tls'?)"},
+		{"merged channel config", "notification_channels:\n  - name: chat\n    type: telegram\n    config: &chat\n      theading: edit\n  - name: ops\n    type: telegram\n    config:\n      <<: *chat\n      chat_id: \"1\"\n",
+			"line 5: unknown key 'theading' in the config of channel 'ops' (did you mean 'threading'?)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tt.yaml), 0644))
+			_, err := LoadConfig(configFile)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			if tt.name == "no suggestion" {
+				assert.NotContains(t, err.Error(), "did you mean")
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"aggregation", "duration", "channels"}
+	assert.Equal(t, "aggregation", suggest("agregation", candidates))
+	assert.Equal(t, "duration", suggest("duraton", candidates))
+	assert.Equal(t, "channels", suggest("channel", candidates))
+	assert.Equal(t, "", suggest("severity", candidates))
+}
@@ -7,18 +7,77 @@ import (
 	"github.com/mattmezza/monres/internal/config"
 )
 
+// DataPoint is a sample, or a downsampled aggregate of several samples.
+// For raw samples Count is 0 or 1 and Min/Max are unused.
 type DataPoint struct {
-	Timestamp time.Time
-	Value     float64
+	Timestamp time.Time // For aggregates: time of the first sample
+	Value     float64   // For aggregates: average of the samples
+	Min       float64
+	Max       float64
+	Count     int
 }
 
+// Weight returns the number of samples the point represents.
+func (dp DataPoint) Weight() int {
+	if dp.Count > 1 {
+		return dp.Count
+	}
+	return 1
+}
+
+// MinValue returns the smallest sample the point represents.
+func (dp DataPoint) MinValue() float64 {
+	if dp.Count > 1 {
+		return dp.Min
+	}
+	return dp.Value
+}
+
+// MaxValue returns the largest sample the point represents.
+func (dp DataPoint) MaxValue() float64 {
+	if dp.Count > 1 {
+		return dp.Max
+	}
+	return dp.Value
+}
+
+// Defaults for downsampling, used when the history window is longer than
+// DefaultRawRetention: older samples are merged into DefaultResolution buckets.
+const (
+	DefaultRawRetention = 15 * time.Minute
+	DefaultResolution   = time.Minute
+)
+
+// staleSweepInterval is how often AddDataPoint looks for stale series.
+const staleSweepInterval = time.Minute
+
 type MetricHistoryBuffer struct {
 	sync.RWMutex
-	buffers       map[string][]DataPoint // metricName -> []DataPoint
-	maxDataPoints int                    // Max data points to keep per metric
+	buffers       map[string][]DataPoint // metricName -> []DataPoint (raw samples)
+	maxDataPoints int                    // Max raw data points to keep per metric
+
+	// Downsampling of samples that fall out of the raw window; disabled when resolution is 0
+	downsampled   map[string][]DataPoint // metricName -> per-resolution aggregates, oldest first
+	resolution    time.Duration
+	maxAggregates int
+
+	// Series without a sample for keepSeries are dropped, e.g. those of a
+	// container that is gone; disabled when keepSeries is 0. It is at least
+	// the history window, and long enough for auto_resolve_after to see a
+	// metric stop reporting (see KeepSeriesFor).
+	keepSeries time.Duration
+	lastSweep  time.Time
 }
 
 func NewMetricHistoryBuffer(maxAge time.Duration, collectionInterval time.Duration) *MetricHistoryBuffer {
+	return NewDownsampledMetricHistoryBuffer(maxAge, collectionInterval, DefaultRawRetention, DefaultResolution)
+}
+
+// NewDownsampledMetricHistoryBuffer creates a buffer that keeps raw samples for
+// rawRetention and per-resolution min/avg/max aggregates for the rest of maxAge.
+// Downsampling only kicks in when maxAge exceeds rawRetention and resolution
+// is coarser than the collection interval; otherwise every sample is kept.
+func NewDownsampledMetricHistoryBuffer(maxAge, collectionInterval, rawRetention, resolution time.Duration) *MetricHistoryBuffer {
 	if maxAge <= 0 || collectionInterval <= 0 { // Should not happen with config validation
 		maxDataPoints := 60 // Default to 60 points if params are weird.
 		return &MetricHistoryBuffer{
@@ -26,23 +85,38 @@ func NewMetricHistoryBuffer(maxAge time.Duration, collectionInterval time.Durati
 			maxDataPoints: maxDataPoints,
 		}
 	}
-	maxDataPoints := int(maxAge.Seconds()/collectionInterval.Seconds()) + 1 // +1 for safety
-	if maxDataPoints < 2 { // Need at least 2 points for some calcs or reasonable history
-		maxDataPoints = 2
+
+	hb := &MetricHistoryBuffer{buffers: make(map[string][]DataPoint), keepSeries: maxAge}
+	rawAge := maxAge
+	if rawRetention > 0 && maxAge > rawRetention && resolution > collectionInterval {
+		rawAge = rawRetention
+		hb.resolution = resolution
+		hb.downsampled = make(map[string][]DataPoint)
+		hb.maxAggregates = int((maxAge-rawRetention)/resolution) + 2 // +2 for partial buckets at both ends
 	}
 
-	return &MetricHistoryBuffer{
-		buffers:       make(map[string][]DataPoint),
-		maxDataPoints: maxDataPoints,
+	maxDataPoints := int(rawAge.Seconds()/collectionInterval.Seconds()) + 1 // +1 for safety
+	if maxDataPoints < 2 { // Need at least 2 points for some calcs or reasonable history
+		maxDataPoints = 2
 	}
+	hb.maxDataPoints = maxDataPoints
+	return hb
 }
 
 // AddDataPoint adds a new data point for a metric.
-// It evicts the oldest point if the buffer for that metric exceeds maxDataPoints.
+// The oldest point is evicted once the buffer for that metric exceeds
+// maxDataPoints; with downsampling it is merged into an aggregate instead.
+// Metrics without a point for the history window, or for KeepSeriesFor if
+// longer, are dropped altogether.
 func (hb *MetricHistoryBuffer) AddDataPoint(metricName string, value float64, timestamp time.Time) {
 	hb.Lock()
 	defer hb.Unlock()
 
+	if hb.keepSeries > 0 && timestamp.Sub(hb.lastSweep) >= staleSweepInterval {
+		hb.dropStale(timestamp.Add(-hb.keepSeries))
+		hb.lastSweep = timestamp
+	}
+
 	points, exists := hb.buffers[metricName]
 	if !exists {
 		points = make([]DataPoint, 0, hb.maxDataPoints)
@@ -51,11 +125,65 @@ func (hb *MetricHistoryBuffer) AddDataPoint(metricName string, value float64, ti
 	points = append(points, DataPoint{Timestamp: timestamp, Value: value})
 
 	if len(points) > hb.maxDataPoints {
-		points = points[len(points)-hb.maxDataPoints:] // Keep the newest N points
+		evicted := points[:len(points)-hb.maxDataPoints]
+		if hb.resolution > 0 {
+			for _, dp := range evicted {
+				hb.downsample(metricName, dp)
+			}
+		}
+		// Keep the newest N points, copying them down so the backing array doesn't grow forever
+		points = append(points[:0], points[len(points)-hb.maxDataPoints:]...)
 	}
 	hb.buffers[metricName] = points
 }
 
+// KeepSeriesFor keeps the latest point of a metric that stopped reporting
+// for at least d, so that rules with auto_resolve_after d still find when
+// it last reported. It never shortens how long series are kept.
+func (hb *MetricHistoryBuffer) KeepSeriesFor(d time.Duration) {
+	hb.Lock()
+	defer hb.Unlock()
+	if hb.keepSeries > 0 && d > hb.keepSeries {
+		hb.keepSeries = d
+	}
+}
+
+// dropStale drops the metrics without a point since cutoff. Their
+// aggregates, all older than their raw points, go with them.
+func (hb *MetricHistoryBuffer) dropStale(cutoff time.Time) {
+	for name, points := range hb.buffers {
+		if len(points) == 0 || points[len(points)-1].Timestamp.Before(cutoff) {
+			delete(hb.buffers, name)
+			delete(hb.downsampled, name)
+		}
+	}
+}
+
+// downsample merges a raw sample into the aggregate of its resolution bucket.
+func (hb *MetricHistoryBuffer) downsample(metricName string, dp DataPoint) {
+	aggs := hb.downsampled[metricName]
+	bucket := dp.Timestamp.Truncate(hb.resolution)
+
+	if n := len(aggs); n > 0 && aggs[n-1].Timestamp.Truncate(hb.resolution).Equal(bucket) {
+		agg := &aggs[n-1]
+		agg.Value = (agg.Value*float64(agg.Count) + dp.Value) / float64(agg.Count+1)
+		if dp.Value < agg.Min {
+			agg.Min = dp.Value
+		}
+		if dp.Value > agg.Max {
+			agg.Max = dp.Value
+		}
+		agg.Count++
+		return
+	}
+
+	aggs = append(aggs, DataPoint{Timestamp: dp.Timestamp, Value: dp.Value, Min: dp.Value, Max: dp.Value, Count: 1})
+	if len(aggs) > hb.maxAggregates {
+		aggs = append(aggs[:0], aggs[len(aggs)-hb.maxAggregates:]...)
+	}
+	hb.downsampled[metricName] = aggs
+}
+
 // GetDataPointsForDuration retrieves data points for a specific metric within the given duration.
 // It returns points whose Timestamp is within [now - duration, now].
 func (hb *MetricHistoryBuffer) GetDataPointsForDuration(metricName string, duration time.Duration, now time.Time) []DataPoint {
@@ -79,15 +207,31 @@ func (hb *MetricHistoryBuffer) GetDataPointsForDuration(metricName string, durat
 	for i := len(points) - 1; i >= 0; i-- { // Iterate backwards for efficiency
 		dp := points[i]
 		if dp.Timestamp.Before(startTime) {
-			break // Older points are not needed
+			return reverse(result) // Older points are not needed
 		}
 		result = append(result, dp) // Will be in reverse chronological order
 	}
-    // Reverse result to be chronological
-    for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
-        result[i], result[j] = result[j], result[i]
-    }
-	return result
+
+	// The raw window is exhausted; continue with aggregates overlapping the window.
+	// An aggregate is included whole, so the result may reach up to one
+	// resolution further back than startTime.
+	aggs := hb.downsampled[metricName]
+	for i := len(aggs) - 1; i >= 0; i-- {
+		agg := aggs[i]
+		if !agg.Timestamp.Truncate(hb.resolution).Add(hb.resolution).After(startTime) {
+			break
+		}
+		result = append(result, agg)
+	}
+	return reverse(result)
+}
+
+// reverse puts points collected newest-first back in chronological order.
+func reverse(points []DataPoint) []DataPoint {
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	return points
 }
 
 // GetLatestDataPoint returns the most recent data point for a metric, if any.
@@ -102,6 +246,88 @@ func (hb *MetricHistoryBuffer) GetLatestDataPoint(metricName string) (DataPoint,
 	return points[len(points)-1], true
 }
 
+// Coverage describes how much history is held for a metric.
+type Coverage struct {
+	Oldest time.Time // Timestamp of the oldest sample held
+	Newest time.Time // Timestamp of the newest sample held
+	Points int       // Number of samples held, counting every sample merged into an aggregate
+}
+
+// Span returns how far back the history reaches from now.
+func (c Coverage) Span(now time.Time) time.Duration {
+	if c.Points == 0 {
+		return 0
+	}
+	return now.Sub(c.Oldest)
+}
+
+// Coverage reports how much history is held for a metric.
+func (hb *MetricHistoryBuffer) Coverage(metricName string) (Coverage, bool) {
+	hb.RLock()
+	defer hb.RUnlock()
+	return hb.coverage(metricName)
+}
+
+// AllCoverage reports the coverage of every metric in the buffer.
+func (hb *MetricHistoryBuffer) AllCoverage() map[string]Coverage {
+	hb.RLock()
+	defer hb.RUnlock()
+
+	result := make(map[string]Coverage, len(hb.buffers))
+	for name := range hb.buffers {
+		if c, ok := hb.coverage(name); ok {
+			result[name] = c
+		}
+	}
+	return result
+}
+
+// coverage computes a metric's coverage. Callers hold the read lock.
+func (hb *MetricHistoryBuffer) coverage(metricName string) (Coverage, bool) {
+	points := hb.buffers[metricName]
+	if len(points) == 0 {
+		return Coverage{}, false
+	}
+	c := Coverage{
+		Oldest: points[0].Timestamp,
+		Newest: points[len(points)-1].Timestamp,
+		Points: len(points),
+	}
+	aggs := hb.downsampled[metricName]
+	if len(aggs) > 0 {
+		c.Oldest = aggs[0].Timestamp
+	}
+	for _, agg := range aggs {
+		c.Points += agg.Weight()
+	}
+	return c, true
+}
+
+// NewForConfig creates a buffer large enough for the alert rules in cfg and
+// the retention of its history section, downsampling as configured there.
+func NewForConfig(cfg *config.Config, collectionInterval time.Duration) *MetricHistoryBuffer {
+	rawRetention, resolution := DefaultRawRetention, DefaultResolution
+	if cfg.History.RawRetention > 0 {
+		rawRetention = cfg.History.RawRetention
+	}
+	if cfg.History.Resolution > 0 {
+		resolution = cfg.History.Resolution
+	}
+	maxAge := max(GetMaxConfiguredDuration(cfg.Alerts, collectionInterval), cfg.History.Retention)
+	hb := NewDownsampledMetricHistoryBuffer(maxAge, collectionInterval, rawRetention, resolution)
+	hb.KeepSeriesFor(MaxAutoResolveAfter(cfg.Alerts))
+	return hb
+}
+
+// MaxAutoResolveAfter returns the longest auto_resolve_after of the rules.
+func MaxAutoResolveAfter(rules []config.AlertRuleConfig) time.Duration {
+	var longest time.Duration
+	for _, rule := range rules {
+		longest = max(longest, rule.AutoResolveAfter)
+	}
+	return longest
+}
+
 // GetMaxConfiguredDuration determines the maximum duration from all alert rules
 // This is used by the main app to initialize the history buffer appropriately.
 func GetMaxConfiguredDuration(rules []config.AlertRuleConfig, collectionInterval time.Duration) time.Duration {
@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
 )
 
 func TestNewMetricHistoryBuffer(t *testing.T) {
@@ -103,6 +105,23 @@ func TestAddDataPointEviction(t *testing.T) {
 	assert.Equal(t, 4.0, points[len(points)-1].Value) // most recent
 }
 
+func TestStaleMetricsDropped(t *testing.T) {
+	buffer := NewDownsampledMetricHistoryBuffer(time.Hour, time.Minute, 15*time.Minute, 5*time.Minute)
+	start := time.Now()
+	for i := 0; i < 30; i++ { // Enough to downsample
+		buffer.AddDataPoint("pod_a_cpu", 1, start.Add(time.Duration(i)*time.Minute))
+	}
+	require.NotEmpty(t, buffer.downsampled["pod_a_cpu"])
+
+	// pod_a is gone; pod_b keeps reporting
+	for i := 30; i < 100; i++ {
+		buffer.AddDataPoint("pod_b_cpu", 1, start.Add(time.Duration(i)*time.Minute))
+	}
+	assert.NotContains(t, buffer.buffers, "pod_a_cpu")
+	assert.NotContains(t, buffer.downsampled, "pod_a_cpu")
+	assert.Contains(t, buffer.buffers, "pod_b_cpu")
+}
+
 func TestGetLatestDataPoint(t *testing.T) {
 	buffer := NewMetricHistoryBuffer(5*time.Minute, 30*time.Second)
 	now := time.Now()
@@ -270,4 +289,92 @@ func TestMultipleMetrics(t *testing.T) {
 	
 	// Verify metrics are independent
 	assert.Len(t, buffer.buffers, 3)
-}
\ No newline at end of file
+}
+func TestDownsampling(t *testing.T) {
+	// 2h window at 5s: raw samples for 10m, 1m aggregates for the rest
+	buffer := NewDownsampledMetricHistoryBuffer(2*time.Hour, 5*time.Second, 10*time.Minute, time.Minute)
+	assert.Equal(t, 121, buffer.maxDataPoints)
+	assert.Equal(t, 112, buffer.maxAggregates)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var now time.Time
+	for i := 0; i < 3*60*12; i++ { // 3h of samples, value = minute of the hour
+		now = start.Add(time.Duration(i) * 5 * time.Second)
+		buffer.AddDataPoint("load", float64(i/12%60), now)
+	}
+
+	assert.Len(t, buffer.buffers["load"], 121)
+	assert.Len(t, buffer.downsampled["load"], 112)
+
+	agg := buffer.downsampled["load"][50]
+	assert.Equal(t, 12, agg.Count) // 12 samples per minute
+	assert.Equal(t, agg.Value, agg.MinValue())
+	assert.Equal(t, agg.Value, agg.MaxValue())
+
+	points := buffer.GetDataPointsForDuration("load", time.Hour, now)
+	require.NotEmpty(t, points)
+	// Chronological, covering the whole window
+	for i := 1; i < len(points); i++ {
+		assert.True(t, points[i].Timestamp.After(points[i-1].Timestamp))
+	}
+	assert.False(t, points[0].Timestamp.After(now.Add(-time.Hour)))
+	total := 0
+	for _, p := range points {
+		total += p.Weight()
+	}
+	assert.InDelta(t, 60*12, total, 12) // One hour of samples, give or take a bucket
+}
+
+func TestDownsamplingAggregates(t *testing.T) {
+	buffer := NewDownsampledMetricHistoryBuffer(time.Hour, time.Second, 2*time.Second, time.Minute)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, v := range []float64{4, 1, 7, 2, 9} {
+		buffer.AddDataPoint("m", v, start.Add(time.Duration(i)*time.Second))
+	}
+
+	aggs := buffer.downsampled["m"]
+	require.Len(t, aggs, 1)
+	assert.Equal(t, 2, aggs[0].Count) // 4 and 1 fell out of the 3 point raw window
+	assert.Equal(t, 2.5, aggs[0].Value)
+	assert.Equal(t, 1.0, aggs[0].MinValue())
+	assert.Equal(t, 4.0, aggs[0].MaxValue())
+	assert.Equal(t, start, aggs[0].Timestamp)
+}
+
+func TestNoDownsamplingForShortWindows(t *testing.T) {
+	buffer := NewMetricHistoryBuffer(10*time.Minute, 5*time.Second)
+	assert.Nil(t, buffer.downsampled)
+	assert.Equal(t, 121, buffer.maxDataPoints)
+}
+
+func TestCoverage(t *testing.T) {
+	buffer := NewDownsampledMetricHistoryBuffer(time.Hour, time.Second, 2*time.Second, time.Minute)
+	_, ok := buffer.Coverage("m")
+	assert.False(t, ok)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		buffer.AddDataPoint("m", float64(i), start.Add(time.Duration(i)*time.Second))
+	}
+
+	c, ok := buffer.Coverage("m")
+	require.True(t, ok)
+	assert.Equal(t, start, c.Oldest) // Reaches into the aggregates
+	assert.Equal(t, start.Add(4*time.Second), c.Newest)
+	assert.Equal(t, 5, c.Points)
+	assert.Equal(t, 10*time.Second, c.Span(start.Add(10*time.Second)))
+
+	all := buffer.AllCoverage()
+	assert.Equal(t, map[string]Coverage{"m": c}, all)
+}
+
+func TestNewForConfigRetention(t *testing.T) {
+	cfg := &config.Config{Alerts: []config.AlertRuleConfig{{Name: "cpu", Duration: 5 * time.Minute}}}
+	buffer := NewForConfig(cfg, 10*time.Second)
+	assert.Nil(t, buffer.downsampled) // Only the 5m window
+
+	cfg.History.Retention = 24 * time.Hour
+	buffer = NewForConfig(cfg, 10*time.Second)
+	assert.Equal(t, time.Minute, buffer.resolution)
+	assert.Equal(t, int((24*time.Hour-DefaultRawRetention)/time.Minute)+2, buffer.maxAggregates)
+}
@@ -0,0 +1,158 @@
+// Package i18n bundles the default notification templates and the number and
+// time formatting of the supported notification languages.
+package i18n
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default is the locale used when none is configured.
+const Default = "en"
+
+// Locale holds the defaults of one notification language.
+type Locale struct {
+	Code string // e.g. "de"
+	Name string // e.g. "Deutsch"
+
+	FiredTemplate    string // Default alert_fired template
+	ResolvedTemplate string // Default alert_resolved template
+
+	// Email subject prefixes
+	FiredSubject     string
+	AtStartupSubject string
+	ResolvedSubject  string
+	StaleSubject     string // Appended to ResolvedSubject in parentheses
+	ActionSubject    string // Of remediation action results, followed by the outcome in parentheses
+
+	TimeFormat       string // Go reference time layout of .FormattedTime
+	DecimalSeparator string
+}
+
+// FormatTime formats t with the locale's time layout.
+func (l *Locale) FormatTime(t time.Time) string {
+	return t.Format(l.TimeFormat)
+}
+
+// LocalizeNumber replaces the decimal point of a formatted value such as
+// "85.5%" with the locale's decimal separator.
+func (l *Locale) LocalizeNumber(formatted string) string {
+	if l.DecimalSeparator == "." {
+		return formatted
+	}
+	return strings.Replace(formatted, ".", l.DecimalSeparator, 1)
+}
+
+// topProcesses ends the default fired templates with the processes listed
+// for rules setting top_processes, one per line.
+const topProcesses = "{{range .TopProcesses}}\n- {{.}}{{end}}"
+
+// captures ends the default fired templates with the output of the rule's
+// capture commands, if any.
+const captures = "{{range .Captures}}\n\n$ {{.Command}}\n{{.Output}}{{if .Error}}\n({{.Error}}){{end}}{{end}}"
+
+// actionResult ends the default fired templates, which also render the
+// results of remediation actions, with the action's error and output.
+const actionResult = "{{with .Action}}{{with .Error}}\n({{.}}){{end}}{{with .Output}}\n{{.}}{{end}}{{end}}"
+
+// dashboardLink ends the default templates with the link rendered from the
+// dashboard_url_template, if one is configured.
+const dashboardLink = "{{with .DashboardURL}}\n{{.}}{{end}}"
+
+var locales = map[string]*Locale{
+	"en": {
+		Code:             "en",
+		Name:             "English",
+		FiredTemplate:    `{{if .Action}}REMEDIATION ACTION ({{.Action.Name}}): {{.Action.Status}}{{else if .AtStartup}}ALERT ALREADY FIRING AT STARTUP{{else}}ALERT FIRED{{end}}: {{.AlertName}} on {{.Hostname}}. Metric: {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Current: {{.FormattedMetricValue}}). Time: {{.FormattedTime}}` + topProcesses + captures + actionResult + dashboardLink,
+		ResolvedTemplate: `ALERT RESOLVED{{if .Stale}} (stale){{end}}: {{.AlertName}} on {{.Hostname}}. Time: {{.FormattedTime}}` + dashboardLink,
+		FiredSubject:     "ALERT FIRED",
+		AtStartupSubject: "ALERT ALREADY FIRING AT STARTUP",
+		ResolvedSubject:  "ALERT RESOLVED",
+		StaleSubject:     "STALE",
+		ActionSubject:    "REMEDIATION ACTION",
+		TimeFormat:       "2006-01-02 15:04:05",
+		DecimalSeparator: ".",
+	},
+	"de": {
+		Code:             "de",
+		Name:             "Deutsch",
+		FiredTemplate:    `{{if .Action}}KORREKTURMASSNAHME ({{.Action.Name}}): {{.Action.Status}}{{else if .AtStartup}}ALARM BEIM START BEREITS AKTIV{{else}}ALARM AUSGELÖST{{end}}: {{.AlertName}} auf {{.Hostname}}. Metrik: {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Aktuell: {{.FormattedMetricValue}}). Zeit: {{.FormattedTime}}` + topProcesses + captures + actionResult + dashboardLink,
+		ResolvedTemplate: `ALARM BEHOBEN{{if .Stale}} (veraltet){{end}}: {{.AlertName}} auf {{.Hostname}}. Zeit: {{.FormattedTime}}` + dashboardLink,
+		FiredSubject:     "ALARM AUSGELÖST",
+		AtStartupSubject: "ALARM BEIM START BEREITS AKTIV",
+		ResolvedSubject:  "ALARM BEHOBEN",
+		StaleSubject:     "VERALTET",
+		ActionSubject:    "KORREKTURMASSNAHME",
+		TimeFormat:       "02.01.2006 15:04:05",
+		DecimalSeparator: ",",
+	},
+	"fr": {
+		Code:             "fr",
+		Name:             "Français",
+		FiredTemplate:    `{{if .Action}}ACTION CORRECTIVE ({{.Action.Name}}): {{.Action.Status}}{{else if .AtStartup}}ALERTE DÉJÀ ACTIVE AU DÉMARRAGE{{else}}ALERTE DÉCLENCHÉE{{end}} : {{.AlertName}} sur {{.Hostname}}. Métrique : {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Actuelle : {{.FormattedMetricValue}}). Heure : {{.FormattedTime}}` + topProcesses + captures + actionResult + dashboardLink,
+		ResolvedTemplate: `ALERTE RÉSOLUE{{if .Stale}} (obsolète){{end}} : {{.AlertName}} sur {{.Hostname}}. Heure : {{.FormattedTime}}` + dashboardLink,
+		FiredSubject:     "ALERTE DÉCLENCHÉE",
+		AtStartupSubject: "ALERTE DÉJÀ ACTIVE AU DÉMARRAGE",
+		ResolvedSubject:  "ALERTE RÉSOLUE",
+		StaleSubject:     "OBSOLÈTE",
+		ActionSubject:    "ACTION CORRECTIVE",
+		TimeFormat:       "02/01/2006 15:04:05",
+		DecimalSeparator: ",",
+	},
+	"it": {
+		Code:             "it",
+		Name:             "Italiano",
+		FiredTemplate:    `{{if .Action}}AZIONE CORRETTIVA ({{.Action.Name}}): {{.Action.Status}}{{else if .AtStartup}}ALLARME GIÀ ATTIVO ALL'AVVIO{{else}}ALLARME ATTIVATO{{end}}: {{.AlertName}} su {{.Hostname}}. Metrica: {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Attuale: {{.FormattedMetricValue}}). Ora: {{.FormattedTime}}` + topProcesses + captures + actionResult + dashboardLink,
+		ResolvedTemplate: `ALLARME RIENTRATO{{if .Stale}} (dati non aggiornati){{end}}: {{.AlertName}} su {{.Hostname}}. Ora: {{.FormattedTime}}` + dashboardLink,
+		FiredSubject:     "ALLARME ATTIVATO",
+		AtStartupSubject: "ALLARME GIÀ ATTIVO ALL'AVVIO",
+		ResolvedSubject:  "ALLARME RIENTRATO",
+		StaleSubject:     "DATI NON AGGIORNATI",
+		ActionSubject:    "AZIONE CORRETTIVA",
+		TimeFormat:       "02/01/2006 15:04:05",
+		DecimalSeparator: ",",
+	},
+	"es": {
+		Code:             "es",
+		Name:             "Español",
+		FiredTemplate:    `{{if .Action}}ACCIÓN CORRECTIVA ({{.Action.Name}}): {{.Action.Status}}{{else if .AtStartup}}ALERTA YA ACTIVA AL INICIO{{else}}ALERTA ACTIVADA{{end}}: {{.AlertName}} en {{.Hostname}}. Métrica: {{.MetricName}} {{.Condition}} {{.FormattedThresholdValue}} (Actual: {{.FormattedMetricValue}}). Hora: {{.FormattedTime}}` + topProcesses + captures + actionResult + dashboardLink,
+		ResolvedTemplate: `ALERTA RESUELTA{{if .Stale}} (obsoleta){{end}}: {{.AlertName}} en {{.Hostname}}. Hora: {{.FormattedTime}}` + dashboardLink,
+		FiredSubject:     "ALERTA ACTIVADA",
+		AtStartupSubject: "ALERTA YA ACTIVA AL INICIO",
+		ResolvedSubject:  "ALERTA RESUELTA",
+		StaleSubject:     "OBSOLETA",
+		ActionSubject:    "ACCIÓN CORRECTIVA",
+		TimeFormat:       "02/01/2006 15:04:05",
+		DecimalSeparator: ",",
+	},
+}
+
+// Lookup returns the locale for a code such as "de". Region and encoding
+// suffixes are ignored ("de_CH.UTF-8" is "de"), and so is case.
+func Lookup(code string) (*Locale, bool) {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "_-."); i >= 0 {
+		code = code[:i]
+	}
+	l, ok := locales[code]
+	return l, ok
+}
+
+// Get returns the locale for code, or the default locale if code is unknown.
+func Get(code string) *Locale {
+	if l, ok := Lookup(code); ok {
+		return l
+	}
+	return locales[Default]
+}
+
+// Codes returns the codes of the bundled locales, sorted.
+func Codes() []string {
+	codes := make([]string, 0, len(locales))
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
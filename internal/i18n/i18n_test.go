@@ -0,0 +1,44 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	for _, code := range []string{"de", "DE", "de_CH.UTF-8", "de-AT"} {
+		l, ok := Lookup(code)
+		require.True(t, ok, code)
+		assert.Equal(t, "de", l.Code)
+	}
+	_, ok := Lookup("xx")
+	assert.False(t, ok)
+	assert.Equal(t, Default, Get("xx").Code)
+	assert.Contains(t, Codes(), Default)
+}
+
+func TestLocalesComplete(t *testing.T) {
+	for _, code := range Codes() {
+		l := Get(code)
+		assert.Equal(t, code, l.Code)
+		for name, value := range map[string]string{
+			"Name": l.Name, "FiredTemplate": l.FiredTemplate, "ResolvedTemplate": l.ResolvedTemplate,
+			"FiredSubject": l.FiredSubject, "AtStartupSubject": l.AtStartupSubject,
+			"ResolvedSubject": l.ResolvedSubject, "StaleSubject": l.StaleSubject, "ActionSubject": l.ActionSubject,
+			"TimeFormat": l.TimeFormat, "DecimalSeparator": l.DecimalSeparator,
+		} {
+			assert.NotEmpty(t, value, "%s: %s", code, name)
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	at := time.Date(2025, 3, 9, 14, 5, 0, 0, time.UTC)
+	assert.Equal(t, "2025-03-09 14:05:00", Get("en").FormatTime(at))
+	assert.Equal(t, "09.03.2025 14:05:00", Get("de").FormatTime(at))
+	assert.Equal(t, "85.5%", Get("en").LocalizeNumber("85.5%"))
+	assert.Equal(t, "1,5 GB/s", Get("fr").LocalizeNumber("1.5 GB/s"))
+}
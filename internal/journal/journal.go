@@ -0,0 +1,147 @@
+// Package journal writes log entries to the systemd journal through its
+// native datagram protocol, with priorities and structured fields.
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SocketPath is where journald listens for native protocol datagrams.
+const SocketPath = "/run/systemd/journal/socket"
+
+// Priority is a syslog priority level, as used by journalctl -p.
+type Priority int
+
+const (
+	PriEmerg Priority = iota
+	PriAlert
+	PriCrit
+	PriErr
+	PriWarning
+	PriNotice
+	PriInfo
+	PriDebug
+)
+
+// Available reports whether the journal socket exists on this host.
+func Available() bool {
+	_, err := os.Stat(SocketPath)
+	return err == nil
+}
+
+// Journal sends entries to journald.
+type Journal struct {
+	identifier string
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	mu         sync.Mutex
+	buf        bytes.Buffer
+}
+
+// Open connects to the journal at SocketPath. identifier becomes the
+// entries' SYSLOG_IDENTIFIER (shown by journalctl and matched by -t).
+func Open(identifier string) (*Journal, error) {
+	return OpenPath(SocketPath, identifier)
+}
+
+// OpenPath connects to a journal socket at path.
+func OpenPath(path, identifier string) (*Journal, error) {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal connection: %w", err)
+	}
+	return &Journal{
+		identifier: identifier,
+		conn:       conn,
+		addr:       &net.UnixAddr{Name: path, Net: "unixgram"},
+	}, nil
+}
+
+// Send writes one entry. Field names must consist of uppercase letters,
+// digits and underscores, and must not start with an underscore.
+func (j *Journal) Send(priority Priority, message string, fields map[string]string) error {
+	for name := range fields {
+		if !validFieldName(name) {
+			return fmt.Errorf("invalid journal field name %q", name)
+		}
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.buf.Reset()
+	writeField(&j.buf, "PRIORITY", strconv.Itoa(int(priority)))
+	writeField(&j.buf, "SYSLOG_IDENTIFIER", j.identifier)
+	writeField(&j.buf, "MESSAGE", message)
+	for name, value := range fields {
+		writeField(&j.buf, name, value)
+	}
+	if _, err := j.conn.WriteToUnix(j.buf.Bytes(), j.addr); err != nil {
+		return fmt.Errorf("failed to write to journal: %w", err)
+	}
+	return nil
+}
+
+// Write implements io.Writer for the log package: each call is one entry,
+// with the priority derived from the message (see PriorityFor).
+func (j *Journal) Write(p []byte) (int, error) {
+	message := strings.TrimSuffix(string(p), "\n")
+	if err := j.Send(PriorityFor(message), message, nil); err != nil {
+		// Don't lose the line if the journal went away
+		fmt.Fprintln(os.Stderr, message)
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the journal.
+func (j *Journal) Close() error {
+	return j.conn.Close()
+}
+
+// PriorityFor derives the priority of a log line from the prefixes used in
+// monres log messages ("FATAL:", "ERROR:", "Warning:", ...).
+func PriorityFor(message string) Priority {
+	switch {
+	case strings.HasPrefix(message, "FATAL"):
+		return PriCrit
+	case strings.HasPrefix(message, "ERROR"), strings.HasPrefix(message, "Error"), strings.HasPrefix(message, "Failed"):
+		return PriErr
+	case strings.HasPrefix(message, "Warning"), strings.HasPrefix(message, "WARNING"):
+		return PriWarning
+	default:
+		return PriInfo
+	}
+}
+
+// writeField appends a field in the native protocol's format: NAME=value
+// lines, or a length-prefixed value when it contains newlines.
+func writeField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+func validFieldName(name string) bool {
+	if name == "" || name[0] == '_' {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
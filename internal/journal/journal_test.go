@@ -0,0 +1,93 @@
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJournal listens like journald and returns received entries as field maps.
+func fakeJournal(t *testing.T) (path string, read func() map[string]string) {
+	path = filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return path, func() map[string]string {
+		buf := make([]byte, 65536)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		return parseEntry(t, buf[:n])
+	}
+}
+
+func parseEntry(t *testing.T, data []byte) map[string]string {
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		nl := bytes.IndexByte(data, '\n')
+		require.GreaterOrEqual(t, nl, 0)
+		line := data[:nl]
+		if eq := bytes.IndexByte(line, '='); eq >= 0 {
+			fields[string(line[:eq])] = string(line[eq+1:])
+			data = data[nl+1:]
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[nl+1:])
+		value := data[nl+9 : nl+9+int(size)]
+		fields[string(line)] = string(value)
+		data = data[nl+9+int(size)+1:]
+	}
+	return fields
+}
+
+func TestSend(t *testing.T) {
+	path, read := fakeJournal(t)
+	j, err := OpenPath(path, "monres")
+	require.NoError(t, err)
+	defer j.Close()
+
+	require.NoError(t, j.Send(PriCrit, "High CPU fired\nsecond line", map[string]string{"ALERT_NAME": "High CPU", "METRIC": "cpu_percent_total"}))
+	assert.Equal(t, map[string]string{
+		"PRIORITY":          "2",
+		"SYSLOG_IDENTIFIER": "monres",
+		"MESSAGE":           "High CPU fired\nsecond line",
+		"ALERT_NAME":        "High CPU",
+		"METRIC":            "cpu_percent_total",
+	}, read())
+
+	assert.Error(t, j.Send(PriInfo, "x", map[string]string{"alert": "lowercase"}))
+	assert.Error(t, j.Send(PriInfo, "x", map[string]string{"_PID": "trusted fields are set by journald"}))
+}
+
+func TestWrite(t *testing.T) {
+	path, read := fakeJournal(t)
+	j, err := OpenPath(path, "monres")
+	require.NoError(t, err)
+	defer j.Close()
+
+	_, err = j.Write([]byte("Warning: disk collector failed\n"))
+	require.NoError(t, err)
+	entry := read()
+	assert.Equal(t, "4", entry["PRIORITY"])
+	assert.Equal(t, "Warning: disk collector failed", entry["MESSAGE"])
+}
+
+func TestPriorityFor(t *testing.T) {
+	for message, expected := range map[string]Priority{
+		"FATAL: Failed to load configuration":             PriCrit,
+		"ERROR: No notification channels":                 PriErr,
+		"Error evaluating rule 'x'":                       PriErr,
+		"Failed to send notification for alert 'x'":       PriErr,
+		"Warning: Circuit breaker opened for channel 'x'": PriWarning,
+		"ALERT FIRED: High CPU [critical]":                PriInfo,
+		"Notification sent for alert 'x' via channel 'y'": PriInfo,
+	} {
+		assert.Equal(t, expected, PriorityFor(message), message)
+	}
+}
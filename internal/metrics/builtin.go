@@ -0,0 +1,60 @@
+package metrics
+
+// builtinMetrics describes every metric emitted by the built-in collectors.
+var builtinMetrics = []Metadata{
+	{Name: "cpu_percent_total", Unit: UnitPercent, Type: TypeGauge, Description: "Total CPU usage"},
+	{Name: "cpu_percent_user", Unit: UnitPercent, Type: TypeGauge, Description: "CPU time in user space, including niced processes"},
+	{Name: "cpu_percent_system", Unit: UnitPercent, Type: TypeGauge, Description: "CPU time in the kernel, including interrupts"},
+	{Name: "cpu_percent_iowait", Unit: UnitPercent, Type: TypeGauge, Description: "CPU time idle while waiting for I/O"},
+	{Name: "cpu_percent_steal", Unit: UnitPercent, Type: TypeGauge, Description: "CPU time taken by the hypervisor for other guests"},
+	{Name: "cpu_freq_mhz_avg", Unit: UnitNone, Type: TypeGauge, Description: "Average current CPU frequency in MHz"},
+	{Name: "cpu_throttle_events_ps", Unit: UnitNone, Type: TypeRate, Description: "Thermal throttle events per second, summed over CPUs"},
+	{Name: "temp_celsius_max", Unit: UnitCelsius, Type: TypeGauge, Description: "Temperature of the hottest thermal zone or hwmon sensor"},
+	{Name: "mem_percent_used", Unit: UnitPercent, Type: TypeGauge, Description: "Used memory (based on MemAvailable)"},
+	{Name: "mem_percent_free", Unit: UnitPercent, Type: TypeGauge, Description: "Free memory (based on MemAvailable)"},
+	{Name: "swap_percent_used", Unit: UnitPercent, Type: TypeGauge, Description: "Used swap"},
+	{Name: "swap_percent_free", Unit: UnitPercent, Type: TypeGauge, Description: "Free swap"},
+	{Name: "mem_total_bytes", Unit: UnitBytes, Type: TypeGauge, Description: "Total memory"},
+	{Name: "mem_available_bytes", Unit: UnitBytes, Type: TypeGauge, Description: "Memory available for new allocations (MemAvailable)"},
+	{Name: "mem_cached_bytes", Unit: UnitBytes, Type: TypeGauge, Description: "Page cache"},
+	{Name: "mem_dirty_bytes", Unit: UnitBytes, Type: TypeGauge, Description: "Memory waiting to be written back to disk"},
+	{Name: "swap_used_bytes", Unit: UnitBytes, Type: TypeGauge, Description: "Used swap"},
+	{Name: "hugepages_percent_used", Unit: UnitPercent, Type: TypeGauge, Description: "Used or reserved huge pages of the pool"},
+	{Name: "disk_read_bytes_ps", Unit: UnitBytesPerSecond, Type: TypeRate, Description: "Aggregated disk read throughput"},
+	{Name: "disk_write_bytes_ps", Unit: UnitBytesPerSecond, Type: TypeRate, Description: "Aggregated disk write throughput"},
+	{Name: "net_recv_bytes_ps", Unit: UnitBytesPerSecond, Type: TypeRate, Description: "Aggregated network receive throughput"},
+	{Name: "net_sent_bytes_ps", Unit: UnitBytesPerSecond, Type: TypeRate, Description: "Aggregated network transmit throughput"},
+	{Name: "fs_readonly_count", Unit: UnitNone, Type: TypeGauge, Description: "Disk and network filesystems mounted read-only"},
+	{Name: "cert_files_unreadable", Unit: UnitNone, Type: TypeGauge, Description: "Certificate files that could not be read or hold no certificate"},
+	{Name: "acme_cert_age_days_max", Unit: UnitNone, Type: TypeGauge, Description: "Days since the oldest ACME certificate was issued"},
+	{Name: "caddy_upstreams_up_percent", Unit: UnitPercent, Type: TypeGauge, Description: "Caddy reverse proxy upstreams that are healthy"},
+	{Name: "textfile_scrape_error", Unit: UnitNone, Type: TypeGauge, Description: "1 if any textfile could not be read or parsed"},
+}
+
+// builtinFamilies describes metric families whose names end in a per-instance
+// suffix. Name holds the shared prefix.
+var builtinFamilies = []Metadata{
+	{Name: "mem_free_bytes_node", Unit: UnitBytes, Type: TypeGauge, Description: "Free memory of a NUMA node"},
+	{Name: "mem_percent_free_node", Unit: UnitPercent, Type: TypeGauge, Description: "Free memory of a NUMA node"},
+	{Name: "temp_celsius_", Unit: UnitCelsius, Type: TypeGauge, Description: "Temperature of the thermal zone or hwmon sensor"},
+	{Name: "fs_readonly_", Unit: UnitNone, Type: TypeGauge, Description: "1 if the filesystem is mounted read-only, else 0"},
+	{Name: "disk_percent_used_", Unit: UnitPercent, Type: TypeGauge, Description: "Used space of the filesystem, excluding blocks reserved for root"},
+	{Name: "disk_bytes_free_", Unit: UnitBytes, Type: TypeGauge, Description: "Space of the filesystem available to unprivileged users"},
+	{Name: "mount_present_", Unit: UnitNone, Type: TypeGauge, Description: "1 if the expected filesystem is mounted, else 0"},
+	{Name: "port_listening_", Unit: UnitNone, Type: TypeGauge, Description: "1 if a TCP socket listens on the port, else 0"},
+	{Name: "haproxy_backend_up_", Unit: UnitNone, Type: TypeGauge, Description: "1 if the HAProxy backend is up, else 0"},
+	{Name: "haproxy_backend_queue_", Unit: UnitNone, Type: TypeGauge, Description: "Requests queued in the HAProxy backend"},
+	{Name: "haproxy_backend_servers_up_percent_", Unit: UnitPercent, Type: TypeGauge, Description: "Servers of the HAProxy backend that are up"},
+	{Name: "caddy_upstream_up_", Unit: UnitNone, Type: TypeGauge, Description: "1 if the Caddy upstream is healthy, else 0"},
+	{Name: "caddy_upstream_requests_", Unit: UnitNone, Type: TypeGauge, Description: "Active requests to the Caddy upstream"},
+	{Name: "queue_depth_", Unit: UnitNone, Type: TypeGauge, Description: "Messages in the RabbitMQ queue"},
+	{Name: "consumer_lag_", Unit: UnitNone, Type: TypeGauge, Description: "Messages of the Kafka topic not yet consumed by the group"},
+	{Name: "lvm_thinpool_data_percent_", Unit: UnitPercent, Type: TypeGauge, Description: "Data space used in the LVM thin pool"},
+	{Name: "lvm_thinpool_metadata_percent_", Unit: UnitPercent, Type: TypeGauge, Description: "Metadata space used in the LVM thin pool"},
+	{Name: "vm_running_", Unit: UnitNone, Type: TypeGauge, Description: "1 if the libvirt guest is running, else 0"},
+	{Name: "vm_cpu_percent_", Unit: UnitPercent, Type: TypeGauge, Description: "CPU use of the libvirt guest, relative to its vCPUs"},
+	{Name: "vm_memory_bytes_", Unit: UnitBytes, Type: TypeGauge, Description: "Host memory used by the libvirt guest"},
+	{Name: "pod_cpu_millicores_", Unit: UnitNone, Type: TypeGauge, Description: "CPU use of the Kubernetes pod, in thousandths of a core"},
+	{Name: "pod_memory_bytes_", Unit: UnitBytes, Type: TypeGauge, Description: "Working set memory of the Kubernetes pod"},
+	{Name: "collector_up_", Unit: UnitNone, Type: TypeGauge, Description: "1 if the collector succeeded in the last cycle, 0 if it failed or timed out"},
+}
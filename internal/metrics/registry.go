@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Unit describes how a metric value should be interpreted and displayed.
+type Unit string
+
+const (
+	UnitNone           Unit = ""
+	UnitPercent        Unit = "percent"
+	UnitBytes          Unit = "bytes"
+	UnitBytesPerSecond Unit = "bytes_per_second"
+	UnitSeconds        Unit = "seconds"
+	UnitCelsius        Unit = "celsius"
+)
+
+// KnownUnits lists the units accepted in configuration and textfile UNIT lines.
+var KnownUnits = []Unit{UnitNone, UnitPercent, UnitBytes, UnitBytesPerSecond, UnitSeconds, UnitCelsius}
+
+// Type describes the kind of a metric.
+type Type string
+
+const (
+	TypeGauge   Type = "gauge"
+	TypeRate    Type = "rate"
+	TypeCounter Type = "counter"
+)
+
+// Metadata describes a metric (or a family of metrics sharing a name prefix).
+type Metadata struct {
+	Name        string // Metric name, or the prefix for a family registered with RegisterPrefix
+	Unit        Unit
+	Description string
+	Type        Type
+}
+
+// Registry maps metric names to their metadata.
+// Exact names take precedence over prefix families; the longest prefix wins.
+type Registry struct {
+	mu       sync.RWMutex
+	exact    map[string]Metadata
+	prefixes map[string]Metadata
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		exact:    make(map[string]Metadata),
+		prefixes: make(map[string]Metadata),
+	}
+}
+
+// Register adds or replaces metadata for a single metric name.
+func (r *Registry) Register(md Metadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if md.Type == "" {
+		md.Type = TypeGauge
+	}
+	r.exact[md.Name] = md
+}
+
+// RegisterPrefix adds or replaces metadata for every metric whose name starts
+// with prefix (e.g. per-device metrics like "disk_percent_used_").
+func (r *Registry) RegisterPrefix(prefix string, md Metadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if md.Type == "" {
+		md.Type = TypeGauge
+	}
+	md.Name = prefix
+	r.prefixes[prefix] = md
+}
+
+// Lookup returns the metadata for a metric name, if any is registered.
+func (r *Registry) Lookup(name string) (Metadata, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if md, ok := r.exact[name]; ok {
+		return md, true
+	}
+	var best Metadata
+	found := false
+	for prefix, md := range r.prefixes {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best.Name) {
+			best = md
+			found = true
+		}
+	}
+	if found {
+		best.Name = name
+	}
+	return best, found
+}
+
+// All returns every registered entry (exact names and prefix families) sorted by name.
+func (r *Registry) All() []Metadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]Metadata, 0, len(r.exact)+len(r.prefixes))
+	for _, md := range r.exact {
+		all = append(all, md)
+	}
+	for _, md := range r.prefixes {
+		all = append(all, md)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Default is the process-wide registry, pre-populated with the built-in metrics.
+var Default = NewRegistry()
+
+func init() {
+	for _, md := range builtinMetrics {
+		Default.Register(md)
+	}
+	for _, md := range builtinFamilies {
+		Default.RegisterPrefix(md.Name, md)
+	}
+}
+
+// Register adds metadata for a metric to the Default registry.
+func Register(md Metadata) { Default.Register(md) }
+
+// RegisterPrefix adds metadata for a metric family to the Default registry.
+func RegisterPrefix(prefix string, md Metadata) { Default.RegisterPrefix(prefix, md) }
+
+// Lookup returns metadata for a metric from the Default registry.
+func Lookup(name string) (Metadata, bool) { return Default.Lookup(name) }
+
+// IsKnownUnit reports whether u is one of KnownUnits.
+func IsKnownUnit(u string) bool {
+	for _, known := range KnownUnits {
+		if string(known) == u {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Metadata{Name: "cpu_percent_total", Unit: UnitPercent})
+	r.RegisterPrefix("disk_", Metadata{Unit: UnitBytes})
+	r.RegisterPrefix("disk_percent_used_", Metadata{Unit: UnitPercent, Description: "Filesystem usage"})
+
+	md, ok := r.Lookup("cpu_percent_total")
+	assert.True(t, ok)
+	assert.Equal(t, UnitPercent, md.Unit)
+	assert.Equal(t, TypeGauge, md.Type) // Defaulted
+
+	// Longest prefix wins and the returned name is the concrete metric
+	md, ok = r.Lookup("disk_percent_used_root")
+	assert.True(t, ok)
+	assert.Equal(t, UnitPercent, md.Unit)
+	assert.Equal(t, "disk_percent_used_root", md.Name)
+
+	md, ok = r.Lookup("disk_bytes_free_root")
+	assert.True(t, ok)
+	assert.Equal(t, UnitBytes, md.Unit)
+
+	_, ok = r.Lookup("unknown_metric")
+	assert.False(t, ok)
+}
+
+func TestDefaultRegistryBuiltins(t *testing.T) {
+	for _, name := range []string{"cpu_percent_total", "mem_percent_used", "disk_read_bytes_ps", "net_sent_bytes_ps"} {
+		md, ok := Lookup(name)
+		assert.True(t, ok, "builtin metric %s should be registered", name)
+		assert.NotEmpty(t, md.Description)
+	}
+}
+
+func TestIsKnownUnit(t *testing.T) {
+	assert.True(t, IsKnownUnit("percent"))
+	assert.True(t, IsKnownUnit("bytes_per_second"))
+	assert.True(t, IsKnownUnit(""))
+	assert.False(t, IsKnownUnit("furlongs"))
+}
@@ -0,0 +1,97 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrSendTimeout is returned by SendWithTimeout when a send takes too long.
+var ErrSendTimeout = errors.New("send timed out")
+
+// SendWithTimeout sends through n, giving up after timeout. A send that
+// times out is left to finish in the background, so a hanging server
+// delays delivery to other channels by at most timeout.
+func SendWithTimeout(n Notifier, data NotificationData, templates NotificationTemplates, timeout time.Duration) error {
+	if timeout <= 0 {
+		return n.Send(data, templates)
+	}
+	done := make(chan error, 1) // Buffered so an abandoned send can still complete
+	go func() {
+		done <- n.Send(data, templates)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w after %s", ErrSendTimeout, timeout)
+	}
+}
+
+// CircuitBreaker stops sending to a failing channel for a while. After
+// threshold consecutive failures the circuit opens and Allow refuses sends
+// until cooldown has passed; then one trial send is let through, which
+// closes the circuit on success or reopens it on failure.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int       // Consecutive failures
+	openUntil time.Time // Zero when closed
+	trial     bool      // A trial send is in flight after the cooldown
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports whether a send may be attempted at now.
+func (cb *CircuitBreaker) Allow(now time.Time) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(cb.openUntil) || cb.trial {
+		return false
+	}
+	cb.trial = true
+	return true
+}
+
+// Success records a successful send, closing the circuit.
+func (cb *CircuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures = 0
+	cb.openUntil = time.Time{}
+	cb.trial = false
+}
+
+// Failure records a failed send at now and reports whether it opened the circuit.
+func (cb *CircuitBreaker) Failure(now time.Time) (opened bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.failures++
+	wasTrial := cb.trial
+	cb.trial = false
+	if wasTrial || (cb.openUntil.IsZero() && cb.failures >= cb.threshold) {
+		cb.openUntil = now.Add(cb.cooldown)
+		return true
+	}
+	return false
+}
+
+// OpenUntil returns when the circuit closes again, or the zero time when it is closed.
+func (cb *CircuitBreaker) OpenUntil() time.Time {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.openUntil
+}
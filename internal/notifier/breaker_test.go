@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowNotifier blocks each send for delay.
+type slowNotifier struct {
+	delay time.Duration
+}
+
+func (s slowNotifier) Name() string { return "slow" }
+
+func (s slowNotifier) Send(NotificationData, NotificationTemplates) error {
+	time.Sleep(s.delay)
+	return nil
+}
+
+func TestSendWithTimeout(t *testing.T) {
+	start := time.Now()
+	err := SendWithTimeout(slowNotifier{delay: time.Second}, NotificationData{}, NotificationTemplates{}, 20*time.Millisecond)
+	assert.ErrorIs(t, err, ErrSendTimeout)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.NoError(t, SendWithTimeout(slowNotifier{}, NotificationData{}, NotificationTemplates{}, time.Second))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(3, time.Minute)
+	now := time.Now()
+
+	assert.True(t, cb.Allow(now))
+	assert.False(t, cb.Failure(now))
+	assert.False(t, cb.Failure(now))
+	assert.True(t, cb.Failure(now), "third consecutive failure opens the circuit")
+	assert.Equal(t, now.Add(time.Minute), cb.OpenUntil())
+	assert.False(t, cb.Allow(now.Add(30*time.Second)))
+
+	// After the cooldown a single trial send is allowed; failing it reopens the circuit
+	assert.True(t, cb.Allow(now.Add(time.Minute)))
+	assert.False(t, cb.Allow(now.Add(time.Minute)))
+	assert.True(t, cb.Failure(now.Add(time.Minute)))
+	assert.False(t, cb.Allow(now.Add(90*time.Second)))
+
+	// A successful trial closes it
+	assert.True(t, cb.Allow(now.Add(2*time.Minute)))
+	cb.Success()
+	assert.True(t, cb.OpenUntil().IsZero())
+	assert.True(t, cb.Allow(now.Add(2*time.Minute)))
+	assert.False(t, cb.Failure(now.Add(2*time.Minute)))
+}
+
+func TestCircuitBreakerSuccessResetsCount(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Minute)
+	now := time.Now()
+	cb.Failure(now)
+	cb.Success()
+	assert.False(t, cb.Failure(now))
+	assert.True(t, cb.Allow(now))
+}
@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// DashboardURLData is the data of the dashboard_url_template: the
+// notification and the time range to show, e.g.
+// {{.From.UnixMilli}} for Grafana's from parameter.
+type DashboardURLData struct {
+	NotificationData
+	From time.Time // One hour plus the rule's duration before the alert
+	To   time.Time // 15 minutes after the alert
+}
+
+// dashboardRangeAfter is how far the time range of dashboard links extends
+// past the alert, so the graph shows how the metric went on.
+const dashboardRangeAfter = 15 * time.Minute
+
+var dashboardURL atomic.Pointer[template.Template]
+
+// SetDashboardURLTemplate sets the template of the links added to
+// notifications as DashboardURL; an empty text disables them.
+func SetDashboardURLTemplate(text string) error {
+	if text == "" {
+		dashboardURL.Store(nil)
+		return nil
+	}
+	tmpl, err := template.New("dashboard_url_template").Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse dashboard_url_template: %w", err)
+	}
+	dashboardURL.Store(tmpl)
+	return nil
+}
+
+// DashboardURL renders the link to the graph of an alert, showing the
+// duration of its rule and the hour before. It returns "" when no template
+// is set or the template fails, which is logged.
+func DashboardURL(data NotificationData, duration time.Duration) string {
+	url, err := renderDashboardURL(data, duration)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return url
+}
+
+func renderDashboardURL(data NotificationData, duration time.Duration) (string, error) {
+	tmpl := dashboardURL.Load()
+	if tmpl == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, DashboardURLData{
+		NotificationData: data,
+		From:             data.Time.Add(-time.Hour - duration),
+		To:               data.Time.Add(dashboardRangeAfter),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute dashboard_url_template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
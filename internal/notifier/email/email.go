@@ -0,0 +1,260 @@
+// Package email implements the "email" notification channel type (SMTP).
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/smtp"
+	"strings"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+)
+
+func init() {
+	notifier.Register("email", func(nc config.NotificationChannelConfig) (notifier.Notifier, error) {
+		emailCfg, err := config.GetEmailChannelConfig(nc)
+		if err != nil {
+			return nil, err
+		}
+		return New(nc.Name, *emailCfg)
+	})
+}
+
+type Notifier struct {
+	name      string
+	config    config.EmailChannelConfig
+	tlsConfig *tls.Config // Used for STARTTLS
+	dialer    *outbound.Dialer
+}
+
+func New(name string, cfg config.EmailChannelConfig) (*Notifier, error) {
+	if cfg.SMTPHost == "" || cfg.SMTPPort == 0 || cfg.SMTPFrom == "" || len(cfg.SMTPTo) == 0 {
+		return nil, fmt.Errorf("email notifier '%s' is missing required configuration (host, port, from, to)", name)
+	}
+	// Password check is tricky: it might be optional for some non-auth SMTP relays.
+	// If username is present, password should ideally be present.
+	if cfg.SMTPUsername != "" && cfg.SMTPPassword == "" {
+		// This indicates MONRES_SMTP_PASSWORD_<CHANNEL> was not set.
+		// Depending on strictness, this could be an error. For now, allow it and let SMTP server reject.
+		// log.Printf("Warning: Email notifier '%s' has a username but no password. SMTP auth might fail.", name)
+	}
+
+	tlsConfig, err := outbound.NewTLSConfig(cfg.TLS, cfg.SMTPHost)
+	if err != nil {
+		return nil, fmt.Errorf("email notifier '%s': %w", name, err)
+	}
+
+	dialer, err := outbound.NewDialer(outbound.Options{AddressFamily: cfg.AddressFamily, Resolver: cfg.DNSResolver})
+	if err != nil {
+		return nil, fmt.Errorf("email notifier '%s': %w", name, err)
+	}
+
+	return &Notifier{name: name, config: cfg, tlsConfig: tlsConfig, dialer: dialer}, nil
+}
+
+func (en *Notifier) Name() string {
+	return en.name
+}
+
+// render returns the subject and body of the email of a notification.
+func (en *Notifier) render(data notifier.NotificationData, templates notifier.NotificationTemplates) (subject, body string, err error) {
+	locale := notifier.CurrentLocale()
+	templateToUse := templates.FiredTemplate
+	subjectPrefix := locale.FiredSubject
+	if data.AtStartup {
+		subjectPrefix = locale.AtStartupSubject
+	}
+	if data.Severity != "" {
+		subjectPrefix = fmt.Sprintf("%s (%s)", subjectPrefix, strings.ToUpper(data.Severity))
+	}
+	if data.Action != nil {
+		subjectPrefix = fmt.Sprintf("%s (%s)", locale.ActionSubject, strings.ToUpper(data.Action.Status))
+	}
+	if data.State == "RESOLVED" {
+		templateToUse = templates.ResolvedTemplate
+		subjectPrefix = locale.ResolvedSubject
+		if data.Stale {
+			subjectPrefix = fmt.Sprintf("%s (%s)", subjectPrefix, locale.StaleSubject)
+		}
+	}
+
+	subject = fmt.Sprintf("%s: %s on %s", subjectPrefix, data.AlertName, data.Hostname)
+	body, err = notifier.Render("email_body", templateToUse, data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render email template for alert '%s': %w", data.AlertName, err)
+	}
+	return subject, body, nil
+}
+
+// Preview returns the headers and body of the email, unencoded.
+func (en *Notifier) Preview(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error) {
+	subject, body, err := en.render(data, templates)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("To: %s\nFrom: %s\nSubject: %s\n\n%s", strings.Join(en.config.SMTPTo, ","), en.config.SMTPFrom, subject, body), nil
+}
+
+// previewPage lays out an email like a mail client, with a bar in the color
+// of its state.
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Subject }}</title></head>
+<body style="font-family: sans-serif; margin: 2em">
+<table style="border-left: 6px solid {{ .Color }}; padding-left: 1em">
+<tr><th align="left">From</th><td>{{ .From }}</td></tr>
+<tr><th align="left">To</th><td>{{ .To }}</td></tr>
+<tr><th align="left">Subject</th><td>{{ .Subject }}</td></tr>
+</table>
+<hr>
+<pre style="white-space: pre-wrap; font-size: 1.1em">{{ .Body }}</pre>
+</body>
+</html>
+`))
+
+// PreviewHTML returns the email as an HTML page, to see it the way a mail
+// client shows it. The email itself is sent as plain text.
+func (en *Notifier) PreviewHTML(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error) {
+	subject, body, err := en.render(data, templates)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = previewPage.Execute(&buf, map[string]string{
+		"From":    en.config.SMTPFrom,
+		"To":      strings.Join(en.config.SMTPTo, ", "),
+		"Subject": subject,
+		"Body":    body,
+		"Color":   notifier.StateColor(data.State),
+	})
+	return buf.String(), err
+}
+
+func (en *Notifier) Send(data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	subject, body, err := en.render(data, templates)
+	if err != nil {
+		return err
+	}
+
+	// Construct message
+	// MIME headers are important for many email clients
+	toList := strings.Join(en.config.SMTPTo, ",")
+	msg := []byte(fmt.Sprintf("To: %s\r\n"+
+		"From: %s\r\n"+
+		"Subject: %s\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"%s\r\n", toList, en.config.SMTPFrom, mime.QEncoding.Encode("UTF-8", subject), body)) // Subjects may be localized
+
+	client, err := en.connect(context.Background())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Send email
+	if err = client.Mail(extractEmail(en.config.SMTPFrom)); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range en.config.SMTPTo {
+		if err = client.Rcpt(extractEmail(rcpt)); err != nil {
+			return fmt.Errorf("SMTP RCPT TO failed for %s: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA command failed: %w", err)
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		return fmt.Errorf("failed to write email body: %w", err)
+	}
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close email data writer: %w", err)
+	}
+	return client.Quit()
+}
+
+// connect opens an SMTP session, switching to TLS if the server supports it,
+// and authenticates if credentials are configured.
+func (en *Notifier) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := fmt.Sprintf("%s:%d", en.config.SMTPHost, en.config.SMTPPort)
+	var auth smtp.Auth
+	if en.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", en.config.SMTPUsername, en.config.SMTPPassword, en.config.SMTPHost)
+	}
+
+	// Connect to the server and, if it supports it, switch to TLS.
+	conn, err := en.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial SMTP server (pre-TLS): %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, en.config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		// Plain SMTP upgrades opportunistically, like smtp.SendMail
+		if err = client.StartTLS(en.tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to start TLS with SMTP server: %w", err)
+		}
+	} else if en.config.SMTPUseTLS {
+		client.Close()
+		// Server does not support STARTTLS, but config said to use it.
+		// Or, if port is 465 (SMTPS), direct TLS connection is needed, not STARTTLS.
+		// This simple client does not handle direct SMTPS on 465 well.
+		// For port 465, a different approach is needed: tls.Dial then smtp.NewClient
+		if en.config.SMTPPort == 465 { // SMTPS often on 465
+			return nil, fmt.Errorf("STARTTLS configured, but port 465 suggests direct SSL/TLS. This client uses STARTTLS for smtp_use_tls=true. For port 465, explicit SSL/TLS connection is needed (not implemented in this basic SMTP sender).")
+		}
+		return nil, fmt.Errorf("SMTP server does not support STARTTLS, but smtp_use_tls was true")
+	}
+
+	// Authenticate if credentials are provided
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// CheckHealth opens an SMTP session the way Send does, including TLS and
+// authentication, and closes it with NOOP and QUIT without sending mail.
+func (en *Notifier) CheckHealth(ctx context.Context) error {
+	client, err := en.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.Noop(); err != nil {
+		return fmt.Errorf("SMTP NOOP failed: %w", err)
+	}
+	return client.Quit()
+}
+
+// extractEmail parses "Display Name <email@example.com>" and returns "email@example.com"
+func extractEmail(fullEmail string) string {
+	if strings.Contains(fullEmail, "<") && strings.Contains(fullEmail, ">") {
+		start := strings.LastIndex(fullEmail, "<")
+		end := strings.LastIndex(fullEmail, ">")
+		if start != -1 && end != -1 && end > start {
+			return fullEmail[start+1 : end]
+		}
+	}
+	return fullEmail // Assume it's already just the email address
+}
@@ -0,0 +1,219 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      config.EmailChannelConfig
+		expectError bool
+	}{
+		{
+			name: "valid_config",
+			config: config.EmailChannelConfig{
+				SMTPHost:     "smtp.example.com",
+				SMTPPort:     587,
+				SMTPUsername: "user@example.com",
+				SMTPPassword: "password",
+				SMTPFrom:     "Test <test@example.com>",
+				SMTPTo:       []string{"admin@example.com"},
+				SMTPUseTLS:   true,
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid_tls",
+			config: config.EmailChannelConfig{
+				SMTPHost: "smtp.example.com",
+				SMTPPort: 587,
+				SMTPFrom: "test@example.com",
+				SMTPTo:   []string{"admin@example.com"},
+				TLS:      outbound.TLSOptions{MinVersion: "2.0"},
+			},
+			expectError: true,
+		},
+		{
+			name: "missing_host",
+			config: config.EmailChannelConfig{
+				SMTPPort: 587,
+				SMTPFrom: "test@example.com",
+				SMTPTo:   []string{"admin@example.com"},
+			},
+			expectError: true,
+		},
+		{
+			name: "missing_port",
+			config: config.EmailChannelConfig{
+				SMTPHost: "smtp.example.com",
+				SMTPFrom: "test@example.com",
+				SMTPTo:   []string{"admin@example.com"},
+			},
+			expectError: true,
+		},
+		{
+			name: "missing_from",
+			config: config.EmailChannelConfig{
+				SMTPHost: "smtp.example.com",
+				SMTPPort: 587,
+				SMTPTo:   []string{"admin@example.com"},
+			},
+			expectError: true,
+		},
+		{
+			name: "missing_to",
+			config: config.EmailChannelConfig{
+				SMTPHost: "smtp.example.com",
+				SMTPPort: 587,
+				SMTPFrom: "test@example.com",
+				SMTPTo:   []string{},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notifier, err := New("test-email", tc.config)
+
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, notifier)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, notifier)
+				assert.Equal(t, "test-email", notifier.Name())
+			}
+		})
+	}
+}
+
+// fakeSMTPServer accepts one plain SMTP session and returns the received message.
+func fakeSMTPServer(t *testing.T) (port int, received <-chan string) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, messages
+}
+
+func TestSend(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+	n, err := New("mail", config.EmailChannelConfig{
+		SMTPHost:      "localhost",
+		SMTPPort:      port,
+		SMTPFrom:      "Monres <monres@example.com>",
+		SMTPTo:        []string{"ops@example.com"},
+		AddressFamily: outbound.FamilyPreferV4,
+	})
+	require.NoError(t, err)
+
+	data := notifier.NotificationData{AlertName: "High CPU", Hostname: "web-1", State: "FIRED", Severity: "warning"}
+	templates := notifier.NotificationTemplates{FiredTemplate: "{{ .AlertName }} fired"}
+	require.NoError(t, n.Send(data, templates))
+
+	select {
+	case msg := <-received:
+		assert.Contains(t, msg, "Subject: ALERT FIRED (WARNING): High CPU on web-1")
+		assert.Contains(t, msg, "High CPU fired")
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestPreview(t *testing.T) {
+	n, err := New("mail", config.EmailChannelConfig{
+		SMTPHost: "smtp.example.com",
+		SMTPPort: 587,
+		SMTPFrom: "monres <monres@example.com>",
+		SMTPTo:   []string{"admin@example.com"},
+	})
+	require.NoError(t, err)
+	templates := notifier.NotificationTemplates{
+		FiredTemplate:    "{{ .AlertName }} <{{ .FormattedMetricValue }}>",
+		ResolvedTemplate: "{{ .AlertName }} resolved",
+	}
+	data := notifier.NotificationData{AlertName: "cpu", State: "FIRED", Severity: "critical", Hostname: "vm", FormattedMetricValue: "95.0%"}
+
+	preview, err := n.Preview(data, templates)
+	require.NoError(t, err)
+	assert.Equal(t, "To: admin@example.com\nFrom: monres <monres@example.com>\nSubject: ALERT FIRED (CRITICAL): cpu on vm\n\ncpu <95.0%>", preview)
+
+	page, err := n.PreviewHTML(data, templates)
+	require.NoError(t, err)
+	assert.Contains(t, page, "<title>ALERT FIRED (CRITICAL): cpu on vm</title>")
+	assert.Contains(t, page, "cpu &lt;95.0%&gt;</pre>")
+	assert.Contains(t, page, "border-left: 6px solid #e01e5a")
+
+	_, err = n.Preview(notifier.NotificationData{State: "RESOLVED"}, notifier.NotificationTemplates{ResolvedTemplate: "{{ .Nope }}"})
+	assert.Error(t, err)
+}
+
+func TestCheckHealth(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+	n, err := New("mail", config.EmailChannelConfig{
+		SMTPHost:      "localhost",
+		SMTPPort:      port,
+		SMTPFrom:      "monres@example.com",
+		SMTPTo:        []string{"ops@example.com"},
+		AddressFamily: outbound.FamilyPreferV4,
+	})
+	require.NoError(t, err)
+	require.NoError(t, n.CheckHealth(context.Background()))
+	assert.Empty(t, received, "no mail is sent")
+
+	// The server only accepts one session, so the next check times out waiting for its greeting
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Error(t, n.CheckHealth(ctx))
+}
@@ -0,0 +1,56 @@
+package notifier
+
+import "time"
+
+// Event is the JSON representation of a notification, used by channels
+// that emit machine-readable output (stdout in JSON format, webhooks).
+type Event struct {
+	Alert              string        `json:"alert"`
+	State              string        `json:"state"`
+	Severity           string        `json:"severity,omitempty"`
+	Hostname           string        `json:"hostname"`
+	Time               string        `json:"time"`
+	Metric             string        `json:"metric"`
+	Value              float64       `json:"value"`
+	Threshold          float64       `json:"threshold"`
+	Condition          string        `json:"condition"`
+	Unit               string        `json:"unit,omitempty"`
+	FormattedValue     string        `json:"formatted_value"`
+	FormattedThreshold string        `json:"formatted_threshold"`
+	Duration           string        `json:"duration,omitempty"`
+	Aggregation        string        `json:"aggregation,omitempty"`
+	Stale              bool          `json:"stale,omitempty"`
+	AtStartup          bool          `json:"at_startup,omitempty"`
+	Action             *ActionResult `json:"action,omitempty"`
+	Fingerprint        string        `json:"fingerprint,omitempty"`
+	IncidentID         string        `json:"incident_id,omitempty"`
+	DashboardURL       string        `json:"dashboard_url,omitempty"`
+	Message            string        `json:"message"`
+}
+
+// NewEvent builds the Event of a notification with its rendered message.
+func NewEvent(data NotificationData, message string) Event {
+	return Event{
+		Alert:              data.AlertName,
+		State:              data.State,
+		Severity:           data.Severity,
+		Hostname:           data.Hostname,
+		Time:               data.Time.Format(time.RFC3339),
+		Metric:             data.MetricName,
+		Value:              data.MetricValue,
+		Threshold:          data.ThresholdValue,
+		Condition:          data.Condition,
+		Unit:               data.MetricUnit,
+		FormattedValue:     data.FormattedMetricValue,
+		FormattedThreshold: data.FormattedThresholdValue,
+		Duration:           data.DurationString,
+		Aggregation:        data.Aggregation,
+		Stale:              data.Stale,
+		AtStartup:          data.AtStartup,
+		Action:             data.Action,
+		Fingerprint:        data.Fingerprint,
+		IncidentID:         data.IncidentID,
+		DashboardURL:       data.DashboardURL,
+		Message:            message,
+	}
+}
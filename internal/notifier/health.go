@@ -0,0 +1,146 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+)
+
+// HealthChecker is implemented by notifiers that can check, without sending
+// anything, that their channel still accepts messages: the server answers
+// and the credentials are valid.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthMetricName returns the metric reporting the health of a channel,
+// e.g. channel_healthy_ops_telegram for "ops-telegram".
+func HealthMetricName(channel string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(channel) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return "channel_healthy_" + b.String()
+}
+
+// channelHealth is the state of the health check of a channel.
+type channelHealth struct {
+	name     string
+	checker  HealthChecker // Nil if the channel failed to initialize
+	interval time.Duration
+	timeout  time.Duration
+	lastRun  time.Time
+	running  bool
+	checked  bool
+	healthy  bool
+}
+
+// HealthCollector runs the health checks of the notification channels with
+// a health_check interval and reports channel_healthy_<name>: 1 when the
+// last check passed, 0 when it failed. Checks run in the background, so a
+// slow server never delays a collection cycle; a channel is reported once
+// its first check has completed. Channels that failed to initialize, e.g.
+// for a missing bot token, are reported unhealthy right away.
+type HealthCollector struct {
+	channels []*channelHealth
+	mu       sync.Mutex
+}
+
+// NewHealthCollector creates the collector checking the channels of cfg that
+// have a health_check interval, or returns nil if there are none.
+func NewHealthCollector(channels []config.NotificationChannelConfig, notifiers map[string]Notifier) *HealthCollector {
+	hc := &HealthCollector{}
+	for _, nc := range channels {
+		if nc.HealthCheck <= 0 || !nc.IsEnabled() {
+			continue
+		}
+		ch := &channelHealth{name: nc.Name, interval: nc.HealthCheck, timeout: nc.Timeout}
+		if n, ok := notifiers[nc.Name]; ok {
+			checker, ok := n.(HealthChecker)
+			if !ok {
+				log.Printf("Warning: Notification channel '%s' (%s) has no health check. Ignoring its health_check.", nc.Name, nc.Type)
+				continue
+			}
+			ch.checker = checker
+		} else {
+			ch.checked = true // Unhealthy until a reload or restart initializes it
+		}
+		name := HealthMetricName(nc.Name)
+		if _, known := metrics.Lookup(name); !known {
+			metrics.Register(metrics.Metadata{
+				Name:        name,
+				Type:        metrics.TypeGauge,
+				Description: fmt.Sprintf("1 when the %s notification channel passed its last health check, else 0", nc.Name),
+			})
+		}
+		hc.channels = append(hc.channels, ch)
+	}
+	if len(hc.channels) == 0 {
+		return nil
+	}
+	return hc
+}
+
+// Len returns the number of channels checked.
+func (hc *HealthCollector) Len() int {
+	return len(hc.channels)
+}
+
+func (hc *HealthCollector) Name() string {
+	return "channel_health"
+}
+
+// Collect starts the checks that are due and returns the result of the last
+// completed check of every channel.
+func (hc *HealthCollector) Collect() (collector.CollectedMetrics, error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	now := time.Now()
+	result := make(collector.CollectedMetrics, len(hc.channels))
+	for _, ch := range hc.channels {
+		if ch.checker != nil && !ch.running && (ch.lastRun.IsZero() || now.Sub(ch.lastRun) >= ch.interval) {
+			ch.running, ch.lastRun = true, now
+			go hc.check(ch)
+		}
+		if ch.checked {
+			result[HealthMetricName(ch.name)] = boolMetric(ch.healthy)
+		}
+	}
+	return result, nil
+}
+
+// check runs the health check of a channel, logging changes of its health.
+func (hc *HealthCollector) check(ch *channelHealth) {
+	ctx, cancel := context.WithTimeout(context.Background(), ch.timeout)
+	defer cancel()
+	err := ch.checker.CheckHealth(ctx)
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	switch {
+	case err != nil && (ch.healthy || !ch.checked):
+		log.Printf("Warning: Health check of notification channel '%s' failed: %v", ch.name, err)
+	case err == nil && !ch.healthy && ch.checked:
+		log.Printf("Notification channel '%s' is healthy again.", ch.name)
+	}
+	ch.running, ch.checked, ch.healthy = false, true, err == nil
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// checkedNotifier fails its health checks while failing is set.
+type checkedNotifier struct {
+	slowNotifier
+	failing atomic.Bool
+	checks  atomic.Int32
+}
+
+func (c *checkedNotifier) CheckHealth(context.Context) error {
+	c.checks.Add(1)
+	if c.failing.Load() {
+		return errors.New("401 Unauthorized")
+	}
+	return nil
+}
+
+func TestHealthMetricName(t *testing.T) {
+	assert.Equal(t, "channel_healthy_ops_telegram", HealthMetricName("ops-telegram"))
+	assert.Equal(t, "channel_healthy_email", HealthMetricName("Email"))
+}
+
+func TestHealthCollector(t *testing.T) {
+	disabled := false
+	channels := []config.NotificationChannelConfig{
+		{Name: "telegram", HealthCheck: time.Hour, Timeout: time.Second},
+		{Name: "broken", HealthCheck: time.Hour, Timeout: time.Second},
+		{Name: "stdout", HealthCheck: time.Hour, Timeout: time.Second},
+		{Name: "unchecked", Timeout: time.Second},
+		{Name: "off", HealthCheck: time.Hour, Timeout: time.Second, Enabled: &disabled},
+	}
+	checked := &checkedNotifier{}
+	notifiers := map[string]Notifier{
+		"telegram":  checked,
+		"stdout":    slowNotifier{}, // No health check
+		"unchecked": &checkedNotifier{},
+	}
+	assert.Nil(t, NewHealthCollector(channels[3:], notifiers))
+
+	hc := NewHealthCollector(channels, notifiers)
+	require.NotNil(t, hc)
+	assert.Equal(t, 2, hc.Len())
+
+	collect := func() map[string]float64 {
+		metrics, err := hc.Collect()
+		require.NoError(t, err)
+		return metrics
+	}
+	// The first check runs in the background; a channel that failed to initialize is unhealthy
+	assert.Equal(t, map[string]float64{"channel_healthy_broken": 0}, map[string]float64(collect()))
+	assert.Eventually(t, func() bool { return collect()["channel_healthy_telegram"] == 1 }, time.Second, time.Millisecond)
+
+	// Not due again within the interval
+	checked.failing.Store(true)
+	collect()
+	assert.Equal(t, int32(1), checked.checks.Load())
+
+	hc.channels[0].lastRun = time.Now().Add(-2 * time.Hour)
+	assert.Eventually(t, func() bool { return collect()["channel_healthy_telegram"] == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), checked.checks.Load())
+}
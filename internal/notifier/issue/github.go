@@ -0,0 +1,52 @@
+package issue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// github tracks alerts as issues of a GitHub repository. Labels missing from
+// the repository are created by GitHub, if the token may push to it.
+type github struct {
+	api
+	repository string // "owner/repo"
+}
+
+func (g *github) createURL() string {
+	return g.baseURL + "/repos/" + g.repository + "/issues"
+}
+
+func (g *github) create(title, body string, labels []string) (string, error) {
+	var created struct {
+		Number int `json:"number"`
+	}
+	err := g.call(context.Background(), http.MethodPost, "/repos/"+g.repository+"/issues", map[string]any{
+		"title":  title,
+		"body":   body,
+		"labels": labels,
+	}, &created)
+	if err != nil {
+		return "", err
+	}
+	if created.Number == 0 {
+		return "", fmt.Errorf("GitHub returned no issue number")
+	}
+	return strconv.Itoa(created.Number), nil
+}
+
+func (g *github) comment(issue, body string) error {
+	return g.call(context.Background(), http.MethodPost, "/repos/"+g.repository+"/issues/"+issue+"/comments", map[string]any{"body": body}, nil)
+}
+
+func (g *github) close(issue string) error {
+	return g.call(context.Background(), http.MethodPatch, "/repos/"+g.repository+"/issues/"+issue, map[string]any{
+		"state":        "closed",
+		"state_reason": "completed",
+	}, nil)
+}
+
+func (g *github) checkHealth(ctx context.Context) error {
+	return g.call(ctx, http.MethodGet, "/repos/"+g.repository, nil, nil)
+}
@@ -0,0 +1,248 @@
+// Package issue implements the "issue" notification channel type, which
+// tracks alerts as issues on GitHub or Jira: an issue is opened when an
+// alert fires, and commented on and closed when it resolves.
+package issue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+)
+
+func init() {
+	notifier.Register("issue", func(nc config.NotificationChannelConfig) (notifier.Notifier, error) {
+		issueCfg, err := config.GetIssueChannelConfig(nc)
+		if err != nil {
+			return nil, err
+		}
+		return New(nc.Name, *issueCfg)
+	})
+}
+
+// tracker is the API of an issue tracker. Issues are identified by their
+// number on GitHub and their key on Jira.
+type tracker interface {
+	create(title, body string, labels []string) (string, error)
+	comment(issue, body string) error
+	close(issue string) error
+	checkHealth(ctx context.Context) error
+	createURL() string // Where issues are created, for previews
+}
+
+type Notifier struct {
+	name    string
+	config  config.IssueChannelConfig
+	tracker tracker
+	issues  map[string]string // Open issue of every firing alert, by incident
+	mu      sync.Mutex
+}
+
+func New(name string, cfg config.IssueChannelConfig) (*Notifier, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("issue notifier '%s' is missing token (from ENV)", name)
+	}
+	client, err := outbound.NewHTTPClient(outbound.Options{
+		Proxy:         cfg.Proxy,
+		TLS:           cfg.TLS,
+		AddressFamily: cfg.AddressFamily,
+		Resolver:      cfg.DNSResolver,
+	}, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("issue notifier '%s': %w", name, err)
+	}
+	n := &Notifier{name: name, config: cfg, issues: make(map[string]string)}
+	switch cfg.Provider {
+	case config.IssueProviderGitHub:
+		n.tracker = &github{api: api{client: client, baseURL: cfg.URL, service: "GitHub", auth: func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+cfg.Token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+			req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		}}, repository: cfg.Repository}
+	case config.IssueProviderJira:
+		n.tracker = &jira{api: api{client: client, baseURL: cfg.URL, service: "Jira", auth: func(req *http.Request) {
+			if cfg.User != "" {
+				req.SetBasicAuth(cfg.User, cfg.Token) // Jira Cloud API token
+			} else {
+				req.Header.Set("Authorization", "Bearer "+cfg.Token) // Data Center personal access token
+			}
+		}}, project: cfg.Project, issueType: cfg.IssueType, closeTransition: cfg.CloseTransition}
+	default:
+		return nil, fmt.Errorf("issue notifier '%s' has unknown provider '%s'", name, cfg.Provider)
+	}
+	return n, nil
+}
+
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// render returns the title and body of the issue, or comment, of a
+// notification.
+func (n *Notifier) render(data notifier.NotificationData, templates notifier.NotificationTemplates) (title, body string, err error) {
+	body, err = notifier.RenderMessage(data, templates)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render issue template for alert '%s': %w", data.AlertName, err)
+	}
+	if data.IncidentID != "" {
+		body += "\n\nIncident: " + data.IncidentID
+	}
+	locale := notifier.CurrentLocale()
+	subject := locale.FiredSubject
+	if data.AtStartup {
+		subject = locale.AtStartupSubject
+	}
+	return fmt.Sprintf("%s: %s on %s", subject, data.AlertName, data.Hostname), body, nil
+}
+
+// labels returns the labels of the issue of an alert: "monres", its
+// severity and its host, then the configured labels.
+func (n *Notifier) labels(data notifier.NotificationData) []string {
+	labels := []string{"monres"}
+	if data.Severity != "" {
+		labels = append(labels, "severity:"+data.Severity)
+	}
+	if data.Hostname != "" {
+		labels = append(labels, "host:"+strings.Join(strings.Fields(data.Hostname), "-")) // Jira labels can't hold spaces
+	}
+	return append(labels, n.config.Labels...)
+}
+
+// incidentKey identifies the firing of an alert, for the issue it opened.
+func incidentKey(data notifier.NotificationData) string {
+	if data.IncidentID != "" {
+		return data.IncidentID
+	}
+	return data.Hostname + "\x00" + data.AlertName
+}
+
+// Preview returns what Send would do for the notification: the issue it
+// opens, or the comment it adds to the issue of the alert.
+func (n *Notifier) Preview(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error) {
+	title, body, err := n.render(data, templates)
+	if err != nil {
+		return "", err
+	}
+	n.mu.Lock()
+	issue, known := n.issues[incidentKey(data)]
+	n.mu.Unlock()
+	switch {
+	case !known && data.State == "FIRED":
+		return fmt.Sprintf("POST %s\nTitle: %s\nLabels: %s\n\n%s", n.tracker.createURL(), title, strings.Join(n.labels(data), ", "), body), nil
+	case !known:
+		return "(no issue is open for the alert; nothing is sent)", nil
+	case data.State == "RESOLVED" && n.config.CloseOnResolve:
+		return fmt.Sprintf("Comment on and close issue %s:\n\n%s", issue, body), nil
+	default:
+		return fmt.Sprintf("Comment on issue %s:\n\n%s", issue, body), nil
+	}
+}
+
+// Send opens an issue when an alert fires. Severity changes and remediation
+// actions of the alert are added to the issue as comments, and so is the
+// RESOLVED notification, which also closes the issue unless close_on_resolve
+// is false. The issues are only known to the running process: after a
+// restart, alerts that fired before resolve without updating their issue.
+func (n *Notifier) Send(data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	title, body, err := n.render(data, templates)
+	if err != nil {
+		return err
+	}
+	key := incidentKey(data)
+	n.mu.Lock()
+	issue, known := n.issues[key]
+	n.mu.Unlock()
+
+	if !known {
+		if data.State != "FIRED" {
+			log.Printf("Issue notifier '%s': no issue is open for alert '%s'; skipping its %s notification.", n.name, data.AlertName, data.State)
+			return nil
+		}
+		issue, err := n.tracker.create(title, body, n.labels(data))
+		if err != nil {
+			return err
+		}
+		n.mu.Lock()
+		n.issues[key] = issue
+		n.mu.Unlock()
+		return nil
+	}
+
+	if err := n.tracker.comment(issue, body); err != nil {
+		return err
+	}
+	if data.State != "RESOLVED" {
+		return nil
+	}
+	if n.config.CloseOnResolve {
+		if err := n.tracker.close(issue); err != nil {
+			return err
+		}
+	}
+	n.mu.Lock()
+	delete(n.issues, key)
+	n.mu.Unlock()
+	return nil
+}
+
+// CheckHealth reads the repository or the user the token belongs to, which
+// fails once the token is revoked.
+func (n *Notifier) CheckHealth(ctx context.Context) error {
+	return n.tracker.checkHealth(ctx)
+}
+
+// api is a JSON REST API authenticated by auth.
+type api struct {
+	client  *http.Client
+	baseURL string
+	service string // Name in errors, e.g. "GitHub"
+	auth    func(req *http.Request)
+}
+
+// call sends payload, unless it is nil, to path and decodes the response
+// into result, unless it is nil.
+func (a *api) call(ctx context.Context, method, path string, payload, result any) error {
+	var body io.Reader
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s payload: %w", a.service, err)
+		}
+		body = bytes.NewReader(payloadBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", a.service, err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "monres")
+	a.auth(req)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", a.service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s request %s %s failed with status %d: %s", a.service, method, path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("%s returned an unexpected response to %s %s: %w", a.service, method, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package issue
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// request is a request received by the fake tracker.
+type request struct {
+	method, path, auth string
+	body               map[string]any
+}
+
+// fakeTracker serves the given responses by "METHOD path" and records the requests.
+func fakeTracker(t *testing.T, responses map[string]string) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization")}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			require.NoError(t, json.Unmarshal(data, &req.body))
+		}
+		requests = append(requests, req)
+		response, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+var templates = notifier.NotificationTemplates{
+	FiredTemplate:    "{{ .AlertName }} is {{ .FormattedMetricValue }}",
+	ResolvedTemplate: "{{ .AlertName }} resolved",
+}
+
+func TestSendGitHub(t *testing.T) {
+	server, requests := fakeTracker(t, map[string]string{
+		"POST /repos/acme/infra/issues":             `{"number": 42}`,
+		"POST /repos/acme/infra/issues/42/comments": `{}`,
+		"PATCH /repos/acme/infra/issues/42":         `{}`,
+		"GET /repos/acme/infra":                     `{}`,
+	})
+	n, err := New("tickets", config.IssueChannelConfig{
+		Provider:       config.IssueProviderGitHub,
+		URL:            server.URL,
+		Repository:     "acme/infra",
+		Labels:         []string{"infra"},
+		CloseOnResolve: true,
+		Token:          "ghp_secret",
+	})
+	require.NoError(t, err)
+
+	fired := notifier.NotificationData{AlertName: "High CPU", Hostname: "web 1", State: "FIRED", Severity: "warning", FormattedMetricValue: "91%", IncidentID: "abc123"}
+	preview, err := n.Preview(fired, templates)
+	require.NoError(t, err)
+	assert.Contains(t, preview, "POST "+server.URL+"/repos/acme/infra/issues\nTitle: ALERT FIRED: High CPU on web 1")
+	require.NoError(t, n.Send(fired, templates))
+
+	// A severity change of the same firing comments on the issue
+	escalated := fired
+	escalated.Severity, escalated.FormattedMetricValue = "critical", "99%"
+	require.NoError(t, n.Send(escalated, templates))
+
+	resolved := fired
+	resolved.State = "RESOLVED"
+	preview, err = n.Preview(resolved, templates)
+	require.NoError(t, err)
+	assert.Equal(t, "Comment on and close issue 42:\n\nHigh CPU resolved\n\nIncident: abc123", preview)
+	require.NoError(t, n.Send(resolved, templates))
+	// The issue is closed, so a late RESOLVED sends nothing
+	require.NoError(t, n.Send(resolved, templates))
+
+	require.Len(t, *requests, 4)
+	create := (*requests)[0]
+	assert.Equal(t, "Bearer ghp_secret", create.auth)
+	assert.Equal(t, "ALERT FIRED: High CPU on web 1", create.body["title"])
+	assert.Equal(t, "High CPU is 91%\n\nIncident: abc123", create.body["body"])
+	assert.Equal(t, []any{"monres", "severity:warning", "host:web-1", "infra"}, create.body["labels"])
+	assert.Equal(t, "/repos/acme/infra/issues/42/comments", (*requests)[1].path)
+	assert.Equal(t, "High CPU is 99%\n\nIncident: abc123", (*requests)[1].body["body"])
+	assert.Equal(t, "High CPU resolved\n\nIncident: abc123", (*requests)[2].body["body"])
+	assert.Equal(t, request{method: http.MethodPatch, path: "/repos/acme/infra/issues/42", auth: "Bearer ghp_secret",
+		body: map[string]any{"state": "closed", "state_reason": "completed"}}, (*requests)[3])
+
+	require.NoError(t, n.CheckHealth(context.Background()))
+	n.tracker.(*github).repository = "acme/gone"
+	assert.ErrorContains(t, n.CheckHealth(context.Background()), "status 404")
+}
+
+func TestSendJira(t *testing.T) {
+	server, requests := fakeTracker(t, map[string]string{
+		"POST /rest/api/2/issue":                   `{"id": "10001", "key": "OPS-7"}`,
+		"POST /rest/api/2/issue/OPS-7/comment":     `{}`,
+		"GET /rest/api/2/issue/OPS-7/transitions":  `{"transitions": [{"id": "11", "name": "In Progress"}, {"id": "31", "name": "Done"}]}`,
+		"POST /rest/api/2/issue/OPS-7/transitions": `{}`,
+	})
+	n, err := New("jira", config.IssueChannelConfig{
+		Provider:        config.IssueProviderJira,
+		URL:             server.URL,
+		Project:         "OPS",
+		IssueType:       "Incident",
+		User:            "ops@example.com",
+		CloseOnResolve:  true,
+		CloseTransition: "done",
+		Token:           "jira-token",
+	})
+	require.NoError(t, err)
+
+	fired := notifier.NotificationData{AlertName: "Disk", Hostname: "db", State: "FIRED", Severity: "critical", FormattedMetricValue: "97%"}
+	require.NoError(t, n.Send(fired, templates))
+	resolved := fired
+	resolved.State = "RESOLVED"
+	require.NoError(t, n.Send(resolved, templates))
+
+	require.Len(t, *requests, 4)
+	create := (*requests)[0]
+	assert.Equal(t, "Basic b3BzQGV4YW1wbGUuY29tOmppcmEtdG9rZW4=", create.auth) // ops@example.com:jira-token
+	assert.Equal(t, map[string]any{
+		"project":     map[string]any{"key": "OPS"},
+		"issuetype":   map[string]any{"name": "Incident"},
+		"summary":     "ALERT FIRED: Disk on db",
+		"description": "Disk is 97%",
+		"labels":      []any{"monres", "severity:critical", "host:db"},
+	}, create.body["fields"])
+	assert.Equal(t, "Disk resolved", (*requests)[1].body["body"])
+	assert.Equal(t, map[string]any{"transition": map[string]any{"id": "31"}}, (*requests)[3].body)
+
+	// Without the close transition, the RESOLVED notification fails after commenting
+	n.tracker.(*jira).closeTransition = "Closed"
+	require.NoError(t, n.Send(fired, templates))
+	assert.ErrorContains(t, n.Send(resolved, templates), "no transition 'Closed' (available: In Progress, Done)")
+}
+
+func TestSendWithoutClosing(t *testing.T) {
+	server, requests := fakeTracker(t, map[string]string{
+		"POST /repos/acme/infra/issues":            `{"number": 1}`,
+		"POST /repos/acme/infra/issues/1/comments": `{}`,
+	})
+	n, err := New("tickets", config.IssueChannelConfig{Provider: config.IssueProviderGitHub, URL: server.URL, Repository: "acme/infra", Token: "t"})
+	require.NoError(t, err)
+
+	fired := notifier.NotificationData{AlertName: "Disk", Hostname: "db", State: "FIRED"}
+	require.NoError(t, n.Send(fired, templates))
+	resolved := fired
+	resolved.State = "RESOLVED"
+	require.NoError(t, n.Send(resolved, templates))
+	// The next firing opens a new issue
+	require.NoError(t, n.Send(fired, templates))
+
+	require.Len(t, *requests, 3)
+	assert.Equal(t, "/repos/acme/infra/issues/1/comments", (*requests)[1].path)
+	assert.Equal(t, "/repos/acme/infra/issues", (*requests)[2].path)
+
+	_, err = New("tickets", config.IssueChannelConfig{Provider: config.IssueProviderGitHub, URL: server.URL, Repository: "acme/infra"})
+	assert.ErrorContains(t, err, "missing token")
+}
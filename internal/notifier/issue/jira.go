@@ -0,0 +1,74 @@
+package issue
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// jira tracks alerts as issues of a Jira project, through the version 2
+// REST API, which takes plain text descriptions and comments.
+type jira struct {
+	api
+	project         string
+	issueType       string
+	closeTransition string // Name of the workflow transition closing issues
+}
+
+func (j *jira) createURL() string {
+	return j.baseURL + "/rest/api/2/issue"
+}
+
+func (j *jira) create(title, body string, labels []string) (string, error) {
+	var created struct {
+		Key string `json:"key"`
+	}
+	err := j.call(context.Background(), http.MethodPost, "/rest/api/2/issue", map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]string{"key": j.project},
+			"issuetype":   map[string]string{"name": j.issueType},
+			"summary":     title,
+			"description": body,
+			"labels":      labels,
+		},
+	}, &created)
+	if err != nil {
+		return "", err
+	}
+	if created.Key == "" {
+		return "", fmt.Errorf("Jira returned no issue key")
+	}
+	return created.Key, nil
+}
+
+func (j *jira) comment(issue, body string) error {
+	return j.call(context.Background(), http.MethodPost, "/rest/api/2/issue/"+issue+"/comment", map[string]any{"body": body}, nil)
+}
+
+// close applies the close transition, looked up by name as its ID depends
+// on the project's workflow.
+func (j *jira) close(issue string) error {
+	var available struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	path := "/rest/api/2/issue/" + issue + "/transitions"
+	if err := j.call(context.Background(), http.MethodGet, path, nil, &available); err != nil {
+		return err
+	}
+	var names []string
+	for _, t := range available.Transitions {
+		if strings.EqualFold(t.Name, j.closeTransition) {
+			return j.call(context.Background(), http.MethodPost, path, map[string]any{"transition": map[string]string{"id": t.ID}}, nil)
+		}
+		names = append(names, t.Name)
+	}
+	return fmt.Errorf("Jira issue %s has no transition '%s' (available: %s)", issue, j.closeTransition, strings.Join(names, ", "))
+}
+
+func (j *jira) checkHealth(ctx context.Context) error {
+	return j.call(ctx, http.MethodGet, "/rest/api/2/myself", nil, nil)
+}
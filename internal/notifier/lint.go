@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"slices"
+	"time"
+
+	"github.com/mattmezza/monres/internal/metrics"
+)
+
+// SampleNotificationData returns representative template data for every kind
+// of notification, fired ones first, for linting and previewing templates.
+func SampleNotificationData(hostname string) []NotificationData {
+	fired := NotificationData{
+		AlertName:         "High CPU Usage",
+		MetricName:        "cpu_percent_total",
+		MetricValue:       97.5,
+		ThresholdValue:    90,
+		Condition:         ">",
+		State:             "FIRED",
+		Severity:          "critical",
+		Hostname:          hostname,
+		Time:              time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		DurationString:    "5m",
+		Aggregation:       "average",
+		IncidentID:        "5d41402abc4b2a76",
+		MetricUnit:        "percent",
+		MetricDescription: "Total CPU usage",
+		Metrics: map[string]float64{
+			"cpu_percent_total":  97.5,
+			"mem_percent_used":   61.2,
+			"disk_read_bytes_ps": 1.5e6,
+		},
+	}
+	fired.FormattedMetricValue = FormatValue(fired.MetricName, fired.MetricValue)
+	fired.FormattedThresholdValue = FormatValue(fired.MetricName, fired.ThresholdValue)
+	fired.FormattedTime = FormatTime(fired.Time)
+	fired.TopProcesses = []ProcessInfo{{
+		PID:          4242,
+		Name:         "postgres",
+		CPUPercent:   91.5,
+		RSSBytes:     512 << 20,
+		FormattedCPU: FormatUnitValue(metrics.UnitPercent, 91.5),
+		FormattedRSS: FormatUnitValue(metrics.UnitBytes, 512<<20),
+	}}
+	fired.Captures = []CaptureOutput{{
+		Command: "df -h /",
+		Output:  "Filesystem      Size  Used Avail Use% Mounted on\n/dev/sda1        40G   37G  3.0G  93% /",
+	}}
+
+	atStartup := fired
+	atStartup.AtStartup = true
+	resolved := fired
+	resolved.State = "RESOLVED"
+	resolved.MetricValue = 42
+	resolved.FormattedMetricValue = FormatValue(resolved.MetricName, resolved.MetricValue)
+	stale := resolved
+	stale.Stale = true
+	action := fired
+	action.State = "ACTION"
+	action.TopProcesses, action.Captures = nil, nil
+	action.Action = &ActionResult{
+		Name:   "systemctl restart postgresql",
+		Status: ActionFailed,
+		Output: "Job for postgresql.service failed.",
+		Error:  "exit status 1",
+	}
+	return []NotificationData{fired, atStartup, resolved, stale, action}
+}
+
+// LintTemplates renders the templates against SampleNotificationData, so
+// typos such as unknown fields surface before an alert fires. Errors carry
+// the template name and, where text/template reports them, line and column.
+// The dashboard_url_template set with SetDashboardURLTemplate is rendered too.
+func LintTemplates(templates NotificationTemplates) []error {
+	var errs []error
+	lint := func(name, text string, states ...string) {
+		for _, data := range SampleNotificationData("example-host") {
+			if !slices.Contains(states, data.State) {
+				continue
+			}
+			if _, err := Render(name, text, data); err != nil {
+				errs = append(errs, err)
+				return // One error per template
+			}
+		}
+	}
+	lint("alert_fired", templates.FiredTemplate, "FIRED", "ACTION")
+	lint("alert_resolved", templates.ResolvedTemplate, "RESOLVED")
+	if _, err := renderDashboardURL(SampleNotificationData("example-host")[0], 5*time.Minute); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/i18n"
+	"github.com/mattmezza/monres/internal/metrics"
+)
+
+func TestLintTemplates(t *testing.T) {
+	valid := NotificationTemplates{
+		FiredTemplate:    "{{if .AtStartup}}STARTUP{{end}} {{ .AlertName }} {{ .FormattedMetricValue }}",
+		ResolvedTemplate: "{{ .AlertName }}{{if .Stale}} (stale){{end}}",
+	}
+	assert.Empty(t, LintTemplates(valid))
+
+	invalid := NotificationTemplates{
+		// The typo is only reached by alerts already firing at startup
+		FiredTemplate:    "{{ .AlertName }}\n{{if .AtStartup}}{{ .NonExistentField }}{{end}}",
+		ResolvedTemplate: "{{ .AlertName }",
+	}
+	errs := LintTemplates(invalid)
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "alert_fired:2:")
+	assert.Contains(t, errs[0].Error(), "NonExistentField")
+	assert.Contains(t, errs[1].Error(), "alert_resolved:1:")
+}
+
+func TestLocaleTemplatesAndFormatting(t *testing.T) {
+	t.Cleanup(func() { SetLocale(i18n.Default) })
+	for _, code := range i18n.Codes() {
+		l := i18n.Get(code)
+		assert.Empty(t, LintTemplates(NotificationTemplates{FiredTemplate: l.FiredTemplate, ResolvedTemplate: l.ResolvedTemplate}), code)
+	}
+
+	assert.Equal(t, "85.5%", FormatUnitValue(metrics.UnitPercent, 85.5))
+	SetLocale("de")
+	assert.Equal(t, "85,5%", FormatUnitValue(metrics.UnitPercent, 85.5))
+	assert.Equal(t, "de", CurrentLocale().Code)
+}
+
+func TestDashboardURL(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetDashboardURLTemplate("")) })
+	data := SampleNotificationData("web 1")[0]
+	assert.Empty(t, DashboardURL(data, 5*time.Minute))
+
+	require.NoError(t, SetDashboardURLTemplate("https://grafana/d/monres?var-host={{ .Hostname | urlquery }}&var-metric={{ .MetricName }}&from={{ .From.UnixMilli }}&to={{ .To.UnixMilli }}\n"))
+	from, to := data.Time.Add(-65*time.Minute).UnixMilli(), data.Time.Add(15*time.Minute).UnixMilli()
+	want := fmt.Sprintf("https://grafana/d/monres?var-host=web+1&var-metric=cpu_percent_total&from=%d&to=%d", from, to)
+	assert.Equal(t, want, DashboardURL(data, 5*time.Minute))
+
+	// The default templates show the link on a line of its own
+	data.DashboardURL = want
+	message, err := Render("alert_fired", i18n.Get(i18n.Default).FiredTemplate, data)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(message, "\n"+want), message)
+
+	require.NoError(t, SetDashboardURLTemplate("https://grafana/?q={{ .NoSuchField }}"))
+	assert.Empty(t, DashboardURL(data, 5*time.Minute))
+	errs := LintTemplates(NotificationTemplates{FiredTemplate: "{{ .AlertName }}", ResolvedTemplate: "{{ .AlertName }}"})
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "dashboard_url_template")
+
+	assert.Error(t, SetDashboardURLTemplate("{{ .Hostname"))
+}
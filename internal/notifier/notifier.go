@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync/atomic"
 	gotexttemplate "text/template"
 	"time"
 
 	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/i18n"
+	"github.com/mattmezza/monres/internal/metrics"
 )
 
 // NotificationData is the data passed to templates.
@@ -19,14 +22,88 @@ type NotificationData struct {
 	ThresholdValue float64
 	Condition      string
 	State          string // "FIRED" or "RESOLVED"
+	Severity       string // "info", "warning" or "critical"
+	Stale          bool   // RESOLVED because the metric stopped reporting
+	AtStartup      bool   // FIRED because the condition already held when monres started
 	Hostname       string
 	Time           time.Time
+	FormattedTime  string // Time in the format of the configured locale
 	DurationString string // e.g. "5m"
 	Aggregation    string // e.g. "average"
 
 	// Pre-formatted fields for human-readable display
 	FormattedMetricValue    string // e.g. "525.5 MB/s" or "85.5%"
 	FormattedThresholdValue string // e.g. "500.0 MB/s" or "90.0%"
+
+	// Metadata from the metric registry
+	MetricUnit        string // e.g. "percent", "bytes_per_second"
+	MetricDescription string // e.g. "Total CPU usage"
+
+	// Metrics holds the latest value of every collected metric, e.g.
+	// {{ index .Metrics "load_avg_1m" }}; {{ metric "name" }} formats one.
+	Metrics map[string]float64
+
+	// TopProcesses lists the busiest processes when the alert fired, if the
+	// rule sets top_processes
+	TopProcesses []ProcessInfo
+
+	// Captures holds the output of the rule's capture commands, run when
+	// the alert fired
+	Captures []CaptureOutput
+
+	// Action is the result of a remediation action, set when State is
+	// "ACTION"; those notifications are rendered with the fired template
+	Action *ActionResult
+
+	// Fingerprint identifies the alert, state and severity notified, e.g.
+	// for deduplication by the receiver
+	Fingerprint string
+
+	// IncidentID identifies the firing of the alert: its FIRED, ACTION and
+	// RESOLVED notifications share it, e.g. to close a ticket on resolve
+	IncidentID string
+
+	// DashboardURL links to the graph of the alert, rendered from the
+	// dashboard_url_template; empty if none is configured
+	DashboardURL string
+}
+
+// Outcomes of a remediation action.
+const (
+	ActionSucceeded = "succeeded"
+	ActionFailed    = "failed"
+	ActionSkipped   = "skipped" // Not run, e.g. because of max_per_hour
+)
+
+// ActionResult is the outcome of a remediation action.
+type ActionResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`           // ActionSucceeded, ActionFailed or ActionSkipped
+	Output string `json:"output,omitempty"` // Combined stdout and stderr, truncated
+	Error  string `json:"error,omitempty"`  // Why it failed or was skipped
+}
+
+// CaptureOutput is the result of a capture command.
+type CaptureOutput struct {
+	Command string
+	Output  string // Combined stdout and stderr, truncated
+	Error   string // Why the command failed, e.g. "exit status 1"
+}
+
+// ProcessInfo describes a process listed in a notification.
+type ProcessInfo struct {
+	PID          int
+	Name         string
+	CPUPercent   float64
+	RSSBytes     uint64
+	FormattedCPU string // e.g. "85.0%"
+	FormattedRSS string // e.g. "512.0 MB"
+}
+
+// String formats the process for a notification line, e.g.
+// "postgres (pid 42): CPU 85.0%, RSS 512.0 MB".
+func (p ProcessInfo) String() string {
+	return fmt.Sprintf("%s (pid %d): CPU %s, RSS %s", p.Name, p.PID, p.FormattedCPU, p.FormattedRSS)
 }
 
 type NotificationTemplates struct {
@@ -40,9 +117,17 @@ type Notifier interface {
 	Name() string // Returns the configured channel name
 }
 
-func renderTemplate(templateName string, templateStr string, data NotificationData) (string, error) {
+// Previewer is implemented by notifiers that can show what Send would
+// deliver, without sending it, for the render command.
+type Previewer interface {
+	Preview(data NotificationData, templates NotificationTemplates) (string, error)
+}
+
+// Render executes a notification template against data. Channel implementations
+// use it to render their message bodies.
+func Render(templateName string, templateStr string, data NotificationData) (string, error) {
 	// Using text/template as per requirements. If HTML emails were a primary concern, html/template would be safer.
-	tmpl, err := gotexttemplate.New(templateName).Parse(templateStr)
+	tmpl, err := gotexttemplate.New(templateName).Funcs(templateFuncs).Funcs(dataFuncs(data)).Parse(templateStr)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse notification template '%s': %w", templateName, err)
 	}
@@ -55,34 +140,77 @@ func renderTemplate(templateName string, templateStr string, data NotificationDa
 	return buf.String(), nil
 }
 
+// templateFuncs are the helpers available in notification templates.
+var templateFuncs = gotexttemplate.FuncMap{
+	"severityEmoji": SeverityEmoji,
+	"stateColor":    StateColor,
+}
+
+// MissingMetric is what {{ metric "name" }} renders for a metric without a value.
+const MissingMetric = "n/a"
+
+// dataFuncs are the template helpers reading the data being rendered.
+func dataFuncs(data NotificationData) gotexttemplate.FuncMap {
+	return gotexttemplate.FuncMap{
+		// metric formats the latest value of a metric with its unit, e.g. "85.5%"
+		"metric": func(name string) string {
+			value, ok := data.Metrics[name]
+			if !ok {
+				return MissingMetric
+			}
+			return FormatValue(name, value)
+		},
+	}
+}
+
+// SeverityEmoji returns a glyph for a severity, e.g. for {{ severityEmoji .Severity }}.
+func SeverityEmoji(severity string) string {
+	switch severity {
+	case config.SeverityInfo:
+		return "ℹ️"
+	case config.SeverityWarning:
+		return "⚠️"
+	case config.SeverityCritical:
+		return "🚨"
+	default:
+		return "❗"
+	}
+}
+
+// StateColor returns a hex color for a notification state ("FIRED" red,
+// "RESOLVED" green), e.g. for chat attachments or HTML.
+func StateColor(state string) string {
+	if state == "RESOLVED" {
+		return "#2eb67d"
+	}
+	return "#e01e5a"
+}
+
+// RenderMessage renders the fired or resolved template matching data.State.
+func RenderMessage(data NotificationData, templates NotificationTemplates) (string, error) {
+	if data.State == "RESOLVED" {
+		return Render("resolved_message", templates.ResolvedTemplate, data)
+	}
+	return Render("fired_message", templates.FiredTemplate, data)
+}
 
+// InitializeNotifiers creates a notifier for every configured channel using
+// the factories registered for their types. Channels that fail to initialize,
+// or whose type was not compiled in, are logged and skipped.
 func InitializeNotifiers(cfgNotifChannels []config.NotificationChannelConfig) (map[string]Notifier, error) {
     notifiers := make(map[string]Notifier)
     for _, ncCfg := range cfgNotifChannels {
-        var instance Notifier
-        var err error
-        switch ncCfg.Type {
-        case "email":
-            emailCfg, convErr := config.GetEmailChannelConfig(ncCfg)
-            if convErr != nil {
-                 log.Printf("Skipping email channel '%s' due to config error: %v", ncCfg.Name, convErr)
-                 continue
-            }
-            instance, err = NewEmailNotifier(ncCfg.Name, *emailCfg)
-        case "telegram":
-            telegramCfg, convErr := config.GetTelegramChannelConfig(ncCfg)
-             if convErr != nil {
-                 log.Printf("Skipping telegram channel '%s' due to config error: %v", ncCfg.Name, convErr)
-                 continue
-            }
-            instance, err = NewTelegramNotifier(ncCfg.Name, *telegramCfg)
-		case "stdout":
-			instance, err = NewStdoutNotifier(ncCfg.Name)
-        default:
-            log.Printf("Unsupported notification channel type '%s' for channel '%s'. Skipping.", ncCfg.Type, ncCfg.Name)
+        if !ncCfg.IsEnabled() {
+            log.Printf("Notification channel '%s' is disabled. Skipping.", ncCfg.Name)
+            continue
+        }
+        factory, ok := lookupFactory(ncCfg.Type)
+        if !ok {
+            log.Printf("Unsupported notification channel type '%s' for channel '%s' (supported: %s). Skipping.", ncCfg.Type, ncCfg.Name, strings.Join(RegisteredTypes(), ", "))
             continue
         }
 
+        instance, err := factory(ncCfg)
         if err != nil {
             log.Printf("Failed to initialize notifier for channel '%s' (%s): %v. Skipping.", ncCfg.Name, ncCfg.Type, err)
             continue
@@ -96,19 +224,58 @@ func InitializeNotifiers(cfgNotifChannels []config.NotificationChannelConfig) (m
     return notifiers, nil
 }
 
-// FormatValue formats a numeric value based on the metric name.
+// FormatValue formats a numeric value based on the unit registered for the
+// metric in the metrics registry.
 // Returns a human-readable string with appropriate units.
 func FormatValue(metricName string, value float64) string {
-	switch {
-	case strings.HasSuffix(metricName, "_bytes_ps"):
+	md, _ := metrics.Lookup(metricName)
+	return FormatUnitValue(md.Unit, value)
+}
+
+// FormatUnitValue formats a numeric value for the given unit, with the
+// decimal separator of the current locale.
+func FormatUnitValue(unit metrics.Unit, value float64) string {
+	return CurrentLocale().LocalizeNumber(formatUnitValue(unit, value))
+}
+
+func formatUnitValue(unit metrics.Unit, value float64) string {
+	switch unit {
+	case metrics.UnitBytesPerSecond:
 		return formatBytesPerSecond(value)
-	case strings.Contains(metricName, "_percent_"):
+	case metrics.UnitBytes:
+		return formatBytes(value)
+	case metrics.UnitPercent:
 		return formatPercent(value)
+	case metrics.UnitSeconds:
+		return fmt.Sprintf("%.1fs", value)
+	case metrics.UnitCelsius:
+		return fmt.Sprintf("%.1f°C", value)
 	default:
 		return fmt.Sprintf("%.2f", value)
 	}
 }
 
+// FormatTime formats a notification time with the layout of the current locale.
+func FormatTime(t time.Time) string {
+	return CurrentLocale().FormatTime(t)
+}
+
+var currentLocale atomic.Pointer[i18n.Locale]
+
+// SetLocale sets the locale of formatted values and times; unknown codes
+// select the default locale.
+func SetLocale(code string) {
+	currentLocale.Store(i18n.Get(code))
+}
+
+// CurrentLocale returns the locale set by SetLocale, English by default.
+func CurrentLocale() *i18n.Locale {
+	if l := currentLocale.Load(); l != nil {
+		return l
+	}
+	return i18n.Get(i18n.Default)
+}
+
 // formatBytesPerSecond converts bytes/s to human-readable format (B/s, KB/s, MB/s, GB/s)
 func formatBytesPerSecond(bytes float64) string {
 	const (
@@ -129,6 +296,26 @@ func formatBytesPerSecond(bytes float64) string {
 	}
 }
 
+// formatBytes converts bytes to human-readable format (B, KB, MB, GB)
+func formatBytes(bytes float64) string {
+	const (
+		KB = 1024.0
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.1f GB", bytes/GB)
+	case bytes >= MB:
+		return fmt.Sprintf("%.1f MB", bytes/MB)
+	case bytes >= KB:
+		return fmt.Sprintf("%.1f KB", bytes/KB)
+	default:
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+}
+
 // formatPercent formats a percentage value with % suffix
 func formatPercent(value float64) string {
 	return fmt.Sprintf("%.1f%%", value)
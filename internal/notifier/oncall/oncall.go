@@ -0,0 +1,201 @@
+// Package oncall implements the notification channel types of on-call
+// routers: "grafana_oncall", "squadcast" and "zenduty". They POST every
+// notification as JSON to the router's generic alert endpoint, triggering an
+// incident when an alert fires and resolving it when the alert resolves.
+package oncall
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+)
+
+func init() {
+	for _, service := range config.OnCallServices {
+		notifier.Register(service, func(nc config.NotificationChannelConfig) (notifier.Notifier, error) {
+			onCallCfg, err := config.GetOnCallChannelConfig(nc)
+			if err != nil {
+				return nil, err
+			}
+			return New(nc.Name, *onCallCfg)
+		})
+	}
+}
+
+type Notifier struct {
+	name   string
+	config config.OnCallChannelConfig
+	client *http.Client
+}
+
+func New(name string, cfg config.OnCallChannelConfig) (*Notifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("%s notifier '%s' is missing url", cfg.Service, name)
+	}
+	if _, ok := payloads[cfg.Service]; !ok {
+		return nil, fmt.Errorf("%s notifier '%s': unknown on-call router", cfg.Service, name)
+	}
+	client, err := outbound.NewHTTPClient(outbound.Options{
+		Proxy:         cfg.Proxy,
+		TLS:           cfg.TLS,
+		AddressFamily: cfg.AddressFamily,
+		Resolver:      cfg.DNSResolver,
+	}, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("%s notifier '%s': %w", cfg.Service, name, err)
+	}
+	return &Notifier{name: name, config: cfg, client: client}, nil
+}
+
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// DedupKey identifies the incident of a notification at the router: the
+// incident ID of the alert's firing, so its RESOLVED notification resolves
+// the incident its FIRED one triggered.
+func DedupKey(data notifier.NotificationData) string {
+	if data.IncidentID != "" {
+		return data.IncidentID
+	}
+	sum := sha256.Sum256([]byte(data.Hostname + "\x00" + data.AlertName))
+	return hex.EncodeToString(sum[:8])
+}
+
+// payloads build the request body of every router from a notification and
+// its rendered message.
+var payloads = map[string]func(data notifier.NotificationData, message string) any{
+	// https://grafana.com/docs/oncall/latest/configure/integrations/references/webhook/
+	config.OnCallGrafana: func(data notifier.NotificationData, message string) any {
+		state := "alerting"
+		if data.State == "RESOLVED" {
+			state = "ok"
+		}
+		return map[string]any{
+			"alert_uid":                DedupKey(data),
+			"title":                    title(data),
+			"state":                    state,
+			"message":                  message,
+			"link_to_upstream_details": data.DashboardURL,
+			"event":                    notifier.NewEvent(data, message),
+		}
+	},
+	// https://support.squadcast.com/integrations/incident-webhook-incident-webhook-api
+	config.OnCallSquadcast: func(data notifier.NotificationData, message string) any {
+		status := "trigger"
+		if data.State == "RESOLVED" {
+			status = "resolve"
+		}
+		tags := map[string]string{"host": data.Hostname, "alert": data.AlertName, "metric": data.MetricName}
+		if data.Severity != "" {
+			tags["severity"] = data.Severity
+		}
+		return map[string]any{
+			"event_id":    DedupKey(data),
+			"status":      status,
+			"message":     title(data),
+			"description": message,
+			"tags":        tags,
+		}
+	},
+	// https://docs.zenduty.com/docs/api
+	config.OnCallZenduty: func(data notifier.NotificationData, message string) any {
+		alertType := data.Severity
+		switch {
+		case data.State == "RESOLVED":
+			alertType = "resolved"
+		case alertType == "":
+			alertType = config.SeverityCritical
+		}
+		payload := map[string]any{
+			"alert_type": alertType,
+			"entity_id":  DedupKey(data),
+			"message":    title(data),
+			"summary":    message,
+			"payload":    notifier.NewEvent(data, message),
+		}
+		if data.DashboardURL != "" {
+			payload["urls"] = []map[string]string{{"link_url": data.DashboardURL, "link_text": "Dashboard"}}
+		}
+		return payload
+	},
+}
+
+// title names the incident of an alert, the same for all its notifications.
+func title(data notifier.NotificationData) string {
+	return fmt.Sprintf("%s on %s", data.AlertName, data.Hostname)
+}
+
+// body returns the JSON request body of a notification.
+func (n *Notifier) body(data notifier.NotificationData, templates notifier.NotificationTemplates) ([]byte, error) {
+	msg, err := notifier.RenderMessage(data, templates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s template for alert '%s': %w", n.config.Service, data.AlertName, err)
+	}
+	body, err := json.Marshal(payloads[n.config.Service](data, msg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", n.config.Service, err)
+	}
+	return body, nil
+}
+
+// Preview returns the request body, indented, under the endpoint with its
+// path, which holds the integration key, left out.
+func (n *Notifier) Preview(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error) {
+	body, err := n.body(data, templates)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return "", err
+	}
+	endpoint := n.config.URL
+	if u, err := url.Parse(endpoint); err == nil {
+		endpoint = u.Scheme + "://" + u.Host + "/..."
+	}
+	return fmt.Sprintf("POST %s\n\n%s", endpoint, buf.String()), nil
+}
+
+// Send POSTs the notification to the router: FIRED and ACTION notifications
+// trigger, or add to, the incident of the alert's firing, RESOLVED ones
+// resolve it.
+func (n *Notifier) Send(data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	body, err := n.body(data, templates)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", n.config.Service, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "monres")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL holds the integration key
+		}
+		return fmt.Errorf("failed to send %s event: %w", n.config.Service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s request failed with status %d: %s", n.config.Service, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package oncall
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func TestSend(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/integrations/s3cret/", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(data, &body))
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	templates := notifier.NotificationTemplates{FiredTemplate: "{{ .AlertName }} fired", ResolvedTemplate: "{{ .AlertName }} resolved"}
+	fired := notifier.NotificationData{AlertName: "High CPU", Hostname: "web-1", State: "FIRED", Severity: "warning", MetricName: "cpu_percent_total", IncidentID: "abc123", DashboardURL: "https://grafana/d/x"}
+	resolved := fired
+	resolved.State = "RESOLVED"
+
+	testCases := []struct {
+		service       string
+		fired, solved map[string]any // Expected fields of the FIRED and RESOLVED bodies
+	}{
+		{
+			service: config.OnCallGrafana,
+			fired:   map[string]any{"alert_uid": "abc123", "title": "High CPU on web-1", "state": "alerting", "message": "High CPU fired", "link_to_upstream_details": "https://grafana/d/x"},
+			solved:  map[string]any{"alert_uid": "abc123", "state": "ok", "message": "High CPU resolved"},
+		},
+		{
+			service: config.OnCallSquadcast,
+			fired: map[string]any{"event_id": "abc123", "status": "trigger", "message": "High CPU on web-1", "description": "High CPU fired",
+				"tags": map[string]any{"host": "web-1", "alert": "High CPU", "metric": "cpu_percent_total", "severity": "warning"}},
+			solved: map[string]any{"event_id": "abc123", "status": "resolve", "description": "High CPU resolved"},
+		},
+		{
+			service: config.OnCallZenduty,
+			fired: map[string]any{"entity_id": "abc123", "alert_type": "warning", "message": "High CPU on web-1", "summary": "High CPU fired",
+				"urls": []any{map[string]any{"link_url": "https://grafana/d/x", "link_text": "Dashboard"}}},
+			solved: map[string]any{"entity_id": "abc123", "alert_type": "resolved", "summary": "High CPU resolved"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.service, func(t *testing.T) {
+			bodies = nil
+			n, err := New("oncall", config.OnCallChannelConfig{Service: tc.service, URL: server.URL + "/integrations/s3cret/"})
+			require.NoError(t, err)
+			require.NoError(t, n.Send(fired, templates))
+			require.NoError(t, n.Send(resolved, templates))
+
+			require.Len(t, bodies, 2)
+			for key, want := range tc.fired {
+				assert.Equal(t, want, bodies[0][key], key)
+			}
+			for key, want := range tc.solved {
+				assert.Equal(t, want, bodies[1][key], key)
+			}
+
+			preview, err := n.Preview(fired, templates)
+			require.NoError(t, err)
+			assert.NotContains(t, preview, "s3cret")
+		})
+	}
+}
+
+func TestSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid integration key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	n, err := New("oncall", config.OnCallChannelConfig{Service: config.OnCallZenduty, URL: server.URL + "/api/events/s3cret/"})
+	require.NoError(t, err)
+	err = n.Send(notifier.NotificationData{AlertName: "Disk", State: "FIRED"}, notifier.NotificationTemplates{FiredTemplate: "x"})
+	assert.ErrorContains(t, err, "zenduty request failed with status 401: invalid integration key")
+
+	// Without an incident ID, the key still matches the alert's notifications
+	a := DedupKey(notifier.NotificationData{AlertName: "Disk", Hostname: "db", State: "FIRED"})
+	assert.Equal(t, a, DedupKey(notifier.NotificationData{AlertName: "Disk", Hostname: "db", State: "RESOLVED"}))
+	assert.NotEqual(t, a, DedupKey(notifier.NotificationData{AlertName: "Disk", Hostname: "web"}))
+}
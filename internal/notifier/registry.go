@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Factory creates a notifier for a configured channel.
+type Factory func(channel config.NotificationChannelConfig) (Notifier, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a channel type available to InitializeNotifiers. Channel
+// packages call it from init, so a type is only available when its package
+// is imported (see the build-tagged imports in cmd/monres).
+// It panics if the type is registered twice.
+func Register(channelType string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, exists := factories[channelType]; exists {
+		panic(fmt.Sprintf("notifier: channel type %q registered twice", channelType))
+	}
+	factories[channelType] = factory
+}
+
+// RegisteredTypes returns the available channel types, sorted.
+func RegisteredTypes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	types := make([]string, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func lookupFactory(channelType string) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	f, ok := factories[channelType]
+	return f, ok
+}
@@ -0,0 +1,80 @@
+package notifier_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	_ "github.com/mattmezza/monres/internal/notifier/email"
+	_ "github.com/mattmezza/monres/internal/notifier/stdout"
+	_ "github.com/mattmezza/monres/internal/notifier/telegram"
+)
+
+func TestInitializeNotifiers(t *testing.T) {
+	channels := []config.NotificationChannelConfig{
+		{
+			Name: "email-test",
+			Type: "email",
+			Config: map[string]interface{}{
+				"smtp_host": "smtp.example.com",
+				"smtp_port": 587,
+				"smtp_from": "test@example.com",
+				"smtp_to":   []interface{}{"admin@example.com"},
+			},
+		},
+		{
+			Name: "telegram-test",
+			Type: "telegram",
+			Config: map[string]interface{}{
+				"bot_token": "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+				"chat_id":   "-123456789",
+			},
+		},
+		{
+			Name: "stdout-test",
+			Type: "stdout",
+		},
+		{
+			Name: "invalid-type",
+			Type: "unsupported",
+		},
+	}
+
+	notifiers, err := notifier.InitializeNotifiers(channels)
+	require.NoError(t, err)
+
+	// Should have 3 successful notifiers (email, telegram, stdout) and skip the invalid one
+	assert.Len(t, notifiers, 3)
+	assert.Contains(t, notifiers, "email-test")
+	assert.Contains(t, notifiers, "telegram-test")
+	assert.Contains(t, notifiers, "stdout-test")
+	assert.NotContains(t, notifiers, "invalid-type")
+}
+
+func TestInitializeNotifiersDuplicateNames(t *testing.T) {
+	channels := []config.NotificationChannelConfig{
+		{
+			Name: "duplicate",
+			Type: "stdout",
+		},
+		{
+			Name: "duplicate",
+			Type: "stdout",
+		},
+	}
+
+	notifiers, err := notifier.InitializeNotifiers(channels)
+	assert.Error(t, err)
+	assert.Nil(t, notifiers)
+	assert.Contains(t, err.Error(), "duplicate notification channel name")
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	assert.Equal(t, []string{"email", "stdout", "telegram"}, notifier.RegisteredTypes())
+	assert.Panics(t, func() {
+		notifier.Register("stdout", func(config.NotificationChannelConfig) (notifier.Notifier, error) { return nil, nil })
+	})
+}
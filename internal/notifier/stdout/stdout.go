@@ -0,0 +1,145 @@
+// Package stdout implements the "stdout" notification channel type.
+package stdout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func init() {
+	notifier.Register("stdout", func(nc config.NotificationChannelConfig) (notifier.Notifier, error) {
+		stdoutCfg, err := config.GetStdoutChannelConfig(nc)
+		if err != nil {
+			return nil, err
+		}
+		return NewWithConfig(nc.Name, *stdoutCfg)
+	})
+}
+
+// ANSI escape sequences used when colors are enabled.
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+type Notifier struct {
+	name   string
+	format string
+	color  bool
+
+	mu  sync.Mutex // Keeps concurrent messages from interleaving
+	out io.Writer
+}
+
+// New creates a stdout notifier printing plain text, colorized on terminals.
+func New(name string) (*Notifier, error) {
+	return NewWithConfig(name, config.StdoutChannelConfig{})
+}
+
+// NewWithConfig creates a stdout notifier with the given output settings.
+func NewWithConfig(name string, cfg config.StdoutChannelConfig) (*Notifier, error) {
+	n := &Notifier{name: name, format: cfg.Format, out: os.Stdout}
+	switch n.format {
+	case "":
+		n.format = config.StdoutFormatText
+	case config.StdoutFormatText, config.StdoutFormatJSON:
+	default:
+		return nil, fmt.Errorf("stdout notifier '%s' has invalid format '%s'", name, cfg.Format)
+	}
+	switch cfg.Color {
+	case config.StdoutColorAlways:
+		n.color = true
+	case config.StdoutColorNever:
+	case "", config.StdoutColorAuto:
+		n.color = isTerminal(os.Stdout)
+	default:
+		return nil, fmt.Errorf("stdout notifier '%s' has invalid color '%s'", name, cfg.Color)
+	}
+	return n, nil
+}
+
+func (sout *Notifier) Name() string {
+	return sout.name
+}
+
+// Preview returns the line Send prints, without colors.
+func (sout *Notifier) Preview(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error) {
+	line, err := sout.line(data, templates, false)
+	return string(line), err
+}
+
+func (sout *Notifier) Send(data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	line, err := sout.line(data, templates, sout.color)
+	if err != nil {
+		return err
+	}
+
+	// Print to Stdout
+	sout.mu.Lock()
+	defer sout.mu.Unlock()
+	_, err = fmt.Fprintf(sout.out, "%s\n", line)
+	return err
+}
+
+// line renders the output of a notification, colorized if color is set.
+func (sout *Notifier) line(data notifier.NotificationData, templates notifier.NotificationTemplates, color bool) ([]byte, error) {
+	var templateToUse string
+	if data.State == "RESOLVED" {
+		templateToUse = templates.ResolvedTemplate
+	} else {
+		templateToUse = templates.FiredTemplate
+	}
+
+	// Render the template (which is plain text)
+	msg, err := notifier.Render("stdout_message", templateToUse, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render stdout template for alert '%s': %w", data.AlertName, err)
+	}
+
+	var line []byte
+	switch sout.format {
+	case config.StdoutFormatJSON:
+		line, err = json.Marshal(notifier.NewEvent(data, msg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode stdout event for alert '%s': %w", data.AlertName, err)
+		}
+	default:
+		if color {
+			msg = colorFor(data) + msg + ansiReset
+		}
+		line = []byte(msg)
+	}
+	return line, nil
+}
+
+// colorFor returns the ANSI color of a notification: green when resolved,
+// otherwise by severity.
+func colorFor(data notifier.NotificationData) string {
+	if data.State == "RESOLVED" {
+		return ansiGreen
+	}
+	switch data.Severity {
+	case config.SeverityInfo:
+		return ansiCyan
+	case config.SeverityWarning:
+		return ansiYellow
+	default:
+		return ansiRed
+	}
+}
+
+// isTerminal reports whether f is a character device such as a terminal,
+// rather than a pipe or file (e.g. the systemd journal).
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,108 @@
+package stdout
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func TestStdoutNotifier(t *testing.T) {
+	// Capture stdout
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	n, err := New("test-stdout")
+	require.NoError(t, err)
+	assert.Equal(t, "test-stdout", n.Name())
+
+	testData := notifier.NotificationData{
+		AlertName:      "Test Alert",
+		MetricName:     "test_metric",
+		MetricValue:    50.0,
+		ThresholdValue: 40.0,
+		Condition:      ">",
+		State:          "FIRED",
+		Hostname:       "test-host",
+		Time:           time.Now(),
+		DurationString: "1m",
+		Aggregation:    "average",
+	}
+
+	templates := notifier.NotificationTemplates{
+		FiredTemplate: "FIRED: {{ .AlertName }} on {{ .Hostname }}",
+	}
+
+	err = n.Send(testData, templates)
+	require.NoError(t, err)
+
+	// Close writer and read captured output
+	w.Close()
+	os.Stdout = oldStdout
+
+	output, _ := io.ReadAll(r)
+	assert.Contains(t, string(output), "FIRED: Test Alert on test-host")
+}
+
+func TestStdoutNotifierFormats(t *testing.T) {
+	data := notifier.NotificationData{
+		AlertName:   "Disk",
+		MetricName:  "disk_percent_used",
+		MetricValue: 97,
+		State:       "FIRED",
+		Severity:    "warning",
+		Hostname:    "db-1",
+		Time:        time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	templates := notifier.NotificationTemplates{
+		FiredTemplate:    "{{ severityEmoji .Severity }} {{ .AlertName }}",
+		ResolvedTemplate: "{{ .AlertName }} ok",
+	}
+
+	var out bytes.Buffer
+	n, err := NewWithConfig("json", config.StdoutChannelConfig{Format: config.StdoutFormatJSON})
+	require.NoError(t, err)
+	n.out = &out
+	require.NoError(t, n.Send(data, templates))
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, "Disk", got["alert"])
+	assert.Equal(t, "warning", got["severity"])
+	assert.Equal(t, "2025-01-02T03:04:05Z", got["time"])
+	assert.Equal(t, 97.0, got["value"])
+	assert.Equal(t, "⚠️ Disk", got["message"])
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"), "one line per event")
+
+	out.Reset()
+	n, err = NewWithConfig("color", config.StdoutChannelConfig{Color: config.StdoutColorAlways})
+	require.NoError(t, err)
+	n.out = &out
+	require.NoError(t, n.Send(data, templates))
+	assert.Equal(t, ansiYellow+"⚠️ Disk"+ansiReset+"\n", out.String())
+	out.Reset()
+	data.State = "RESOLVED"
+	require.NoError(t, n.Send(data, templates))
+	assert.Equal(t, ansiGreen+"Disk ok"+ansiReset+"\n", out.String())
+
+	_, err = NewWithConfig("bad", config.StdoutChannelConfig{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestStdoutNotifierRenderError(t *testing.T) {
+	n, err := NewWithConfig("plain", config.StdoutChannelConfig{Color: config.StdoutColorNever})
+	require.NoError(t, err)
+	err = n.Send(notifier.NotificationData{AlertName: "Disk"}, notifier.NotificationTemplates{FiredTemplate: "{{ .Nope }}"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stdout_message")
+	assert.NotContains(t, err.Error(), "elegram")
+}
@@ -0,0 +1,296 @@
+// Package telegram implements the "telegram" notification channel type.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+)
+
+func init() {
+	notifier.Register("telegram", func(nc config.NotificationChannelConfig) (notifier.Notifier, error) {
+		telegramCfg, err := config.GetTelegramChannelConfig(nc)
+		if err != nil {
+			return nil, err
+		}
+		return New(nc.Name, *telegramCfg)
+	})
+}
+
+type Notifier struct {
+	name   string
+	config config.TelegramChannelConfig
+	client *http.Client
+	incidents map[string]incident // Messages of every firing alert, by alert name
+	mu        sync.Mutex
+}
+
+// incident holds the messages sent about an alert since it fired.
+type incident struct {
+	id       string    // IncidentID of the firing
+	messages []int     // IDs of the FIRED message and the replies to it
+	firedAt  time.Time // Time of the FIRED event
+}
+
+func New(name string, cfg config.TelegramChannelConfig) (*Notifier, error) {
+	if cfg.BotToken == "" || cfg.ChatID == "" {
+		return nil, fmt.Errorf("telegram notifier '%s' is missing bot_token (from ENV) or chat_id", name)
+	}
+	client, err := outbound.NewHTTPClient(outbound.Options{
+		Proxy:         cfg.Proxy,
+		TLS:           cfg.TLS,
+		AddressFamily: cfg.AddressFamily,
+		Resolver:      cfg.DNSResolver,
+	}, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("telegram notifier '%s': %w", name, err)
+	}
+	return &Notifier{
+		name:   name,
+		config: cfg,
+		client: client,
+		incidents: make(map[string]incident),
+	}, nil
+}
+
+func (tn *Notifier) Name() string {
+	return tn.name
+}
+
+// Preview returns the message as it appears in the chat.
+func (tn *Notifier) Preview(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error) {
+	var templateToUse string
+	if data.State == "RESOLVED" {
+		templateToUse = templates.ResolvedTemplate
+	} else {
+		templateToUse = templates.FiredTemplate
+	}
+
+	// Render the template (which is plain text)
+	rawMessage, err := notifier.Render("telegram_message", templateToUse, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render Telegram template for alert '%s': %w", data.AlertName, err)
+	}
+	return rawMessage, nil
+}
+
+// Send sends a message to Telegram.
+// Telegram API prefers MarkdownV2 or HTML for formatting. Let's use MarkdownV2.
+// Note: text/template output needs to be escaped for MarkdownV2.
+//
+// Unless threading is off, the RESOLVED, ACTION and severity change messages
+// of an alert reply to its FIRED message, or with threading "edit" RESOLVED
+// replaces the text of the FIRED message. With quick_resolve, an alert
+// resolving within quick_resolve_max_age of firing deletes its messages or
+// collapses the FIRED message into one line instead. The FIRED messages are
+// only known to the running process: after a restart, alerts that fired
+// before are resolved with a new message. Messages are threaded by
+// incident, so a firing whose RESOLVED message was never sent here (e.g.
+// silenced, or notify_on_resolve false) doesn't thread the next one.
+func (tn *Notifier) Send(data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	rawMessage, err := tn.Preview(data, templates)
+	if err != nil {
+		return err
+	}
+
+	// Telegram API expects MarkdownV2 or HTML.
+	// The default templates are simple text. For MarkdownV2, special chars need escaping.
+	// For this version, we'll send as plain text (MarkdownV2 without special chars).
+	// A more advanced version could allow Markdown in templates and then escape it here, or use HTML.
+	// For now, we assume templates produce fairly plain text.
+	// Telegram's parse_mode MarkdownV2 requires escaping characters like '.', '!', '-', '(', ')', etc.
+	// For simplicity, let's use plain text and not set parse_mode or use "Markdown" which is more lenient but deprecated.
+	// The example templates have '🔥' and '✅', which are fine.
+	// Let's try with "MarkdownV2" and a simple escaper for critical characters.
+
+	escapedMessage := escapeTextForMarkdownV2(rawMessage)
+
+	tn.mu.Lock()
+	inc, known := tn.incidents[data.AlertName]
+	tn.mu.Unlock()
+	known = known && inc.id == data.IncidentID
+
+	if known && data.State == "RESOLVED" {
+		if tn.config.QuickResolve != "" && data.Time.Sub(inc.firedAt) <= tn.config.QuickResolveMaxAge {
+			err := tn.cleanUp(data, inc)
+			if err == nil {
+				tn.forget(data.AlertName)
+				return nil
+			}
+			log.Printf("Warning: Telegram notifier '%s' failed to %s the messages of alert '%s', sending the RESOLVED message: %v", tn.name, tn.config.QuickResolve, data.AlertName, err)
+		}
+		if tn.config.Threading == config.TelegramThreadingEdit {
+			err := tn.call("editMessageText", map[string]any{
+				"chat_id":    tn.config.ChatID,
+				"message_id": inc.messages[0],
+				"text":       escapedMessage,
+				"parse_mode": "MarkdownV2",
+			}, nil)
+			if err == nil {
+				tn.forget(data.AlertName)
+				return nil
+			}
+			// E.g. the message was deleted from the chat
+			log.Printf("Warning: Telegram notifier '%s' failed to edit the FIRED message of alert '%s', replying instead: %v", tn.name, data.AlertName, err)
+		}
+	}
+
+	payload := map[string]any{
+		"chat_id":    tn.config.ChatID,
+		"text":       escapedMessage,
+		"parse_mode": "MarkdownV2", // Specify parse mode
+	}
+	if known && tn.config.Threading != config.TelegramThreadingOff {
+		payload["reply_parameters"] = map[string]any{"message_id": inc.messages[0], "allow_sending_without_reply": true}
+	}
+	var sent struct {
+		MessageID int `json:"message_id"`
+	}
+	if err := tn.call("sendMessage", payload, &sent); err != nil {
+		return err
+	}
+
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+	switch {
+	case data.State == "RESOLVED":
+		delete(tn.incidents, data.AlertName)
+	case known:
+		inc.messages = append(inc.messages, sent.MessageID)
+		tn.incidents[data.AlertName] = inc
+	case data.State == "FIRED" && sent.MessageID != 0:
+		tn.incidents[data.AlertName] = incident{id: data.IncidentID, messages: []int{sent.MessageID}, firedAt: data.Time}
+	}
+	return nil
+}
+
+// cleanUp deletes the messages of an alert that resolved quickly, or
+// collapses its FIRED message into a line saying it resolved.
+func (tn *Notifier) cleanUp(data notifier.NotificationData, inc incident) error {
+	if tn.config.QuickResolve == config.TelegramQuickResolveDelete {
+		return tn.call("deleteMessages", map[string]any{
+			"chat_id":     tn.config.ChatID,
+			"message_ids": inc.messages,
+		}, nil)
+	}
+	line := fmt.Sprintf("%s: %s on %s", notifier.CurrentLocale().ResolvedSubject, data.AlertName, data.Hostname)
+	return tn.call("editMessageText", map[string]any{
+		"chat_id":    tn.config.ChatID,
+		"message_id": inc.messages[0],
+		"text":       escapeTextForMarkdownV2(line),
+		"parse_mode": "MarkdownV2",
+	}, nil)
+}
+
+// forget drops the messages of a resolved alert.
+func (tn *Notifier) forget(alert string) {
+	tn.mu.Lock()
+	delete(tn.incidents, alert)
+	tn.mu.Unlock()
+}
+
+// call invokes a method of the Bot API and decodes its result into result,
+// unless it is nil.
+func (tn *Notifier) call(method string, payload any, result any) error {
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/%s", tn.config.BotToken, method)
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create Telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tn.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL holds the bot token
+		}
+		return fmt.Errorf("failed to send message to Telegram API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := readAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if result != nil {
+		var response struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(bodyBytes, &response); err != nil || json.Unmarshal(response.Result, result) != nil {
+			return fmt.Errorf("telegram API returned an unexpected response to %s: %s", method, strings.TrimSpace(string(bodyBytes)))
+		}
+	}
+	return nil
+}
+
+// CheckHealth calls getMe, which fails once the bot token is revoked.
+func (tn *Notifier) CheckHealth(ctx context.Context) error {
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/getMe", tn.config.BotToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Telegram request: %w", err)
+	}
+	resp, err := tn.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL holds the bot token
+		}
+		return fmt.Errorf("failed to reach Telegram API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := readAll(resp.Body)
+		return fmt.Errorf("telegram getMe failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(bodyBytes)))
+	}
+	return nil
+}
+
+// escapeTextForMarkdownV2 escapes text for Telegram MarkdownV2.
+// Telegram requires escaping: _ * [ ] ( ) ~ ` > # + - = | { } . !
+func escapeTextForMarkdownV2(text string) string {
+	escapeChars := []string{"_", "*", "[", "]", "(", ")", "~", "`", ">", "#", "+", "-", "=", "|", "{", "}", ".", "!"}
+	var result strings.Builder
+	for _, r := range text {
+		char := string(r)
+		shouldEscape := false
+		for _, esc := range escapeChars {
+			if char == esc {
+				shouldEscape = true
+				break
+			}
+		}
+		if shouldEscape {
+			result.WriteString("\\")
+		}
+		result.WriteString(char)
+	}
+	return result.String()
+}
+
+// Helper to read all from io.Reader (like ioutil.ReadAll)
+func readAll(r io.Reader) ([]byte, error) {
+    var b bytes.Buffer
+    _, err := b.ReadFrom(r)
+    return b.Bytes(), err
+}
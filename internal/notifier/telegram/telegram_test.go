@@ -0,0 +1,343 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      config.TelegramChannelConfig
+		expectError bool
+	}{
+		{
+			name: "valid_config",
+			config: config.TelegramChannelConfig{
+				BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+				ChatID:   "-123456789",
+			},
+			expectError: false,
+		},
+		{
+			name: "missing_token",
+			config: config.TelegramChannelConfig{
+				ChatID: "-123456789",
+			},
+			expectError: true,
+		},
+		{
+			name: "missing_chat_id",
+			config: config.TelegramChannelConfig{
+				BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := New("test-telegram", tc.config)
+
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, n)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, n)
+				assert.Equal(t, "test-telegram", n.Name())
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	// Create a mock HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Contains(t, r.URL.Path, "/sendMessage")
+
+		// Check request body (JSON format)
+		body, _ := io.ReadAll(r.Body)
+		bodyStr := string(body)
+		assert.Contains(t, bodyStr, "\"-123456789\"")
+		assert.Contains(t, bodyStr, "Test Alert")
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok": true, "result": {"message_id": 1}}`))
+	}))
+	defer server.Close()
+
+	config := config.TelegramChannelConfig{
+		BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+		ChatID:   "-123456789",
+	}
+
+	n, err := New("test-telegram", config)
+	require.NoError(t, err)
+
+	// Replace the Telegram API URL with our test server
+	// This is a bit hacky but works for testing
+	originalClient := n.client
+	n.client = &http.Client{
+		Transport: &MockTransport{
+			server: server,
+		},
+	}
+	defer func() { n.client = originalClient }()
+
+	testData := notifier.NotificationData{
+		AlertName:      "Test Alert",
+		MetricName:     "test_metric",
+		MetricValue:    50.0,
+		ThresholdValue: 40.0,
+		Condition:      ">",
+		State:          "FIRED",
+		Hostname:       "test-host",
+		Time:           time.Now(),
+		DurationString: "1m",
+		Aggregation:    "average",
+	}
+
+	templates := notifier.NotificationTemplates{
+		FiredTemplate: "FIRED: {{ .AlertName }} on {{ .Hostname }}",
+	}
+
+	err = n.Send(testData, templates)
+	require.NoError(t, err)
+}
+
+func TestSendError(t *testing.T) {
+	// Create a mock HTTP server that returns an error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok": false, "error_code": 400, "description": "Bad Request"}`))
+	}))
+	defer server.Close()
+
+	config := config.TelegramChannelConfig{
+		BotToken: "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11",
+		ChatID:   "-123456789",
+	}
+
+	n, err := New("test-telegram", config)
+	require.NoError(t, err)
+
+	n.client = &http.Client{
+		Transport: &MockTransport{
+			server: server,
+		},
+	}
+
+	testData := notifier.NotificationData{
+		AlertName: "Test Alert",
+		State:     "FIRED",
+		Hostname:  "test-host",
+		Time:      time.Now(),
+	}
+
+	templates := notifier.NotificationTemplates{
+		FiredTemplate: "FIRED: {{ .AlertName }}",
+	}
+
+	err = n.Send(testData, templates)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "telegram API request failed")
+}
+
+// MockTransport is a helper for mocking HTTP requests
+type MockTransport struct {
+	server *httptest.Server
+}
+
+func (t *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Replace the URL with our test server URL
+	req.URL.Scheme = "http"
+	req.URL.Host = strings.TrimPrefix(t.server.URL, "http://")
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCheckHealth(t *testing.T) {
+	status := http.StatusOK
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(status)
+		w.Write([]byte(`{"ok": false, "error_code": 401, "description": "Unauthorized"}`))
+	}))
+	defer server.Close()
+
+	n, err := New("test-telegram", config.TelegramChannelConfig{BotToken: "123456:ABC", ChatID: "-1"})
+	require.NoError(t, err)
+	n.client = &http.Client{Transport: &MockTransport{server: server}}
+
+	require.NoError(t, n.CheckHealth(context.Background()))
+	assert.Equal(t, "/bot123456:ABC/getMe", path)
+
+	status = http.StatusUnauthorized
+	err = n.CheckHealth(context.Background())
+	assert.ErrorContains(t, err, "status 401")
+}
+
+func TestSendThreading(t *testing.T) {
+	type request struct {
+		Method string
+		Body   map[string]any
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, request{path.Base(r.URL.Path), body})
+		w.Write([]byte(fmt.Sprintf(`{"ok": true, "result": {"message_id": %d}}`, 40+len(requests))))
+	}))
+	defer server.Close()
+
+	templates := notifier.NotificationTemplates{FiredTemplate: "FIRED {{ .AlertName }}", ResolvedTemplate: "RESOLVED {{ .AlertName }}"}
+	send := func(n *Notifier, alert, state string) {
+		require.NoError(t, n.Send(notifier.NotificationData{AlertName: alert, State: state}, templates))
+	}
+	newNotifier := func(threading string) *Notifier {
+		n, err := New("test-telegram", config.TelegramChannelConfig{BotToken: "123456:ABC", ChatID: "-1", Threading: threading})
+		require.NoError(t, err)
+		n.client = &http.Client{Transport: &MockTransport{server: server}}
+		return n
+	}
+
+	n := newNotifier(config.TelegramThreadingReply)
+	send(n, "cpu", "FIRED")  // Message 41
+	send(n, "disk", "FIRED") // Message 42
+	send(n, "cpu", "FIRED")  // Severity change, replies to 41
+	send(n, "cpu", "RESOLVED")
+	send(n, "cpu", "RESOLVED") // Already resolved: unthreaded
+	require.Len(t, requests, 5)
+	assert.Nil(t, requests[1].Body["reply_parameters"])
+	assert.Equal(t, map[string]any{"message_id": 41.0, "allow_sending_without_reply": true}, requests[2].Body["reply_parameters"])
+	assert.Equal(t, map[string]any{"message_id": 41.0, "allow_sending_without_reply": true}, requests[3].Body["reply_parameters"])
+	assert.Nil(t, requests[4].Body["reply_parameters"])
+
+	requests = nil
+	n = newNotifier(config.TelegramThreadingEdit)
+	send(n, "cpu", "FIRED")
+	send(n, "cpu", "RESOLVED")
+	require.Len(t, requests, 2)
+	assert.Equal(t, "editMessageText", requests[1].Method)
+	assert.Equal(t, 41.0, requests[1].Body["message_id"])
+	assert.Equal(t, "RESOLVED cpu", requests[1].Body["text"])
+
+	requests = nil
+	n = newNotifier(config.TelegramThreadingOff)
+	send(n, "cpu", "FIRED")
+	send(n, "cpu", "RESOLVED")
+	require.Len(t, requests, 2)
+	assert.Equal(t, "sendMessage", requests[1].Method)
+	assert.Nil(t, requests[1].Body["reply_parameters"])
+}
+
+func TestSendQuickResolve(t *testing.T) {
+	type request struct {
+		Method string
+		Body   map[string]any
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, request{path.Base(r.URL.Path), body})
+		w.Write([]byte(fmt.Sprintf(`{"ok": true, "result": {"message_id": %d}}`, 40+len(requests))))
+	}))
+	defer server.Close()
+
+	templates := notifier.NotificationTemplates{FiredTemplate: "FIRED {{ .AlertName }}", ResolvedTemplate: "RESOLVED {{ .AlertName }}"}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	send := func(n *Notifier, state string, after time.Duration) {
+		data := notifier.NotificationData{AlertName: "cpu", State: state, Hostname: "web-1", Time: start.Add(after)}
+		require.NoError(t, n.Send(data, templates))
+	}
+	newNotifier := func(quickResolve string) *Notifier {
+		n, err := New("test-telegram", config.TelegramChannelConfig{
+			BotToken: "123456:ABC", ChatID: "-1", Threading: config.TelegramThreadingReply,
+			QuickResolve: quickResolve, QuickResolveMaxAge: 5 * time.Minute,
+		})
+		require.NoError(t, err)
+		n.client = &http.Client{Transport: &MockTransport{server: server}}
+		return n
+	}
+
+	n := newNotifier(config.TelegramQuickResolveDelete)
+	send(n, "FIRED", 0)           // Message 41
+	send(n, "FIRED", time.Minute) // Severity change, message 42
+	send(n, "RESOLVED", 2*time.Minute)
+	require.Len(t, requests, 3)
+	assert.Equal(t, "deleteMessages", requests[2].Method)
+	assert.Equal(t, []any{41.0, 42.0}, requests[2].Body["message_ids"])
+
+	requests = nil
+	n = newNotifier(config.TelegramQuickResolveCollapse)
+	send(n, "FIRED", 0)
+	send(n, "RESOLVED", 5*time.Minute)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "editMessageText", requests[1].Method)
+	assert.Equal(t, 41.0, requests[1].Body["message_id"])
+	assert.Equal(t, `ALERT RESOLVED: cpu on web\-1`, requests[1].Body["text"])
+
+	// Resolving later than quick_resolve_max_age replies as usual
+	requests = nil
+	send(n, "FIRED", 0)
+	send(n, "RESOLVED", 6*time.Minute)
+	require.Len(t, requests, 2)
+	assert.Equal(t, "sendMessage", requests[1].Method)
+	assert.Equal(t, "RESOLVED cpu", requests[1].Body["text"])
+}
+
+func TestSendThreadingUndeliveredResolve(t *testing.T) {
+	type request struct {
+		Method string
+		Body   map[string]any
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, request{path.Base(r.URL.Path), body})
+		w.Write([]byte(fmt.Sprintf(`{"ok": true, "result": {"message_id": %d}}`, 40+len(requests))))
+	}))
+	defer server.Close()
+
+	n, err := New("test-telegram", config.TelegramChannelConfig{
+		BotToken: "123456:ABC", ChatID: "-1", Threading: config.TelegramThreadingReply,
+		QuickResolve: config.TelegramQuickResolveDelete, QuickResolveMaxAge: 5 * time.Minute,
+	})
+	require.NoError(t, err)
+	n.client = &http.Client{Transport: &MockTransport{server: server}}
+
+	templates := notifier.NotificationTemplates{FiredTemplate: "FIRED {{ .AlertName }}", ResolvedTemplate: "RESOLVED {{ .AlertName }}"}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	send := func(incidentID, state string, after time.Duration) {
+		data := notifier.NotificationData{AlertName: "cpu", State: state, IncidentID: incidentID, Time: start.Add(after)}
+		require.NoError(t, n.Send(data, templates))
+	}
+
+	send("a1", "FIRED", 0) // Message 41; its RESOLVED is silenced, never sent here
+	send("b2", "FIRED", time.Hour)
+	send("b2", "RESOLVED", time.Hour+time.Minute)
+	require.Len(t, requests, 3)
+	assert.Nil(t, requests[1].Body["reply_parameters"], "a new incident starts a new thread")
+	assert.Equal(t, "deleteMessages", requests[2].Method, "quick_resolve times the new incident")
+	assert.Equal(t, []any{42.0}, requests[2].Body["message_ids"])
+}
@@ -0,0 +1,100 @@
+package voice
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// twilio places calls through the Twilio Programmable Voice API, with the
+// text read by a <Say> verb.
+type twilio struct {
+	client     *http.Client
+	baseURL    string
+	accountSID string
+	token      string
+	from       string
+	language   string
+}
+
+func (t *twilio) accountURL() string {
+	return t.baseURL + "/2010-04-01/Accounts/" + url.PathEscape(t.accountSID)
+}
+
+// twiml returns the instructions of a call reading text twice.
+func (t *twilio) twiml(text string) string {
+	var b strings.Builder
+	b.WriteString(`<Response><Say loop="2"`)
+	if t.language != "" {
+		b.WriteString(` language="`)
+		xml.EscapeText(&b, []byte(t.language))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">")
+	xml.EscapeText(&b, []byte(text))
+	b.WriteString("</Say></Response>")
+	return b.String()
+}
+
+func (t *twilio) call(to, text string) error {
+	form := url.Values{"To": {to}, "From": {t.from}, "Twiml": {t.twiml(text)}}
+	req, err := http.NewRequest(http.MethodPost, t.accountURL()+"/Calls.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.accountSID, t.token)
+	return do(t.client, req, "Twilio")
+}
+
+func (t *twilio) checkHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.accountURL()+".json", nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.accountSID, t.token)
+	return do(t.client, req, "Twilio")
+}
+
+func (t *twilio) describe(to string) string {
+	return "POST " + t.accountURL() + "/Calls.json (call " + to + " from " + t.from + ")"
+}
+
+// callMeBot places Telegram voice calls through CallMeBot, which reads the
+// text to users who authorized its bot. It needs no credentials.
+type callMeBot struct {
+	client   *http.Client
+	baseURL  string
+	language string
+}
+
+func (c *callMeBot) callURL(to, text string) string {
+	query := url.Values{"user": {to}, "text": {text}, "rpt": {"2"}}
+	if c.language != "" {
+		query.Set("lang", c.language)
+	}
+	return c.baseURL + "/start.php?" + query.Encode()
+}
+
+func (c *callMeBot) call(to, text string) error {
+	req, err := http.NewRequest(http.MethodGet, c.callURL(to, text), nil)
+	if err != nil {
+		return err
+	}
+	return do(c.client, req, "CallMeBot")
+}
+
+// checkHealth checks the API answers; CallMeBot has no credentials to check.
+func (c *callMeBot) checkHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL+"/start.php", nil)
+	if err != nil {
+		return err
+	}
+	return do(c.client, req, "CallMeBot")
+}
+
+func (c *callMeBot) describe(to string) string {
+	return "GET " + c.baseURL + "/start.php (Telegram call to " + to + ")"
+}
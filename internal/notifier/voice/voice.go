@@ -0,0 +1,151 @@
+// Package voice implements the "voice" notification channel type, which
+// phones the on-call people and reads the alert's name and host by text to
+// speech, through Twilio or CallMeBot. It is meant for the highest
+// escalation level, e.g. with the channel's min_severity set to "critical".
+package voice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+)
+
+func init() {
+	notifier.Register("voice", func(nc config.NotificationChannelConfig) (notifier.Notifier, error) {
+		voiceCfg, err := config.GetVoiceChannelConfig(nc)
+		if err != nil {
+			return nil, err
+		}
+		return New(nc.Name, *voiceCfg)
+	})
+}
+
+// provider places calls through the API of a voice provider.
+type provider interface {
+	call(to, text string) error
+	checkHealth(ctx context.Context) error
+	describe(to string) string // How a call is placed, for previews
+}
+
+type Notifier struct {
+	name     string
+	config   config.VoiceChannelConfig
+	provider provider
+}
+
+func New(name string, cfg config.VoiceChannelConfig) (*Notifier, error) {
+	if len(cfg.To) == 0 {
+		return nil, fmt.Errorf("voice notifier '%s' has no one to call", name)
+	}
+	client, err := outbound.NewHTTPClient(outbound.Options{
+		Proxy:         cfg.Proxy,
+		TLS:           cfg.TLS,
+		AddressFamily: cfg.AddressFamily,
+		Resolver:      cfg.DNSResolver,
+	}, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("voice notifier '%s': %w", name, err)
+	}
+	n := &Notifier{name: name, config: cfg}
+	switch cfg.Provider {
+	case config.VoiceProviderTwilio:
+		if cfg.Token == "" {
+			return nil, fmt.Errorf("voice notifier '%s' is missing token (from ENV)", name)
+		}
+		n.provider = &twilio{client: client, baseURL: cfg.URL, accountSID: cfg.AccountSID, token: cfg.Token, from: cfg.From, language: cfg.Language}
+	case config.VoiceProviderCallMeBot:
+		n.provider = &callMeBot{client: client, baseURL: cfg.URL, language: cfg.Language}
+	default:
+		return nil, fmt.Errorf("voice notifier '%s' has unknown provider '%s'", name, cfg.Provider)
+	}
+	return n, nil
+}
+
+func (n *Notifier) Name() string {
+	return n.name
+}
+
+// Speech returns the text read in the call of a notification. It is short
+// and plain, as templates written for chats don't read well aloud.
+func Speech(data notifier.NotificationData) string {
+	subject := fmt.Sprintf("%s on %s", data.AlertName, data.Hostname)
+	switch {
+	case data.Action != nil:
+		return fmt.Sprintf("Monres alert. %s. Remediation action %s %s.", subject, data.Action.Name, data.Action.Status)
+	case data.State == "RESOLVED":
+		return fmt.Sprintf("Monres alert resolved. %s.", subject)
+	}
+	text := "Monres alert. " + subject + "."
+	if data.Severity != "" {
+		text += " Severity " + data.Severity + "."
+	}
+	if data.FormattedMetricValue != "" {
+		text += fmt.Sprintf(" %s is %s.", data.MetricName, data.FormattedMetricValue)
+	}
+	return text
+}
+
+// Preview returns the calls Send would place, with the text read in them.
+func (n *Notifier) Preview(data notifier.NotificationData, _ notifier.NotificationTemplates) (string, error) {
+	var b strings.Builder
+	for _, to := range n.config.To {
+		fmt.Fprintf(&b, "%s\n", n.provider.describe(to))
+	}
+	fmt.Fprintf(&b, "\n%s", Speech(data))
+	return b.String(), nil
+}
+
+// Send calls everyone in to, in turn. It fails only if no call could be
+// placed; whether anyone picked up is not known.
+func (n *Notifier) Send(data notifier.NotificationData, _ notifier.NotificationTemplates) error {
+	text := Speech(data)
+	var errs []error
+	for _, to := range n.config.To {
+		if err := n.provider.call(to, text); err != nil {
+			errs = append(errs, fmt.Errorf("call to %s: %w", to, err))
+		}
+	}
+	if len(errs) == len(n.config.To) {
+		return fmt.Errorf("failed to place %s calls: %w", n.config.Provider, errors.Join(errs...))
+	}
+	for _, err := range errs {
+		log.Printf("Warning: Voice notifier '%s': %v", n.name, err)
+	}
+	return nil
+}
+
+// CheckHealth checks the provider's API accepts the channel's credentials,
+// without calling anyone.
+func (n *Notifier) CheckHealth(ctx context.Context) error {
+	return n.provider.checkHealth(ctx)
+}
+
+// do sends a request and fails on a status other than 2xx, with the start
+// of the response body.
+func do(client *http.Client, req *http.Request, service string) error {
+	req.Header.Set("User-Agent", "monres")
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // The URL may hold the text and recipient
+		}
+		return fmt.Errorf("%s request failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s request failed with status %d: %s", service, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
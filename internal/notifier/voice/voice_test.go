@@ -0,0 +1,84 @@
+package voice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+var fired = notifier.NotificationData{AlertName: "Disk Full", Hostname: "db-1", State: "FIRED", Severity: "critical", MetricName: "disk_percent_used_root", FormattedMetricValue: "99.1%"}
+
+func TestSendTwilio(t *testing.T) {
+	var calls []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "s3cret", pass)
+		if r.Method == http.MethodGet {
+			assert.Equal(t, "/2010-04-01/Accounts/AC123.json", r.URL.Path)
+			return
+		}
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Calls.json", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		calls = append(calls, r.PostForm)
+		if r.PostForm.Get("To") == "+15550002" {
+			http.Error(w, `{"message": "The 'To' number is not a valid phone number."}`, http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	n, err := New("phone", config.VoiceChannelConfig{
+		Provider:   config.VoiceProviderTwilio,
+		URL:        server.URL,
+		AccountSID: "AC123",
+		From:       "+15550000",
+		To:         []string{"+15550001", "+15550002"},
+		Language:   "en-GB",
+		Token:      "s3cret",
+	})
+	require.NoError(t, err)
+
+	// One call failing is only logged
+	require.NoError(t, n.Send(fired, notifier.NotificationTemplates{}))
+	require.Len(t, calls, 2)
+	assert.Equal(t, "+15550001", calls[0].Get("To"))
+	assert.Equal(t, "+15550000", calls[0].Get("From"))
+	assert.Equal(t, `<Response><Say loop="2" language="en-GB">Monres alert. Disk Full on db-1. Severity critical. disk_percent_used_root is 99.1%.</Say></Response>`, calls[0].Get("Twiml"))
+
+	require.NoError(t, n.CheckHealth(context.Background()))
+
+	n.config.To = []string{"+15550002"}
+	assert.ErrorContains(t, n.Send(fired, notifier.NotificationTemplates{}), "Twilio request failed with status 400")
+
+	_, err = New("phone", config.VoiceChannelConfig{Provider: config.VoiceProviderTwilio, URL: server.URL, To: []string{"+15550001"}})
+	assert.ErrorContains(t, err, "missing token")
+}
+
+func TestSendCallMeBot(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/start.php", r.URL.Path)
+		queries = append(queries, r.URL.Query())
+	}))
+	defer server.Close()
+
+	n, err := New("phone", config.VoiceChannelConfig{Provider: config.VoiceProviderCallMeBot, URL: server.URL, To: []string{"@oncall"}})
+	require.NoError(t, err)
+	resolved := fired
+	resolved.State = "RESOLVED"
+	require.NoError(t, n.Send(resolved, notifier.NotificationTemplates{}))
+	require.Len(t, queries, 1)
+	assert.Equal(t, url.Values{"user": {"@oncall"}, "text": {"Monres alert resolved. Disk Full on db-1."}, "rpt": {"2"}}, queries[0])
+
+	preview, err := n.Preview(fired, notifier.NotificationTemplates{})
+	require.NoError(t, err)
+	assert.Equal(t, "GET "+server.URL+"/start.php (Telegram call to @oncall)\n\nMonres alert. Disk Full on db-1. Severity critical. disk_percent_used_root is 99.1%.", preview)
+}
@@ -0,0 +1,165 @@
+// Package webhook implements the "webhook" notification channel type, which
+// POSTs every notification as JSON and optionally signs it with HMAC-SHA256.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+	"github.com/mattmezza/monres/internal/outbound"
+)
+
+func init() {
+	notifier.Register("webhook", func(nc config.NotificationChannelConfig) (notifier.Notifier, error) {
+		webhookCfg, err := config.GetWebhookChannelConfig(nc)
+		if err != nil {
+			return nil, err
+		}
+		return New(nc.Name, *webhookCfg)
+	})
+}
+
+// Headers of signed requests.
+const (
+	HeaderTimestamp = "X-Monres-Timestamp" // Unix time of the request, in seconds
+	HeaderNonce     = "X-Monres-Nonce"     // Random hex string, unique per request
+	HeaderSignature = "X-Monres-Signature" // "sha256=" + hex HMAC of timestamp "." nonce "." body
+)
+
+type Notifier struct {
+	name   string
+	config config.WebhookChannelConfig
+	client *http.Client
+	now    func() time.Time // Replaced in tests
+}
+
+func New(name string, cfg config.WebhookChannelConfig) (*Notifier, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook notifier '%s' is missing url", name)
+	}
+	client, err := outbound.NewHTTPClient(outbound.Options{
+		Proxy:         cfg.Proxy,
+		TLS:           cfg.TLS,
+		AddressFamily: cfg.AddressFamily,
+		Resolver:      cfg.DNSResolver,
+	}, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("webhook notifier '%s': %w", name, err)
+	}
+	return &Notifier{name: name, config: cfg, client: client, now: time.Now}, nil
+}
+
+func (wn *Notifier) Name() string {
+	return wn.name
+}
+
+// payload returns the JSON event POSTed for a notification.
+func payload(data notifier.NotificationData, templates notifier.NotificationTemplates) ([]byte, error) {
+	msg, err := notifier.RenderMessage(data, templates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render webhook template for alert '%s': %w", data.AlertName, err)
+	}
+	body, err := json.Marshal(notifier.NewEvent(data, msg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	return body, nil
+}
+
+// Preview returns the request body, indented.
+func (wn *Notifier) Preview(data notifier.NotificationData, templates notifier.NotificationTemplates) (string, error) {
+	body, err := payload(data, templates)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("POST %s\n\n%s", wn.config.URL, buf.String()), nil
+}
+
+// Send POSTs the notification as a JSON event with the rendered message.
+func (wn *Notifier) Send(data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	body, err := payload(data, templates)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, wn.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "monres")
+	for k, v := range wn.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if wn.config.Secret != "" {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("failed to generate webhook nonce: %w", err)
+		}
+		timestamp := strconv.FormatInt(wn.now().Unix(), 10)
+		nonceHex := hex.EncodeToString(nonce)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderNonce, nonceHex)
+		req.Header.Set(HeaderSignature, Sign(wn.config.Secret, timestamp, nonceHex, body))
+	}
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// CheckHealth sends a HEAD request to the URL. Any answer below 500 counts
+// as healthy, since endpoints often only accept POST.
+func (wn *Notifier) CheckHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, wn.config.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("User-Agent", "monres")
+	for k, v := range wn.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("webhook answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of a request: "sha256=" followed
+// by the hex HMAC-SHA256 of timestamp, nonce and body joined with ".".
+// Receivers recompute it with the shared secret and compare in constant time.
+func Sign(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
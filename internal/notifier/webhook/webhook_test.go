@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+func TestSend(t *testing.T) {
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	n, err := New("hooks", config.WebhookChannelConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Team": "ops"},
+		Secret:  "s3cret",
+	})
+	require.NoError(t, err)
+	n.now = func() time.Time { return time.Unix(1700000000, 0) }
+	assert.Equal(t, "hooks", n.Name())
+
+	data := notifier.NotificationData{AlertName: "cpu_high", State: "FIRED", Severity: "critical", MetricName: "cpu_percent_total", MetricValue: 95}
+	templates := notifier.NotificationTemplates{FiredTemplate: "{{ .AlertName }} fired"}
+	require.NoError(t, n.Send(data, templates))
+	require.NoError(t, n.Send(data, templates))
+
+	require.Len(t, requests, 2)
+	r := requests[0]
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "ops", r.Header.Get("X-Team"))
+	assert.Equal(t, "1700000000", r.Header.Get(HeaderTimestamp))
+	assert.Len(t, r.Header.Get(HeaderNonce), 32)
+	assert.NotEqual(t, r.Header.Get(HeaderNonce), requests[1].Header.Get(HeaderNonce))
+
+	// The receiver's check
+	expected := Sign("s3cret", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce), bodies[0])
+	assert.True(t, hmac.Equal([]byte(expected), []byte(r.Header.Get(HeaderSignature))))
+	assert.NotEqual(t, expected, Sign("wrong", r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderNonce), bodies[0]))
+
+	var event notifier.Event
+	require.NoError(t, json.Unmarshal(bodies[0], &event))
+	assert.Equal(t, "cpu_high", event.Alert)
+	assert.Equal(t, "cpu_high fired", event.Message)
+}
+
+func TestSendUnsigned(t *testing.T) {
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(HeaderSignature)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n, err := New("hooks", config.WebhookChannelConfig{URL: server.URL})
+	require.NoError(t, err)
+	err = n.Send(notifier.NotificationData{AlertName: "a", State: "RESOLVED"}, notifier.NotificationTemplates{ResolvedTemplate: "ok"})
+	assert.ErrorContains(t, err, "status 502")
+	assert.Empty(t, signature)
+}
+
+func TestSign(t *testing.T) {
+	// Known value, so receivers in other languages can check their implementation
+	assert.Equal(t, "sha256=bb6100887c3caa44e1e10f6c0a4e070feaaa0f33f506e804c9ce870ff7ab2d6d",
+		Sign("secret", "1700000000", "abc", []byte(`{"alert":"x"}`)))
+}
+
+func TestCheckHealth(t *testing.T) {
+	status := http.StatusMethodNotAllowed
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	n, err := New("hooks", config.WebhookChannelConfig{URL: server.URL})
+	require.NoError(t, err)
+	require.NoError(t, n.CheckHealth(context.Background()))
+	assert.Equal(t, http.MethodHead, method)
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, n.CheckHealth(context.Background()), "status 503")
+
+	server.Close()
+	assert.ErrorContains(t, n.CheckHealth(context.Background()), "failed to reach webhook")
+}
@@ -0,0 +1,137 @@
+package outbound
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Address families preferred when a host resolves to both IPv4 and IPv6 addresses.
+const (
+	FamilyAny      = "any"       // Race both, as the standard library does (default)
+	FamilyPreferV4 = "prefer_v4" // Try IPv4 addresses first
+	FamilyPreferV6 = "prefer_v6" // Try IPv6 addresses first
+)
+
+// ValidateAddressFamily checks an address family setting; empty means FamilyAny.
+func ValidateAddressFamily(family string) error {
+	switch family {
+	case "", FamilyAny, FamilyPreferV4, FamilyPreferV6:
+		return nil
+	}
+	return fmt.Errorf("invalid address_family %q: must be %s, %s or %s", family, FamilyAny, FamilyPreferV4, FamilyPreferV6)
+}
+
+// ValidateResolver checks a DNS resolver setting: empty for the system
+// resolver, or an IP address with an optional port (default 53).
+func ValidateResolver(resolver string) error {
+	_, err := resolverAddress(resolver)
+	return err
+}
+
+// resolverAddress returns the host:port of a DNS resolver setting.
+func resolverAddress(resolver string) (string, error) {
+	if resolver == "" {
+		return "", nil
+	}
+	host, port, err := net.SplitHostPort(resolver)
+	if err != nil {
+		host, port = strings.Trim(resolver, "[]"), "53"
+	}
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("invalid dns_resolver %q: must be an IP address with an optional port", resolver)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		return "", fmt.Errorf("invalid dns_resolver %q: invalid port", resolver)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Dialer opens TCP connections, trying the addresses of a host in the order
+// of its address family preference.
+type Dialer struct {
+	family   string
+	resolver *net.Resolver
+	dialer   net.Dialer
+}
+
+// NewDialer creates a Dialer for the address family and DNS resolver of opts.
+func NewDialer(opts Options) (*Dialer, error) {
+	if err := ValidateAddressFamily(opts.AddressFamily); err != nil {
+		return nil, err
+	}
+	address, err := resolverAddress(opts.Resolver)
+	if err != nil {
+		return nil, err
+	}
+	resolver := net.DefaultResolver
+	if address != "" {
+		// Bypass resolv.conf: the local resolver may be what is broken
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+		}
+	}
+	return &Dialer{
+		family:   opts.AddressFamily,
+		resolver: resolver,
+		dialer:   net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Resolver: resolver},
+	}, nil
+}
+
+// DialContext connects to address on the named network.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.family == "" || d.family == FamilyAny {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	preferV4 := d.family == FamilyPreferV4
+	slices.SortStableFunc(ips, func(a, b net.IP) int {
+		aPreferred := (a.To4() != nil) == preferV4
+		bPreferred := (b.To4() != nil) == preferV4
+		switch {
+		case aPreferred && !bPreferred:
+			return -1
+		case bPreferred && !aPreferred:
+			return 1
+		}
+		return 0
+	})
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, lastErr
+}
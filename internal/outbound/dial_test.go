@@ -0,0 +1,107 @@
+package outbound
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAddressFamily(t *testing.T) {
+	for _, family := range []string{"", FamilyAny, FamilyPreferV4, FamilyPreferV6} {
+		assert.NoError(t, ValidateAddressFamily(family), family)
+	}
+	assert.Error(t, ValidateAddressFamily("v6_only"))
+}
+
+// listenTCP4 accepts and closes connections on a local IPv4 port.
+func listenTCP4(t *testing.T) (port string) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, err = net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func TestDialerAddressFamily(t *testing.T) {
+	port := listenTCP4(t)
+
+	for _, family := range []string{FamilyAny, FamilyPreferV4, FamilyPreferV6} {
+		// Falls back to IPv4 when localhost also resolves to an unreachable ::1
+		dialer, err := NewDialer(Options{AddressFamily: family})
+		require.NoError(t, err)
+		conn, err := dialer.DialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+		require.NoError(t, err, family)
+		assert.NotNil(t, conn.RemoteAddr().(*net.TCPAddr).IP.To4(), family)
+		conn.Close()
+	}
+}
+
+func TestValidateResolver(t *testing.T) {
+	for _, resolver := range []string{"", "1.1.1.1", "1.1.1.1:5353", "[2606:4700::1111]:53", "2606:4700::1111"} {
+		assert.NoError(t, ValidateResolver(resolver), resolver)
+	}
+	for _, resolver := range []string{"dns.example.com", "dns.example.com:53", "1.1.1.1:0", "1.1.1.1:dns"} {
+		assert.Error(t, ValidateResolver(resolver), resolver)
+	}
+}
+
+// fakeDNSServer answers every A query with 127.0.0.1 and every other query
+// with no records.
+func fakeDNSServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+			questionEnd := 12
+			for query[questionEnd] != 0 {
+				questionEnd += int(query[questionEnd]) + 1
+			}
+			questionEnd += 5 // Root label, type and class
+			qtype := binary.BigEndian.Uint16(query[questionEnd-4:])
+
+			resp := append([]byte{}, query[:questionEnd]...)
+			binary.BigEndian.PutUint16(resp[2:], 0x8180) // Response, recursion available
+			binary.BigEndian.PutUint16(resp[10:], 0)     // No additional records
+			if qtype == 1 {
+				binary.BigEndian.PutUint16(resp[6:], 1)
+				resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+			}
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDialerResolver(t *testing.T) {
+	port := listenTCP4(t)
+	resolver := fakeDNSServer(t)
+
+	for _, family := range []string{FamilyAny, FamilyPreferV6} {
+		dialer, err := NewDialer(Options{AddressFamily: family, Resolver: resolver})
+		require.NoError(t, err)
+		conn, err := dialer.DialContext(context.Background(), "tcp", net.JoinHostPort("monitored.invalid", port))
+		require.NoError(t, err, family)
+		conn.Close()
+	}
+}
@@ -0,0 +1,78 @@
+// Package outbound builds the HTTP clients notifiers use to reach the
+// internet, applying proxy, TLS, address family and DNS resolver settings
+// from the configuration.
+package outbound
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyDirect disables proxying, including proxies set in the environment.
+const ProxyDirect = "direct"
+
+// Options controls how outbound connections are made.
+type Options struct {
+	// Proxy is a proxy URL (http://, https://, socks5:// or socks5h://),
+	// ProxyDirect, or empty to use HTTPS_PROXY, HTTP_PROXY and NO_PROXY.
+	Proxy string
+	TLS   TLSOptions
+	// AddressFamily is FamilyAny, FamilyPreferV4 or FamilyPreferV6 (empty means FamilyAny)
+	AddressFamily string
+	// Resolver is the DNS server (IP with optional port) used instead of the system resolver
+	Resolver string
+}
+
+// ValidateProxy checks a proxy setting.
+func ValidateProxy(proxy string) error {
+	_, err := proxyFunc(proxy)
+	return err
+}
+
+// proxyFunc returns the http.Transport proxy function for a proxy setting.
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyDirect:
+		return nil, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https, socks5 or socks5h", proxy)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// NewHTTPClient creates an HTTP client for opts whose requests time out after timeout.
+func NewHTTPClient(opts Options, timeout time.Duration) (*http.Client, error) {
+	proxy, err := proxyFunc(opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	dialer, err := NewDialer(opts)
+	if err != nil {
+		return nil, err
+	}
+	transport.DialContext = dialer.DialContext
+	if !opts.TLS.IsZero() {
+		transport.TLSClientConfig, err = NewTLSConfig(opts.TLS, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
@@ -0,0 +1,77 @@
+package outbound
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSOptions customizes certificate verification and client authentication
+// for a channel's TLS connections.
+type TLSOptions struct {
+	CAFile             string // PEM bundle trusted in addition to the system roots
+	CertFile           string // Client certificate (PEM), requires KeyFile
+	KeyFile            string // Client key (PEM), requires CertFile
+	MinVersion         string // "1.0", "1.1", "1.2" or "1.3"; empty for Go's default
+	InsecureSkipVerify bool   // Disables verification of the server certificate
+}
+
+// IsZero reports whether no TLS option is set.
+func (o TLSOptions) IsZero() bool {
+	return o == TLSOptions{}
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ValidateTLS checks TLS options, including that their files can be loaded.
+func ValidateTLS(opts TLSOptions) error {
+	_, err := NewTLSConfig(opts, "")
+	return err
+}
+
+// NewTLSConfig creates the tls.Config for opts. serverName may be empty when
+// the caller (such as an http.Transport) fills it in.
+func NewTLSConfig(opts TLSOptions, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.MinVersion != "" {
+		version, ok := tlsVersions[opts.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS min_version %q: must be 1.0, 1.1, 1.2 or 1.3", opts.MinVersion)
+		}
+		cfg.MinVersion = version
+	}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS ca_file %s contains no PEM certificates", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, fmt.Errorf("TLS cert_file and key_file must be set together")
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,58 @@
+package outbound
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClientCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0644))
+
+	client, err := NewHTTPClient(Options{Proxy: ProxyDirect}, time.Second)
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err, "self-signed certificate must not be trusted by default")
+
+	client, err = NewHTTPClient(Options{Proxy: ProxyDirect, TLS: TLSOptions{CAFile: caFile}}, time.Second)
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	client, err = NewHTTPClient(Options{Proxy: ProxyDirect, TLS: TLSOptions{InsecureSkipVerify: true}}, time.Second)
+	require.NoError(t, err)
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestValidateTLS(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0644))
+
+	assert.NoError(t, ValidateTLS(TLSOptions{}))
+	assert.NoError(t, ValidateTLS(TLSOptions{MinVersion: "1.3"}))
+	for name, opts := range map[string]TLSOptions{
+		"bad_min_version":  {MinVersion: "1.4"},
+		"missing_ca_file":  {CAFile: filepath.Join(dir, "missing.pem")},
+		"ca_file_not_pem":  {CAFile: notPEM},
+		"cert_without_key": {CertFile: notPEM},
+		"bad_key_pair":     {CertFile: notPEM, KeyFile: notPEM},
+	} {
+		assert.Error(t, ValidateTLS(opts), name)
+	}
+}
@@ -0,0 +1,193 @@
+package promrules
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+)
+
+// PromRule is a Prometheus alerting rule exported from a monres alert rule.
+type PromRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Exported is the export of one monres alert rule: a Prometheus rule per
+// severity tier.
+type Exported struct {
+	Name  string     // Name of the monres rule
+	Rules []PromRule // Least severe first
+	Notes []string   // Approximations and settings Prometheus has no equivalent of
+}
+
+// nodeExporterExprs are node_exporter expressions computing the built-in
+// metrics, for hosts scraped by Prometheus rather than running monres.
+var nodeExporterExprs = map[string]string{
+	"cpu_percent_total":   `100 * (1 - avg by (instance) (rate(node_cpu_seconds_total{mode="idle"}[1m])))`,
+	"cpu_percent_user":    `100 * sum by (instance) (rate(node_cpu_seconds_total{mode=~"user|nice"}[1m])) / count by (instance) (node_cpu_seconds_total{mode="idle"})`,
+	"cpu_percent_system":  `100 * sum by (instance) (rate(node_cpu_seconds_total{mode=~"system|irq|softirq"}[1m])) / count by (instance) (node_cpu_seconds_total{mode="idle"})`,
+	"cpu_percent_iowait":  `100 * avg by (instance) (rate(node_cpu_seconds_total{mode="iowait"}[1m]))`,
+	"cpu_percent_steal":   `100 * avg by (instance) (rate(node_cpu_seconds_total{mode="steal"}[1m]))`,
+	"mem_percent_used":    `100 * (1 - node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes)`,
+	"mem_percent_free":    `100 * node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes`,
+	"swap_percent_used":   `100 * (1 - node_memory_SwapFree_bytes / node_memory_SwapTotal_bytes)`,
+	"swap_percent_free":   `100 * node_memory_SwapFree_bytes / node_memory_SwapTotal_bytes`,
+	"disk_read_bytes_ps":  `sum by (instance) (rate(node_disk_read_bytes_total[1m]))`,
+	"disk_write_bytes_ps": `sum by (instance) (rate(node_disk_written_bytes_total[1m]))`,
+	"net_recv_bytes_ps":   `sum by (instance) (rate(node_network_receive_bytes_total{device!="lo"}[1m]))`,
+	"net_sent_bytes_ps":   `sum by (instance) (rate(node_network_transmit_bytes_total{device!="lo"}[1m]))`,
+}
+
+// Export translates loaded monres alert rules into Prometheus alerting rules,
+// the reverse of Convert. Metrics keep their monres names unless nodeExporter
+// is set, in which case built-in metrics are computed from node_exporter's.
+func Export(rules []config.AlertRuleConfig, nodeExporter bool) []Exported {
+	exported := make([]Exported, 0, len(rules))
+	for _, rule := range rules {
+		exported = append(exported, exportRule(rule, nodeExporter))
+	}
+	return exported
+}
+
+func exportRule(rule config.AlertRuleConfig, nodeExporter bool) Exported {
+	e := Exported{Name: rule.Name}
+	tiers := rule.Tiers
+	if !rule.OverrideUntil.IsZero() {
+		tiers = rule.BaseTiers
+		e.Notes = append(e.Notes, fmt.Sprintf("the temporary override until %s is not exported", rule.OverrideUntil.Format("2006-01-02 15:04")))
+	}
+
+	series, subquery := rule.Metric, false
+	if expr, ok := nodeExporterExprs[rule.Metric]; ok && nodeExporter {
+		series, subquery = expr, true
+	} else if rule.IsTemplate() {
+		// Prometheus alerts on every series matched, like monres on every entity
+		series = fmt.Sprintf(`{__name__=~"%s"}`, strings.Replace(regexp.QuoteMeta(rule.Metric), `\*`, ".+", 1))
+		e.Notes = append(e.Notes, fmt.Sprintf("the metrics matching %s must be scraped by Prometheus under these names", rule.Metric))
+		if len(rule.Entities) > 0 || len(rule.Exclude) > 0 {
+			e.Notes = append(e.Notes, "entities and exclude are not exported; every entity has the thresholds of the rule")
+		}
+	} else {
+		e.Notes = append(e.Notes, fmt.Sprintf("%s must be scraped by Prometheus under this name", rule.Metric))
+	}
+	if rule.Duration > 0 {
+		function := "avg_over_time"
+		if strings.EqualFold(rule.Aggregation, "max") {
+			function = "max_over_time"
+		}
+		if subquery {
+			series = fmt.Sprintf("%s((%s)[%s:])", function, series, promDuration(rule.Duration.String()))
+		} else {
+			series = fmt.Sprintf("%s(%s[%s])", function, series, promDuration(rule.Duration.String()))
+		}
+	}
+	if (rule.Condition == "==" || rule.Condition == "!=") && rule.Epsilon > 0 {
+		e.Notes = append(e.Notes, fmt.Sprintf("epsilon %g is not exported; %s compares exactly", rule.Epsilon, rule.Condition))
+	}
+
+	for _, tier := range tiers {
+		pr := PromRule{
+			Alert:  rule.Name,
+			Expr:   fmt.Sprintf("%s %s %s", series, rule.Condition, strconv.FormatFloat(tier.Threshold, 'g', -1, 64)),
+			Labels: map[string]string{"severity": tier.Severity},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s on {{ $labels.instance }}: %s %s %s (current: {{ $value }})",
+					rule.Name, rule.Metric, rule.Condition, strconv.FormatFloat(tier.Threshold, 'g', -1, 64)),
+			},
+		}
+		if rule.MinimumFiringDuration > 0 {
+			pr.For = promDuration(rule.MinimumFiringDuration.String())
+		}
+		if md, ok := metrics.Lookup(rule.Metric); ok && md.Description != "" {
+			pr.Annotations["description"] = md.Description
+		}
+		e.Rules = append(e.Rules, pr)
+	}
+	if len(tiers) > 1 {
+		e.Notes = append(e.Notes, "Prometheus fires every tier that is breached; inhibit the lower severities in Alertmanager")
+	}
+
+	if len(rule.Overrides) > 0 {
+		e.Notes = append(e.Notes, "per-host overrides are not exported; the thresholds are the ones of this host")
+	}
+	for _, s := range []struct {
+		name string
+		set  bool
+	}{
+		{"auto_resolve_after", rule.AutoResolveAfter > 0},
+		{"notify_on_resolve", !rule.SendsResolved()},
+		{"top_processes", rule.TopProcesses > 0},
+		{"capture", len(rule.Capture) > 0},
+		{"actions", len(rule.Actions) > 0},
+	} {
+		if s.set {
+			e.Notes = append(e.Notes, s.name+" has no Prometheus equivalent")
+		}
+	}
+	e.Notes = append(e.Notes, "channels "+strings.Join(rule.Channels, ", ")+": route by alertname in Alertmanager")
+	return e
+}
+
+// promDuration trims the zero units Go adds to durations ("5m0s" -> "5m"),
+// which Prometheus doesn't accept.
+func promDuration(d string) string {
+	if strings.HasSuffix(d, "m0s") {
+		d = strings.TrimSuffix(d, "0s")
+	}
+	if strings.HasSuffix(d, "h0m") {
+		d = strings.TrimSuffix(d, "0m")
+	}
+	return d
+}
+
+// MarshalPrometheus renders exported rules as a Prometheus rule file with a
+// single group, with notes as comments.
+func MarshalPrometheus(exported []Exported, group string) ([]byte, error) {
+	rules := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, e := range exported {
+		for i, pr := range e.Rules {
+			var node yaml.Node
+			if err := node.Encode(pr); err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				node.HeadComment = strings.Join(e.Notes, "\n")
+			}
+			rules.Content = append(rules.Content, &node)
+		}
+	}
+	if len(rules.Content) == 0 {
+		rules.Style = yaml.FlowStyle
+	}
+	groupNode := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "name"},
+		{Kind: yaml.ScalarNode, Value: group},
+		{Kind: yaml.ScalarNode, Value: "rules"},
+		rules,
+	}}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "groups"},
+		{Kind: yaml.SequenceNode, Content: []*yaml.Node{groupNode}},
+	}}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,78 @@
+package promrules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func TestExport(t *testing.T) {
+	rules := []config.AlertRuleConfig{
+		{
+			Name: "High CPU", Metric: "cpu_percent_total", Condition: ">", Duration: 5 * time.Minute, Aggregation: "average",
+			Tiers:    []config.ThresholdTier{{Severity: config.SeverityWarning, Threshold: 80}, {Severity: config.SeverityCritical, Threshold: 95}},
+			Channels: []string{"email"},
+		},
+		{
+			Name: "Backup stale", Metric: "backup_age_seconds", Condition: ">=", MinimumFiringDuration: 90 * time.Minute,
+			Tiers:    []config.ThresholdTier{{Severity: config.SeverityCritical, Threshold: 86400}},
+			Capture:  []string{"ls -l /backups"},
+			Channels: []string{"telegram"},
+		},
+	}
+
+	exported := Export(rules, false)
+	require.Len(t, exported, 2)
+	cpu := exported[0]
+	require.Len(t, cpu.Rules, 2)
+	assert.Equal(t, "avg_over_time(cpu_percent_total[5m]) > 80", cpu.Rules[0].Expr)
+	assert.Equal(t, map[string]string{"severity": "warning"}, cpu.Rules[0].Labels)
+	assert.Equal(t, "avg_over_time(cpu_percent_total[5m]) > 95", cpu.Rules[1].Expr)
+	assert.Equal(t, "Total CPU usage", cpu.Rules[1].Annotations["description"])
+	assert.Contains(t, cpu.Notes, "cpu_percent_total must be scraped by Prometheus under this name")
+
+	backup := exported[1]
+	assert.Equal(t, "backup_age_seconds >= 86400", backup.Rules[0].Expr)
+	assert.Equal(t, "1h30m", backup.Rules[0].For)
+	assert.Contains(t, backup.Notes, "capture has no Prometheus equivalent")
+
+	exported = Export(rules, true)
+	assert.Equal(t, `avg_over_time((100 * (1 - avg by (instance) (rate(node_cpu_seconds_total{mode="idle"}[1m]))))[5m:]) > 80`, exported[0].Rules[0].Expr)
+	assert.NotContains(t, exported[0].Notes, "cpu_percent_total must be scraped by Prometheus under this name")
+
+	exported = Export([]config.AlertRuleConfig{{
+		Name: "Disk full", Metric: "disk_percent_used_*", Condition: ">",
+		Tiers:    []config.ThresholdTier{{Severity: config.SeverityCritical, Threshold: 90}},
+		Exclude:  []string{"boot"},
+		Channels: []string{"email"},
+	}}, false)
+	assert.Equal(t, `{__name__=~"disk_percent_used_.+"} > 90`, exported[0].Rules[0].Expr)
+	assert.Contains(t, exported[0].Notes, "entities and exclude are not exported; every entity has the thresholds of the rule")
+}
+
+func TestMarshalPrometheus(t *testing.T) {
+	rules := []config.AlertRuleConfig{{
+		Name: "Memory", Metric: "mem_percent_used", Condition: ">",
+		Tiers: []config.ThresholdTier{{Severity: config.SeverityCritical, Threshold: 90}}, Channels: []string{"email"},
+	}}
+	out, err := MarshalPrometheus(Export(rules, false), "monres")
+	require.NoError(t, err)
+
+	// The output is a rule file Convert reads back
+	results, err := Convert(out, []string{"email"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, &Rule{
+		Name: "Memory", Metric: "mem_percent_used", Condition: ">", Threshold: 90,
+		Severity: config.SeverityCritical, Channels: []string{"email"},
+	}, results[0].Rule)
+	assert.Contains(t, string(out), "# channels email: route by alertname in Alertmanager")
+
+	out, err = MarshalPrometheus(nil, "monres")
+	require.NoError(t, err)
+	assert.Equal(t, "groups:\n  - name: monres\n    rules: []\n", string(out))
+}
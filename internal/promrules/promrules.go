@@ -0,0 +1,232 @@
+// Package promrules converts simple Prometheus alerting rules into monres
+// alert rules, and monres alert rules into Prometheus alerting rules.
+package promrules
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+)
+
+// ruleFile is the Prometheus rule file format.
+type ruleFile struct {
+	Groups []struct {
+		Name  string `yaml:"name"`
+		Rules []struct {
+			Alert       string            `yaml:"alert"`
+			Record      string            `yaml:"record"`
+			Expr        string            `yaml:"expr"`
+			For         string            `yaml:"for"`
+			Labels      map[string]string `yaml:"labels"`
+			Annotations map[string]string `yaml:"annotations"`
+		} `yaml:"rules"`
+	} `yaml:"groups"`
+}
+
+// Rule is a monres alert rule converted from a Prometheus alerting rule, in
+// the shape of the alerts section of the monres config.
+type Rule struct {
+	Name        string   `yaml:"name"`
+	Metric      string   `yaml:"metric"`
+	Condition   string   `yaml:"condition"`
+	Threshold   float64  `yaml:"threshold"`
+	Severity    string   `yaml:"severity,omitempty"`
+	Duration    string   `yaml:"duration,omitempty"`
+	Aggregation string   `yaml:"aggregation,omitempty"`
+	Channels    []string `yaml:"channels"`
+}
+
+// Result is the conversion of one Prometheus alerting rule.
+type Result struct {
+	Alert   string   // Name of the Prometheus alert
+	Rule    *Rule    // Nil if the rule could not be converted
+	Skipped string   // Why the rule was not converted
+	Notes   []string // Annotations and approximations worth a look
+}
+
+// exprPattern matches "metric{labels} op number", optionally wrapped in
+// avg_over_time or max_over_time with a range.
+var exprPattern = regexp.MustCompile(`^(?:(avg|max)_over_time\(\s*)?([a-zA-Z_:][a-zA-Z0-9_:]*)\s*(\{[^}]*\})?\s*(?:\[([0-9]+[smhdw])\])?\s*(\))?\s*(==|!=|>=|<=|>|<)\s*([-+]?[0-9.]+(?:[eE][-+]?[0-9]+)?)$`)
+
+// matcherPattern matches one equality label matcher.
+var matcherPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*=\s*"((?:[^"\\]|\\.)*)"\s*$`)
+
+// Convert translates the alerting rules of a Prometheus rule file. Recording
+// rules are ignored. Converted rules notify channels.
+func Convert(data []byte, channels []string) ([]Result, error) {
+	var file ruleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse Prometheus rule file: %w", err)
+	}
+	var results []Result
+	for _, group := range file.Groups {
+		for _, pr := range group.Rules {
+			if pr.Alert == "" {
+				continue // Recording rule
+			}
+			result := Result{Alert: pr.Alert}
+			rule, notes, err := convertRule(pr.Alert, pr.Expr, pr.For, pr.Labels, channels)
+			if err != nil {
+				result.Skipped = err.Error()
+			} else {
+				result.Rule = rule
+			}
+			result.Notes = append(result.Notes, notes...)
+			for _, name := range sortedKeys(pr.Annotations) {
+				result.Notes = append(result.Notes, fmt.Sprintf("%s: %s", name, strings.TrimSpace(pr.Annotations[name])))
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func convertRule(alert, expr, forStr string, labels map[string]string, channels []string) (*Rule, []string, error) {
+	expr = strings.Join(strings.Fields(expr), " ")
+	m := exprPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, nil, fmt.Errorf("expr %q is not a single metric comparison", expr)
+	}
+	function, name, selector, rangeStr, closing, op, thresholdStr := m[1], m[2], m[3], m[4], m[5], m[6], m[7]
+	if (function != "") != (closing != "") || (function != "") != (rangeStr != "") {
+		return nil, nil, fmt.Errorf("expr %q is not a single metric comparison", expr)
+	}
+	threshold, err := strconv.ParseFloat(thresholdStr, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid threshold in expr %q", expr)
+	}
+
+	var labelValues []string
+	if selector != "" {
+		inner := strings.TrimSpace(selector[1 : len(selector)-1])
+		for _, matcher := range splitMatchers(inner) {
+			mm := matcherPattern.FindStringSubmatch(matcher)
+			if mm == nil {
+				return nil, nil, fmt.Errorf("label matcher %q is not an equality match", strings.TrimSpace(matcher))
+			}
+			labelValues = append(labelValues, mm[2])
+		}
+	}
+
+	rule := &Rule{
+		Name:      alert,
+		Metric:    collector.TextfileMetricName(name, labelValues),
+		Condition: op,
+		Threshold: threshold,
+		Channels:  channels,
+	}
+	var notes []string
+	switch {
+	case forStr != "":
+		rule.Duration = forStr
+	case rangeStr != "":
+		rule.Duration = rangeStr
+	}
+	if rule.Duration != "" {
+		rule.Aggregation = "average"
+		if function == "max" {
+			rule.Aggregation = "max"
+		}
+		if forStr != "" && function == "" {
+			notes = append(notes, fmt.Sprintf("for: %s requires the condition to hold throughout; monres compares the %s over the window", forStr, rule.Aggregation))
+		}
+	}
+
+	switch severity := strings.ToLower(labels["severity"]); severity {
+	case "":
+	case config.SeverityInfo, config.SeverityWarning, config.SeverityCritical:
+		rule.Severity = severity
+	case "page":
+		rule.Severity = config.SeverityCritical
+	default:
+		notes = append(notes, fmt.Sprintf("severity %q has no monres equivalent; using %s", labels["severity"], config.SeverityCritical))
+	}
+	for _, name := range sortedKeys(labels) {
+		if name != "severity" {
+			notes = append(notes, fmt.Sprintf("label %s=%q dropped", name, labels[name]))
+		}
+	}
+	if _, ok := metrics.Lookup(rule.Metric); !ok {
+		notes = append(notes, fmt.Sprintf("%s is not a built-in metric; feed it through the textfile collector", rule.Metric))
+	}
+	return rule, notes, nil
+}
+
+// splitMatchers splits a label selector body on the commas between matchers.
+func splitMatchers(s string) []string {
+	var parts []string
+	inQuotes, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuotes = !inQuotes
+		case ',':
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Marshal renders results as the alerts section of a monres config, with
+// notes and skipped rules as comments.
+func Marshal(results []Result) ([]byte, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	var skipped []string
+	for _, result := range results {
+		if result.Rule == nil {
+			skipped = append(skipped, fmt.Sprintf("Skipped %s: %s", result.Alert, result.Skipped))
+			continue
+		}
+		var node yaml.Node
+		if err := node.Encode(result.Rule); err != nil {
+			return nil, err
+		}
+		node.HeadComment = strings.Join(result.Notes, "\n")
+		seq.Content = append(seq.Content, &node)
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "alerts"},
+		seq,
+	}}
+	if len(seq.Content) == 0 {
+		seq.Style = yaml.FlowStyle
+	}
+	doc.FootComment = strings.Join(skipped, "\n")
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2) // Like config.example.yaml
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+package promrules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+const rulesFile = `
+groups:
+  - name: node
+    rules:
+      - record: job:cpu:avg
+        expr: avg(cpu_percent_total)
+      - alert: HighCPU
+        expr: avg_over_time(cpu_percent_total[5m]) > 90
+        labels:
+          severity: page
+        annotations:
+          summary: "CPU is busy"
+      - alert: BackupTooOld
+        expr: backup_age_seconds{job="db"} >= 86400
+        for: 10m
+        labels:
+          severity: warning
+          team: storage
+      - alert: HostDown
+        expr: up == 0
+      - alert: HighLoad
+        expr: rate(node_cpu_seconds_total[5m]) > 0.9
+      - alert: RegexMatch
+        expr: disk_percent_used{device=~"sd.*"} > 90
+`
+
+func TestConvert(t *testing.T) {
+	results, err := Convert([]byte(rulesFile), []string{"stdout"})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, &Rule{
+		Name: "HighCPU", Metric: "cpu_percent_total", Condition: ">", Threshold: 90,
+		Severity: config.SeverityCritical, Duration: "5m", Aggregation: "average", Channels: []string{"stdout"},
+	}, results[0].Rule)
+	assert.Equal(t, []string{"summary: CPU is busy"}, results[0].Notes)
+
+	backup := results[1]
+	assert.Equal(t, &Rule{
+		Name: "BackupTooOld", Metric: "backup_age_seconds_db", Condition: ">=", Threshold: 86400,
+		Severity: config.SeverityWarning, Duration: "10m", Aggregation: "average", Channels: []string{"stdout"},
+	}, backup.Rule)
+	assert.Contains(t, backup.Notes, `label team="storage" dropped`)
+	assert.Len(t, backup.Notes, 3) // for: approximation, dropped label, not built-in
+
+	assert.Equal(t, "up", results[2].Rule.Metric)
+	assert.Empty(t, results[2].Rule.Duration)
+
+	assert.Nil(t, results[3].Rule)
+	assert.Contains(t, results[3].Skipped, "not a single metric comparison")
+	assert.Nil(t, results[4].Rule)
+	assert.Contains(t, results[4].Skipped, "not an equality match")
+}
+
+func TestMarshal(t *testing.T) {
+	results, err := Convert([]byte(rulesFile), []string{"email", "stdout"})
+	require.NoError(t, err)
+	out, err := Marshal(results)
+	require.NoError(t, err)
+
+	// The output is a valid alerts section
+	var parsed struct {
+		Alerts []config.AlertRuleConfig `yaml:"alerts"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &parsed))
+	require.Len(t, parsed.Alerts, 3)
+	assert.Equal(t, "HighCPU", parsed.Alerts[0].Name)
+	assert.Equal(t, []string{"email", "stdout"}, parsed.Alerts[0].Channels)
+
+	assert.Contains(t, string(out), "# summary: CPU is busy")
+	assert.Contains(t, string(out), "# Skipped HighLoad:")
+
+	out, err = Marshal(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "alerts: []\n", string(out))
+}
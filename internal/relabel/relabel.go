@@ -0,0 +1,77 @@
+// Package relabel renames and drops collected metrics according to the
+// relabel rules of the configuration.
+package relabel
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+)
+
+// Relabeler applies relabel rules to every collection cycle.
+// It implements collector.Processor.
+type Relabeler struct {
+	rules []config.RelabelConfig
+
+	mu      sync.Mutex
+	renames []rename          // Scratch space reused between cycles
+	known   map[string]string // Renamed metric -> source name, to copy metadata once
+}
+
+type rename struct {
+	from, to string
+}
+
+// New returns a Relabeler for rules that were validated by config.LoadConfig.
+func New(rules []config.RelabelConfig) *Relabeler {
+	return &Relabeler{rules: rules, known: make(map[string]string)}
+}
+
+// Process applies every rule in order to m, in place.
+func (r *Relabeler) Process(_ time.Time, m collector.CollectedMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rule := range r.rules {
+		r.renames = r.renames[:0]
+		for name := range m {
+			match := rule.Regex.FindStringSubmatchIndex(name)
+			if match == nil {
+				continue
+			}
+			if rule.Action == "drop" {
+				delete(m, name)
+				continue
+			}
+			target := string(rule.Regex.ExpandString(nil, rule.Target, name, match))
+			if target != name {
+				r.renames = append(r.renames, rename{from: name, to: target})
+			}
+		}
+		// Renames are applied after iterating so a renamed metric is not matched twice by the same rule
+		for _, rn := range r.renames {
+			m[rn.to] = m[rn.from]
+			delete(m, rn.from)
+			r.inheritMetadata(rn.from, rn.to)
+		}
+	}
+}
+
+// inheritMetadata gives a renamed metric the unit and description of its
+// source, unless metadata is already registered for the new name.
+func (r *Relabeler) inheritMetadata(from, to string) {
+	if _, seen := r.known[to]; seen {
+		return
+	}
+	r.known[to] = from
+	if _, ok := metrics.Lookup(to); ok {
+		return
+	}
+	if md, ok := metrics.Lookup(from); ok {
+		md.Name = to
+		metrics.Register(md)
+	}
+}
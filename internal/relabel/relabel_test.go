@@ -0,0 +1,80 @@
+package relabel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+)
+
+func loadRules(t *testing.T, yaml string) []config.RelabelConfig {
+	t.Helper()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(yaml), 0644))
+	cfg, err := config.LoadConfig(configFile)
+	require.NoError(t, err)
+	return cfg.Relabel
+}
+
+func TestRelabelerProcess(t *testing.T) {
+	r := New(loadRules(t, `
+relabel:
+  - source: "disk_percent_used_nvme0n1p1"
+    target: "disk_percent_used_root"
+  - source: "container_cpu_percent_(veth.*)"
+    action: "drop"
+  - source: "backup_age_seconds_(.+)"
+    target: "backup_age_${1}_seconds"
+`))
+
+	m := collector.CollectedMetrics{
+		"disk_percent_used_nvme0n1p1":    71,
+		"disk_percent_used_nvme0n1p2":    12,
+		"container_cpu_percent_veth1234": 3,
+		"container_cpu_percent_web":      4,
+		"backup_age_seconds_db":          3600,
+		"cpu_percent_total":              10,
+	}
+	r.Process(time.Now(), m)
+
+	assert.Equal(t, collector.CollectedMetrics{
+		"disk_percent_used_root":      71,
+		"disk_percent_used_nvme0n1p2": 12,
+		"container_cpu_percent_web":   4,
+		"backup_age_db_seconds":       3600,
+		"cpu_percent_total":           10,
+	}, m)
+}
+
+func TestRelabelerChainsRules(t *testing.T) {
+	r := New(loadRules(t, `
+relabel:
+  - source: "a"
+    target: "b"
+  - source: "b"
+    target: "c"
+`))
+	m := collector.CollectedMetrics{"a": 1}
+	r.Process(time.Now(), m)
+	assert.Equal(t, collector.CollectedMetrics{"c": 1}, m)
+}
+
+func TestRelabelerInheritsMetadata(t *testing.T) {
+	r := New(loadRules(t, `
+relabel:
+  - source: "cpu_percent_total"
+    target: "relabel_test_cpu"
+`))
+	r.Process(time.Now(), collector.CollectedMetrics{"cpu_percent_total": 5})
+
+	md, ok := metrics.Lookup("relabel_test_cpu")
+	require.True(t, ok)
+	assert.Equal(t, metrics.UnitPercent, md.Unit)
+}
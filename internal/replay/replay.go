@@ -0,0 +1,127 @@
+package replay
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+// Notification is a notification that was (or would have been) sent during a replay.
+type Notification struct {
+	Channel string
+	Data    notifier.NotificationData
+	Message string // Rendered template
+}
+
+// Recorder collects notifications instead of delivering them.
+type Recorder struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+// Channel returns a notifier recording into r under the given channel name.
+func (r *Recorder) Channel(name string) notifier.Notifier {
+	return &recordingNotifier{name: name, recorder: r}
+}
+
+// Notifications returns the recorded notifications in the order they were sent.
+func (r *Recorder) Notifications() []Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Notification(nil), r.notifications...)
+}
+
+type recordingNotifier struct {
+	name     string
+	recorder *Recorder
+}
+
+func (rn *recordingNotifier) Name() string {
+	return rn.name
+}
+
+func (rn *recordingNotifier) Send(data notifier.NotificationData, templates notifier.NotificationTemplates) error {
+	msg, err := notifier.RenderMessage(data, templates)
+	if err != nil {
+		return fmt.Errorf("failed to render template for alert '%s': %w", data.AlertName, err)
+	}
+	rn.recorder.mu.Lock()
+	defer rn.recorder.mu.Unlock()
+	rn.recorder.notifications = append(rn.recorder.notifications, Notification{Channel: rn.name, Data: data, Message: msg})
+	return nil
+}
+
+// eventBufferSize is how many alert events may queue up for delivery.
+const eventBufferSize = 64
+
+// Run feeds cycles through a fresh history buffer and alerter, delivering
+// notifications to the given notifiers. The history buffer is sized for the
+// spacing of the recorded cycles when it is shorter than the configured interval.
+//
+// speed controls pacing: 0 replays as fast as possible, otherwise the real
+// time between cycles is divided by speed (e.g. 60 replays an hour in a minute).
+func Run(cfg *config.Config, cycles []Cycle, notifiers map[string]notifier.Notifier, speed float64) error {
+	interval := cfg.CollectionInterval
+	if inferred := InferInterval(cycles); inferred > 0 && inferred < interval {
+		interval = inferred
+	}
+	hist := history.NewForConfig(cfg, interval)
+
+	a, err := alerter.NewAlerter(cfg, hist)
+	if err != nil {
+		return fmt.Errorf("failed to initialize alerter: %w", err)
+	}
+	router := alerter.NewRouter(cfg, notifiers)
+	events := a.Subscribe(eventBufferSize)
+	routed := make(chan struct{})
+	go func() {
+		router.Run(events)
+		close(routed)
+	}()
+	// Deliver everything still queued before returning
+	defer func() {
+		a.Close()
+		<-routed
+	}()
+
+	for i, c := range cycles {
+		if speed > 0 && i > 0 {
+			time.Sleep(time.Duration(float64(c.Time.Sub(cycles[i-1].Time)) / speed))
+		}
+		for name, value := range c.Metrics {
+			hist.AddDataPoint(name, value, c.Time)
+		}
+		a.CheckAndNotify(c.Time, c.Metrics)
+	}
+	return nil
+}
+
+// Evaluate replays cycles against the configured alert rules and returns the
+// notifications that would have been sent, without delivering anything.
+func Evaluate(cfg *config.Config, cycles []Cycle) ([]Notification, error) {
+	recorder := &Recorder{}
+	notifiers := make(map[string]notifier.Notifier)
+	for _, rule := range cfg.Alerts {
+		for _, channel := range rule.Channels {
+			notifiers[channel] = recorder.Channel(channel)
+		}
+	}
+
+	if err := Run(cfg, cycles, notifiers, 0); err != nil {
+		return nil, err
+	}
+	return recorder.Notifications(), nil
+}
+
+// Span returns the first and last timestamps covered by cycles.
+func Span(cycles []Cycle) (time.Time, time.Time) {
+	if len(cycles) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	return cycles[0].Time, cycles[len(cycles)-1].Time
+}
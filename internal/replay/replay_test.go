@@ -0,0 +1,146 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func TestLoadSamplesCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.csv")
+	require.NoError(t, os.WriteFile(path, []byte(`timestamp,metric,value
+# comment lines are ignored
+2024-01-01T12:00:00Z,cpu_percent_total,50
+1704110410,cpu_percent_total,60.5
+`), 0644))
+
+	samples, err := LoadSamples(path)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), samples[0].Timestamp.UTC())
+	assert.Equal(t, "cpu_percent_total", samples[1].Metric)
+	assert.Equal(t, 60.5, samples[1].Value)
+	assert.Equal(t, int64(1704110410), samples[1].Timestamp.Unix())
+}
+
+func TestLoadSamplesJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+  {"timestamp": "2024-01-01T12:00:00Z", "metric": "mem_percent_used", "value": 91},
+  {"timestamp": 1704110410, "metric": "mem_percent_used", "value": 93}
+]`), 0644))
+
+	samples, err := LoadSamples(path)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, 93.0, samples[1].Value)
+}
+
+func TestLoadSamplesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	badTimestamp := filepath.Join(dir, "bad.csv")
+	require.NoError(t, os.WriteFile(badTimestamp, []byte("yesterday,cpu_percent_total,1\n"), 0644))
+	_, err := LoadSamples(badTimestamp)
+	assert.Error(t, err)
+
+	badValue := filepath.Join(dir, "bad2.csv")
+	require.NoError(t, os.WriteFile(badValue, []byte("1704110410,cpu_percent_total,high\n"), 0644))
+	_, err = LoadSamples(badValue)
+	assert.Error(t, err)
+
+	_, err = LoadSamples(filepath.Join(dir, "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestGroupCyclesAndInferInterval(t *testing.T) {
+	base := time.Unix(1704110400, 0)
+	samples := []Sample{
+		{Timestamp: base.Add(20 * time.Second), Metric: "a", Value: 3},
+		{Timestamp: base, Metric: "a", Value: 1},
+		{Timestamp: base, Metric: "b", Value: 2},
+		{Timestamp: base.Add(10 * time.Second), Metric: "a", Value: 2},
+	}
+
+	cycles := GroupCycles(samples)
+	require.Len(t, cycles, 3)
+	assert.Equal(t, base, cycles[0].Time)
+	assert.Len(t, cycles[0].Metrics, 2)
+	assert.Equal(t, 3.0, cycles[2].Metrics["a"])
+	assert.Equal(t, 10*time.Second, InferInterval(cycles))
+	assert.Equal(t, time.Duration(0), InferInterval(cycles[:1]))
+}
+
+func TestEvaluate(t *testing.T) {
+	cfg := &config.Config{
+		CollectionInterval: 10 * time.Second,
+		EffectiveHostname:  "replay-host",
+		Alerts: []config.AlertRuleConfig{
+			{
+				Name:        "High CPU",
+				Metric:      "cpu_percent_total",
+				Condition:   ">",
+				Threshold:   90,
+				DurationStr: "30s",
+				Duration:    30 * time.Second,
+				Aggregation: "average",
+				Channels:    []string{"ops"},
+			},
+		},
+		Templates: config.TemplateConfig{
+			AlertFired:    "FIRED {{ .AlertName }} {{ .FormattedMetricValue }}",
+			AlertResolved: "RESOLVED {{ .AlertName }}",
+		},
+	}
+
+	base := time.Unix(1704110400, 0)
+	var samples []Sample
+	for i := 0; i < 20; i++ {
+		value := 20.0
+		if i >= 5 && i < 12 {
+			value = 95
+		}
+		samples = append(samples, Sample{Timestamp: base.Add(time.Duration(i) * 10 * time.Second), Metric: "cpu_percent_total", Value: value})
+	}
+
+	notifications, err := Evaluate(cfg, GroupCycles(samples))
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+
+	assert.Equal(t, "FIRED", notifications[0].Data.State)
+	assert.Equal(t, "ops", notifications[0].Channel)
+	assert.Equal(t, base.Add(80*time.Second), notifications[0].Data.Time)
+	assert.Equal(t, "FIRED High CPU 95.0%", notifications[0].Message)
+
+	assert.Equal(t, "RESOLVED", notifications[1].Data.State)
+	assert.Equal(t, "RESOLVED High CPU", notifications[1].Message)
+}
+
+func TestTraceRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	tw, err := NewTraceWriter(path)
+	require.NoError(t, err)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, tw.Write(base, map[string]float64{"cpu_percent_total": 12.5}))
+	require.NoError(t, tw.Write(base.Add(time.Second), map[string]float64{"cpu_percent_total": 99}))
+	require.NoError(t, tw.Close())
+
+	cycles, err := LoadCycles(path)
+	require.NoError(t, err)
+	require.Len(t, cycles, 2)
+	assert.True(t, base.Equal(cycles[0].Time))
+	assert.Equal(t, 99.0, cycles[1].Metrics["cpu_percent_total"])
+}
+
+func TestLoadTraceInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{not json}\n"), 0644))
+	_, err := LoadTrace(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/collector"
+)
+
+// Sample is a single timestamped metric value.
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Metric    string    `json:"metric"`
+	Value     float64   `json:"value"`
+}
+
+// Cycle groups all samples sharing a timestamp, like one collection cycle.
+type Cycle struct {
+	Time    time.Time                  `json:"time"`
+	Metrics collector.CollectedMetrics `json:"metrics"`
+}
+
+// LoadSamples reads samples from a CSV or JSON file, chosen by extension.
+//
+// CSV files have the columns timestamp,metric,value (a header row is optional).
+// JSON files contain an array of {"timestamp", "metric", "value"} objects.
+// Timestamps are RFC 3339 strings or Unix seconds.
+func LoadSamples(path string) ([]Sample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open samples file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return parseJSONSamples(file)
+	}
+	return parseCSVSamples(file)
+}
+
+func parseCSVSamples(r io.Reader) ([]Sample, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var samples []Sample
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV samples: %w", err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "timestamp") {
+			continue // Header
+		}
+
+		ts, err := parseTimestamp(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("CSV line %d: %w", line, err)
+		}
+		value, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("CSV line %d: invalid value '%s': %w", line, record[2], err)
+		}
+		samples = append(samples, Sample{Timestamp: ts, Metric: record[1], Value: value})
+	}
+	return samples, nil
+}
+
+func parseJSONSamples(r io.Reader) ([]Sample, error) {
+	var raw []struct {
+		Timestamp json.RawMessage `json:"timestamp"`
+		Metric    string          `json:"metric"`
+		Value     float64         `json:"value"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON samples: %w", err)
+	}
+
+	samples := make([]Sample, 0, len(raw))
+	for i, s := range raw {
+		ts, err := parseTimestamp(strings.Trim(string(s.Timestamp), `"`))
+		if err != nil {
+			return nil, fmt.Errorf("JSON sample %d: %w", i, err)
+		}
+		if s.Metric == "" {
+			return nil, fmt.Errorf("JSON sample %d: missing metric", i)
+		}
+		samples = append(samples, Sample{Timestamp: ts, Metric: s.Metric, Value: s.Value})
+	}
+	return samples, nil
+}
+
+// parseTimestamp accepts RFC 3339 strings and Unix seconds (optionally fractional).
+func parseTimestamp(s string) (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339, s); err == nil {
+		return ts, nil
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(0, int64(secs*float64(time.Second))), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp '%s': use RFC 3339 or Unix seconds", s)
+}
+
+// GroupCycles groups samples by timestamp into chronologically ordered cycles.
+func GroupCycles(samples []Sample) []Cycle {
+	byTime := make(map[int64]*Cycle)
+	for _, s := range samples {
+		key := s.Timestamp.UnixNano()
+		c, ok := byTime[key]
+		if !ok {
+			c = &Cycle{Time: s.Timestamp, Metrics: make(collector.CollectedMetrics)}
+			byTime[key] = c
+		}
+		c.Metrics[s.Metric] = s.Value
+	}
+
+	cycles := make([]Cycle, 0, len(byTime))
+	for _, c := range byTime {
+		cycles = append(cycles, *c)
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Time.Before(cycles[j].Time) })
+	return cycles
+}
+
+// InferInterval returns the median spacing between cycles, or 0 if there are
+// fewer than two cycles.
+func InferInterval(cycles []Cycle) time.Duration {
+	if len(cycles) < 2 {
+		return 0
+	}
+	gaps := make([]time.Duration, 0, len(cycles)-1)
+	for i := 1; i < len(cycles); i++ {
+		gaps = append(gaps, cycles[i].Time.Sub(cycles[i-1].Time))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
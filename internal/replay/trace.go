@@ -0,0 +1,87 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/collector"
+)
+
+// TraceWriter appends collection cycles to a trace file, one JSON object per line.
+type TraceWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewTraceWriter opens (or creates) a trace file for appending.
+func NewTraceWriter(path string) (*TraceWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file %s: %w", path, err)
+	}
+	return &TraceWriter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Write appends one collection cycle to the trace.
+func (tw *TraceWriter) Write(t time.Time, metrics collector.CollectedMetrics) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if err := tw.enc.Encode(Cycle{Time: t, Metrics: metrics}); err != nil {
+		return fmt.Errorf("failed to write trace cycle: %w", err)
+	}
+	return nil
+}
+
+func (tw *TraceWriter) Close() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.file.Close()
+}
+
+// LoadTrace reads a trace file written by TraceWriter.
+func LoadTrace(path string) ([]Cycle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var cycles []Cycle
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Cycles with many per-device metrics can be long
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var c Cycle
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+		cycles = append(cycles, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trace file %s: %w", path, err)
+	}
+	return cycles, nil
+}
+
+// LoadCycles reads cycles from a trace file (.jsonl or .trace) or from a
+// CSV/JSON samples file.
+func LoadCycles(path string) ([]Cycle, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".trace":
+		return LoadTrace(path)
+	}
+	samples, err := LoadSamples(path)
+	if err != nil {
+		return nil, err
+	}
+	return GroupCycles(samples), nil
+}
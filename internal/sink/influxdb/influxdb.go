@@ -0,0 +1,136 @@
+// Package influxdb implements the "influxdb" sink type, writing metrics to
+// an InfluxDB v2 bucket in line protocol.
+package influxdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/outbound"
+	"github.com/mattmezza/monres/internal/sink"
+)
+
+func init() {
+	sink.Register(config.SinkInfluxDB, func(cfg config.SinkConfig) (sink.Sink, error) {
+		return New(cfg)
+	})
+}
+
+type Sink struct {
+	name        string
+	writeURL    string
+	token       string
+	measurement string
+	host        string
+	client      *http.Client
+}
+
+// New creates a sink writing to the /api/v2/write endpoint of cfg.URL.
+func New(cfg config.SinkConfig) (*Sink, error) {
+	base, err := url.Parse(cfg.URL)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("influxdb sink '%s' has invalid url '%s'", cfg.Name, cfg.URL)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/api/v2/write"
+	base.RawQuery = url.Values{"org": {cfg.Org}, "bucket": {cfg.Bucket}, "precision": {"s"}}.Encode()
+
+	client, err := outbound.NewHTTPClient(outbound.Options{
+		Proxy:         cfg.Proxy,
+		TLS:           cfg.TLSOptions(),
+		AddressFamily: cfg.AddressFamily,
+		Resolver:      cfg.DNSResolver,
+	}, cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("influxdb sink '%s': %w", cfg.Name, err)
+	}
+	return &Sink{
+		name:        cfg.Name,
+		writeURL:    base.String(),
+		token:       cfg.Token,
+		measurement: cfg.Measurement,
+		host:        cfg.Host,
+		client:      client,
+	}, nil
+}
+
+func (s *Sink) Name() string {
+	return s.name
+}
+
+// Write sends a batch as a single point with one field per metric.
+func (s *Sink) Write(batch sink.Batch) error {
+	line := s.line(batch)
+	if line == nil {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPost, s.writeURL, bytes.NewReader(line))
+	if err != nil {
+		return fmt.Errorf("failed to create InfluxDB request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("InfluxDB write failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// line encodes a batch in line protocol:
+//
+//	monres,host=web1 cpu_percent_total=12.5,mem_percent_used=40 1700000000
+//
+// It returns nil if the batch has no finite values.
+func (s *Sink) line(batch sink.Batch) []byte {
+	var b bytes.Buffer
+	b.WriteString(escape(s.measurement, ", "))
+	b.WriteString(",host=")
+	b.WriteString(escape(s.host, ",= "))
+	sep := byte(' ')
+	for _, name := range batch.SortedNames() {
+		value := batch.Metrics[name]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue // Not representable in line protocol
+		}
+		b.WriteByte(sep)
+		b.WriteString(escape(name, ",= "))
+		b.WriteByte('=')
+		b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+		sep = ','
+	}
+	if sep == ' ' {
+		return nil
+	}
+	fmt.Fprintf(&b, " %d\n", batch.Time.Unix())
+	return b.Bytes()
+}
+
+// escape backslash-escapes the given special characters.
+func escape(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
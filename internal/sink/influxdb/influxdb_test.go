@@ -0,0 +1,55 @@
+package influxdb
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/sink"
+)
+
+func TestWrite(t *testing.T) {
+	var gotQuery, gotAuth, gotBody string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/influx/api/v2/write", r.URL.Path)
+		gotQuery = r.URL.RawQuery
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"code":"unauthorized"}`))
+	}))
+	defer server.Close()
+
+	s, err := New(config.SinkConfig{
+		Name:        "influx",
+		URL:         server.URL + "/influx/",
+		Org:         "ops",
+		Bucket:      "hosts",
+		Token:       "secret",
+		Measurement: "monres",
+		Host:        "web 1",
+		Timeout:     5 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "influx", s.Name())
+
+	batch := sink.Batch{Time: time.Unix(1700000000, 0), Metrics: map[string]float64{"mem_percent_used": 40, "cpu_percent_total": 12.5}}
+	require.NoError(t, s.Write(batch))
+	assert.Equal(t, "bucket=hosts&org=ops&precision=s", gotQuery)
+	assert.Equal(t, "Token secret", gotAuth)
+	assert.Equal(t, "monres,host=web\\ 1 cpu_percent_total=12.5,mem_percent_used=40 1700000000\n", gotBody)
+
+	status = http.StatusUnauthorized
+	assert.ErrorContains(t, s.Write(batch), "status 401")
+
+	_, err = New(config.SinkConfig{Name: "bad", URL: "not a url"})
+	assert.Error(t, err)
+}
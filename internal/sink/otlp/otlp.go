@@ -0,0 +1,209 @@
+// Package otlp implements the "otlp" sink type, exporting metrics to an
+// OpenTelemetry collector over OTLP/HTTP with JSON encoding. OTLP/gRPC is not
+// supported.
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattmezza/monres/internal/buildinfo"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+	"github.com/mattmezza/monres/internal/outbound"
+	"github.com/mattmezza/monres/internal/sink"
+)
+
+func init() {
+	sink.Register(config.SinkOTLP, func(cfg config.SinkConfig) (sink.Sink, error) {
+		return New(cfg)
+	})
+}
+
+// metricsPath is the OTLP/HTTP path for metrics.
+const metricsPath = "/v1/metrics"
+
+// aggregationTemporalityCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const aggregationTemporalityCumulative = 2
+
+type Sink struct {
+	name      string
+	endpoint  string
+	headers   map[string]string
+	resource  []attribute
+	startTime time.Time // Start of the cumulative counters
+	client    *http.Client
+}
+
+// New creates a sink posting to the /v1/metrics endpoint of cfg.URL.
+func New(cfg config.SinkConfig) (*Sink, error) {
+	endpoint, err := url.Parse(cfg.URL)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("otlp sink '%s' has invalid url '%s'", cfg.Name, cfg.URL)
+	}
+	if !strings.HasSuffix(endpoint.Path, metricsPath) {
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + metricsPath
+	}
+
+	client, err := outbound.NewHTTPClient(outbound.Options{
+		Proxy:         cfg.Proxy,
+		TLS:           cfg.TLSOptions(),
+		AddressFamily: cfg.AddressFamily,
+		Resolver:      cfg.DNSResolver,
+	}, cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("otlp sink '%s': %w", cfg.Name, err)
+	}
+
+	resourceAttrs := map[string]string{
+		"host.name":       cfg.Host,
+		"service.name":    "monres",
+		"service.version": buildinfo.GetVersion(),
+	}
+	for k, v := range cfg.ResourceAttributes {
+		resourceAttrs[k] = v
+	}
+	return &Sink{
+		name:      cfg.Name,
+		endpoint:  endpoint.String(),
+		headers:   cfg.Headers,
+		resource:  attributes(resourceAttrs),
+		startTime: time.Now(),
+		client:    client,
+	}, nil
+}
+
+func (s *Sink) Name() string {
+	return s.name
+}
+
+// Subset of the OTLP JSON encoding (opentelemetry/proto/metrics/v1).
+type (
+	exportRequest struct {
+		ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+	}
+	resourceMetrics struct {
+		Resource     resource       `json:"resource"`
+		ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+	}
+	resource struct {
+		Attributes []attribute `json:"attributes"`
+	}
+	scopeMetrics struct {
+		Scope   scope    `json:"scope"`
+		Metrics []metric `json:"metrics"`
+	}
+	scope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	attribute struct {
+		Key   string         `json:"key"`
+		Value attributeValue `json:"value"`
+	}
+	attributeValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	metric struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Unit        string `json:"unit,omitempty"`
+		Gauge       *gauge `json:"gauge,omitempty"`
+		Sum         *sum   `json:"sum,omitempty"`
+	}
+	gauge struct {
+		DataPoints []dataPoint `json:"dataPoints"`
+	}
+	sum struct {
+		DataPoints             []dataPoint `json:"dataPoints"`
+		AggregationTemporality int         `json:"aggregationTemporality"`
+		IsMonotonic            bool        `json:"isMonotonic"`
+	}
+	dataPoint struct {
+		StartTimeUnixNano string  `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string  `json:"timeUnixNano"`
+		AsDouble          float64 `json:"asDouble"`
+	}
+)
+
+func attributes(m map[string]string) []attribute {
+	attrs := make([]attribute, 0, len(m))
+	for k, v := range m {
+		attrs = append(attrs, attribute{Key: k, Value: attributeValue{StringValue: v}})
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
+
+// ucumUnits maps monres units to the UCUM units used by OpenTelemetry.
+var ucumUnits = map[metrics.Unit]string{
+	metrics.UnitPercent:        "%",
+	metrics.UnitBytes:          "By",
+	metrics.UnitBytesPerSecond: "By/s",
+	metrics.UnitSeconds:        "s",
+	metrics.UnitCelsius:        "Cel",
+}
+
+// Write exports a batch with one data point per metric. Counters are
+// exported as cumulative monotonic sums, everything else as gauges.
+func (s *Sink) Write(batch sink.Batch) error {
+	body, err := json.Marshal(s.request(batch))
+	if err != nil {
+		return fmt.Errorf("failed to encode OTLP request: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export metrics over OTLP: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("OTLP export failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+func (s *Sink) request(batch sink.Batch) exportRequest {
+	ts := strconv.FormatInt(batch.Time.UnixNano(), 10)
+	var ms []metric
+	for _, name := range batch.SortedNames() {
+		value := batch.Metrics[name]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		md, _ := metrics.Lookup(name)
+		m := metric{Name: name, Description: md.Description, Unit: ucumUnits[md.Unit]}
+		if md.Type == metrics.TypeCounter {
+			m.Sum = &sum{
+				DataPoints:             []dataPoint{{StartTimeUnixNano: strconv.FormatInt(s.startTime.UnixNano(), 10), TimeUnixNano: ts, AsDouble: value}},
+				AggregationTemporality: aggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			}
+		} else {
+			m.Gauge = &gauge{DataPoints: []dataPoint{{TimeUnixNano: ts, AsDouble: value}}}
+		}
+		ms = append(ms, m)
+	}
+	return exportRequest{ResourceMetrics: []resourceMetrics{{
+		Resource:     resource{Attributes: s.resource},
+		ScopeMetrics: []scopeMetrics{{Scope: scope{Name: "monres", Version: buildinfo.GetVersion()}, Metrics: ms}},
+	}}}
+}
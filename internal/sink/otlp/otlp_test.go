@@ -0,0 +1,78 @@
+package otlp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+	"github.com/mattmezza/monres/internal/sink"
+)
+
+func TestWrite(t *testing.T) {
+	metrics.Register(metrics.Metadata{Name: "check_web_requests", Type: metrics.TypeCounter})
+
+	var got exportRequest
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		gotHeader = r.Header.Get("X-Api-Key")
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &got))
+	}))
+	defer server.Close()
+
+	s, err := New(config.SinkConfig{
+		Name:               "otel",
+		URL:                server.URL,
+		Host:               "web1",
+		Headers:            map[string]string{"X-Api-Key": "secret"},
+		ResourceAttributes: map[string]string{"deployment.environment": "prod"},
+		Timeout:            5 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "otel", s.Name())
+
+	now := time.Unix(1700000000, 0)
+	require.NoError(t, s.Write(sink.Batch{Time: now, Metrics: map[string]float64{"cpu_percent_total": 12.5, "check_web_requests": 42}}))
+	assert.Equal(t, "secret", gotHeader)
+
+	require.Len(t, got.ResourceMetrics, 1)
+	attrs := make(map[string]string)
+	for _, a := range got.ResourceMetrics[0].Resource.Attributes {
+		attrs[a.Key] = a.Value.StringValue
+	}
+	assert.Equal(t, "web1", attrs["host.name"])
+	assert.Equal(t, "monres", attrs["service.name"])
+	assert.Equal(t, "prod", attrs["deployment.environment"])
+
+	ms := got.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, ms, 2)
+	assert.Equal(t, "check_web_requests", ms[0].Name)
+	require.NotNil(t, ms[0].Sum)
+	assert.True(t, ms[0].Sum.IsMonotonic)
+	assert.Equal(t, 42.0, ms[0].Sum.DataPoints[0].AsDouble)
+	assert.Equal(t, "cpu_percent_total", ms[1].Name)
+	assert.Equal(t, "%", ms[1].Unit)
+	require.NotNil(t, ms[1].Gauge)
+	assert.Equal(t, "1700000000000000000", ms[1].Gauge.DataPoints[0].TimeUnixNano)
+}
+
+func TestWriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	s, err := New(config.SinkConfig{Name: "otel", URL: server.URL + "/v1/metrics", Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	assert.ErrorContains(t, s.Write(sink.Batch{Time: time.Now(), Metrics: map[string]float64{"a": 1}}), "status 400")
+}
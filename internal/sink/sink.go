@@ -0,0 +1,170 @@
+// Package sink forwards the metrics of every collection cycle to external
+// systems (InfluxDB, Zabbix, ...), so existing dashboards can ingest monres
+// metrics without a second agent. Each sink type lives in its own
+// sub-package and registers itself like the notification channel types.
+package sink
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Batch is the metrics of one collection cycle.
+type Batch struct {
+	Time    time.Time
+	Metrics map[string]float64
+}
+
+// Sink writes batches to an external system.
+type Sink interface {
+	Write(batch Batch) error
+	Name() string // Returns the configured sink name
+}
+
+// Factory creates a sink from its configuration.
+type Factory func(cfg config.SinkConfig) (Sink, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a sink type available to New. Sink packages call it from
+// init, so a type is only available when its package is imported (see the
+// build-tagged imports in cmd/monres).
+// It panics if the type is registered twice.
+func Register(sinkType string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, exists := factories[sinkType]; exists {
+		panic(fmt.Sprintf("sink: type %q registered twice", sinkType))
+	}
+	factories[sinkType] = factory
+}
+
+// RegisteredTypes returns the available sink types, sorted.
+func RegisteredTypes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	types := make([]string, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// queueSize is how many batches may wait for a slow sink before new ones are dropped.
+const queueSize = 8
+
+// worker delivers batches to a sink from its own goroutine.
+type worker struct {
+	sink    Sink
+	cfg     config.SinkConfig
+	queue   chan Batch
+	failing bool // Last write failed, to log transitions only
+}
+
+// Dispatcher hands every cycle's metrics to the configured sinks without
+// blocking the collection loop.
+type Dispatcher struct {
+	workers []*worker
+	wg      sync.WaitGroup
+}
+
+// New creates the configured sinks using the factories registered for their
+// types and starts delivering to them. Sinks that fail to initialize, or
+// whose type was not compiled in, are logged and skipped.
+func New(sinks []config.SinkConfig) *Dispatcher {
+	d := &Dispatcher{}
+	for _, sc := range sinks {
+		factoriesMu.RLock()
+		factory, ok := factories[sc.Type]
+		factoriesMu.RUnlock()
+		if !ok {
+			log.Printf("Unsupported sink type '%s' for sink '%s' (supported: %s). Skipping.", sc.Type, sc.Name, strings.Join(RegisteredTypes(), ", "))
+			continue
+		}
+		s, err := factory(sc)
+		if err != nil {
+			log.Printf("Failed to initialize sink '%s' (%s): %v. Skipping.", sc.Name, sc.Type, err)
+			continue
+		}
+		d.add(s, sc)
+		log.Printf("Successfully initialized sink: %s (type: %s)", sc.Name, sc.Type)
+	}
+	return d
+}
+
+func (d *Dispatcher) add(s Sink, cfg config.SinkConfig) {
+	w := &worker{sink: s, cfg: cfg, queue: make(chan Batch, queueSize)}
+	d.workers = append(d.workers, w)
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for batch := range w.queue {
+			w.write(batch)
+		}
+	}()
+}
+
+func (w *worker) write(batch Batch) {
+	err := w.sink.Write(batch)
+	switch {
+	case err != nil && !w.failing:
+		log.Printf("Error writing metrics to sink '%s': %v", w.sink.Name(), err)
+	case err == nil && w.failing:
+		log.Printf("Sink '%s' recovered.", w.sink.Name())
+	}
+	w.failing = err != nil
+}
+
+// Len returns the number of active sinks.
+func (d *Dispatcher) Len() int {
+	return len(d.workers)
+}
+
+// Push queues the metrics of a cycle for every sink, filtered by the sink's
+// metrics setting. A batch is dropped for a sink whose queue is full.
+func (d *Dispatcher) Push(t time.Time, metrics map[string]float64) {
+	for _, w := range d.workers {
+		batch := Batch{Time: t, Metrics: make(map[string]float64, len(metrics))}
+		for name, value := range metrics {
+			if w.cfg.SendsMetric(name) {
+				batch.Metrics[name] = value
+			}
+		}
+		if len(batch.Metrics) == 0 {
+			continue
+		}
+		select {
+		case w.queue <- batch:
+		default:
+			log.Printf("Warning: Sink '%s' is falling behind, dropping metrics of %s.", w.sink.Name(), t.Format(time.RFC3339))
+		}
+	}
+}
+
+// Close delivers the queued batches and stops the sinks.
+func (d *Dispatcher) Close() {
+	for _, w := range d.workers {
+		close(w.queue)
+	}
+	d.wg.Wait()
+}
+
+// SortedNames returns the metric names of a batch in a stable order.
+func (b Batch) SortedNames() []string {
+	names := make([]string, 0, len(b.Metrics))
+	for name := range b.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	batches []Batch
+}
+
+func (r *recordingSink) Name() string { return "recording" }
+
+func (r *recordingSink) Write(batch Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, batch)
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	all, filtered := &recordingSink{}, &recordingSink{}
+	d := New(nil)
+	d.add(all, config.SinkConfig{Name: "all"})
+	d.add(filtered, config.SinkConfig{Name: "filtered", Metrics: []string{"cpu_percent_total", "check_*"}})
+	assert.Equal(t, 2, d.Len())
+
+	now := time.Unix(1700000000, 0)
+	d.Push(now, map[string]float64{"cpu_percent_total": 12.5, "mem_percent_used": 40, "check_http_status": 0})
+	d.Push(now.Add(time.Second), map[string]float64{"mem_percent_used": 41}) // Nothing left for the filtered sink
+	d.Close()
+
+	require.Len(t, all.batches, 2)
+	assert.Equal(t, now, all.batches[0].Time)
+	assert.Len(t, all.batches[0].Metrics, 3)
+	require.Len(t, filtered.batches, 1)
+	assert.Equal(t, []string{"check_http_status", "cpu_percent_total"}, filtered.batches[0].SortedNames())
+}
+
+func TestNewSkipsUnknownTypes(t *testing.T) {
+	d := New([]config.SinkConfig{{Name: "x", Type: "graphite"}})
+	assert.Equal(t, 0, d.Len())
+	d.Close()
+}
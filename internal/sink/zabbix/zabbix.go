@@ -0,0 +1,171 @@
+// Package zabbix implements the "zabbix" sink type, sending metrics to
+// Zabbix trapper items with the Zabbix sender protocol.
+package zabbix
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/outbound"
+	"github.com/mattmezza/monres/internal/sink"
+)
+
+func init() {
+	sink.Register(config.SinkZabbix, func(cfg config.SinkConfig) (sink.Sink, error) {
+		return New(cfg)
+	})
+}
+
+// DefaultPort is the trapper port of Zabbix servers and proxies.
+const DefaultPort = "10051"
+
+// header starts every message of the Zabbix protocol, followed by the
+// little-endian data length and 4 reserved bytes.
+var header = []byte("ZBXD\x01")
+
+type Sink struct {
+	name      string
+	addr      string
+	host      string
+	keyPrefix string
+	timeout   time.Duration
+	dialer    *outbound.Dialer
+}
+
+// New creates a sink sending to the Zabbix server or proxy at cfg.Server.
+func New(cfg config.SinkConfig) (*Sink, error) {
+	addr := cfg.Server
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, DefaultPort)
+	}
+	dialer, err := outbound.NewDialer(outbound.Options{AddressFamily: cfg.AddressFamily, Resolver: cfg.DNSResolver})
+	if err != nil {
+		return nil, fmt.Errorf("zabbix sink '%s': %w", cfg.Name, err)
+	}
+	return &Sink{
+		name:      cfg.Name,
+		addr:      addr,
+		host:      cfg.Host,
+		keyPrefix: cfg.KeyPrefix,
+		timeout:   cfg.Timeout,
+		dialer:    dialer,
+	}, nil
+}
+
+func (s *Sink) Name() string {
+	return s.name
+}
+
+type item struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock"`
+}
+
+type request struct {
+	Request string `json:"request"`
+	Data    []item `json:"data"`
+	Clock   int64  `json:"clock"`
+}
+
+type response struct {
+	Response string `json:"response"`
+	Info     string `json:"info"`
+}
+
+// infoPattern extracts the counters of a response's info, e.g.
+// "processed: 2; failed: 1; total: 3; seconds spent: 0.000055".
+var infoPattern = regexp.MustCompile(`failed: (\d+); total: (\d+)`)
+
+// Write sends a batch as one "sender data" request, one item per metric.
+func (s *Sink) Write(batch sink.Batch) error {
+	req := request{Request: "sender data", Clock: batch.Time.Unix()}
+	for _, name := range batch.SortedNames() {
+		value := batch.Metrics[name]
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		req.Data = append(req.Data, item{
+			Host:  s.host,
+			Key:   s.keyPrefix + name,
+			Value: strconv.FormatFloat(value, 'f', -1, 64),
+			Clock: batch.Time.Unix(),
+		})
+	}
+	if len(req.Data) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode Zabbix request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	conn, err := s.dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Zabbix server %s: %w", s.addr, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	if _, err := conn.Write(frame(payload)); err != nil {
+		return fmt.Errorf("failed to send to Zabbix server %s: %w", s.addr, err)
+	}
+	body, err := readFrame(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read Zabbix response: %w", err)
+	}
+	var resp response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid Zabbix response: %w", err)
+	}
+	if resp.Response != "success" {
+		return fmt.Errorf("zabbix server rejected the data: %s %s", resp.Response, resp.Info)
+	}
+	if m := infoPattern.FindStringSubmatch(resp.Info); m != nil && m[1] != "0" {
+		return fmt.Errorf("zabbix server failed %s of %s values (are trapper items with keys '%s<metric>' defined for host '%s'?)", m[1], m[2], s.keyPrefix, s.host)
+	}
+	return nil
+}
+
+// frame prepends the protocol header to a payload.
+func frame(payload []byte) []byte {
+	msg := make([]byte, 0, len(header)+8+len(payload))
+	msg = append(msg, header...)
+	msg = binary.LittleEndian.AppendUint32(msg, uint32(len(payload)))
+	msg = binary.LittleEndian.AppendUint32(msg, 0) // Reserved
+	return append(msg, payload...)
+}
+
+// maxResponse bounds the size of a response the sink is willing to read.
+const maxResponse = 1 << 20
+
+// readFrame reads a message written by frame.
+func readFrame(r io.Reader) ([]byte, error) {
+	head := make([]byte, len(header)+8)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(head[:len(header)], header) {
+		return nil, fmt.Errorf("unexpected header %q", head[:len(header)])
+	}
+	size := binary.LittleEndian.Uint32(head[len(header):])
+	if size > maxResponse {
+		return nil, fmt.Errorf("response too large (%d bytes)", size)
+	}
+	body := make([]byte, size)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
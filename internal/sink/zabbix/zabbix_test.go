@@ -0,0 +1,74 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/sink"
+)
+
+// fakeServer answers every sender request with info, passing the decoded requests to received.
+func fakeServer(t *testing.T, info string, received chan<- request) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			body, err := readFrame(conn)
+			if err == nil {
+				var req request
+				json.Unmarshal(body, &req)
+				received <- req
+				resp, _ := json.Marshal(response{Response: "success", Info: info})
+				conn.Write(frame(resp))
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestWrite(t *testing.T) {
+	received := make(chan request, 2)
+	addr := fakeServer(t, "processed: 2; failed: 0; total: 2; seconds spent: 0.000055", received)
+
+	s, err := New(config.SinkConfig{Name: "zbx", Server: addr, Host: "web1", KeyPrefix: "monres.", Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "zbx", s.Name())
+
+	batch := sink.Batch{Time: time.Unix(1700000000, 0), Metrics: map[string]float64{"mem_percent_used": 40, "cpu_percent_total": 12.5}}
+	require.NoError(t, s.Write(batch))
+	req := <-received
+	assert.Equal(t, "sender data", req.Request)
+	assert.Equal(t, []item{
+		{Host: "web1", Key: "monres.cpu_percent_total", Value: "12.5", Clock: 1700000000},
+		{Host: "web1", Key: "monres.mem_percent_used", Value: "40", Clock: 1700000000},
+	}, req.Data)
+}
+
+func TestWriteFailedItems(t *testing.T) {
+	received := make(chan request, 1)
+	addr := fakeServer(t, "processed: 1; failed: 1; total: 2; seconds spent: 0.000055", received)
+
+	s, err := New(config.SinkConfig{Name: "zbx", Server: addr, Host: "web1", KeyPrefix: "monres.", Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	err = s.Write(sink.Batch{Time: time.Now(), Metrics: map[string]float64{"a": 1, "b": 2}})
+	assert.ErrorContains(t, err, "failed 1 of 2 values")
+}
+
+func TestNewDefaultPort(t *testing.T) {
+	s, err := New(config.SinkConfig{Name: "zbx", Server: "zabbix.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "zabbix.example.com:10051", s.addr)
+}
@@ -0,0 +1,116 @@
+// Package transform applies the per-metric value transforms of the
+// configuration (scale, offset, EWMA smoothing, clamp) after collection.
+package transform
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+// Transformer applies configured transforms to every collection cycle.
+// It implements collector.Processor.
+type Transformer struct {
+	exact    map[string]*config.TransformConfig
+	prefixes map[string]*config.TransformConfig
+
+	mu     sync.Mutex
+	series map[string]*series // Per metric name; nil entries cache "no transform"
+}
+
+// series holds the smoothing state of one metric.
+type series struct {
+	tc       *config.TransformConfig
+	smoothed float64
+	last     time.Time
+}
+
+// New returns a Transformer for the metrics entries that declare a transform,
+// or nil if none does. Names ending in "*" apply to every metric with that prefix.
+func New(metricConfigs []config.MetricConfig) *Transformer {
+	t := &Transformer{
+		exact:    make(map[string]*config.TransformConfig),
+		prefixes: make(map[string]*config.TransformConfig),
+		series:   make(map[string]*series),
+	}
+	for _, mc := range metricConfigs {
+		if mc.Transform == nil {
+			continue
+		}
+		if strings.HasSuffix(mc.Name, "*") {
+			t.prefixes[strings.TrimSuffix(mc.Name, "*")] = mc.Transform
+		} else {
+			t.exact[mc.Name] = mc.Transform
+		}
+	}
+	if len(t.exact) == 0 && len(t.prefixes) == 0 {
+		return nil
+	}
+	return t
+}
+
+// Process transforms the values in m in place.
+func (t *Transformer) Process(now time.Time, m collector.CollectedMetrics) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name, value := range m {
+		s, seen := t.series[name]
+		if !seen {
+			if tc := t.lookup(name); tc != nil {
+				s = &series{tc: tc}
+			}
+			t.series[name] = s
+		}
+		if s != nil {
+			m[name] = s.apply(value, now)
+		}
+	}
+}
+
+// lookup finds the transform for a metric: exact names first, then the longest prefix.
+func (t *Transformer) lookup(name string) *config.TransformConfig {
+	if tc, ok := t.exact[name]; ok {
+		return tc
+	}
+	var best *config.TransformConfig
+	bestLen := -1
+	for prefix, tc := range t.prefixes {
+		if strings.HasPrefix(name, prefix) && len(prefix) > bestLen {
+			best, bestLen = tc, len(prefix)
+		}
+	}
+	return best
+}
+
+func (s *series) apply(value float64, now time.Time) float64 {
+	tc := s.tc
+	if tc.Scale != nil {
+		value *= *tc.Scale
+	}
+	value += tc.Offset
+
+	if tc.EWMA > 0 {
+		if s.last.IsZero() {
+			s.smoothed = value
+		} else if dt := now.Sub(s.last); dt > 0 {
+			// Time-based weight, so irregular intervals smooth consistently
+			alpha := 1 - math.Exp(-dt.Seconds()/tc.EWMA.Seconds())
+			s.smoothed += alpha * (value - s.smoothed)
+		}
+		s.last = now
+		value = s.smoothed
+	}
+
+	if tc.Min != nil && value < *tc.Min {
+		value = *tc.Min
+	}
+	if tc.Max != nil && value > *tc.Max {
+		value = *tc.Max
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package transform
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestNewWithoutTransforms(t *testing.T) {
+	assert.Nil(t, New([]config.MetricConfig{{Name: "cpu_percent_total", Unit: "percent"}}))
+}
+
+func TestScaleOffsetClamp(t *testing.T) {
+	tr := New([]config.MetricConfig{
+		{Name: "temp_millicelsius", Transform: &config.TransformConfig{Scale: ptr(0.001), Offset: -1}},
+		{Name: "ratio_*", Transform: &config.TransformConfig{Scale: ptr(100), Min: ptr(0), Max: ptr(100)}},
+	})
+	require.NotNil(t, tr)
+
+	m := collector.CollectedMetrics{
+		"temp_millicelsius": 42000,
+		"ratio_hits":        1.5,
+		"ratio_misses":      -0.2,
+		"cpu_percent_total": 12,
+	}
+	tr.Process(time.Now(), m)
+
+	assert.InDelta(t, 41.0, m["temp_millicelsius"], 1e-9)
+	assert.Equal(t, 100.0, m["ratio_hits"])
+	assert.Equal(t, 0.0, m["ratio_misses"])
+	assert.Equal(t, 12.0, m["cpu_percent_total"])
+}
+
+func TestEWMA(t *testing.T) {
+	tr := New([]config.MetricConfig{
+		{Name: "probe_latency", Transform: &config.TransformConfig{EWMA: 10 * time.Second}},
+	})
+	require.NotNil(t, tr)
+
+	start := time.Now()
+	m := collector.CollectedMetrics{"probe_latency": 100}
+	tr.Process(start, m)
+	assert.Equal(t, 100.0, m["probe_latency"]) // First sample seeds the average
+
+	m = collector.CollectedMetrics{"probe_latency": 200}
+	tr.Process(start.Add(10*time.Second), m)
+	expected := 100 + (1-math.Exp(-1))*100
+	assert.InDelta(t, expected, m["probe_latency"], 1e-9)
+
+	// A spike only moves the average partially
+	m = collector.CollectedMetrics{"probe_latency": 1000}
+	tr.Process(start.Add(11*time.Second), m)
+	assert.Less(t, m["probe_latency"], 300.0)
+}
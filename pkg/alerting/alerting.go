@@ -0,0 +1,94 @@
+// Package alerting is the public API of monres's alert evaluation.
+//
+// An Alerter evaluates rules against a History of metric values and publishes
+// AlertEvents; a Router delivers them to notification channels from pkg/notify:
+//
+//	cfg, _ := alerting.LoadConfig("config.yaml")
+//	hist := alerting.NewHistory(cfg)
+//	a, _ := alerting.NewAlerter(cfg, hist)
+//	go alerting.NewRouter(cfg, notifiers).Run(a.Subscribe(64))
+//
+//	for range ticker.C {
+//		now := time.Now()
+//		metrics, _ := gc.CollectAll()
+//		hist.AddMetrics(metrics, now)
+//		a.CheckAndNotify(now, metrics)
+//	}
+package alerting
+
+import (
+	"time"
+
+	"github.com/mattmezza/monres/internal/alerter"
+	"github.com/mattmezza/monres/internal/collector"
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/history"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+type (
+	// Config is the monres configuration; only the alerting related fields are used here.
+	Config = config.Config
+	// RuleConfig is the configuration of one alert rule.
+	RuleConfig = config.AlertRuleConfig
+	// TemplateConfig holds the message templates used by the Router.
+	TemplateConfig = config.TemplateConfig
+	// Alerter evaluates alert rules and publishes state changes.
+	Alerter = alerter.Alerter
+	// AlertRule is the runtime representation of an alert rule.
+	AlertRule = alerter.AlertRule
+	// AlertEvent is a state change of an alert rule.
+	AlertEvent = alerter.AlertEvent
+	// EventType tells whether an alert fired or resolved.
+	EventType = alerter.EventType
+	// Router delivers alert events to notification channels.
+	Router = alerter.Router
+	// RuleStatus is a point-in-time view of an alert rule, returned by Alerter.Status.
+	RuleStatus = alerter.RuleStatus
+	// DataPoint is one timestamped metric value.
+	DataPoint = history.DataPoint
+)
+
+const (
+	EventTypeFired    = alerter.EventTypeFired
+	EventTypeResolved = alerter.EventTypeResolved
+	EventTypeAction   = alerter.EventTypeAction
+)
+
+// History stores recent metric values for rule evaluation.
+type History struct {
+	*history.MetricHistoryBuffer
+}
+
+// NewHistory creates a History retaining enough data for the longest rule in cfg.
+func NewHistory(cfg *Config) *History {
+	return &History{history.NewForConfig(cfg, cfg.CollectionInterval)}
+}
+
+// AddMetrics records every metric of a collection cycle at the given time.
+func (h *History) AddMetrics(metrics collector.CollectedMetrics, t time.Time) {
+	for name, value := range metrics {
+		h.AddDataPoint(name, value, t)
+	}
+}
+
+// LoadConfig reads and validates a monres configuration file.
+func LoadConfig(path string) (*Config, error) {
+	return config.LoadConfig(path)
+}
+
+// NewAlerter creates an Alerter for the rules in cfg, evaluating against hist.
+func NewAlerter(cfg *Config, hist *History) (*Alerter, error) {
+	return alerter.NewAlerter(cfg, hist.MetricHistoryBuffer)
+}
+
+// NewRouter creates a Router delivering to the given notifiers, keyed by channel name,
+// using the templates in cfg.
+func NewRouter(cfg *Config, notifiers map[string]notifier.Notifier) *Router {
+	return alerter.NewRouter(cfg, notifiers)
+}
+
+// NotificationDataForEvent prepares the template context for an event.
+func NotificationDataForEvent(event AlertEvent) notifier.NotificationData {
+	return alerter.NotificationDataForEvent(event)
+}
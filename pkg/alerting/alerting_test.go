@@ -0,0 +1,77 @@
+package alerting
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattmezza/monres/pkg/collector"
+	"github.com/mattmezza/monres/pkg/notify"
+)
+
+// captureNotifier records the messages it is asked to send.
+type captureNotifier struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (c *captureNotifier) Name() string { return "capture" }
+
+func (c *captureNotifier) Send(data notify.NotificationData, templates notify.NotificationTemplates) error {
+	msg, err := notify.RenderMessage(data, templates)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+func TestEmbeddedAlerting(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+hostname: "embedded"
+alerts:
+  - name: "Queue backlog"
+    metric: "queue_depth"
+    condition: ">"
+    threshold: 100
+    channels: ["capture"]
+templates:
+  alert_fired: "{{ .AlertName }} on {{ .Hostname }}: {{ .MetricValue }}"
+  alert_resolved: "{{ .AlertName }} resolved"
+`), 0644))
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+
+	hist := NewHistory(cfg)
+	a, err := NewAlerter(cfg, hist)
+	require.NoError(t, err)
+
+	capture := &captureNotifier{}
+	events := a.Subscribe(8)
+	done := make(chan struct{})
+	go func() {
+		NewRouter(cfg, map[string]notify.Notifier{"capture": capture}).Run(events)
+		close(done)
+	}()
+
+	now := time.Now()
+	for i, depth := range []float64{10, 150, 20} {
+		at := now.Add(time.Duration(i) * time.Second)
+		metrics := collector.CollectedMetrics{"queue_depth": depth}
+		hist.AddMetrics(metrics, at)
+		a.CheckAndNotify(at, metrics)
+	}
+	a.Close()
+	<-done
+
+	assert.Equal(t, []string{"Queue backlog on embedded: 150", "Queue backlog resolved"}, capture.messages)
+}
@@ -0,0 +1,60 @@
+// Package collector is the public API of monres's metric collectors.
+//
+// It lets other Go programs embed the same CPU, memory, disk, network and
+// textfile collection that the monres daemon uses:
+//
+//	gc := collector.NewGlobalCollector(nil)
+//	metrics, err := gc.CollectAll()
+//
+// The types are aliases of monres's internal implementation, so values can be
+// passed freely between this package and pkg/alerting.
+package collector
+
+import (
+	"time"
+
+	"github.com/mattmezza/monres/internal/collector"
+)
+
+type (
+	// CollectedMetrics holds all metrics gathered in one collection cycle, keyed by metric name.
+	CollectedMetrics = collector.CollectedMetrics
+	// MetricCollector is implemented by individual collectors, including custom ones passed to AddCollector.
+	MetricCollector = collector.MetricCollector
+	// GlobalCollector runs the built-in collectors and any added ones each cycle.
+	GlobalCollector = collector.GlobalCollector
+	// NetworkInterfaceFilter selects the interfaces counted by the network collector.
+	NetworkInterfaceFilter = collector.NetworkInterfaceFilter
+	// CollectorError is the failure of a single collector during a cycle.
+	CollectorError = collector.CollectorError
+	// CollectErrors lists every collector that failed during a cycle.
+	CollectErrors = collector.CollectErrors
+	// TextfileCollector reads metrics from node_exporter style textfiles.
+	TextfileCollector = collector.TextfileCollector
+	// BenchmarkResult summarizes the cost of one collector over several cycles.
+	BenchmarkResult = collector.BenchmarkResult
+)
+
+var (
+	// ErrCollectorTimeout is reported for collectors that did not finish before the cycle deadline.
+	ErrCollectorTimeout = collector.ErrCollectorTimeout
+	// ErrCollectorBusy is reported for collectors skipped because a previous run is still in progress.
+	ErrCollectorBusy = collector.ErrCollectorBusy
+)
+
+// NewGlobalCollector creates a collector for the built-in metrics.
+// A nil filter excludes loopback and Docker interfaces from network metrics.
+func NewGlobalCollector(networkFilter *NetworkInterfaceFilter) *GlobalCollector {
+	return collector.NewGlobalCollector(networkFilter)
+}
+
+// DefaultNetworkInterfaceFilter returns the filter used when none is given.
+func DefaultNetworkInterfaceFilter() NetworkInterfaceFilter {
+	return collector.DefaultNetworkInterfaceFilter()
+}
+
+// NewTextfileCollector returns a collector reading node_exporter style *.prom
+// files from directory. Files older than maxAge are ignored; zero disables the check.
+func NewTextfileCollector(directory string, maxAge time.Duration) *TextfileCollector {
+	return collector.NewTextfileCollector(directory, maxAge)
+}
@@ -0,0 +1,21 @@
+//go:build !no_email
+
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier/email"
+)
+
+type (
+	// EmailConfig configures an email channel.
+	EmailConfig = config.EmailChannelConfig
+	// EmailNotifier sends notifications over SMTP.
+	EmailNotifier = email.Notifier
+)
+
+// NewEmailNotifier creates an email notifier for the channel name,
+// sending through the SMTP server in cfg.
+func NewEmailNotifier(name string, cfg EmailConfig) (*EmailNotifier, error) {
+	return email.New(name, cfg)
+}
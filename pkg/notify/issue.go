@@ -0,0 +1,21 @@
+//go:build !no_issue
+
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier/issue"
+)
+
+type (
+	// IssueConfig configures a GitHub or Jira issue channel.
+	IssueConfig = config.IssueChannelConfig
+	// IssueNotifier opens an issue when an alert fires and closes it when it resolves.
+	IssueNotifier = issue.Notifier
+)
+
+// NewIssueNotifier creates a notifier for the channel name opening and
+// closing issues in the GitHub repository or Jira project in cfg.
+func NewIssueNotifier(name string, cfg IssueConfig) (*IssueNotifier, error) {
+	return issue.New(name, cfg)
+}
@@ -0,0 +1,61 @@
+// Package notify is the public API of monres's notification channels.
+//
+// Other Go programs can use it to send alerts through the same email,
+// Telegram, stdout, webhook, issue, on-call router and voice channels as the
+// monres daemon, or implement Notifier to plug their own channel into
+// pkg/alerting. Channels are left out with the same build tags as in the
+// monres binary, e.g. -tags no_email.
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/metrics"
+	"github.com/mattmezza/monres/internal/notifier"
+)
+
+type (
+	// Notifier is the interface for all notification channel types.
+	Notifier = notifier.Notifier
+	// NotificationData is the data passed to templates.
+	NotificationData = notifier.NotificationData
+	// NotificationTemplates holds the fired and resolved message templates.
+	NotificationTemplates = notifier.NotificationTemplates
+	// ChannelConfig is the configuration of one notification channel, as in the config file.
+	ChannelConfig = config.NotificationChannelConfig
+	// Factory creates a notifier for a configured channel.
+	Factory = notifier.Factory
+	// Unit is the unit of a metric, used to format values.
+	Unit = metrics.Unit
+)
+
+// NewNotifiers creates a Notifier for each channel, keyed by channel name.
+// Channels that fail to initialize are logged and skipped.
+func NewNotifiers(channels []ChannelConfig) (map[string]Notifier, error) {
+	return notifier.InitializeNotifiers(channels)
+}
+
+// Register makes a custom channel type available to NewNotifiers.
+// It panics if the type is already registered.
+func Register(channelType string, factory Factory) {
+	notifier.Register(channelType, factory)
+}
+
+// Render executes a notification template against data.
+func Render(templateName, templateStr string, data NotificationData) (string, error) {
+	return notifier.Render(templateName, templateStr, data)
+}
+
+// RenderMessage renders the fired or resolved template matching data.State.
+func RenderMessage(data NotificationData, templates NotificationTemplates) (string, error) {
+	return notifier.RenderMessage(data, templates)
+}
+
+// FormatValue formats a metric value for display according to the metric's unit.
+func FormatValue(metricName string, value float64) string {
+	return notifier.FormatValue(metricName, value)
+}
+
+// FormatUnitValue formats a value for display in the given unit.
+func FormatUnitValue(unit Unit, value float64) string {
+	return notifier.FormatUnitValue(unit, value)
+}
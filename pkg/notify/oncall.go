@@ -0,0 +1,21 @@
+//go:build !no_oncall
+
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier/oncall"
+)
+
+type (
+	// OnCallConfig configures a Grafana OnCall, Squadcast or Zenduty channel.
+	OnCallConfig = config.OnCallChannelConfig
+	// OnCallNotifier triggers and resolves incidents of an on-call router.
+	OnCallNotifier = oncall.Notifier
+)
+
+// NewOnCallNotifier creates a notifier for the channel name triggering and
+// resolving incidents through the integration URL in cfg.
+func NewOnCallNotifier(name string, cfg OnCallConfig) (*OnCallNotifier, error) {
+	return oncall.New(name, cfg)
+}
@@ -0,0 +1,16 @@
+//go:build !no_stdout
+
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/notifier/stdout"
+)
+
+// StdoutNotifier prints notifications to standard output.
+type StdoutNotifier = stdout.Notifier
+
+// NewStdoutNotifier creates a notifier printing the notifications of the
+// channel name to standard output.
+func NewStdoutNotifier(name string) (*StdoutNotifier, error) {
+	return stdout.New(name)
+}
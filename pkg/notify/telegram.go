@@ -0,0 +1,21 @@
+//go:build !no_telegram
+
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier/telegram"
+)
+
+type (
+	// TelegramConfig configures a Telegram channel.
+	TelegramConfig = config.TelegramChannelConfig
+	// TelegramNotifier sends notifications through the Telegram bot API.
+	TelegramNotifier = telegram.Notifier
+)
+
+// NewTelegramNotifier creates a Telegram notifier for the channel name,
+// sending to the chat in cfg with its bot token.
+func NewTelegramNotifier(name string, cfg TelegramConfig) (*TelegramNotifier, error) {
+	return telegram.New(name, cfg)
+}
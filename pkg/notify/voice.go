@@ -0,0 +1,21 @@
+//go:build !no_voice
+
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier/voice"
+)
+
+type (
+	// VoiceConfig configures a Twilio or CallMeBot voice call channel.
+	VoiceConfig = config.VoiceChannelConfig
+	// VoiceNotifier phones the alert's name and host by text to speech.
+	VoiceNotifier = voice.Notifier
+)
+
+// NewVoiceNotifier creates a notifier for the channel name calling the
+// numbers or users in cfg through its provider.
+func NewVoiceNotifier(name string, cfg VoiceConfig) (*VoiceNotifier, error) {
+	return voice.New(name, cfg)
+}
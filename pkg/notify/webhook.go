@@ -0,0 +1,26 @@
+//go:build !no_webhook
+
+package notify
+
+import (
+	"github.com/mattmezza/monres/internal/config"
+	"github.com/mattmezza/monres/internal/notifier/webhook"
+)
+
+type (
+	// WebhookConfig configures a webhook channel.
+	WebhookConfig = config.WebhookChannelConfig
+	// WebhookNotifier POSTs notifications as JSON, optionally signed.
+	WebhookNotifier = webhook.Notifier
+)
+
+// NewWebhookNotifier creates a webhook notifier for the channel name,
+// POSTing to the URL in cfg and signing requests if it holds a secret.
+func NewWebhookNotifier(name string, cfg WebhookConfig) (*WebhookNotifier, error) {
+	return webhook.New(name, cfg)
+}
+
+// SignWebhook computes the X-Monres-Signature of a webhook request, for receivers written in Go.
+func SignWebhook(secret, timestamp, nonce string, body []byte) string {
+	return webhook.Sign(secret, timestamp, nonce, body)
+}